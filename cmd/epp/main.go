@@ -25,19 +25,118 @@ limitations under the License.
 package main
 
 import (
+	"flag"
+	"maps"
 	"os"
+	"runtime"
+	"slices"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/gateway-api-inference-extension/cmd/epp/runner"
+	eppplugins "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/version"
 
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/extproc"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/telemetry"
 )
 
 func main() {
 	// Register llm-d-inference-scheduler plugins
 	plugins.RegisterAllPlugins()
 
-	if err := runner.NewRunner().Run(ctrl.SetupSignalHandler()); err != nil {
+	ctx := ctrl.SetupSignalHandler()
+	setupLog := ctrl.Log.WithName("setup")
+
+	tracingOptions := telemetry.NewTracingOptions()
+	profilingOptions := telemetry.NewProfilingOptions()
+	pprofOptions := telemetry.NewPprofOptions()
+	errorResponsesOptions := extproc.NewErrorResponsesOptions()
+	featuresOptions := telemetry.NewFeaturesOptions()
+	telemetryFlags := flag.NewFlagSet("telemetry", flag.ContinueOnError)
+	tracingOptions.AddFlags(telemetryFlags)
+	profilingOptions.AddFlags(telemetryFlags)
+	pprofOptions.AddFlags(telemetryFlags)
+	errorResponsesOptions.AddFlags(telemetryFlags)
+	featuresOptions.AddFlags(telemetryFlags)
+
+	// The runner parses the command line and initializes tracing from environment
+	// variables only. Parse the telemetry related flags upfront so that tracing is
+	// configured here, and disable the runner's own tracing initialization.
+	knownFlags := []string{"tracing", "pool-name", "pool-namespace", "secure-serving"}
+	telemetryFlags.VisitAll(func(f *flag.Flag) {
+		flag.CommandLine.Var(f.Value, f.Name, f.Usage)
+		knownFlags = append(knownFlags, f.Name)
+	})
+	if err := telemetry.ParseKnownFlags(flag.CommandLine, os.Args[1:], knownFlags...); err != nil {
+		setupLog.Error(err, "failed to parse telemetry flags")
 		os.Exit(1)
 	}
+	// The OpenAI error responses are rewritten by the gRPC codec of the runner's ext_proc server. The
+	// codec registry is not thread safe, so it is registered before any gRPC client or server starts.
+	extproc.RegisterErrorResponseCodec(errorResponsesOptions)
+
+	tracing := flag.Lookup("tracing")
+	tracingEnabled := tracing != nil && tracing.Value.String() == "true"
+	secureServing := flag.Lookup("secure-serving").Value.String() == "true"
+	if tracingEnabled {
+		tracingOptions.ServiceVersion = version.BuildRef
+		tracingOptions.PoolName = flag.Lookup("pool-name").Value.String()
+		tracingOptions.PoolNamespace = flag.Lookup("pool-namespace").Value.String()
+		if err := telemetry.InitTracing(ctx, setupLog, tracingOptions); err != nil {
+			setupLog.Error(err, "failed to initialize tracing")
+			os.Exit(1)
+		}
+		os.Args = append(os.Args, "--tracing=false")
+	}
+
+	if err := telemetry.StartContinuousProfiling(ctx, setupLog, profilingOptions); err != nil {
+		setupLog.Error(err, "failed to start continuous profiling")
+		os.Exit(1)
+	}
+
+	if err := telemetry.StartPprofServer(ctx, setupLog, pprofOptions); err != nil {
+		setupLog.Error(err, "failed to start the pprof endpoints")
+		os.Exit(1)
+	}
+
+	features := eppFeatures(secureServing, map[string]bool{
+		"tracing":              tracingEnabled,
+		"continuousProfiling":  profilingOptions.Enabled,
+		"pprof":                pprofOptions.Addr != "",
+		"openaiErrorResponses": errorResponsesOptions.Enabled,
+	})
+	if err := telemetry.StartFeaturesServer(ctx, setupLog, featuresOptions, features); err != nil {
+		setupLog.Error(err, "failed to start the features endpoint")
+		os.Exit(1)
+	}
+
+	if err := runner.NewRunner().Run(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+// eppFeatures returns the build, the registered plugins, and the enabled features of the EPP
+func eppFeatures(secureServing bool, optional map[string]bool) common.Features {
+	features := common.Features{
+		Component: "epp",
+		Version:   version.BuildRef,
+		Commit:    version.CommitSHA,
+		GoVersion: runtime.Version(),
+		Plugins:   slices.Sorted(maps.Keys(eppplugins.Registry)),
+		Security:  map[string]bool{"secureServing": secureServing},
+		Protocols: map[string]string{"extProc": "grpc"},
+		Enabled:   []string{},
+	}
+	if secureServing {
+		features.Protocols["extProc"] = "grpc+tls"
+	}
+	for name, enabled := range optional {
+		if enabled {
+			features.Enabled = append(features.Enabled, name)
+		}
+	}
+	slices.Sort(features.Enabled)
+	return features
 }
@@ -17,34 +17,131 @@ package main
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"flag"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/sidecar/config"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/sidecar/proxy"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/sidecar/version"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/telemetry"
 )
 
+const defaultTracingServiceName = "llm-d-pd-sidecar"
+
 func main() {
+	configFile := flag.String(config.FlagName, "", "the path of a YAML or JSON file setting the options of the sidecar by flag name. The flags set on the command line override the file")
 	port := flag.String("port", "8000", "the port the sidecar is listening on")
 	vLLMPort := flag.String("vllm-port", "8001", "the port vLLM is listening on")
+	vLLMSocket := flag.String("vllm-socket", "", "the path of the Unix domain socket vLLM is listening on, e.g. with its --uds option. When set, the sidecar connects to vLLM over the socket instead of --vllm-port")
 	vLLMDataParallelSize := flag.Int("data-parallel-size", 1, "the vLLM DATA-PARALLEL-SIZE value")
-	connector := flag.String("connector", "nixlv2", "the P/D connector being used. Either nixl, nixlv2 or lmcache")
+	dataParallelPorts := flag.String("data-parallel-ports", "", "comma separated <rank>=<sidecar port>:<vLLM port> entries giving the ports of the data parallel ranks after the first one, e.g. 1=9000:9100,2=9200:9300 for the vLLM servers listening on non-contiguous ports. The ranks missing from it listen on --port and --vllm-port plus the rank")
+	dataParallelFallback := flag.Bool("data-parallel-fallback", true, "sends the requests naming an unknown data parallel rank, e.g. after a resize the EPP is not aware of yet, to the local rank with the fewest decodes in flight instead of rejecting them with a 400")
+	dataParallelDiscoveryInterval := flag.Duration("data-parallel-discovery-interval", 0, "discovers the data parallel size from the health endpoints of the local vLLM servers on the ports following --vllm-port, at startup and on this interval, starting and stopping the proxies of the ranks accordingly. --data-parallel-size is then the size until it is discovered. Disabled when 0")
+	connector := flag.String("connector", "nixlv2", "the P/D connector being used, one of the registered connectors, e.g. nixlv2, nixl (the legacy NIXL v1 protocol) or lmcache")
 	prefillerUseTLS := flag.Bool("prefiller-use-tls", false, "whether to use TLS when sending requests to prefillers")
 	decoderUseTLS := flag.Bool("decoder-use-tls", false, "whether to use TLS when sending requests to the decoder")
 	prefillerInsecureSkipVerify := flag.Bool("prefiller-tls-insecure-skip-verify", false, "configures the proxy to skip TLS verification for requests to prefiller")
 	decoderInsecureSkipVerify := flag.Bool("decoder-tls-insecure-skip-verify", false, "configures the proxy to skip TLS verification for requests to decoder")
+	prefillerCACert := flag.String("prefiller-ca-cert", "", "the path of the PEM encoded certificate authorities verifying the certificates of the prefillers. The system ones are used when empty")
+	decoderCACert := flag.String("decoder-ca-cert", "", "the path of the PEM encoded certificate authorities verifying the certificate of the decoder. The system ones are used when empty")
+	prefillerTLSServerName := flag.String("prefiller-tls-server-name", "", "the name the certificates of the prefillers must be valid for, instead of their IP")
+	decoderTLSServerName := flag.String("decoder-tls-server-name", "", "the name the certificate of the decoder must be valid for, instead of localhost")
+	prefillerHTTP2 := flag.Bool("prefiller-http2", false, "send the requests to the prefillers over HTTP/2, negotiated with TLS or with prior knowledge (h2c) without TLS")
+	decoderHTTP2 := flag.Bool("decoder-http2", false, "send the requests to the decoder over HTTP/2, negotiated with TLS or with prior knowledge (h2c) without TLS")
+	listenerHTTP2 := flag.Bool("listener-http2", false, "accept HTTP/2 with prior knowledge (h2c) when the sidecar does not serve TLS. HTTP/2 is always negotiated with TLS")
 	secureProxy := flag.Bool("secure-proxy", true, "Enables secure proxy. Defaults to true.")
 	certPath := flag.String(
 		"cert-path", "", "The path to the certificate for secure proxy. The certificate and private key files "+
 			"are assumed to be named tls.crt and tls.key, respectively. If not set, and secureProxy is enabled, "+
 			"then a self-signed certificate is used (for testing).")
+	certReloadInterval := flag.Duration("cert-reload-interval", proxy.DefaultCertReloadInterval, "the interval the certificate of --cert-path is checked for changes at, the listeners of all the data parallel ranks serving the renewed certificate without a restart")
+	clientCAPath := flag.String("client-ca-path", "", "the path of the PEM encoded certificate authorities of the client certificates required by the secure proxy, e.g. from the gateway and the EPP. Not required when empty")
+	routingResponseHeaders := flag.Bool("routing-response-headers", false, "add the "+proxy.RoutingPrefillTargetHeader+", "+proxy.RoutingDecodeRankHeader+" and "+proxy.RoutingConnectorHeader+" response headers reporting where each request was served")
+	scrubInternalResponseFields := flag.Bool("scrub-internal-response-fields", false, "remove kv_transfer_params and other P/D internal fields from the responses returned to clients")
+	validateModel := flag.Bool("validate-model", false, "reject requests for models not served by the local vLLM before running prefill and decode")
+	modelsCacheTTL := flag.Duration("models-cache-ttl", proxy.DefaultModelsCacheTTL, "the time the models served by the local vLLM are cached when validating models")
+	circuitBreakerThreshold := flag.Int("circuit-breaker-failure-threshold", 0, "the number of consecutive failures of a prefiller or of the local vLLM opening its circuit. Disabled when 0")
+	circuitBreakerErrorRate := flag.Float64("circuit-breaker-error-rate", 0, "the failure rate, between 0 and 1, of the recent requests to a prefiller or to the local vLLM opening its circuit. Disabled when 0")
+	circuitBreakerWindow := flag.Int("circuit-breaker-window", proxy.DefaultCircuitBreakerWindow, "the number of recent requests the circuit breaker error rate is computed over")
+	circuitOpenDuration := flag.Duration("circuit-breaker-open-duration", proxy.DefaultCircuitOpenDuration, "the time a circuit stays open before a probe request is let through")
+	prefillerDNSCacheTTL := flag.Duration("prefiller-dns-cache-ttl", 0, "the time the addresses of the prefillers given by DNS name are cached. Resolved on each new connection when 0")
+	prefillRetries := flag.Int("prefill-retries", 0, "the number of times a prefill request failing with a 5xx status code or a connection error is retried")
+	prefillRetryBackoff := flag.Duration("prefill-retry-backoff", proxy.DefaultPrefillRetryBackoff, "the delay before the first prefill retry, doubled on each retry")
+	prefillTimeout := flag.Duration("prefill-timeout", 0, "the time each prefill request has to complete before it fails with a 504. Disabled when 0")
+	interceptPaths := flag.String("intercept-paths", strings.Join(proxy.DefaultInterceptPaths, ","), "comma separated paths whose POST requests are served through the P/D protocol when a prefill pod is selected, e.g. to add the routes of OpenAI-compatible extensions of vLLM")
+	disaggregatedPoolingPaths := flag.String("disaggregated-pooling-paths", "", "comma separated pooling paths, e.g. /v1/embeddings,/score, whose requests are sent through the P/D protocol when a prefill pod is selected, 'pooling' stands for all of them")
+	prefillHedgeDelay := flag.Duration("prefill-hedge-delay", 0, "the latency budget of the prefill requests, the requests not answered within it are sent to a second allowed prefiller selected by the EPP as well. Disabled when 0")
+	maxRequestBodyBytes := flag.Int64("max-request-body-bytes", 0, "the size limit of the bodies of the completion requests, the larger ones are rejected with a 413. Unlimited when 0")
+	prefillPipelining := flag.Bool("prefill-pipelining", false, "send the decode request as soon as the KV-transfer parameters are received from the prefiller, before the end of its response")
+	disableLegacyPrefillURLs := flag.Bool("disable-legacy-prefill-urls", false, "reject with a 400 the requests whose prefill pod header lists http:// URLs, the deprecated form, instead of <host:port>")
+	prefillBypassTokens := flag.Int("prefill-bypass-tokens", 0, "the number of estimated prompt tokens below which the requests are decoded by the local vLLM even when a prefill pod is selected, saving the prefill round trip of the short prompts. The tokens are estimated from the size of the request bodies. Disabled when 0")
+	prefillFallback := flag.Bool("prefill-fallback", false, "send the request to the local vLLM without disaggregation when the prefill failed")
+	prefillResponsePolicy := flag.String("prefill-response-policy", proxy.PrefillResponsePolicyWarn, "the handling of the successful prefill responses without valid KV-transfer parameters: "+proxy.PrefillResponsePolicyWarn+" decodes without the missing parameters, "+proxy.PrefillResponsePolicyFail+" fails the request with a 502, "+proxy.PrefillResponsePolicyDecode+" sends it to the local vLLM without disaggregation")
+	prefillTransferParams := flag.String("prefill-kv-transfer-params", "", "a JSON object whose fields are injected in the kv_transfer_params of the prefill requests, e.g. transport hints, the fields set by the connector being kept")
+	decodeTransferParams := flag.String("decode-kv-transfer-params", "", "a JSON object whose fields are injected in the kv_transfer_params of the decode requests, e.g. tenant tags, the fields set by the connector being kept")
+	enableAnthropicMessages := flag.Bool("enable-anthropic-messages", false, "serve the Anthropic Messages API on "+proxy.MessagesPath+", translating its requests to chat completions served through the P/D pipeline, and their responses back")
+	maxConcurrentRequests := flag.Int("max-concurrent-requests", 0, "the number of concurrent completion requests served by the pod, the others are rejected with a 429 and a Retry-After header. Unlimited when 0")
+	tenantHeader := flag.String("tenant-header", "", "the request header identifying the tenant of a request, for the tenant concurrency quotas")
+	tenantMaxConcurrentRequests := flag.Int("tenant-max-concurrent-requests", 0, "the number of concurrent completion requests of a tenant served by the pod, the others are rejected with a 429. Disabled when 0")
+	tokenRateLimit := flag.Float64("token-rate-limit", 0, "the number of estimated prompt tokens per second of a client served by the pod, the others are rejected with a 429 and a Retry-After header. The tokens are estimated from the size of the request bodies. Disabled when 0")
+	tokenRateBurst := flag.Int("token-rate-burst", 0, "the number of estimated prompt tokens a client may send at once. Defaults to ten seconds of --token-rate-limit when 0")
+	tokenRateClientHeader := flag.String("token-rate-client-header", "", "the request header identifying the client of a request for --token-rate-limit, e.g. the header of its API key. The clients are identified by their source IP when empty or when the header is missing")
+	jobHeader := flag.String("job-header", "", "the request header identifying the batch job of a request, the requests of a job are kept on the prefill target which served its first request when the EPP selects it. Disabled when empty")
+	shadowURL := flag.String("shadow-url", "", "the URL of a secondary engine, e.g. a canary vLLM build, a sample of the completion requests is mirrored to in the background, its responses being discarded. Disabled when empty")
+	shadowPercentage := flag.Float64("shadow-percentage", 100, "the percentage of the completion requests mirrored to --shadow-url")
+	shadowTimeout := flag.Duration("shadow-timeout", proxy.DefaultShadowTimeout, "the time a request mirrored to --shadow-url has to complete")
+	jobPinTTL := flag.Duration("job-pin-ttl", proxy.DefaultJobPinTTL, "the time a batch job is pinned to its prefill target after its last request")
+	streamWriteTimeout := flag.Duration("stream-write-timeout", 0, "the time a client has to accept each write of a response before it is aborted, cancelling the request to the local vLLM. Disabled when 0")
+	streamFlushInterval := flag.Duration("stream-flush-interval", 0, "the interval the flushes of streamed responses are coalesced over. Each event is flushed immediately when 0")
+	unbufferedPaths := flag.String("unbuffered-paths", "", "comma separated paths, e.g. long-poll endpoints of the local vLLM, whose responses are flushed to the clients as soon as they are written, regardless of --stream-flush-interval and --stream-buffer-size, 'pooling' stands for all the pooling paths")
+	streamBufferSize := flag.Int("stream-buffer-size", proxy.DefaultStreamBufferSize, "the number of bytes of a response written to a client before a flush is forced")
 	enableSSRFProtection := flag.Bool("enable-ssrf-protection", false, "enable SSRF protection using InferencePool allowlisting")
+	ssrfAuditLog := flag.String("ssrf-audit-log", "", "writes a JSON audit line for each allow or deny decision of the SSRF protection to the given file, or to stdout when 'stdout'. Disabled when empty")
+	readinessCacheTTL := flag.Duration("readiness-cache-ttl", proxy.DefaultReadinessCacheTTL, "the time the result of a readiness probe of the local vLLM servers is cached")
+	readinessCheckAllRanks := flag.Bool("readiness-check-all-ranks", false, "make the readiness of the sidecar depend on the vLLM servers of all the data parallel ranks")
+	drainTimeout := flag.Duration("drain-timeout", proxy.DefaultDrainTimeout, "the time the sidecar waits on shutdown for the inference requests in flight to complete")
+	allowlistReadyWait := flag.Duration("ssrf-protection-ready-wait", proxy.DefaultAllowlistReadyWait, "the time a disaggregated request waits for the SSRF protection allowlist to be synced before failing with a 503")
 	inferencePoolNamespace := flag.String("inference-pool-namespace", os.Getenv("INFERENCE_POOL_NAMESPACE"), "the Kubernetes namespace to watch for InferencePool resources (defaults to INFERENCE_POOL_NAMESPACE env var)")
-	inferencePoolName := flag.String("inference-pool-name", os.Getenv("INFERENCE_POOL_NAME"), "the specific InferencePool name to watch (defaults to INFERENCE_POOL_NAME env var)")
+	inferencePoolName := flag.String("inference-pool-name", os.Getenv("INFERENCE_POOL_NAME"), "the specific InferencePool name to watch, or a comma separated list of names whose pods are all allowed (defaults to INFERENCE_POOL_NAME env var)")
+	inferencePoolSelector := flag.String("inference-pool-selector", "", "the label selector of the InferencePools to watch instead of --inference-pool-name, e.g. to allow the pods of the pools of several models sharing the sidecar")
+	inferencePoolGroup := flag.String("inference-pool-group", proxy.InferencePoolAutoGroup, "the API group of the InferencePool to watch: "+proxy.InferencePoolGroup+" (v1), "+proxy.InferencePoolAlphaGroup+" (v1alpha2), or "+proxy.InferencePoolAutoGroup+" to detect the one served by the API server, v1 when both are served and the pool exists in it")
+	allowlistConfigMap := flag.String("ssrf-protection-configmap", "", "the ConfigMap of the endpoint set published by the EPP endpoints-publisher plugin, the SSRF protection allowlist is built from it instead of watching the InferencePool")
+	allowlistService := flag.String("ssrf-protection-service", "", "the Service fronting the prefillers, e.g. a headless Service, the SSRF protection allowlist is built from its EndpointSlices instead of watching the InferencePool and its pods")
+	allowlistStatic := flag.String("allowlist-static", "", "comma separated CIDRs, hostnames or host:port entries allowed by the SSRF protection besides the InferencePool, e.g. the prefillers running outside of the cluster")
+	allowlistPolicy := flag.String("ssrf-protection-policy", proxy.AllowlistFailClosed, "how the prefill targets missing from the SSRF protection allowlist are handled until it is synced and during its grace period: "+proxy.AllowlistFailClosed+" fails the requests with a 503 and reports the sidecar not ready until the allowlist is synced, "+proxy.AllowlistFailOpen+" allows them")
+	allowlistGracePeriod := flag.Duration("ssrf-protection-grace-period", 0, "the time after the start of the sidecar during which the prefill targets missing from the synced SSRF protection allowlist are handled by --ssrf-protection-policy instead of being rejected with a 403, e.g. while the prefill pods of a rollout appear")
+	allowlistEnforcePorts := flag.Bool("ssrf-protection-enforce-ports", false, "only allow the prefill targets on the target ports of the InferencePool, instead of any port of its pods")
+	allowlistSigningKeyFile := flag.String("ssrf-protection-signing-key-file", "", "the file of the key the endpoint set published by the EPP is signed with, required with --ssrf-protection-configmap")
+	kubeAPIQPS := flag.Float64("kube-api-qps", proxy.DefaultKubernetesQPS, "the rate of the requests to the Kubernetes API server")
+	kubeAPIBurst := flag.Int("kube-api-burst", proxy.DefaultKubernetesBurst, "the burst of the requests to the Kubernetes API server")
+	maxIdleConns := flag.Int("proxy-max-idle-conns", 0, "the number of idle connections kept by the transport of each prefill target and of the decoder, unlimited when negative. Go's default (100) when 0")
+	maxIdleConnsPerHost := flag.Int("proxy-max-idle-conns-per-host", 0, "the number of idle connections kept per prefill target and to the decoder. Go's default (2) when 0")
+	maxConnsPerHost := flag.Int("proxy-max-conns-per-host", 0, "the number of connections, including the active ones, per prefill target and to the decoder. Unlimited when 0")
+	idleConnTimeout := flag.Duration("proxy-idle-conn-timeout", 0, "the time an idle connection to a prefill target or to the decoder is kept. Go's default (90s) when 0")
+	tlsHandshakeTimeout := flag.Duration("proxy-tls-handshake-timeout", 0, "the time a TLS handshake with a prefill target or the decoder has to complete. Go's default (10s) when 0")
+	responseHeaderTimeout := flag.Duration("proxy-response-header-timeout", 0, "the time the response headers of a prefill target or of the decoder have to be received once a request is written. Unlimited when 0")
+	adminPort := flag.String("admin-port", "", "the port of the admin API reporting the runtime state of the sidecar, only bound to localhost. Disabled when empty")
+	accessLog := flag.Bool("access-log", false, "writes a JSON access log line to stdout for each request, with its prefill and decode targets")
+	enableTracing := flag.Bool("tracing", false, "enables emitting OpenTelemetry traces of the P/D requests")
+
+	tracingOptions := telemetry.NewTracingOptions()
+	if os.Getenv("OTEL_SERVICE_NAME") == "" {
+		tracingOptions.ServiceName = defaultTracingServiceName
+	}
+	tracingOptions.AddFlags(flag.CommandLine)
+	pprofOptions := telemetry.NewPprofOptions()
+	pprofOptions.AddFlags(flag.CommandLine)
 
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -52,29 +149,184 @@ func main() {
 	// make sure to flush logs before exiting
 	defer klog.Flush()
 
+	if *configFile != "" {
+		if err := config.Load(flag.CommandLine, *configFile); err != nil {
+			klog.Background().Error(err, "failed to load the configuration file")
+			return
+		}
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 	logger := klog.FromContext(ctx)
 
 	logger.Info("Proxy starting", "Built on", version.BuildRef, "From Git SHA", version.CommitSHA)
 
-	if *connector != proxy.ConnectorNIXLV2 && *connector != proxy.ConnectorLMCache {
-		logger.Info("Error: --connector must either be 'nixlv2' or 'lmcache'")
+	if !slices.Contains(proxy.RegisteredConnectors(), *connector) {
+		logger.Info("Error: --connector must be one of the registered connectors", "connectors", proxy.RegisteredConnectors())
 		return
 	}
 	logger.Info("p/d connector validated", "connector", connector)
 
+	if !isPort(*port) {
+		logger.Info("Error: --port must be a port number", "port", *port)
+		return
+	}
+	if !isPort(*vLLMPort) {
+		logger.Info("Error: --vllm-port must be a port number", "vllm-port", *vLLMPort)
+		return
+	}
+	if *vLLMDataParallelSize < 1 {
+		logger.Info("Error: --data-parallel-size must be at least 1", "data-parallel-size", *vLLMDataParallelSize)
+		return
+	}
+	dataParallelPortsByRank, err := proxy.ParseDataParallelPorts(*dataParallelPorts)
+	if err != nil {
+		logger.Info("Error: invalid --data-parallel-ports", "error", err.Error())
+		return
+	}
+	if *dataParallelDiscoveryInterval < 0 {
+		logger.Info("Error: --data-parallel-discovery-interval must not be negative", "data-parallel-discovery-interval", *dataParallelDiscoveryInterval)
+		return
+	}
+	if *vLLMSocket != "" && (*vLLMDataParallelSize > 1 || *dataParallelDiscoveryInterval > 0) {
+		logger.Info("Error: --vllm-socket does not support the data parallel ranks", "data-parallel-size", *vLLMDataParallelSize)
+		return
+	}
+
+	if *maxIdleConnsPerHost < 0 || *maxConnsPerHost < 0 || *idleConnTimeout < 0 || *tlsHandshakeTimeout < 0 || *responseHeaderTimeout < 0 {
+		logger.Info("Error: --proxy-max-idle-conns-per-host, --proxy-max-conns-per-host and the --proxy-* timeouts must not be negative")
+		return
+	}
+
+	var shadowTarget *url.URL
+	if *shadowURL != "" {
+		var parseErr error
+		shadowTarget, parseErr = url.Parse(*shadowURL)
+		if parseErr != nil || (shadowTarget.Scheme != "http" && shadowTarget.Scheme != "https") || shadowTarget.Host == "" {
+			logger.Info("Error: --shadow-url must be an http or https URL", "shadow-url", *shadowURL)
+			return
+		}
+		if *shadowPercentage <= 0 || *shadowPercentage > 100 {
+			logger.Info("Error: --shadow-percentage must be greater than 0 and at most 100", "shadow-percentage", *shadowPercentage)
+			return
+		}
+	}
+
+	if !slices.Contains([]string{proxy.PrefillResponsePolicyWarn, proxy.PrefillResponsePolicyFail, proxy.PrefillResponsePolicyDecode}, *prefillResponsePolicy) {
+		logger.Info("Error: --prefill-response-policy must be "+proxy.PrefillResponsePolicyWarn+", "+proxy.PrefillResponsePolicyFail+" or "+proxy.PrefillResponsePolicyDecode, "prefill-response-policy", *prefillResponsePolicy)
+		return
+	}
+
+	prefillTransferFields, err := parseTransferParams(*prefillTransferParams)
+	if err != nil {
+		logger.Info("Error: --prefill-kv-transfer-params must be a JSON object", "error", err)
+		return
+	}
+	decodeTransferFields, err := parseTransferParams(*decodeTransferParams)
+	if err != nil {
+		logger.Info("Error: --decode-kv-transfer-params must be a JSON object", "error", err)
+		return
+	}
+
+	if *prefillBypassTokens < 0 {
+		logger.Info("Error: --prefill-bypass-tokens must not be negative", "prefill-bypass-tokens", *prefillBypassTokens)
+		return
+	}
+
+	if *tokenRateLimit < 0 || *tokenRateBurst < 0 {
+		logger.Info("Error: --token-rate-limit and --token-rate-burst must not be negative")
+		return
+	}
+
+	if *circuitBreakerErrorRate < 0 || *circuitBreakerErrorRate > 1 {
+		logger.Info("Error: --circuit-breaker-error-rate must be between 0 and 1")
+		return
+	}
+
+	for _, path := range splitPaths(*interceptPaths) {
+		if !strings.HasPrefix(path, "/") || common.IsPoolingPath(path) || path == proxy.MessagesPath || path == proxy.DrainPath {
+			logger.Info("Error: --intercept-paths must list absolute paths, other than the pooling, "+proxy.MessagesPath+" and "+proxy.DrainPath+" paths", "path", path)
+			return
+		}
+	}
+
+	for _, path := range splitPaths(*disaggregatedPoolingPaths) {
+		if path != common.PoolingPathsAlias && !common.IsPoolingPath(path) {
+			logger.Info("Error: --disaggregated-pooling-paths must only list pooling paths", "path", path, "pooling paths", common.PoolingPaths)
+			return
+		}
+	}
+
+	if *enableTracing {
+		tracingOptions.ServiceVersion = version.BuildRef
+		tracingOptions.PoolName = *inferencePoolName
+		tracingOptions.PoolNamespace = *inferencePoolNamespace
+		if err := telemetry.InitTracing(ctx, logger, tracingOptions); err != nil {
+			logger.Error(err, "failed to initialize tracing")
+			return
+		}
+	}
+
+	if err := telemetry.StartPprofServer(ctx, logger, pprofOptions); err != nil {
+		logger.Error(err, "failed to start the pprof endpoints")
+		return
+	}
+
 	// Determine namespace and pool name for SSRF protection
+	var staticAllowlist *proxy.StaticAllowlist
 	if *enableSSRFProtection {
 		if *inferencePoolNamespace == "" {
 			logger.Info("Error: --inference-pool-namespace or INFERENCE_POOL_NAMESPACE environment variable is required when --enable-ssrf-protection is true")
 			return
 		}
-		if *inferencePoolName == "" {
-			logger.Info("Error: --inference-pool-name or INFERENCE_POOL_NAME environment variable is required when --enable-ssrf-protection is true")
+		if *inferencePoolName == "" && *inferencePoolSelector == "" && *allowlistConfigMap == "" && *allowlistService == "" {
+			logger.Info("Error: --inference-pool-name, INFERENCE_POOL_NAME environment variable or --inference-pool-selector is required when --enable-ssrf-protection is true")
+			return
+		}
+		if *inferencePoolSelector != "" {
+			if *inferencePoolName != "" {
+				logger.Info("Error: --inference-pool-name and --inference-pool-selector are mutually exclusive")
+				return
+			}
+			if _, err := labels.Parse(*inferencePoolSelector); err != nil {
+				logger.Info("Error: invalid --inference-pool-selector", "error", err.Error())
+				return
+			}
+		}
+		if !slices.Contains([]string{proxy.InferencePoolGroup, proxy.InferencePoolAlphaGroup, proxy.InferencePoolAutoGroup}, *inferencePoolGroup) {
+			logger.Info("Error: --inference-pool-group must be "+proxy.InferencePoolGroup+", "+proxy.InferencePoolAlphaGroup+" or "+proxy.InferencePoolAutoGroup, "inference-pool-group", *inferencePoolGroup)
+			return
+		}
+		if *allowlistConfigMap != "" && *allowlistSigningKeyFile == "" {
+			logger.Info("Error: --ssrf-protection-signing-key-file is required with --ssrf-protection-configmap")
+			return
+		}
+		if *allowlistConfigMap != "" && *allowlistService != "" {
+			logger.Info("Error: --ssrf-protection-configmap and --ssrf-protection-service are mutually exclusive")
+			return
+		}
+		if *allowlistPolicy != proxy.AllowlistFailClosed && *allowlistPolicy != proxy.AllowlistFailOpen {
+			logger.Info("Error: --ssrf-protection-policy must be "+proxy.AllowlistFailClosed+" or "+proxy.AllowlistFailOpen, "ssrf-protection-policy", *allowlistPolicy)
+			return
+		}
+		if *allowlistGracePeriod < 0 {
+			logger.Info("Error: --ssrf-protection-grace-period must not be negative", "ssrf-protection-grace-period", *allowlistGracePeriod)
+			return
+		}
+		if *allowlistEnforcePorts && (*allowlistConfigMap != "" || *allowlistService != "") {
+			logger.Info("Error: --ssrf-protection-enforce-ports requires the allowlist of the InferencePool, it is incompatible with --ssrf-protection-configmap and --ssrf-protection-service")
+			return
+		}
+		staticAllowlist, err = proxy.NewStaticAllowlist(strings.Split(*allowlistStatic, ","))
+		if err != nil {
+			logger.Info("Error: invalid --allowlist-static", "error", err.Error())
 			return
 		}
 
-		logger.Info("SSRF protection enabled", "namespace", inferencePoolNamespace, "poolName", inferencePoolName)
+		logger.Info("SSRF protection enabled", "namespace", inferencePoolNamespace, "poolName", inferencePoolName, "poolSelector", inferencePoolSelector, "poolGroup", inferencePoolGroup, "service", allowlistService, "static", staticAllowlist.Entries())
+	} else if *allowlistStatic != "" {
+		logger.Info("Error: --allowlist-static requires --enable-ssrf-protection")
+		return
 	}
 
 	// start reverse proxy HTTP server
@@ -88,11 +340,18 @@ func main() {
 		return
 	}
 
+	if *certReloadInterval <= 0 {
+		logger.Info("Error: --cert-reload-interval must be positive", "cert-reload-interval", *certReloadInterval)
+		return
+	}
+
 	var cert *tls.Certificate
+	var listenerCertPath string
 	if *secureProxy {
 		var tempCert tls.Certificate
 		if *certPath != "" {
 			tempCert, err = tls.LoadX509KeyPair(*certPath+"/tls.crt", *certPath+"/tls.key")
+			listenerCertPath = *certPath
 		} else {
 			tempCert, err = proxy.CreateSelfSignedTLSCertificate()
 		}
@@ -103,24 +362,186 @@ func main() {
 		cert = &tempCert
 	}
 
-	config := proxy.Config{
-		Connector:                   *connector,
-		PrefillerUseTLS:             *prefillerUseTLS,
-		PrefillerInsecureSkipVerify: *prefillerInsecureSkipVerify,
-		DecoderInsecureSkipVerify:   *decoderInsecureSkipVerify,
-		DataParallelSize:            *vLLMDataParallelSize,
+	var prefillerRootCAs, decoderRootCAs *x509.CertPool
+	if *prefillerCACert != "" {
+		prefillerRootCAs, err = proxy.LoadCertPool(*prefillerCACert)
+		if err != nil {
+			logger.Error(err, "failed to load the prefiller certificate authorities")
+			return
+		}
+	}
+	if *decoderCACert != "" {
+		decoderRootCAs, err = proxy.LoadCertPool(*decoderCACert)
+		if err != nil {
+			logger.Error(err, "failed to load the decoder certificate authorities")
+			return
+		}
+	}
+
+	var clientCAs *x509.CertPool
+	if *clientCAPath != "" {
+		if !*secureProxy {
+			logger.Info("Error: --client-ca-path requires --secure-proxy")
+			return
+		}
+		clientCAs, err = proxy.LoadCertPool(*clientCAPath)
+		if err != nil {
+			logger.Error(err, "failed to load the client certificate authorities")
+			return
+		}
+	}
+
+	proxyConfig := proxy.Config{
+		Connector:                     *connector,
+		PrefillerUseTLS:               *prefillerUseTLS,
+		PrefillerInsecureSkipVerify:   *prefillerInsecureSkipVerify,
+		DecoderInsecureSkipVerify:     *decoderInsecureSkipVerify,
+		PrefillerRootCAs:              prefillerRootCAs,
+		PrefillerServerName:           *prefillerTLSServerName,
+		DecoderRootCAs:                decoderRootCAs,
+		DecoderServerName:             *decoderTLSServerName,
+		PrefillerHTTP2:                *prefillerHTTP2,
+		DecoderHTTP2:                  *decoderHTTP2,
+		DecoderSocket:                 *vLLMSocket,
+		ListenerHTTP2:                 *listenerHTTP2,
+		DataParallelSize:              *vLLMDataParallelSize,
+		DataParallelPorts:             dataParallelPortsByRank,
+		DataParallelFallback:          *dataParallelFallback,
+		DataParallelDiscoveryInterval: *dataParallelDiscoveryInterval,
+		ScrubInternalResponseFields:   *scrubInternalResponseFields,
+		RoutingResponseHeaders:        *routingResponseHeaders,
+		ValidateModel:                 *validateModel,
+		ModelsCacheTTL:                *modelsCacheTTL,
+		CircuitBreakerThreshold:       *circuitBreakerThreshold,
+		CircuitBreakerErrorRate:       *circuitBreakerErrorRate,
+		CircuitBreakerWindow:          *circuitBreakerWindow,
+		CircuitOpenDuration:           *circuitOpenDuration,
+		ClientCAs:                     clientCAs,
+		CertPath:                      listenerCertPath,
+		CertReloadInterval:            *certReloadInterval,
+		AllowlistReadyWait:            *allowlistReadyWait,
+		DrainTimeout:                  *drainTimeout,
+		AdminPort:                     *adminPort,
+		ReadinessCacheTTL:             *readinessCacheTTL,
+		ReadinessCheckAllRanks:        *readinessCheckAllRanks,
+		PrefillerDNSCacheTTL:          *prefillerDNSCacheTTL,
+		PrefillRetries:                *prefillRetries,
+		PrefillRetryBackoff:           *prefillRetryBackoff,
+		PrefillTimeout:                *prefillTimeout,
+		PrefillHedgeDelay:             *prefillHedgeDelay,
+		PrefillPipelining:             *prefillPipelining,
+		DisableLegacyPrefillURLs:      *disableLegacyPrefillURLs,
+		MaxRequestBodyBytes:           *maxRequestBodyBytes,
+		PrefillFallback:               *prefillFallback,
+		PrefillResponsePolicy:         *prefillResponsePolicy,
+		PrefillBypassTokens:           *prefillBypassTokens,
+		PrefillTransferParams:         prefillTransferFields,
+		DecodeTransferParams:          decodeTransferFields,
+		InterceptPaths:                splitPaths(*interceptPaths),
+		DisaggregatedPoolingPaths:     splitPaths(*disaggregatedPoolingPaths),
+		AnthropicMessages:             *enableAnthropicMessages,
+		MaxConcurrentRequests:         *maxConcurrentRequests,
+		TenantHeader:                  *tenantHeader,
+		TenantMaxConcurrentRequests:   *tenantMaxConcurrentRequests,
+		TokenRateLimit:                *tokenRateLimit,
+		TokenRateBurst:                *tokenRateBurst,
+		TokenRateClientHeader:         *tokenRateClientHeader,
+		JobHeader:                     *jobHeader,
+		JobPinTTL:                     *jobPinTTL,
+		ShadowURL:                     shadowTarget,
+		ShadowPercentage:              *shadowPercentage,
+		ShadowTimeout:                 *shadowTimeout,
+		StreamWriteTimeout:            *streamWriteTimeout,
+		StreamFlushInterval:           *streamFlushInterval,
+		StreamBufferSize:              *streamBufferSize,
+		UnbufferedPaths:               splitPaths(*unbufferedPaths),
+		Transport: proxy.TransportConfig{
+			MaxIdleConns:          *maxIdleConns,
+			MaxIdleConnsPerHost:   *maxIdleConnsPerHost,
+			MaxConnsPerHost:       *maxConnsPerHost,
+			IdleConnTimeout:       *idleConnTimeout,
+			TLSHandshakeTimeout:   *tlsHandshakeTimeout,
+			ResponseHeaderTimeout: *responseHeaderTimeout,
+		},
+	}
+	if *accessLog {
+		proxyConfig.AccessLog = os.Stdout
+	}
+	switch *ssrfAuditLog {
+	case "":
+	case "stdout":
+		proxyConfig.SSRFAuditLog = os.Stdout
+	default:
+		auditLog, err := os.OpenFile(*ssrfAuditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			logger.Error(err, "failed to open the SSRF audit log", "path", *ssrfAuditLog)
+			return
+		}
+		defer auditLog.Close() //nolint:errcheck
+		proxyConfig.SSRFAuditLog = auditLog
 	}
 
 	// Create SSRF protection validator
-	validator, err := proxy.NewAllowlistValidator(*enableSSRFProtection, *inferencePoolNamespace, *inferencePoolName)
+	userAgent := "llm-d-pd-sidecar/" + version.BuildRef
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		userAgent += " (" + *inferencePoolNamespace + "/" + podName + ")"
+	}
+	kubernetesOptions := proxy.KubernetesClientOptions{QPS: float32(*kubeAPIQPS), Burst: *kubeAPIBurst, UserAgent: userAgent}
+	var validator *proxy.AllowlistValidator
+	if *enableSSRFProtection && *allowlistConfigMap != "" {
+		signingKey, readErr := os.ReadFile(*allowlistSigningKeyFile)
+		if readErr != nil {
+			logger.Error(readErr, "failed to read the signing key of the published endpoints")
+			return
+		}
+		validator, err = proxy.NewPublishedAllowlistValidator(*inferencePoolNamespace, *allowlistConfigMap, signingKey, kubernetesOptions)
+	} else if *enableSSRFProtection && *allowlistService != "" {
+		validator, err = proxy.NewEndpointSliceAllowlistValidator(*inferencePoolNamespace, *allowlistService, kubernetesOptions)
+	} else {
+		validator, err = proxy.NewAllowlistValidatorWithOptions(*enableSSRFProtection, *inferencePoolNamespace, *inferencePoolName, kubernetesOptions)
+		if err == nil {
+			validator = validator.WithPoolGroup(*inferencePoolGroup).WithPoolSelector(*inferencePoolSelector).
+				WithPortEnforcement(*allowlistEnforcePorts)
+		}
+	}
 	if err != nil {
 		logger.Error(err, "failed to create SSRF protection validator")
 		return
 	}
+	validator = validator.WithStaticAllowlist(staticAllowlist).WithFailurePolicy(*allowlistPolicy).WithGracePeriod(*allowlistGracePeriod)
 
-	proxyServer := proxy.NewProxy(*port, targetURL, config)
+	proxyServer := proxy.NewProxy(*port, targetURL, proxyConfig)
 
 	if err := proxyServer.Start(ctx, cert, validator); err != nil {
 		logger.Error(err, "failed to start proxy server")
 	}
 }
+
+// isPort returns whether the value is a TCP port number
+func isPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port > 0 && port <= 65535
+}
+
+// splitPaths returns the non-empty paths of a comma separated list
+func splitPaths(paths string) []string {
+	var result []string
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// parseTransferParams returns the fields of a JSON object, none when the value is empty
+func parseTransferParams(value string) (map[string]any, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -4,10 +4,158 @@
 //revive:disable:var-naming
 package common
 
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
 const (
-	// PrefillPodHeader is the header name used to indicate Prefill worker <ip:port>
+	// PrefillPodHeader is the header name used to indicate Prefill worker <ip:port>, or a comma separated
+	// list of Prefill workers ranked in order of preference when more than one was selected
 	PrefillPodHeader = "x-prefiller-host-port"
 
 	// DataParallelPodHeader is the header name used to indicate the worker <ip:port> for Data Parallel
 	DataParallelPodHeader = "x-data-parallel-host-port"
+
+	// RequestPathHeader is the pseudo header holding the request path in the EPP request headers
+	RequestPathHeader = ":path"
+
+	// PoolingPathsAlias stands for all the PoolingPaths in configured path lists
+	PoolingPathsAlias = "pooling"
+)
+
+// PoolingPaths are the vLLM endpoints of the pooling requests (scoring, reranking, classification
+// and embeddings). These requests generate no tokens, so they are never disaggregated.
+var PoolingPaths = []string{
+	"/pooling",
+	"/classify",
+	"/score",
+	"/v1/score",
+	"/rerank",
+	"/v1/rerank",
+	"/v2/rerank",
+	"/v1/embeddings",
+}
+
+// RequestPath returns the path of a request URI, without its query
+func RequestPath(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	return path
+}
+
+// IsPoolingPath returns true when the request URI targets a pooling endpoint
+func IsPoolingPath(uri string) bool {
+	return slices.Contains(PoolingPaths, RequestPath(uri))
+}
+
+// MatchesPath returns true when the request URI targets one of the paths, or a pooling
+// endpoint when the paths contain the PoolingPathsAlias
+func MatchesPath(paths []string, uri string) bool {
+	if slices.Contains(paths, PoolingPathsAlias) && IsPoolingPath(uri) {
+		return true
+	}
+	return slices.Contains(paths, RequestPath(uri))
+}
+
+// CircuitsPath is the sidecar endpoint reporting the state of its circuit breakers
+const CircuitsPath = "/circuits"
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed lets the requests through
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen fails the requests fast after repeated failures
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen lets a probe request through once the circuit was open long enough
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitsResponse is the state of the sidecar circuit breakers, reported on the CircuitsPath
+type CircuitsResponse struct {
+	// Decoder is the state of the circuit of the local decoder
+	Decoder CircuitState `json:"decoder"`
+
+	// Prefillers are the states of the circuits of the prefill targets, by <ip:port>
+	Prefillers map[string]CircuitState `json:"prefillers"`
+}
+
+const (
+	// PublishedEndpointsKey is the key of the endpoint set in the ConfigMap published by the EPP
+	PublishedEndpointsKey = "endpoints.json"
+
+	// PublishedSignatureKey is the key of the signature of the endpoint set in the ConfigMap published by the EPP
+	PublishedSignatureKey = "signature"
 )
+
+// PublishedEndpoints is the endpoint set of the pool published by the EPP, from which the sidecars
+// build their SSRF protection allowlist without watching the API server
+type PublishedEndpoints struct {
+	// Endpoints are the addresses and the names of the pods of the pool
+	Endpoints []string `json:"endpoints"`
+
+	// PublishedAt is the time the endpoint set was published
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// SignEndpoints returns the hex encoded HMAC-SHA256 signature of a published endpoint set payload
+func SignEndpoints(payload []byte, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload) //nolint:all
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyEndpoints returns whether the signature of a published endpoint set payload is valid
+func VerifyEndpoints(payload []byte, signature string, key []byte) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload) //nolint:all
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
+
+// FeaturesPath is the endpoint of the sidecar and of the EPP reporting their enabled features, so
+// that the auditing tools can compare the configuration of the pods of a fleet
+const FeaturesPath = "/features"
+
+// Features are the build, the enabled features and the protocols of a binary, reported on the FeaturesPath
+type Features struct {
+	// Component is the name of the binary, sidecar or epp
+	Component string `json:"component"`
+
+	// Version is the build ref of the binary
+	Version string `json:"version"`
+
+	// Commit is the git hash the binary was built from
+	Commit string `json:"commit"`
+
+	// GoVersion is the version of the Go toolchain the binary was built with
+	GoVersion string `json:"goVersion"`
+
+	// Connector is the KV-transfer protocol used by the sidecar
+	Connector string `json:"connector,omitempty"`
+
+	// Connectors are the sorted names of the KV-transfer protocols registered in the sidecar
+	Connectors []string `json:"connectors,omitempty"`
+
+	// Plugins are the sorted types of the plugins registered in the EPP
+	Plugins []string `json:"plugins,omitempty"`
+
+	// Security reports whether each security feature is enabled, e.g. the SSRF protection or TLS
+	Security map[string]bool `json:"security"`
+
+	// Protocols are the protocols of the listeners and of the upstream connections, e.g. http/1.1, h2 or h2c
+	Protocols map[string]string `json:"protocols"`
+
+	// Enabled are the sorted names of the optional features enabled
+	Enabled []string `json:"enabled"`
+}
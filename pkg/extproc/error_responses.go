@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extproc
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/mem"
+)
+
+const gatewayErrorPrefix = "inference gateway: "
+
+// ErrorResponsesOptions configures the rewriting of the rejection responses of the EPP in the
+// OpenAI error format
+type ErrorResponsesOptions struct {
+	// Enabled rewrites the error bodies of the immediate responses of the ext_proc server of the EPP
+	// in the OpenAI error format
+	Enabled bool
+}
+
+// NewErrorResponsesOptions returns the default error responses options: disabled
+func NewErrorResponsesOptions() *ErrorResponsesOptions {
+	return &ErrorResponsesOptions{}
+}
+
+// AddFlags registers the error responses flags in the given FlagSet
+func (o *ErrorResponsesOptions) AddFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "openai-error-responses", o.Enabled,
+		"Return the rejections of the EPP (no candidate pods, shedding, ...) as OpenAI error JSON bodies instead of plain text.")
+}
+
+// openAIError is the error body returned by the OpenAI compatible servers, vLLM among them
+type openAIError struct {
+	Object  string `json:"object"`
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Param   string `json:"param"`
+	Code    int    `json:"code"`
+}
+
+// errorTypes are the OpenAI error types of the status codes of the EPP rejections
+var errorTypes = map[int]string{
+	http.StatusBadRequest:          "BadRequestError",
+	http.StatusNotFound:            "NotFoundError",
+	http.StatusTooManyRequests:     "RateLimitError",
+	http.StatusInternalServerError: "InternalServerError",
+	http.StatusServiceUnavailable:  "ServiceUnavailableError",
+}
+
+// OpenAIErrorBody returns the OpenAI error body of a rejection of the EPP. The message drops the
+// "inference gateway: <code> - " prefix of the EPP errors.
+func OpenAIErrorBody(code int, message string) []byte {
+	if rest, ok := strings.CutPrefix(message, gatewayErrorPrefix); ok {
+		if _, msg, found := strings.Cut(rest, " - "); found {
+			message = msg
+		}
+	}
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	errorType, ok := errorTypes[code]
+	if !ok {
+		errorType = "APIError"
+	}
+	body, _ := json.Marshal(openAIError{Object: "error", Message: message, Type: errorType, Code: code}) //nolint:all
+	return body
+}
+
+// rewriteErrorResponse rewrites the plain text body of an immediate error response in the OpenAI
+// error format. The other responses are left as is.
+func rewriteErrorResponse(resp *extProcPb.ProcessingResponse) {
+	immediate := resp.GetImmediateResponse()
+	if immediate == nil {
+		return
+	}
+	code := int(immediate.GetStatus().GetCode())
+	if code < http.StatusBadRequest || json.Valid(immediate.GetBody()) {
+		return
+	}
+
+	immediate.Body = OpenAIErrorBody(code, string(immediate.GetBody()))
+	if immediate.Headers == nil {
+		immediate.Headers = &extProcPb.HeaderMutation{}
+	}
+	immediate.Headers.SetHeaders = append(immediate.Headers.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")},
+	})
+}
+
+// errorResponseCodec is the proto codec of gRPC, rewriting the immediate error responses of the
+// ext_proc server before marshaling them
+type errorResponseCodec struct {
+	encoding.CodecV2
+}
+
+// Marshal rewrites the immediate error responses and marshals v with the proto codec
+func (c errorResponseCodec) Marshal(v any) (mem.BufferSlice, error) {
+	if resp, ok := v.(*extProcPb.ProcessingResponse); ok {
+		rewriteErrorResponse(resp)
+	}
+	return c.CodecV2.Marshal(v)
+}
+
+// RegisterErrorResponseCodec replaces the proto codec of gRPC with one rewriting the immediate error
+// responses of the ext_proc server of the EPP in the OpenAI error format, so that the rejections are
+// rewritten in the response path of the EPP itself. The codec is process wide: it must be registered
+// before the gRPC servers and clients are created, and only changes the ext_proc responses.
+func RegisterErrorResponseCodec(opts *ErrorResponsesOptions) {
+	if !opts.Enabled {
+		return
+	}
+	if _, registered := encoding.GetCodecV2(proto.Name).(errorResponseCodec); registered {
+		return
+	}
+	encoding.RegisterCodecV2(errorResponseCodec{CodecV2: encoding.GetCodecV2(proto.Name)})
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extproc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	envoyTypePb "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/extproc"
+)
+
+// rejectingServer answers every request of a stream with the given immediate response
+type rejectingServer struct {
+	extProcPb.UnimplementedExternalProcessorServer
+	response *extProcPb.ImmediateResponse
+}
+
+func (s *rejectingServer) Process(stream extProcPb.ExternalProcessor_ProcessServer) error {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return nil
+		}
+		resp := &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ImmediateResponse{ImmediateResponse: s.response},
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// serve serves an ext_proc server on a loopback port and returns its address
+func serve(t *testing.T, server extProcPb.ExternalProcessorServer) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	extProcPb.RegisterExternalProcessorServer(grpcServer, server)
+	go grpcServer.Serve(ln) //nolint:all
+	t.Cleanup(grpcServer.Stop)
+	return ln.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *grpc.ClientConn {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() }) //nolint:all
+	return conn
+}
+
+func TestOpenAIErrorBody(t *testing.T) {
+	tests := []struct {
+		code    int
+		message string
+		want    string
+	}{
+		{503, "inference gateway: ServiceUnavailable - failed to find candidate pods for serving the request",
+			`{"object":"error","message":"failed to find candidate pods for serving the request","type":"ServiceUnavailableError","param":"","code":503}`},
+		{429, "inference gateway: InferencePoolResourceExhausted - request dropped",
+			`{"object":"error","message":"request dropped","type":"RateLimitError","param":"","code":429}`},
+		{404, "model not found", `{"object":"error","message":"model not found","type":"NotFoundError","param":"","code":404}`},
+		{502, "", `{"object":"error","message":"Bad Gateway","type":"APIError","param":"","code":502}`},
+	}
+	for _, test := range tests {
+		if got := string(extproc.OpenAIErrorBody(test.code, test.message)); got != test.want {
+			t.Errorf("expected %s, got %s", test.want, got)
+		}
+	}
+}
+
+func TestErrorResponseCodec(t *testing.T) {
+	extproc.RegisterErrorResponseCodec(&extproc.ErrorResponsesOptions{Enabled: true})
+
+	tests := []struct {
+		name        string
+		response    *extProcPb.ImmediateResponse
+		wantBody    string
+		wantHeaders int
+	}{
+		{
+			name: "rejection",
+			response: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_ServiceUnavailable},
+				Body:   []byte("inference gateway: ServiceUnavailable - failed to find candidate pods for serving the request"),
+			},
+			wantBody:    `{"object":"error","message":"failed to find candidate pods for serving the request","type":"ServiceUnavailableError","param":"","code":503}`,
+			wantHeaders: 1,
+		},
+		{
+			name: "JSON rejection",
+			response: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_BadRequest},
+				Body:   []byte(`{"error":"bad request"}`),
+			},
+			wantBody: `{"error":"bad request"}`,
+		},
+		{
+			name: "success",
+			response: &extProcPb.ImmediateResponse{
+				Status: &envoyTypePb.HttpStatus{Code: envoyTypePb.StatusCode_OK},
+				Body:   []byte("ok"),
+			},
+			wantBody: "ok",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := extProcPb.NewExternalProcessorClient(dial(t, serve(t, &rejectingServer{response: test.response})))
+
+			stream, err := client.Process(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := stream.Send(&extProcPb.ProcessingRequest{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := stream.CloseSend(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			immediate := resp.GetImmediateResponse()
+			if got := string(immediate.GetBody()); got != test.wantBody {
+				t.Errorf("expected body %s, got %s", test.wantBody, got)
+			}
+			if immediate.GetStatus().GetCode() != test.response.GetStatus().GetCode() {
+				t.Errorf("expected status %v, got %v", test.response.GetStatus().GetCode(), immediate.GetStatus().GetCode())
+			}
+			headers := immediate.GetHeaders().GetSetHeaders()
+			if len(headers) != test.wantHeaders {
+				t.Fatalf("expected %d headers, got %v", test.wantHeaders, headers)
+			}
+			if test.wantHeaders > 0 && string(headers[0].GetHeader().GetRawValue()) != "application/json" {
+				t.Errorf("expected a JSON content type, got %v", headers[0])
+			}
+		})
+	}
+}
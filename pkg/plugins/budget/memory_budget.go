@@ -0,0 +1,236 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// MemoryBudgetType is the type of the MemoryBudget
+	MemoryBudgetType = "memory-budget"
+
+	defaultMemoryBudgetInterval = 10 * time.Second
+
+	// evictionTarget is the fraction of the budget the usage is brought back to when over budget, so
+	// that the eviction does not run again on each check
+	evictionTarget = 0.9
+)
+
+// Stateful is implemented by the plugins keeping state growing with the traffic, such as session
+// tables, LRU caches or latency windows, so that their memory is accounted for by the MemoryBudget.
+type Stateful interface {
+	plugins.Plugin
+
+	// StateUsage returns the number of entries of the state, and the estimated size of an entry in bytes
+	StateUsage() (entries int, entryBytes int)
+
+	// EvictState evicts up to n of the oldest entries of the state, and returns the number evicted
+	EvictState(n int) int
+}
+
+// EvictOldest evicts up to n of the least recently set entries of a ttlcache, and returns the number evicted
+func EvictOldest[K comparable, V any](cache *ttlcache.Cache[K, V], n int) int {
+	keys := make([]K, 0, n)
+	cache.RangeBackwards(func(item *ttlcache.Item[K, V]) bool {
+		if len(keys) == n {
+			return false
+		}
+		keys = append(keys, item.Key())
+		return true
+	})
+	for _, key := range keys {
+		cache.Delete(key)
+	}
+	return len(keys)
+}
+
+// MemoryBudgetParameters defines the parameters of the MemoryBudget
+type MemoryBudgetParameters struct {
+	// MaxBytes is the estimated memory the state of the stateful plugins may use in total
+	MaxBytes int64 `json:"maxBytes"`
+
+	// Interval is the interval the usage is checked at.
+	// This field accepts duration strings like "10s", "1m". Defaults to "10s".
+	Interval string `json:"interval"`
+}
+
+// compile-time type assertion
+var _ plugins.Plugin = &MemoryBudget{}
+
+// MemoryBudgetFactory defines the factory function for the MemoryBudget
+func MemoryBudgetFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := MemoryBudgetParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' plugin - %w", MemoryBudgetType, err)
+		}
+	}
+
+	if parameters.MaxBytes <= 0 {
+		return nil, fmt.Errorf("invalid configuration for '%s' plugin: 'maxBytes' must be positive, got %d",
+			MemoryBudgetType, parameters.MaxBytes)
+	}
+	interval := defaultMemoryBudgetInterval
+	if parameters.Interval != "" {
+		var err error
+		interval, err = time.ParseDuration(parameters.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval: must be a positive duration, got '%s'", parameters.Interval)
+		}
+	}
+
+	budget := NewMemoryBudget(parameters.MaxBytes, handle).WithName(name)
+	if err := budget.register(metrics.Registry); err != nil {
+		return nil, fmt.Errorf("failed to register the metrics of the '%s' plugin - %w", MemoryBudgetType, err)
+	}
+	go budget.Run(handle.Context(), interval)
+	return budget, nil
+}
+
+// NewMemoryBudget initializes a new MemoryBudget and returns its pointer. The stateful plugins are
+// looked up in the given plugins on each check, whatever the order they are defined in.
+func NewMemoryBudget(maxBytes int64, handlePlugins plugins.HandlePlugins) *MemoryBudget {
+	return &MemoryBudget{
+		typedName: plugins.TypedName{Type: MemoryBudgetType},
+		maxBytes:  maxBytes,
+		plugins:   handlePlugins,
+		usage: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "llm_d_epp_memory_budget_usage_bytes",
+			Help: "Estimated memory used by the state of the stateful plugins, by plugin.",
+		}, []string{"plugin"}),
+		limit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "llm_d_epp_memory_budget_limit_bytes",
+			Help: "Memory budget of the state of the stateful plugins.",
+		}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "llm_d_epp_memory_budget_evictions_total",
+			Help: "Number of state entries evicted to keep the stateful plugins within the memory budget, by plugin.",
+		}, []string{"plugin"}),
+	}
+}
+
+// MemoryBudget bounds the memory of the stateful plugins, so that the memory of the epp stays
+// predictable on very large fleets. The usage of the plugins is estimated from their number of
+// entries and exported as metrics. Once the total usage exceeds the budget, each plugin evicts its
+// oldest entries in proportion to its share of the usage, until the usage is back to 90% of the
+// budget.
+type MemoryBudget struct {
+	typedName plugins.TypedName
+	maxBytes  int64
+	plugins   plugins.HandlePlugins
+
+	usage     *prometheus.GaugeVec
+	limit     prometheus.Gauge
+	evictions *prometheus.CounterVec
+}
+
+// TypedName returns the typed name of the plugin.
+func (b *MemoryBudget) TypedName() plugins.TypedName {
+	return b.typedName
+}
+
+// WithName sets the name of the plugin.
+func (b *MemoryBudget) WithName(name string) *MemoryBudget {
+	b.typedName.Name = name
+	return b
+}
+
+// register registers the metrics of the budget
+func (b *MemoryBudget) register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{b.usage, b.limit, b.evictions} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run checks the usage every interval, until the context is done
+func (b *MemoryBudget) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Check(ctx)
+		}
+	}
+}
+
+// statefulUsage is the estimated usage of a stateful plugin
+type statefulUsage struct {
+	name       string
+	plugin     Stateful
+	entries    int
+	entryBytes int
+}
+
+func (u statefulUsage) bytes() int64 {
+	return int64(u.entries) * int64(u.entryBytes)
+}
+
+// Check exports the usage of the stateful plugins, and evicts their oldest entries when over budget.
+// It returns the total usage after the eviction.
+func (b *MemoryBudget) Check(ctx context.Context) int64 {
+	var usages []statefulUsage
+	var total int64
+	for name, plugin := range b.plugins.GetAllPluginsWithNames() {
+		if stateful, ok := plugin.(Stateful); ok {
+			entries, entryBytes := stateful.StateUsage()
+			usage := statefulUsage{name: name, plugin: stateful, entries: entries, entryBytes: entryBytes}
+			usages = append(usages, usage)
+			total += usage.bytes()
+		}
+	}
+
+	b.limit.Set(float64(b.maxBytes))
+	if total > b.maxBytes {
+		excess := total - int64(float64(b.maxBytes)*evictionTarget)
+		log.FromContext(ctx).V(logutil.DEFAULT).Info("Stateful plugins over the memory budget, evicting their oldest entries",
+			"usage", total, "budget", b.maxBytes)
+		for i, usage := range usages {
+			if usage.entryBytes <= 0 || usage.entries == 0 {
+				continue
+			}
+			share := float64(excess) * float64(usage.bytes()) / float64(total)
+			evicted := usage.plugin.EvictState(int(math.Ceil(share / float64(usage.entryBytes))))
+			b.evictions.WithLabelValues(usage.name).Add(float64(evicted))
+			usages[i].entries -= evicted
+		}
+	}
+
+	total = 0
+	for _, usage := range usages {
+		b.usage.WithLabelValues(usage.name).Set(float64(usage.bytes()))
+		total += usage.bytes()
+	}
+	return total
+}
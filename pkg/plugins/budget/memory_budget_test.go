@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+)
+
+// fakeStateful is a stateful plugin with a ttlcache state
+type fakeStateful struct {
+	typedName  plugins.TypedName
+	entryBytes int
+	cache      *ttlcache.Cache[int, struct{}]
+}
+
+func newFakeStateful(name string, entries int, entryBytes int) *fakeStateful {
+	cache := ttlcache.New[int, struct{}]()
+	for i := range entries {
+		cache.Set(i, struct{}{}, ttlcache.NoTTL)
+	}
+	return &fakeStateful{typedName: plugins.TypedName{Type: "fake", Name: name}, entryBytes: entryBytes, cache: cache}
+}
+
+func (p *fakeStateful) TypedName() plugins.TypedName { return p.typedName }
+
+func (p *fakeStateful) StateUsage() (int, int) { return p.cache.Len(), p.entryBytes }
+
+func (p *fakeStateful) EvictState(n int) int { return EvictOldest(p.cache, n) }
+
+func TestEvictOldest(t *testing.T) {
+	cache := ttlcache.New[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		cache.Set(key, i, ttlcache.NoTTL)
+	}
+	cache.Set("a", 4, ttlcache.NoTTL) // the updated entries are the most recent
+
+	if evicted := EvictOldest(cache, 2); evicted != 2 {
+		t.Errorf("expected 2 evicted entries, got %d", evicted)
+	}
+	if diff := cmp.Diff([]string{"a", "d"}, sortedKeys(cache)); diff != "" {
+		t.Errorf("unexpected remaining keys (-want +got): %s", diff)
+	}
+	if evicted := EvictOldest(cache, 5); evicted != 2 {
+		t.Errorf("expected the 2 remaining entries to be evicted, got %d", evicted)
+	}
+}
+
+func sortedKeys(cache *ttlcache.Cache[string, int]) []string {
+	keys := cache.Keys()
+	slices.Sort(keys)
+	return keys
+}
+
+func TestMemoryBudget(t *testing.T) {
+	handle := plugins.NewEppHandle(context.Background(), nil)
+	sessions := newFakeStateful("sessions", 600, 100) // 60000 bytes
+	jobs := newFakeStateful("jobs", 100, 200)         // 20000 bytes
+	handle.AddPlugin("sessions", sessions)
+	handle.AddPlugin("jobs", jobs)
+
+	budget := NewMemoryBudget(100000, handle)
+	if usage := budget.Check(context.Background()); usage != 80000 {
+		t.Errorf("expected a usage of 80000 bytes within the budget, got %d", usage)
+	}
+	if got := testutil.ToFloat64(budget.evictions.WithLabelValues("sessions")); got != 0 {
+		t.Errorf("expected no eviction within the budget, got %v", got)
+	}
+
+	for i := 600; i < 1200; i++ {
+		sessions.cache.Set(i, struct{}{}, ttlcache.NoTTL)
+	} // 140000 bytes in total, 50000 over 90% of the budget
+
+	usage := budget.Check(context.Background())
+	if usage > 90000 {
+		t.Errorf("expected the usage to be back to 90%% of the budget, got %d", usage)
+	}
+	// the plugins evict in proportion to their usage: 120000 / 140000 and 20000 / 140000
+	if diff := cmp.Diff([]float64{429, 36}, []float64{
+		testutil.ToFloat64(budget.evictions.WithLabelValues("sessions")),
+		testutil.ToFloat64(budget.evictions.WithLabelValues("jobs")),
+	}); diff != "" {
+		t.Errorf("unexpected evictions (-want +got): %s", diff)
+	}
+	if got := testutil.ToFloat64(budget.usage.WithLabelValues("jobs")); got != 12800 {
+		t.Errorf("expected a usage of 12800 bytes of the jobs, got %v", got)
+	}
+	if got := testutil.ToFloat64(budget.limit); got != 100000 {
+		t.Errorf("expected a limit of 100000 bytes, got %v", got)
+	}
+}
+
+func TestMemoryBudgetFactory(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{name: "missing budget", params: `{}`, wantErr: true},
+		{name: "negative budget", params: `{"maxBytes": -1}`, wantErr: true},
+		{name: "invalid interval", params: `{"maxBytes": 1000, "interval": "soon"}`, wantErr: true},
+		{name: "valid", params: `{"maxBytes": 1000, "interval": "1m"}`},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_, err := MemoryBudgetFactory(fmt.Sprintf("budget-%d", i), json.RawMessage(test.params), plugins.NewEppHandle(ctx, nil))
+			if (err != nil) != test.wantErr {
+				t.Errorf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
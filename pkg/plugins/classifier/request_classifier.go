@@ -0,0 +1,184 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package classifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+const (
+	// RequestClassifierType is the type of the RequestClassifier
+	RequestClassifierType = "request-classifier"
+
+	defaultClassHeader = "x-compute-class"
+)
+
+// Classifier assigns a class to a request.
+type Classifier interface {
+	plugins.Plugin
+	// Classify returns the class of the request.
+	Classify(ctx context.Context, request *types.LLMRequest) string
+}
+
+// RuleParameters defines a classification rule. A request matches a rule when it matches all
+// its conditions.
+type RuleParameters struct {
+	// Class is the class of the requests matching the rule
+	Class string `json:"class"`
+
+	// Models are the target models matching the rule. Any model matches when empty.
+	Models []string `json:"models"`
+
+	// Headers are the request header values matching the rule.
+	Headers map[string]string `json:"headers"`
+
+	// Paths are the request paths matching the rule, e.g. "/score". The "pooling" path matches
+	// all the pooling endpoints: scoring, reranking, classification and embeddings.
+	// Any path matches when empty.
+	Paths []string `json:"paths"`
+}
+
+// RequestClassifierParameters defines the parameters of the RequestClassifier.
+type RequestClassifierParameters struct {
+	// ClassHeader is the request header a client may set to request a class explicitly.
+	// Defaults to "x-compute-class".
+	ClassHeader string `json:"classHeader"`
+
+	// Rules are evaluated in order, the first matching rule assigns the class
+	Rules []RuleParameters `json:"rules"`
+
+	// DefaultClass is the class of the requests matching no rule
+	DefaultClass string `json:"defaultClass"`
+}
+
+// compile-time type assertion
+var _ Classifier = &RequestClassifier{}
+
+// RequestClassifierFactory defines the factory function for the RequestClassifier.
+func RequestClassifierFactory(name string, rawParameters json.RawMessage, _ plugins.Handle) (plugins.Plugin, error) {
+	parameters := RequestClassifierParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' classifier - %w", RequestClassifierType, err)
+		}
+	}
+
+	classifier, err := NewRequestClassifier(&parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' classifier - %w", RequestClassifierType, err)
+	}
+	return classifier.WithName(name), nil
+}
+
+// NewRequestClassifier initializes a new RequestClassifier and returns its pointer.
+func NewRequestClassifier(params *RequestClassifierParameters) (*RequestClassifier, error) {
+	if params.DefaultClass == "" {
+		return nil, errors.New("defaultClass must be specified")
+	}
+	classHeader := params.ClassHeader
+	if classHeader == "" {
+		classHeader = defaultClassHeader
+	}
+
+	// the header names are matched case insensitively
+	classes := map[string]struct{}{params.DefaultClass: {}}
+	rules := make([]RuleParameters, len(params.Rules))
+	for i, rule := range params.Rules {
+		if rule.Class == "" {
+			return nil, fmt.Errorf("invalid rule %d: class must be specified", i)
+		}
+		classes[rule.Class] = struct{}{}
+		headers := make(map[string]string, len(rule.Headers))
+		for header, value := range rule.Headers {
+			headers[strings.ToLower(header)] = value
+		}
+		rule.Headers = headers
+		rules[i] = rule
+	}
+
+	return &RequestClassifier{
+		typedName:    plugins.TypedName{Type: RequestClassifierType},
+		classHeader:  strings.ToLower(classHeader),
+		rules:        rules,
+		defaultClass: params.DefaultClass,
+		classes:      classes,
+	}, nil
+}
+
+// RequestClassifier assigns a class to requests based on the explicitly requested class, or
+// on rules matching the target model and the request headers.
+type RequestClassifier struct {
+	typedName    plugins.TypedName
+	classHeader  string
+	rules        []RuleParameters
+	defaultClass string
+	classes      map[string]struct{}
+}
+
+// TypedName returns the typed name of the plugin.
+func (c *RequestClassifier) TypedName() plugins.TypedName {
+	return c.typedName
+}
+
+// WithName sets the name of the plugin.
+func (c *RequestClassifier) WithName(name string) *RequestClassifier {
+	c.typedName.Name = name
+	return c
+}
+
+// Classify returns the class requested in the class header when it is a known class, otherwise
+// the class of the first matching rule, or the default class.
+func (c *RequestClassifier) Classify(_ context.Context, request *types.LLMRequest) string {
+	if class, ok := request.Headers[c.classHeader]; ok {
+		if _, known := c.classes[class]; known {
+			return class
+		}
+	}
+
+	for _, rule := range c.rules {
+		if matches(rule, request) {
+			return rule.Class
+		}
+	}
+	return c.defaultClass
+}
+
+// matches returns true when the request matches all the conditions of the rule
+func matches(rule RuleParameters, request *types.LLMRequest) bool {
+	if len(rule.Models) > 0 && !slices.Contains(rule.Models, request.TargetModel) {
+		return false
+	}
+	if len(rule.Paths) > 0 && !common.MatchesPath(rule.Paths, request.Headers[common.RequestPathHeader]) {
+		return false
+	}
+	for header, value := range rule.Headers {
+		if request.Headers[header] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package classifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+func TestRequestClassifier(t *testing.T) {
+	classifier, err := NewRequestClassifier(&RequestClassifierParameters{
+		Rules: []RuleParameters{
+			{Class: "embeddings", Paths: []string{"/v1/embeddings"}},
+			{Class: "pooling", Paths: []string{common.PoolingPathsAlias}},
+			{Class: "high", Models: []string{"large-model"}},
+			{Class: "high", Headers: map[string]string{"X-Tier": "premium"}},
+		},
+		ClassHeader:  "X-Compute-Class",
+		DefaultClass: "low",
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		request   *types.LLMRequest
+		wantClass string
+	}{
+		{
+			name:      "no matching rule",
+			request:   &types.LLMRequest{TargetModel: "small-model", Headers: map[string]string{}},
+			wantClass: "low",
+		},
+		{
+			name:      "model rule",
+			request:   &types.LLMRequest{TargetModel: "large-model", Headers: map[string]string{}},
+			wantClass: "high",
+		},
+		{
+			name:      "header rule",
+			request:   &types.LLMRequest{TargetModel: "small-model", Headers: map[string]string{"x-tier": "premium"}},
+			wantClass: "high",
+		},
+		{
+			name:      "path rule",
+			request:   &types.LLMRequest{TargetModel: "large-model", Headers: map[string]string{":path": "/v1/embeddings"}},
+			wantClass: "embeddings",
+		},
+		{
+			name:      "pooling path rule",
+			request:   &types.LLMRequest{TargetModel: "large-model", Headers: map[string]string{":path": "/v1/rerank?top_n=2"}},
+			wantClass: "pooling",
+		},
+		{
+			name:      "generation path matches no path rule",
+			request:   &types.LLMRequest{TargetModel: "large-model", Headers: map[string]string{":path": "/v1/chat/completions"}},
+			wantClass: "high",
+		},
+		{
+			name:      "explicit class",
+			request:   &types.LLMRequest{TargetModel: "large-model", Headers: map[string]string{"x-compute-class": "low"}},
+			wantClass: "low",
+		},
+		{
+			name:      "unknown explicit class is ignored",
+			request:   &types.LLMRequest{TargetModel: "large-model", Headers: map[string]string{"x-compute-class": "unknown"}},
+			wantClass: "high",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantClass, classifier.Classify(context.Background(), tt.request))
+		})
+	}
+}
+
+func TestRequestClassifierFactory(t *testing.T) {
+	tests := []struct {
+		name       string
+		jsonParams string
+		expectErr  bool
+	}{
+		{
+			name:       "valid configuration",
+			jsonParams: `{"defaultClass": "low", "rules": [{"class": "high", "models": ["large-model"]}]}`,
+			expectErr:  false,
+		},
+		{
+			name:       "missing default class should error",
+			jsonParams: `{"rules": [{"class": "high"}]}`,
+			expectErr:  true,
+		},
+		{
+			name:       "rule without class should error",
+			jsonParams: `{"defaultClass": "low", "rules": [{"models": ["large-model"]}]}`,
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := RequestClassifierFactory("classifier", []byte(tt.jsonParams), nil)
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, plugin)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, plugin)
+			}
+		})
+	}
+}
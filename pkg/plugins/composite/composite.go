@@ -0,0 +1,69 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// ResolveScorers returns the scorer plugins of the given names, in order. The scorers must be
+// defined before the plugin combining them.
+func ResolveScorers(handle plugins.Handle, pluginRefs []string) ([]framework.Scorer, error) {
+	scorers := make([]framework.Scorer, len(pluginRefs))
+	for i, pluginRef := range pluginRefs {
+		scorer, ok := handle.Plugin(pluginRef).(framework.Scorer)
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a scorer defined before it", pluginRef)
+		}
+		scorers[i] = scorer
+	}
+	return scorers, nil
+}
+
+// WeightedScore returns the weighted average of the scores of the scorers, in range of 0-1, each
+// score being clamped to 0-1. The scorers of zero weight are not called, and all the pods get zero
+// when the weights sum to zero.
+func WeightedScore(ctx context.Context, cycleState *types.CycleState, request *types.LLMRequest, pods []types.Pod,
+	scorers []framework.Scorer, weights []float64) map[types.Pod]float64 {
+	totalWeight := 0.0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+	}
+	if totalWeight == 0 {
+		return scoredPods
+	}
+
+	for i, scorer := range scorers {
+		if weights[i] == 0 {
+			continue
+		}
+		for pod, score := range scorer.Score(ctx, cycleState, request, pods) {
+			scoredPods[pod] += min(max(score, 0), 1) * weights[i] / totalWeight
+		}
+	}
+	return scoredPods
+}
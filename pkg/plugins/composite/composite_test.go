@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite/compositetest"
+)
+
+// otherPlugin is a plugin which is not a scorer
+type otherPlugin struct{}
+
+func (otherPlugin) TypedName() plugins.TypedName {
+	return plugins.TypedName{Type: "other", Name: "other"}
+}
+
+func TestResolveScorers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handle := plugins.NewEppHandle(ctx, nil)
+	cacheScorer := compositetest.NewFixedScorer("cache", nil)
+	loadScorer := compositetest.NewFixedScorer("load", nil)
+	handle.AddPlugin("cache", cacheScorer)
+	handle.AddPlugin("load", loadScorer)
+	handle.AddPlugin("other", otherPlugin{})
+
+	scorers, err := ResolveScorers(handle, []string{"load", "cache"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]framework.Scorer{loadScorer, cacheScorer}, scorers, cmp.AllowUnexported(compositetest.FixedScorer{})); diff != "" {
+		t.Errorf("Unexpected scorers (-want +got): %v", diff)
+	}
+
+	for _, pluginRef := range []string{"unknown", "other"} {
+		if _, err := ResolveScorers(handle, []string{"cache", pluginRef}); err == nil {
+			t.Errorf("ResolveScorers() of '%s' succeeded, want an error", pluginRef)
+		}
+	}
+}
+
+func TestWeightedScore(t *testing.T) {
+	ctx := context.Background()
+
+	podA, podB := compositetest.NewPod("pod-a"), compositetest.NewPod("pod-b")
+	pods := []types.Pod{podA, podB}
+
+	tests := []struct {
+		name      string
+		weights   []float64
+		want      map[types.Pod]float64
+		wantCalls []int
+	}{
+		{name: "equal weights", weights: []float64{1, 1}, want: map[types.Pod]float64{podA: 0.5, podB: 0.5}, wantCalls: []int{1, 1}},
+		{name: "unequal weights", weights: []float64{1, 3}, want: map[types.Pod]float64{podA: 0.25, podB: 0.75}, wantCalls: []int{1, 1}},
+		{name: "zero weight", weights: []float64{2, 0}, want: map[types.Pod]float64{podA: 1, podB: 0}, wantCalls: []int{1, 0}},
+		{name: "zero weights", weights: []float64{0, 0}, want: map[types.Pod]float64{podA: 0, podB: 0}, wantCalls: []int{0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the scores out of range are clamped
+			cacheScorer := compositetest.NewFixedScorer("cache", map[string]float64{"pod-a": 1, "pod-b": -1})
+			loadScorer := compositetest.NewFixedScorer("load", map[string]float64{"pod-a": 0, "pod-b": 2})
+
+			got := WeightedScore(ctx, nil, &types.LLMRequest{}, pods, []framework.Scorer{cacheScorer, loadScorer}, test.weights)
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Unexpected scores (-want +got): %v", diff)
+			}
+			if diff := cmp.Diff(test.wantCalls, []int{cacheScorer.Calls, loadScorer.Calls}); diff != "" {
+				t.Errorf("Unexpected scorer calls (-want +got): %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compositetest contains the test doubles of the scorers combined by the composite plugins,
+// e.g. the weighted and adaptive scorers and the Pareto picker.
+package compositetest
+
+import (
+	"context"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// compile-time type assertions
+var _ framework.Scorer = &FixedScorer{}
+
+// FixedScorer is a scorer returning the same scores for every request, and counting its calls
+type FixedScorer struct {
+	typedName plugins.TypedName
+
+	// Scores are the scores of the pods by name, the other pods are scored 0
+	Scores map[string]float64
+
+	// Calls is the number of times the pods were scored
+	Calls int
+}
+
+// NewFixedScorer returns a FixedScorer with the given scores, named after its type
+func NewFixedScorer(scorerType string, scores map[string]float64) *FixedScorer {
+	return &FixedScorer{typedName: plugins.TypedName{Type: scorerType, Name: scorerType}, Scores: scores}
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *FixedScorer) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// Score returns the fixed scores of the given pods.
+func (s *FixedScorer) Score(_ context.Context, _ *types.CycleState, _ *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	s.Calls++
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = s.Scores[pod.GetPod().NamespacedName.Name]
+	}
+	return scoredPods
+}
+
+// NewPod returns a pod of the given name in the default namespace, without metrics
+func NewPod(name string) types.Pod {
+	return &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// CanaryProberType is the type of the CanaryProber
+	CanaryProberType = "canary-prober"
+
+	defaultCanaryInterval = 30 * time.Second
+	defaultCanaryTimeout  = 10 * time.Second
+	defaultCanaryPrompt   = "ping"
+
+	canaryPath = "/v1/completions"
+
+	canaryResultSuccess = "success"
+	canaryResultFailure = "failure"
+)
+
+// CanaryProberParameters defines the parameters of the CanaryProber
+type CanaryProberParameters struct {
+	// GatewayURL is the URL of the gateway serving the pool, e.g. http://inference-gateway.llm-d.svc
+	GatewayURL string `json:"gatewayURL"`
+
+	// Model is the model of the canary completions
+	Model string `json:"model"`
+
+	// Prompt is the prompt of the canary completions, long enough to be disaggregated to validate the
+	// P/D path. Defaults to "ping".
+	Prompt string `json:"prompt"`
+
+	// Headers are added to the canary completions, e.g. the authorization of the gateway
+	Headers map[string]string `json:"headers"`
+
+	// PoolName is the value of the pool label of the metrics, e.g. the name of the InferencePool
+	PoolName string `json:"poolName"`
+
+	// Interval is the interval the canary completions are sent at.
+	// This field accepts duration strings like "30s", "1m". Defaults to "30s".
+	Interval string `json:"interval"`
+
+	// Timeout is the time a canary completion has to complete before it fails.
+	// This field accepts duration strings like "10s", "1m". Defaults to "10s".
+	Timeout string `json:"timeout"`
+}
+
+// compile-time type assertion
+var _ plugins.Plugin = &CanaryProber{}
+
+// CanaryProberFactory defines the factory function for the CanaryProber
+func CanaryProberFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := CanaryProberParameters{Prompt: defaultCanaryPrompt}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' plugin - %w", CanaryProberType, err)
+		}
+	}
+
+	if parameters.GatewayURL == "" {
+		return nil, fmt.Errorf("invalid configuration for '%s' plugin: 'gatewayURL' is required", CanaryProberType)
+	}
+	if _, err := url.ParseRequestURI(parameters.GatewayURL); err != nil {
+		return nil, fmt.Errorf("invalid gatewayURL: '%s' - %w", parameters.GatewayURL, err)
+	}
+	if parameters.Model == "" {
+		return nil, fmt.Errorf("invalid configuration for '%s' plugin: 'model' is required", CanaryProberType)
+	}
+
+	interval, err := parseCanaryDuration("interval", parameters.Interval, defaultCanaryInterval)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseCanaryDuration("timeout", parameters.Timeout, defaultCanaryTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: timeout}
+	prober := NewCanaryProber(client, parameters.GatewayURL, parameters.Model, parameters.Prompt,
+		parameters.Headers, parameters.PoolName).WithName(name)
+	if err := prober.register(metrics.Registry); err != nil {
+		return nil, fmt.Errorf("failed to register the metrics of the '%s' plugin - %w", CanaryProberType, err)
+	}
+	go prober.Run(handle.Context(), interval)
+	return prober, nil
+}
+
+func parseCanaryDuration(parameter string, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be a positive duration, got '%s'", parameter, value)
+	}
+	return duration, nil
+}
+
+// NewCanaryProber initializes a new CanaryProber and returns its pointer.
+// The canary completions are sent with the given client to the gateway URL.
+func NewCanaryProber(client *http.Client, gatewayURL string, model string, prompt string, headers map[string]string,
+	poolName string) *CanaryProber {
+	return &CanaryProber{
+		typedName: plugins.TypedName{Type: CanaryProberType},
+		client:    client,
+		url:       strings.TrimSuffix(gatewayURL, "/") + canaryPath,
+		model:     model,
+		prompt:    prompt,
+		headers:   headers,
+		poolName:  poolName,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "llm_d_epp_canary_requests_total",
+			Help: "Number of canary completions sent through the gateway, by pool and result.",
+		}, []string{"pool", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "llm_d_epp_canary_duration_seconds",
+			Help:    "Duration of the successful canary completions sent through the gateway, by pool.",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"pool"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "llm_d_epp_canary_last_success_timestamp_seconds",
+			Help: "Time of the last successful canary completion sent through the gateway, by pool.",
+		}, []string{"pool"}),
+	}
+}
+
+// CanaryProber periodically sends a tiny canary completion through the gateway, which goes through the
+// full gateway, epp, sidecar and engine path, and exports its result and latency. Broken wiring, e.g. of
+// the P/D protocol, is then detected before the requests of the users fail.
+type CanaryProber struct {
+	typedName plugins.TypedName
+	client    *http.Client
+	url       string
+	model     string
+	prompt    string
+	headers   map[string]string
+	poolName  string
+
+	requests    *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	lastSuccess *prometheus.GaugeVec
+}
+
+// TypedName returns the typed name of the plugin.
+func (p *CanaryProber) TypedName() plugins.TypedName {
+	return p.typedName
+}
+
+// WithName sets the name of the plugin.
+func (p *CanaryProber) WithName(name string) *CanaryProber {
+	p.typedName.Name = name
+	return p
+}
+
+// register registers the metrics of the prober
+func (p *CanaryProber) register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{p.requests, p.duration, p.lastSuccess} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run sends a canary completion every interval, until the context is done
+func (p *CanaryProber) Run(ctx context.Context, interval time.Duration) {
+	logger := log.FromContext(ctx).WithName(p.typedName.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := p.Probe(ctx); err != nil && ctx.Err() == nil {
+			logger.Error(err, "canary completion failed", "url", p.url, "model", p.model)
+		}
+	}
+}
+
+// Probe sends a canary completion and records its result. It fails unless the completion succeeded
+// with at least one choice.
+func (p *CanaryProber) Probe(ctx context.Context) error {
+	start := time.Now()
+	err := p.complete(ctx)
+	if err != nil {
+		p.requests.WithLabelValues(p.poolName, canaryResultFailure).Inc()
+		return err
+	}
+
+	p.requests.WithLabelValues(p.poolName, canaryResultSuccess).Inc()
+	p.duration.WithLabelValues(p.poolName).Observe(time.Since(start).Seconds())
+	p.lastSuccess.WithLabelValues(p.poolName).SetToCurrentTime()
+	log.FromContext(ctx).V(logutil.TRACE).Info("canary completion succeeded", "url", p.url, "duration", time.Since(start))
+	return nil
+}
+
+// complete sends a canary completion generating a single token, and checks its response
+func (p *CanaryProber) complete(ctx context.Context) error {
+	body, err := json.Marshal(map[string]any{"model": p.model, "prompt": p.prompt, "max_tokens": 1})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:all
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, payload)
+	}
+
+	var completion struct {
+		Choices []json.RawMessage `json:"choices"`
+	}
+	if err := json.Unmarshal(payload, &completion); err != nil {
+		return fmt.Errorf("invalid completion response - %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return errors.New("completion response without choices")
+	}
+	return nil
+}
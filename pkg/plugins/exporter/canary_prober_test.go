@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCanaryProber(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusCode  int
+		response    string
+		wantErr     bool
+		wantSuccess float64
+		wantFailure float64
+	}{
+		{
+			name:        "successful completion",
+			statusCode:  http.StatusOK,
+			response:    `{"choices":[{"text":"pong"}]}`,
+			wantSuccess: 1,
+		},
+		{
+			name:        "failed completion",
+			statusCode:  http.StatusServiceUnavailable,
+			response:    `{"error":"no healthy upstream"}`,
+			wantErr:     true,
+			wantFailure: 1,
+		},
+		{
+			name:        "completion without choices",
+			statusCode:  http.StatusOK,
+			response:    `{"choices":[]}`,
+			wantErr:     true,
+			wantFailure: 1,
+		},
+		{
+			name:        "invalid completion",
+			statusCode:  http.StatusOK,
+			response:    `not json`,
+			wantErr:     true,
+			wantFailure: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var received map[string]any
+			var authorization string
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != canaryPath {
+					t.Errorf("unexpected canary path %s", r.URL.Path)
+				}
+				authorization = r.Header.Get("Authorization")
+				_ = json.NewDecoder(r.Body).Decode(&received) //nolint:all
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.response)) //nolint:all
+			}))
+			defer gateway.Close()
+
+			prober := NewCanaryProber(gateway.Client(), gateway.URL+"/", "model", "ping",
+				map[string]string{"Authorization": "Bearer token"}, "pool")
+			err := prober.Probe(context.Background())
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Probe() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			want := map[string]any{"model": "model", "prompt": "ping", "max_tokens": float64(1)}
+			if diff := cmp.Diff(want, received); diff != "" {
+				t.Errorf("unexpected canary completion (-want +got):\n%s", diff)
+			}
+			if authorization != "Bearer token" {
+				t.Errorf("expected the configured headers, got authorization '%s'", authorization)
+			}
+			if got := testutil.ToFloat64(prober.requests.WithLabelValues("pool", canaryResultSuccess)); got != test.wantSuccess {
+				t.Errorf("expected %v successful canaries, got %v", test.wantSuccess, got)
+			}
+			if got := testutil.ToFloat64(prober.requests.WithLabelValues("pool", canaryResultFailure)); got != test.wantFailure {
+				t.Errorf("expected %v failed canaries, got %v", test.wantFailure, got)
+			}
+			if got := testutil.CollectAndCount(prober.lastSuccess); got != int(test.wantSuccess) {
+				t.Errorf("expected %v last success timestamps, got %d", test.wantSuccess, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/filter"
+)
+
+const (
+	// DiscoveryMetricsType is the type of the DiscoveryMetrics
+	DiscoveryMetricsType = "discovery-metrics"
+
+	// defaultStalenessThreshold matches the default metrics staleness threshold of the epp
+	defaultStalenessThreshold = 2 * time.Second
+
+	// roleNone labels the endpoints without the llm-d role label
+	roleNone = "none"
+)
+
+var (
+	discoveryEndpointsDesc = prometheus.NewDesc("llm_d_epp_discovery_endpoints",
+		"Number of endpoints known by the epp, by pool and llm-d role.",
+		[]string{"pool", "role"}, nil)
+	discoveryFreshEndpointsDesc = prometheus.NewDesc("llm_d_epp_discovery_fresh_endpoints",
+		"Number of endpoints whose metrics were refreshed within the staleness threshold, by pool and llm-d role.",
+		[]string{"pool", "role"}, nil)
+	discoveryLagDesc = prometheus.NewDesc("llm_d_epp_discovery_lag_seconds",
+		"Age of the oldest metrics of the endpoints, by pool and llm-d role. The endpoints never scraped are not included.",
+		[]string{"pool", "role"}, nil)
+)
+
+// DiscoveryMetricsParameters defines the parameters of the DiscoveryMetrics
+type DiscoveryMetricsParameters struct {
+	// PoolName is the value of the pool label of the metrics, e.g. the name of the InferencePool
+	PoolName string `json:"poolName"`
+
+	// StalenessThreshold is the age after which the metrics of an endpoint are stale.
+	// This field accepts duration strings like "2s", "1m". Defaults to "2s".
+	StalenessThreshold string `json:"stalenessThreshold"`
+}
+
+// compile-time type assertions
+var _ plugins.Plugin = &DiscoveryMetrics{}
+var _ prometheus.Collector = &DiscoveryMetrics{}
+
+// DiscoveryMetricsFactory defines the factory function for the DiscoveryMetrics
+func DiscoveryMetricsFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := DiscoveryMetricsParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' plugin - %w", DiscoveryMetricsType, err)
+		}
+	}
+
+	stalenessThreshold := defaultStalenessThreshold
+	if parameters.StalenessThreshold != "" {
+		threshold, err := time.ParseDuration(parameters.StalenessThreshold)
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid stalenessThreshold: must be a positive duration, got '%s'", parameters.StalenessThreshold)
+		}
+		stalenessThreshold = threshold
+	}
+
+	discoveryMetrics := NewDiscoveryMetrics(parameters.PoolName, stalenessThreshold, handle.PodList).WithName(name)
+	if err := metrics.Registry.Register(discoveryMetrics); err != nil {
+		return nil, fmt.Errorf("failed to register the metrics of the '%s' plugin - %w", DiscoveryMetricsType, err)
+	}
+	return discoveryMetrics, nil
+}
+
+// NewDiscoveryMetrics initializes a new DiscoveryMetrics and returns its pointer.
+// The endpoints are listed with the given function, e.g. the PodList of the plugins handle.
+func NewDiscoveryMetrics(poolName string, stalenessThreshold time.Duration,
+	podList func(func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics) *DiscoveryMetrics {
+	return &DiscoveryMetrics{
+		typedName:          plugins.TypedName{Type: DiscoveryMetricsType},
+		poolName:           poolName,
+		stalenessThreshold: stalenessThreshold,
+		podList:            podList,
+	}
+}
+
+// DiscoveryMetrics exports the health of the endpoint discovery of the epp, so that the scheduling
+// quality can be correlated with stale endpoints: the number of endpoints known, the number of
+// endpoints with fresh metrics, and the age of the oldest metrics. The metrics are computed when
+// scraped, by llm-d role.
+type DiscoveryMetrics struct {
+	typedName          plugins.TypedName
+	poolName           string
+	stalenessThreshold time.Duration
+	podList            func(func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics
+}
+
+// TypedName returns the typed name of the plugin.
+func (m *DiscoveryMetrics) TypedName() plugins.TypedName {
+	return m.typedName
+}
+
+// WithName sets the name of the plugin.
+func (m *DiscoveryMetrics) WithName(name string) *DiscoveryMetrics {
+	m.typedName.Name = name
+	return m
+}
+
+// Describe implements prometheus.Collector
+func (m *DiscoveryMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- discoveryEndpointsDesc
+	ch <- discoveryFreshEndpointsDesc
+	ch <- discoveryLagDesc
+}
+
+// discoveryStats are the discovery statistics of the endpoints of a role
+type discoveryStats struct {
+	endpoints      int
+	freshEndpoints int
+	lag            time.Duration
+}
+
+// Collect implements prometheus.Collector
+func (m *DiscoveryMetrics) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	stats := map[string]*discoveryStats{}
+	for _, pod := range m.podList(func(backendmetrics.PodMetrics) bool { return true }) {
+		role := roleNone
+		if info := pod.GetPod(); info != nil && info.Labels[filter.RoleLabel] != "" {
+			role = info.Labels[filter.RoleLabel]
+		}
+		roleStats, ok := stats[role]
+		if !ok {
+			roleStats = &discoveryStats{}
+			stats[role] = roleStats
+		}
+
+		roleStats.endpoints++
+		podMetrics := pod.GetMetrics()
+		if podMetrics == nil || podMetrics.UpdateTime.IsZero() {
+			continue // never scraped
+		}
+		age := now.Sub(podMetrics.UpdateTime)
+		if age <= m.stalenessThreshold {
+			roleStats.freshEndpoints++
+		}
+		roleStats.lag = max(roleStats.lag, age)
+	}
+
+	for role, roleStats := range stats {
+		ch <- prometheus.MustNewConstMetric(discoveryEndpointsDesc, prometheus.GaugeValue, float64(roleStats.endpoints), m.poolName, role)
+		ch <- prometheus.MustNewConstMetric(discoveryFreshEndpointsDesc, prometheus.GaugeValue, float64(roleStats.freshEndpoints), m.poolName, role)
+		ch <- prometheus.MustNewConstMetric(discoveryLagDesc, prometheus.GaugeValue, roleStats.lag.Seconds(), m.poolName, role)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/filter"
+)
+
+func TestDiscoveryMetrics(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, role string, updateTime time.Time) backendmetrics.PodMetrics {
+		labels := map[string]string{}
+		if role != "" {
+			labels[filter.RoleLabel] = role
+		}
+		return &backendmetrics.FakePodMetrics{
+			Pod:     &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}, Labels: labels},
+			Metrics: &backendmetrics.MetricsState{UpdateTime: updateTime},
+		}
+	}
+	pods := []backendmetrics.PodMetrics{
+		pod("decode-0", filter.RoleDecode, now),
+		pod("decode-1", filter.RoleDecode, now.Add(-time.Minute)),
+		pod("decode-2", filter.RoleDecode, time.Time{}), // never scraped
+		pod("prefill-0", filter.RolePrefill, now),
+		pod("vllm-0", "", now.Add(-30*time.Second)),
+	}
+	discoveryMetrics := NewDiscoveryMetrics("pool", 10*time.Second,
+		func(func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics { return pods })
+
+	expected := `
+# HELP llm_d_epp_discovery_endpoints Number of endpoints known by the epp, by pool and llm-d role.
+# TYPE llm_d_epp_discovery_endpoints gauge
+llm_d_epp_discovery_endpoints{pool="pool",role="decode"} 3
+llm_d_epp_discovery_endpoints{pool="pool",role="none"} 1
+llm_d_epp_discovery_endpoints{pool="pool",role="prefill"} 1
+# HELP llm_d_epp_discovery_fresh_endpoints Number of endpoints whose metrics were refreshed within the staleness threshold, by pool and llm-d role.
+# TYPE llm_d_epp_discovery_fresh_endpoints gauge
+llm_d_epp_discovery_fresh_endpoints{pool="pool",role="decode"} 1
+llm_d_epp_discovery_fresh_endpoints{pool="pool",role="none"} 0
+llm_d_epp_discovery_fresh_endpoints{pool="pool",role="prefill"} 1
+`
+	if err := testutil.CollectAndCompare(discoveryMetrics, strings.NewReader(expected),
+		"llm_d_epp_discovery_endpoints", "llm_d_epp_discovery_fresh_endpoints"); err != nil {
+		t.Error(err)
+	}
+
+	// the lag is the age of the oldest metrics
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(discoveryMetrics)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather the metrics: %v", err)
+	}
+	lags := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "llm_d_epp_discovery_lag_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "role" {
+					lags[label.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	if lags[filter.RoleDecode] < 60 || lags[filter.RoleDecode] > 70 {
+		t.Errorf("unexpected decode lag %v", lags[filter.RoleDecode])
+	}
+	if lags[filter.RolePrefill] > 5 {
+		t.Errorf("unexpected prefill lag %v", lags[filter.RolePrefill])
+	}
+	if lags[roleNone] < 30 || lags[roleNone] > 40 {
+		t.Errorf("unexpected lag %v of the endpoints without role", lags[roleNone])
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+const (
+	// EndpointsPublisherType is the type of the EndpointsPublisher
+	EndpointsPublisherType = "endpoints-publisher"
+
+	defaultPublishInterval = 5 * time.Second
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// EndpointsPublisherParameters defines the parameters of the EndpointsPublisher
+type EndpointsPublisherParameters struct {
+	// ConfigMapName is the name of the ConfigMap the endpoint set is published to
+	ConfigMapName string `json:"configMapName"`
+
+	// Namespace is the namespace of the ConfigMap. Defaults to the namespace of the epp.
+	Namespace string `json:"namespace"`
+
+	// SigningKeyFile is the file of the key the endpoint set is signed with, shared with the sidecars
+	SigningKeyFile string `json:"signingKeyFile"`
+
+	// Interval is the interval the endpoint set is published at, when it changed.
+	// This field accepts duration strings like "5s", "1m". Defaults to "5s".
+	Interval string `json:"interval"`
+}
+
+// compile-time type assertion
+var _ plugins.Plugin = &EndpointsPublisher{}
+
+// EndpointsPublisherFactory defines the factory function for the EndpointsPublisher
+func EndpointsPublisherFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := EndpointsPublisherParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' plugin - %w", EndpointsPublisherType, err)
+		}
+	}
+
+	if parameters.ConfigMapName == "" {
+		return nil, fmt.Errorf("invalid configuration for '%s' plugin: 'configMapName' is required", EndpointsPublisherType)
+	}
+	if parameters.SigningKeyFile == "" {
+		return nil, fmt.Errorf("invalid configuration for '%s' plugin: 'signingKeyFile' is required", EndpointsPublisherType)
+	}
+	signingKey, err := os.ReadFile(parameters.SigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signing key of the '%s' plugin - %w", EndpointsPublisherType, err)
+	}
+
+	interval := defaultPublishInterval
+	if parameters.Interval != "" {
+		interval, err = time.ParseDuration(parameters.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval: must be a positive duration, got '%s'", parameters.Interval)
+		}
+	}
+
+	namespace := parameters.Namespace
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for '%s' plugin: 'namespace' is required outside of a cluster", EndpointsPublisherType)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	config, err := ctrlconfig.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the Kubernetes config of the '%s' plugin - %w", EndpointsPublisherType, err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Kubernetes client of the '%s' plugin - %w", EndpointsPublisherType, err)
+	}
+
+	publisher := NewEndpointsPublisher(client, namespace, parameters.ConfigMapName, signingKey, handle.PodList).WithName(name)
+	go publisher.Run(handle.Context(), interval)
+	return publisher, nil
+}
+
+// NewEndpointsPublisher initializes a new EndpointsPublisher and returns its pointer.
+// The endpoint set is listed with the given function, e.g. the PodList of the plugins handle.
+func NewEndpointsPublisher(client kubernetes.Interface, namespace string, configMapName string, signingKey []byte,
+	podList func(func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics) *EndpointsPublisher {
+	return &EndpointsPublisher{
+		typedName:     plugins.TypedName{Type: EndpointsPublisherType},
+		client:        client,
+		namespace:     namespace,
+		configMapName: configMapName,
+		signingKey:    signingKey,
+		podList:       podList,
+	}
+}
+
+// EndpointsPublisher publishes the endpoint set of the pool to a ConfigMap, signed with a key shared
+// with the sidecars. The sidecars then build their SSRF protection allowlist from the ConfigMap,
+// instead of each of them watching the pool and its pods on the API server.
+type EndpointsPublisher struct {
+	typedName     plugins.TypedName
+	client        kubernetes.Interface
+	namespace     string
+	configMapName string
+	signingKey    []byte
+	podList       func(func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics
+
+	published []string // the endpoint set last published, only accessed by Run
+}
+
+// TypedName returns the typed name of the plugin.
+func (p *EndpointsPublisher) TypedName() plugins.TypedName {
+	return p.typedName
+}
+
+// WithName sets the name of the plugin.
+func (p *EndpointsPublisher) WithName(name string) *EndpointsPublisher {
+	p.typedName.Name = name
+	return p
+}
+
+// Run publishes the endpoint set every interval when it changed, until the context is done
+func (p *EndpointsPublisher) Run(ctx context.Context, interval time.Duration) {
+	logger := log.FromContext(ctx).WithName(p.typedName.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Publish(ctx); err != nil {
+			logger.Error(err, "failed to publish the endpoint set", "configMap", p.configMapName)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish writes the current endpoint set to the ConfigMap, unless it was already published
+func (p *EndpointsPublisher) Publish(ctx context.Context) error {
+	endpoints := p.endpoints()
+	if p.published != nil && slices.Equal(endpoints, p.published) {
+		return nil
+	}
+
+	payload, err := json.Marshal(common.PublishedEndpoints{Endpoints: endpoints, PublishedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		common.PublishedEndpointsKey: string(payload),
+		common.PublishedSignatureKey: common.SignEndpoints(payload, p.signingKey),
+	}
+
+	configMaps := p.client.CoreV1().ConfigMaps(p.namespace)
+	configMap, err := configMaps.Get(ctx, p.configMapName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: p.configMapName, Namespace: p.namespace},
+			Data:       data,
+		}, metav1.CreateOptions{})
+	case err == nil:
+		configMap.Data = data
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	p.published = endpoints
+	log.FromContext(ctx).V(logutil.DEBUG).Info("published the endpoint set", "configMap", p.configMapName, "endpoints", len(endpoints))
+	return nil
+}
+
+// endpoints returns the sorted addresses and names of the pods of the pool
+func (p *EndpointsPublisher) endpoints() []string {
+	endpoints := []string{}
+	for _, pod := range p.podList(func(backendmetrics.PodMetrics) bool { return true }) {
+		info := pod.GetPod()
+		if info == nil || info.Address == "" {
+			continue
+		}
+		endpoints = append(endpoints, info.Address)
+		if info.PodName != "" {
+			endpoints = append(endpoints, info.PodName)
+		} else if info.NamespacedName.Name != "" {
+			endpoints = append(endpoints, info.NamespacedName.Name)
+		}
+	}
+	slices.Sort(endpoints)
+	return slices.Compact(endpoints)
+}
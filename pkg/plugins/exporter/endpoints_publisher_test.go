@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+func TestEndpointsPublisher(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("secret")
+	pods := []backendmetrics.PodMetrics{
+		&backendmetrics.FakePodMetrics{Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "vllm-1"}, PodName: "vllm-1", Address: "10.0.0.2"}},
+		&backendmetrics.FakePodMetrics{Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "vllm-0-rank-1"}, PodName: "vllm-0", Address: "10.0.0.1"}},
+		&backendmetrics.FakePodMetrics{Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "vllm-0-rank-0"}, PodName: "vllm-0", Address: "10.0.0.1"}},
+	}
+	podList := func(func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics { return pods }
+
+	client := fake.NewClientset()
+	publisher := NewEndpointsPublisher(client, "default", "endpoints", key, podList)
+
+	readPublished := func() []string {
+		t.Helper()
+		configMap, err := client.CoreV1().ConfigMaps("default").Get(ctx, "endpoints", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get the published ConfigMap: %v", err)
+		}
+		payload := []byte(configMap.Data[common.PublishedEndpointsKey])
+		if !common.VerifyEndpoints(payload, configMap.Data[common.PublishedSignatureKey], key) {
+			t.Fatalf("invalid signature of the published endpoints")
+		}
+		if common.VerifyEndpoints(payload, configMap.Data[common.PublishedSignatureKey], []byte("other")) {
+			t.Fatalf("the signature of the published endpoints is valid with another key")
+		}
+		var published common.PublishedEndpoints
+		if err := json.Unmarshal(payload, &published); err != nil {
+			t.Fatalf("failed to parse the published endpoints: %v", err)
+		}
+		return published.Endpoints
+	}
+
+	if err := publisher.Publish(ctx); err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"10.0.0.1", "10.0.0.2", "vllm-0", "vllm-1"}, readPublished()); diff != "" {
+		t.Errorf("unexpected published endpoints (-want +got):\n%s", diff)
+	}
+
+	// the unchanged endpoint set is not published again
+	actions := len(client.Actions())
+	if err := publisher.Publish(ctx); err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	if len(client.Actions()) != actions {
+		t.Errorf("unexpected API calls for an unchanged endpoint set: %v", client.Actions()[actions:])
+	}
+
+	pods = pods[:1]
+	if err := publisher.Publish(ctx); err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"10.0.0.2", "vllm-1"}, readPublished()); diff != "" {
+		t.Errorf("unexpected published endpoints (-want +got):\n%s", diff)
+	}
+	var updated bool
+	for _, action := range client.Actions() {
+		if _, ok := action.(k8stesting.UpdateAction); ok && action.GetResource().Resource == "configmaps" {
+			updated = true
+		}
+	}
+	if !updated {
+		t.Errorf("expected the existing ConfigMap to be updated")
+	}
+}
+
+func TestEndpointsPublisherFactoryValidation(t *testing.T) {
+	tests := map[string]string{
+		"missing configMapName":  `{"signingKeyFile": "/tmp/key"}`,
+		"missing signingKeyFile": `{"configMapName": "endpoints"}`,
+		"missing signing key":    `{"configMapName": "endpoints", "signingKeyFile": "/does/not/exist"}`,
+	}
+	for name, parameters := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := EndpointsPublisherFactory("publisher", json.RawMessage(parameters), nil); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
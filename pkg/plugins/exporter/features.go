@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"time"
+)
+
+// minPromptLengthBucket is the upper bound of the smallest prompt length bucket
+const minPromptLengthBucket = 64
+
+// SchedulingFeatures is the anonymized record of a scheduling decision and its outcome.
+// It holds no prompt content nor client identifiers, only the features needed to train
+// scheduling policies offline.
+type SchedulingFeatures struct {
+	// Timestamp is the time the request was sent to the selected pod
+	Timestamp time.Time `json:"timestamp"`
+
+	// Model is the target model of the request
+	Model string `json:"model"`
+
+	// PromptLengthBucket is the smallest power of two, in bytes, holding the prompt
+	PromptLengthBucket int `json:"promptLengthBucket"`
+
+	// CandidatePods is the number of pods the scheduler scored
+	CandidatePods int `json:"candidatePods"`
+
+	// PredictedCacheHitRatio is the prompt fraction the prefix cache predicted
+	// to be cached on the decode pod, between 0 and 1
+	PredictedCacheHitRatio float64 `json:"predictedCacheHitRatio"`
+
+	// DecodePod is the pod selected by the primary profile
+	DecodePod string `json:"decodePod"`
+
+	// PrefillPod is the pod selected by the prefill profile, empty when prefill was not disaggregated
+	PrefillPod string `json:"prefillPod,omitempty"`
+
+	// Streaming indicates whether the response was streamed
+	Streaming bool `json:"streaming"`
+
+	// TimeToFirstByteMs is the time between sending the request and receiving the response headers
+	TimeToFirstByteMs float64 `json:"timeToFirstByteMs"`
+
+	// LatencyMs is the time between sending the request and receiving the complete response
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// promptLengthBucket returns the smallest power of two, starting at minPromptLengthBucket,
+// greater or equal to the given length
+func promptLengthBucket(length int) int {
+	bucket := minPromptLengthBucket
+	for bucket < length {
+		bucket <<= 1
+	}
+	return bucket
+}
+
+// predictedCacheHitRatio returns the cached fraction of a prompt given its number of prefix cache block hits
+func predictedCacheHitRatio(hitBlocks int, hashBlockSize int, promptLength int) float64 {
+	if promptLength <= 0 || hitBlocks <= 0 {
+		return 0
+	}
+	return min(float64(hitBlocks*hashBlockSize)/float64(promptLength), 1)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/prompt"
+)
+
+const (
+	// SchedulingFeaturesExporterType is the type of the SchedulingFeaturesExporter
+	SchedulingFeaturesExporterType = "scheduling-features-exporter"
+
+	defaultPrefillProfile = "prefill"
+
+	// defaultRequestTimeout is the time after which a request without a response is dropped
+	defaultRequestTimeout = 5 * time.Minute
+
+	sinkShutdownTimeout = 10 * time.Second
+)
+
+// SchedulingFeaturesExporterParameters defines the parameters of the SchedulingFeaturesExporter
+type SchedulingFeaturesExporterParameters struct {
+	// Sink is where the features are exported, either "stdout", "otlp" or "sqlite". Defaults to "stdout".
+	Sink string `json:"sink"`
+
+	// Path is the file of the SQLite database. Defaults to "scheduling-features.db".
+	Path string `json:"path"`
+
+	// MaxFileSize is the size, in bytes, after which the SQLite database file is rotated. Defaults to 64MiB.
+	MaxFileSize int64 `json:"maxFileSize"`
+
+	// MaxFiles is the number of SQLite database files kept, the current one included. Defaults to 5.
+	MaxFiles int `json:"maxFiles"`
+
+	// Endpoint is the OTLP gRPC collector URL. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable.
+	Endpoint string `json:"endpoint"`
+
+	// Insecure disables the transport security of the OTLP connection
+	Insecure bool `json:"insecure"`
+
+	// PrefixPluginName is the name of the prefix cache plugin the cache hit prediction is read from.
+	// Defaults to "prefix-cache-scorer".
+	PrefixPluginName string `json:"prefixPluginName"`
+
+	// HashBlockSize is the prefix cache block size, in bytes. Defaults to the prefix cache plugin default.
+	HashBlockSize int `json:"hashBlockSize"`
+
+	// HashingRef is the prefix-hashing plugin the block sizes are read from, instead of HashBlockSize.
+	HashingRef string `json:"hashingRef"`
+
+	// PrefillProfile is the name of the prefill scheduling profile. Defaults to "prefill".
+	PrefillProfile string `json:"prefillProfile"`
+
+	// RequestTimeout is the time after which a request without a complete response is dropped.
+	// This field accepts duration strings like "30s", "1m", "2h".
+	RequestTimeout string `json:"requestTimeout"`
+}
+
+// scoringState holds the features observed while scoring a request
+type scoringState struct {
+	candidatePods int
+	prefixHits    map[string]int // pod name -> matched prefix blocks
+}
+
+// Clone implements the plugins.StateData interface
+func (s *scoringState) Clone() plugins.StateData {
+	prefixHits := make(map[string]int, len(s.prefixHits))
+	for pod, hits := range s.prefixHits {
+		prefixHits[pod] = hits
+	}
+	return &scoringState{candidatePods: s.candidatePods, prefixHits: prefixHits}
+}
+
+// pendingRequest holds the features of a request waiting for its response
+type pendingRequest struct {
+	features *SchedulingFeatures
+	sentAt   time.Time
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &SchedulingFeaturesExporter{}
+var _ requestcontrol.PreRequest = &SchedulingFeaturesExporter{}
+var _ requestcontrol.ResponseReceived = &SchedulingFeaturesExporter{}
+var _ requestcontrol.ResponseComplete = &SchedulingFeaturesExporter{}
+
+// SchedulingFeaturesExporterFactory defines the factory function for the SchedulingFeaturesExporter
+func SchedulingFeaturesExporterFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := SchedulingFeaturesExporterParameters{
+		Sink:             SinkStdout,
+		Path:             defaultSQLitePath,
+		MaxFileSize:      defaultSQLiteMaxFileSize,
+		MaxFiles:         defaultSQLiteMaxFiles,
+		PrefixPluginName: prefix.PrefixCachePluginType,
+		HashBlockSize:    prefix.DefaultBlockSize,
+		PrefillProfile:   defaultPrefillProfile,
+	}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' plugin - %w", SchedulingFeaturesExporterType, err)
+		}
+	}
+
+	if parameters.HashBlockSize <= 0 {
+		return nil, fmt.Errorf("invalid hashBlockSize: must be > 0, got %d", parameters.HashBlockSize)
+	}
+
+	requestTimeout := defaultRequestTimeout
+	if parameters.RequestTimeout != "" {
+		timeout, err := time.ParseDuration(parameters.RequestTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid requestTimeout: must be a positive duration, got '%s'", parameters.RequestTimeout)
+		}
+		requestTimeout = timeout
+	}
+
+	ctx := handle.Context()
+	var sink featureSink
+	switch parameters.Sink {
+	case SinkStdout:
+		sink = newWriterSink(os.Stdout)
+	case SinkOTLP:
+		otlpSink, err := newOTLPLogSink(ctx, parameters.Endpoint, parameters.Insecure)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the OTLP sink of the '%s' plugin - %w", SchedulingFeaturesExporterType, err)
+		}
+		sink = otlpSink
+	case SinkSQLite:
+		if parameters.MaxFileSize <= 0 {
+			return nil, fmt.Errorf("invalid maxFileSize: must be > 0, got %d", parameters.MaxFileSize)
+		}
+		if parameters.MaxFiles <= 0 {
+			return nil, fmt.Errorf("invalid maxFiles: must be > 0, got %d", parameters.MaxFiles)
+		}
+		sqliteSink, err := newSQLiteSink(parameters.Path, parameters.MaxFileSize, parameters.MaxFiles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the SQLite sink of the '%s' plugin - %w", SchedulingFeaturesExporterType, err)
+		}
+		sink = sqliteSink
+	default:
+		return nil, fmt.Errorf("invalid sink: must be '%s', '%s' or '%s', got '%s'", SinkStdout, SinkOTLP, SinkSQLite,
+			parameters.Sink)
+	}
+
+	var prefixHashing *hashing.PrefixHashing
+	if parameters.HashingRef != "" {
+		var ok bool
+		prefixHashing, ok = handle.Plugin(parameters.HashingRef).(*hashing.PrefixHashing)
+		if !ok {
+			return nil, fmt.Errorf("the '%s' plugin references '%s' which is not a %s plugin defined before it",
+				SchedulingFeaturesExporterType, parameters.HashingRef, hashing.PrefixHashingType)
+		}
+	}
+
+	exporter := NewSchedulingFeaturesExporter(ctx, sink, parameters.PrefixPluginName, parameters.HashBlockSize,
+		parameters.PrefillProfile, requestTimeout).WithName(name)
+	exporter.hashing = prefixHashing
+	return exporter, nil
+}
+
+// NewSchedulingFeaturesExporter initializes a new SchedulingFeaturesExporter and returns its pointer.
+// The sink is shut down when the context is done.
+func NewSchedulingFeaturesExporter(ctx context.Context, sink featureSink, prefixPluginName string, hashBlockSize int,
+	prefillProfile string, requestTimeout time.Duration) *SchedulingFeaturesExporter {
+	pendingRequests := ttlcache.New[string, *pendingRequest](
+		ttlcache.WithTTL[string, *pendingRequest](requestTimeout),
+		ttlcache.WithDisableTouchOnHit[string, *pendingRequest](),
+	)
+
+	exporter := &SchedulingFeaturesExporter{
+		typedName:             plugins.TypedName{Type: SchedulingFeaturesExporterType},
+		prefixPluginTypedName: plugins.TypedName{Type: prefix.PrefixCachePluginType, Name: prefixPluginName},
+		hashBlockSize:         hashBlockSize,
+		prefillProfile:        prefillProfile,
+		sink:                  sink,
+		pluginState:           plugins.NewPluginState(ctx),
+		pendingRequests:       pendingRequests,
+	}
+
+	go exporter.run(ctx, requestTimeout)
+
+	return exporter
+}
+
+// SchedulingFeaturesExporter streams anonymized scheduling features, along with the realized
+// latency, to a sink for training learned scheduling policies offline.
+// It observes the scheduling cycle as a scorer returning the same score for all pods, so it
+// never influences the pod selection.
+type SchedulingFeaturesExporter struct {
+	typedName             plugins.TypedName
+	prefixPluginTypedName plugins.TypedName
+	hashBlockSize         int
+	hashing               *hashing.PrefixHashing // overrides hashBlockSize when set
+	prefillProfile        string
+	sink                  featureSink
+	pluginState           *plugins.PluginState
+	pendingRequests       *ttlcache.Cache[string, *pendingRequest]
+}
+
+// TypedName returns the typed name of the plugin.
+func (e *SchedulingFeaturesExporter) TypedName() plugins.TypedName {
+	return e.typedName
+}
+
+// WithName sets the name of the plugin.
+func (e *SchedulingFeaturesExporter) WithName(name string) *SchedulingFeaturesExporter {
+	e.typedName.Name = name
+	return e
+}
+
+// blockSize returns the prefix cache block size of the given model
+func (e *SchedulingFeaturesExporter) blockSize(model string) int {
+	if e.hashing != nil {
+		return e.hashing.BlockSize(model)
+	}
+	return e.hashBlockSize
+}
+
+// Score records the number of candidate pods and the prefix cache hits of the request.
+// All pods get a score of 0.
+func (e *SchedulingFeaturesExporter) Score(ctx context.Context, cycleState *types.CycleState, request *types.LLMRequest,
+	pods []types.Pod) map[types.Pod]float64 {
+	stateKey := plugins.StateKey(e.typedName.String())
+
+	// profiles are scored in turn, accumulate the observations of all the profiles
+	state, err := plugins.ReadPluginStateKey[*scoringState](e.pluginState, request.RequestId, stateKey)
+	if err != nil {
+		state = &scoringState{prefixHits: map[string]int{}}
+	}
+	state.candidatePods = max(state.candidatePods, len(pods))
+
+	prefixState, err := types.ReadCycleStateKey[*prefix.SchedulingContextState](cycleState, plugins.StateKey(e.prefixPluginTypedName.String()))
+	if err != nil {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("No prefix cache state found", "error", err)
+	} else {
+		for server, hits := range prefixState.PrefixCacheServers {
+			state.prefixHits[server.String()] = max(hits-1, 0) // The first hit is always the model name
+		}
+	}
+	e.pluginState.Write(request.RequestId, stateKey, state)
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+	}
+	return scoredPods
+}
+
+// PreRequest records the features of the scheduling decision before the request is sent to the selected pod.
+func (e *SchedulingFeaturesExporter) PreRequest(ctx context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	logger := log.FromContext(ctx).V(logutil.DEBUG)
+
+	state, err := plugins.ReadPluginStateKey[*scoringState](e.pluginState, request.RequestId, plugins.StateKey(e.typedName.String()))
+	// drop the scoring state immediately, otherwise it hangs around until it becomes stale
+	e.pluginState.Delete(request.RequestId)
+	if err != nil {
+		logger.Info("No scoring state found, the plugin must be part of the scheduling profiles", "error", err)
+		state = &scoringState{}
+	}
+
+	if schedulingResult == nil {
+		return
+	}
+	primaryProfile := schedulingResult.ProfileResults[schedulingResult.PrimaryProfileName]
+	if primaryProfile == nil || len(primaryProfile.TargetPods) == 0 {
+		logger.Info("No target pod in primary profile")
+		return
+	}
+
+	decodePod := primaryProfile.TargetPods[0].GetPod().NamespacedName.String()
+	promptLength := prompt.Length(request)
+	now := time.Now()
+	features := &SchedulingFeatures{
+		Timestamp:              now,
+		Model:                  request.TargetModel,
+		PromptLengthBucket:     promptLengthBucket(promptLength),
+		CandidatePods:          state.candidatePods,
+		PredictedCacheHitRatio: predictedCacheHitRatio(state.prefixHits[decodePod], e.blockSize(request.TargetModel), promptLength),
+		DecodePod:              decodePod,
+	}
+	if prefillProfile := schedulingResult.ProfileResults[e.prefillProfile]; prefillProfile != nil && len(prefillProfile.TargetPods) > 0 {
+		features.PrefillPod = prefillProfile.TargetPods[0].GetPod().NamespacedName.String()
+	}
+
+	e.pendingRequests.Set(request.RequestId, &pendingRequest{features: features, sentAt: now}, ttlcache.DefaultTTL)
+}
+
+// ResponseReceived records the time to first byte of the request.
+func (e *SchedulingFeaturesExporter) ResponseReceived(_ context.Context, request *types.LLMRequest,
+	response *requestcontrol.Response, _ *backend.Pod) {
+	item := e.pendingRequests.Get(request.RequestId)
+	if item == nil {
+		return
+	}
+	pending := item.Value()
+	pending.features.TimeToFirstByteMs = durationMs(time.Since(pending.sentAt))
+	pending.features.Streaming = response.IsStreaming
+}
+
+// ResponseComplete records the request latency and exports the features.
+func (e *SchedulingFeaturesExporter) ResponseComplete(ctx context.Context, request *types.LLMRequest,
+	response *requestcontrol.Response, _ *backend.Pod) {
+	item, found := e.pendingRequests.GetAndDelete(request.RequestId)
+	if !found {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Request not found in pending requests", "requestId", request.RequestId)
+		return
+	}
+
+	pending := item.Value()
+	pending.features.LatencyMs = durationMs(time.Since(pending.sentAt))
+	pending.features.Streaming = pending.features.Streaming || response.IsStreaming
+	if err := e.sink.Export(ctx, pending.features); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to export scheduling features")
+	}
+}
+
+// run drops the timed out requests and shuts down the sink when the context is done
+func (e *SchedulingFeaturesExporter) run(ctx context.Context, requestTimeout time.Duration) {
+	ticker := time.NewTicker(requestTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), sinkShutdownTimeout)
+			if err := e.sink.Shutdown(shutdownCtx); err != nil {
+				log.FromContext(ctx).Error(err, "Failed to shut down the scheduling features sink")
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			e.pendingRequests.DeleteExpired()
+		}
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestPromptLengthBucket(t *testing.T) {
+	tests := map[int]int{
+		0:    64,
+		1:    64,
+		64:   64,
+		65:   128,
+		1000: 1024,
+		1025: 2048,
+	}
+	for length, want := range tests {
+		if got := promptLengthBucket(length); got != want {
+			t.Errorf("promptLengthBucket(%d) = %d, want %d", length, got, want)
+		}
+	}
+}
+
+func TestPredictedCacheHitRatio(t *testing.T) {
+	tests := []struct {
+		name         string
+		hitBlocks    int
+		promptLength int
+		want         float64
+	}{
+		{name: "no hit", hitBlocks: 0, promptLength: 100, want: 0},
+		{name: "empty prompt", hitBlocks: 2, promptLength: 0, want: 0},
+		{name: "partial hit", hitBlocks: 1, promptLength: 256, want: 0.25},
+		{name: "full hit is capped", hitBlocks: 5, promptLength: 256, want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := predictedCacheHitRatio(test.hitBlocks, 64, test.promptLength); got != test.want {
+				t.Errorf("predictedCacheHitRatio() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSchedulingFeaturesExporter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	decodePod := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "decode"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	otherPod := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "other"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	prefillPod := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "prefill"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+
+	var output bytes.Buffer
+	exporter := NewSchedulingFeaturesExporter(ctx, newWriterSink(&output), prefix.PrefixCachePluginType, 64,
+		defaultPrefillProfile, time.Minute)
+
+	request := &types.LLMRequest{
+		RequestId:   "request-1",
+		TargetModel: "model-a",
+		Body: &types.LLMRequestBody{
+			Completions: &types.CompletionsRequest{Prompt: strings.Repeat("a", 200)},
+		},
+	}
+
+	cycleState := types.NewCycleState()
+	prefixPlugin := plugins.TypedName{Type: prefix.PrefixCachePluginType, Name: prefix.PrefixCachePluginType}
+	cycleState.Write(plugins.StateKey(prefixPlugin.String()), &prefix.SchedulingContextState{
+		PrefixCacheServers: map[prefix.ServerID]int{
+			prefix.ServerID(decodePod.GetPod().NamespacedName): 3, // model name block + 2 prompt blocks
+		},
+	})
+
+	scores := exporter.Score(ctx, cycleState, request, []types.Pod{decodePod, otherPod})
+	if diff := cmp.Diff(map[types.Pod]float64{decodePod: 0, otherPod: 0}, scores); diff != "" {
+		t.Errorf("Unexpected scores (-want +got): %v", diff)
+	}
+
+	exporter.PreRequest(ctx, request, &types.SchedulingResult{
+		PrimaryProfileName: "decode",
+		ProfileResults: map[string]*types.ProfileRunResult{
+			"decode":              {TargetPods: []types.Pod{decodePod}},
+			defaultPrefillProfile: {TargetPods: []types.Pod{prefillPod}},
+		},
+	})
+	exporter.ResponseReceived(ctx, request, &requestcontrol.Response{IsStreaming: true}, decodePod.GetPod())
+	exporter.ResponseComplete(ctx, request, &requestcontrol.Response{IsStreaming: true}, decodePod.GetPod())
+
+	var got SchedulingFeatures
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("failed to parse exported features %q: %v", output.String(), err)
+	}
+	want := SchedulingFeatures{
+		Model:                  "model-a",
+		PromptLengthBucket:     256,
+		CandidatePods:          2,
+		PredictedCacheHitRatio: 0.64,
+		DecodePod:              "default/decode",
+		PrefillPod:             "default/prefill",
+		Streaming:              true,
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(SchedulingFeatures{}, "Timestamp", "TimeToFirstByteMs", "LatencyMs")); diff != "" {
+		t.Errorf("Unexpected features (-want +got): %v", diff)
+	}
+	if got.LatencyMs < got.TimeToFirstByteMs {
+		t.Errorf("expected latency %v to be greater than the time to first byte %v", got.LatencyMs, got.TimeToFirstByteMs)
+	}
+
+	// the request is exported only once
+	output.Reset()
+	exporter.ResponseComplete(ctx, request, &requestcontrol.Response{}, decodePod.GetPod())
+	if output.Len() != 0 {
+		t.Errorf("expected no export for an already completed request, got %q", output.String())
+	}
+}
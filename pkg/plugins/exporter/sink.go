@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+const (
+	// SinkStdout writes the features as JSON lines to the standard output
+	SinkStdout = "stdout"
+
+	// SinkOTLP sends the features as OTLP log records
+	SinkOTLP = "otlp"
+
+	featuresEventName = "llm-d.scheduling.features"
+	featuresScopeName = "github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/exporter"
+)
+
+// featureSink is the destination of the exported scheduling features
+type featureSink interface {
+	// Export sends a single record
+	Export(ctx context.Context, features *SchedulingFeatures) error
+
+	// Shutdown flushes pending records and releases the sink resources
+	Shutdown(ctx context.Context) error
+}
+
+// writerSink writes the features as JSON lines
+type writerSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func newWriterSink(w io.Writer) *writerSink {
+	return &writerSink{encoder: json.NewEncoder(w)}
+}
+
+// Export writes the record as a single JSON line
+func (s *writerSink) Export(_ context.Context, features *SchedulingFeatures) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(features)
+}
+
+// Shutdown is a no-op, records are written synchronously
+func (s *writerSink) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// otlpLogSink sends the features as OTLP log records. Records can be routed
+// to Kafka or any other backend by an OpenTelemetry collector.
+type otlpLogSink struct {
+	provider *sdklog.LoggerProvider
+	logger   log.Logger
+}
+
+// newOTLPLogSink creates an OTLP gRPC log sink. The endpoint and the transport security default
+// to the OTEL_EXPORTER_OTLP_* environment variables when not set.
+func newOTLPLogSink(ctx context.Context, endpoint string, insecure bool) (*otlpLogSink, error) {
+	var opts []otlploggrpc.Option
+	if endpoint != "" {
+		opts = append(opts, otlploggrpc.WithEndpointURL(endpoint))
+	}
+	if insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+
+	exporter, err := otlploggrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithFromEnv(), resource.WithTelemetrySDK())
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, err
+	}
+
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	)
+	return &otlpLogSink{
+		provider: provider,
+		logger:   provider.Logger(featuresScopeName),
+	}, nil
+}
+
+// Export emits the record, the batch processor sends it asynchronously
+func (s *otlpLogSink) Export(ctx context.Context, features *SchedulingFeatures) error {
+	var record log.Record
+	record.SetEventName(featuresEventName)
+	record.SetTimestamp(features.Timestamp)
+	record.SetSeverity(log.SeverityInfo)
+	record.SetBody(log.StringValue(featuresEventName))
+	record.AddAttributes(
+		log.String("model", features.Model),
+		log.Int("prompt_length_bucket", features.PromptLengthBucket),
+		log.Int("candidate_pods", features.CandidatePods),
+		log.Float64("predicted_cache_hit_ratio", features.PredictedCacheHitRatio),
+		log.String("decode_pod", features.DecodePod),
+		log.String("prefill_pod", features.PrefillPod),
+		log.Bool("streaming", features.Streaming),
+		log.Float64("time_to_first_byte_ms", features.TimeToFirstByteMs),
+		log.Float64("latency_ms", features.LatencyMs),
+	)
+	s.logger.Emit(ctx, record)
+	return nil
+}
+
+// Shutdown flushes the pending records
+func (s *otlpLogSink) Shutdown(ctx context.Context) error {
+	return s.provider.Shutdown(ctx)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 database/sql driver
+)
+
+const (
+	// SinkSQLite writes the features to a local SQLite database
+	SinkSQLite = "sqlite"
+
+	// defaultSQLitePath is the database file the features are written to
+	defaultSQLitePath = "scheduling-features.db"
+
+	// defaultSQLiteMaxFileSize is the size, in bytes, after which the database file is rotated
+	defaultSQLiteMaxFileSize = 64 << 20
+
+	// defaultSQLiteMaxFiles is the number of database files kept, the current one included
+	defaultSQLiteMaxFiles = 5
+
+	// sqliteQueueSize is the number of records waiting to be written before the records are dropped
+	sqliteQueueSize = 4096
+
+	// sqliteBatchSize is the maximum number of records written in a single transaction
+	sqliteBatchSize = 256
+)
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS scheduling_features (
+	timestamp TEXT NOT NULL,
+	model TEXT NOT NULL,
+	prompt_length_bucket INTEGER NOT NULL,
+	candidate_pods INTEGER NOT NULL,
+	predicted_cache_hit_ratio REAL NOT NULL,
+	decode_pod TEXT NOT NULL,
+	prefill_pod TEXT NOT NULL,
+	streaming INTEGER NOT NULL,
+	time_to_first_byte_ms REAL NOT NULL,
+	latency_ms REAL NOT NULL
+)`
+
+const sqliteInsert = `INSERT INTO scheduling_features (timestamp, model, prompt_length_bucket, candidate_pods,
+	predicted_cache_hit_ratio, decode_pod, prefill_pod, streaming, time_to_first_byte_ms, latency_ms)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// sqliteSink writes the features to a local SQLite database, for single-cluster users to query the
+// scheduling decisions without a log pipeline. The records are written in batches by a background
+// goroutine, so the responses are never delayed by the disk. The database file is rotated when it
+// exceeds the size cap: the current file is renamed <path>.1, the previous ones shifted, and the
+// files beyond the maximum count removed.
+type sqliteSink struct {
+	path        string
+	maxFileSize int64
+	maxFiles    int
+
+	mu      sync.Mutex
+	closed  bool
+	records chan *SchedulingFeatures
+
+	db   *sql.DB
+	done chan error
+}
+
+// newSQLiteSink opens, or creates, the database at the given path and starts writing the records
+func newSQLiteSink(path string, maxFileSize int64, maxFiles int) (*sqliteSink, error) {
+	sink := &sqliteSink{
+		path:        path,
+		maxFileSize: maxFileSize,
+		maxFiles:    maxFiles,
+		records:     make(chan *SchedulingFeatures, sqliteQueueSize),
+		done:        make(chan error, 1),
+	}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+
+	go sink.run()
+	return sink, nil
+}
+
+// Export queues the record, it is dropped when the writer falls behind
+func (s *sqliteSink) Export(_ context.Context, features *SchedulingFeatures) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("the SQLite sink is shut down")
+	}
+	select {
+	case s.records <- features:
+		return nil
+	default:
+		return errors.New("the SQLite sink queue is full, the record is dropped")
+	}
+}
+
+// Shutdown writes the queued records and closes the database
+func (s *sqliteSink) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.records)
+	}
+	s.mu.Unlock()
+
+	select {
+	case err := <-s.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// open opens the database file and creates the table
+func (s *sqliteSink) open() error {
+	db, err := sql.Open("sqlite3", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open the SQLite database '%s' - %w", s.path, err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close() //nolint:errcheck
+		return fmt.Errorf("failed to create the table of the SQLite database '%s' - %w", s.path, err)
+	}
+	s.db = db
+	return nil
+}
+
+// run writes the queued records until the queue is closed
+func (s *sqliteSink) run() {
+	var err error
+	batch := make([]*SchedulingFeatures, 0, sqliteBatchSize)
+	for record := range s.records {
+		batch = append(batch[:0], record)
+	drain:
+		for len(batch) < sqliteBatchSize {
+			select {
+			case next, ok := <-s.records:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		if writeErr := s.write(batch); writeErr != nil {
+			err = writeErr
+		}
+	}
+	s.done <- errors.Join(err, s.db.Close())
+}
+
+// write inserts the records in a single transaction, then rotates the database file when it is too large
+func (s *sqliteSink) write(batch []*SchedulingFeatures) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, features := range batch {
+		if _, err := tx.Exec(sqliteInsert, features.Timestamp, features.Model, features.PromptLengthBucket,
+			features.CandidatePods, features.PredictedCacheHitRatio, features.DecodePod, features.PrefillPod,
+			features.Streaming, features.TimeToFirstByteMs, features.LatencyMs); err != nil {
+			tx.Rollback() //nolint:errcheck
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil || info.Size() < s.maxFileSize {
+		return err
+	}
+	return s.rotate()
+}
+
+// rotate renames the current database file to <path>.1, shifting the previous ones and removing the
+// files beyond the maximum count, then opens a new database file
+func (s *sqliteSink) rotate() error {
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return errors.Join(s.shiftFiles(), s.open())
+}
+
+// shiftFiles renames each database file to the path of the next rotation, the oldest one being removed
+func (s *sqliteSink) shiftFiles() error {
+	if err := os.Remove(s.rotatedPath(s.maxFiles - 1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := s.maxFiles - 2; i >= 0; i-- {
+		if err := os.Rename(s.rotatedPath(i), s.rotatedPath(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotatedPath returns the path of the database file rotated the given number of times
+func (s *sqliteSink) rotatedPath(rotations int) string {
+	if rotations == 0 {
+		return s.path
+	}
+	return fmt.Sprintf("%s.%d", s.path, rotations)
+}
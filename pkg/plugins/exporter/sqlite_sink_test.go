@@ -0,0 +1,110 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteSink(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "features.db")
+
+	sink, err := newSQLiteSink(path, 1<<30, 2)
+	if err != nil {
+		t.Fatalf("newSQLiteSink() error = %v", err)
+	}
+	want := &SchedulingFeatures{
+		Timestamp:              time.Now(),
+		Model:                  "food-review",
+		PromptLengthBucket:     256,
+		CandidatePods:          3,
+		PredictedCacheHitRatio: 0.5,
+		DecodePod:              "default/decode",
+		PrefillPod:             "default/prefill",
+		Streaming:              true,
+		TimeToFirstByteMs:      12.5,
+		LatencyMs:              250,
+	}
+	for range 3 {
+		if err := sink.Export(ctx, want); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+	}
+	if err := sink.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := sink.Export(ctx, want); err == nil {
+		t.Error("Export() after Shutdown() succeeded, want an error")
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	var count int
+	var got SchedulingFeatures
+	if err := db.QueryRow(`SELECT COUNT(*), model, prompt_length_bucket, candidate_pods, predicted_cache_hit_ratio,
+		decode_pod, prefill_pod, streaming, time_to_first_byte_ms, latency_ms FROM scheduling_features`).Scan(&count,
+		&got.Model, &got.PromptLengthBucket, &got.CandidatePods, &got.PredictedCacheHitRatio, &got.DecodePod,
+		&got.PrefillPod, &got.Streaming, &got.TimeToFirstByteMs, &got.LatencyMs); err != nil {
+		t.Fatalf("query error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("count = %d, want 3", count)
+	}
+	got.Timestamp = want.Timestamp
+	if got != *want {
+		t.Errorf("record = %+v, want %+v", got, *want)
+	}
+}
+
+func TestSQLiteSinkRotation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "features.db")
+
+	// every write exceeds the size cap of a single byte, so every batch is written to its own file
+	sink, err := newSQLiteSink(path, 1, 3)
+	if err != nil {
+		t.Fatalf("newSQLiteSink() error = %v", err)
+	}
+	for range 5 {
+		if err := sink.Export(ctx, &SchedulingFeatures{Model: "m"}); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		time.Sleep(20 * time.Millisecond) // let the batch be written
+	}
+	if err := sink.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("the database file %s is missing: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("the database file %s is kept beyond the maximum count", path+".3")
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+const (
+	// CircuitBreakerType is the type of the CircuitBreaker filter
+	CircuitBreakerType = "circuit-breaker-filter"
+
+	defaultCircuitsRefreshInterval = 5 * time.Second
+
+	// the circuits reported by a sidecar are ignored when they were not refreshed for
+	// this number of refresh intervals, e.g. while its pod is not responding
+	circuitsValidIntervals = 3
+
+	// the circuits of a pod are forgotten when the pod was not a candidate for
+	// this number of refresh intervals
+	circuitsRetainedIntervals = 12
+)
+
+type circuitBreakerParameters struct {
+	RefreshInterval    string `json:"refreshInterval"`
+	UseTLS             *bool  `json:"useTLS"`
+	InsecureSkipVerify *bool  `json:"insecureSkipVerify"`
+}
+
+var _ framework.Filter = &CircuitBreaker{} // validate interface conformance
+
+// CircuitBreakerFactory defines the factory function for the CircuitBreaker filter.
+func CircuitBreakerFactory(name string, rawParameters json.RawMessage, _ plugins.Handle) (plugins.Plugin, error) {
+	parameters := circuitBreakerParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' filter - %w", CircuitBreakerType, err)
+		}
+	}
+
+	refreshInterval := defaultCircuitsRefreshInterval
+	if parameters.RefreshInterval != "" {
+		interval, err := time.ParseDuration(parameters.RefreshInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid configuration for '%s' filter: 'refreshInterval' must be a positive duration, got '%s'",
+				CircuitBreakerType, parameters.RefreshInterval)
+		}
+		refreshInterval = interval
+	}
+
+	// the sidecar serves TLS with a self-signed certificate by default
+	useTLS, insecureSkipVerify := true, true
+	if parameters.UseTLS != nil {
+		useTLS = *parameters.UseTLS
+	}
+	if parameters.InsecureSkipVerify != nil {
+		insecureSkipVerify = *parameters.InsecureSkipVerify
+	}
+
+	return NewCircuitBreaker(refreshInterval, useTLS, insecureSkipVerify).WithName(name), nil
+}
+
+// NewCircuitBreaker initializes a new CircuitBreaker filter and returns its pointer.
+// The circuits of the pods are fetched from their sidecar every refreshInterval.
+func NewCircuitBreaker(refreshInterval time.Duration, useTLS bool, insecureSkipVerify bool) *CircuitBreaker {
+	scheme := "http"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if useTLS {
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // the sidecar certificate is self-signed by default
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
+
+	return &CircuitBreaker{
+		typedName:       plugins.TypedName{Type: CircuitBreakerType},
+		scheme:          scheme,
+		client:          &http.Client{Transport: transport, Timeout: refreshInterval},
+		refreshInterval: refreshInterval,
+		sidecars:        map[string]*sidecarCircuits{},
+	}
+}
+
+// sidecarCircuits are the circuits reported by the sidecar of a pod
+type sidecarCircuits struct {
+	circuits    *common.CircuitsResponse // nil until fetched
+	fetchedAt   time.Time                // the time the circuits were last fetched successfully
+	attemptedAt time.Time
+	fetching    bool
+	lastSeen    time.Time
+}
+
+// CircuitBreaker filters out the pods whose circuit is open in the P/D sidecars: the decode pods
+// whose local vLLM fails repeatedly, and the prefill pods failing the prefill requests of any
+// decode pod. The state of the circuits is fetched asynchronously from the sidecars, and the pods
+// are never filtered out based on a missing or outdated state.
+type CircuitBreaker struct {
+	typedName       plugins.TypedName
+	scheme          string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	mutex    sync.Mutex
+	sidecars map[string]*sidecarCircuits // by pod <ip:port>
+}
+
+// TypedName returns the typed name of the plugin
+func (f *CircuitBreaker) TypedName() plugins.TypedName {
+	return f.typedName
+}
+
+// WithName sets the name of the plugin.
+func (f *CircuitBreaker) WithName(name string) *CircuitBreaker {
+	f.typedName.Name = name
+	return f
+}
+
+// Filter filters out the pods with an open circuit. All the pods are kept when all of them
+// have an open circuit, since there is no better choice.
+func (f *CircuitBreaker) Filter(ctx context.Context, _ *types.CycleState, _ *types.LLMRequest, pods []types.Pod) []types.Pod {
+	openDecoders, openPrefillers := f.openCircuits(ctx, pods)
+	if len(openDecoders) == 0 && len(openPrefillers) == 0 {
+		return pods
+	}
+
+	filteredPods := []types.Pod{}
+	for _, pod := range pods {
+		hostPort := podHostPort(pod)
+		if _, open := openDecoders[hostPort]; open {
+			continue
+		}
+		if _, open := openPrefillers[hostPort]; open {
+			continue
+		}
+		filteredPods = append(filteredPods, pod)
+	}
+
+	if len(filteredPods) == 0 {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("All the pods have an open circuit, keeping all of them")
+		return pods
+	}
+	return filteredPods
+}
+
+// openCircuits returns the pods with an open decoder circuit, and the prefill targets with an open
+// circuit in any sidecar. The circuits of the candidate pods are refreshed when outdated.
+func (f *CircuitBreaker) openCircuits(ctx context.Context, pods []types.Pod) (map[string]struct{}, map[string]struct{}) {
+	now := time.Now()
+	openDecoders := map[string]struct{}{}
+	openPrefillers := map[string]struct{}{}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, pod := range pods {
+		hostPort := podHostPort(pod)
+		sidecar, ok := f.sidecars[hostPort]
+		if !ok {
+			sidecar = &sidecarCircuits{}
+			f.sidecars[hostPort] = sidecar
+		}
+		sidecar.lastSeen = now
+		if !sidecar.fetching && now.Sub(sidecar.attemptedAt) >= f.refreshInterval {
+			sidecar.fetching = true
+			sidecar.attemptedAt = now
+			go f.fetch(log.FromContext(ctx), hostPort)
+		}
+	}
+
+	for hostPort, sidecar := range f.sidecars {
+		if now.Sub(sidecar.lastSeen) > circuitsRetainedIntervals*f.refreshInterval {
+			delete(f.sidecars, hostPort)
+			continue
+		}
+		if sidecar.circuits == nil || now.Sub(sidecar.fetchedAt) > circuitsValidIntervals*f.refreshInterval {
+			continue
+		}
+		if sidecar.circuits.Decoder == common.CircuitOpen {
+			openDecoders[hostPort] = struct{}{}
+		}
+		for prefiller, state := range sidecar.circuits.Prefillers {
+			if state == common.CircuitOpen {
+				openPrefillers[prefiller] = struct{}{}
+			}
+		}
+	}
+	return openDecoders, openPrefillers
+}
+
+// fetch fetches the circuits reported by the sidecar of a pod
+func (f *CircuitBreaker) fetch(logger logr.Logger, hostPort string) {
+	circuits, err := f.getCircuits(hostPort)
+	if err != nil {
+		logger.V(logutil.DEBUG).Info("Failed to fetch the circuits of the pod sidecar", "pod", hostPort, "error", err.Error())
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	sidecar, ok := f.sidecars[hostPort]
+	if !ok {
+		return
+	}
+	sidecar.fetching = false
+	if err == nil {
+		sidecar.circuits = circuits
+		sidecar.fetchedAt = time.Now()
+	}
+}
+
+func (f *CircuitBreaker) getCircuits(hostPort string) (*common.CircuitsResponse, error) {
+	resp, err := f.client.Get(f.scheme + "://" + hostPort + common.CircuitsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:all
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	circuits := &common.CircuitsResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(circuits); err != nil {
+		return nil, err
+	}
+	return circuits, nil
+}
+
+// podHostPort returns the <ip:port> of a pod, as used in the prefill header
+func podHostPort(pod types.Pod) string {
+	return net.JoinHostPort(pod.GetPod().Address, pod.GetPod().Port)
+}
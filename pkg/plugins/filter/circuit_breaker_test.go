@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+func TestCircuitBreakerFactory(t *testing.T) {
+	tests := []struct {
+		name       string
+		jsonParams string
+		expectErr  bool
+	}{
+		{
+			name:       "valid configuration with all defaults",
+			jsonParams: "{}",
+			expectErr:  false,
+		},
+		{
+			name:       "valid configuration with custom values",
+			jsonParams: `{"refreshInterval": "2s", "useTLS": false, "insecureSkipVerify": false}`,
+			expectErr:  false,
+		},
+		{
+			name:       "invalid refresh interval should error",
+			jsonParams: `{"refreshInterval": "soon"}`,
+			expectErr:  true,
+		},
+		{
+			name:       "negative refresh interval should error",
+			jsonParams: `{"refreshInterval": "-1s"}`,
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := CircuitBreakerFactory("circuit-breaker", json.RawMessage(tt.jsonParams), nil)
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, plugin)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, plugin)
+			}
+		})
+	}
+}
+
+// newSidecar starts a sidecar stub reporting the given circuits, and returns a pod served by it
+func newSidecar(t *testing.T, name string, circuits *common.CircuitsResponse) types.Pod {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != common.CircuitsPath || circuits == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(circuits) //nolint:all
+	}))
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	return &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, Address: host, Port: port},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+}
+
+func TestCircuitBreakerFilter(t *testing.T) {
+	ctx := context.Background()
+
+	prefill := newSidecar(t, "prefill", nil) // no sidecar endpoint on the prefill pod
+	healthy := newSidecar(t, "healthy", &common.CircuitsResponse{
+		Decoder:    common.CircuitClosed,
+		Prefillers: map[string]common.CircuitState{podHostPort(prefill): common.CircuitOpen},
+	})
+	failing := newSidecar(t, "failing", &common.CircuitsResponse{Decoder: common.CircuitOpen})
+	unreachable := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "unreachable"}, Address: "127.0.0.1", Port: "1"},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	pods := []types.Pod{prefill, healthy, failing, unreachable}
+
+	filter := NewCircuitBreaker(50*time.Millisecond, false, false)
+
+	// the circuits are not known yet
+	assert.Equal(t, pods, filter.Filter(ctx, types.NewCycleState(), &types.LLMRequest{}, pods))
+
+	assert.Eventually(t, func() bool {
+		return len(filter.Filter(ctx, types.NewCycleState(), &types.LLMRequest{}, pods)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []types.Pod{healthy, unreachable}, filter.Filter(ctx, types.NewCycleState(), &types.LLMRequest{}, pods))
+
+	// all the pods are kept when all of them have an open circuit
+	assert.Equal(t, []types.Pod{prefill, failing},
+		filter.Filter(ctx, types.NewCycleState(), &types.LLMRequest{}, []types.Pod{prefill, failing}))
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField defines the range of the values of a cron expression field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7}, // both 0 and 7 are Sunday
+}
+
+// cronSchedule is a parsed cron expression with the standard five fields: minute, hour, day of
+// month, month and day of week. It matches the minutes of the times it would run at.
+type cronSchedule struct {
+	fields [5]map[int]bool
+	// the day of month and day of week fields are restricted, a time then matches when it
+	// matches either of them, as in cron
+	domRestricted, dowRestricted bool
+}
+
+// parseCronSchedule parses a cron expression. Each field is '*', a value, a range 'a-b', or a
+// comma separated list of them, each optionally followed by a step '/n'.
+func parseCronSchedule(expression string) (*cronSchedule, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule '%s': expected 5 fields, got %d", expression, len(parts))
+	}
+
+	schedule := &cronSchedule{
+		domRestricted: parts[2] != "*",
+		dowRestricted: parts[4] != "*",
+	}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': %w", expression, err)
+		}
+		schedule.fields[i] = values
+	}
+	if schedule.fields[4][7] {
+		schedule.fields[4][0] = true
+	}
+	return schedule, nil
+}
+
+// parseCronField returns the values matched by a field of a cron expression
+func parseCronField(expression string, field cronField) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(expression, ",") {
+		rangeExpression, stepExpression, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepExpression); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step '%s' of the %s field", stepExpression, field.name)
+			}
+		}
+
+		low, high := field.min, field.max
+		if rangeExpression != "*" {
+			lowExpression, highExpression, isRange := strings.Cut(rangeExpression, "-")
+			var err error
+			if low, err = parseCronValue(lowExpression, field); err != nil {
+				return nil, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseCronValue(highExpression, field); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				high = field.max // a/n stands for a-max/n
+			}
+			if low > high {
+				return nil, fmt.Errorf("invalid range '%s' of the %s field", rangeExpression, field.name)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+func parseCronValue(expression string, field cronField) (int, error) {
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("invalid value '%s' of the %s field: must be between %d and %d",
+			expression, field.name, field.min, field.max)
+	}
+	return value, nil
+}
+
+// matches returns whether the minute of the given time matches the schedule
+func (s *cronSchedule) matches(t time.Time) bool {
+	if !s.fields[0][t.Minute()] || !s.fields[1][t.Hour()] || !s.fields[3][int(t.Month())] {
+		return false
+	}
+
+	domMatches, dowMatches := s.fields[2][t.Day()], s.fields[4][int(t.Weekday())]
+	if s.domRestricted && s.dowRestricted {
+		return domMatches || dowMatches
+	}
+	return domMatches && dowMatches
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronSchedule(t *testing.T) {
+	// Monday 2025-06-02
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.June, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		matches    []time.Time
+		misses     []time.Time
+	}{
+		{
+			name:       "every minute",
+			expression: "* * * * *",
+			matches:    []time.Time{at(2, 0, 0), at(7, 23, 59)},
+		},
+		{
+			name:       "nightly window",
+			expression: "* 0-5 * * *",
+			matches:    []time.Time{at(2, 0, 0), at(2, 5, 59)},
+			misses:     []time.Time{at(2, 6, 0), at(2, 23, 59)},
+		},
+		{
+			name:       "business hours on weekdays",
+			expression: "* 9-17 * * 1-5",
+			matches:    []time.Time{at(2, 9, 0), at(6, 17, 30)},
+			misses:     []time.Time{at(2, 8, 59), at(2, 18, 0), at(7, 12, 0), at(8, 12, 0)},
+		},
+		{
+			name:       "lists and steps",
+			expression: "0,30 */6 * * *",
+			matches:    []time.Time{at(2, 0, 0), at(2, 6, 30), at(2, 18, 0)},
+			misses:     []time.Time{at(2, 6, 15), at(2, 7, 0)},
+		},
+		{
+			name:       "sunday as 7",
+			expression: "* * * * 7",
+			matches:    []time.Time{at(8, 12, 0)},
+			misses:     []time.Time{at(7, 12, 0)},
+		},
+		{
+			name:       "day of month or day of week",
+			expression: "* * 1 * 1",
+			matches:    []time.Time{at(1, 12, 0), at(2, 12, 0)},
+			misses:     []time.Time{at(3, 12, 0)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := parseCronSchedule(test.expression)
+			require.NoError(t, err)
+			for _, tm := range test.matches {
+				assert.True(t, schedule.matches(tm), tm.String())
+			}
+			for _, tm := range test.misses {
+				assert.False(t, schedule.matches(tm), tm.String())
+			}
+		})
+	}
+}
+
+func TestCronScheduleInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 5-1 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "* * * 13 *"} {
+		_, err := parseCronSchedule(expression)
+		assert.Error(t, err, expression)
+	}
+}
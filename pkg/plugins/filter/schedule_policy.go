@@ -0,0 +1,221 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/classifier"
+)
+
+const (
+	// SchedulePolicyType is the type of the SchedulePolicy filter
+	SchedulePolicyType = "schedule-policy-filter"
+)
+
+// SchedulePolicyRuleParameters defines a capacity policy applied during a schedule.
+type SchedulePolicyRuleParameters struct {
+	// Name identifies the rule in the logs
+	Name string `json:"name"`
+
+	// Schedule is a cron expression, e.g. "* 0-5 * * *", the rule is active during the minutes it matches
+	Schedule string `json:"schedule"`
+
+	// Classes are the request classes the rule applies to. All the requests match when empty.
+	Classes []string `json:"classes"`
+
+	// Pools are the values of the pool label of the pods the requests may be scheduled on.
+	// All the pods are allowed when empty.
+	Pools []string `json:"pools"`
+
+	// MaxKVCacheUsage reserves headroom on the allowed pods: the pods whose KV cache usage, between 0
+	// and 1, is above it are filtered out. Disabled when 0.
+	MaxKVCacheUsage float64 `json:"maxKVCacheUsage"`
+}
+
+// SchedulePolicyParameters defines the parameters of the SchedulePolicy filter.
+type SchedulePolicyParameters struct {
+	// ClassifierRef is the name of the classifier plugin assigning the request classes. Only required
+	// when a rule has classes.
+	ClassifierRef string `json:"classifierRef"`
+
+	// Label is the pod label whose values are the pools of the rules.
+	Label string `json:"label"`
+
+	// TimeZone is the IANA time zone the schedules are evaluated in. Defaults to UTC.
+	TimeZone string `json:"timeZone"`
+
+	// Rules are evaluated in order, the first active rule matching the request applies
+	Rules []SchedulePolicyRuleParameters `json:"rules"`
+}
+
+// schedulePolicyRule is a parsed SchedulePolicyRuleParameters
+type schedulePolicyRule struct {
+	SchedulePolicyRuleParameters
+	schedule *cronSchedule
+}
+
+var _ framework.Filter = &SchedulePolicy{} // validate interface conformance
+
+// SchedulePolicyFactory defines the factory function for the SchedulePolicy filter.
+func SchedulePolicyFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := SchedulePolicyParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' filter - %w", SchedulePolicyType, err)
+		}
+	}
+
+	var requestClassifier classifier.Classifier
+	if parameters.ClassifierRef != "" {
+		var ok bool
+		if requestClassifier, ok = handle.Plugin(parameters.ClassifierRef).(classifier.Classifier); !ok {
+			return nil, fmt.Errorf("the '%s' filter references '%s' which is not a classifier defined before it",
+				SchedulePolicyType, parameters.ClassifierRef)
+		}
+	}
+
+	schedulePolicy, err := NewSchedulePolicy(&parameters, requestClassifier)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration for '%s' filter: %w", SchedulePolicyType, err)
+	}
+	return schedulePolicy.WithName(name), nil
+}
+
+// NewSchedulePolicy initializes a new SchedulePolicy filter and returns its pointer.
+// The classifier may be nil when no rule has classes.
+func NewSchedulePolicy(params *SchedulePolicyParameters, requestClassifier classifier.Classifier) (*SchedulePolicy, error) {
+	if len(params.Rules) == 0 {
+		return nil, errors.New("'rules' must not be empty")
+	}
+	location := time.UTC
+	if params.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(params.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid timeZone: %w", err)
+		}
+	}
+
+	rules := make([]schedulePolicyRule, len(params.Rules))
+	for i, rule := range params.Rules {
+		schedule, err := parseCronSchedule(rule.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
+		if len(rule.Classes) > 0 && requestClassifier == nil {
+			return nil, fmt.Errorf("invalid rule %d: 'classifierRef' must be specified for the rules with classes", i)
+		}
+		if len(rule.Pools) > 0 && params.Label == "" {
+			return nil, fmt.Errorf("invalid rule %d: 'label' must be specified for the rules with pools", i)
+		}
+		if rule.MaxKVCacheUsage < 0 || rule.MaxKVCacheUsage > 1 {
+			return nil, fmt.Errorf("invalid rule %d: 'maxKVCacheUsage' must be between 0 and 1, got %v", i, rule.MaxKVCacheUsage)
+		}
+		rules[i] = schedulePolicyRule{SchedulePolicyRuleParameters: rule, schedule: schedule}
+	}
+
+	return &SchedulePolicy{
+		typedName:  plugins.TypedName{Type: SchedulePolicyType},
+		classifier: requestClassifier,
+		label:      params.Label,
+		location:   location,
+		rules:      rules,
+		now:        time.Now,
+	}, nil
+}
+
+// SchedulePolicy applies different capacity policies depending on the time of day, e.g. letting the
+// batch requests spill onto the interactive pods during a nightly window, and reserving headroom on
+// them during business hours. The first rule whose schedule is active and whose classes include the
+// class of the request applies: only the pods of its pools with enough headroom are kept, possibly
+// none. The requests matching no active rule are not filtered.
+type SchedulePolicy struct {
+	typedName  plugins.TypedName
+	classifier classifier.Classifier // nil when no rule has classes
+	label      string
+	location   *time.Location
+	rules      []schedulePolicyRule
+	now        func() time.Time
+}
+
+// TypedName returns the typed name of the plugin
+func (f *SchedulePolicy) TypedName() plugins.TypedName {
+	return f.typedName
+}
+
+// WithName sets the name of the plugin.
+func (f *SchedulePolicy) WithName(name string) *SchedulePolicy {
+	f.typedName.Name = name
+	return f
+}
+
+// Filter keeps the pods allowed by the first active rule matching the request
+func (f *SchedulePolicy) Filter(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) []types.Pod {
+	rule := f.activeRule(ctx, request)
+	if rule == nil {
+		return pods
+	}
+
+	filteredPods := []types.Pod{}
+	for _, pod := range pods {
+		if len(rule.Pools) > 0 && !slices.Contains(rule.Pools, pod.GetPod().Labels[f.label]) {
+			continue
+		}
+		if rule.MaxKVCacheUsage > 0 && pod.GetMetrics().KVCacheUsagePercent > rule.MaxKVCacheUsage {
+			continue
+		}
+		filteredPods = append(filteredPods, pod)
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Applied the schedule policy", "rule", rule.Name,
+		"pods", len(pods), "allowedPods", len(filteredPods))
+	return filteredPods
+}
+
+// activeRule returns the first rule whose schedule is active and which applies to the request,
+// or nil when there is none
+func (f *SchedulePolicy) activeRule(ctx context.Context, request *types.LLMRequest) *schedulePolicyRule {
+	now := f.now().In(f.location)
+	class := ""
+	for i := range f.rules {
+		rule := &f.rules[i]
+		if !rule.schedule.matches(now) {
+			continue
+		}
+		if len(rule.Classes) > 0 {
+			if class == "" {
+				class = f.classifier.Classify(ctx, request)
+			}
+			if !slices.Contains(rule.Classes, class) {
+				continue
+			}
+		}
+		return rule
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/classifier"
+)
+
+func TestSchedulePolicy(t *testing.T) {
+	newPod := func(name string, pool string, kvCacheUsage float64) types.Pod {
+		return &types.PodMetrics{
+			Pod: &backend.Pod{
+				NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name},
+				Labels:         map[string]string{"workload": pool},
+			},
+			MetricsState: &backendmetrics.MetricsState{KVCacheUsagePercent: kvCacheUsage},
+		}
+	}
+	batchPod := newPod("batch", "batch", 0.9)
+	idleInteractivePod := newPod("idle-interactive", "interactive", 0.2)
+	busyInteractivePod := newPod("busy-interactive", "interactive", 0.7)
+	pods := []types.Pod{batchPod, idleInteractivePod, busyInteractivePod}
+
+	requestClassifier, err := classifier.NewRequestClassifier(&classifier.RequestClassifierParameters{
+		DefaultClass: "interactive",
+		Rules:        []classifier.RuleParameters{{Class: "batch", Headers: map[string]string{"x-priority": "batch"}}},
+	})
+	require.NoError(t, err)
+
+	schedulePolicy, err := NewSchedulePolicy(&SchedulePolicyParameters{
+		Label:    "workload",
+		TimeZone: "America/New_York",
+		Rules: []SchedulePolicyRuleParameters{
+			{Name: "nightly-batch-window", Schedule: "* 0-5 * * *", Classes: []string{"batch"}, Pools: []string{"batch", "interactive"}},
+			{Name: "business-hours", Schedule: "* 9-17 * * 1-5", Classes: []string{"batch"}, Pools: []string{"batch", "interactive"},
+				MaxKVCacheUsage: 0.5},
+			{Name: "batch", Schedule: "* * * * *", Classes: []string{"batch"}, Pools: []string{"batch"}},
+		},
+	}, requestClassifier)
+	require.NoError(t, err)
+
+	batchRequest := &types.LLMRequest{TargetModel: "model", Headers: map[string]string{"x-priority": "batch"}}
+	interactiveRequest := &types.LLMRequest{TargetModel: "model", Headers: map[string]string{}}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		request  *types.LLMRequest
+		expected []types.Pod
+	}{
+		{
+			name:     "nightly batch window spills onto the interactive pods",
+			now:      time.Date(2025, time.June, 3, 2, 0, 0, 0, newYork),
+			request:  batchRequest,
+			expected: pods,
+		},
+		{
+			name:     "business hours reserve headroom on the interactive pods",
+			now:      time.Date(2025, time.June, 3, 10, 0, 0, 0, newYork),
+			request:  batchRequest,
+			expected: []types.Pod{idleInteractivePod},
+		},
+		{
+			name:     "batch pods only otherwise",
+			now:      time.Date(2025, time.June, 3, 20, 0, 0, 0, newYork),
+			request:  batchRequest,
+			expected: []types.Pod{batchPod},
+		},
+		{
+			name:     "schedules evaluated in the time zone",
+			now:      time.Date(2025, time.June, 3, 2, 0, 0, 0, time.UTC), // 22:00 in New York
+			request:  batchRequest,
+			expected: []types.Pod{batchPod},
+		},
+		{
+			name:     "requests matching no rule are not filtered",
+			now:      time.Date(2025, time.June, 3, 10, 0, 0, 0, newYork),
+			request:  interactiveRequest,
+			expected: pods,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedulePolicy.now = func() time.Time { return test.now }
+			got := schedulePolicy.Filter(context.Background(), types.NewCycleState(), test.request, pods)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestSchedulePolicyFactory(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		jsonParams string
+		expectErr  bool
+	}{
+		{
+			name: "valid configuration",
+			jsonParams: `{"classifierRef": "request-classifier", "label": "workload", "timeZone": "Europe/Paris",
+				"rules": [{"schedule": "* 0-5 * * *", "classes": ["batch"], "pools": ["batch", "interactive"]}]}`,
+		},
+		{
+			name:       "rules without classes do not need a classifier",
+			jsonParams: `{"rules": [{"schedule": "* 9-17 * * 1-5", "maxKVCacheUsage": 0.8}]}`,
+		},
+		{
+			name:       "no rules",
+			jsonParams: `{"label": "workload"}`,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid schedule",
+			jsonParams: `{"rules": [{"schedule": "* 25 * * *"}]}`,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid time zone",
+			jsonParams: `{"timeZone": "Mars/Olympus", "rules": [{"schedule": "* * * * *"}]}`,
+			expectErr:  true,
+		},
+		{
+			name:       "classes without classifier",
+			jsonParams: `{"rules": [{"schedule": "* * * * *", "classes": ["batch"]}]}`,
+			expectErr:  true,
+		},
+		{
+			name:       "unknown classifier",
+			jsonParams: `{"classifierRef": "missing", "rules": [{"schedule": "* * * * *", "classes": ["batch"]}]}`,
+			expectErr:  true,
+		},
+		{
+			name:       "pools without label",
+			jsonParams: `{"rules": [{"schedule": "* * * * *", "pools": ["batch"]}]}`,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid headroom",
+			jsonParams: `{"rules": [{"schedule": "* * * * *", "maxKVCacheUsage": 1.5}]}`,
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handle := plugins.NewEppHandle(ctx, nil)
+			requestClassifier, err := classifier.NewRequestClassifier(&classifier.RequestClassifierParameters{DefaultClass: "interactive"})
+			require.NoError(t, err)
+			handle.AddPlugin(classifier.RequestClassifierType, requestClassifier)
+
+			plugin, err := SchedulePolicyFactory("schedule-policy", json.RawMessage(test.jsonParams), handle)
+			if test.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, plugin)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, plugin)
+			}
+		})
+	}
+}
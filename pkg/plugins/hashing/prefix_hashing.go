@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashing
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+
+	"github.com/cespare/xxhash/v2"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+)
+
+const (
+	// PrefixHashingType is the type of the PrefixHashing
+	PrefixHashingType = "prefix-hashing"
+
+	// HashFNV64a is the 64-bit FNV-1a hash function
+	HashFNV64a = "fnv64a"
+
+	// HashXXHash64 is the 64-bit xxHash hash function, used by the prefix cache plugin
+	HashXXHash64 = "xxhash64"
+)
+
+// PrefixHashingParameters defines the parameters of the PrefixHashing
+type PrefixHashingParameters struct {
+	// BlockSize is the prefix cache block size, in bytes. Defaults to the prefix cache plugin default.
+	BlockSize int `json:"blockSize"`
+
+	// ModelBlockSizes are the block sizes of the models whose engine caches the prefixes with other block
+	// sizes, by model name
+	ModelBlockSizes map[string]int `json:"modelBlockSizes"`
+
+	// HashFunction is the function hashing the prompt blocks, "fnv64a" or "xxhash64". Defaults to "fnv64a".
+	HashFunction string `json:"hashFunction"`
+}
+
+// compile-time type assertion
+var _ plugins.Plugin = &PrefixHashing{}
+
+// PrefixHashingFactory defines the factory function for the PrefixHashing
+func PrefixHashingFactory(name string, rawParameters json.RawMessage, _ plugins.Handle) (plugins.Plugin, error) {
+	parameters := PrefixHashingParameters{
+		BlockSize:    prefix.DefaultBlockSize,
+		HashFunction: HashFNV64a,
+	}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' plugin - %w", PrefixHashingType, err)
+		}
+	}
+
+	hashing, err := NewPrefixHashing(parameters.BlockSize, parameters.ModelBlockSizes, parameters.HashFunction)
+	if err != nil {
+		return nil, err
+	}
+	return hashing.WithName(name), nil
+}
+
+// NewPrefixHashing initializes a new PrefixHashing and returns its pointer.
+// The block size of the models without their own applies to all of them.
+func NewPrefixHashing(blockSize int, modelBlockSizes map[string]int, hashFunction string) (*PrefixHashing, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid blockSize: must be > 0, got %d", blockSize)
+	}
+	for model, modelBlockSize := range modelBlockSizes {
+		if modelBlockSize <= 0 {
+			return nil, fmt.Errorf("invalid block size of model '%s': must be > 0, got %d", model, modelBlockSize)
+		}
+	}
+
+	var newHash func() hash.Hash64
+	switch hashFunction {
+	case HashFNV64a:
+		newHash = fnv.New64a
+	case HashXXHash64:
+		newHash = func() hash.Hash64 { return xxhash.New() }
+	default:
+		return nil, fmt.Errorf("invalid hashFunction: must be '%s' or '%s', got '%s'", HashFNV64a, HashXXHash64, hashFunction)
+	}
+
+	return &PrefixHashing{
+		typedName:       plugins.TypedName{Type: PrefixHashingType},
+		blockSize:       blockSize,
+		modelBlockSizes: modelBlockSizes,
+		newHash:         newHash,
+	}, nil
+}
+
+// PrefixHashing is the prefix hashing configuration shared by the prefix-affinity plugins, such as the
+// pd-profile-handler and the llm-d prefix scorers, which reference it by name. The block sizes must match
+// the prefix caching block sizes of the engines, so that all the plugins agree on the block boundaries.
+// It takes no part in the scheduling.
+type PrefixHashing struct {
+	typedName       plugins.TypedName
+	blockSize       int
+	modelBlockSizes map[string]int
+	newHash         func() hash.Hash64
+}
+
+// TypedName returns the typed name of the plugin.
+func (h *PrefixHashing) TypedName() plugins.TypedName {
+	return h.typedName
+}
+
+// WithName sets the name of the plugin.
+func (h *PrefixHashing) WithName(name string) *PrefixHashing {
+	h.typedName.Name = name
+	return h
+}
+
+// BlockSize returns the block size of the given model, in bytes
+func (h *PrefixHashing) BlockSize(model string) int {
+	if blockSize, ok := h.modelBlockSizes[model]; ok {
+		return blockSize
+	}
+	return h.blockSize
+}
+
+// Hash returns the hash of the given data with the configured hash function
+func (h *PrefixHashing) Hash(data []byte) uint64 {
+	hash := h.newHash()
+	_, _ = hash.Write(data)
+	return hash.Sum64()
+}
+
+// Blocks returns the number of blocks of a prompt of the given length for the given model, including
+// the trailing partial block
+func (h *PrefixHashing) Blocks(model string, promptLength int) int {
+	blockSize := h.BlockSize(model)
+	return (promptLength + blockSize - 1) / blockSize
+}
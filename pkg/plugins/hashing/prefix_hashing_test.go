@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashing
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+)
+
+func TestPrefixHashingFactory(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{}`},
+		{name: "xxhash", parameters: `{"blockSize": 128, "hashFunction": "xxhash64"}`},
+		{name: "model block sizes", parameters: `{"modelBlockSizes": {"llama": 256}}`},
+		{name: "invalid block size", parameters: `{"blockSize": 0}`, wantErr: true},
+		{name: "invalid model block size", parameters: `{"modelBlockSizes": {"llama": -1}}`, wantErr: true},
+		{name: "unknown hash function", parameters: `{"hashFunction": "md5"}`, wantErr: true},
+		{name: "invalid json", parameters: `{"blockSize": "64"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plugin, err := PrefixHashingFactory("hashing", json.RawMessage(test.parameters), nil)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("PrefixHashingFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err == nil && plugin.TypedName().Name != "hashing" {
+				t.Errorf("expected the plugin name 'hashing', got '%s'", plugin.TypedName().Name)
+			}
+		})
+	}
+}
+
+func TestPrefixHashing(t *testing.T) {
+	hashing, err := NewPrefixHashing(prefix.DefaultBlockSize, map[string]int{"llama": 16}, HashXXHash64)
+	if err != nil {
+		t.Fatalf("NewPrefixHashing() failed: %v", err)
+	}
+
+	if got := hashing.BlockSize("llama"); got != 16 {
+		t.Errorf("expected the block size of the model 16, got %d", got)
+	}
+	if got := hashing.BlockSize("other"); got != prefix.DefaultBlockSize {
+		t.Errorf("expected the default block size %d, got %d", prefix.DefaultBlockSize, got)
+	}
+	if got := hashing.Blocks("llama", 33); got != 3 {
+		t.Errorf("expected 3 blocks including the partial one, got %d", got)
+	}
+	if got := hashing.Blocks("llama", 32); got != 2 {
+		t.Errorf("expected 2 blocks, got %d", got)
+	}
+
+	if got, want := hashing.Hash([]byte("prompt")), xxhash.Sum64([]byte("prompt")); got != want {
+		t.Errorf("expected the xxhash of the data %d, got %d", want, got)
+	}
+
+	fnvHashing, err := NewPrefixHashing(prefix.DefaultBlockSize, nil, HashFNV64a)
+	if err != nil {
+		t.Fatalf("NewPrefixHashing() failed: %v", err)
+	}
+	want := fnv.New64a()
+	_, _ = want.Write([]byte("prompt"))
+	if got := fnvHashing.Hash([]byte("prompt")); got != want.Sum64() {
+		t.Errorf("expected the FNV-1a hash of the data %d, got %d", want.Sum64(), got)
+	}
+}
@@ -0,0 +1,372 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite"
+)
+
+const (
+	// ParetoPickerType is the type of the ParetoPicker
+	ParetoPickerType = "pareto-picker"
+
+	// PolicyUtopiaDistance picks the Pareto-optimal pod closest to the best score of every objective
+	PolicyUtopiaDistance = "utopia-distance"
+
+	// PolicyMaxMin picks the Pareto-optimal pod with the best worst objective score
+	PolicyMaxMin = "max-min"
+
+	// PolicyLexicographic picks the Pareto-optimal pod with the best score on the first objective,
+	// breaking ties with the following objectives
+	PolicyLexicographic = "lexicographic"
+
+	// PolicyRandom picks a random Pareto-optimal pod
+	PolicyRandom = "random"
+
+	defaultMaxNumOfEndpoints = 1
+)
+
+// ObjectiveParameters defines an objective of the ParetoPicker.
+type ObjectiveParameters struct {
+	// PluginRef is the name of the scorer plugin evaluating the objective.
+	// It must be defined before the ParetoPicker.
+	PluginRef string `json:"pluginRef"`
+
+	// Weight is the importance of the objective for the utopia-distance and max-min policies. Defaults to 1.
+	Weight float64 `json:"weight"`
+}
+
+// ParetoPickerParameters defines the parameters of the ParetoPicker.
+type ParetoPickerParameters struct {
+	// Objectives are the scorers treated as separate objectives, all maximized
+	Objectives []ObjectiveParameters `json:"objectives"`
+
+	// Policy selects among the Pareto-optimal pods. Defaults to "utopia-distance".
+	Policy string `json:"policy"`
+
+	// MaxNumOfEndpoints is the maximum number of pods to pick. Defaults to 1.
+	MaxNumOfEndpoints int `json:"maxNumOfEndpoints"`
+}
+
+// objective is a scorer evaluating an objective and its weight
+type objective struct {
+	scorer framework.Scorer
+	weight float64
+}
+
+// objectivesState holds the objective scores of the pods in the current scheduling cycle
+type objectivesState struct {
+	scores map[types.Pod][]float64
+}
+
+// Clone implements plugins.StateData
+func (s *objectivesState) Clone() plugins.StateData {
+	return s
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &ParetoPicker{}
+var _ framework.Picker = &ParetoPicker{}
+
+// ParetoPickerFactory defines the factory function for the ParetoPicker.
+func ParetoPickerFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := ParetoPickerParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' picker - %w", ParetoPickerType, err)
+		}
+	}
+
+	pluginRefs := make([]string, len(parameters.Objectives))
+	for i, objectiveParameters := range parameters.Objectives {
+		pluginRefs[i] = objectiveParameters.PluginRef
+	}
+	scorers, err := composite.ResolveScorers(handle, pluginRefs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid objectives of the '%s' picker - %w", ParetoPickerType, err)
+	}
+
+	picker, err := NewParetoPicker(&parameters, scorers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' picker - %w", ParetoPickerType, err)
+	}
+	return picker.WithName(name), nil
+}
+
+// NewParetoPicker creates a new ParetoPicker evaluating the given scorers, in the order of params.Objectives.
+func NewParetoPicker(params *ParetoPickerParameters, scorers []framework.Scorer) (*ParetoPicker, error) {
+	if len(params.Objectives) == 0 {
+		return nil, errors.New("at least one objective is required")
+	}
+	if len(params.Objectives) != len(scorers) {
+		return nil, fmt.Errorf("expected %d scorers, got %d", len(params.Objectives), len(scorers))
+	}
+
+	policy := params.Policy
+	switch policy {
+	case "":
+		policy = PolicyUtopiaDistance
+	case PolicyUtopiaDistance, PolicyMaxMin, PolicyLexicographic, PolicyRandom:
+	default:
+		return nil, fmt.Errorf("invalid policy: must be '%s', '%s', '%s' or '%s', got '%s'",
+			PolicyUtopiaDistance, PolicyMaxMin, PolicyLexicographic, PolicyRandom, policy)
+	}
+
+	maxNumOfEndpoints := params.MaxNumOfEndpoints
+	if maxNumOfEndpoints <= 0 {
+		maxNumOfEndpoints = defaultMaxNumOfEndpoints
+	}
+
+	objectives := make([]objective, len(scorers))
+	for i, objectiveParameters := range params.Objectives {
+		weight := objectiveParameters.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("invalid weight for '%s': must be > 0, got %v", objectiveParameters.PluginRef, weight)
+		}
+		objectives[i] = objective{scorer: scorers[i], weight: weight}
+	}
+
+	return &ParetoPicker{
+		typedName:         plugins.TypedName{Type: ParetoPickerType},
+		objectives:        objectives,
+		policy:            policy,
+		maxNumOfEndpoints: maxNumOfEndpoints,
+	}, nil
+}
+
+// ParetoPicker treats scorers as separate objectives, such as latency, cache affinity or cost,
+// instead of collapsing them into one weighted sum. It picks from the Pareto-optimal pods, the
+// pods no other pod beats on every objective, according to a configurable policy.
+//
+// As pickers do not receive the request, the ParetoPicker evaluates the objectives as a scorer
+// giving the same score to all pods, and stores the objective scores in the cycle state for the
+// pick. Referencing it in a scheduling profile registers it as both.
+type ParetoPicker struct {
+	typedName         plugins.TypedName
+	objectives        []objective
+	policy            string
+	maxNumOfEndpoints int
+}
+
+// TypedName returns the typed name of the plugin.
+func (p *ParetoPicker) TypedName() plugins.TypedName {
+	return p.typedName
+}
+
+// WithName sets the name of the plugin.
+func (p *ParetoPicker) WithName(name string) *ParetoPicker {
+	p.typedName.Name = name
+	return p
+}
+
+// Score evaluates the objectives of the pods and stores them in the cycle state.
+// It gives the same score to all pods.
+func (p *ParetoPicker) Score(ctx context.Context, cycleState *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	state := &objectivesState{scores: make(map[types.Pod][]float64, len(pods))}
+	for _, pod := range pods {
+		state.scores[pod] = make([]float64, len(p.objectives))
+	}
+	for i, objective := range p.objectives {
+		for pod, score := range objective.scorer.Score(ctx, cycleState, request, pods) {
+			if scores, ok := state.scores[pod]; ok {
+				scores[i] = min(max(score, 0), 1)
+			}
+		}
+	}
+	cycleState.Write(plugins.StateKey(p.typedName.String()), state)
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+	}
+	return scoredPods
+}
+
+// Pick selects the pods from the Pareto-optimal set according to the policy. When more pods
+// are requested than the set holds, the next Pareto fronts are used.
+func (p *ParetoPicker) Pick(ctx context.Context, cycleState *types.CycleState, scoredPods []*types.ScoredPod) *types.ProfileRunResult {
+	logger := log.FromContext(ctx)
+
+	state, err := types.ReadCycleStateKey[*objectivesState](cycleState, plugins.StateKey(p.typedName.String()))
+	if err != nil {
+		// the objectives were not evaluated, the picker was not referenced as a scorer
+		logger.Error(err, "Objective scores not found, picking by the aggregated score")
+	}
+
+	candidates := make([]*candidate, len(scoredPods))
+	for i, scoredPod := range scoredPods {
+		var scores []float64
+		switch {
+		case state == nil:
+			scores = []float64{scoredPod.Score}
+		case state.scores[scoredPod.Pod] != nil:
+			scores = state.scores[scoredPod.Pod]
+		default:
+			scores = make([]float64, len(p.objectives))
+		}
+		candidates[i] = &candidate{scoredPod: scoredPod, scores: scores}
+	}
+
+	// random tie break between pods ranked equally
+	randomGenerator := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+	randomGenerator.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	assignFronts(candidates)
+	p.assignPolicyRanks(candidates)
+
+	slices.SortStableFunc(candidates, func(a, b *candidate) int {
+		if a.front != b.front {
+			return a.front - b.front
+		}
+		if a.rank > b.rank {
+			return -1
+		}
+		if a.rank < b.rank {
+			return 1
+		}
+		return 0
+	})
+
+	if p.maxNumOfEndpoints < len(candidates) {
+		candidates = candidates[:p.maxNumOfEndpoints]
+	}
+
+	targetPods := make([]types.Pod, len(candidates))
+	for i, candidate := range candidates {
+		targetPods[i] = candidate.scoredPod
+	}
+
+	logger.V(logutil.DEBUG).Info("Picked pods from the Pareto fronts", "policy", p.policy, "targetPods", targetPods)
+	return &types.ProfileRunResult{TargetPods: targetPods}
+}
+
+// candidate is a pod with its objective scores, its Pareto front and its rank by the policy
+type candidate struct {
+	scoredPod *types.ScoredPod
+	scores    []float64
+	front     int
+	rank      float64
+}
+
+// dominates returns true when a is at least as good as b on every objective and better on one
+func dominates(a *candidate, b *candidate) bool {
+	better := false
+	for i := range a.scores {
+		if a.scores[i] < b.scores[i] {
+			return false
+		}
+		if a.scores[i] > b.scores[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// assignFronts assigns the candidates to successive Pareto fronts, front 0 being the
+// Pareto-optimal set and front n the Pareto-optimal set once fronts 0 to n-1 are removed
+func assignFronts(candidates []*candidate) {
+	remaining := slices.Clone(candidates)
+	for front := 0; len(remaining) > 0; front++ {
+		next := remaining[:0:0]
+		for _, c := range remaining {
+			dominated := slices.ContainsFunc(remaining, func(other *candidate) bool {
+				return dominates(other, c)
+			})
+			if dominated {
+				next = append(next, c)
+			} else {
+				c.front = front
+			}
+		}
+		remaining = next
+	}
+}
+
+// assignPolicyRanks ranks the candidates according to the policy, higher being better
+func (p *ParetoPicker) assignPolicyRanks(candidates []*candidate) {
+	switch p.policy {
+	case PolicyUtopiaDistance:
+		// the utopia point holds the best score of every objective
+		utopia := make([]float64, 0)
+		for _, c := range candidates {
+			for i, score := range c.scores {
+				if i == len(utopia) {
+					utopia = append(utopia, score)
+				}
+				utopia[i] = max(utopia[i], score)
+			}
+		}
+		for _, c := range candidates {
+			distance := 0.0
+			for i, score := range c.scores {
+				distance += p.weight(i) * (utopia[i] - score) * (utopia[i] - score)
+			}
+			c.rank = -math.Sqrt(distance)
+		}
+	case PolicyMaxMin:
+		for _, c := range candidates {
+			c.rank = math.Inf(1)
+			for i, score := range c.scores {
+				c.rank = min(c.rank, p.weight(i)*score)
+			}
+		}
+	case PolicyLexicographic:
+		// sort the candidates by their objective scores, in order, and rank them by position
+		sorted := slices.Clone(candidates)
+		slices.SortStableFunc(sorted, func(a, b *candidate) int {
+			return -slices.Compare(a.scores, b.scores)
+		})
+		for i, c := range sorted {
+			c.rank = -float64(i)
+			if i > 0 && slices.Equal(c.scores, sorted[i-1].scores) {
+				c.rank = sorted[i-1].rank
+			}
+		}
+	case PolicyRandom:
+		for _, c := range candidates {
+			c.rank = 0
+		}
+	}
+}
+
+// weight returns the weight of the i-th objective, or 1 when picking by the aggregated score
+func (p *ParetoPicker) weight(i int) float64 {
+	if i < len(p.objectives) {
+		return p.objectives[i].weight
+	}
+	return 1
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite/compositetest"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/picker"
+)
+
+func newTestParetoPicker(t *testing.T, policy string, maxNumOfEndpoints int) *picker.ParetoPicker {
+	t.Helper()
+
+	// pod-d is dominated by pod-c, the other pods are Pareto-optimal
+	latency := compositetest.NewFixedScorer("latency", map[string]float64{"pod-a": 1, "pod-b": 0, "pod-c": 0.6, "pod-d": 0.5})
+	cache := compositetest.NewFixedScorer("cache", map[string]float64{"pod-a": 0, "pod-b": 1, "pod-c": 0.6, "pod-d": 0.5})
+
+	paretoPicker, err := picker.NewParetoPicker(&picker.ParetoPickerParameters{
+		Objectives:        []picker.ObjectiveParameters{{PluginRef: "latency"}, {PluginRef: "cache"}},
+		Policy:            policy,
+		MaxNumOfEndpoints: maxNumOfEndpoints,
+	}, []framework.Scorer{latency, cache})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return paretoPicker
+}
+
+// pick runs the score and pick steps of a scheduling cycle and returns the names of the picked pods
+func pick(ctx context.Context, paretoPicker *picker.ParetoPicker, pods []types.Pod) []string {
+	cycleState := types.NewCycleState()
+	scores := paretoPicker.Score(ctx, cycleState, &types.LLMRequest{}, pods)
+
+	scoredPods := make([]*types.ScoredPod, len(pods))
+	for i, pod := range pods {
+		scoredPods[i] = &types.ScoredPod{Pod: pod, Score: scores[pod]}
+	}
+
+	result := paretoPicker.Pick(ctx, cycleState, scoredPods)
+	names := make([]string, len(result.TargetPods))
+	for i, pod := range result.TargetPods {
+		names[i] = pod.GetPod().NamespacedName.Name
+	}
+	return names
+}
+
+func TestParetoPicker(t *testing.T) {
+	pods := []types.Pod{compositetest.NewPod("pod-a"), compositetest.NewPod("pod-b"), compositetest.NewPod("pod-c"), compositetest.NewPod("pod-d")}
+
+	tests := []struct {
+		name              string
+		policy            string
+		maxNumOfEndpoints int
+		want              []string
+	}{
+		{
+			name:   "utopia distance is the default policy",
+			policy: "",
+			want:   []string{"pod-c"},
+		},
+		{
+			name:   "max-min",
+			policy: picker.PolicyMaxMin,
+			want:   []string{"pod-c"},
+		},
+		{
+			name:   "lexicographic",
+			policy: picker.PolicyLexicographic,
+			want:   []string{"pod-a"},
+		},
+		{
+			name:              "dominated pods come last",
+			policy:            picker.PolicyUtopiaDistance,
+			maxNumOfEndpoints: 4,
+			want:              []string{"pod-c", "pod-a", "pod-b", "pod-d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			paretoPicker := newTestParetoPicker(t, test.policy, test.maxNumOfEndpoints)
+			got := pick(ctx, paretoPicker, pods)
+			if test.maxNumOfEndpoints > 1 {
+				// pod-a and pod-b are at the same distance, their order is random
+				if got[1] == "pod-b" {
+					got[1], got[2] = got[2], got[1]
+				}
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestParetoPickerRandomPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pods := []types.Pod{compositetest.NewPod("pod-a"), compositetest.NewPod("pod-b"), compositetest.NewPod("pod-c"), compositetest.NewPod("pod-d")}
+	paretoPicker := newTestParetoPicker(t, picker.PolicyRandom, 1)
+
+	for range 50 {
+		if got := pick(ctx, paretoPicker, pods); got[0] == "pod-d" {
+			t.Fatalf("picked the dominated pod")
+		}
+	}
+}
+
+func TestParetoPickerWithoutObjectiveScores(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	podA := compositetest.NewPod("pod-a")
+	podB := compositetest.NewPod("pod-b")
+	paretoPicker := newTestParetoPicker(t, "", 1)
+
+	// the picker was not called as a scorer: the aggregated score is used
+	result := paretoPicker.Pick(ctx, types.NewCycleState(), []*types.ScoredPod{{Pod: podA, Score: 0.2}, {Pod: podB, Score: 0.8}})
+	if len(result.TargetPods) != 1 || result.TargetPods[0].GetPod().NamespacedName.Name != "pod-b" {
+		t.Errorf("expected pod-b to be picked, got %v", result.TargetPods)
+	}
+}
+
+func TestNewParetoPickerErrors(t *testing.T) {
+	scorer := compositetest.NewFixedScorer("latency", nil)
+
+	tests := []struct {
+		name    string
+		params  *picker.ParetoPickerParameters
+		scorers []framework.Scorer
+	}{
+		{name: "no objectives", params: &picker.ParetoPickerParameters{}},
+		{
+			name:    "invalid policy",
+			params:  &picker.ParetoPickerParameters{Objectives: []picker.ObjectiveParameters{{PluginRef: "latency"}}, Policy: "best"},
+			scorers: []framework.Scorer{scorer},
+		},
+		{
+			name:    "negative weight",
+			params:  &picker.ParetoPickerParameters{Objectives: []picker.ObjectiveParameters{{PluginRef: "latency", Weight: -1}}},
+			scorers: []framework.Scorer{scorer},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := picker.NewParetoPicker(test.params, test.scorers); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
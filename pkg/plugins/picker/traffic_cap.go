@@ -0,0 +1,229 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	giepicker "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// TrafficCapPickerType is the type of the TrafficCapPicker
+	TrafficCapPickerType = "traffic-cap-picker"
+
+	defaultTrafficCapWindow      = time.Minute
+	defaultTrafficCapMinRequests = 20
+)
+
+// TrafficCapPickerParameters defines the parameters of the TrafficCapPicker.
+type TrafficCapPickerParameters struct {
+	// MaxFraction is the maximum fraction, between 0 and 1, of the recent requests routed to any single pod.
+	MaxFraction float64 `json:"maxFraction"`
+
+	// Window is the sliding window the fractions of the requests are computed over.
+	// This field accepts duration strings like "30s", "1m". Defaults to "1m".
+	Window string `json:"window"`
+
+	// MinRequests is the number of requests of the window below which the pods are not capped,
+	// so that a few requests do not trip the cap. Defaults to 20.
+	MinRequests int `json:"minRequests"`
+
+	// PickerRef is the name of the picker selecting among the pods under the cap. It must be defined
+	// before the TrafficCapPicker. Defaults to a max-score picker.
+	PickerRef string `json:"pickerRef"`
+
+	// MaxNumOfEndpoints is the maximum number of pods picked by the default max-score picker. Defaults to 1.
+	MaxNumOfEndpoints int `json:"maxNumOfEndpoints"`
+}
+
+// trafficCapEntry is a request of the sliding window and the pod it was routed to
+type trafficCapEntry struct {
+	time time.Time
+	pod  string
+}
+
+// compile-time type assertion
+var _ framework.Picker = &TrafficCapPicker{}
+
+// TrafficCapPickerFactory defines the factory function for the TrafficCapPicker.
+func TrafficCapPickerFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := TrafficCapPickerParameters{MinRequests: defaultTrafficCapMinRequests}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' picker - %w", TrafficCapPickerType, err)
+		}
+	}
+
+	window := defaultTrafficCapWindow
+	if parameters.Window != "" {
+		duration, err := time.ParseDuration(parameters.Window)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid window: must be a positive duration, got '%s'", parameters.Window)
+		}
+		window = duration
+	}
+
+	var picker framework.Picker
+	if parameters.PickerRef != "" {
+		var ok bool
+		picker, ok = handle.Plugin(parameters.PickerRef).(framework.Picker)
+		if !ok {
+			return nil, fmt.Errorf("the '%s' picker references '%s' which is not a picker defined before it",
+				TrafficCapPickerType, parameters.PickerRef)
+		}
+	} else {
+		maxNumOfEndpoints := parameters.MaxNumOfEndpoints
+		if maxNumOfEndpoints <= 0 {
+			maxNumOfEndpoints = defaultMaxNumOfEndpoints
+		}
+		picker = giepicker.NewMaxScorePicker(maxNumOfEndpoints)
+	}
+
+	capPicker, err := NewTrafficCapPicker(parameters.MaxFraction, window, parameters.MinRequests, picker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' picker - %w", TrafficCapPickerType, err)
+	}
+	return capPicker.WithName(name), nil
+}
+
+// NewTrafficCapPicker creates a new TrafficCapPicker capping the fraction of the requests of the
+// window routed to any single pod, the picking among the pods under the cap being done by picker.
+func NewTrafficCapPicker(maxFraction float64, window time.Duration, minRequests int, picker framework.Picker) (*TrafficCapPicker, error) {
+	if maxFraction <= 0 || maxFraction > 1 {
+		return nil, fmt.Errorf("invalid maxFraction: must be in (0, 1], got %v", maxFraction)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid window: must be positive, got %v", window)
+	}
+	if minRequests < 0 {
+		return nil, fmt.Errorf("invalid minRequests: must be >= 0, got %d", minRequests)
+	}
+	if picker == nil {
+		return nil, errors.New("a picker is required")
+	}
+
+	return &TrafficCapPicker{
+		typedName:   plugins.TypedName{Type: TrafficCapPickerType},
+		maxFraction: maxFraction,
+		window:      window,
+		minRequests: minRequests,
+		picker:      picker,
+		counts:      map[string]int{},
+	}, nil
+}
+
+// TrafficCapPicker is a safety valve capping the fraction of the recent requests routed to any single
+// pod. The pods which would exceed the cap are removed from the scored pods before they are handed to
+// the wrapped picker, overriding the affinity scorers, e.g. the prefix cache and session affinity ones,
+// when they would concentrate the load on one replica. All the pods are kept when all of them exceed
+// the cap, since there is no better choice.
+type TrafficCapPicker struct {
+	typedName   plugins.TypedName
+	maxFraction float64
+	window      time.Duration
+	minRequests int
+	picker      framework.Picker
+
+	mutex   sync.Mutex
+	entries []trafficCapEntry // the requests of the window, oldest first
+	counts  map[string]int    // the number of requests of the window by pod
+}
+
+// TypedName returns the typed name of the plugin.
+func (p *TrafficCapPicker) TypedName() plugins.TypedName {
+	return p.typedName
+}
+
+// WithName sets the name of the plugin.
+func (p *TrafficCapPicker) WithName(name string) *TrafficCapPicker {
+	p.typedName.Name = name
+	return p
+}
+
+// Pick removes the pods which would exceed the cap from the scored pods, picks among the others with
+// the wrapped picker, and records the request as routed to the first picked pod.
+func (p *TrafficCapPicker) Pick(ctx context.Context, cycleState *types.CycleState, scoredPods []*types.ScoredPod) *types.ProfileRunResult {
+	now := time.Now()
+
+	p.mutex.Lock()
+	p.expire(now)
+	candidates := make([]*types.ScoredPod, 0, len(scoredPods))
+	for _, scoredPod := range scoredPods {
+		if !p.exceedsCap(scoredPod.GetPod().NamespacedName.String()) {
+			candidates = append(candidates, scoredPod)
+		}
+	}
+	p.mutex.Unlock()
+
+	switch {
+	case len(candidates) == 0:
+		log.FromContext(ctx).V(logutil.DEBUG).Info("All the pods exceed the traffic cap, keeping all of them",
+			"maxFraction", p.maxFraction)
+		candidates = scoredPods
+	case len(candidates) < len(scoredPods):
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Removed the pods exceeding the traffic cap",
+			"maxFraction", p.maxFraction, "capped", len(scoredPods)-len(candidates))
+	}
+
+	result := p.picker.Pick(ctx, cycleState, candidates)
+	if result != nil && len(result.TargetPods) > 0 {
+		p.mutex.Lock()
+		p.record(now, result.TargetPods[0].GetPod().NamespacedName.String())
+		p.mutex.Unlock()
+	}
+	return result
+}
+
+// exceedsCap returns true when routing one more request to the pod would exceed the cap.
+// Must be called with the mutex held.
+func (p *TrafficCapPicker) exceedsCap(pod string) bool {
+	total := len(p.entries)
+	if total < p.minRequests {
+		return false
+	}
+	return float64(p.counts[pod]+1) > p.maxFraction*float64(total+1)
+}
+
+// record adds a request routed to the pod to the window. Must be called with the mutex held.
+func (p *TrafficCapPicker) record(now time.Time, pod string) {
+	p.entries = append(p.entries, trafficCapEntry{time: now, pod: pod})
+	p.counts[pod]++
+}
+
+// expire removes the requests older than the window. Must be called with the mutex held.
+func (p *TrafficCapPicker) expire(now time.Time) {
+	expired := 0
+	for expired < len(p.entries) && now.Sub(p.entries[expired].time) > p.window {
+		pod := p.entries[expired].pod
+		if p.counts[pod]--; p.counts[pod] == 0 {
+			delete(p.counts, pod)
+		}
+		expired++
+	}
+	p.entries = p.entries[expired:]
+}
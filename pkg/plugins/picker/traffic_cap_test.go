@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	giepicker "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite/compositetest"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/picker"
+)
+
+// pickCapped runs the pick step of n scheduling cycles over pods scored in decreasing order, and
+// returns the number of requests routed to each pod
+func pickCapped(ctx context.Context, capPicker *picker.TrafficCapPicker, pods []types.Pod, n int) map[string]int {
+	counts := map[string]int{}
+	for range n {
+		scoredPods := make([]*types.ScoredPod, len(pods))
+		for i, pod := range pods {
+			scoredPods[i] = &types.ScoredPod{Pod: pod, Score: float64(len(pods) - i)}
+		}
+		result := capPicker.Pick(ctx, types.NewCycleState(), scoredPods)
+		counts[result.TargetPods[0].GetPod().NamespacedName.Name]++
+	}
+	return counts
+}
+
+func TestTrafficCapPicker(t *testing.T) {
+	ctx := context.Background()
+	pods := []types.Pod{compositetest.NewPod("pod-a"), compositetest.NewPod("pod-b"), compositetest.NewPod("pod-c")}
+
+	tests := []struct {
+		name        string
+		maxFraction float64
+		minRequests int
+		pods        []types.Pod
+		wantMax     int // the maximum number of the 100 requests routed to any pod
+	}{
+		{
+			name:        "pods not capped below the minimum requests",
+			maxFraction: 0.5,
+			minRequests: 100,
+			pods:        pods,
+			wantMax:     100,
+		},
+		{
+			name:        "best pod capped to the max fraction",
+			maxFraction: 0.5,
+			minRequests: 10,
+			pods:        pods,
+			wantMax:     50,
+		},
+		{
+			name:        "traffic spread over the pods under the cap",
+			maxFraction: 0.3,
+			minRequests: 10,
+			pods:        append(pods, compositetest.NewPod("pod-d")),
+			wantMax:     30,
+		},
+		{
+			name:        "all pods kept when all of them exceed the cap",
+			maxFraction: 0.5,
+			pods:        pods[:1],
+			wantMax:     100,
+		},
+		{
+			name:        "pods never capped with a max fraction of 1",
+			maxFraction: 1,
+			pods:        pods,
+			wantMax:     100,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			capPicker, err := picker.NewTrafficCapPicker(test.maxFraction, time.Minute, test.minRequests, giepicker.NewMaxScorePicker(1))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			counts := pickCapped(ctx, capPicker, test.pods, 100)
+			if counts["pod-a"] != test.wantMax {
+				t.Errorf("expected %d requests routed to the best pod, got %v", test.wantMax, counts)
+			}
+			for pod, count := range counts {
+				if count > test.wantMax {
+					t.Errorf("expected at most %d requests routed to %s, got %v", test.wantMax, pod, counts)
+				}
+			}
+		})
+	}
+}
+
+func TestTrafficCapPickerWindow(t *testing.T) {
+	ctx := context.Background()
+	pods := []types.Pod{compositetest.NewPod("pod-a"), compositetest.NewPod("pod-b")}
+
+	capPicker, err := picker.NewTrafficCapPicker(0.5, 20*time.Millisecond, 0, giepicker.NewMaxScorePicker(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := pickCapped(ctx, capPicker, pods, 2); got["pod-a"] != 1 || got["pod-b"] != 1 {
+		t.Fatalf("expected the requests to be split by the cap, got %v", got)
+	}
+
+	// the requests routed before the window expired no longer count
+	time.Sleep(30 * time.Millisecond)
+	if got := pickCapped(ctx, capPicker, pods, 1); got["pod-a"] != 1 {
+		t.Errorf("expected the best pod once the window expired, got %v", got)
+	}
+}
+
+func TestTrafficCapPickerFactory(t *testing.T) {
+	handle := plugins.NewEppHandle(context.Background(), nil)
+	handle.AddPlugin("max-score", giepicker.NewMaxScorePicker(2))
+	handle.AddPlugin("latency", compositetest.NewFixedScorer("latency", nil))
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "default picker", parameters: `{"maxFraction": 0.5}`},
+		{name: "referenced picker", parameters: `{"maxFraction": 0.5, "window": "30s", "minRequests": 10, "pickerRef": "max-score"}`},
+		{name: "missing max fraction", parameters: `{}`, wantErr: true},
+		{name: "max fraction above 1", parameters: `{"maxFraction": 1.5}`, wantErr: true},
+		{name: "invalid window", parameters: `{"maxFraction": 0.5, "window": "-1s"}`, wantErr: true},
+		{name: "negative min requests", parameters: `{"maxFraction": 0.5, "minRequests": -1}`, wantErr: true},
+		{name: "reference to a scorer", parameters: `{"maxFraction": 0.5, "pickerRef": "latency"}`, wantErr: true},
+		{name: "reference to a missing picker", parameters: `{"maxFraction": 0.5, "pickerRef": "missing"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plugin, err := picker.TrafficCapPickerFactory("cap", json.RawMessage(test.parameters), handle)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("TrafficCapPickerFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err == nil && plugin.TypedName() != (plugins.TypedName{Type: picker.TrafficCapPickerType, Name: "cap"}) {
+				t.Errorf("unexpected typed name %v", plugin.TypedName())
+			}
+		})
+	}
+}
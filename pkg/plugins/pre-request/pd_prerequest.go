@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
@@ -77,7 +78,10 @@ func (p *PrefillHeaderHandler) PreRequest(_ context.Context, request *types.LLMR
 		return // prefill profile failed to run or we chose not to run it, no-op in this case
 	}
 
-	targetPod := prefillProfileRunResult.TargetPods[0].GetPod()
-	prefillHostPort := net.JoinHostPort(targetPod.Address, targetPod.Port)
-	request.Headers[common.PrefillPodHeader] = prefillHostPort // in the form of <ip:port>
+	// the target pods are ranked by the picker, the sidecar fails over to the next one when a prefill fails
+	prefillHostPorts := make([]string, 0, len(prefillProfileRunResult.TargetPods))
+	for _, targetPod := range prefillProfileRunResult.TargetPods {
+		prefillHostPorts = append(prefillHostPorts, net.JoinHostPort(targetPod.GetPod().Address, targetPod.GetPod().Port))
+	}
+	request.Headers[common.PrefillPodHeader] = strings.Join(prefillHostPorts, ",") // in the form of <ip:port>[,<ip:port>...]
 }
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/classifier"
+)
+
+const (
+	// ComputeClassProfileHandlerType is the type of the ComputeClassProfileHandler
+	ComputeClassProfileHandlerType = "compute-class-profile-handler"
+
+	defaultClassifierRef = classifier.RequestClassifierType
+)
+
+type computeClassProfileHandlerParameters struct {
+	ClassifierRef string            `json:"classifierRef"`
+	Profiles      map[string]string `json:"profiles"`
+}
+
+// compile-time type assertion
+var _ framework.ProfileHandler = &ComputeClassProfileHandler{}
+
+// ComputeClassProfileHandlerFactory defines the factory function for the ComputeClassProfileHandler
+func ComputeClassProfileHandlerFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := computeClassProfileHandlerParameters{
+		ClassifierRef: defaultClassifierRef,
+	}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' profile handler - %w", ComputeClassProfileHandlerType, err)
+		}
+	}
+
+	requestClassifier, ok := handle.Plugin(parameters.ClassifierRef).(classifier.Classifier)
+	if !ok {
+		return nil, fmt.Errorf("the '%s' profile handler references '%s' which is not a classifier defined before it",
+			ComputeClassProfileHandlerType, parameters.ClassifierRef)
+	}
+
+	return NewComputeClassProfileHandler(requestClassifier, parameters.Profiles).WithName(name), nil
+}
+
+// NewComputeClassProfileHandler initializes a new ComputeClassProfileHandler and returns its pointer.
+// The profiles map the classes to the names of the profiles scheduling them. A class without a
+// profile in the map is scheduled by the profile named after the class.
+func NewComputeClassProfileHandler(requestClassifier classifier.Classifier, profiles map[string]string) *ComputeClassProfileHandler {
+	return &ComputeClassProfileHandler{
+		typedName:  plugins.TypedName{Type: ComputeClassProfileHandlerType},
+		classifier: requestClassifier,
+		profiles:   profiles,
+	}
+}
+
+// ComputeClassProfileHandler lets a single EPP manage several subsets of a pool, such as pods of
+// different compute classes, each with its own scheduling profile. A classifier assigns each
+// request to a class, and the request is scheduled by the profile of its class only. The profiles
+// typically select their subset with a label filter.
+type ComputeClassProfileHandler struct {
+	typedName  plugins.TypedName
+	classifier classifier.Classifier
+	profiles   map[string]string
+}
+
+// TypedName returns the typed name of the plugin.
+func (h *ComputeClassProfileHandler) TypedName() plugins.TypedName {
+	return h.typedName
+}
+
+// WithName sets the name of the plugin.
+func (h *ComputeClassProfileHandler) WithName(name string) *ComputeClassProfileHandler {
+	h.typedName.Name = name
+	return h
+}
+
+// Pick selects the profile of the request class. No profile is selected when the class has no profile.
+func (h *ComputeClassProfileHandler) Pick(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, profiles map[string]*framework.SchedulerProfile,
+	profileResults map[string]*types.ProfileRunResult) map[string]*framework.SchedulerProfile {
+	if len(profileResults) > 0 { // the profile of the request class was executed already in previous call
+		return map[string]*framework.SchedulerProfile{}
+	}
+
+	class := h.classifier.Classify(ctx, request)
+	profileName := h.profileName(class)
+	profile, ok := profiles[profileName]
+	if !ok {
+		log.FromContext(ctx).Error(nil, "No scheduling profile for the request class", "class", class, "profile", profileName)
+		return map[string]*framework.SchedulerProfile{}
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Classified request", "class", class, "profile", profileName)
+	return map[string]*framework.SchedulerProfile{profileName: profile}
+}
+
+// ProcessResults returns the result of the profile of the request class as the primary result.
+func (h *ComputeClassProfileHandler) ProcessResults(_ context.Context, _ *types.CycleState, _ *types.LLMRequest,
+	profileResults map[string]*types.ProfileRunResult) (*types.SchedulingResult, error) {
+	if len(profileResults) != 1 {
+		return nil, errors.New("compute class profile handler expects the result of a single profile, the request class has no profile")
+	}
+
+	var profileName string
+	for name := range profileResults {
+		profileName = name
+	}
+
+	if profileResults[profileName] == nil { // there was an error while running the profile
+		return nil, fmt.Errorf("failed to run scheduler profile '%s'", profileName)
+	}
+
+	return &types.SchedulingResult{
+		ProfileResults:     profileResults,
+		PrimaryProfileName: profileName,
+	}, nil
+}
+
+// profileName returns the name of the profile scheduling the requests of the class
+func (h *ComputeClassProfileHandler) profileName(class string) string {
+	if profileName, ok := h.profiles[class]; ok {
+		return profileName
+	}
+	return class
+}
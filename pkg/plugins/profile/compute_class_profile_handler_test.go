@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/classifier"
+)
+
+func TestComputeClassProfileHandler(t *testing.T) {
+	requestClassifier, err := classifier.NewRequestClassifier(&classifier.RequestClassifierParameters{
+		Rules:        []classifier.RuleParameters{{Class: "high", Models: []string{"large-model"}}, {Class: "medium", Models: []string{"medium-model"}}},
+		DefaultClass: "low",
+	})
+	assert.NoError(t, err)
+
+	handler := NewComputeClassProfileHandler(requestClassifier, map[string]string{"high": "high-profile"})
+	profiles := map[string]*framework.SchedulerProfile{
+		"high-profile": framework.NewSchedulerProfile(),
+		"low":          framework.NewSchedulerProfile(),
+	}
+
+	tests := []struct {
+		name        string
+		model       string
+		wantProfile string
+	}{
+		{name: "class with a mapped profile", model: "large-model", wantProfile: "high-profile"},
+		{name: "class with a profile named after it", model: "small-model", wantProfile: "low"},
+		{name: "class without profile", model: "medium-model", wantProfile: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			request := &types.LLMRequest{TargetModel: tt.model, Headers: map[string]string{}}
+
+			picked := handler.Pick(ctx, types.NewCycleState(), request, profiles, map[string]*types.ProfileRunResult{})
+			if tt.wantProfile == "" {
+				assert.Empty(t, picked)
+				_, err := handler.ProcessResults(ctx, types.NewCycleState(), request, map[string]*types.ProfileRunResult{})
+				assert.Error(t, err)
+				return
+			}
+			assert.Equal(t, map[string]*framework.SchedulerProfile{tt.wantProfile: profiles[tt.wantProfile]}, picked)
+
+			pod := &types.PodMetrics{Pod: &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}}}
+			profileResults := map[string]*types.ProfileRunResult{tt.wantProfile: {TargetPods: []types.Pod{pod}}}
+			assert.Empty(t, handler.Pick(ctx, types.NewCycleState(), request, profiles, profileResults))
+
+			result, err := handler.ProcessResults(ctx, types.NewCycleState(), request, profileResults)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantProfile, result.PrimaryProfileName)
+		})
+	}
+}
+
+func TestComputeClassProfileHandlerProfileFailure(t *testing.T) {
+	requestClassifier, err := classifier.NewRequestClassifier(&classifier.RequestClassifierParameters{DefaultClass: "low"})
+	assert.NoError(t, err)
+
+	handler := NewComputeClassProfileHandler(requestClassifier, nil)
+	_, err = handler.ProcessResults(context.Background(), types.NewCycleState(), &types.LLMRequest{},
+		map[string]*types.ProfileRunResult{"low": nil})
+	assert.Error(t, err)
+}
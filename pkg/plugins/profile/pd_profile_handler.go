@@ -17,6 +17,7 @@ import (
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
 )
 
 const (
@@ -30,25 +31,33 @@ const (
 
 type pdProfileHandlerParameters struct {
 	Threshold        int    `json:"threshold"`
+	BlockThreshold   int    `json:"blockThreshold"`
 	DecodeProfile    string `json:"decodeProfile"`
 	PrefillProfile   string `json:"prefillProfile"`
 	PrefixPluginName string `json:"prefixPluginName"`
 	HashBlockSize    int    `json:"hashBlockSize"`
 	PrimaryPort      int    `json:"primaryPort"`
+	// HashingRef is the prefix-hashing plugin the block sizes are read from, instead of hashBlockSize
+	HashingRef string `json:"hashingRef"`
+	// NeverDisaggregatePaths are the request paths scheduled on a decode pod only
+	NeverDisaggregatePaths []string `json:"neverDisaggregatePaths"`
 }
 
 // compile-time type assertion
 var _ framework.ProfileHandler = &PdProfileHandler{}
 
 // PdProfileHandlerFactory defines the factory function for the PdProfileHandler
-func PdProfileHandlerFactory(name string, rawParameters json.RawMessage, _ plugins.Handle) (plugins.Plugin, error) {
+func PdProfileHandlerFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
 	parameters := pdProfileHandlerParameters{
 		Threshold:        0,
+		BlockThreshold:   0,
 		DecodeProfile:    defaultDecodeProfile,
 		PrefillProfile:   defaultPrefillProfile,
 		PrefixPluginName: defaultPrefixPluginName,
 		HashBlockSize:    prefix.DefaultBlockSize,
 		PrimaryPort:      0,
+		// pooling requests generate no tokens, so there is nothing to decode after their prefill
+		NeverDisaggregatePaths: []string{common.PoolingPathsAlias},
 	}
 	if rawParameters != nil {
 		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
@@ -60,6 +69,14 @@ func PdProfileHandlerFactory(name string, rawParameters json.RawMessage, _ plugi
 		return nil, fmt.Errorf("invalid threshold: must be >= 0, got %d", parameters.Threshold)
 	}
 
+	if parameters.BlockThreshold < 0 {
+		return nil, fmt.Errorf("invalid blockThreshold: must be >= 0, got %d", parameters.BlockThreshold)
+	}
+
+	if parameters.Threshold > 0 && parameters.BlockThreshold > 0 {
+		return nil, errors.New("invalid thresholds: threshold and blockThreshold are mutually exclusive")
+	}
+
 	if parameters.HashBlockSize <= 0 {
 		return nil, fmt.Errorf("invalid hashBlockSize: must be > 0, got %d", parameters.HashBlockSize)
 	}
@@ -70,19 +87,36 @@ func PdProfileHandlerFactory(name string, rawParameters json.RawMessage, _ plugi
 		}
 	}
 
-	return NewPdProfileHandler(parameters.PrefillProfile, parameters.DecodeProfile, parameters.PrefixPluginName,
-		parameters.Threshold, parameters.HashBlockSize, parameters.PrimaryPort).WithName(name), nil
+	handler := NewPdProfileHandler(parameters.PrefillProfile, parameters.DecodeProfile, parameters.PrefixPluginName,
+		parameters.Threshold, parameters.BlockThreshold, parameters.HashBlockSize, parameters.PrimaryPort).WithName(name)
+	handler.neverDisaggregatePaths = parameters.NeverDisaggregatePaths
+
+	if parameters.HashingRef != "" {
+		prefixHashing, ok := handle.Plugin(parameters.HashingRef).(*hashing.PrefixHashing)
+		if !ok {
+			return nil, fmt.Errorf("the '%s' profile handler references '%s' which is not a %s plugin defined before it",
+				PdProfileHandlerType, parameters.HashingRef, hashing.PrefixHashingType)
+		}
+		handler.hashing = prefixHashing
+	}
+	return handler, nil
 }
 
 // NewPdProfileHandler initializes a new PdProfileHandler and returns its pointer.
-func NewPdProfileHandler(prefillProfile string, decodeProfile string, prefixPluginName string, pdThreshold int, hashBlockSize int, primaryPort int) *PdProfileHandler {
+// The prefill profile runs when the non-cached prompt suffix is at least pdThreshold bytes long, or,
+// when blockThreshold is set, when at least blockThreshold prompt blocks are not cached on the decode pod.
+// The pooling requests are never disaggregated.
+func NewPdProfileHandler(prefillProfile string, decodeProfile string, prefixPluginName string, pdThreshold int, blockThreshold int,
+	hashBlockSize int, primaryPort int) *PdProfileHandler {
 	result := &PdProfileHandler{
-		typedName:             plugins.TypedName{Type: PdProfileHandlerType},
-		prefixPluginTypedName: plugins.TypedName{Type: prefix.PrefixCachePluginType, Name: prefixPluginName},
-		decodeProfile:         decodeProfile,
-		prefillProfile:        prefillProfile,
-		pdThreshold:           pdThreshold,
-		hashBlockSize:         hashBlockSize,
+		typedName:              plugins.TypedName{Type: PdProfileHandlerType},
+		prefixPluginTypedName:  plugins.TypedName{Type: prefix.PrefixCachePluginType, Name: prefixPluginName},
+		decodeProfile:          decodeProfile,
+		prefillProfile:         prefillProfile,
+		pdThreshold:            pdThreshold,
+		blockThreshold:         blockThreshold,
+		hashBlockSize:          hashBlockSize,
+		neverDisaggregatePaths: []string{common.PoolingPathsAlias},
 	}
 	if primaryPort != 0 {
 		result.primaryPort = strconv.Itoa(primaryPort)
@@ -93,13 +127,16 @@ func NewPdProfileHandler(prefillProfile string, decodeProfile string, prefixPlug
 
 // PdProfileHandler handles scheduler profiles for PD.
 type PdProfileHandler struct {
-	typedName             plugins.TypedName
-	prefixPluginTypedName plugins.TypedName
-	decodeProfile         string
-	prefillProfile        string
-	pdThreshold           int
-	hashBlockSize         int
-	primaryPort           string
+	typedName              plugins.TypedName
+	prefixPluginTypedName  plugins.TypedName
+	decodeProfile          string
+	prefillProfile         string
+	pdThreshold            int
+	blockThreshold         int
+	hashBlockSize          int
+	hashing                *hashing.PrefixHashing // overrides hashBlockSize when set
+	primaryPort            string
+	neverDisaggregatePaths []string
 }
 
 // TypedName returns the typed name of the plugin.
@@ -131,7 +168,12 @@ func (h *PdProfileHandler) Pick(ctx context.Context, cycleState *types.CycleStat
 		return map[string]*framework.SchedulerProfile{}
 	}
 
-	if h.pdThreshold > 0 {
+	if path := request.Headers[common.RequestPathHeader]; common.MatchesPath(h.neverDisaggregatePaths, path) {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Request path is never disaggregated, using decode profile only", "path", path)
+		return map[string]*framework.SchedulerProfile{} // do not run prefill
+	}
+
+	if h.pdThreshold > 0 || h.blockThreshold > 0 {
 		userInput, err := getUserInputBytes(request)
 		if err != nil {
 			log.FromContext(ctx).V(logutil.DEBUG).Error(err, "Failed to get user input bytes")
@@ -142,21 +184,27 @@ func (h *PdProfileHandler) Pick(ctx context.Context, cycleState *types.CycleStat
 		// which means PD is enabled (otherwise, prefill profile is not configured at all and this profile handler is not used).
 		// inspect decode execution result to decide if prefill should run or not.
 		// if the request is short enough, use decode results only and don't run the prefill profile.
-		hitPercentagePrefix := 0.0 // default to 0, meaning no prefix cache hit
-		prefixState, err := types.ReadCycleStateKey[*prefix.SchedulingContextState](cycleState, plugins.StateKey(h.prefixPluginTypedName.String()))
-		if err != nil {
-			log.FromContext(ctx).Error(err, "unable to read prefix state")
+		cachedBlocks := h.cachedBlocks(ctx, cycleState, profileResults[h.decodeProfile])
+		hashBlockSize := h.blockSize(request.TargetModel)
+
+		if h.blockThreshold > 0 {
+			// the trailing partial block is never cached, but still needs to be prefilled
+			promptBlocks := (len(userInput) + hashBlockSize - 1) / hashBlockSize
+			uncachedBlocks := max(promptBlocks-cachedBlocks, 0)
+			if uncachedBlocks < h.blockThreshold {
+				log.FromContext(ctx).Info("Non-cached blocks are fewer than threshold, using decode profile only",
+					"uncachedBlocks", uncachedBlocks, "promptBlocks", promptBlocks)
+				return map[string]*framework.SchedulerProfile{} // do not run prefill
+			}
 		} else {
-			decodePod := profileResults[h.decodeProfile].TargetPods[0].GetPod().NamespacedName
-			hitPrefix := max(prefixState.PrefixCacheServers[prefix.ServerID(decodePod)]-1, 0) // The first hit is always the model name
-			hitPercentagePrefix = float64(hitPrefix*h.hashBlockSize) / float64(len(userInput))
+			hitPercentagePrefix := float64(cachedBlocks*hashBlockSize) / float64(len(userInput))
 			log.FromContext(ctx).V(logutil.DEBUG).Info("Computed hit percentage for prefix cache", "hitPercentage", hitPercentagePrefix,
 				"promptLength", len(userInput))
-		}
 
-		if (1.0-hitPercentagePrefix)*float64(len(userInput)) < float64(h.pdThreshold) {
-			log.FromContext(ctx).Info("Non-cached suffix is smaller than threshold, using decode profile only", "hitPercentage", hitPercentagePrefix)
-			return map[string]*framework.SchedulerProfile{} // do not run prefill
+			if (1.0-hitPercentagePrefix)*float64(len(userInput)) < float64(h.pdThreshold) {
+				log.FromContext(ctx).Info("Non-cached suffix is smaller than threshold, using decode profile only", "hitPercentage", hitPercentagePrefix)
+				return map[string]*framework.SchedulerProfile{} // do not run prefill
+			}
 		}
 	}
 
@@ -213,6 +261,26 @@ func (h *PdProfileHandler) ProcessResults(_ context.Context, _ *types.CycleState
 	}, nil
 }
 
+// blockSize returns the prefix cache block size of the given model
+func (h *PdProfileHandler) blockSize(model string) int {
+	if h.hashing != nil {
+		return h.hashing.BlockSize(model)
+	}
+	return h.hashBlockSize
+}
+
+// cachedBlocks returns the number of prompt blocks the prefix cache plugin found on the decode pod,
+// or 0 when the prefix cache state is not available.
+func (h *PdProfileHandler) cachedBlocks(ctx context.Context, cycleState *types.CycleState, decodeResult *types.ProfileRunResult) int {
+	prefixState, err := types.ReadCycleStateKey[*prefix.SchedulingContextState](cycleState, plugins.StateKey(h.prefixPluginTypedName.String()))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "unable to read prefix state")
+		return 0
+	}
+	decodePod := decodeResult.TargetPods[0].GetPod().NamespacedName
+	return max(prefixState.PrefixCacheServers[prefix.ServerID(decodePod)]-1, 0) // The first hit is always the model name
+}
+
 func getUserInputBytes(request *types.LLMRequest) ([]byte, error) {
 	if request.Body.Completions != nil { // assumed to be valid if not nil
 		return []byte(request.Body.Completions.Prompt), nil
@@ -1,10 +1,14 @@
 package profile
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
 )
 
 func TestPdProfileHandlerFactory(t *testing.T) {
@@ -33,6 +37,12 @@ func TestPdProfileHandlerFactory(t *testing.T) {
 			}`,
 			expectErr: false,
 		},
+		{
+			name:       "neverDisaggregatePaths is allowed",
+			pluginName: "never-disaggregate",
+			jsonParams: `{"neverDisaggregatePaths": ["pooling", "/v1/custom"]}`,
+			expectErr:  false,
+		},
 		{
 			name:       "zero primaryPort is allowed",
 			pluginName: "zero-port",
@@ -51,6 +61,24 @@ func TestPdProfileHandlerFactory(t *testing.T) {
 			jsonParams: `{"threshold": -1}`,
 			expectErr:  true,
 		},
+		{
+			name:       "blockThreshold is allowed",
+			pluginName: "block-threshold",
+			jsonParams: `{"blockThreshold": 4}`,
+			expectErr:  false,
+		},
+		{
+			name:       "negative blockThreshold should error",
+			pluginName: "neg-block-threshold",
+			jsonParams: `{"blockThreshold": -1}`,
+			expectErr:  true,
+		},
+		{
+			name:       "threshold and blockThreshold should error",
+			pluginName: "both-thresholds",
+			jsonParams: `{"threshold": 100, "blockThreshold": 4}`,
+			expectErr:  true,
+		},
 		{
 			name:       "hashBlockSize = 0 should error",
 			pluginName: "zero-block-size",
@@ -165,3 +193,23 @@ func TestPdProfileHandlerFactoryInvalidJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestPdProfileHandlerHashingRef(t *testing.T) {
+	ctx := context.Background()
+	handle := plugins.NewEppHandle(ctx, nil)
+	prefixHashing, err := hashing.NewPrefixHashing(64, map[string]int{"llama": 16}, hashing.HashFNV64a)
+	assert.NoError(t, err)
+	handle.AddPlugin("hashing", prefixHashing)
+	handle.AddPlugin("other", NewPdProfileHandler("prefill", "decode", "prefix", 0, 0, 64, 0))
+
+	plugin, err := PdProfileHandlerFactory("pd", json.RawMessage(`{"hashingRef": "hashing"}`), handle)
+	assert.NoError(t, err)
+	handler := plugin.(*PdProfileHandler)
+	assert.Equal(t, 16, handler.blockSize("llama"))
+	assert.Equal(t, 64, handler.blockSize("other"))
+
+	_, err = PdProfileHandlerFactory("pd", json.RawMessage(`{"hashingRef": "other"}`), handle)
+	assert.Error(t, err)
+	_, err = PdProfileHandlerFactory("pd", json.RawMessage(`{"hashingRef": "missing"}`), handle)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompt
+
+import (
+	"encoding/json"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// Bytes returns the prompt of a completions request, or the JSON encoded messages of a chat
+// completions request. It returns nil when the request has no body.
+func Bytes(request *types.LLMRequest) []byte {
+	if request == nil || request.Body == nil {
+		return nil
+	}
+	if request.Body.Completions != nil {
+		return []byte(request.Body.Completions.Prompt)
+	}
+	if request.Body.ChatCompletions != nil {
+		messages, err := json.Marshal(request.Body.ChatCompletions.Messages)
+		if err != nil {
+			return nil
+		}
+		return messages
+	}
+	return nil
+}
+
+// Length returns the length of the prompt of the request in bytes, 0 when the request has no body.
+func Length(request *types.LLMRequest) int {
+	return len(Bytes(request))
+}
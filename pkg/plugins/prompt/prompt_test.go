@@ -0,0 +1,57 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompt
+
+import (
+	"testing"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *types.LLMRequest
+		want    string
+	}{
+		{name: "no request"},
+		{name: "no body", request: &types.LLMRequest{}},
+		{
+			name:    "completions",
+			request: &types.LLMRequest{Body: &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: "hello"}}},
+			want:    "hello",
+		},
+		{
+			name: "chat completions",
+			request: &types.LLMRequest{Body: &types.LLMRequestBody{ChatCompletions: &types.ChatCompletionsRequest{
+				Messages: []types.Message{{Role: "user", Content: types.Content{Raw: "hello"}}},
+			}}},
+			want: `[{"role":"user","content":"hello"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := string(Bytes(test.request)); got != test.want {
+				t.Errorf("Bytes() = %q, want %q", got, test.want)
+			}
+			if got := Length(test.request); got != len(test.want) {
+				t.Errorf("Length() = %d, want %d", got, len(test.want))
+			}
+		})
+	}
+}
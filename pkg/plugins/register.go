@@ -1,7 +1,12 @@
 package plugins
 
 import (
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/classifier"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/exporter"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/filter"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/picker"
 	prerequest "github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/pre-request"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/profile"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
@@ -10,16 +15,39 @@ import (
 
 // RegisterAllPlugins registers the factory functions of all plugins in this repository.
 func RegisterAllPlugins() {
+	plugins.Register(budget.MemoryBudgetType, budget.MemoryBudgetFactory)
+	plugins.Register(classifier.RequestClassifierType, classifier.RequestClassifierFactory)
+	plugins.Register(exporter.CanaryProberType, exporter.CanaryProberFactory)
+	plugins.Register(exporter.DiscoveryMetricsType, exporter.DiscoveryMetricsFactory)
+	plugins.Register(exporter.EndpointsPublisherType, exporter.EndpointsPublisherFactory)
+	plugins.Register(exporter.SchedulingFeaturesExporterType, exporter.SchedulingFeaturesExporterFactory)
+	plugins.Register(hashing.PrefixHashingType, hashing.PrefixHashingFactory)
 	plugins.Register(filter.ByLabelType, filter.ByLabelFactory)
 	plugins.Register(filter.ByLabelSelectorType, filter.ByLabelSelectorFactory)
+	plugins.Register(filter.CircuitBreakerType, filter.CircuitBreakerFactory)
 	plugins.Register(filter.DecodeRoleType, filter.DecodeRoleFactory)
 	plugins.Register(filter.PrefillRoleType, filter.PrefillRoleFactory)
+	plugins.Register(filter.SchedulePolicyType, filter.SchedulePolicyFactory)
+	plugins.Register(picker.ParetoPickerType, picker.ParetoPickerFactory)
+	plugins.Register(picker.TrafficCapPickerType, picker.TrafficCapPickerFactory)
 	plugins.Register(prerequest.PrefillHeaderHandlerType, prerequest.PrefillHeaderHandlerFactory)
+	plugins.Register(profile.ComputeClassProfileHandlerType, profile.ComputeClassProfileHandlerFactory)
 	plugins.Register(profile.DataParallelProfileHandlerType, profile.DataParallelProfileHandlerFactory)
 	plugins.Register(profile.PdProfileHandlerType, profile.PdProfileHandlerFactory)
 	plugins.Register(scorer.PrecisePrefixCachePluginType, scorer.PrecisePrefixCachePluginFactory)
 	plugins.Register(scorer.LoadAwareType, scorer.LoadAwareFactory)
 	plugins.Register(scorer.SessionAffinityType, scorer.SessionAffinityFactory)
+	plugins.Register(scorer.SessionHeaderAffinityType, scorer.SessionHeaderAffinityFactory)
 	plugins.Register(scorer.ActiveRequestType, scorer.ActiveRequestFactory)
 	plugins.Register(scorer.NoHitLRUType, scorer.NoHitLRUFactory)
+	plugins.Register(scorer.LearnedType, scorer.LearnedFactory)
+	plugins.Register(scorer.AdaptiveWeightsType, scorer.AdaptiveWeightsFactory)
+	plugins.Register(scorer.WeightedCompositeType, scorer.WeightedCompositeFactory)
+	plugins.Register(scorer.JobAffinityType, scorer.JobAffinityFactory)
+	plugins.Register(scorer.AgentLoopAffinityType, scorer.AgentLoopAffinityFactory)
+	plugins.Register(scorer.BatchWindowType, scorer.BatchWindowFactory)
+	plugins.Register(scorer.PrecisionType, scorer.PrecisionFactory)
+	plugins.Register(scorer.KVCacheUtilizationType, scorer.KVCacheUtilizationFactory)
+	plugins.Register(scorer.PrefillQueueDepthType, scorer.PrefillQueueDepthFactory)
+	plugins.Register(scorer.LatencyPredictionType, scorer.LatencyPredictionFactory)
 }
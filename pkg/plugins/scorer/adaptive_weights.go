@@ -0,0 +1,349 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite"
+)
+
+const (
+	// AdaptiveWeightsType is the type of the AdaptiveWeights scorer
+	AdaptiveWeightsType = "adaptive-weights-scorer"
+
+	// RoleCacheAffinity marks scorers favoring pods holding the request KV cache.
+	// Their weights are increased when the throughput lags.
+	RoleCacheAffinity = "cache-affinity"
+
+	// RoleLoadBalancing marks scorers spreading the load across pods.
+	// Their weights are increased when the time to first token misses its target.
+	RoleLoadBalancing = "load-balancing"
+
+	defaultTTFTTarget         = time.Second
+	defaultLatencyTarget      = 30 * time.Second
+	defaultAttainmentGoal     = 0.9
+	defaultAdjustmentInterval = 30 * time.Second
+	defaultWeightStep         = 0.1
+	defaultMinSamples         = 20
+)
+
+// AdaptiveScorerParameters defines a scorer combined by the AdaptiveWeights scorer.
+type AdaptiveScorerParameters struct {
+	// PluginRef is the name of the scorer plugin. It must be defined before the AdaptiveWeights scorer.
+	PluginRef string `json:"pluginRef"`
+
+	// Role is either "cache-affinity" or "load-balancing". Scorers without a role keep their weight.
+	Role string `json:"role"`
+
+	// Weight is the initial weight of the scorer
+	Weight float64 `json:"weight"`
+
+	// MinWeight is the lower bound of the weight
+	MinWeight float64 `json:"minWeight"`
+
+	// MaxWeight is the upper bound of the weight
+	MaxWeight float64 `json:"maxWeight"`
+}
+
+// AdaptiveWeightsParameters defines the parameters of the AdaptiveWeights scorer.
+type AdaptiveWeightsParameters struct {
+	// Scorers are the combined scorers
+	Scorers []AdaptiveScorerParameters `json:"scorers"`
+
+	// TTFTTarget is the time to first token SLO. Defaults to "1s".
+	TTFTTarget string `json:"ttftTarget"`
+
+	// LatencyTarget is the end-to-end request latency SLO, used as the throughput indicator. Defaults to "30s".
+	LatencyTarget string `json:"latencyTarget"`
+
+	// AttainmentGoal is the fraction of requests which must meet each SLO. Defaults to 0.9.
+	AttainmentGoal float64 `json:"attainmentGoal"`
+
+	// AdjustmentInterval is the time between two weight adjustments. Defaults to "30s".
+	AdjustmentInterval string `json:"adjustmentInterval"`
+
+	// Step is the weight change applied on each adjustment. Defaults to 0.1.
+	Step float64 `json:"step"`
+
+	// MinSamples is the number of completed requests required to adjust the weights. Defaults to 20.
+	MinSamples int `json:"minSamples"`
+}
+
+// adaptiveScorer is a combined scorer and its current weight
+type adaptiveScorer struct {
+	scorer    framework.Scorer
+	role      string
+	weight    float64
+	minWeight float64
+	maxWeight float64
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &AdaptiveWeights{}
+var _ requestcontrol.PreRequest = &AdaptiveWeights{}
+var _ requestcontrol.ResponseReceived = &AdaptiveWeights{}
+var _ requestcontrol.ResponseComplete = &AdaptiveWeights{}
+var _ budget.Stateful = &AdaptiveWeights{}
+
+// AdaptiveWeightsFactory defines the factory function for the AdaptiveWeights scorer.
+func AdaptiveWeightsFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := AdaptiveWeightsParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", AdaptiveWeightsType, err)
+		}
+	}
+
+	pluginRefs := make([]string, len(parameters.Scorers))
+	for i, scorerParameters := range parameters.Scorers {
+		pluginRefs[i] = scorerParameters.PluginRef
+	}
+	scorers, err := composite.ResolveScorers(handle, pluginRefs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scorers of the '%s' scorer - %w", AdaptiveWeightsType, err)
+	}
+
+	adaptiveWeights, err := NewAdaptiveWeights(handle.Context(), &parameters, scorers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", AdaptiveWeightsType, err)
+	}
+	return adaptiveWeights.WithName(name), nil
+}
+
+// NewAdaptiveWeights creates a new AdaptiveWeights scorer combining the given scorers,
+// in the order of params.Scorers, and starts its feedback loop.
+func NewAdaptiveWeights(ctx context.Context, params *AdaptiveWeightsParameters, scorers []framework.Scorer) (*AdaptiveWeights, error) {
+	if len(params.Scorers) == 0 {
+		return nil, errors.New("at least one scorer is required")
+	}
+	if len(params.Scorers) != len(scorers) {
+		return nil, fmt.Errorf("expected %d scorers, got %d", len(params.Scorers), len(scorers))
+	}
+
+	ttftTarget, err := parsePositiveDuration(params.TTFTTarget, defaultTTFTTarget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ttftTarget: %w", err)
+	}
+	latencyTarget, err := parsePositiveDuration(params.LatencyTarget, defaultLatencyTarget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latencyTarget: %w", err)
+	}
+	adjustmentInterval, err := parsePositiveDuration(params.AdjustmentInterval, defaultAdjustmentInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid adjustmentInterval: %w", err)
+	}
+
+	attainmentGoal := params.AttainmentGoal
+	if attainmentGoal == 0 {
+		attainmentGoal = defaultAttainmentGoal
+	}
+	if attainmentGoal < 0 || attainmentGoal > 1 {
+		return nil, fmt.Errorf("invalid attainmentGoal: must be between 0 and 1, got %v", attainmentGoal)
+	}
+	step := params.Step
+	if step == 0 {
+		step = defaultWeightStep
+	}
+	if step < 0 {
+		return nil, fmt.Errorf("invalid step: must be > 0, got %v", step)
+	}
+	minSamples := params.MinSamples
+	if minSamples <= 0 {
+		minSamples = defaultMinSamples
+	}
+
+	adaptiveScorers := make([]*adaptiveScorer, len(scorers))
+	for i, scorerParameters := range params.Scorers {
+		if scorerParameters.Role != "" && scorerParameters.Role != RoleCacheAffinity && scorerParameters.Role != RoleLoadBalancing {
+			return nil, fmt.Errorf("invalid role for '%s': must be '%s' or '%s', got '%s'", scorerParameters.PluginRef,
+				RoleCacheAffinity, RoleLoadBalancing, scorerParameters.Role)
+		}
+		maxWeight := scorerParameters.MaxWeight
+		if maxWeight == 0 {
+			maxWeight = scorerParameters.Weight
+		}
+		if scorerParameters.MinWeight < 0 || scorerParameters.MinWeight > scorerParameters.Weight || scorerParameters.Weight > maxWeight {
+			return nil, fmt.Errorf("invalid weights for '%s': must satisfy 0 <= minWeight <= weight <= maxWeight", scorerParameters.PluginRef)
+		}
+		adaptiveScorers[i] = &adaptiveScorer{
+			scorer:    scorers[i],
+			role:      scorerParameters.Role,
+			weight:    scorerParameters.Weight,
+			minWeight: scorerParameters.MinWeight,
+			maxWeight: maxWeight,
+		}
+	}
+
+	scorer := &AdaptiveWeights{
+		typedName:      plugins.TypedName{Type: AdaptiveWeightsType},
+		scorers:        adaptiveScorers,
+		recorder:       newLatencyRecorder(ttftTarget, latencyTarget, defaultRequestTimeout),
+		attainmentGoal: attainmentGoal,
+		step:           step,
+		minSamples:     minSamples,
+	}
+
+	go scorer.adjustPeriodically(ctx, adjustmentInterval)
+
+	return scorer, nil
+}
+
+// AdaptiveWeights scorer combines other scorers with weights adjusted, within bounds, by a
+// feedback loop on the observed SLO attainment:
+//   - when the time to first token misses its goal, load-balancing weights are increased
+//     and cache-affinity weights are decreased;
+//   - when the time to first token is fine but the end-to-end latency misses its goal,
+//     cache-affinity weights are increased and load-balancing weights are decreased.
+type AdaptiveWeights struct {
+	typedName      plugins.TypedName
+	recorder       *latencyRecorder
+	attainmentGoal float64
+	step           float64
+	minSamples     int
+
+	mutex   sync.RWMutex
+	scorers []*adaptiveScorer
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *AdaptiveWeights) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *AdaptiveWeights) WithName(name string) *AdaptiveWeights {
+	s.typedName.Name = name
+	return s
+}
+
+// Weights returns the current weight of each combined scorer, by scorer name.
+func (s *AdaptiveWeights) Weights() map[string]float64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	weights := make(map[string]float64, len(s.scorers))
+	for _, scorer := range s.scorers {
+		weights[scorer.scorer.TypedName().Name] = scorer.weight
+	}
+	return weights
+}
+
+// Score returns the weighted average of the combined scorers, in range of 0-1.
+func (s *AdaptiveWeights) Score(ctx context.Context, cycleState *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	s.mutex.RLock()
+	scorers := make([]framework.Scorer, len(s.scorers))
+	weights := make([]float64, len(s.scorers))
+	for i, scorer := range s.scorers {
+		scorers[i] = scorer.scorer
+		weights[i] = scorer.weight
+	}
+	s.mutex.RUnlock()
+
+	scoredPods := composite.WeightedScore(ctx, cycleState, request, pods, scorers, weights)
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "weights", weights, "scores", scoredPods)
+	return scoredPods
+}
+
+// PreRequest records the time the request is sent.
+func (s *AdaptiveWeights) PreRequest(_ context.Context, request *types.LLMRequest, _ *types.SchedulingResult) {
+	s.recorder.requestSent(request.RequestId, time.Now())
+}
+
+// ResponseReceived records the time to first token of the request.
+func (s *AdaptiveWeights) ResponseReceived(_ context.Context, request *types.LLMRequest, _ *requestcontrol.Response, _ *backend.Pod) {
+	s.recorder.responseReceived(request.RequestId, time.Now())
+}
+
+// ResponseComplete records the end-to-end latency of the request.
+func (s *AdaptiveWeights) ResponseComplete(_ context.Context, request *types.LLMRequest, _ *requestcontrol.Response, _ *backend.Pod) {
+	s.recorder.responseComplete(request.RequestId, time.Now())
+}
+
+// adjust runs one iteration of the feedback loop
+func (s *AdaptiveWeights) adjust(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	stats := s.recorder.collect()
+	if stats.latencySamples < s.minSamples {
+		logger.V(logutil.DEBUG).Info("Not enough samples to adjust the scorer weights", "samples", stats.latencySamples)
+		return
+	}
+
+	var increased, decreased string
+	switch {
+	case stats.ttftAttainment() < s.attainmentGoal:
+		increased, decreased = RoleLoadBalancing, RoleCacheAffinity
+	case stats.latencyAttainment() < s.attainmentGoal:
+		increased, decreased = RoleCacheAffinity, RoleLoadBalancing
+	default:
+		return
+	}
+
+	s.mutex.Lock()
+	for _, scorer := range s.scorers {
+		switch scorer.role {
+		case increased:
+			scorer.weight = min(scorer.weight+s.step, scorer.maxWeight)
+		case decreased:
+			scorer.weight = max(scorer.weight-s.step, scorer.minWeight)
+		}
+	}
+	s.mutex.Unlock()
+
+	logger.V(logutil.DEFAULT).Info("Adjusted scorer weights", "favored", increased,
+		"ttftAttainment", stats.ttftAttainment(), "latencyAttainment", stats.latencyAttainment(), "weights", s.Weights())
+}
+
+// StateUsage returns the number of requests waiting for their response and their estimated size.
+func (s *AdaptiveWeights) StateUsage() (int, int) {
+	return s.recorder.inFlight.Len(), inFlightRequestBytes
+}
+
+// EvictState stops tracking the requests sent the least recently, their latency is then not recorded.
+func (s *AdaptiveWeights) EvictState(n int) int {
+	return budget.EvictOldest(s.recorder.inFlight, n)
+}
+
+func (s *AdaptiveWeights) adjustPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.adjust(ctx)
+		}
+	}
+}
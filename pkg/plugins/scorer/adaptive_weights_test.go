@@ -0,0 +1,158 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite/compositetest"
+)
+
+func newTestAdaptiveWeights(ctx context.Context, t *testing.T) *AdaptiveWeights {
+	t.Helper()
+
+	cacheScorer := compositetest.NewFixedScorer("cache", map[string]float64{"pod-a": 1, "pod-b": 0})
+	loadScorer := compositetest.NewFixedScorer("load", map[string]float64{"pod-a": 0, "pod-b": 1})
+
+	scorer, err := NewAdaptiveWeights(ctx, &AdaptiveWeightsParameters{
+		Scorers: []AdaptiveScorerParameters{
+			{PluginRef: "cache", Role: RoleCacheAffinity, Weight: 1, MinWeight: 0.5, MaxWeight: 1.2},
+			{PluginRef: "load", Role: RoleLoadBalancing, Weight: 1, MinWeight: 0.5, MaxWeight: 1.2},
+		},
+		TTFTTarget:         "100ms",
+		LatencyTarget:      "1s",
+		AdjustmentInterval: "1h", // adjustments are triggered by the tests
+		Step:               0.25,
+		MinSamples:         2,
+	}, []framework.Scorer{cacheScorer, loadScorer})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return scorer
+}
+
+// recordRequests records requests with the given time to first token and latency
+func recordRequests(scorer *AdaptiveWeights, count int, ttft time.Duration, latency time.Duration) {
+	start := time.Now()
+	for i := range count {
+		requestID := fmt.Sprintf("request-%d", i)
+		scorer.recorder.requestSent(requestID, start)
+		scorer.recorder.responseReceived(requestID, start.Add(ttft))
+		scorer.recorder.responseComplete(requestID, start.Add(latency))
+	}
+}
+
+func TestAdaptiveWeightsScore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	podA := compositetest.NewPod("pod-a")
+	podB := compositetest.NewPod("pod-b")
+
+	scorer := newTestAdaptiveWeights(ctx, t)
+	got := scorer.Score(ctx, types.NewCycleState(), &types.LLMRequest{}, []types.Pod{podA, podB})
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0.5, podB: 0.5}, got); diff != "" {
+		t.Errorf("Unexpected output (-want +got): %v", diff)
+	}
+
+	// TTFT misses its target: favor load balancing
+	recordRequests(scorer, 2, time.Second, time.Second)
+	scorer.adjust(ctx)
+
+	got = scorer.Score(ctx, types.NewCycleState(), &types.LLMRequest{}, []types.Pod{podA, podB})
+	want := map[types.Pod]float64{podA: 0.75 / 1.95, podB: 1.2 / 1.95}
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected output (-want +got): %v", diff)
+	}
+}
+
+func TestAdaptiveWeightsAdjust(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     int
+		ttft        time.Duration
+		latency     time.Duration
+		adjustments int
+		wantWeights map[string]float64
+	}{
+		{
+			name:        "not enough samples",
+			samples:     1,
+			ttft:        time.Second,
+			latency:     time.Minute,
+			adjustments: 1,
+			wantWeights: map[string]float64{"cache": 1, "load": 1},
+		},
+		{
+			name:        "slos met",
+			samples:     10,
+			ttft:        10 * time.Millisecond,
+			latency:     500 * time.Millisecond,
+			adjustments: 1,
+			wantWeights: map[string]float64{"cache": 1, "load": 1},
+		},
+		{
+			name:        "ttft missed favors load balancing",
+			samples:     10,
+			ttft:        time.Second,
+			latency:     time.Minute,
+			adjustments: 1,
+			wantWeights: map[string]float64{"cache": 0.75, "load": 1.2},
+		},
+		{
+			name:        "throughput lagging favors cache affinity",
+			samples:     10,
+			ttft:        10 * time.Millisecond,
+			latency:     time.Minute,
+			adjustments: 1,
+			wantWeights: map[string]float64{"cache": 1.2, "load": 0.75},
+		},
+		{
+			name:        "weights stay within bounds",
+			samples:     10,
+			ttft:        time.Second,
+			latency:     time.Minute,
+			adjustments: 5,
+			wantWeights: map[string]float64{"cache": 0.5, "load": 1.2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			scorer := newTestAdaptiveWeights(ctx, t)
+			for range test.adjustments {
+				recordRequests(scorer, test.samples, test.ttft, test.latency)
+				scorer.adjust(ctx)
+			}
+
+			if diff := cmp.Diff(test.wantWeights, scorer.Weights(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Unexpected weights (-want +got): %v", diff)
+			}
+		})
+	}
+}
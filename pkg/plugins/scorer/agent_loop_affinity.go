@@ -0,0 +1,314 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/prompt"
+)
+
+const (
+	// AgentLoopAffinityType is the type of the AgentLoopAffinity scorer.
+	AgentLoopAffinityType = "agent-loop-affinity-scorer"
+
+	defaultAgentSessionHeader    = "x-session-id"
+	defaultMaxCallInterval       = 30 * time.Second
+	defaultMinLoopIterations     = 2
+	defaultReleaseQueueThreshold = 8
+
+	// agentSessionBytes is the estimated memory of a session, its key and its cache item
+	agentSessionBytes = 256
+)
+
+// AgentLoopAffinityParameters defines the parameters of the AgentLoopAffinity scorer.
+type AgentLoopAffinityParameters struct {
+	// SessionHeader is the request header holding the session ID. Defaults to "x-session-id".
+	SessionHeader string `json:"sessionHeader"`
+
+	// MaxCallInterval is the maximum time between two calls of a loop. Defaults to "30s".
+	MaxCallInterval string `json:"maxCallInterval"`
+
+	// MinLoopIterations is the number of sequential calls extending the prompt of the previous
+	// call, including the scored one, from which a session is considered an agent loop. Defaults to 2.
+	MinLoopIterations int `json:"minLoopIterations"`
+
+	// ReleaseQueueThreshold is the waiting queue size of the pinned pod above which a loop
+	// is released to be pinned to another pod. Defaults to 8.
+	ReleaseQueueThreshold int `json:"releaseQueueThreshold"`
+
+	// HashingRef is the prefix-hashing plugin the prompts are hashed with. Defaults to FNV-1a.
+	HashingRef string `json:"hashingRef"`
+}
+
+// agentSession is the last call of a session
+type agentSession struct {
+	pod          string
+	calledAt     time.Time
+	promptLength int
+	promptHash   uint64
+	iterations   int
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &AgentLoopAffinity{}
+var _ requestcontrol.PreRequest = &AgentLoopAffinity{}
+var _ budget.Stateful = &AgentLoopAffinity{}
+
+// AgentLoopAffinityFactory defines the factory function for the AgentLoopAffinity scorer.
+func AgentLoopAffinityFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := AgentLoopAffinityParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", AgentLoopAffinityType, err)
+		}
+	}
+
+	agentLoopAffinity, err := NewAgentLoopAffinity(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", AgentLoopAffinityType, err)
+	}
+	if parameters.HashingRef != "" {
+		prefixHashing, ok := handle.Plugin(parameters.HashingRef).(*hashing.PrefixHashing)
+		if !ok {
+			return nil, fmt.Errorf("the '%s' scorer references '%s' which is not a %s plugin defined before it",
+				AgentLoopAffinityType, parameters.HashingRef, hashing.PrefixHashingType)
+		}
+		agentLoopAffinity.hashPrompt = prefixHashing.Hash
+	}
+	return agentLoopAffinity.WithName(name), nil
+}
+
+// NewAgentLoopAffinity creates a new AgentLoopAffinity scorer.
+func NewAgentLoopAffinity(ctx context.Context, params *AgentLoopAffinityParameters) (*AgentLoopAffinity, error) {
+	sessionHeader := params.SessionHeader
+	if sessionHeader == "" {
+		sessionHeader = defaultAgentSessionHeader
+	}
+	maxCallInterval, err := parsePositiveDuration(params.MaxCallInterval, defaultMaxCallInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maxCallInterval: %w", err)
+	}
+	minLoopIterations := params.MinLoopIterations
+	if minLoopIterations == 0 {
+		minLoopIterations = defaultMinLoopIterations
+	}
+	if minLoopIterations < 0 {
+		return nil, fmt.Errorf("invalid minLoopIterations: must be > 0, got %d", minLoopIterations)
+	}
+	releaseQueueThreshold := params.ReleaseQueueThreshold
+	if releaseQueueThreshold == 0 {
+		releaseQueueThreshold = defaultReleaseQueueThreshold
+	}
+	if releaseQueueThreshold < 0 {
+		return nil, fmt.Errorf("invalid releaseQueueThreshold: must be > 0, got %d", releaseQueueThreshold)
+	}
+
+	scorer := &AgentLoopAffinity{
+		typedName:             plugins.TypedName{Type: AgentLoopAffinityType},
+		sessionHeader:         strings.ToLower(sessionHeader),
+		maxCallInterval:       maxCallInterval,
+		minLoopIterations:     minLoopIterations,
+		releaseQueueThreshold: releaseQueueThreshold,
+		hashPrompt:            hashPrompt,
+		sessions: ttlcache.New[string, *agentSession](
+			ttlcache.WithTTL[string, *agentSession](maxCallInterval),
+			ttlcache.WithDisableTouchOnHit[string, *agentSession](),
+		),
+	}
+
+	go scorer.deleteExpiredPeriodically(ctx, maxCallInterval)
+
+	return scorer, nil
+}
+
+// AgentLoopAffinity recognizes iterative agent loops, sessions issuing rapid sequential calls
+// whose prompts extend the previous prompt, and pins a loop to one pod, so the growing
+// conversation context is not prefilled again on each call.
+// The pinned pod gets the highest score and the other pods zero. When the waiting queue of the
+// pinned pod exceeds the release threshold, the loop is released and pinned to the pod the
+// next call is sent to.
+type AgentLoopAffinity struct {
+	typedName             plugins.TypedName
+	sessionHeader         string
+	maxCallInterval       time.Duration
+	minLoopIterations     int
+	releaseQueueThreshold int
+	hashPrompt            func([]byte) uint64
+
+	mutex    sync.Mutex // protects the sessions
+	sessions *ttlcache.Cache[string, *agentSession]
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *AgentLoopAffinity) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *AgentLoopAffinity) WithName(name string) *AgentLoopAffinity {
+	s.typedName.Name = name
+	return s
+}
+
+// Score gives the highest score to the pod the agent loop of the request is pinned to, and zero
+// to the others. All pods get the same score when the request is not part of an agent loop.
+func (s *AgentLoopAffinity) Score(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+	}
+
+	pinnedPod := s.pinnedPod(request, time.Now())
+	if pinnedPod == "" {
+		return scoredPods
+	}
+
+	for _, pod := range pods {
+		if pod.GetPod().NamespacedName.String() != pinnedPod {
+			continue
+		}
+		if pod.GetMetrics().WaitingQueueSize > s.releaseQueueThreshold {
+			log.FromContext(ctx).V(logutil.DEBUG).Info("Releasing agent loop from its busy pod", "pod", pinnedPod,
+				"waitingQueueSize", pod.GetMetrics().WaitingQueueSize)
+			return scoredPods
+		}
+		scoredPods[pod] = 1
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "scores", scoredPods)
+	return scoredPods
+}
+
+// PreRequest records the call of the session and the pod it is sent to.
+func (s *AgentLoopAffinity) PreRequest(_ context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	sessionID := request.Headers[s.sessionHeader]
+	if sessionID == "" {
+		return
+	}
+	primaryProfile := schedulingResult.ProfileResults[schedulingResult.PrimaryProfileName]
+	if primaryProfile == nil || len(primaryProfile.TargetPods) == 0 {
+		return
+	}
+
+	now := time.Now()
+	prompt := requestPrompt(request)
+	session := &agentSession{
+		pod:          primaryProfile.TargetPods[0].GetPod().NamespacedName.String(),
+		calledAt:     now,
+		promptLength: len(prompt),
+		promptHash:   s.hashPrompt(prompt),
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if item := s.sessions.Get(sessionID); item != nil && s.continuesLoop(item.Value(), prompt, now) {
+		session.iterations = item.Value().iterations + 1
+	}
+	s.sessions.Set(sessionID, session, ttlcache.DefaultTTL)
+}
+
+// pinnedPod returns the pod the agent loop of the request is pinned to, or an empty string when
+// the request does not continue an agent loop
+func (s *AgentLoopAffinity) pinnedPod(request *types.LLMRequest, now time.Time) string {
+	sessionID := request.Headers[s.sessionHeader]
+	if sessionID == "" {
+		return ""
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item := s.sessions.Get(sessionID)
+	if item == nil {
+		return ""
+	}
+	session := item.Value()
+	if session.iterations+1 < s.minLoopIterations || !s.continuesLoop(session, requestPrompt(request), now) {
+		return ""
+	}
+	return session.pod
+}
+
+// continuesLoop returns true when the prompt extends the prompt of the previous call of the
+// session, received recently enough
+func (s *AgentLoopAffinity) continuesLoop(previous *agentSession, prompt []byte, now time.Time) bool {
+	return now.Sub(previous.calledAt) <= s.maxCallInterval &&
+		len(prompt) > previous.promptLength &&
+		s.hashPrompt(prompt[:previous.promptLength]) == previous.promptHash
+}
+
+// StateUsage returns the number of tracked sessions and their estimated size.
+func (s *AgentLoopAffinity) StateUsage() (int, int) {
+	return s.sessions.Len(), agentSessionBytes
+}
+
+// EvictState evicts the sessions called the least recently.
+func (s *AgentLoopAffinity) EvictState(n int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return budget.EvictOldest(s.sessions, n)
+}
+
+func (s *AgentLoopAffinity) deleteExpiredPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sessions.DeleteExpired()
+		}
+	}
+}
+
+// requestPrompt returns the prompt of the request. The chat completions messages are returned
+// serialized without the closing bracket, so the prompt of a conversation extends the prompt of
+// its previous turns.
+func requestPrompt(request *types.LLMRequest) []byte {
+	requestPrompt := prompt.Bytes(request)
+	if request != nil && request.Body != nil && request.Body.Completions == nil {
+		return bytes.TrimSuffix(requestPrompt, []byte("]"))
+	}
+	return requestPrompt
+}
+
+func hashPrompt(prompt []byte) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write(prompt)
+	return hash.Sum64()
+}
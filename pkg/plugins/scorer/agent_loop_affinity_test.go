@@ -0,0 +1,154 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestAgentLoopAffinity(t *testing.T) {
+	newRequest := func(sessionID string, prompt string) *types.LLMRequest {
+		return &types.LLMRequest{
+			RequestId: "test",
+			Headers:   map[string]string{"x-session-id": sessionID},
+			Body:      &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: prompt}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		waitingQueue  int
+		calls         []string
+		request       *types.LLMRequest
+		wantPodAScore float64
+	}{
+		{
+			name:          "first call",
+			request:       newRequest("session", "you are an agent"),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "loop not detected yet",
+			calls:         []string{"you are an agent"},
+			request:       newRequest("session", "you are an agent. call a tool"),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "loop pinned to its pod",
+			calls:         []string{"you are an agent", "you are an agent. call a tool"},
+			request:       newRequest("session", "you are an agent. call a tool. tool result"),
+			wantPodAScore: 1,
+		},
+		{
+			name:          "prompt not extending the previous one",
+			calls:         []string{"you are an agent", "you are an agent. call a tool"},
+			request:       newRequest("session", "a new conversation"),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "other session",
+			calls:         []string{"you are an agent", "you are an agent. call a tool"},
+			request:       newRequest("other-session", "you are an agent. call a tool. tool result"),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "loop released from its busy pod",
+			waitingQueue:  20,
+			calls:         []string{"you are an agent", "you are an agent. call a tool"},
+			request:       newRequest("session", "you are an agent. call a tool. tool result"),
+			wantPodAScore: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			podA := &types.PodMetrics{
+				Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+				MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: test.waitingQueue},
+			}
+			podB := &types.PodMetrics{
+				Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-b"}},
+				MetricsState: &backendmetrics.MetricsState{},
+			}
+			pods := []types.Pod{podA, podB}
+
+			// the header names are matched case insensitively
+			agentLoopAffinity, err := scorer.NewAgentLoopAffinity(ctx, &scorer.AgentLoopAffinityParameters{SessionHeader: "X-Session-Id"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, prompt := range test.calls {
+				agentLoopAffinity.PreRequest(ctx, newRequest("session", prompt), &types.SchedulingResult{
+					PrimaryProfileName: "default",
+					ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{podA}}},
+				})
+			}
+
+			got := agentLoopAffinity.Score(ctx, types.NewCycleState(), test.request, pods)
+			want := map[types.Pod]float64{podA: test.wantPodAScore, podB: 0}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestAgentLoopAffinityEvictState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	agentLoopAffinity, err := scorer.NewAgentLoopAffinity(ctx, &scorer.AgentLoopAffinityParameters{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, sessionID := range []string{"old", "recent"} {
+		agentLoopAffinity.PreRequest(ctx, &types.LLMRequest{
+			RequestId: "test",
+			Headers:   map[string]string{"x-session-id": sessionID},
+			Body:      &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: "you are an agent"}},
+		}, &types.SchedulingResult{
+			PrimaryProfileName: "default",
+			ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{podA}}},
+		})
+	}
+
+	if entries, entryBytes := agentLoopAffinity.StateUsage(); entries != 2 || entryBytes <= 0 {
+		t.Errorf("expected 2 sessions with a positive size, got %d of %d bytes", entries, entryBytes)
+	}
+	if evicted := agentLoopAffinity.EvictState(1); evicted != 1 {
+		t.Errorf("expected 1 evicted session, got %d", evicted)
+	}
+	if entries, _ := agentLoopAffinity.StateUsage(); entries != 1 {
+		t.Errorf("expected 1 remaining session, got %d", entries)
+	}
+}
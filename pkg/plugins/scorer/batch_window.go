@@ -0,0 +1,264 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
+)
+
+const (
+	// BatchWindowType is the type of the BatchWindow scorer.
+	BatchWindowType = "batch-window-scorer"
+
+	defaultBatchWindow       = 5 * time.Millisecond
+	maxBatchWindow           = 10 * time.Millisecond
+	defaultBatchPrefixBlocks = 4
+
+	// the interval the expired batches are deleted at
+	batchesCleanupInterval = time.Second
+)
+
+// BatchWindowParameters defines the parameters of the BatchWindow scorer.
+type BatchWindowParameters struct {
+	// Window is the time the requests sharing the prefix of the first request of a batch are routed
+	// with it. This field accepts duration strings like "5ms", at most "10ms". Defaults to "5ms".
+	Window string `json:"window"`
+
+	// PrefixBlocks is the number of leading prompt blocks the requests of a batch share. The requests
+	// with shorter prompts are not batched. Defaults to 4.
+	PrefixBlocks int `json:"prefixBlocks"`
+
+	// HashingRef is the prefix-hashing plugin the prompts are hashed with, and the block sizes are read from.
+	// Defaults to FNV-1a and the prefix cache plugin default block size.
+	HashingRef string `json:"hashingRef"`
+}
+
+// batchKey identifies the requests sharing a prefix
+type batchKey struct {
+	model      string
+	prefixHash uint64
+}
+
+// batch is a group of near-simultaneous requests sharing a prefix
+type batch struct {
+	pod       string        // the pod of the first request, set once it is scheduled
+	scheduled chan struct{} // closed once the pod of the first request is set
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &BatchWindow{}
+var _ requestcontrol.PreRequest = &BatchWindow{}
+
+// BatchWindowFactory defines the factory function for the BatchWindow scorer.
+func BatchWindowFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := BatchWindowParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", BatchWindowType, err)
+		}
+	}
+
+	batchWindow, err := NewBatchWindow(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", BatchWindowType, err)
+	}
+	if parameters.HashingRef != "" {
+		prefixHashing, ok := handle.Plugin(parameters.HashingRef).(*hashing.PrefixHashing)
+		if !ok {
+			return nil, fmt.Errorf("the '%s' scorer references '%s' which is not a %s plugin defined before it",
+				BatchWindowType, parameters.HashingRef, hashing.PrefixHashingType)
+		}
+		batchWindow.hashing = prefixHashing
+	}
+	return batchWindow.WithName(name), nil
+}
+
+// NewBatchWindow creates a new BatchWindow scorer.
+func NewBatchWindow(ctx context.Context, params *BatchWindowParameters) (*BatchWindow, error) {
+	window, err := parsePositiveDuration(params.Window, defaultBatchWindow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window: %w", err)
+	}
+	if window > maxBatchWindow {
+		return nil, fmt.Errorf("invalid window: must be at most %v, got %v", maxBatchWindow, window)
+	}
+	prefixBlocks := params.PrefixBlocks
+	if prefixBlocks == 0 {
+		prefixBlocks = defaultBatchPrefixBlocks
+	}
+	if prefixBlocks < 0 {
+		return nil, fmt.Errorf("invalid prefixBlocks: must be > 0, got %d", prefixBlocks)
+	}
+
+	scorer := &BatchWindow{
+		typedName:    plugins.TypedName{Type: BatchWindowType},
+		window:       window,
+		prefixBlocks: prefixBlocks,
+		batches: ttlcache.New[batchKey, *batch](
+			ttlcache.WithTTL[batchKey, *batch](window),
+			ttlcache.WithDisableTouchOnHit[batchKey, *batch](),
+		),
+	}
+
+	go scorer.deleteExpiredPeriodically(ctx)
+
+	return scorer, nil
+}
+
+// BatchWindow groups the near-simultaneous requests sharing a hot prefix, e.g. a system prompt, and
+// routes them to the same pod, so that the engine batches them together and prefills the shared prefix
+// once. The first request of a batch is scheduled normally and opens a tiny window, during which the
+// requests sharing its prefix give the highest score to its pod and zero to the others. The requests
+// scheduled while the pod of the first request is not known yet wait for it, at most until the window
+// closes.
+type BatchWindow struct {
+	typedName    plugins.TypedName
+	window       time.Duration
+	prefixBlocks int
+	hashing      *hashing.PrefixHashing // overrides the default block size and hash function when set
+
+	mutex   sync.Mutex // protects the batches
+	batches *ttlcache.Cache[batchKey, *batch]
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *BatchWindow) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *BatchWindow) WithName(name string) *BatchWindow {
+	s.typedName.Name = name
+	return s
+}
+
+// Score gives the highest score to the pod of the open batch of the request, and zero to the others.
+// All pods get the same score when the request opens a new batch, or has no prefix to share.
+func (s *BatchWindow) Score(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+	}
+
+	key, ok := s.batchKey(request)
+	if !ok {
+		return scoredPods
+	}
+
+	s.mutex.Lock()
+	item := s.batches.Get(key)
+	if item == nil {
+		// the request is the first of a new batch
+		s.batches.Set(key, &batch{scheduled: make(chan struct{})}, ttlcache.DefaultTTL)
+		s.mutex.Unlock()
+		return scoredPods
+	}
+	s.mutex.Unlock()
+
+	// wait for the first request of the batch to be scheduled, until the window closes
+	timer := time.NewTimer(time.Until(item.ExpiresAt()))
+	defer timer.Stop()
+	select {
+	case <-item.Value().scheduled:
+	case <-timer.C:
+		return scoredPods
+	case <-ctx.Done():
+		return scoredPods
+	}
+
+	batchPod := item.Value().pod
+	for _, pod := range pods {
+		if pod.GetPod().NamespacedName.String() == batchPod {
+			scoredPods[pod] = 1
+		}
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "batchPod", batchPod, "scores", scoredPods)
+	return scoredPods
+}
+
+// PreRequest records the pod of the first request of a batch.
+func (s *BatchWindow) PreRequest(_ context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	primaryProfile := schedulingResult.ProfileResults[schedulingResult.PrimaryProfileName]
+	if primaryProfile == nil || len(primaryProfile.TargetPods) == 0 {
+		return
+	}
+	key, ok := s.batchKey(request)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item := s.batches.Get(key)
+	if item == nil || item.Value().pod != "" {
+		return // the batch closed, or its pod is already set by its first request
+	}
+	item.Value().pod = primaryProfile.TargetPods[0].GetPod().NamespacedName.String()
+	close(item.Value().scheduled)
+}
+
+// batchKey returns the key of the batch of the request, and false when its prompt is shorter than
+// the shared prefix
+func (s *BatchWindow) batchKey(request *types.LLMRequest) (batchKey, bool) {
+	if request == nil {
+		return batchKey{}, false
+	}
+	blockSize, hash := prefix.DefaultBlockSize, hashPrompt
+	if s.hashing != nil {
+		blockSize, hash = s.hashing.BlockSize(request.TargetModel), s.hashing.Hash
+	}
+
+	prompt := requestPrompt(request)
+	prefixLength := s.prefixBlocks * blockSize
+	if len(prompt) < prefixLength {
+		return batchKey{}, false
+	}
+	return batchKey{model: request.TargetModel, prefixHash: hash(prompt[:prefixLength])}, true
+}
+
+func (s *BatchWindow) deleteExpiredPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(batchesCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			s.batches.DeleteExpired()
+			s.mutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestBatchWindow(t *testing.T) {
+	systemPrompt := strings.Repeat("you are a helpful assistant. ", 4) // more than one 64 bytes block
+	newRequest := func(prompt string) *types.LLMRequest {
+		return &types.LLMRequest{
+			RequestId:   "test",
+			TargetModel: "model",
+			Body:        &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: prompt}},
+		}
+	}
+
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	podB := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-b"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	pods := []types.Pod{podA, podB}
+	routedToPodA := &types.SchedulingResult{
+		PrimaryProfileName: "default",
+		ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{podA}}},
+	}
+
+	tests := []struct {
+		name          string
+		leader        string        // the prompt of the first request of the batch, scheduled to pod-a
+		wait          time.Duration // the time between the first request and the scored one
+		request       *types.LLMRequest
+		wantPodAScore float64
+	}{
+		{
+			name:          "first request of a batch",
+			request:       newRequest(systemPrompt + "first question"),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "request sharing the prefix within the window",
+			leader:        systemPrompt + "first question",
+			request:       newRequest(systemPrompt + "second question"),
+			wantPodAScore: 1,
+		},
+		{
+			name:          "request with another prefix",
+			leader:        systemPrompt + "first question",
+			request:       newRequest(strings.Repeat("you are a coding assistant. ", 4)),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "request shorter than the prefix",
+			leader:        "hi",
+			request:       newRequest("hi"),
+			wantPodAScore: 0,
+		},
+		{
+			name:          "request sharing the prefix after the window",
+			leader:        systemPrompt + "first question",
+			wait:          20 * time.Millisecond,
+			request:       newRequest(systemPrompt + "second question"),
+			wantPodAScore: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			batchWindow, err := scorer.NewBatchWindow(ctx, &scorer.BatchWindowParameters{Window: "10ms", PrefixBlocks: 1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.leader != "" {
+				leader := newRequest(test.leader)
+				batchWindow.Score(ctx, types.NewCycleState(), leader, pods)
+				batchWindow.PreRequest(ctx, leader, routedToPodA)
+			}
+			time.Sleep(test.wait)
+
+			scores := batchWindow.Score(ctx, types.NewCycleState(), test.request, pods)
+			if scores[podA] != test.wantPodAScore || scores[podB] != 0 {
+				t.Errorf("expected pod-a score %v and pod-b score 0, got %v", test.wantPodAScore, scores)
+			}
+		})
+	}
+}
+
+func TestBatchWindowWaitsForTheFirstRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	pods := []types.Pod{podA}
+	request := &types.LLMRequest{
+		RequestId: "test",
+		Body:      &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: strings.Repeat("shared prefix ", 8)}},
+	}
+
+	batchWindow, err := scorer.NewBatchWindow(ctx, &scorer.BatchWindowParameters{Window: "10ms", PrefixBlocks: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the first request is being scheduled while the second one is scored
+	batchWindow.Score(ctx, types.NewCycleState(), request, pods)
+	scores := make(chan map[types.Pod]float64)
+	go func() {
+		scores <- batchWindow.Score(ctx, types.NewCycleState(), request, pods)
+	}()
+	batchWindow.PreRequest(ctx, request, &types.SchedulingResult{
+		PrimaryProfileName: "default",
+		ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{podA}}},
+	})
+	if got := <-scores; got[podA] != 1 {
+		t.Errorf("expected the pod of the first request once scheduled, got %v", got)
+	}
+
+	// the first request of the next batch is never scheduled, the second one stops waiting when the window closes
+	time.Sleep(20 * time.Millisecond)
+	batchWindow.Score(ctx, types.NewCycleState(), request, pods)
+	start := time.Now()
+	if got := batchWindow.Score(ctx, types.NewCycleState(), request, pods); got[podA] != 0 {
+		t.Errorf("expected no batch pod, got %v", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to end with the window, waited %v", elapsed)
+	}
+}
+
+func TestBatchWindowFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handle := plugins.NewEppHandle(ctx, nil)
+	prefixHashing, err := hashing.NewPrefixHashing(16, nil, hashing.HashXXHash64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handle.AddPlugin("hashing", prefixHashing)
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{}`},
+		{name: "custom values", parameters: `{"window": "2ms", "prefixBlocks": 8, "hashingRef": "hashing"}`},
+		{name: "window too large", parameters: `{"window": "1s"}`, wantErr: true},
+		{name: "invalid window", parameters: `{"window": "-1ms"}`, wantErr: true},
+		{name: "negative prefix blocks", parameters: `{"prefixBlocks": -1}`, wantErr: true},
+		{name: "missing hashing plugin", parameters: `{"hashingRef": "missing"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scorer.BatchWindowFactory("batch", json.RawMessage(test.parameters), handle)
+			if (err != nil) != test.wantErr {
+				t.Errorf("BatchWindowFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
+)
+
+const (
+	// JobAffinityType is the type of the JobAffinity scorer.
+	JobAffinityType = "job-affinity-scorer"
+
+	defaultJobHeader     = "x-job-id"
+	defaultMaxPodsPerJob = 1
+	defaultJobTimeout    = 5 * time.Minute
+
+	// jobPlacementBytes is the estimated memory of a job placement, its key, its pod maps and its cache item
+	jobPlacementBytes = 512
+
+	// maxJobAssignments is the number of upfront assignments kept per job, e.g. the prefill and the
+	// decode assignments of a job when the plugin filters both profiles of a P/D deployment
+	maxJobAssignments = 4
+)
+
+// JobAffinityParameters defines the parameters of the JobAffinity scorer.
+type JobAffinityParameters struct {
+	// JobHeader is the request header holding the job ID. Defaults to "x-job-id".
+	JobHeader string `json:"jobHeader"`
+
+	// MaxPodsPerJob is the number of pods a job may spread over. Defaults to 1.
+	MaxPodsPerJob int `json:"maxPodsPerJob"`
+
+	// JobTimeout is the time after which a job without new requests is forgotten. Defaults to "5m".
+	JobTimeout string `json:"jobTimeout"`
+
+	// AssignUpfront assigns the MaxPodsPerJob pods with the fewest waiting requests to a job on its
+	// first request, instead of spreading its first requests, such as the items of a batch job
+	// submitted at once, before the job reaches its pod limit.
+	AssignUpfront bool `json:"assignUpfront"`
+}
+
+// jobPlacement holds the number of requests of a job placed on each pod
+type jobPlacement struct {
+	podRequests map[string]int
+	// assignments are the sets of pods assigned to the job upfront, one per set of candidate pods
+	// such as the prefill and the decode pods, the most recently used last
+	assignments []map[string]bool
+}
+
+// compile-time type assertions
+var _ framework.Filter = &JobAffinity{}
+var _ framework.Scorer = &JobAffinity{}
+var _ requestcontrol.PreRequest = &JobAffinity{}
+var _ budget.Stateful = &JobAffinity{}
+
+// JobAffinityFactory defines the factory function for the JobAffinity scorer.
+func JobAffinityFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := JobAffinityParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", JobAffinityType, err)
+		}
+	}
+
+	jobAffinity, err := NewJobAffinity(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", JobAffinityType, err)
+	}
+	return jobAffinity.WithName(name), nil
+}
+
+// NewJobAffinity creates a new JobAffinity scorer.
+func NewJobAffinity(ctx context.Context, params *JobAffinityParameters) (*JobAffinity, error) {
+	jobHeader := params.JobHeader
+	if jobHeader == "" {
+		jobHeader = defaultJobHeader
+	}
+	maxPodsPerJob := params.MaxPodsPerJob
+	if maxPodsPerJob == 0 {
+		maxPodsPerJob = defaultMaxPodsPerJob
+	}
+	if maxPodsPerJob < 0 {
+		return nil, fmt.Errorf("invalid maxPodsPerJob: must be > 0, got %d", maxPodsPerJob)
+	}
+	jobTimeout, err := parsePositiveDuration(params.JobTimeout, defaultJobTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jobTimeout: %w", err)
+	}
+
+	scorer := &JobAffinity{
+		typedName:     plugins.TypedName{Type: JobAffinityType},
+		jobHeader:     strings.ToLower(jobHeader),
+		maxPodsPerJob: maxPodsPerJob,
+		assignUpfront: params.AssignUpfront,
+		jobs: ttlcache.New[string, *jobPlacement](
+			ttlcache.WithTTL[string, *jobPlacement](jobTimeout),
+		),
+	}
+
+	go scorer.deleteExpiredPeriodically(ctx, jobTimeout)
+
+	return scorer, nil
+}
+
+// JobAffinity gang-schedules the requests of a job, such as the tool calls fanned out by an agent,
+// identified by a request header. The requests of a job are placed on the pods already serving it,
+// maximizing their shared prefix reuse, and a job never spreads over more than MaxPodsPerJob pods:
+//   - as a filter, once a job reached its pod limit, it keeps only the pods serving the job;
+//   - as a scorer, it favors the pods serving the most requests of the job.
+//
+// With upfront assignment, the pods of a job are assigned on its first request, and the filter
+// always keeps only them. Requests without a job header are not affected.
+type JobAffinity struct {
+	typedName     plugins.TypedName
+	jobHeader     string
+	maxPodsPerJob int
+	assignUpfront bool
+
+	mutex sync.Mutex // protects the job placements
+	jobs  *ttlcache.Cache[string, *jobPlacement]
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *JobAffinity) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *JobAffinity) WithName(name string) *JobAffinity {
+	s.typedName.Name = name
+	return s
+}
+
+// Filter keeps only the pods serving the job of the request once the job reached its pod limit,
+// or the pods assigned to the job upfront.
+func (s *JobAffinity) Filter(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) []types.Pod {
+	if s.assignUpfront {
+		return s.assignedPods(ctx, request, pods)
+	}
+
+	podRequests := s.jobPodRequests(request)
+	if podRequests == nil {
+		return pods
+	}
+
+	jobPods := make([]types.Pod, 0, len(podRequests))
+	for _, pod := range pods {
+		if podRequests[pod.GetPod().NamespacedName.String()] > 0 {
+			jobPods = append(jobPods, pod)
+		}
+	}
+	if len(jobPods) == 0 || len(jobPods) < s.maxPodsPerJob {
+		return pods
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Job reached its pod limit", "job", request.Headers[s.jobHeader], "pods", len(jobPods))
+	return jobPods
+}
+
+// Score favors the pods serving the most requests of the job of the request, in range of 0-1.
+// All pods get the same score when the request has no job, or the job has no requests yet.
+func (s *JobAffinity) Score(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	podRequests := s.jobPodRequests(request)
+
+	maxRequests := 0
+	for _, pod := range pods {
+		maxRequests = max(maxRequests, podRequests[pod.GetPod().NamespacedName.String()])
+	}
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+		if maxRequests > 0 {
+			scoredPods[pod] = float64(podRequests[pod.GetPod().NamespacedName.String()]) / float64(maxRequests)
+		}
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "scores", scoredPods)
+	return scoredPods
+}
+
+// PreRequest records the pods the request of a job is sent to.
+func (s *JobAffinity) PreRequest(_ context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	jobID := request.Headers[s.jobHeader]
+	if jobID == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	placement := &jobPlacement{podRequests: make(map[string]int)}
+	if item := s.jobs.Get(jobID); item != nil {
+		placement = item.Value()
+	}
+	for _, profileResult := range schedulingResult.ProfileResults {
+		if profileResult == nil || len(profileResult.TargetPods) == 0 {
+			continue
+		}
+		placement.podRequests[profileResult.TargetPods[0].GetPod().NamespacedName.String()]++
+	}
+	s.jobs.Set(jobID, placement, ttlcache.DefaultTTL)
+}
+
+// assignedPods returns the pods assigned to the job of the request among the candidate pods. The pods
+// with the fewest waiting requests are assigned to the job on its first request, or when none of its
+// assigned pods is a candidate. Since the plugin may filter several profiles, e.g. the prefill and the
+// decode profiles, whose candidate pods differ, a job keeps an assignment per set of candidate pods.
+func (s *JobAffinity) assignedPods(ctx context.Context, request *types.LLMRequest, pods []types.Pod) []types.Pod {
+	jobID := request.Headers[s.jobHeader]
+	if jobID == "" {
+		return pods
+	}
+
+	// the assignment is checked and set atomically, so that the requests of a job submitted at once
+	// share the same pods
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	placement := &jobPlacement{podRequests: make(map[string]int)}
+	if item := s.jobs.Get(jobID); item != nil {
+		placement = item.Value()
+	}
+	for i, assignment := range placement.assignments {
+		if jobPods := assignedCandidates(assignment, pods); len(jobPods) > 0 {
+			placement.assignments = append(slices.Delete(placement.assignments, i, i+1), assignment)
+			return jobPods
+		}
+	}
+
+	leastLoaded := slices.Clone(pods)
+	slices.SortStableFunc(leastLoaded, func(a, b types.Pod) int {
+		return cmp.Compare(a.GetMetrics().WaitingQueueSize, b.GetMetrics().WaitingQueueSize)
+	})
+	leastLoaded = leastLoaded[:min(len(leastLoaded), s.maxPodsPerJob)]
+	assignment := make(map[string]bool, len(leastLoaded))
+	for _, pod := range leastLoaded {
+		assignment[pod.GetPod().NamespacedName.String()] = true
+	}
+	// the assignments used the least recently, such as the ones of pods gone, are forgotten first
+	placement.assignments = append(placement.assignments, assignment)
+	placement.assignments = placement.assignments[max(0, len(placement.assignments)-maxJobAssignments):]
+	s.jobs.Set(jobID, placement, ttlcache.DefaultTTL)
+
+	jobPods := assignedCandidates(assignment, pods)
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Assigned pods to job", "job", jobID, "pods", len(jobPods))
+	return jobPods
+}
+
+// assignedCandidates returns the candidate pods in the assignment
+func assignedCandidates(assignment map[string]bool, pods []types.Pod) []types.Pod {
+	jobPods := make([]types.Pod, 0, len(assignment))
+	for _, pod := range pods {
+		if assignment[pod.GetPod().NamespacedName.String()] {
+			jobPods = append(jobPods, pod)
+		}
+	}
+	return jobPods
+}
+
+// jobPodRequests returns a copy of the number of requests of the job placed on each pod,
+// or nil when the request has no job or the job is unknown
+func (s *JobAffinity) jobPodRequests(request *types.LLMRequest) map[string]int {
+	jobID := request.Headers[s.jobHeader]
+	if jobID == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item := s.jobs.Get(jobID)
+	if item == nil {
+		return nil
+	}
+	podRequests := make(map[string]int, len(item.Value().podRequests))
+	for pod, requests := range item.Value().podRequests {
+		podRequests[pod] = requests
+	}
+	return podRequests
+}
+
+// StateUsage returns the number of tracked jobs and their estimated size.
+func (s *JobAffinity) StateUsage() (int, int) {
+	return s.jobs.Len(), jobPlacementBytes
+}
+
+// EvictState evicts the jobs placed the least recently.
+func (s *JobAffinity) EvictState(n int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return budget.EvictOldest(s.jobs, n)
+}
+
+func (s *JobAffinity) deleteExpiredPeriodically(ctx context.Context, jobTimeout time.Duration) {
+	ticker := time.NewTicker(jobTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.jobs.DeleteExpired()
+		}
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestJobAffinity(t *testing.T) {
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	podB := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-b"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	podC := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-c"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	pods := []types.Pod{podA, podB, podC}
+
+	jobRequest := &types.LLMRequest{RequestId: "job-request", Headers: map[string]string{"x-job-id": "job-1"}}
+	otherJobRequest := &types.LLMRequest{RequestId: "other-job-request", Headers: map[string]string{"x-job-id": "job-2"}}
+	noJobRequest := &types.LLMRequest{RequestId: "no-job-request", Headers: map[string]string{}}
+
+	schedulingResult := func(pod types.Pod) *types.SchedulingResult {
+		return &types.SchedulingResult{
+			PrimaryProfileName: "default",
+			ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{pod}}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		maxPodsPerJob int
+		placed        []types.Pod
+		request       *types.LLMRequest
+		wantFiltered  []types.Pod
+		wantScores    map[types.Pod]float64
+	}{
+		{
+			name:          "new job",
+			maxPodsPerJob: 1,
+			request:       jobRequest,
+			wantFiltered:  pods,
+			wantScores:    map[types.Pod]float64{podA: 0, podB: 0, podC: 0},
+		},
+		{
+			name:          "job at its pod limit",
+			maxPodsPerJob: 1,
+			placed:        []types.Pod{podB},
+			request:       jobRequest,
+			wantFiltered:  []types.Pod{podB},
+			wantScores:    map[types.Pod]float64{podA: 0, podB: 1, podC: 0},
+		},
+		{
+			name:          "job below its pod limit",
+			maxPodsPerJob: 3,
+			placed:        []types.Pod{podA, podA, podB},
+			request:       jobRequest,
+			wantFiltered:  pods,
+			wantScores:    map[types.Pod]float64{podA: 1, podB: 0.5, podC: 0},
+		},
+		{
+			name:          "other job",
+			maxPodsPerJob: 1,
+			placed:        []types.Pod{podB},
+			request:       otherJobRequest,
+			wantFiltered:  pods,
+			wantScores:    map[types.Pod]float64{podA: 0, podB: 0, podC: 0},
+		},
+		{
+			name:          "request without job",
+			maxPodsPerJob: 1,
+			placed:        []types.Pod{podB},
+			request:       noJobRequest,
+			wantFiltered:  pods,
+			wantScores:    map[types.Pod]float64{podA: 0, podB: 0, podC: 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// the header names are matched case insensitively
+			jobAffinity, err := scorer.NewJobAffinity(ctx, &scorer.JobAffinityParameters{JobHeader: "X-Job-Id", MaxPodsPerJob: test.maxPodsPerJob})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, pod := range test.placed {
+				jobAffinity.PreRequest(ctx, jobRequest, schedulingResult(pod))
+			}
+
+			gotFiltered := jobAffinity.Filter(ctx, types.NewCycleState(), test.request, pods)
+			if diff := cmp.Diff(podNames(test.wantFiltered), podNames(gotFiltered)); diff != "" {
+				t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+			}
+
+			gotScores := jobAffinity.Score(ctx, types.NewCycleState(), test.request, pods)
+			if diff := cmp.Diff(test.wantScores, gotScores); diff != "" {
+				t.Errorf("Unexpected scores (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func podNames(pods []types.Pod) []string {
+	names := make([]string, len(pods))
+	for i, pod := range pods {
+		names[i] = pod.GetPod().NamespacedName.String()
+	}
+	return names
+}
+
+func TestJobAffinityAssignUpfront(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newPod := func(name string, waitingQueueSize int) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+			MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: waitingQueueSize},
+		}
+	}
+	podA, podB, podC := newPod("pod-a", 5), newPod("pod-b", 0), newPod("pod-c", 1)
+	pods := []types.Pod{podA, podB, podC}
+
+	jobAffinity, err := scorer.NewJobAffinity(ctx, &scorer.JobAffinityParameters{MaxPodsPerJob: 2, AssignUpfront: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jobRequest := &types.LLMRequest{RequestId: "job-request", Headers: map[string]string{"x-job-id": "job-1"}}
+
+	// the first request of the job assigns the least loaded pods
+	gotFiltered := jobAffinity.Filter(ctx, types.NewCycleState(), jobRequest, pods)
+	if diff := cmp.Diff(podNames([]types.Pod{podB, podC}), podNames(gotFiltered)); diff != "" {
+		t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+	}
+
+	// the next requests of the job keep the assigned pods, even though the load changed
+	podB.MetricsState.WaitingQueueSize = 10
+	gotFiltered = jobAffinity.Filter(ctx, types.NewCycleState(), jobRequest, pods)
+	if diff := cmp.Diff(podNames([]types.Pod{podB, podC}), podNames(gotFiltered)); diff != "" {
+		t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+	}
+
+	// only the remaining assigned pods are kept when one of them is gone
+	gotFiltered = jobAffinity.Filter(ctx, types.NewCycleState(), jobRequest, []types.Pod{podA, podC})
+	if diff := cmp.Diff(podNames([]types.Pod{podC}), podNames(gotFiltered)); diff != "" {
+		t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+	}
+
+	// the pods are assigned again when none of them is a candidate anymore
+	gotFiltered = jobAffinity.Filter(ctx, types.NewCycleState(), jobRequest, []types.Pod{podA})
+	if diff := cmp.Diff(podNames([]types.Pod{podA}), podNames(gotFiltered)); diff != "" {
+		t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+	}
+
+	// the requests without a job are not filtered
+	noJobRequest := &types.LLMRequest{RequestId: "no-job-request", Headers: map[string]string{}}
+	gotFiltered = jobAffinity.Filter(ctx, types.NewCycleState(), noJobRequest, pods)
+	if diff := cmp.Diff(podNames(pods), podNames(gotFiltered)); diff != "" {
+		t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+	}
+}
+
+func TestJobAffinityAssignUpfrontPrefillDecode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newPod := func(name string, waitingQueueSize int) *types.PodMetrics {
+		return &types.PodMetrics{
+			Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+			MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: waitingQueueSize},
+		}
+	}
+	prefillA, prefillB := newPod("prefill-a", 5), newPod("prefill-b", 0)
+	decodeA, decodeB := newPod("decode-a", 0), newPod("decode-b", 3)
+	prefillPods := []types.Pod{prefillA, prefillB}
+	decodePods := []types.Pod{decodeA, decodeB}
+
+	jobAffinity, err := scorer.NewJobAffinity(ctx, &scorer.JobAffinityParameters{AssignUpfront: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jobRequest := &types.LLMRequest{RequestId: "job-request", Headers: map[string]string{"x-job-id": "job-1"}}
+
+	// the same plugin filters the prefill and the decode profiles of each request of the job
+	for i := range 3 {
+		gotFiltered := jobAffinity.Filter(ctx, types.NewCycleState(), jobRequest, prefillPods)
+		if diff := cmp.Diff(podNames([]types.Pod{prefillB}), podNames(gotFiltered)); diff != "" {
+			t.Errorf("Unexpected prefill pods of request %d (-want +got): %v", i, diff)
+		}
+		gotFiltered = jobAffinity.Filter(ctx, types.NewCycleState(), jobRequest, decodePods)
+		if diff := cmp.Diff(podNames([]types.Pod{decodeA}), podNames(gotFiltered)); diff != "" {
+			t.Errorf("Unexpected decode pods of request %d (-want +got): %v", i, diff)
+		}
+
+		// the job stays pinned to its pods although the load changes
+		prefillB.MetricsState.WaitingQueueSize += 10
+		decodeA.MetricsState.WaitingQueueSize += 10
+	}
+}
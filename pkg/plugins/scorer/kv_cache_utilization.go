@@ -0,0 +1,156 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// KVCacheUtilizationType is the type of the KVCacheUtilization scorer.
+	KVCacheUtilizationType = "kv-cache-utilization-scorer"
+
+	defaultKVCacheSaturation = 0.9
+	defaultQueueWeight       = 0.25
+)
+
+// KVCacheUtilizationParameters defines the parameters of the KVCacheUtilization scorer.
+type KVCacheUtilizationParameters struct {
+	// KVCacheSaturation is the KV cache usage, between 0 and 1, at which a pod is about to evict KV
+	// blocks and gets the lowest KV cache score. Defaults to 0.9.
+	KVCacheSaturation float64 `json:"kvCacheSaturation"`
+
+	// QueueSaturation is the number of waiting requests at which a pod gets the lowest queue score.
+	// Defaults to 128.
+	QueueSaturation int `json:"queueSaturation"`
+
+	// QueueWeight is the weight, between 0 and 1, of the queue score in the score of a pod, the KV
+	// cache score having the rest. Defaults to 0.25, the queue being ignored when 0.
+	QueueWeight *float64 `json:"queueWeight"`
+}
+
+// compile-time type assertion
+var _ framework.Scorer = &KVCacheUtilization{}
+
+// KVCacheUtilizationFactory defines the factory function for the KVCacheUtilization scorer.
+func KVCacheUtilizationFactory(name string, rawParameters json.RawMessage, _ plugins.Handle) (plugins.Plugin, error) {
+	parameters := KVCacheUtilizationParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", KVCacheUtilizationType, err)
+		}
+	}
+
+	scorer, err := NewKVCacheUtilization(&parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", KVCacheUtilizationType, err)
+	}
+	return scorer.WithName(name), nil
+}
+
+// NewKVCacheUtilization creates a new KVCacheUtilization scorer.
+func NewKVCacheUtilization(params *KVCacheUtilizationParameters) (*KVCacheUtilization, error) {
+	kvCacheSaturation := params.KVCacheSaturation
+	if kvCacheSaturation == 0 {
+		kvCacheSaturation = defaultKVCacheSaturation
+	}
+	if kvCacheSaturation < 0 || kvCacheSaturation > 1 {
+		return nil, fmt.Errorf("invalid kvCacheSaturation %v: must be between 0 and 1", kvCacheSaturation)
+	}
+	queueSaturation := params.QueueSaturation
+	if queueSaturation == 0 {
+		queueSaturation = QueueThresholdDefault
+	}
+	if queueSaturation < 0 {
+		return nil, fmt.Errorf("invalid queueSaturation %d: must be positive", queueSaturation)
+	}
+	queueWeight := defaultQueueWeight
+	if params.QueueWeight != nil {
+		queueWeight = *params.QueueWeight
+	}
+	if queueWeight < 0 || queueWeight > 1 {
+		return nil, fmt.Errorf("invalid queueWeight %v: must be between 0 and 1", queueWeight)
+	}
+
+	return &KVCacheUtilization{
+		typedName:         plugins.TypedName{Type: KVCacheUtilizationType},
+		kvCacheSaturation: kvCacheSaturation,
+		queueSaturation:   float64(queueSaturation),
+		queueWeight:       queueWeight,
+	}, nil
+}
+
+// KVCacheUtilization scores the decode pods by their free KV cache, from the KV cache usage scraped
+// from vLLM (vllm:gpu_cache_usage_perc), so that the requests are not routed to the pods about to
+// evict KV blocks. The length of the waiting queue is taken into account too, since the KV cache
+// usage of a pod does not reflect the requests it has not admitted yet.
+// Both are normalized to [0,1] against their saturation threshold, 1 for an idle pod and 0 for a
+// saturated one, and combined by the weight of the queue.
+type KVCacheUtilization struct {
+	typedName         plugins.TypedName
+	kvCacheSaturation float64
+	queueSaturation   float64
+	queueWeight       float64
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *KVCacheUtilization) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *KVCacheUtilization) WithName(name string) *KVCacheUtilization {
+	s.typedName.Name = name
+	return s
+}
+
+// Score scores the given pods in the range of 0-1, the pods without metrics getting 0
+func (s *KVCacheUtilization) Score(ctx context.Context, _ *types.CycleState, _ *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		metrics := pod.GetMetrics()
+		if metrics == nil {
+			scoredPods[pod] = 0
+			continue
+		}
+		kvCacheScore := saturationScore(metrics.KVCacheUsagePercent, s.kvCacheSaturation)
+		queueScore := saturationScore(float64(metrics.WaitingQueueSize), s.queueSaturation)
+		scoredPods[pod] = (1-s.queueWeight)*kvCacheScore + s.queueWeight*queueScore
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "scores", scoredPods)
+	return scoredPods
+}
+
+// saturationScore normalizes a value to 1 when it is 0, down to 0 when it reaches the saturation
+func saturationScore(value float64, saturation float64) float64 {
+	if value >= saturation {
+		return 0
+	}
+	if value <= 0 {
+		return 1
+	}
+	return 1 - value/saturation
+}
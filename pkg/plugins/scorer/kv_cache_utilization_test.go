@@ -0,0 +1,115 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestKVCacheUtilization(t *testing.T) {
+	newPod := func(name string, kvCacheUsage float64, waitingQueueSize int) types.Pod {
+		return &types.PodMetrics{
+			Pod: &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+			MetricsState: &backendmetrics.MetricsState{
+				KVCacheUsagePercent: kvCacheUsage,
+				WaitingQueueSize:    waitingQueueSize,
+			},
+		}
+	}
+	podIdle := newPod("pod-idle", 0, 0)
+	podHalf := newPod("pod-half", 0.4, 5)
+	podFullCache := newPod("pod-full-cache", 0.95, 0)
+	podLongQueue := newPod("pod-long-queue", 0.2, 50)
+	pods := []types.Pod{podIdle, podHalf, podFullCache, podLongQueue}
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantScores map[types.Pod]float64
+	}{
+		{
+			name:       "weighted KV cache and queue scores",
+			parameters: `{"kvCacheSaturation": 0.8, "queueSaturation": 10, "queueWeight": 0.5}`,
+			wantScores: map[types.Pod]float64{podIdle: 1, podHalf: 0.5, podFullCache: 0.5, podLongQueue: 0.375},
+		},
+		{
+			name:       "KV cache only",
+			parameters: `{"kvCacheSaturation": 0.8, "queueWeight": 0}`,
+			wantScores: map[types.Pod]float64{podIdle: 1, podHalf: 0.5, podFullCache: 0, podLongQueue: 0.75},
+		},
+		{
+			name:       "defaults",
+			parameters: `{}`,
+			wantScores: map[types.Pod]float64{
+				podIdle:      1,
+				podHalf:      0.75*(1-0.4/0.9) + 0.25*(1-5.0/128),
+				podFullCache: 0.25,
+				podLongQueue: 0.75*(1-0.2/0.9) + 0.25*(1-50.0/128),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plugin, err := scorer.KVCacheUtilizationFactory("kv-cache", json.RawMessage(test.parameters), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := plugin.(*scorer.KVCacheUtilization).Score(context.Background(), nil, &types.LLMRequest{}, pods)
+
+			if diff := cmp.Diff(test.wantScores, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestKVCacheUtilizationFactory(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{}`},
+		{name: "custom values", parameters: `{"kvCacheSaturation": 0.75, "queueSaturation": 32, "queueWeight": 0.1}`},
+		{name: "KV cache saturation above 1", parameters: `{"kvCacheSaturation": 1.5}`, wantErr: true},
+		{name: "negative KV cache saturation", parameters: `{"kvCacheSaturation": -0.1}`, wantErr: true},
+		{name: "negative queue saturation", parameters: `{"queueSaturation": -1}`, wantErr: true},
+		{name: "queue weight above 1", parameters: `{"queueWeight": 2}`, wantErr: true},
+		{name: "invalid parameters", parameters: `{"queueSaturation": "many"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scorer.KVCacheUtilizationFactory("kv-cache", json.RawMessage(test.parameters), nil)
+			if (err != nil) != test.wantErr {
+				t.Errorf("KVCacheUtilizationFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
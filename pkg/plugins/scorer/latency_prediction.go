@@ -0,0 +1,388 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/prompt"
+)
+
+const (
+	// LatencyPredictionType is the type of the LatencyPrediction scorer.
+	LatencyPredictionType = "latency-prediction-scorer"
+
+	defaultLatencySmoothing    = 0.2
+	defaultMaxTokensHeader     = "x-max-tokens"
+	defaultOutputTokens        = 256
+	predictedRequestEntryBytes = 224
+)
+
+// LatencyPredictionParameters defines the parameters of the LatencyPrediction scorer.
+type LatencyPredictionParameters struct {
+	// Smoothing is the weight of a new observation in the moving averages of the time to first token
+	// and the time per output token of the pods, in (0,1]. Defaults to 0.2.
+	Smoothing float64 `json:"smoothing"`
+
+	// MaxTokensHeader is the request header giving the maximum number of output tokens of the request.
+	// Defaults to "x-max-tokens".
+	MaxTokensHeader string `json:"maxTokensHeader"`
+
+	// DefaultOutputTokens is the number of output tokens predicted for the requests without the max
+	// tokens header. Defaults to 256.
+	DefaultOutputTokens int `json:"defaultOutputTokens"`
+
+	// LatencyTarget is the latency objective of the requests: the pods predicted to miss it get a
+	// score of 0, unless every pod is. Disabled by default.
+	LatencyTarget string `json:"latencyTarget"`
+
+	// RequestTimeout is the duration after which a request is no longer tracked, when its response
+	// never completes. Defaults to 2m.
+	RequestTimeout string `json:"requestTimeout"`
+}
+
+// latencyEstimate is the moving average of the latencies observed on a pod, in seconds
+type latencyEstimate struct {
+	ttftPerToken float64
+	tpot         float64
+	ttftSamples  int
+	tpotSamples  int
+}
+
+// predictedRequest tracks the timing of a request until its response completes
+type predictedRequest struct {
+	podName      string
+	promptTokens int
+	sentAt       time.Time
+	firstChunkAt time.Time
+	chunks       int
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &LatencyPrediction{}
+var _ requestcontrol.PreRequest = &LatencyPrediction{}
+var _ requestcontrol.ResponseStreaming = &LatencyPrediction{}
+var _ requestcontrol.ResponseComplete = &LatencyPrediction{}
+var _ budget.Stateful = &LatencyPrediction{}
+
+// LatencyPredictionFactory defines the factory function for the LatencyPrediction scorer.
+func LatencyPredictionFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := LatencyPredictionParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", LatencyPredictionType, err)
+		}
+	}
+
+	scorer, err := NewLatencyPrediction(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", LatencyPredictionType, err)
+	}
+	return scorer.WithName(name), nil
+}
+
+// NewLatencyPrediction creates a new LatencyPrediction scorer.
+func NewLatencyPrediction(ctx context.Context, params *LatencyPredictionParameters) (*LatencyPrediction, error) {
+	smoothing := params.Smoothing
+	if smoothing == 0 {
+		smoothing = defaultLatencySmoothing
+	}
+	if smoothing < 0 || smoothing > 1 {
+		return nil, fmt.Errorf("invalid smoothing %v: must be in (0,1]", smoothing)
+	}
+	maxTokensHeader := params.MaxTokensHeader
+	if maxTokensHeader == "" {
+		maxTokensHeader = defaultMaxTokensHeader
+	}
+	outputTokens := params.DefaultOutputTokens
+	if outputTokens == 0 {
+		outputTokens = defaultOutputTokens
+	}
+	if outputTokens < 0 {
+		return nil, fmt.Errorf("invalid defaultOutputTokens %d: must be positive", outputTokens)
+	}
+	latencyTarget, err := parsePositiveDuration(params.LatencyTarget, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latencyTarget: %w", err)
+	}
+	requestTimeout, err := parsePositiveDuration(params.RequestTimeout, defaultRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requestTimeout: %w", err)
+	}
+
+	requests := ttlcache.New[string, *predictedRequest](
+		ttlcache.WithTTL[string, *predictedRequest](requestTimeout),
+		ttlcache.WithDisableTouchOnHit[string, *predictedRequest](),
+	)
+	go cleanCachePeriodically(ctx, requests, requestTimeout)
+
+	return &LatencyPrediction{
+		typedName:       plugins.TypedName{Type: LatencyPredictionType},
+		smoothing:       smoothing,
+		maxTokensHeader: strings.ToLower(maxTokensHeader),
+		outputTokens:    outputTokens,
+		latencyTarget:   latencyTarget.Seconds(),
+		requests:        requests,
+		estimates:       map[string]*latencyEstimate{},
+	}, nil
+}
+
+// LatencyPrediction scores the pods by the latency predicted for the request, so that the requests
+// are placed by their latency objective rather than by the load of the pods alone. The time to first
+// token per prompt token and the time per output token of each pod are estimated by exponentially
+// weighted moving averages over its streamed responses: the time to first token is the time to the
+// first chunk, and the time per output token the time between the first and the last chunks divided
+// by the number of chunks, a chunk approximating a token. The non-streamed responses are not observed,
+// since their first token is not seen.
+//
+// The predicted latency of a request on a pod is the time to first token of its prompt plus the time
+// to generate its maximum number of output tokens, read from a request header. A pod without samples
+// is predicted by the average of the other pods, and the pods are scored by the ratio of the lowest
+// predicted latency to their own, so the fastest pod gets 1.
+type LatencyPrediction struct {
+	typedName       plugins.TypedName
+	smoothing       float64
+	maxTokensHeader string
+	outputTokens    int
+	latencyTarget   float64
+
+	// requests are the requests waiting for their response to complete, by request ID
+	requests *ttlcache.Cache[string, *predictedRequest]
+
+	// estimates are the latency estimates of the pods with samples
+	estimates map[string]*latencyEstimate
+	mutex     sync.RWMutex
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *LatencyPrediction) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *LatencyPrediction) WithName(name string) *LatencyPrediction {
+	s.typedName.Name = name
+	return s
+}
+
+// Score scores the given pods in the range of 0-1 by the latency predicted for the request
+func (s *LatencyPrediction) Score(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	promptTokens := float64(estimatedPromptTokens(request))
+	outputTokens := float64(s.requestOutputTokens(request))
+
+	predictions := make(map[types.Pod]float64, len(pods))
+	s.mutex.RLock()
+	averageTTFTPerToken, averageTPOT := s.averageEstimate()
+	for _, pod := range pods {
+		ttftPerToken, tpot := averageTTFTPerToken, averageTPOT
+		if estimate := s.estimates[pod.GetPod().NamespacedName.String()]; estimate != nil {
+			if estimate.ttftSamples > 0 {
+				ttftPerToken = estimate.ttftPerToken
+			}
+			if estimate.tpotSamples > 0 {
+				tpot = estimate.tpot
+			}
+		}
+		predictions[pod] = ttftPerToken*promptTokens + tpot*outputTokens
+	}
+	s.mutex.RUnlock()
+
+	scoredPods := s.scorePredictions(predictions)
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "predictions", predictions, "scores", scoredPods)
+	return scoredPods
+}
+
+// scorePredictions scores the pods by the ratio of the lowest predicted latency to their own, the
+// pods predicted to miss the latency target getting 0 unless every pod is
+func (s *LatencyPrediction) scorePredictions(predictions map[types.Pod]float64) map[types.Pod]float64 {
+	lowest := -1.0
+	for _, prediction := range predictions {
+		if lowest < 0 || prediction < lowest {
+			lowest = prediction
+		}
+	}
+	enforceTarget := s.latencyTarget > 0 && lowest <= s.latencyTarget
+
+	scoredPods := make(map[types.Pod]float64, len(predictions))
+	for pod, prediction := range predictions {
+		switch {
+		case enforceTarget && prediction > s.latencyTarget:
+			scoredPods[pod] = 0
+		case prediction <= 0:
+			scoredPods[pod] = 1
+		default:
+			scoredPods[pod] = lowest / prediction
+		}
+	}
+	return scoredPods
+}
+
+// averageEstimate returns the average estimates of the pods with samples, 0 when none has
+func (s *LatencyPrediction) averageEstimate() (float64, float64) {
+	var ttftPerToken, tpot float64
+	var ttftPods, tpotPods int
+	for _, estimate := range s.estimates {
+		if estimate.ttftSamples > 0 {
+			ttftPerToken += estimate.ttftPerToken
+			ttftPods++
+		}
+		if estimate.tpotSamples > 0 {
+			tpot += estimate.tpot
+			tpotPods++
+		}
+	}
+	if ttftPods > 0 {
+		ttftPerToken /= float64(ttftPods)
+	}
+	if tpotPods > 0 {
+		tpot /= float64(tpotPods)
+	}
+	return ttftPerToken, tpot
+}
+
+// requestOutputTokens returns the maximum number of output tokens of the request given by the max
+// tokens header, or the default number of output tokens
+func (s *LatencyPrediction) requestOutputTokens(request *types.LLMRequest) int {
+	if request == nil {
+		return s.outputTokens
+	}
+	if tokens, err := strconv.Atoi(request.Headers[s.maxTokensHeader]); err == nil && tokens > 0 {
+		return tokens
+	}
+	return s.outputTokens
+}
+
+// PreRequest starts tracking the request sent to the pod selected by the primary profile
+func (s *LatencyPrediction) PreRequest(_ context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	profileResult := schedulingResult.ProfileResults[schedulingResult.PrimaryProfileName]
+	if profileResult == nil || len(profileResult.TargetPods) == 0 {
+		return
+	}
+	s.requestSent(request.RequestId, profileResult.TargetPods[0].GetPod().NamespacedName.String(),
+		estimatedPromptTokens(request), time.Now())
+}
+
+// ResponseStreaming records a chunk of the response, the first one giving the time to first token
+func (s *LatencyPrediction) ResponseStreaming(_ context.Context, request *types.LLMRequest, _ *requestcontrol.Response, _ *backend.Pod) {
+	s.chunkReceived(request.RequestId, time.Now())
+}
+
+// ResponseComplete records the time per output token of the streamed response
+func (s *LatencyPrediction) ResponseComplete(ctx context.Context, request *types.LLMRequest, _ *requestcontrol.Response, _ *backend.Pod) {
+	if estimate, found := s.responseCompleted(request.RequestId, time.Now()); found {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Updated latency estimate", "ttftPerToken", estimate.ttftPerToken,
+			"tpot", estimate.tpot)
+	}
+}
+
+// StateUsage returns the number of requests waiting for their response to complete and their estimated size.
+func (s *LatencyPrediction) StateUsage() (int, int) {
+	return s.requests.Len(), predictedRequestEntryBytes
+}
+
+// EvictState stops tracking the requests sent the least recently, their latencies are then not observed.
+func (s *LatencyPrediction) EvictState(n int) int {
+	return budget.EvictOldest(s.requests, n)
+}
+
+// requestSent starts tracking a request sent to a pod
+func (s *LatencyPrediction) requestSent(requestID string, podName string, promptTokens int, now time.Time) {
+	s.requests.Set(requestID, &predictedRequest{podName: podName, promptTokens: promptTokens, sentAt: now},
+		ttlcache.DefaultTTL)
+}
+
+// chunkReceived counts a chunk of a streamed response, and observes the time to first token of the
+// pod on the first one
+func (s *LatencyPrediction) chunkReceived(requestID string, now time.Time) {
+	item := s.requests.Get(requestID)
+	if item == nil {
+		return
+	}
+	request := item.Value()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	request.chunks++
+	if request.chunks > 1 {
+		return
+	}
+	request.firstChunkAt = now
+	estimate := s.podEstimate(request.podName)
+	ttftPerToken := now.Sub(request.sentAt).Seconds() / float64(request.promptTokens)
+	estimate.ttftPerToken = s.average(estimate.ttftPerToken, ttftPerToken, estimate.ttftSamples)
+	estimate.ttftSamples++
+}
+
+// responseCompleted stops tracking a request, and observes the time per output token of the pod
+// when the response was streamed in several chunks
+func (s *LatencyPrediction) responseCompleted(requestID string, now time.Time) (latencyEstimate, bool) {
+	item, found := s.requests.GetAndDelete(requestID)
+	if !found {
+		return latencyEstimate{}, false
+	}
+	request := item.Value()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if request.chunks < 2 {
+		return latencyEstimate{}, false
+	}
+	estimate := s.podEstimate(request.podName)
+	tpot := now.Sub(request.firstChunkAt).Seconds() / float64(request.chunks-1)
+	estimate.tpot = s.average(estimate.tpot, tpot, estimate.tpotSamples)
+	estimate.tpotSamples++
+	return *estimate, true
+}
+
+// podEstimate returns the latency estimate of a pod, created on its first sample
+func (s *LatencyPrediction) podEstimate(podName string) *latencyEstimate {
+	estimate := s.estimates[podName]
+	if estimate == nil {
+		estimate = &latencyEstimate{}
+		s.estimates[podName] = estimate
+	}
+	return estimate
+}
+
+// average returns the moving average updated with a sample, the first sample being the average
+func (s *LatencyPrediction) average(average float64, sample float64, samples int) float64 {
+	if samples == 0 {
+		return sample
+	}
+	return s.smoothing*sample + (1-s.smoothing)*average
+}
+
+// estimatedPromptTokens estimates the number of prompt tokens of a request from the length of its prompt
+func estimatedPromptTokens(request *types.LLMRequest) int {
+	return prompt.Length(request)/averageCharactersPerToken + 1
+}
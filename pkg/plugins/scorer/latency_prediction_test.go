@@ -0,0 +1,169 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestLatencyPrediction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newPod := func(name string) types.Pod {
+		return &types.PodMetrics{
+			Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+			MetricsState: &backendmetrics.MetricsState{},
+		}
+	}
+	podA, podB, podC := newPod("pod-a"), newPod("pod-b"), newPod("pod-c")
+	pods := []types.Pod{podA, podB, podC}
+
+	latencyPrediction, err := NewLatencyPrediction(ctx, &LatencyPredictionParameters{Smoothing: 0.5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 399 characters are 100 prompt tokens
+	request := &types.LLMRequest{
+		Body:    &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: strings.Repeat("a", 399)}},
+		Headers: map[string]string{"x-max-tokens": "10"},
+	}
+	score := func() map[types.Pod]float64 {
+		return latencyPrediction.Score(ctx, nil, request, pods)
+	}
+	// stream sends a request of 100 prompt tokens to a pod, whose response is streamed in 3 chunks
+	start := time.Now()
+	stream := func(requestID string, pod types.Pod, ttft time.Duration, tpot time.Duration) {
+		latencyPrediction.requestSent(requestID, pod.GetPod().NamespacedName.String(), 100, start)
+		for chunk := range 3 {
+			latencyPrediction.chunkReceived(requestID, start.Add(ttft+time.Duration(chunk)*tpot))
+		}
+		latencyPrediction.responseCompleted(requestID, start.Add(ttft+2*tpot))
+	}
+
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 1, podC: 1}, score()); diff != "" {
+		t.Errorf("Unexpected scores without samples (-want +got): %v", diff)
+	}
+
+	// the predicted latencies are 1s+10*100ms=2s for pod-a, 500ms+10*50ms=1s for pod-b, and the
+	// average of 750ms+10*75ms=1.5s for pod-c without samples
+	stream("request-1", podA, time.Second, 100*time.Millisecond)
+	stream("request-2", podB, 500*time.Millisecond, 50*time.Millisecond)
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0.5, podB: 1, podC: 1.0 / 1.5}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores with samples (-want +got): %v", diff)
+	}
+
+	// the non-streamed responses are not observed
+	latencyPrediction.requestSent("request-3", "default/pod-a", 100, start)
+	latencyPrediction.responseCompleted("request-3", start.Add(time.Minute))
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0.5, podB: 1, podC: 1.0 / 1.5}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores after a non-streamed response (-want +got): %v", diff)
+	}
+
+	// pod-a averages 2s with a sample of 0s time to first token and 0s time per output token, to 1s
+	stream("request-4", podA, 0, 0)
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 1, podC: 1}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores after a faster response (-want +got): %v", diff)
+	}
+
+	// the pods predicted to miss the latency target get 0
+	stream("request-5", podA, 2*time.Second, 200*time.Millisecond)
+	latencyPrediction.latencyTarget = 1.2
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0, podB: 1, podC: 0}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores with a latency target (-want +got): %v", diff)
+	}
+	latencyPrediction.latencyTarget = 0.5
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0.4, podB: 1, podC: 1.0 / 1.75}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores when every pod misses the latency target (-want +got): %v", diff)
+	}
+
+	if entries, _ := latencyPrediction.StateUsage(); entries != 0 {
+		t.Errorf("StateUsage() entries = %d, want 0", entries)
+	}
+}
+
+func TestLatencyPredictionOutputTokens(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	latencyPrediction, err := NewLatencyPrediction(ctx, &LatencyPredictionParameters{
+		MaxTokensHeader:     "X-Output-Budget",
+		DefaultOutputTokens: 64,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "header", headers: map[string]string{"x-output-budget": "512"}, want: 512},
+		{name: "no header", want: 64},
+		{name: "invalid header", headers: map[string]string{"x-output-budget": "many"}, want: 64},
+		{name: "zero header", headers: map[string]string{"x-output-budget": "0"}, want: 64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := latencyPrediction.requestOutputTokens(&types.LLMRequest{Headers: test.headers}); got != test.want {
+				t.Errorf("requestOutputTokens() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLatencyPredictionFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handle := plugins.NewEppHandle(ctx, nil)
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{}`},
+		{name: "custom values", parameters: `{"smoothing": 1, "maxTokensHeader": "x-budget", "defaultOutputTokens": 128, "latencyTarget": "5s", "requestTimeout": "1m"}`},
+		{name: "invalid smoothing", parameters: `{"smoothing": 1.5}`, wantErr: true},
+		{name: "negative output tokens", parameters: `{"defaultOutputTokens": -1}`, wantErr: true},
+		{name: "invalid latency target", parameters: `{"latencyTarget": "soon"}`, wantErr: true},
+		{name: "invalid request timeout", parameters: `{"requestTimeout": "0s"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LatencyPredictionFactory("latency-prediction", json.RawMessage(test.parameters), handle)
+			if (err != nil) != test.wantErr {
+				t.Errorf("LatencyPredictionFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+)
+
+// latencyStats are the SLO attainment counters of the requests completed during a window
+type latencyStats struct {
+	ttftSamples    int
+	ttftMet        int
+	latencySamples int
+	latencyMet     int
+}
+
+// ttftAttainment returns the fraction of requests meeting the time to first token target
+func (s latencyStats) ttftAttainment() float64 {
+	if s.ttftSamples == 0 {
+		return 1
+	}
+	return float64(s.ttftMet) / float64(s.ttftSamples)
+}
+
+// latencyAttainment returns the fraction of requests meeting the end-to-end latency target
+func (s latencyStats) latencyAttainment() float64 {
+	if s.latencySamples == 0 {
+		return 1
+	}
+	return float64(s.latencyMet) / float64(s.latencySamples)
+}
+
+// inFlightRequestBytes is the estimated memory of an in-flight request, its ID and its cache item
+const inFlightRequestBytes = 192
+
+// inFlightRequest tracks the timing of a request waiting for its response
+type inFlightRequest struct {
+	sentAt     time.Time
+	ttftMet    bool
+	ttftStored bool
+}
+
+// latencyRecorder records the time to first token and end-to-end latency of the requests
+// against their targets. The time to first token is approximated by the time the response
+// headers are received.
+type latencyRecorder struct {
+	ttftTarget    time.Duration
+	latencyTarget time.Duration
+	inFlight      *ttlcache.Cache[string, *inFlightRequest]
+
+	mutex sync.Mutex
+	stats latencyStats
+}
+
+func newLatencyRecorder(ttftTarget time.Duration, latencyTarget time.Duration, requestTimeout time.Duration) *latencyRecorder {
+	return &latencyRecorder{
+		ttftTarget:    ttftTarget,
+		latencyTarget: latencyTarget,
+		inFlight: ttlcache.New[string, *inFlightRequest](
+			ttlcache.WithTTL[string, *inFlightRequest](requestTimeout),
+			ttlcache.WithDisableTouchOnHit[string, *inFlightRequest](),
+		),
+	}
+}
+
+// requestSent starts tracking a request
+func (r *latencyRecorder) requestSent(requestID string, now time.Time) {
+	r.inFlight.Set(requestID, &inFlightRequest{sentAt: now}, ttlcache.DefaultTTL)
+}
+
+// responseReceived records the time to first token of a request
+func (r *latencyRecorder) responseReceived(requestID string, now time.Time) {
+	item := r.inFlight.Get(requestID)
+	if item == nil {
+		return
+	}
+	request := item.Value()
+	if request.ttftStored {
+		return
+	}
+	request.ttftStored = true
+	request.ttftMet = now.Sub(request.sentAt) <= r.ttftTarget
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stats.ttftSamples++
+	if request.ttftMet {
+		r.stats.ttftMet++
+	}
+}
+
+// responseComplete records the end-to-end latency of a request and stops tracking it
+func (r *latencyRecorder) responseComplete(requestID string, now time.Time) {
+	item, found := r.inFlight.GetAndDelete(requestID)
+	if !found {
+		return
+	}
+	request := item.Value()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stats.latencySamples++
+	if now.Sub(request.sentAt) <= r.latencyTarget {
+		r.stats.latencyMet++
+	}
+}
+
+// collect returns the statistics recorded since the previous call and resets them
+func (r *latencyRecorder) collect() latencyStats {
+	r.inFlight.DeleteExpired()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stats := r.stats
+	r.stats = latencyStats{}
+	return stats
+}
@@ -0,0 +1,386 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/hashing"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/prompt"
+)
+
+const (
+	// LearnedType is the type of the Learned scorer
+	LearnedType = "learned-scorer"
+
+	defaultLearnedReloadInterval = 30 * time.Second
+	defaultLearnedLatencyBudget  = time.Millisecond
+
+	// LearnedModelLinear outputs the weighted sum of the features clamped to [0, 1]
+	LearnedModelLinear = "linear"
+
+	// LearnedModelLogistic outputs the sigmoid of the weighted sum of the features
+	LearnedModelLogistic = "logistic"
+)
+
+// Features available to learned models
+const (
+	// FeatureWaitingQueueSize is the number of requests waiting in the pod queue
+	FeatureWaitingQueueSize = "waiting_queue_size"
+	// FeatureRunningQueueSize is the number of requests running in the pod
+	FeatureRunningQueueSize = "running_queue_size"
+	// FeatureKVCacheUsage is the pod KV cache usage, between 0 and 1
+	FeatureKVCacheUsage = "kv_cache_usage"
+	// FeaturePrefixCacheHitRatio is the prompt fraction predicted to be cached on the pod, between 0 and 1
+	FeaturePrefixCacheHitRatio = "prefix_cache_hit_ratio"
+	// FeaturePromptLengthLog2 is the base 2 logarithm of the prompt length in bytes
+	FeaturePromptLengthLog2 = "prompt_length_log2"
+	// FeatureModelActive is 1 when the target model is loaded on the pod, 0 otherwise
+	FeatureModelActive = "model_active"
+)
+
+var learnedFeatures = map[string]struct{}{
+	FeatureWaitingQueueSize:    {},
+	FeatureRunningQueueSize:    {},
+	FeatureKVCacheUsage:        {},
+	FeaturePrefixCacheHitRatio: {},
+	FeaturePromptLengthLog2:    {},
+	FeatureModelActive:         {},
+}
+
+// LearnedParameters defines the parameters of the Learned scorer.
+type LearnedParameters struct {
+	// ModelPath is the path of the model file, typically mounted from a ConfigMap.
+	ModelPath string `json:"modelPath"`
+
+	// ReloadInterval is how often the model file is checked for changes.
+	// This field accepts duration strings like "30s", "1m". Defaults to "30s".
+	ReloadInterval string `json:"reloadInterval"`
+
+	// LatencyBudget is the maximum time spent evaluating the model for a request before
+	// falling back to the heuristic scorer. Defaults to "1ms".
+	LatencyBudget string `json:"latencyBudget"`
+
+	// PrefixPluginName is the name of the prefix cache plugin to read state from.
+	// Defaults to "prefix-cache-scorer".
+	PrefixPluginName string `json:"prefixPluginName"`
+
+	// HashBlockSize is the prefix cache block size, in bytes. Defaults to the prefix cache plugin default.
+	HashBlockSize int `json:"hashBlockSize"`
+
+	// HashingRef is the prefix-hashing plugin the block sizes are read from, instead of HashBlockSize.
+	HashingRef string `json:"hashingRef"`
+}
+
+// LearnedModel is a trained placement model mapping request and pod features to a score.
+type LearnedModel struct {
+	// Type is either "linear" or "logistic". Defaults to "logistic".
+	Type string `json:"type"`
+
+	// Version identifies the trained model, it is only used for logging.
+	Version string `json:"version"`
+
+	// Bias is the model intercept
+	Bias float64 `json:"bias"`
+
+	// Weights maps feature names to their coefficient. Features not listed have a weight of 0.
+	Weights map[string]float64 `json:"weights"`
+}
+
+// validate checks the model type and feature names
+func (m *LearnedModel) validate() error {
+	if m.Type == "" {
+		m.Type = LearnedModelLogistic
+	}
+	if m.Type != LearnedModelLinear && m.Type != LearnedModelLogistic {
+		return fmt.Errorf("invalid model type: must be '%s' or '%s', got '%s'", LearnedModelLinear, LearnedModelLogistic, m.Type)
+	}
+	for feature, weight := range m.Weights {
+		if _, ok := learnedFeatures[feature]; !ok {
+			return fmt.Errorf("unknown model feature '%s'", feature)
+		}
+		if math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("invalid weight for feature '%s': %v", feature, weight)
+		}
+	}
+	return nil
+}
+
+// predict returns the model output, between 0 and 1, for the given features
+func (m *LearnedModel) predict(features map[string]float64) float64 {
+	value := m.Bias
+	for feature, weight := range m.Weights {
+		value += weight * features[feature]
+	}
+
+	if m.Type == LearnedModelLinear {
+		return min(max(value, 0), 1)
+	}
+	return 1 / (1 + math.Exp(-value))
+}
+
+// loadLearnedModel reads and validates a model file
+func loadLearnedModel(path string) (*LearnedModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &LearnedModel{}
+	if err := json.Unmarshal(data, model); err != nil {
+		return nil, fmt.Errorf("failed to parse model file '%s' - %w", path, err)
+	}
+	if err := model.validate(); err != nil {
+		return nil, fmt.Errorf("invalid model file '%s' - %w", path, err)
+	}
+	return model, nil
+}
+
+// compile-time type assertion
+var _ framework.Scorer = &Learned{}
+
+// LearnedFactory defines the factory function for the Learned scorer.
+func LearnedFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := LearnedParameters{
+		PrefixPluginName: prefix.PrefixCachePluginType,
+		HashBlockSize:    prefix.DefaultBlockSize,
+	}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", LearnedType, err)
+		}
+	}
+
+	scorer, err := NewLearned(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", LearnedType, err)
+	}
+	if parameters.HashingRef != "" {
+		prefixHashing, ok := handle.Plugin(parameters.HashingRef).(*hashing.PrefixHashing)
+		if !ok {
+			return nil, fmt.Errorf("the '%s' scorer references '%s' which is not a %s plugin defined before it",
+				LearnedType, parameters.HashingRef, hashing.PrefixHashingType)
+		}
+		scorer.hashing = prefixHashing
+	}
+	return scorer.WithName(name), nil
+}
+
+// NewLearned creates a new Learned scorer. A model which fails to load is not fatal,
+// the scorer falls back to the heuristic scorer until a valid model is available.
+func NewLearned(ctx context.Context, params *LearnedParameters) (*Learned, error) {
+	if params == nil || params.ModelPath == "" {
+		return nil, errors.New("modelPath is required")
+	}
+
+	reloadInterval, err := parsePositiveDuration(params.ReloadInterval, defaultLearnedReloadInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reloadInterval: %w", err)
+	}
+	latencyBudget, err := parsePositiveDuration(params.LatencyBudget, defaultLearnedLatencyBudget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latencyBudget: %w", err)
+	}
+	hashBlockSize := params.HashBlockSize
+	if hashBlockSize <= 0 {
+		hashBlockSize = prefix.DefaultBlockSize
+	}
+	prefixPluginName := params.PrefixPluginName
+	if prefixPluginName == "" {
+		prefixPluginName = prefix.PrefixCachePluginType
+	}
+
+	scorer := &Learned{
+		typedName:             plugins.TypedName{Type: LearnedType},
+		prefixPluginTypedName: plugins.TypedName{Type: prefix.PrefixCachePluginType, Name: prefixPluginName},
+		modelPath:             params.ModelPath,
+		latencyBudget:         latencyBudget,
+		hashBlockSize:         hashBlockSize,
+		fallback:              NewLoadAware(ctx, QueueThresholdDefault),
+	}
+
+	scorer.reload(ctx)
+	go scorer.reloadPeriodically(ctx, reloadInterval)
+
+	return scorer, nil
+}
+
+// Learned scorer that evaluates a trained placement model in-process. It falls back to
+// the load-aware heuristic when no valid model is loaded or the latency budget is exceeded.
+type Learned struct {
+	typedName             plugins.TypedName
+	prefixPluginTypedName plugins.TypedName
+	modelPath             string
+	modelModTime          time.Time
+	latencyBudget         time.Duration
+	hashBlockSize         int
+	hashing               *hashing.PrefixHashing // overrides hashBlockSize when set
+	model                 atomic.Pointer[LearnedModel]
+	fallback              framework.Scorer
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *Learned) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *Learned) WithName(name string) *Learned {
+	s.typedName.Name = name
+	return s
+}
+
+// Score scores the given pods with the learned model, in range of 0-1.
+func (s *Learned) Score(ctx context.Context, cycleState *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	logger := log.FromContext(ctx).V(logutil.DEBUG)
+
+	model := s.model.Load()
+	if model == nil {
+		logger.Info("No learned model loaded, using fallback scorer")
+		return s.fallback.Score(ctx, cycleState, request, pods)
+	}
+
+	deadline := time.Now().Add(s.latencyBudget)
+	promptLength := prompt.Length(request)
+	prefixHits := s.prefixHits(ctx, cycleState)
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		if time.Now().After(deadline) {
+			logger.Info("Learned model exceeded the latency budget, using fallback scorer", "budget", s.latencyBudget)
+			return s.fallback.Score(ctx, cycleState, request, pods)
+		}
+		scoredPods[pod] = model.predict(s.features(request, pod, promptLength, prefixHits))
+	}
+
+	logger.Info("Scored pods", "modelVersion", model.Version, "scores", scoredPods)
+	return scoredPods
+}
+
+// blockSize returns the prefix cache block size of the given model
+func (s *Learned) blockSize(model string) int {
+	if s.hashing != nil {
+		return s.hashing.BlockSize(model)
+	}
+	return s.hashBlockSize
+}
+
+// features returns the model input for the given request and pod
+func (s *Learned) features(request *types.LLMRequest, pod types.Pod, promptLength int, prefixHits map[string]int) map[string]float64 {
+	metrics := pod.GetMetrics()
+	podName := pod.GetPod().NamespacedName.String()
+
+	hitRatio := 0.0
+	if promptLength > 0 {
+		hitRatio = min(float64(prefixHits[podName]*s.blockSize(request.TargetModel))/float64(promptLength), 1)
+	}
+	modelActive := 0.0
+	if _, ok := metrics.ActiveModels[request.TargetModel]; ok {
+		modelActive = 1
+	}
+
+	return map[string]float64{
+		FeatureWaitingQueueSize:    float64(metrics.WaitingQueueSize),
+		FeatureRunningQueueSize:    float64(metrics.RunningQueueSize),
+		FeatureKVCacheUsage:        metrics.KVCacheUsagePercent,
+		FeaturePrefixCacheHitRatio: hitRatio,
+		FeaturePromptLengthLog2:    math.Log2(float64(promptLength + 1)),
+		FeatureModelActive:         modelActive,
+	}
+}
+
+// prefixHits returns the number of prompt blocks cached per pod, as predicted by the prefix cache plugin
+func (s *Learned) prefixHits(ctx context.Context, cycleState *types.CycleState) map[string]int {
+	prefixState, err := types.ReadCycleStateKey[*prefix.SchedulingContextState](cycleState, plugins.StateKey(s.prefixPluginTypedName.String()))
+	if err != nil {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("No prefix cache state found", "error", err)
+		return nil
+	}
+
+	hits := make(map[string]int, len(prefixState.PrefixCacheServers))
+	for server, blocks := range prefixState.PrefixCacheServers {
+		hits[server.String()] = max(blocks-1, 0) // The first hit is always the model name
+	}
+	return hits
+}
+
+// reload loads the model file when it changed since the last load.
+// The current model is kept when the new one is invalid.
+func (s *Learned) reload(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	info, err := os.Stat(s.modelPath)
+	if err != nil {
+		logger.Error(err, "Failed to read learned model file, keeping the current model", "path", s.modelPath)
+		return
+	}
+	if info.ModTime().Equal(s.modelModTime) {
+		return
+	}
+
+	model, err := loadLearnedModel(s.modelPath)
+	if err != nil {
+		logger.Error(err, "Failed to load learned model, keeping the current model")
+		return
+	}
+	s.modelModTime = info.ModTime()
+	s.model.Store(model)
+	logger.Info("Loaded learned model", "path", s.modelPath, "type", model.Type, "version", model.Version)
+}
+
+func (s *Learned) reloadPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reload(ctx)
+		}
+	}
+}
+
+// parsePositiveDuration parses a duration string, returning the default value for an empty string
+func parsePositiveDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("must be positive, got '%s'", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func writeLearnedModel(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write model file: %v", err)
+	}
+}
+
+func TestLearnedScorer(t *testing.T) {
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: 0, KVCacheUsagePercent: 0.2},
+	}
+	podB := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-b"}},
+		MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: 4, KVCacheUsagePercent: 0.8},
+	}
+	pods := []types.Pod{podA, podB}
+
+	request := &types.LLMRequest{
+		RequestId: "test",
+		Body: &types.LLMRequestBody{
+			Completions: &types.CompletionsRequest{Prompt: string(make([]byte, 128))},
+		},
+	}
+
+	cycleState := types.NewCycleState()
+	prefixPlugin := plugins.TypedName{Type: prefix.PrefixCachePluginType, Name: prefix.PrefixCachePluginType}
+	cycleState.Write(plugins.StateKey(prefixPlugin.String()), &prefix.SchedulingContextState{
+		PrefixCacheServers: map[prefix.ServerID]int{
+			prefix.ServerID(podB.GetPod().NamespacedName): 3, // model name block + the 2 prompt blocks
+		},
+	})
+
+	tests := []struct {
+		name       string
+		model      string
+		wantScores map[types.Pod]float64
+	}{
+		{
+			name:  "linear model",
+			model: `{"type":"linear","bias":0.5,"weights":{"kv_cache_usage":-0.5,"waiting_queue_size":-0.05}}`,
+			wantScores: map[types.Pod]float64{
+				podA: 0.4,
+				podB: 0, // clamped
+			},
+		},
+		{
+			name:  "linear model with prefix cache hits",
+			model: `{"type":"linear","weights":{"prefix_cache_hit_ratio":0.9}}`,
+			wantScores: map[types.Pod]float64{
+				podA: 0,
+				podB: 0.9,
+			},
+		},
+		{
+			name:  "logistic model",
+			model: `{"bias":0}`,
+			wantScores: map[types.Pod]float64{
+				podA: 0.5,
+				podB: 0.5,
+			},
+		},
+		{
+			name:  "invalid model falls back to the load aware heuristic",
+			model: `{"weights":{"unknown_feature":1}}`,
+			wantScores: map[types.Pod]float64{
+				podA: 0.5,
+				podB: 0.5 * (1 - 4.0/float64(scorer.QueueThresholdDefault)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			modelPath := filepath.Join(t.TempDir(), "model.json")
+			writeLearnedModel(t, modelPath, test.model)
+
+			learned, err := scorer.NewLearned(ctx, &scorer.LearnedParameters{ModelPath: modelPath, LatencyBudget: "1s"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := learned.Score(ctx, cycleState, request, pods)
+			if diff := cmp.Diff(test.wantScores, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestLearnedScorerReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+
+	modelPath := filepath.Join(t.TempDir(), "model.json")
+	writeLearnedModel(t, modelPath, `{"type":"linear","bias":0.2}`)
+
+	learned, err := scorer.NewLearned(ctx, &scorer.LearnedParameters{ModelPath: modelPath, ReloadInterval: "10ms"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	score := func() float64 {
+		return learned.Score(ctx, types.NewCycleState(), &types.LLMRequest{}, []types.Pod{pod})[pod]
+	}
+	if got := score(); got != 0.2 {
+		t.Fatalf("expected score 0.2, got %v", got)
+	}
+
+	// an invalid model keeps the current one
+	writeLearnedModel(t, modelPath, `not json`)
+	time.Sleep(100 * time.Millisecond)
+	if got := score(); got != 0.2 {
+		t.Fatalf("expected the current model to be kept, got %v", got)
+	}
+
+	// a valid model replaces the current one
+	writeLearnedModel(t, modelPath, `{"type":"linear","bias":0.7}`)
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(modelPath, future, future); err != nil {
+		t.Fatalf("failed to update model file time: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := score(); got != 0.7 {
+		t.Errorf("expected the reloaded model score 0.7, got %v", got)
+	}
+}
+
+func TestNewLearnedErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		params *scorer.LearnedParameters
+	}{
+		{name: "missing model path", params: &scorer.LearnedParameters{}},
+		{name: "invalid reload interval", params: &scorer.LearnedParameters{ModelPath: "model.json", ReloadInterval: "-1s"}},
+		{name: "invalid latency budget", params: &scorer.LearnedParameters{ModelPath: "model.json", LatencyBudget: "fast"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := scorer.NewLearned(ctx, test.params); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
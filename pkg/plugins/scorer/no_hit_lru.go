@@ -4,15 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/multi/prefix"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
 )
 
 const (
@@ -21,11 +25,21 @@ const (
 
 	// defaultLRUSize is the maximum number of pods we'll consider in the cache
 	defaultLRUSize = 1024
+
+	// defaultMaxModels is the maximum number of models with their own LRU cache
+	defaultMaxModels = 64
+
+	// defaultPurgeInterval is the interval at which the pods removed from the pool are purged
+	defaultPurgeInterval = 30 * time.Second
+
+	// lruEntryBytes is the estimated memory of an LRU entry, the pod name and the list element
+	lruEntryBytes = 128
 )
 
 // compile-time type assertions
 var _ framework.Scorer = &NoHitLRU{}
 var _ requestcontrol.PreRequest = &NoHitLRU{}
+var _ budget.Stateful = &NoHitLRU{}
 
 // NoHitLRUParameters defines the parameters for the NoHitLRU scorer.
 type NoHitLRUParameters struct {
@@ -35,6 +49,27 @@ type NoHitLRUParameters struct {
 
 	// LRUSize defines the maximum number of pods to track in the LRU cache.
 	LRUSize int `json:"lruSize"`
+
+	// PartitionByModel keeps an LRU cache per target model, so that the cold requests of a model do
+	// not change the order of the pods for the other models of a multi-model pool. Defaults to false,
+	// a single LRU cache being shared by the models.
+	PartitionByModel bool `json:"partitionByModel"`
+
+	// MaxModels defines the maximum number of models with their own LRU cache when partitioned by
+	// model, the cache of the least recently used model being dropped first. Defaults to 64.
+	MaxModels int `json:"maxModels"`
+
+	// EntryTTL defines the time after which a pod which received no cold request ages out of the LRU
+	// cache, and is scored as never used. Disabled by default.
+	EntryTTL string `json:"entryTTL"`
+
+	// PurgeInterval defines the interval at which the pods removed from the pool are purged from the
+	// LRU cache. Defaults to "30s".
+	PurgeInterval string `json:"purgeInterval"`
+
+	// SharedStore defines a Redis or Valkey store sharing the LRU order between the EPP replicas.
+	// Disabled by default, each replica keeping its own LRU order in memory.
+	SharedStore *NoHitLRUSharedStoreParameters `json:"sharedStore"`
 }
 
 // coldRequestState tracks whether a request triggered a KV cache hit
@@ -64,13 +99,43 @@ func NoHitLRUFactory(name string, rawParameters json.RawMessage, handle plugins.
 	// Note: We don't enforce that the prefix plugin exists here
 	// The scorer will gracefully handle missing prefix cache state as an optimization
 
-	return NewNoHitLRU(handle.Context(), &parameters).WithName(name), nil
+	scorer, err := newNoHitLRU(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", NoHitLRUType, err)
+	}
+	purgeInterval, err := parsePositiveDuration(parameters.PurgeInterval, defaultPurgeInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - invalid purgeInterval: %w", NoHitLRUType, err)
+	}
+	if parameters.SharedStore != nil {
+		shared, err := newSharedLRU(handle.Context(), parameters.SharedStore, scorer.lruSize, scorer.maxModels, scorer.entryTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the '%s' scorer - %w", NoHitLRUType, err)
+		}
+		scorer.shared = shared
+	}
+
+	go scorer.purgeRemovedPodsPeriodically(handle.Context(), handle.PodList, purgeInterval)
+
+	return scorer.WithName(name), nil
 }
 
 // NewNoHitLRU creates a new NoHitLRU scorer
 func NewNoHitLRU(ctx context.Context, params *NoHitLRUParameters) *NoHitLRU {
+	scorer, err := newNoHitLRU(ctx, params)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to initialize NoHitLRU scorer")
+		return nil
+	}
+	return scorer
+}
+
+func newNoHitLRU(ctx context.Context, params *NoHitLRUParameters) (*NoHitLRU, error) {
 	prefixPluginName := prefix.PrefixCachePluginType
 	lruSize := defaultLRUSize
+	partitionByModel := false
+	maxModels := 1
+	var entryTTL time.Duration
 
 	if params != nil {
 		if params.PrefixPluginName != "" {
@@ -79,28 +144,54 @@ func NewNoHitLRU(ctx context.Context, params *NoHitLRUParameters) *NoHitLRU {
 		if params.LRUSize > 0 {
 			lruSize = params.LRUSize
 		}
+		if params.PartitionByModel {
+			partitionByModel = true
+			maxModels = defaultMaxModels
+			if params.MaxModels > 0 {
+				maxModels = params.MaxModels
+			}
+		}
+		ttl, err := parsePositiveDuration(params.EntryTTL, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entryTTL: %w", err)
+		}
+		entryTTL = ttl
 	}
 
-	lruCache, err := lru.New[string, struct{}](lruSize)
+	lruCaches, err := lru.New[string, *lru.Cache[string, time.Time]](maxModels)
 	if err != nil {
-		log.FromContext(ctx).Error(err, fmt.Sprintf("failed to initialize NoHitLRU scorer: could not create LRU cache with size %d", lruSize))
-		return nil
+		return nil, fmt.Errorf("could not create LRU caches for %d models: %w", maxModels, err)
 	}
 
 	return &NoHitLRU{
 		typedName:        plugins.TypedName{Type: NoHitLRUType},
-		lruCache:         lruCache,
+		lruCaches:        lruCaches,
+		lruSize:          lruSize,
+		partitionByModel: partitionByModel,
+		maxModels:        maxModels,
+		entryTTL:         entryTTL,
 		prefixPluginName: prefixPluginName,
 		pluginState:      plugins.NewPluginState(ctx),
-	}
+	}, nil
 }
 
 // NoHitLRU scorer that favors pods that were least recently used for cold requests.
 // This can help evenly distribute cache growth, since cold requests result in more
-// new KV blocks.
+// new KV blocks. With a shared store, the LRU order is shared by the EPP replicas,
+// so that the cold requests scheduled by different replicas do not clump. When
+// partitioned by model, each target model has its own LRU order. The pods age out
+// of the LRU order after the entry TTL without cold requests, and are purged from
+// it when removed from the pool.
 type NoHitLRU struct {
-	typedName        plugins.TypedName
-	lruCache         *lru.Cache[string, struct{}] // pod name -> dummy value (we only care about order)
+	typedName plugins.TypedName
+	// lruCaches are the LRU caches by partition, the target model when partitioned by model
+	// and the empty string otherwise: pod name -> time of its last cold request
+	lruCaches        *lru.Cache[string, *lru.Cache[string, time.Time]]
+	lruSize          int
+	partitionByModel bool
+	maxModels        int
+	entryTTL         time.Duration // disabled when 0
+	shared           *sharedLRU    // the LRU order shared by the EPP replicas, nil without a shared store
 	prefixPluginName string
 	pluginState      *plugins.PluginState
 }
@@ -144,16 +235,38 @@ func (s *NoHitLRU) scoreNeutral(pods []types.Pod) map[types.Pod]float64 {
 	return scoredPods
 }
 
-// getLRUPositions returns a map of pod names to their LRU position.
+// partition returns the partition of the LRU order the request belongs to
+func (s *NoHitLRU) partition(request *types.LLMRequest) string {
+	if !s.partitionByModel {
+		return ""
+	}
+	return request.TargetModel
+}
+
+// getLRUPositions returns a map of pod names to their LRU position in the given partition.
 // Position 0 represents the oldest (least recently used) entry.
-func (s *NoHitLRU) getLRUPositions() map[string]int {
+func (s *NoHitLRU) getLRUPositions(ctx context.Context, partition string) map[string]int {
+	if s.shared != nil {
+		return s.shared.positions(ctx, partition, time.Now())
+	}
+
+	lruCache, found := s.lruCaches.Get(partition)
+	if !found {
+		return map[string]int{}
+	}
 	// Get all keys from LRU cache in order (oldest first)
 	// https://pkg.go.dev/github.com/hashicorp/golang-lru/v2#Cache.Keys
-	lruKeys := s.lruCache.Keys()
+	lruKeys := lruCache.Keys()
 
+	now := time.Now()
 	lruPosition := make(map[string]int, len(lruKeys))
-	for i, key := range lruKeys {
-		lruPosition[key] = i
+	for _, key := range lruKeys {
+		// the pods without cold requests for the entry TTL age out
+		if usedAt, found := lruCache.Peek(key); s.entryTTL > 0 && found && now.Sub(usedAt) > s.entryTTL {
+			lruCache.Remove(key)
+			continue
+		}
+		lruPosition[key] = len(lruPosition)
 	}
 	return lruPosition
 }
@@ -211,7 +324,7 @@ func (s *NoHitLRU) scoreUsedPods(scoredPods map[types.Pod]float64, usedPods []ty
 // scoreColdRequestByLRU scores pods based on their LRU position for cold requests.
 // Pods that have never received a cold request get the highest scores.
 // Among previously used pods, least recently used ones get higher scores.
-func (s *NoHitLRU) scoreColdRequestByLRU(pods []types.Pod) map[types.Pod]float64 {
+func (s *NoHitLRU) scoreColdRequestByLRU(ctx context.Context, partition string, pods []types.Pod) map[types.Pod]float64 {
 	scoredPods := make(map[types.Pod]float64, len(pods))
 	totalPods := len(pods)
 
@@ -221,7 +334,7 @@ func (s *NoHitLRU) scoreColdRequestByLRU(pods []types.Pod) map[types.Pod]float64
 		return scoredPods
 	}
 
-	lruPosition := s.getLRUPositions()
+	lruPosition := s.getLRUPositions(ctx, partition)
 	usedPods, neverUsedPods := s.partitionPodsByUsage(pods, lruPosition)
 
 	s.scoreNeverUsedPods(scoredPods, neverUsedPods, totalPods)
@@ -251,7 +364,31 @@ func (s *NoHitLRU) Score(ctx context.Context, cycleState *types.CycleState, requ
 	}
 
 	logger.Info("Cold request detected, scoring pods by LRU")
-	return s.scoreColdRequestByLRU(pods)
+	return s.scoreColdRequestByLRU(ctx, s.partition(request), pods)
+}
+
+// StateUsage returns the number of pods in the LRU caches and their estimated size.
+func (s *NoHitLRU) StateUsage() (int, int) {
+	entries := 0
+	for _, lruCache := range s.lruCaches.Values() {
+		entries += lruCache.Len()
+	}
+	return entries, lruEntryBytes
+}
+
+// EvictState evicts the least recently used pods, those of the least recently used models
+// first, which are then scored as never used.
+func (s *NoHitLRU) EvictState(n int) int {
+	evicted := 0
+	for _, lruCache := range s.lruCaches.Values() {
+		for evicted < n {
+			if _, _, ok := lruCache.RemoveOldest(); !ok {
+				break
+			}
+			evicted++
+		}
+	}
+	return evicted
 }
 
 // PreRequest is called before a request is sent to the target pod.
@@ -289,9 +426,62 @@ func (s *NoHitLRU) PreRequest(ctx context.Context, request *types.LLMRequest, sc
 	targetPod := primaryProfile.TargetPods[0]
 	podName := targetPod.GetPod().NamespacedName.String()
 
-	// Move the pod to the front of the LRU.
-	var present struct{} // dummy value
-	s.lruCache.Add(podName, present)
+	// Move the pod to the front of the LRU of the partition of the request.
+	partition := s.partition(request)
+	lruCache, found := s.lruCaches.Get(partition)
+	if !found {
+		lruCache, _ = lru.New[string, time.Time](s.lruSize)
+		if previous, found, _ := s.lruCaches.PeekOrAdd(partition, lruCache); found {
+			lruCache = previous
+		}
+	}
+	now := time.Now()
+	lruCache.Add(podName, now)
+	if s.shared != nil {
+		s.shared.touch(partition, podName, now)
+	}
 
-	logger.Info("Updated LRU cache for cold request", "pod", podName, "requestId", request.RequestId)
+	logger.Info("Updated LRU cache for cold request", "pod", podName, "model", partition, "requestId", request.RequestId)
+}
+
+// purgeRemovedPods purges the pods no longer in the pool from the LRU caches. Nothing is purged
+// while the pool is empty, e.g. before the pods are discovered.
+func (s *NoHitLRU) purgeRemovedPods(ctx context.Context, podList plugins.PodListFunc) {
+	inPool := map[string]bool{}
+	for _, pod := range podList(func(backendmetrics.PodMetrics) bool { return true }) {
+		inPool[pod.GetPod().NamespacedName.String()] = true
+	}
+	if len(inPool) == 0 {
+		return
+	}
+
+	purged := 0
+	for _, lruCache := range s.lruCaches.Values() {
+		for _, podName := range lruCache.Keys() {
+			if !inPool[podName] {
+				lruCache.Remove(podName)
+				purged++
+			}
+		}
+	}
+	if s.shared != nil {
+		purged += s.shared.purge(ctx, inPool)
+	}
+	if purged > 0 {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Purged the pods removed from the pool from the LRU cache", "purged", purged)
+	}
+}
+
+func (s *NoHitLRU) purgeRemovedPodsPeriodically(ctx context.Context, podList plugins.PodListFunc, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.purgeRemovedPods(ctx, podList)
+		}
+	}
 }
@@ -0,0 +1,430 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/redis/go-redis/v9"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	defaultSharedLRUKey           = "llm-d:no-hit-lru"
+	defaultSharedLRUFlushInterval = 100 * time.Millisecond
+	defaultSharedLRUCacheTTL      = time.Second
+	defaultSharedLRUTimeout       = 500 * time.Millisecond
+)
+
+// NoHitLRUSharedStoreParameters defines the shared store of the NoHitLRU scorer, keeping the LRU
+// order of the pods shared by the EPP replicas.
+type NoHitLRUSharedStoreParameters struct {
+	// URL is the URL of the Redis or Valkey server, e.g. "redis://valkey:6379/0", or "rediss://..."
+	// for TLS. Required.
+	URL string `json:"url"`
+
+	// Key is the key of the sorted set holding the LRU order. Defaults to "llm-d:no-hit-lru".
+	Key string `json:"key"`
+
+	// FlushInterval is the interval at which the pods used by the cold requests are written to the
+	// store, in a single batch. Defaults to "100ms".
+	FlushInterval string `json:"flushInterval"`
+
+	// CacheTTL is the time the LRU order read from the store is cached locally. Defaults to "1s".
+	CacheTTL string `json:"cacheTTL"`
+
+	// Timeout is the timeout of the store operations. Defaults to "500ms".
+	Timeout string `json:"timeout"`
+}
+
+// lruStore is a store of the times the pods last served a cold request, shared by the EPP replicas.
+// The LRU order is partitioned, by target model when the scorer is partitioned by model.
+type lruStore interface {
+	// touch records the times the pods last served a cold request by partition, in Unix milliseconds,
+	// keeping the most recent time of each pod
+	touch(ctx context.Context, usedAt map[string]map[string]int64) error
+
+	// load returns the times the pods last served a cold request in a partition, in Unix milliseconds
+	load(ctx context.Context, partition string) (map[string]int64, error)
+
+	// purge removes the pods not in the pool from all the partitions, including the pods only used
+	// by the other replicas, and returns the number of pods removed
+	purge(ctx context.Context, inPool map[string]bool) (int, error)
+
+	// close releases the connections to the store
+	close() error
+}
+
+// sharedLRU is the LRU order of the pods shared through a store. The pods used by the cold requests
+// are written to the store in batches, and the order read from the store is cached for a TTL, the
+// pods used locally since being applied to the cached order immediately.
+type sharedLRU struct {
+	store    lruStore
+	cacheTTL time.Duration
+	timeout  time.Duration
+	entryTTL time.Duration // disabled when 0
+
+	mutex      sync.Mutex
+	pending    map[string]map[string]int64             // the times the pods were used by partition, not yet written to the store
+	partitions *lru.Cache[string, *sharedLRUPartition] // the cached orders of the partitions used the most recently
+}
+
+// sharedLRUPartition is the cached order of a partition
+type sharedLRUPartition struct {
+	usedAt   map[string]int64 // the times the pods were used, read from the store
+	loadedAt time.Time
+	loading  bool // whether the order is being read from the store
+}
+
+// newSharedLRU creates the shared LRU of the given parameters, keeping up to size pods in the store
+// and the order of up to maxPartitions partitions in memory. The pods without cold requests for the
+// entry TTL age out, unless it is 0.
+func newSharedLRU(ctx context.Context, params *NoHitLRUSharedStoreParameters, size int, maxPartitions int,
+	entryTTL time.Duration) (*sharedLRU, error) {
+	if params.URL == "" {
+		return nil, errors.New("the url of the shared store is required")
+	}
+	key := params.Key
+	if key == "" {
+		key = defaultSharedLRUKey
+	}
+	flushInterval, err := parsePositiveDuration(params.FlushInterval, defaultSharedLRUFlushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flushInterval: %w", err)
+	}
+	cacheTTL, err := parsePositiveDuration(params.CacheTTL, defaultSharedLRUCacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cacheTTL: %w", err)
+	}
+	timeout, err := parsePositiveDuration(params.Timeout, defaultSharedLRUTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	store, err := newRedisLRUStore(params.URL, key, size, entryTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := newSharedLRUWithStore(store, cacheTTL, timeout, maxPartitions, entryTTL)
+	if err != nil {
+		return nil, err
+	}
+	go shared.flushPeriodically(ctx, flushInterval)
+	return shared, nil
+}
+
+func newSharedLRUWithStore(store lruStore, cacheTTL time.Duration, timeout time.Duration, maxPartitions int,
+	entryTTL time.Duration) (*sharedLRU, error) {
+	partitions, err := lru.New[string, *sharedLRUPartition](maxPartitions)
+	if err != nil {
+		return nil, err
+	}
+	return &sharedLRU{
+		store:      store,
+		cacheTTL:   cacheTTL,
+		timeout:    timeout,
+		entryTTL:   entryTTL,
+		pending:    map[string]map[string]int64{},
+		partitions: partitions,
+	}, nil
+}
+
+// touch records a cold request of the partition served by the pod
+func (l *sharedLRU) touch(partition string, podName string, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.pending[partition] == nil {
+		l.pending[partition] = map[string]int64{}
+	}
+	l.pending[partition][podName] = now.UnixMilli()
+	l.partition(partition).usedAt[podName] = now.UnixMilli()
+}
+
+// positions returns the LRU positions of the pods in the partition, 0 being the least recently used,
+// reading the order from the store when the cached one expired. The store is read without holding
+// the lock, by a single caller per partition, the others using the expired order meanwhile.
+func (l *sharedLRU) positions(ctx context.Context, partition string, now time.Time) map[string]int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	cached := l.partition(partition)
+	if now.Sub(cached.loadedAt) >= l.cacheTTL && !cached.loading {
+		cached.loading = true
+		l.mutex.Unlock()
+		loaded, err := l.load(ctx, partition)
+		l.mutex.Lock()
+		l.merge(ctx, partition, cached, loaded, err, now)
+	}
+
+	// the pods without cold requests for the entry TTL age out
+	if l.entryTTL > 0 {
+		maps.DeleteFunc(cached.usedAt, func(_ string, usedAt int64) bool {
+			return now.UnixMilli()-usedAt > l.entryTTL.Milliseconds()
+		})
+	}
+
+	// the pods used at the same time are ordered by name, for the replicas to agree on their order
+	podNames := slices.SortedFunc(maps.Keys(cached.usedAt), func(a, b string) int {
+		return cmp.Or(cmp.Compare(cached.usedAt[a], cached.usedAt[b]), strings.Compare(a, b))
+	})
+	positions := make(map[string]int, len(podNames))
+	for i, podName := range podNames {
+		positions[podName] = i
+	}
+	return positions
+}
+
+// partition returns the cached order of a partition, created empty and expired when not cached
+func (l *sharedLRU) partition(partition string) *sharedLRUPartition {
+	cached, found := l.partitions.Get(partition)
+	if !found {
+		cached = &sharedLRUPartition{usedAt: map[string]int64{}}
+		l.partitions.Add(partition, cached)
+	}
+	return cached
+}
+
+// load reads the order of the partition from the store
+func (l *sharedLRU) load(ctx context.Context, partition string) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	return l.store.load(ctx, partition)
+}
+
+// merge replaces the cached order of the partition by the order read from the store at the given
+// time, keeping the cached order when the store failed. The pods used locally since, or not yet
+// written to the store, are applied to the order read. Must be called with the lock held.
+func (l *sharedLRU) merge(ctx context.Context, partition string, cached *sharedLRUPartition, loaded map[string]int64,
+	err error, loadStart time.Time) {
+	cached.loading = false
+	cached.loadedAt = loadStart
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to read the LRU order from the shared store, using the cached order",
+			"partition", partition)
+		return
+	}
+	for podName, usedAt := range l.pending[partition] {
+		loaded[podName] = max(loaded[podName], usedAt)
+	}
+	// the pods used while the store was read may have been flushed after the read
+	for podName, usedAt := range cached.usedAt {
+		if usedAt >= loadStart.UnixMilli() {
+			loaded[podName] = max(loaded[podName], usedAt)
+		}
+	}
+	cached.usedAt = loaded
+}
+
+// flush writes the pods used locally to the store, in a single batch. They are kept to be written
+// again by the next flush when the store fails.
+func (l *sharedLRU) flush(ctx context.Context) {
+	l.mutex.Lock()
+	pending := l.pending
+	l.pending = map[string]map[string]int64{}
+	l.mutex.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	if err := l.store.touch(ctx, pending); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to write the LRU order to the shared store", "partitions", len(pending))
+		l.mutex.Lock()
+		for partition, usedAt := range pending {
+			if l.pending[partition] == nil {
+				l.pending[partition] = map[string]int64{}
+			}
+			for podName, podUsedAt := range usedAt {
+				l.pending[partition][podName] = max(l.pending[partition][podName], podUsedAt)
+			}
+		}
+		l.mutex.Unlock()
+		return
+	}
+	log.FromContext(ctx).V(logutil.TRACE).Info("Wrote the LRU order to the shared store", "partitions", len(pending))
+}
+
+// purge removes the pods no longer in the pool from the cached orders and from all the partitions of
+// the store, and returns the number of pods removed from the cached orders
+func (l *sharedLRU) purge(ctx context.Context, inPool map[string]bool) int {
+	purged := 0
+	l.mutex.Lock()
+	for _, partition := range l.partitions.Keys() {
+		cached, _ := l.partitions.Peek(partition)
+		for podName := range cached.usedAt {
+			if !inPool[podName] {
+				delete(cached.usedAt, podName)
+				purged++
+			}
+		}
+	}
+	for _, pending := range l.pending {
+		for podName := range pending {
+			if !inPool[podName] {
+				delete(pending, podName)
+			}
+		}
+	}
+	l.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+	removed, err := l.store.purge(ctx, inPool)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to remove the pods removed from the pool from the shared store")
+	} else if removed > 0 {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Removed the pods removed from the pool from the shared store", "removed", removed)
+	}
+	return purged
+}
+
+func (l *sharedLRU) flushPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.store.close() //nolint:errcheck
+			return
+		case <-ticker.C:
+			l.flush(ctx)
+		}
+	}
+}
+
+// redisLRUStore keeps the LRU order of each partition in a Redis or Valkey sorted set, scored by the
+// time the pods last served a cold request. The sorted set of a model partition is keyed by the key
+// suffixed by the model name.
+type redisLRUStore struct {
+	client   *redis.Client
+	key      string
+	size     int
+	entryTTL time.Duration // disabled when 0
+}
+
+func newRedisLRUStore(url string, key string, size int, entryTTL time.Duration) (*redisLRUStore, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url of the shared store: %w", err)
+	}
+	return &redisLRUStore{client: redis.NewClient(options), key: key, size: size, entryTTL: entryTTL}, nil
+}
+
+// touch adds the pods to the sorted sets of their partition, only increasing their time, and trims
+// the sets to the most recently used pods, removing the pods aged out
+func (s *redisLRUStore) touch(ctx context.Context, usedAt map[string]map[string]int64) error {
+	pipeline := s.client.TxPipeline()
+	for partition, partitionUsedAt := range usedAt {
+		members := make([]redis.Z, 0, len(partitionUsedAt))
+		for podName, podUsedAt := range partitionUsedAt {
+			members = append(members, redis.Z{Score: float64(podUsedAt), Member: podName})
+		}
+		pipeline.ZAddGT(ctx, s.partitionKey(partition), members...)
+		pipeline.ZRemRangeByRank(ctx, s.partitionKey(partition), 0, int64(-s.size-1))
+		if s.entryTTL > 0 {
+			agedOut := time.Now().Add(-s.entryTTL).UnixMilli()
+			pipeline.ZRemRangeByScore(ctx, s.partitionKey(partition), "-inf", "("+strconv.FormatInt(agedOut, 10))
+		}
+	}
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+// purge removes the pods not in the pool from the sorted sets of all the partitions, those of the
+// key and of the keys prefixed by it
+func (s *redisLRUStore) purge(ctx context.Context, inPool map[string]bool) (int, error) {
+	keys := []string{s.key}
+	iter := s.client.ScanType(ctx, 0, globEscaper.Replace(s.key)+":*", 0, "zset").Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return 0, err
+	}
+
+	pipeline := s.client.TxPipeline()
+	removed := 0
+	for _, key := range keys {
+		podNames, err := s.client.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return 0, err
+		}
+		members := []any{}
+		for _, podName := range podNames {
+			if !inPool[podName] {
+				members = append(members, podName)
+			}
+		}
+		if len(members) > 0 {
+			pipeline.ZRem(ctx, key, members...)
+			removed += len(members)
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	if _, err := pipeline.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// load returns the pods of the sorted set of the partition and their time
+func (s *redisLRUStore) load(ctx context.Context, partition string) (map[string]int64, error) {
+	members, err := s.client.ZRangeWithScores(ctx, s.partitionKey(partition), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	usedAt := make(map[string]int64, len(members))
+	for _, member := range members {
+		podName, ok := member.Member.(string)
+		if !ok {
+			continue
+		}
+		usedAt[podName] = int64(member.Score)
+	}
+	return usedAt, nil
+}
+
+// partitionKey returns the key of the sorted set of a partition
+func (s *redisLRUStore) partitionKey(partition string) string {
+	if partition == "" {
+		return s.key
+	}
+	return s.key + ":" + partition
+}
+
+// globEscaper escapes the special characters of the glob patterns matching the keys
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+func (s *redisLRUStore) close() error {
+	return s.client.Close()
+}
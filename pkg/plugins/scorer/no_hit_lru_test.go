@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -394,6 +395,96 @@ func TestNoHitLRUPreferLeastRecentlyUsedAfterColdRequests(t *testing.T) {
 	})
 }
 
+func TestNoHitLRUPartitionByModel(t *testing.T) {
+	ctx := context.Background()
+
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod-a", Namespace: "default"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	podB := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod-b", Namespace: "default"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	pods := []types.Pod{podA, podB}
+	requestToPod := func(target types.Pod) *types.SchedulingResult {
+		return &types.SchedulingResult{
+			PrimaryProfileName: "default",
+			ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{target}}},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		partitionByModel bool
+		wantModelBScores map[types.Pod]float64
+	}{
+		{
+			name:             "shared by the models",
+			wantModelBScores: map[types.Pod]float64{podA: 0, podB: 1},
+		},
+		{
+			name:             "partitioned by model",
+			partitionByModel: true,
+			wantModelBScores: map[types.Pod]float64{podA: 1, podB: 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scorer := scorer.NewNoHitLRU(ctx, &scorer.NoHitLRUParameters{PartitionByModel: test.partitionByModel, MaxModels: 2})
+
+			// a cold request of model-a is sent to pod-a
+			coldReqA := &types.LLMRequest{RequestId: "cold-a", TargetModel: "model-a"}
+			scorer.Score(ctx, &types.CycleState{}, coldReqA, pods)
+			scorer.PreRequest(ctx, coldReqA, requestToPod(podA))
+
+			// the scores of model-b only reflect the cold requests of model-a without partitioning
+			coldReqB := &types.LLMRequest{RequestId: "cold-b", TargetModel: "model-b"}
+			if diff := cmp.Diff(test.wantModelBScores, scorer.Score(ctx, &types.CycleState{}, coldReqB, pods)); diff != "" {
+				t.Errorf("Unexpected scores of model-b (-want +got): %v", diff)
+			}
+			if entries, _ := scorer.StateUsage(); entries != 1 {
+				t.Errorf("StateUsage() entries = %d, want 1", entries)
+			}
+		})
+	}
+}
+
+func TestNoHitLRUEntryTTL(t *testing.T) {
+	ctx := context.Background()
+
+	podA := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod-a", Namespace: "default"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	podB := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod-b", Namespace: "default"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	pods := []types.Pod{podA, podB}
+	scorer := scorer.NewNoHitLRU(ctx, &scorer.NoHitLRUParameters{EntryTTL: "50ms"})
+
+	coldReq := &types.LLMRequest{RequestId: "cold-1"}
+	scorer.Score(ctx, &types.CycleState{}, coldReq, pods)
+	scorer.PreRequest(ctx, coldReq, &types.SchedulingResult{
+		PrimaryProfileName: "default",
+		ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{podA}}},
+	})
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0, podB: 1}, scorer.Score(ctx, &types.CycleState{}, &types.LLMRequest{RequestId: "cold-2"}, pods)); diff != "" {
+		t.Errorf("Unexpected scores after a cold request (-want +got): %v", diff)
+	}
+
+	// pod-a ages out, and is scored as never used
+	time.Sleep(100 * time.Millisecond)
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 0}, scorer.Score(ctx, &types.CycleState{}, &types.LLMRequest{RequestId: "cold-3"}, pods)); diff != "" {
+		t.Errorf("Unexpected scores after the entry TTL (-want +got): %v", diff)
+	}
+	if entries, _ := scorer.StateUsage(); entries != 0 {
+		t.Errorf("StateUsage() entries = %d, want 0", entries)
+	}
+}
+
 func TestNoHitLRUEdgeCases(t *testing.T) {
 	ctx := context.Background()
 	scorer := scorer.NewNoHitLRU(ctx, nil)
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// PrecisionType is the type of the Precision scorer.
+	PrecisionType = "precision-scorer"
+
+	// PrecisionLabel is the default pod label advertising the precision of the served model variant.
+	PrecisionLabel = "llm-d.ai/precision"
+
+	defaultPrecisionHeader = "x-precision"
+)
+
+// PrecisionParameters defines the parameters of the Precision scorer.
+type PrecisionParameters struct {
+	// PrecisionHeader is the request header holding the required precision. Defaults to "x-precision".
+	PrecisionHeader string `json:"precisionHeader"`
+
+	// Label is the pod label advertising the precision, such as fp8, int4 or bf16.
+	// Defaults to "llm-d.ai/precision".
+	Label string `json:"label"`
+
+	// ModelPrecisions maps model aliases to the precision they require, used when the request
+	// has no precision header.
+	ModelPrecisions map[string]string `json:"modelPrecisions"`
+}
+
+// compile-time type assertions
+var _ framework.Filter = &Precision{}
+var _ framework.Scorer = &Precision{}
+
+// PrecisionFactory defines the factory function for the Precision scorer.
+func PrecisionFactory(name string, rawParameters json.RawMessage, _ plugins.Handle) (plugins.Plugin, error) {
+	parameters := PrecisionParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", PrecisionType, err)
+		}
+	}
+
+	precision, err := NewPrecision(&parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", PrecisionType, err)
+	}
+	return precision.WithName(name), nil
+}
+
+// NewPrecision creates a new Precision scorer.
+func NewPrecision(params *PrecisionParameters) (*Precision, error) {
+	precisionHeader := params.PrecisionHeader
+	if precisionHeader == "" {
+		precisionHeader = defaultPrecisionHeader
+	}
+	label := params.Label
+	if label == "" {
+		label = PrecisionLabel
+	}
+
+	modelPrecisions := make(map[string]string, len(params.ModelPrecisions))
+	for model, precision := range params.ModelPrecisions {
+		precision = normalizePrecision(precision)
+		if precision == "" {
+			return nil, fmt.Errorf("invalid modelPrecisions: the precision of the model '%s' is empty", model)
+		}
+		modelPrecisions[model] = precision
+	}
+
+	return &Precision{
+		typedName:       plugins.TypedName{Type: PrecisionType},
+		precisionHeader: strings.ToLower(precisionHeader),
+		label:           label,
+		modelPrecisions: modelPrecisions,
+	}, nil
+}
+
+// Precision routes the requests declaring a precision requirement, such as precision-sensitive
+// evaluation traffic, to the pods serving a model variant of that precision. The requirement is
+// read from a request header, or else from the precision of the requested model alias, and matched
+// against a pod label:
+//   - as a filter, it keeps only the pods advertising the required precision;
+//   - as a scorer, it favors the pods advertising the required precision.
+//
+// Requests without a precision requirement are not affected.
+type Precision struct {
+	typedName       plugins.TypedName
+	precisionHeader string
+	label           string
+	modelPrecisions map[string]string
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *Precision) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *Precision) WithName(name string) *Precision {
+	s.typedName.Name = name
+	return s
+}
+
+// Filter keeps only the pods advertising the precision required by the request. No pod is kept
+// when none advertises it, so that the request is never served at a different precision.
+func (s *Precision) Filter(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) []types.Pod {
+	precision := s.requiredPrecision(request)
+	if precision == "" {
+		return pods
+	}
+
+	matchingPods := make([]types.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if s.matches(pod, precision) {
+			matchingPods = append(matchingPods, pod)
+		}
+	}
+
+	if len(matchingPods) == 0 {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("No pod serves the required precision", "precision", precision)
+	}
+	return matchingPods
+}
+
+// Score gives the highest score to the pods advertising the precision required by the request,
+// and zero to the others. All pods get the same score when the request has no precision requirement.
+func (s *Precision) Score(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	precision := s.requiredPrecision(request)
+
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+		if precision != "" && s.matches(pod, precision) {
+			scoredPods[pod] = 1
+		}
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "precision", precision, "scores", scoredPods)
+	return scoredPods
+}
+
+// requiredPrecision returns the precision required by the request, or an empty string when it has none
+func (s *Precision) requiredPrecision(request *types.LLMRequest) string {
+	if precision := normalizePrecision(request.Headers[s.precisionHeader]); precision != "" {
+		return precision
+	}
+	return s.modelPrecisions[request.TargetModel]
+}
+
+// matches returns whether the pod advertises the given precision
+func (s *Precision) matches(pod types.Pod, precision string) bool {
+	return normalizePrecision(pod.GetPod().Labels[s.label]) == precision
+}
+
+// normalizePrecision makes the precisions case-insensitive, so that FP8 matches fp8
+func normalizePrecision(precision string) string {
+	return strings.ToLower(strings.TrimSpace(precision))
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestPrecision(t *testing.T) {
+	newPod := func(name string, labels map[string]string) types.Pod {
+		return &types.PodMetrics{
+			Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}, Labels: labels},
+			MetricsState: &backendmetrics.MetricsState{},
+		}
+	}
+	podFP8 := newPod("pod-fp8", map[string]string{scorer.PrecisionLabel: "fp8"})
+	podBF16 := newPod("pod-bf16", map[string]string{scorer.PrecisionLabel: "BF16"})
+	podUnlabeled := newPod("pod-unlabeled", nil)
+	pods := []types.Pod{podFP8, podBF16, podUnlabeled}
+
+	tests := []struct {
+		name         string
+		request      *types.LLMRequest
+		wantFiltered []types.Pod
+		wantScores   map[types.Pod]float64
+	}{
+		{
+			name:         "precision header",
+			request:      &types.LLMRequest{TargetModel: "llama", Headers: map[string]string{"x-precision": "fp8"}},
+			wantFiltered: []types.Pod{podFP8},
+			wantScores:   map[types.Pod]float64{podFP8: 1, podBF16: 0, podUnlabeled: 0},
+		},
+		{
+			name:         "precision header is case-insensitive",
+			request:      &types.LLMRequest{TargetModel: "llama", Headers: map[string]string{"x-precision": " bf16 "}},
+			wantFiltered: []types.Pod{podBF16},
+			wantScores:   map[types.Pod]float64{podFP8: 0, podBF16: 1, podUnlabeled: 0},
+		},
+		{
+			name:         "model alias precision",
+			request:      &types.LLMRequest{TargetModel: "llama-eval", Headers: map[string]string{}},
+			wantFiltered: []types.Pod{podBF16},
+			wantScores:   map[types.Pod]float64{podFP8: 0, podBF16: 1, podUnlabeled: 0},
+		},
+		{
+			name:         "precision header overrides the model alias",
+			request:      &types.LLMRequest{TargetModel: "llama-eval", Headers: map[string]string{"x-precision": "fp8"}},
+			wantFiltered: []types.Pod{podFP8},
+			wantScores:   map[types.Pod]float64{podFP8: 1, podBF16: 0, podUnlabeled: 0},
+		},
+		{
+			name:         "no pod serves the precision",
+			request:      &types.LLMRequest{TargetModel: "llama", Headers: map[string]string{"x-precision": "int4"}},
+			wantFiltered: []types.Pod{},
+			wantScores:   map[types.Pod]float64{podFP8: 0, podBF16: 0, podUnlabeled: 0},
+		},
+		{
+			name:         "request without precision requirement",
+			request:      &types.LLMRequest{TargetModel: "llama", Headers: map[string]string{}},
+			wantFiltered: pods,
+			wantScores:   map[types.Pod]float64{podFP8: 0, podBF16: 0, podUnlabeled: 0},
+		},
+	}
+
+	// the header names are matched case insensitively
+	precision, err := scorer.NewPrecision(&scorer.PrecisionParameters{
+		PrecisionHeader: "X-Precision",
+		ModelPrecisions: map[string]string{"llama-eval": "BF16"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			gotFiltered := precision.Filter(ctx, types.NewCycleState(), test.request, pods)
+			if diff := cmp.Diff(podNames(test.wantFiltered), podNames(gotFiltered)); diff != "" {
+				t.Errorf("Unexpected filtered pods (-want +got): %v", diff)
+			}
+
+			gotScores := precision.Score(ctx, types.NewCycleState(), test.request, pods)
+			if diff := cmp.Diff(test.wantScores, gotScores); diff != "" {
+				t.Errorf("Unexpected scores (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestPrecisionInvalidModelPrecision(t *testing.T) {
+	if _, err := scorer.NewPrecision(&scorer.PrecisionParameters{ModelPrecisions: map[string]string{"llama": " "}}); err == nil {
+		t.Error("expected an error for an empty model precision")
+	}
+}
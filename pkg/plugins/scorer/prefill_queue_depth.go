@@ -0,0 +1,255 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+const (
+	// PrefillQueueDepthType is the type of the PrefillQueueDepth scorer.
+	PrefillQueueDepthType = "prefill-queue-depth-scorer"
+
+	defaultPrefillProfileName     = "prefill"
+	defaultPendingTokenSaturation = 32768
+	defaultPrefillRequestTimeout  = 30 * time.Second
+
+	// averageCharactersPerToken estimates the number of prompt tokens from the length of the prompt
+	averageCharactersPerToken = 4
+)
+
+// PrefillQueueDepthParameters defines the parameters of the PrefillQueueDepth scorer.
+type PrefillQueueDepthParameters struct {
+	// PrefillProfile is the name of the prefill profile, whose target pods are sent the prefills.
+	// Defaults to "prefill".
+	PrefillProfile string `json:"prefillProfile"`
+
+	// PendingTokensSaturation is the number of pending prefill tokens at which a pod gets the lowest
+	// pending tokens score. Defaults to 32768.
+	PendingTokensSaturation int `json:"pendingTokensSaturation"`
+
+	// QueueSaturation is the number of waiting requests at which a pod gets the lowest queue score.
+	// Defaults to 128.
+	QueueSaturation int `json:"queueSaturation"`
+
+	// PendingTokensWeight is the weight of the pending tokens score. Defaults to 1.
+	PendingTokensWeight *float64 `json:"pendingTokensWeight"`
+
+	// QueueWeight is the weight of the queue score. Defaults to 1.
+	QueueWeight *float64 `json:"queueWeight"`
+
+	// RequestTimeout is the duration after which a prefill is no longer pending, when the response of
+	// its request was never received. Defaults to 30s.
+	RequestTimeout string `json:"requestTimeout"`
+}
+
+// prefillEntry is a prefill pending on a pod
+type prefillEntry struct {
+	PodName string
+	Tokens  int
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &PrefillQueueDepth{}
+var _ requestcontrol.PreRequest = &PrefillQueueDepth{}
+var _ requestcontrol.ResponseReceived = &PrefillQueueDepth{}
+
+// PrefillQueueDepthFactory defines the factory function for the PrefillQueueDepth scorer.
+func PrefillQueueDepthFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := PrefillQueueDepthParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", PrefillQueueDepthType, err)
+		}
+	}
+
+	scorer, err := NewPrefillQueueDepth(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", PrefillQueueDepthType, err)
+	}
+	return scorer.WithName(name), nil
+}
+
+// NewPrefillQueueDepth creates a new PrefillQueueDepth scorer.
+func NewPrefillQueueDepth(ctx context.Context, params *PrefillQueueDepthParameters) (*PrefillQueueDepth, error) {
+	prefillProfile := params.PrefillProfile
+	if prefillProfile == "" {
+		prefillProfile = defaultPrefillProfileName
+	}
+	pendingTokensSaturation := params.PendingTokensSaturation
+	if pendingTokensSaturation == 0 {
+		pendingTokensSaturation = defaultPendingTokenSaturation
+	}
+	queueSaturation := params.QueueSaturation
+	if queueSaturation == 0 {
+		queueSaturation = QueueThresholdDefault
+	}
+	if pendingTokensSaturation < 0 || queueSaturation < 0 {
+		return nil, fmt.Errorf("invalid saturations %d and %d: must be positive", pendingTokensSaturation, queueSaturation)
+	}
+	pendingTokensWeight, queueWeight := 1.0, 1.0
+	if params.PendingTokensWeight != nil {
+		pendingTokensWeight = *params.PendingTokensWeight
+	}
+	if params.QueueWeight != nil {
+		queueWeight = *params.QueueWeight
+	}
+	if pendingTokensWeight < 0 || queueWeight < 0 || pendingTokensWeight+queueWeight == 0 {
+		return nil, fmt.Errorf("invalid weights %v and %v: must not be negative, nor both 0", pendingTokensWeight, queueWeight)
+	}
+	requestTimeout, err := parsePositiveDuration(params.RequestTimeout, defaultPrefillRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requestTimeout: %w", err)
+	}
+
+	// the prefills expire, so that the pending tokens do not leak when no response is received
+	pendingPrefills := ttlcache.New[string, *prefillEntry](
+		ttlcache.WithTTL[string, *prefillEntry](requestTimeout),
+		ttlcache.WithDisableTouchOnHit[string, *prefillEntry](),
+	)
+
+	scorer := &PrefillQueueDepth{
+		typedName:               plugins.TypedName{Type: PrefillQueueDepthType},
+		prefillProfile:          prefillProfile,
+		pendingTokensSaturation: float64(pendingTokensSaturation),
+		queueSaturation:         float64(queueSaturation),
+		pendingTokensWeight:     pendingTokensWeight / (pendingTokensWeight + queueWeight),
+		queueWeight:             queueWeight / (pendingTokensWeight + queueWeight),
+		pendingPrefills:         pendingPrefills,
+		podTokens:               map[string]int{},
+	}
+	pendingPrefills.OnEviction(func(_ context.Context, reason ttlcache.EvictionReason, item *ttlcache.Item[string, *prefillEntry]) {
+		if reason == ttlcache.EvictionReasonExpired {
+			scorer.removeTokens(item.Value())
+		}
+	})
+
+	go cleanCachePeriodically(ctx, pendingPrefills, requestTimeout)
+
+	return scorer, nil
+}
+
+// PrefillQueueDepth scores the prefill pods by their prefill backlog, rather than by the decode
+// oriented metrics scoring the prefill and the decode pods identically:
+//   - the prompt tokens of the prefills pending on the pod, estimated from the length of the prompts
+//     sent to it by the prefill profile, until the response of their request is received;
+//   - the number of requests waiting in the queue of the pod.
+//
+// Both are normalized to [0,1] against their saturation threshold, 1 for an idle pod and 0 for a
+// saturated one, and combined by their weights.
+type PrefillQueueDepth struct {
+	typedName               plugins.TypedName
+	prefillProfile          string
+	pendingTokensSaturation float64
+	queueSaturation         float64
+	pendingTokensWeight     float64
+	queueWeight             float64
+
+	// pendingPrefills are the prefills pending on the pods, by request ID
+	pendingPrefills *ttlcache.Cache[string, *prefillEntry]
+
+	// podTokens are the pending prefill tokens of the pods, only the pods with pending prefills
+	podTokens map[string]int
+	mutex     sync.RWMutex
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *PrefillQueueDepth) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *PrefillQueueDepth) WithName(name string) *PrefillQueueDepth {
+	s.typedName.Name = name
+	return s
+}
+
+// Score scores the given pods in the range of 0-1 by their pending prefill tokens and their waiting
+// requests, the pods without metrics only by their pending prefill tokens
+func (s *PrefillQueueDepth) Score(ctx context.Context, _ *types.CycleState, _ *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	s.mutex.RLock()
+	for _, pod := range pods {
+		pendingTokens := s.podTokens[pod.GetPod().NamespacedName.String()]
+		waitingRequests := 0
+		if metrics := pod.GetMetrics(); metrics != nil {
+			waitingRequests = metrics.WaitingQueueSize
+		}
+		scoredPods[pod] = s.pendingTokensWeight*saturationScore(float64(pendingTokens), s.pendingTokensSaturation) +
+			s.queueWeight*saturationScore(float64(waitingRequests), s.queueSaturation)
+	}
+	s.mutex.RUnlock()
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "scores", scoredPods)
+	return scoredPods
+}
+
+// PreRequest records the prompt tokens of the request as pending on the pod selected by the prefill
+// profile, when the request is disaggregated
+func (s *PrefillQueueDepth) PreRequest(ctx context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	profileResult := schedulingResult.ProfileResults[s.prefillProfile]
+	if profileResult == nil || len(profileResult.TargetPods) == 0 {
+		return
+	}
+
+	entry := &prefillEntry{
+		PodName: profileResult.TargetPods[0].GetPod().NamespacedName.String(),
+		Tokens:  estimatedPromptTokens(request),
+	}
+	s.mutex.Lock()
+	s.podTokens[entry.PodName] += entry.Tokens
+	s.mutex.Unlock()
+	if previous, found := s.pendingPrefills.GetAndDelete(request.RequestId); found {
+		s.removeTokens(previous.Value())
+	}
+	s.pendingPrefills.Set(request.RequestId, entry, ttlcache.DefaultTTL)
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Added pending prefill", "pod", entry.PodName, "tokens", entry.Tokens)
+}
+
+// ResponseReceived removes the pending prefill of the request: the decode started, so the prefill is done
+func (s *PrefillQueueDepth) ResponseReceived(ctx context.Context, request *types.LLMRequest, _ *requestcontrol.Response, _ *backend.Pod) {
+	if item, found := s.pendingPrefills.GetAndDelete(request.RequestId); found {
+		s.removeTokens(item.Value())
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Removed pending prefill", "pod", item.Value().PodName)
+	}
+}
+
+// removeTokens removes the tokens of a prefill from the pending tokens of its pod
+func (s *PrefillQueueDepth) removeTokens(entry *prefillEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.podTokens[entry.PodName] <= entry.Tokens {
+		delete(s.podTokens, entry.PodName)
+	} else {
+		s.podTokens[entry.PodName] -= entry.Tokens
+	}
+}
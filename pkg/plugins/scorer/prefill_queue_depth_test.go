@@ -0,0 +1,158 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestPrefillQueueDepth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newPod := func(name string, waitingQueueSize int) types.Pod {
+		return &types.PodMetrics{
+			Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+			MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: waitingQueueSize},
+		}
+	}
+	podA := newPod("pod-a", 0)
+	podB := newPod("pod-b", 5)
+	pods := []types.Pod{podA, podB}
+
+	weight := 1.0
+	prefillQueueDepth, err := scorer.NewPrefillQueueDepth(ctx, &scorer.PrefillQueueDepthParameters{
+		PendingTokensSaturation: 200,
+		QueueSaturation:         10,
+		QueueWeight:             &weight,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	score := func() map[types.Pod]float64 {
+		return prefillQueueDepth.Score(ctx, nil, &types.LLMRequest{}, pods)
+	}
+	newRequest := func(id string, promptLength int) *types.LLMRequest {
+		return &types.LLMRequest{
+			RequestId: id,
+			Body:      &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: strings.Repeat("a", promptLength)}},
+		}
+	}
+	schedule := func(prefillPod types.Pod) *types.SchedulingResult {
+		return &types.SchedulingResult{ProfileResults: map[string]*types.ProfileRunResult{
+			"prefill": {TargetPods: []types.Pod{prefillPod}},
+			"decode":  {TargetPods: []types.Pod{podB}},
+		}}
+	}
+
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 0.75}, score()); diff != "" {
+		t.Errorf("Unexpected scores without pending prefills (-want +got): %v", diff)
+	}
+
+	// 399 characters are 100 tokens, half the saturation
+	prefillQueueDepth.PreRequest(ctx, newRequest("request-1", 399), schedule(podA))
+	prefillQueueDepth.PreRequest(ctx, newRequest("request-2", 399), schedule(podA))
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0.5, podB: 0.75}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores with pending prefills (-want +got): %v", diff)
+	}
+
+	prefillQueueDepth.ResponseReceived(ctx, newRequest("request-1", 399), nil, podB.GetPod())
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0.75, podB: 0.75}, score(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Unexpected scores after a prefill completed (-want +got): %v", diff)
+	}
+
+	// the requests which were not disaggregated have no pending prefill
+	prefillQueueDepth.PreRequest(ctx, newRequest("request-3", 399), &types.SchedulingResult{
+		ProfileResults: map[string]*types.ProfileRunResult{"decode": {TargetPods: []types.Pod{podA}}},
+	})
+	prefillQueueDepth.ResponseReceived(ctx, newRequest("request-2", 399), nil, podB.GetPod())
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 0.75}, score()); diff != "" {
+		t.Errorf("Unexpected scores after all the prefills completed (-want +got): %v", diff)
+	}
+}
+
+func TestPrefillQueueDepthExpiration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	prefillQueueDepth, err := scorer.NewPrefillQueueDepth(ctx, &scorer.PrefillQueueDepthParameters{
+		PendingTokensSaturation: 10,
+		RequestTimeout:          "50ms",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prefillQueueDepth.PreRequest(ctx, &types.LLMRequest{RequestId: "request-1"}, &types.SchedulingResult{
+		ProfileResults: map[string]*types.ProfileRunResult{"prefill": {TargetPods: []types.Pod{pod}}},
+	})
+	if got := prefillQueueDepth.Score(ctx, nil, &types.LLMRequest{}, []types.Pod{pod})[pod]; got != 0.95 {
+		t.Errorf("score with a pending prefill = %v, want 0.95", got)
+	}
+
+	// the prefill whose response is never received expires
+	time.Sleep(150 * time.Millisecond)
+	if got := prefillQueueDepth.Score(ctx, nil, &types.LLMRequest{}, []types.Pod{pod})[pod]; got != 1 {
+		t.Errorf("score after the prefill expired = %v, want 1", got)
+	}
+}
+
+func TestPrefillQueueDepthFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handle := plugins.NewEppHandle(ctx, nil)
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{}`},
+		{name: "custom values", parameters: `{"prefillProfile": "p", "pendingTokensSaturation": 4096, "queueSaturation": 16, "pendingTokensWeight": 2, "queueWeight": 0, "requestTimeout": "1m"}`},
+		{name: "negative saturation", parameters: `{"queueSaturation": -1}`, wantErr: true},
+		{name: "negative weight", parameters: `{"pendingTokensWeight": -1}`, wantErr: true},
+		{name: "zero weights", parameters: `{"pendingTokensWeight": 0, "queueWeight": 0}`, wantErr: true},
+		{name: "invalid request timeout", parameters: `{"requestTimeout": "0s"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scorer.PrefillQueueDepthFactory("prefill-queue-depth", json.RawMessage(test.parameters), handle)
+			if (err != nil) != test.wantErr {
+				t.Errorf("PrefillQueueDepthFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
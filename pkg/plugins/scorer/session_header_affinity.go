@@ -0,0 +1,188 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/requestcontrol"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/budget"
+)
+
+const (
+	// SessionHeaderAffinityType is the type of the SessionHeaderAffinity scorer.
+	SessionHeaderAffinityType = "session-header-affinity-scorer"
+
+	defaultSessionHeader = "x-session-id"
+	defaultSessionTTL    = 30 * time.Minute
+	defaultMaxSessions   = 10000
+
+	// sessionPodBytes is the estimated memory of a session, its ID, its pod name and its cache item
+	sessionPodBytes = 224
+)
+
+// SessionHeaderAffinityParameters defines the parameters of the SessionHeaderAffinity scorer.
+type SessionHeaderAffinityParameters struct {
+	// SessionHeader is the request header holding the session ID. Defaults to "x-session-id".
+	SessionHeader string `json:"sessionHeader"`
+
+	// SessionTTL is the time after the last request of a session from which the session is
+	// forgotten. Defaults to "30m".
+	SessionTTL string `json:"sessionTTL"`
+
+	// MaxSessions is the maximum number of sessions remembered, the least recently used ones
+	// being forgotten first. Defaults to 10000.
+	MaxSessions int `json:"maxSessions"`
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &SessionHeaderAffinity{}
+var _ requestcontrol.PreRequest = &SessionHeaderAffinity{}
+var _ budget.Stateful = &SessionHeaderAffinity{}
+
+// SessionHeaderAffinityFactory defines the factory function for the SessionHeaderAffinity scorer.
+func SessionHeaderAffinityFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := SessionHeaderAffinityParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", SessionHeaderAffinityType, err)
+		}
+	}
+
+	scorer, err := NewSessionHeaderAffinity(handle.Context(), &parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", SessionHeaderAffinityType, err)
+	}
+	return scorer.WithName(name), nil
+}
+
+// NewSessionHeaderAffinity creates a new SessionHeaderAffinity scorer.
+func NewSessionHeaderAffinity(ctx context.Context, params *SessionHeaderAffinityParameters) (*SessionHeaderAffinity, error) {
+	sessionHeader := params.SessionHeader
+	if sessionHeader == "" {
+		sessionHeader = defaultSessionHeader
+	}
+	sessionTTL, err := parsePositiveDuration(params.SessionTTL, defaultSessionTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sessionTTL: %w", err)
+	}
+	maxSessions := params.MaxSessions
+	if maxSessions == 0 {
+		maxSessions = defaultMaxSessions
+	}
+	if maxSessions < 0 {
+		return nil, fmt.Errorf("invalid maxSessions: must be > 0, got %d", maxSessions)
+	}
+
+	sessions := ttlcache.New[string, string](
+		ttlcache.WithTTL[string, string](sessionTTL),
+		ttlcache.WithCapacity[string, string](uint64(maxSessions)),
+		ttlcache.WithDisableTouchOnHit[string, string](),
+	)
+	go cleanCachePeriodically(ctx, sessions, sessionTTL)
+
+	return &SessionHeaderAffinity{
+		typedName:     plugins.TypedName{Type: SessionHeaderAffinityType},
+		sessionHeader: strings.ToLower(sessionHeader),
+		sessions:      sessions,
+	}, nil
+}
+
+// SessionHeaderAffinity routes the requests of a session, identified by a request header such as
+// the ID of a multi-turn chat, to the pod which served the previous request of the session, so that
+// the conversation gets the affinity even when its prefix is not matched by the prefix cache scorers.
+// Unlike the SessionAffinity scorer, the session pods are remembered by the scorer rather than
+// returned to the client, for a TTL after the last request of the session and up to a maximum number
+// of sessions. The pod of the session gets the highest score and the other pods zero.
+type SessionHeaderAffinity struct {
+	typedName     plugins.TypedName
+	sessionHeader string
+
+	// sessions are the pods which served the last request of the sessions, by session ID
+	sessions *ttlcache.Cache[string, string]
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *SessionHeaderAffinity) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *SessionHeaderAffinity) WithName(name string) *SessionHeaderAffinity {
+	s.typedName.Name = name
+	return s
+}
+
+// Score gives the highest score to the pod which served the last request of the session of the
+// request, and zero to the others. All pods get zero when the session is unknown.
+func (s *SessionHeaderAffinity) Score(ctx context.Context, _ *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	scoredPods := make(map[types.Pod]float64, len(pods))
+	for _, pod := range pods {
+		scoredPods[pod] = 0
+	}
+
+	sessionID := request.Headers[s.sessionHeader]
+	if sessionID == "" {
+		return scoredPods
+	}
+	item := s.sessions.Get(sessionID)
+	if item == nil {
+		return scoredPods
+	}
+	for _, pod := range pods {
+		if pod.GetPod().NamespacedName.String() == item.Value() {
+			scoredPods[pod] = 1
+		}
+	}
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "session", sessionID, "pod", item.Value(), "scores", scoredPods)
+	return scoredPods
+}
+
+// PreRequest records the pod selected by the primary profile as the pod of the session of the request.
+func (s *SessionHeaderAffinity) PreRequest(_ context.Context, request *types.LLMRequest, schedulingResult *types.SchedulingResult) {
+	sessionID := request.Headers[s.sessionHeader]
+	if sessionID == "" {
+		return
+	}
+	primaryProfile := schedulingResult.ProfileResults[schedulingResult.PrimaryProfileName]
+	if primaryProfile == nil || len(primaryProfile.TargetPods) == 0 {
+		return
+	}
+	s.sessions.Set(sessionID, primaryProfile.TargetPods[0].GetPod().NamespacedName.String(), ttlcache.DefaultTTL)
+}
+
+// StateUsage returns the number of remembered sessions and their estimated size.
+func (s *SessionHeaderAffinity) StateUsage() (int, int) {
+	return s.sessions.Len(), sessionPodBytes
+}
+
+// EvictState forgets the sessions used the least recently.
+func (s *SessionHeaderAffinity) EvictState(n int) int {
+	return budget.EvictOldest(s.sessions, n)
+}
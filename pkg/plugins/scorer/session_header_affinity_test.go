@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
+)
+
+func TestSessionHeaderAffinity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newPod := func(name string) types.Pod {
+		return &types.PodMetrics{
+			Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: name}},
+			MetricsState: &backendmetrics.MetricsState{},
+		}
+	}
+	podA, podB := newPod("pod-a"), newPod("pod-b")
+	pods := []types.Pod{podA, podB}
+	newRequest := func(sessionID string) *types.LLMRequest {
+		return &types.LLMRequest{RequestId: "test", Headers: map[string]string{"x-chat-id": sessionID}}
+	}
+	schedule := func(pod types.Pod) *types.SchedulingResult {
+		return &types.SchedulingResult{
+			PrimaryProfileName: "default",
+			ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{pod}}},
+		}
+	}
+
+	sessionAffinity, err := scorer.NewSessionHeaderAffinity(ctx, &scorer.SessionHeaderAffinityParameters{
+		SessionHeader: "X-Chat-Id",
+		MaxSessions:   2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0, podB: 0}, sessionAffinity.Score(ctx, nil, newRequest("chat-1"), pods)); diff != "" {
+		t.Errorf("Unexpected scores of an unknown session (-want +got): %v", diff)
+	}
+
+	sessionAffinity.PreRequest(ctx, newRequest("chat-1"), schedule(podB))
+	sessionAffinity.PreRequest(ctx, newRequest("chat-2"), schedule(podA))
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0, podB: 1}, sessionAffinity.Score(ctx, nil, newRequest("chat-1"), pods)); diff != "" {
+		t.Errorf("Unexpected scores of a known session (-want +got): %v", diff)
+	}
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0, podB: 0}, sessionAffinity.Score(ctx, nil, &types.LLMRequest{}, pods)); diff != "" {
+		t.Errorf("Unexpected scores of a request without session (-want +got): %v", diff)
+	}
+
+	// the session moves with its last request
+	sessionAffinity.PreRequest(ctx, newRequest("chat-1"), schedule(podA))
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 0}, sessionAffinity.Score(ctx, nil, newRequest("chat-1"), pods)); diff != "" {
+		t.Errorf("Unexpected scores of a moved session (-want +got): %v", diff)
+	}
+
+	// the least recently used session is forgotten beyond the maximum number of sessions
+	sessionAffinity.PreRequest(ctx, newRequest("chat-3"), schedule(podB))
+	if entries, _ := sessionAffinity.StateUsage(); entries != 2 {
+		t.Errorf("StateUsage() entries = %d, want 2", entries)
+	}
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 0, podB: 0}, sessionAffinity.Score(ctx, nil, newRequest("chat-2"), pods)); diff != "" {
+		t.Errorf("Unexpected scores of a forgotten session (-want +got): %v", diff)
+	}
+	if diff := cmp.Diff(map[types.Pod]float64{podA: 1, podB: 0}, sessionAffinity.Score(ctx, nil, newRequest("chat-1"), pods)); diff != "" {
+		t.Errorf("Unexpected scores of a kept session (-want +got): %v", diff)
+	}
+}
+
+func TestSessionHeaderAffinityExpiration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pod := &types.PodMetrics{
+		Pod:          &backend.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-a"}},
+		MetricsState: &backendmetrics.MetricsState{},
+	}
+	request := &types.LLMRequest{RequestId: "test", Headers: map[string]string{"x-session-id": "session"}}
+	sessionAffinity, err := scorer.NewSessionHeaderAffinity(ctx, &scorer.SessionHeaderAffinityParameters{SessionTTL: "50ms"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sessionAffinity.PreRequest(ctx, request, &types.SchedulingResult{
+		PrimaryProfileName: "default",
+		ProfileResults:     map[string]*types.ProfileRunResult{"default": {TargetPods: []types.Pod{pod}}},
+	})
+	if got := sessionAffinity.Score(ctx, nil, request, []types.Pod{pod})[pod]; got != 1 {
+		t.Errorf("score of the session pod = %v, want 1", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if got := sessionAffinity.Score(ctx, nil, request, []types.Pod{pod})[pod]; got != 0 {
+		t.Errorf("score after the session expired = %v, want 0", got)
+	}
+}
+
+func TestSessionHeaderAffinityFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handle := plugins.NewEppHandle(ctx, nil)
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{}`},
+		{name: "custom values", parameters: `{"sessionHeader": "x-conversation-id", "sessionTTL": "1h", "maxSessions": 100}`},
+		{name: "invalid session TTL", parameters: `{"sessionTTL": "0s"}`, wantErr: true},
+		{name: "negative max sessions", parameters: `{"maxSessions": -1}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scorer.SessionHeaderAffinityFactory("session-header-affinity", json.RawMessage(test.parameters), handle)
+			if (err != nil) != test.wantErr {
+				t.Errorf("SessionHeaderAffinityFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite"
+)
+
+const (
+	// WeightedCompositeType is the type of the WeightedComposite scorer.
+	WeightedCompositeType = "weighted-composite-scorer"
+
+	defaultSchedulingHintHeader = "x-scheduling-hint"
+)
+
+// CompositeScorerParameters defines a scorer combined by the WeightedComposite scorer.
+type CompositeScorerParameters struct {
+	// PluginRef is the name of the scorer plugin. It must be defined before the WeightedComposite scorer.
+	PluginRef string `json:"pluginRef"`
+
+	// Weight is the default weight of the scorer
+	Weight float64 `json:"weight"`
+}
+
+// WeightedCompositeParameters defines the parameters of the WeightedComposite scorer.
+type WeightedCompositeParameters struct {
+	// Scorers are the combined scorers and their default weights
+	Scorers []CompositeScorerParameters `json:"scorers"`
+
+	// HintHeader is the request header selecting the weights of the request. Defaults to "x-scheduling-hint".
+	HintHeader string `json:"hintHeader"`
+
+	// Hints are the weights overriding the default ones by hint, e.g. "latency", "throughput" or
+	// "cache", each mapping scorer names to their weight. The scorers not listed keep their default
+	// weight.
+	Hints map[string]map[string]float64 `json:"hints"`
+}
+
+// compile-time type assertions
+var _ framework.Scorer = &WeightedComposite{}
+
+// WeightedCompositeFactory defines the factory function for the WeightedComposite scorer.
+func WeightedCompositeFactory(name string, rawParameters json.RawMessage, handle plugins.Handle) (plugins.Plugin, error) {
+	parameters := WeightedCompositeParameters{}
+	if rawParameters != nil {
+		if err := json.Unmarshal(rawParameters, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse the parameters of the '%s' scorer - %w", WeightedCompositeType, err)
+		}
+	}
+
+	pluginRefs := make([]string, len(parameters.Scorers))
+	for i, scorerParameters := range parameters.Scorers {
+		pluginRefs[i] = scorerParameters.PluginRef
+	}
+	scorers, err := composite.ResolveScorers(handle, pluginRefs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scorers of the '%s' scorer - %w", WeightedCompositeType, err)
+	}
+
+	weightedComposite, err := NewWeightedComposite(&parameters, scorers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the '%s' scorer - %w", WeightedCompositeType, err)
+	}
+	return weightedComposite.WithName(name), nil
+}
+
+// NewWeightedComposite creates a new WeightedComposite scorer combining the given scorers, in the
+// order of params.Scorers.
+func NewWeightedComposite(params *WeightedCompositeParameters, scorers []framework.Scorer) (*WeightedComposite, error) {
+	if len(params.Scorers) == 0 {
+		return nil, errors.New("at least one scorer is required")
+	}
+	if len(params.Scorers) != len(scorers) {
+		return nil, fmt.Errorf("expected %d scorers, got %d", len(params.Scorers), len(scorers))
+	}
+	hintHeader := params.HintHeader
+	if hintHeader == "" {
+		hintHeader = defaultSchedulingHintHeader
+	}
+
+	indexes := make(map[string]int, len(params.Scorers))
+	defaultWeights := make([]float64, len(params.Scorers))
+	for i, scorerParameters := range params.Scorers {
+		if scorerParameters.Weight < 0 {
+			return nil, fmt.Errorf("invalid weight for '%s': must be >= 0, got %v", scorerParameters.PluginRef, scorerParameters.Weight)
+		}
+		indexes[scorerParameters.PluginRef] = i
+		defaultWeights[i] = scorerParameters.Weight
+	}
+
+	hintWeights := make(map[string][]float64, len(params.Hints))
+	for hint, weights := range params.Hints {
+		if hint == "" {
+			return nil, errors.New("invalid hints: the hint name is required")
+		}
+		overriddenWeights := append([]float64(nil), defaultWeights...)
+		for pluginRef, weight := range weights {
+			i, found := indexes[pluginRef]
+			if !found {
+				return nil, fmt.Errorf("invalid hint '%s': '%s' is not a combined scorer", hint, pluginRef)
+			}
+			if weight < 0 {
+				return nil, fmt.Errorf("invalid weight for '%s' in hint '%s': must be >= 0, got %v", pluginRef, hint, weight)
+			}
+			overriddenWeights[i] = weight
+		}
+		// the hints are matched case insensitively
+		hintWeights[strings.ToLower(hint)] = overriddenWeights
+	}
+
+	return &WeightedComposite{
+		typedName:      plugins.TypedName{Type: WeightedCompositeType},
+		scorers:        scorers,
+		hintHeader:     strings.ToLower(hintHeader),
+		defaultWeights: defaultWeights,
+		hintWeights:    hintWeights,
+	}, nil
+}
+
+// WeightedComposite scorer combines other scorers with weights selected by a request header, so
+// that the interactive and the batch traffic served by the same EPP get different blends, e.g.
+// favoring the load balancing for the latency sensitive requests and the cache affinity for the
+// throughput oriented ones. The requests without hint, or with an unknown hint, use the default
+// weights.
+type WeightedComposite struct {
+	typedName      plugins.TypedName
+	scorers        []framework.Scorer
+	hintHeader     string
+	defaultWeights []float64
+	hintWeights    map[string][]float64 // the weights of the scorers by hint
+}
+
+// TypedName returns the typed name of the plugin.
+func (s *WeightedComposite) TypedName() plugins.TypedName {
+	return s.typedName
+}
+
+// WithName sets the name of the plugin.
+func (s *WeightedComposite) WithName(name string) *WeightedComposite {
+	s.typedName.Name = name
+	return s
+}
+
+// Score returns the weighted average of the combined scorers, in range of 0-1, with the weights of
+// the hint of the request.
+func (s *WeightedComposite) Score(ctx context.Context, cycleState *types.CycleState, request *types.LLMRequest, pods []types.Pod) map[types.Pod]float64 {
+	hint := strings.ToLower(strings.TrimSpace(request.Headers[s.hintHeader]))
+	weights, found := s.hintWeights[hint]
+	if !found {
+		weights = s.defaultWeights
+	}
+
+	scoredPods := composite.WeightedScore(ctx, cycleState, request, pods, s.scorers, weights)
+
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Scored pods", "hint", hint, "weights", weights, "scores", scoredPods)
+	return scoredPods
+}
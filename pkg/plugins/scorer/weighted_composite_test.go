@@ -0,0 +1,110 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/composite/compositetest"
+)
+
+func TestWeightedCompositeScore(t *testing.T) {
+	ctx := context.Background()
+
+	podA, podB := compositetest.NewPod("pod-a"), compositetest.NewPod("pod-b")
+	pods := []types.Pod{podA, podB}
+
+	cacheScorer := compositetest.NewFixedScorer("cache", map[string]float64{"pod-a": 1, "pod-b": 0})
+	loadScorer := compositetest.NewFixedScorer("load", map[string]float64{"pod-a": 0, "pod-b": 2})
+	weightedComposite, err := NewWeightedComposite(&WeightedCompositeParameters{
+		Scorers: []CompositeScorerParameters{
+			{PluginRef: "cache", Weight: 1},
+			{PluginRef: "load", Weight: 1},
+		},
+		HintHeader: "X-Traffic-Class",
+		Hints: map[string]map[string]float64{
+			"Latency":    {"cache": 1, "load": 3},
+			"throughput": {"load": 0},
+			"off":        {"cache": 0, "load": 0},
+		},
+	}, []framework.Scorer{cacheScorer, loadScorer})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    map[types.Pod]float64
+	}{
+		{name: "no hint", want: map[types.Pod]float64{podA: 0.5, podB: 0.5}},
+		{name: "latency hint", headers: map[string]string{"x-traffic-class": "latency"}, want: map[types.Pod]float64{podA: 0.25, podB: 0.75}},
+		{name: "hint case and spaces", headers: map[string]string{"x-traffic-class": " LATENCY "}, want: map[types.Pod]float64{podA: 0.25, podB: 0.75}},
+		{name: "hint keeping default weights", headers: map[string]string{"x-traffic-class": "throughput"}, want: map[types.Pod]float64{podA: 1, podB: 0}},
+		{name: "unknown hint", headers: map[string]string{"x-traffic-class": "batch"}, want: map[types.Pod]float64{podA: 0.5, podB: 0.5}},
+		{name: "zero weights", headers: map[string]string{"x-traffic-class": "off"}, want: map[types.Pod]float64{podA: 0, podB: 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := weightedComposite.Score(ctx, nil, &types.LLMRequest{Headers: test.headers}, pods)
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Unexpected scores (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestWeightedCompositeFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handle := plugins.NewEppHandle(ctx, nil)
+	handle.AddPlugin("cache", compositetest.NewFixedScorer("cache", nil))
+	handle.AddPlugin("load", compositetest.NewFixedScorer("load", nil))
+
+	tests := []struct {
+		name       string
+		parameters string
+		wantErr    bool
+	}{
+		{name: "defaults", parameters: `{"scorers": [{"pluginRef": "cache", "weight": 1}]}`},
+		{name: "custom values", parameters: `{"scorers": [{"pluginRef": "cache", "weight": 2}, {"pluginRef": "load", "weight": 1}],
+			"hintHeader": "x-traffic-class", "hints": {"latency": {"load": 3}, "cache": {"cache": 4, "load": 0}}}`},
+		{name: "no scorers", parameters: `{}`, wantErr: true},
+		{name: "unknown scorer", parameters: `{"scorers": [{"pluginRef": "queue", "weight": 1}]}`, wantErr: true},
+		{name: "negative weight", parameters: `{"scorers": [{"pluginRef": "cache", "weight": -1}]}`, wantErr: true},
+		{name: "hint of an uncombined scorer", parameters: `{"scorers": [{"pluginRef": "cache", "weight": 1}], "hints": {"latency": {"load": 1}}}`, wantErr: true},
+		{name: "negative hint weight", parameters: `{"scorers": [{"pluginRef": "cache", "weight": 1}], "hints": {"latency": {"cache": -1}}}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := WeightedCompositeFactory("weighted-composite", json.RawMessage(test.parameters), handle)
+			if (err != nil) != test.wantErr {
+				t.Errorf("WeightedCompositeFactory() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/framework/plugins/picker"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/filter"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/profile"
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/plugins/scorer"
@@ -247,7 +248,7 @@ func TestPDSchedule(t *testing.T) {
 			err = decodeSchedulerProfile.AddPlugins(framework.NewWeightedScorer(prefixScorer, 0))
 			assert.NoError(t, err, "SchedulerProfile AddPlugins returned unexpected error")
 
-			profileHandle := profile.NewPdProfileHandler(prefill, decode, prefixScorer.TypedName().Name, 10, 5, 0)
+			profileHandle := profile.NewPdProfileHandler(prefill, decode, prefixScorer.TypedName().Name, 10, 0, 5, 0)
 
 			schedulerConfig := scheduling.NewSchedulerConfig(profileHandle, map[string]*framework.SchedulerProfile{
 				prefill: prefillSchedulerProfile,
@@ -281,3 +282,176 @@ func TestPDSchedule(t *testing.T) {
 		})
 	}
 }
+
+// Tests the decision to run the prefill profile based on the number of non-cached prompt blocks.
+func TestPDScheduleBlockThreshold(t *testing.T) {
+	pod1 := &types.PodMetrics{
+		Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Name: "pod1"},
+			Address:        "1.2.3.4",
+			Labels:         map[string]string{filter.RoleLabel: filter.RolePrefill},
+		},
+		MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: 0},
+	}
+	pod2 := &types.PodMetrics{
+		Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Name: "pod2"},
+			Address:        "5.6.7.8",
+			Labels:         map[string]string{filter.RoleLabel: filter.RoleDecode},
+		},
+		MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: 0},
+	}
+
+	tests := []struct {
+		name             string
+		prompt           string
+		wantPrefill      bool
+		wantPrefillAfter bool // in a subsequent call, once the prompt blocks are cached on the decode pod
+	}{
+		{
+			name:             "prompt with enough uncached blocks",
+			prompt:           "123456789012345",
+			wantPrefill:      true,
+			wantPrefillAfter: false,
+		},
+		{
+			name:             "prompt with a single block",
+			prompt:           "12345",
+			wantPrefill:      false,
+			wantPrefillAfter: false,
+		},
+	}
+
+	ctx := context.Background()
+	logger := testr.New(t)
+	ctx = log.IntoContext(ctx, logger)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prefixScorer := prefix.New(ctx, prefix.Config{DefaultBlockSize: 5, MaxPrefixBlocksToMatch: 256, LRUCapacityPerServer: 31250})
+
+			prefillSchedulerProfile := framework.NewSchedulerProfile().
+				WithFilters(filter.NewPrefillRole()).
+				WithPicker(picker.NewMaxScorePicker(picker.DefaultMaxNumOfEndpoints))
+			err := prefillSchedulerProfile.AddPlugins(framework.NewWeightedScorer(prefixScorer, 50))
+			assert.NoError(t, err, "SchedulerProfile AddPlugins returned unexpected error")
+
+			decodeSchedulerProfile := framework.NewSchedulerProfile().
+				WithFilters(filter.NewDecodeRole()).
+				WithPicker(picker.NewMaxScorePicker(picker.DefaultMaxNumOfEndpoints))
+			err = decodeSchedulerProfile.AddPlugins(framework.NewWeightedScorer(prefixScorer, 0))
+			assert.NoError(t, err, "SchedulerProfile AddPlugins returned unexpected error")
+
+			profileHandle := profile.NewPdProfileHandler(prefill, decode, prefixScorer.TypedName().Name, 0, 2, 5, 0)
+
+			schedulerConfig := scheduling.NewSchedulerConfig(profileHandle, map[string]*framework.SchedulerProfile{
+				prefill: prefillSchedulerProfile,
+				decode:  decodeSchedulerProfile,
+			})
+			scheduler := scheduling.NewSchedulerWithConfig(schedulerConfig)
+
+			req := &types.LLMRequest{
+				RequestId:   uuid.NewString(),
+				TargetModel: "critical",
+				Body:        &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: test.prompt}},
+			}
+
+			got, err := scheduler.Schedule(ctx, req, []types.Pod{pod1, pod2})
+			assert.NoError(t, err)
+			_, gotPrefill := got.ProfileResults[prefill]
+			assert.Equal(t, test.wantPrefill, gotPrefill, "unexpected prefill decision")
+
+			// make sure prefix plugin stores the prefix hit in cache, so we can test it in the following schedule call
+			prefixScorer.PreRequest(ctx, req, got)
+			time.Sleep(time.Second)
+
+			got, err = scheduler.Schedule(ctx, req, []types.Pod{pod1, pod2})
+			assert.NoError(t, err)
+			_, gotPrefill = got.ProfileResults[prefill]
+			assert.Equal(t, test.wantPrefillAfter, gotPrefill, "unexpected prefill decision in subsequent schedule call")
+		})
+	}
+}
+
+func TestPDScheduleNeverDisaggregatePaths(t *testing.T) {
+	pod1 := &types.PodMetrics{
+		Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Name: "pod1"},
+			Address:        "1.2.3.4",
+			Labels:         map[string]string{filter.RoleLabel: filter.RolePrefill},
+		},
+		MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: 0},
+	}
+	pod2 := &types.PodMetrics{
+		Pod: &backend.Pod{
+			NamespacedName: k8stypes.NamespacedName{Name: "pod2"},
+			Address:        "5.6.7.8",
+			Labels:         map[string]string{filter.RoleLabel: filter.RoleDecode},
+		},
+		MetricsState: &backendmetrics.MetricsState{WaitingQueueSize: 0},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantPrefill bool
+	}{
+		{
+			name:        "completions request is disaggregated",
+			path:        "/v1/completions",
+			wantPrefill: true,
+		},
+		{
+			name:        "score request is never disaggregated",
+			path:        "/score",
+			wantPrefill: false,
+		},
+		{
+			name:        "rerank request with query is never disaggregated",
+			path:        "/v1/rerank?top_n=3",
+			wantPrefill: false,
+		},
+	}
+
+	ctx := context.Background()
+	logger := testr.New(t)
+	ctx = log.IntoContext(ctx, logger)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prefixScorer := prefix.New(ctx, prefix.Config{DefaultBlockSize: 5, MaxPrefixBlocksToMatch: 256, LRUCapacityPerServer: 31250})
+
+			prefillSchedulerProfile := framework.NewSchedulerProfile().
+				WithFilters(filter.NewPrefillRole()).
+				WithPicker(picker.NewMaxScorePicker(picker.DefaultMaxNumOfEndpoints))
+			err := prefillSchedulerProfile.AddPlugins(framework.NewWeightedScorer(prefixScorer, 50))
+			assert.NoError(t, err, "SchedulerProfile AddPlugins returned unexpected error")
+
+			decodeSchedulerProfile := framework.NewSchedulerProfile().
+				WithFilters(filter.NewDecodeRole()).
+				WithPicker(picker.NewMaxScorePicker(picker.DefaultMaxNumOfEndpoints))
+			err = decodeSchedulerProfile.AddPlugins(framework.NewWeightedScorer(prefixScorer, 0))
+			assert.NoError(t, err, "SchedulerProfile AddPlugins returned unexpected error")
+
+			profileHandle := profile.NewPdProfileHandler(prefill, decode, prefixScorer.TypedName().Name, 10, 0, 5, 0)
+
+			schedulerConfig := scheduling.NewSchedulerConfig(profileHandle, map[string]*framework.SchedulerProfile{
+				prefill: prefillSchedulerProfile,
+				decode:  decodeSchedulerProfile,
+			})
+			scheduler := scheduling.NewSchedulerWithConfig(schedulerConfig)
+
+			req := &types.LLMRequest{
+				RequestId:   uuid.NewString(),
+				TargetModel: "critical",
+				Headers:     map[string]string{common.RequestPathHeader: test.path},
+				Body:        &types.LLMRequestBody{Completions: &types.CompletionsRequest{Prompt: "12345678901234567890"}},
+			}
+
+			got, err := scheduler.Schedule(ctx, req, []types.Pod{pod1, pod2})
+			assert.NoError(t, err)
+			_, gotPrefill := got.ProfileResults[prefill]
+			assert.Equal(t, test.wantPrefill, gotPrefill, "unexpected prefill decision")
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config loads the options of the P/D sidecar from a configuration file,
+// e.g. mounted from a ConfigMap.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"sigs.k8s.io/yaml"
+)
+
+// FlagName is the name of the flag giving the path of the configuration file
+const FlagName = "config"
+
+// Load sets the flags of the flag set from the YAML or JSON configuration file at the given path.
+// The file maps the flag names to their values, e.g.
+//
+//	connector: nixlv2
+//	data-parallel-size: 2
+//	prefill-timeout: 5s
+//
+// The flags set on the command line override the values of the file. All the invalid options
+// of the file are reported, by name.
+func Load(fs *flag.FlagSet, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the configuration file: %w", err)
+	}
+
+	options := map[string]any{}
+	if err := yaml.Unmarshal(content, &options); err != nil {
+		return fmt.Errorf("failed to parse the configuration file %s: %w", path, err)
+	}
+
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if name == FlagName {
+			errs = append(errs, fmt.Errorf("option '%s' is not allowed in the configuration file", name))
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			errs = append(errs, fmt.Errorf("unknown option '%s'", name))
+			continue
+		}
+		value, err := formatValue(options[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for option '%s': %w", name, err))
+			continue
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value '%s' for option '%s': %w", value, name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration file %s: %w", path, errors.Join(errs...))
+	}
+	return nil
+}
+
+// formatValue formats a value of the configuration file as a flag value
+func formatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", errors.New("the value is empty")
+	default:
+		return "", errors.New("expected a string, a number or a boolean")
+	}
+}
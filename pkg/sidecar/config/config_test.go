@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFlags struct {
+	fs             *flag.FlagSet
+	port           *string
+	connector      *string
+	dataParallel   *int
+	secureProxy    *bool
+	prefillTimeout *time.Duration
+	errorRate      *float64
+}
+
+func newTestFlags() *testFlags {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String(FlagName, "", "")
+	return &testFlags{
+		fs:             fs,
+		port:           fs.String("port", "8000", ""),
+		connector:      fs.String("connector", "nixlv2", ""),
+		dataParallel:   fs.Int("data-parallel-size", 1, ""),
+		secureProxy:    fs.Bool("secure-proxy", true, ""),
+		prefillTimeout: fs.Duration("prefill-timeout", 0, ""),
+		errorRate:      fs.Float64("circuit-breaker-error-rate", 0, ""),
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		args     []string
+		validate func(t *testing.T, flags *testFlags)
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+port: 9000
+connector: lmcache
+data-parallel-size: 4
+secure-proxy: false
+prefill-timeout: 5s
+circuit-breaker-error-rate: 0.5
+`,
+			validate: func(t *testing.T, flags *testFlags) {
+				assert.Equal(t, "9000", *flags.port)
+				assert.Equal(t, "lmcache", *flags.connector)
+				assert.Equal(t, 4, *flags.dataParallel)
+				assert.False(t, *flags.secureProxy)
+				assert.Equal(t, 5*time.Second, *flags.prefillTimeout)
+				assert.Equal(t, 0.5, *flags.errorRate)
+			},
+		},
+		{
+			name:    "json",
+			file:    "config.json",
+			content: `{"port": "9000", "data-parallel-size": 2}`,
+			validate: func(t *testing.T, flags *testFlags) {
+				assert.Equal(t, "9000", *flags.port)
+				assert.Equal(t, 2, *flags.dataParallel)
+				assert.Equal(t, "nixlv2", *flags.connector)
+			},
+		},
+		{
+			name:    "command line overrides",
+			file:    "config.yaml",
+			content: "port: 9000\nconnector: lmcache\n",
+			args:    []string{"--port", "7000"},
+			validate: func(t *testing.T, flags *testFlags) {
+				assert.Equal(t, "7000", *flags.port)
+				assert.Equal(t, "lmcache", *flags.connector)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := newTestFlags()
+			require.NoError(t, flags.fs.Parse(test.args))
+			require.NoError(t, Load(flags.fs, writeFile(t, test.file, test.content)))
+			test.validate(t, flags)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errors  []string
+	}{
+		{
+			name:    "unknown and invalid options",
+			content: "prot: 9000\ndata-parallel-size: two\nprefill-timeout: 5\nconnector: [nixlv2]\n",
+			errors: []string{
+				"unknown option 'prot'",
+				"invalid value 'two' for option 'data-parallel-size'",
+				"invalid value '5' for option 'prefill-timeout'",
+				"invalid value for option 'connector': expected a string, a number or a boolean",
+			},
+		},
+		{
+			name:    "nested configuration file",
+			content: "config: other.yaml\n",
+			errors:  []string{"option 'config' is not allowed in the configuration file"},
+		},
+		{
+			name:    "not a mapping",
+			content: "- port\n",
+			errors:  []string{"failed to parse the configuration file"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := newTestFlags()
+			err := Load(flags.fs, writeFile(t, "config.yaml", test.content))
+			require.Error(t, err)
+			for _, message := range test.errors {
+				assert.Contains(t, err.Error(), message)
+			}
+		})
+	}
+
+	err := Load(newTestFlags().fs, filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read the configuration file")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// accessLogEntry is the access log line of a request. The handlers annotate it with the targets
+// of the request through the request context.
+type accessLogEntry struct {
+	Time          string  `json:"time"`
+	Method        string  `json:"method"`
+	Path          string  `json:"path"`
+	RequestID     string  `json:"request_id,omitempty"`
+	PrefillTarget string  `json:"prefill_target,omitempty"`
+	DecodeTarget  string  `json:"decode_target,omitempty"`
+	Status        int     `json:"status"`
+	DurationMs    float64 `json:"duration_ms"`
+	Bytes         int64   `json:"bytes"`
+}
+
+type accessLogKey struct{}
+
+// accessLogFromContext returns the access log entry of a request, nil when the access log is disabled
+func accessLogFromContext(ctx context.Context) *accessLogEntry {
+	entry, _ := ctx.Value(accessLogKey{}).(*accessLogEntry)
+	return entry
+}
+
+// accessLogger writes the access log lines, shared by the servers of all the data parallel ranks
+type accessLogger struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+func newAccessLogger(out io.Writer) *accessLogger {
+	if out == nil {
+		return nil
+	}
+	return &accessLogger{out: out}
+}
+
+// handler writes an access log line for each request served by the given handler
+func (l *accessLogger) handler(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &accessLogEntry{
+			Time:      start.UTC().Format(time.RFC3339Nano),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			RequestID: r.Header.Get(requestHeaderRequestID),
+		}
+		rw := &byteCounter{statusRecorder: statusRecorder{ResponseWriter: w}}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+
+		entry.Status = rw.status()
+		entry.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		entry.Bytes = rw.bytes
+		l.write(entry)
+	})
+}
+
+func (l *accessLogger) write(entry *accessLogEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.out.Write(line) //nolint:all
+}
+
+// byteCounter records the status code and the number of bytes written to a response
+type byteCounter struct {
+	statusRecorder
+	bytes int64
+}
+
+func (w *byteCounter) Write(b []byte) (int, error) {
+	n, err := w.statusRecorder.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Access log", func() {
+	var (
+		accessLog       *lockedBuffer
+		decodeURL       *url.URL
+		prefillHostPort string
+		server          *httptest.Server
+	)
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill})
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		accessLog = &lockedBuffer{}
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, AccessLog: accessLog})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	send := func(prefillTarget string) (int, int) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		if prefillTarget != "" {
+			req.Header.Set(common.PrefillPodHeader, prefillTarget)
+		}
+		req.Header.Set(requestHeaderRequestID, "client-request")
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.StatusCode, len(body)
+	}
+
+	// the lines are written once the responses are complete, possibly after the client read them
+	entries := func() []map[string]any {
+		Eventually(accessLog.String).ShouldNot(BeEmpty())
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(accessLog.String()), "\n") {
+			var entry map[string]any
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	It("should log the prefill and decode targets of the disaggregated requests", func() {
+		status, size := send(prefillHostPort)
+		Expect(status).To(Equal(http.StatusOK))
+
+		Expect(entries()).To(HaveExactElements(SatisfyAll(
+			HaveKeyWithValue("method", http.MethodPost),
+			HaveKeyWithValue("path", CompletionsPath),
+			HaveKeyWithValue("request_id", "client-request"),
+			HaveKeyWithValue("prefill_target", prefillHostPort),
+			HaveKeyWithValue("decode_target", decodeURL.Host),
+			HaveKeyWithValue("status", BeEquivalentTo(http.StatusOK)),
+			HaveKeyWithValue("bytes", BeEquivalentTo(size)),
+			HaveKey("duration_ms"),
+			HaveKey("time"),
+		)))
+	})
+
+	It("should log the requests without disaggregated prefill", func() {
+		status, _ := send("")
+		Expect(status).To(Equal(http.StatusOK))
+
+		Expect(entries()).To(HaveExactElements(SatisfyAll(
+			HaveKeyWithValue("request_id", "client-request"),
+			Not(HaveKey("prefill_target")),
+			HaveKeyWithValue("decode_target", decodeURL.Host),
+		)))
+	})
+
+	It("should log the failed requests", func() {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`not json`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+		Expect(entries()).To(HaveExactElements(SatisfyAll(
+			HaveKeyWithValue("prefill_target", prefillHostPort),
+			Not(HaveKey("decode_target")),
+			HaveKeyWithValue("status", BeEquivalentTo(http.StatusBadRequest)),
+		)))
+	})
+})
+
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
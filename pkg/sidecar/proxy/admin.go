@@ -0,0 +1,125 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// AdminStatePath is the path of the admin endpoint reporting the runtime state of the sidecar. A
+// prefill target given by the target query parameter is checked against the allowlist.
+const AdminStatePath = "/debug/state"
+
+// adminState is the runtime state of the sidecar reported by the admin API
+type adminState struct {
+	Connector          string            `json:"connector"`
+	DecoderURL         string            `json:"decoder_url"`
+	Config             map[string]any    `json:"config"`
+	PrefillerProxies   []string          `json:"prefiller_proxies"`
+	Allowlist          allowlistState    `json:"allowlist"`
+	DataParallelRoutes map[string]string `json:"data_parallel_routes"`
+	TargetCheck        *targetCheck      `json:"target_check,omitempty"`
+}
+
+// targetCheck explains whether a prefill target is allowed by the SSRF protection
+type targetCheck struct {
+	Target  string `json:"target"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+// startAdmin starts the admin listener, only bound to the loopback interface since the admin API
+// exposes the internals of the sidecar
+func (s *Server) startAdmin(ctx context.Context) error {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", s.config.AdminPort))
+	if err != nil {
+		s.logger.Error(err, "Failed to start the admin listener")
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+AdminStatePath, s.adminStateHandler)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 30 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		server.Close() //nolint:all
+	}()
+
+	s.logger.Info("starting the admin listener", "addr", ln.Addr().String())
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		s.logger.Error(err, "failed to start the admin listener")
+		return err
+	}
+	return nil
+}
+
+// adminStateHandler reports the runtime state of the sidecar
+func (s *Server) adminStateHandler(w http.ResponseWriter, r *http.Request) {
+	state := adminState{
+		Connector:          s.connector,
+		DecoderURL:         s.decoderURL.String(),
+		Config:             configState(s.config),
+		PrefillerProxies:   s.prefillerProxies.Keys(),
+		Allowlist:          s.allowlistValidator.state(),
+		DataParallelRoutes: map[string]string{},
+	}
+	slices.Sort(state.PrefillerProxies)
+	s.dataParallelMu.RLock()
+	for hostPort, rankURL := range s.dataParallelURLs {
+		state.DataParallelRoutes[hostPort] = rankURL.String()
+	}
+	s.dataParallelMu.RUnlock()
+	if target := r.URL.Query().Get("target"); target != "" {
+		allowed, reason := s.allowlistValidator.explain(target)
+		state.TargetCheck = &targetCheck{Target: target, Allowed: allowed, Reason: reason}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state) //nolint:all
+}
+
+// configState returns the configuration as JSON values: the durations are formatted, and the
+// certificate pools and the access log writer are reported as whether they are set
+func configState(config Config) map[string]any {
+	return structState(reflect.ValueOf(config))
+}
+
+// structState returns the fields of a configuration struct as JSON values
+func structState(value reflect.Value) map[string]any {
+	state := map[string]any{}
+	for i := range value.NumField() {
+		field, name := value.Field(i), value.Type().Field(i).Name
+		switch {
+		case field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface:
+			state[name] = !field.IsNil()
+		case field.Type() == reflect.TypeOf(time.Duration(0)):
+			state[name] = time.Duration(field.Int()).String()
+		case field.Kind() == reflect.Struct:
+			state[name] = structState(field)
+		default:
+			state[name] = field.Interface()
+		}
+	}
+	return state
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"time"
+
+	"k8s.io/utils/set"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Admin API", func() {
+	var proxy *Server
+
+	BeforeEach(func() {
+		decodeURL, err := url.Parse("http://localhost:8200")
+		Expect(err).ToNot(HaveOccurred())
+		proxy = NewProxy("8000", decodeURL, Config{Connector: ConnectorNIXLV2, PrefillTimeout: 5 * time.Second})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: true, namespace: "default", poolName: "pool",
+			allowedTargets: set.New("10.0.0.2", "10.0.0.1")}
+		proxy.allowlistValidator.ready.Store(true)
+		proxy.dataParallelURLs["10.0.0.9:8001"] = &url.URL{Scheme: "http", Host: "localhost:8201"}
+	})
+
+	getState := func(query string) adminState {
+		recorder := httptest.NewRecorder()
+		proxy.adminStateHandler(recorder, httptest.NewRequest(http.MethodGet, AdminStatePath+query, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		var state adminState
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &state)).To(Succeed())
+		return state
+	}
+
+	It("should report the runtime state of the sidecar", func() {
+		_, err := proxy.prefillerProxyHandler("10.0.0.2:8000")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = proxy.prefillerProxyHandler("10.0.0.1:8000")
+		Expect(err).ToNot(HaveOccurred())
+
+		state := getState("")
+
+		Expect(state.Connector).To(Equal(ConnectorNIXLV2))
+		Expect(state.DecoderURL).To(Equal("http://localhost:8200"))
+		Expect(state.Config).To(HaveKeyWithValue("PrefillTimeout", "5s"))
+		Expect(state.PrefillerProxies).To(Equal([]string{"10.0.0.1:8000", "10.0.0.2:8000"}))
+		Expect(state.Allowlist).To(Equal(allowlistState{Enabled: true, Synced: true, Namespace: "default", PoolName: "pool",
+			Targets: []string{"10.0.0.1", "10.0.0.2"}}))
+		Expect(state.DataParallelRoutes).To(Equal(map[string]string{"10.0.0.9:8001": "http://localhost:8201"}))
+		Expect(state.TargetCheck).To(BeNil())
+	})
+
+	It("should explain whether a prefill target is allowed", func() {
+		Expect(getState("?target=10.0.0.1:8000").TargetCheck).To(Equal(&targetCheck{
+			Target: "10.0.0.1:8000", Allowed: true, Reason: "the host 10.0.0.1 is in the allowlist"}))
+		Expect(getState("?target=10.0.0.3:8000").TargetCheck).To(Equal(&targetCheck{
+			Target: "10.0.0.3:8000", Allowed: false, Reason: "the host 10.0.0.3 is not in the allowlist"}))
+
+		proxy.allowlistValidator.ready.Store(false)
+		Expect(getState("?target=10.0.0.1:8000").TargetCheck.Reason).To(Equal("the allowlist is not synced yet"))
+	})
+
+	It("should only report whether the certificate pools and the access log are set", func() {
+		state := configState(Config{ClientCAs: x509.NewCertPool(), AccessLog: os.Stdout, PrefillRetries: 2})
+
+		Expect(state).To(HaveKeyWithValue("ClientCAs", true))
+		Expect(state).To(HaveKeyWithValue("DecoderRootCAs", false))
+		Expect(state).To(HaveKeyWithValue("AccessLog", true))
+		Expect(state).To(HaveKeyWithValue("PrefillRetries", 2))
+		_, err := json.Marshal(state)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -18,137 +18,586 @@ package proxy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/set"
+	infextv1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
+	infextv1a2 "sigs.k8s.io/gateway-api-inference-extension/apix/v1alpha2"
+	poolclient "sigs.k8s.io/gateway-api-inference-extension/client-go/clientset/versioned"
+	poolinformers "sigs.k8s.io/gateway-api-inference-extension/client-go/informers/externalversions"
 )
 
 const (
-	inferencePoolGroup    = "inference.networking.x-k8s.io"
-	inferencePoolVersion  = "v1alpha2"
+	// InferencePoolGroup is the API group of the v1 InferencePools
+	InferencePoolGroup = "inference.networking.k8s.io"
+
+	// InferencePoolAlphaGroup is the API group of the v1alpha2 InferencePools, watched by default
+	InferencePoolAlphaGroup = "inference.networking.x-k8s.io"
+
+	// InferencePoolAutoGroup detects the API group of the InferencePools served by the API server,
+	// the v1 one when both are served and the watched pools exist in it
+	InferencePoolAutoGroup = "auto"
+
 	inferencePoolResource = "inferencepools"
 	resyncPeriod          = 30 * time.Second
+
+	// AllowlistFailClosed fails the disaggregated requests until the allowlist is synced, the default
+	AllowlistFailClosed = "fail-closed"
+
+	// AllowlistFailOpen allows the prefill targets until the allowlist is synced, and the ones missing from
+	// it during its grace period
+	AllowlistFailOpen = "fail-open"
+
+	// DefaultAllowlistReadyWait is the default time a disaggregated request waits for the allowlist to be synced
+	DefaultAllowlistReadyWait = 5 * time.Second
+
+	allowlistReadyPollInterval = 50 * time.Millisecond
+
+	podLabelIndex = "label"
+
+	// podRunningFieldSelector selects the running pods on the server side
+	podRunningFieldSelector = "status.phase=" + string(corev1.PodRunning)
 )
 
 // AllowlistValidator manages allowed prefill targets based on InferencePool resources
 type AllowlistValidator struct {
-	logger        logr.Logger
-	dynamicClient dynamic.Interface
-	namespace     string
-	poolName      string
-	enabled       bool
+	logger       logr.Logger
+	poolClient   poolclient.Interface // the typed client of the InferencePools
+	client       kubernetes.Interface
+	namespace    string
+	poolName     string // a comma separated list of InferencePools
+	poolGroup    string // the API group of the InferencePool
+	poolSelector string // the label selector of the InferencePools, instead of their names
+	enabled      bool
+
+	// poolNames are the names of the watched InferencePools, all the selected ones when empty
+	poolNames set.Set[string]
 
 	// allowedTargets maps hostport -> bool for allowed prefill targets
 	allowedTargets   set.Set[string]
+	allowedHostPorts set.Set[string] // the host:port of the target ports of the pods, when the ports are enforced
 	allowedTargetsMu sync.RWMutex
+	generation       uint64 // incremented on each rebuild of the allowlist, reported by the SSRF audit log
+
+	// selectors maps the name of the InferencePools to the label selectors of their pods
+	selectors   map[string]labels.Set
+	targetPorts map[string][]int32 // maps the name of the InferencePools to their target ports
+	selectorsMu sync.RWMutex
 
 	// watchers for cleanup
-	poolInformer   cache.SharedInformer
-	podInformers   map[string]cache.SharedInformer
-	podStopChans   map[string]chan struct{} // individual stop channels for pod informers
-	podInformersMu sync.RWMutex
-	stopCh         chan struct{}
+	poolInformer        cache.SharedIndexInformer
+	poolInformerFactory poolinformers.SharedInformerFactory
+	informerFactory     informers.SharedInformerFactory
+	podInformer         cache.SharedIndexInformer // shared by all the pools, indexed by label
+	stopCh              chan struct{}
+
+	// configMapName is the ConfigMap of the endpoint set published by the EPP, the allowlist is then
+	// built from it instead of the InferencePool and its pods
+	configMapName string
+	signingKey    []byte
+	publishedAt   time.Time // the publication time of the endpoint set in the allowlist
+
+	// serviceName is the Service fronting the prefillers, the allowlist is then built from its
+	// EndpointSlices instead of the InferencePool and its pods
+	serviceName   string
+	sliceInformer cache.SharedIndexInformer
+
+	// static are the prefill targets allowed besides the ones watched, e.g. running outside of the cluster
+	static *StaticAllowlist
+
+	// enforcePorts only allows the target ports of the InferencePools, instead of any port of their pods
+	enforcePorts bool
+
+	// failOpen allows the targets missing from the allowlist while it is pending, instead of failing the requests
+	failOpen bool
+
+	// gracePeriod is the time after the start during which the targets missing from the synced allowlist
+	// may still be added to it, e.g. the pods of a rollout, and are handled by the failure policy
+	gracePeriod time.Duration
+	startedAt   time.Time
+
+	// ready is set once the InferencePool and its pods were synced, or the endpoint set was published
+	ready atomic.Bool
+
+	// watchErrors counts the failed lists and watches of the informers, e.g. denied by a broken RBAC
+	watchErrors atomic.Uint64
 }
 
 // NewAllowlistValidator creates a new SSRF protection validator
 func NewAllowlistValidator(enabled bool, namespace string, poolName string) (*AllowlistValidator, error) {
+	return NewAllowlistValidatorWithOptions(enabled, namespace, poolName, KubernetesClientOptions{})
+}
+
+// NewAllowlistValidatorWithOptions creates a new SSRF protection validator, with the given options
+// for its client of the Kubernetes API server
+func NewAllowlistValidatorWithOptions(enabled bool, namespace string, poolName string, options KubernetesClientOptions) (*AllowlistValidator, error) {
 	if !enabled {
 		return &AllowlistValidator{
 			enabled: false,
 		}, nil
 	}
 
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	overrides := &clientcmd.ConfigOverrides{}
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules,
-		overrides,
-	).ClientConfig()
+	config, err := NewKubernetesConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	poolClient, err := poolclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create InferencePool client: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Kubernetes config (ensure running in a pod with proper RBAC): %w", err)
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
+	return NewAllowlistValidatorWithClients(poolClient, client, namespace, poolName), nil
+}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
+// NewPublishedAllowlistValidator creates a new SSRF protection validator building the allowlist from the
+// endpoint set published by the EPP to the given ConfigMap, signed with the given key
+func NewPublishedAllowlistValidator(namespace string, configMapName string, signingKey []byte, options KubernetesClientOptions) (*AllowlistValidator, error) {
+	config, err := NewKubernetesConfig(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes dynamic client: %w", err)
+		return nil, err
 	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return NewPublishedAllowlistValidatorWithClient(client, namespace, configMapName, signingKey), nil
+}
 
+// NewPublishedAllowlistValidatorWithClient creates a new SSRF protection validator watching the ConfigMap of
+// the endpoint set published by the EPP with the given client, e.g. a fake client in tests
+func NewPublishedAllowlistValidatorWithClient(client kubernetes.Interface, namespace string, configMapName string, signingKey []byte) *AllowlistValidator {
 	return &AllowlistValidator{
 		enabled:        true,
-		dynamicClient:  dynamicClient,
+		client:         client,
+		namespace:      namespace,
+		configMapName:  configMapName,
+		signingKey:     signingKey,
+		allowedTargets: set.New[string](),
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// NewEndpointSliceAllowlistValidator creates a new SSRF protection validator building the allowlist from the
+// EndpointSlices of the given Service, e.g. the headless Service fronting the prefillers
+func NewEndpointSliceAllowlistValidator(namespace string, serviceName string, options KubernetesClientOptions) (*AllowlistValidator, error) {
+	config, err := NewKubernetesConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return NewEndpointSliceAllowlistValidatorWithClient(client, namespace, serviceName), nil
+}
+
+// NewEndpointSliceAllowlistValidatorWithClient creates a new SSRF protection validator watching the
+// EndpointSlices of the given Service with the given client, e.g. a fake client in tests
+func NewEndpointSliceAllowlistValidatorWithClient(client kubernetes.Interface, namespace string, serviceName string) *AllowlistValidator {
+	return &AllowlistValidator{
+		enabled:        true,
+		client:         client,
+		namespace:      namespace,
+		serviceName:    serviceName,
+		allowedTargets: set.New[string](),
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// NewAllowlistValidatorWithClients creates a new enabled SSRF protection validator watching the
+// InferencePool with the given InferencePool client, and its pods with the given client, e.g. fake
+// clients in tests
+func NewAllowlistValidatorWithClients(poolClient poolclient.Interface, client kubernetes.Interface, namespace string, poolName string) *AllowlistValidator {
+	return &AllowlistValidator{
+		enabled:        true,
+		poolClient:     poolClient,
+		client:         client,
 		namespace:      namespace,
 		poolName:       poolName,
+		poolGroup:      InferencePoolAlphaGroup,
 		allowedTargets: set.New[string](),
-		podInformers:   make(map[string]cache.SharedInformer),
-		podStopChans:   make(map[string]chan struct{}),
+		selectors:      make(map[string]labels.Set),
+		targetPorts:    make(map[string][]int32),
 		stopCh:         make(chan struct{}),
-	}, nil
+	}
+}
+
+// WithPoolGroup sets the API group of the watched InferencePool, InferencePoolGroup for the v1
+// InferencePools, InferencePoolAlphaGroup for the v1alpha2 ones, or InferencePoolAutoGroup to detect it
+func (av *AllowlistValidator) WithPoolGroup(group string) *AllowlistValidator {
+	av.poolGroup = group
+	return av
+}
+
+// WithStaticAllowlist sets the prefill targets allowed besides the ones watched, e.g. the prefillers
+// running outside of the cluster
+func (av *AllowlistValidator) WithStaticAllowlist(static *StaticAllowlist) *AllowlistValidator {
+	av.static = static
+	return av
+}
+
+// WithPoolSelector sets the label selector of the watched InferencePools, e.g. to watch the pools of
+// several models sharing the sidecar. The allowlist aggregates the pods of all the selected pools.
+func (av *AllowlistValidator) WithPoolSelector(selector string) *AllowlistValidator {
+	av.poolSelector = selector
+	return av
+}
+
+// WithPortEnforcement only allows the prefill targets on the target ports of the InferencePools, e.g.
+// so that the debug ports of their pods cannot be reached
+func (av *AllowlistValidator) WithPortEnforcement(enforce bool) *AllowlistValidator {
+	av.enforcePorts = enforce
+	return av
+}
+
+// inferencePoolGroupVersion returns the API version of the InferencePools of the given API group
+func inferencePoolGroupVersion(group string) string {
+	if group == InferencePoolGroup {
+		return infextv1.GroupVersion.String()
+	}
+	return infextv1a2.GroupVersion.String()
+}
+
+// WithFailurePolicy sets how the targets missing from the allowlist are handled while it is pending,
+// AllowlistFailClosed to fail the requests with a 503, or AllowlistFailOpen to allow them
+func (av *AllowlistValidator) WithFailurePolicy(policy string) *AllowlistValidator {
+	av.failOpen = policy == AllowlistFailOpen
+	return av
 }
 
-// Start begins watching InferencePool resources and managing the allowlist
+// WithGracePeriod sets the time after the start during which the targets missing from the synced
+// allowlist are handled by the failure policy, instead of being rejected with a 403
+func (av *AllowlistValidator) WithGracePeriod(gracePeriod time.Duration) *AllowlistValidator {
+	av.gracePeriod = gracePeriod
+	return av
+}
+
+// Start begins watching InferencePool resources and managing the allowlist. The caches are synced in
+// the background, IsReady reporting when they are.
 func (av *AllowlistValidator) Start(ctx context.Context) error {
 	if !av.enabled {
 		return nil
 	}
+	av.startedAt = time.Now()
+	if av.configMapName != "" {
+		return av.startPublished(ctx)
+	}
+	if av.serviceName != "" {
+		return av.startEndpointSlices(ctx)
+	}
 
 	av.logger = klog.FromContext(ctx).WithName("allowlist-validator")
-	av.logger.Info("starting SSRF protection allowlist validator", "namespace", av.namespace, "poolName", av.poolName)
+	av.logger.Info("starting SSRF protection allowlist validator", "namespace", av.namespace, "poolName", av.poolName,
+		"poolSelector", av.poolSelector, "poolGroup", av.poolGroup)
+
+	av.poolNames = set.New[string]()
+	for name := range strings.SplitSeq(av.poolName, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			av.poolNames.Insert(name)
+		}
+	}
+	if av.poolSelector != "" {
+		if _, err := labels.Parse(av.poolSelector); err != nil {
+			return fmt.Errorf("invalid InferencePool label selector '%s': %w", av.poolSelector, err)
+		}
+	}
+	if av.poolGroup == InferencePoolAutoGroup {
+		group, err := av.detectPoolGroup(ctx)
+		if err != nil {
+			return err
+		}
+		av.logger.Info("detected the API group of the InferencePools", "poolGroup", group)
+		av.poolGroup = group
+	}
 
-	gvr := schema.GroupVersionResource{
-		Group:    inferencePoolGroup,
-		Version:  inferencePoolVersion,
-		Resource: inferencePoolResource,
+	// A single pod informer serves all the pools, whatever their number and selectors.
+	// Its pods are trimmed to the fields of the allowlist to save memory.
+	// Only the running pods are listed and watched, the others do not serve prefill requests.
+	av.informerFactory = informers.NewSharedInformerFactoryWithOptions(av.client, resyncPeriod,
+		informers.WithNamespace(av.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = podRunningFieldSelector
+		}),
+		informers.WithTransform(trimPod))
+	av.podInformer = av.informerFactory.Core().V1().Pods().Informer()
+	if err := av.podInformer.AddIndexers(cache.Indexers{podLabelIndex: indexPodLabels}); err != nil {
+		return fmt.Errorf("failed to index the pods by label: %w", err)
 	}
+	if err := av.podInformer.SetWatchErrorHandlerWithContext(av.onWatchError); err != nil {
+		return fmt.Errorf("failed to set the watch error handler of the pods: %w", err)
+	}
+	_, _ = av.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    av.onPodAdd,
+		UpdateFunc: av.onPodUpdate,
+		DeleteFunc: av.onPodDelete,
+	})
 
-	// Create informer for the specific InferencePool resource
-	lw := &cache.ListWatch{
-		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-			// List with field selector to get only the specific InferencePool
-			options.FieldSelector = "metadata.name=" + av.poolName
-			return av.dynamicClient.Resource(gvr).Namespace(av.namespace).List(ctx, options)
-		},
-		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			// Watch the specific InferencePool by name using field selector
-			options.FieldSelector = "metadata.name=" + av.poolName
-			return av.dynamicClient.Resource(gvr).Namespace(av.namespace).Watch(ctx, options)
+	// Create informer for the InferencePool resources
+	av.poolInformerFactory = poolinformers.NewSharedInformerFactoryWithOptions(av.poolClient, resyncPeriod,
+		poolinformers.WithNamespace(av.namespace),
+		poolinformers.WithTweakListOptions(av.selectPools))
+	if av.poolGroup == InferencePoolGroup {
+		av.poolInformer = av.poolInformerFactory.Inference().V1().InferencePools().Informer()
+	} else {
+		av.poolInformer = av.poolInformerFactory.XInference().V1alpha2().InferencePools().Informer()
+	}
+	if err := av.poolInformer.SetWatchErrorHandlerWithContext(av.onWatchError); err != nil {
+		return fmt.Errorf("failed to set the watch error handler of the InferencePool: %w", err)
+	}
+
+	// Add event handlers, the pools listed by names are only filtered by the server when there is one
+	_, _ = av.poolInformer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: av.watchesPool,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    av.onInferencePoolAdd,
+			UpdateFunc: av.onInferencePoolUpdate,
+			DeleteFunc: av.onInferencePoolDelete,
 		},
+	})
+
+	// Start the informers
+	av.informerFactory.Start(av.stopCh)
+	av.poolInformerFactory.Start(av.stopCh)
+
+	// the allowlist is marked ready by IsReady once the caches synced, the sidecar is not held until then
+	av.logger.Info("allowlist validator started, syncing the InferencePool cache")
+	return nil
+}
+
+// detectPoolGroup returns the API group of the InferencePools served by the API server. When both the
+// v1 and v1alpha2 ones are served, the v1 group is returned if the watched pools exist in it.
+func (av *AllowlistValidator) detectPoolGroup(ctx context.Context) (string, error) {
+	var groups []string
+	for _, group := range []string{InferencePoolGroup, InferencePoolAlphaGroup} {
+		resources, err := av.client.Discovery().ServerResourcesForGroupVersion(inferencePoolGroupVersion(group))
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to discover the InferencePool API group %s: %w", group, err)
+		}
+		if slices.ContainsFunc(resources.APIResources, func(resource metav1.APIResource) bool {
+			return resource.Name == inferencePoolResource
+		}) {
+			groups = append(groups, group)
+		}
+	}
+
+	switch len(groups) {
+	case 0:
+		return "", fmt.Errorf("no InferencePool API is served in the groups %s and %s (check that the InferencePool CRDs are installed)",
+			InferencePoolGroup, InferencePoolAlphaGroup)
+	case 1:
+		return groups[0], nil
+	}
+
+	var options metav1.ListOptions
+	av.selectPools(&options)
+	pools, err := av.poolClient.InferenceV1().InferencePools(av.namespace).List(ctx, options)
+	if err != nil {
+		av.logger.Info("failed to list the v1 InferencePools, watching the v1alpha2 ones", "error", err.Error())
+		return InferencePoolAlphaGroup, nil
+	}
+	for i := range pools.Items {
+		if av.watchesPool(&pools.Items[i]) {
+			return InferencePoolGroup, nil
+		}
 	}
+	return InferencePoolAlphaGroup, nil
+}
 
-	av.poolInformer = cache.NewSharedInformer(lw, &unstructured.Unstructured{}, resyncPeriod)
+// selectPools restricts the listed and watched InferencePools to the ones of the allowlist
+func (av *AllowlistValidator) selectPools(options *metav1.ListOptions) {
+	if av.poolNames.Len() == 1 {
+		// a field selector only matches a single name
+		options.FieldSelector = "metadata.name=" + av.poolNames.UnsortedList()[0]
+	}
+	if av.poolSelector != "" {
+		options.LabelSelector = av.poolSelector
+	}
+}
 
-	// Add event handlers
-	_, _ = av.poolInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    av.onInferencePoolAdd,
-		UpdateFunc: av.onInferencePoolUpdate,
-		DeleteFunc: av.onInferencePoolDelete,
+// watchesPool returns whether the InferencePool is one of the allowlist
+func (av *AllowlistValidator) watchesPool(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pool, ok := obj.(metav1.Object)
+	return ok && (av.poolNames.Len() == 0 || av.poolNames.Has(pool.GetName()))
+}
+
+// startPublished begins watching the ConfigMap of the endpoint set published by the EPP
+func (av *AllowlistValidator) startPublished(ctx context.Context) error {
+	av.logger = klog.FromContext(ctx).WithName("allowlist-validator")
+	av.logger.Info("starting SSRF protection allowlist validator from the published endpoints",
+		"namespace", av.namespace, "configMap", av.configMapName)
+
+	av.informerFactory = informers.NewSharedInformerFactoryWithOptions(av.client, resyncPeriod,
+		informers.WithNamespace(av.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = "metadata.name=" + av.configMapName
+		}))
+	informer := av.informerFactory.Core().V1().ConfigMaps().Informer()
+	if err := informer.SetWatchErrorHandlerWithContext(av.onWatchError); err != nil {
+		return fmt.Errorf("failed to set the watch error handler of the ConfigMap: %w", err)
+	}
+	_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: av.onEndpointsPublished,
+		UpdateFunc: func(_, newObj interface{}) {
+			av.onEndpointsPublished(newObj)
+		},
+		DeleteFunc: func(interface{}) {
+			av.logger.Info("published endpoints deleted, keeping the last allowlist", "configMap", av.configMapName)
+		},
 	})
+	av.informerFactory.Start(av.stopCh)
 
-	// Start the informer
-	go av.poolInformer.Run(av.stopCh)
+	// the allowlist is marked ready once a valid endpoint set is published
+	av.logger.Info("allowlist validator started, waiting for the published endpoints")
+	return nil
+}
 
-	// Wait for cache sync
-	if !cache.WaitForCacheSync(av.stopCh, av.poolInformer.HasSynced) {
-		return fmt.Errorf("failed to sync InferencePool cache within timeout (check RBAC permissions for inferencepools.%s and that pool '%s' exists)", inferencePoolGroup, av.poolName)
+// startEndpointSlices begins watching the EndpointSlices of the Service fronting the prefillers
+func (av *AllowlistValidator) startEndpointSlices(ctx context.Context) error {
+	av.logger = klog.FromContext(ctx).WithName("allowlist-validator")
+	av.logger.Info("starting SSRF protection allowlist validator from the EndpointSlices",
+		"namespace", av.namespace, "service", av.serviceName)
+
+	av.informerFactory = informers.NewSharedInformerFactoryWithOptions(av.client, resyncPeriod,
+		informers.WithNamespace(av.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = discoveryv1.LabelServiceName + "=" + av.serviceName
+		}))
+	av.sliceInformer = av.informerFactory.Discovery().V1().EndpointSlices().Informer()
+	if err := av.sliceInformer.SetWatchErrorHandlerWithContext(av.onWatchError); err != nil {
+		return fmt.Errorf("failed to set the watch error handler of the EndpointSlices: %w", err)
 	}
+	_, _ = av.sliceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { av.rebuildEndpointSliceAllowlist() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if oldObj.(*discoveryv1.EndpointSlice).ResourceVersion != newObj.(*discoveryv1.EndpointSlice).ResourceVersion {
+				av.rebuildEndpointSliceAllowlist()
+			}
+		},
+		DeleteFunc: func(interface{}) { av.rebuildEndpointSliceAllowlist() },
+	})
+	av.informerFactory.Start(av.stopCh)
 
-	av.logger.Info("allowlist validator started successfully")
+	go func() {
+		if !cache.WaitForCacheSync(av.stopCh, av.sliceInformer.HasSynced) {
+			return
+		}
+		// the event handlers may lag behind the synced store
+		av.rebuildEndpointSliceAllowlist()
+		av.ready.Store(true)
+		av.logger.Info("allowlist synced")
+	}()
+
+	av.logger.Info("allowlist validator started, syncing the EndpointSlice cache")
 	return nil
 }
 
+// rebuildEndpointSliceAllowlist rebuilds the entire allowlist from the addresses, hostnames and pod names
+// of the endpoints of the current EndpointSlices. The endpoints not ready, e.g. terminating, are
+// skipped like the pods not running; an unknown readiness is interpreted as ready.
+func (av *AllowlistValidator) rebuildEndpointSliceAllowlist() {
+	targets := set.New[string]()
+	for _, obj := range av.sliceInformer.GetStore().List() {
+		for _, endpoint := range obj.(*discoveryv1.EndpointSlice).Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			targets.Insert(endpoint.Addresses...)
+			if endpoint.Hostname != nil && *endpoint.Hostname != "" {
+				targets.Insert(*endpoint.Hostname)
+			}
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" && endpoint.TargetRef.Name != "" {
+				targets.Insert(endpoint.TargetRef.Name)
+			}
+		}
+	}
+
+	av.allowedTargetsMu.Lock()
+	defer av.allowedTargetsMu.Unlock()
+	av.allowedTargets = targets
+	av.generation++
+
+	av.logger.Info("rebuilt allowlist from the EndpointSlices", "targetCount", len(av.allowedTargets))
+	av.logger.V(4).Info("allowlist targets", "targets", av.allowedTargets)
+}
+
+// onEndpointsPublished replaces the allowlist with the endpoint set published by the EPP, once its
+// signature is verified. Endpoint sets published before the current one are ignored, so that an old
+// signed endpoint set cannot be replayed.
+func (av *AllowlistValidator) onEndpointsPublished(obj interface{}) {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return
+	}
+
+	payload := []byte(configMap.Data[common.PublishedEndpointsKey])
+	if !common.VerifyEndpoints(payload, configMap.Data[common.PublishedSignatureKey], av.signingKey) {
+		av.logger.Error(nil, "SSRF protection: invalid signature of the published endpoints, ignoring them", "configMap", configMap.Name)
+		return
+	}
+	var published common.PublishedEndpoints
+	if err := json.Unmarshal(payload, &published); err != nil {
+		av.logger.Error(err, "SSRF protection: invalid published endpoints, ignoring them", "configMap", configMap.Name)
+		return
+	}
+
+	av.allowedTargetsMu.Lock()
+	defer av.allowedTargetsMu.Unlock()
+
+	if published.PublishedAt.Before(av.publishedAt) {
+		av.logger.Error(nil, "SSRF protection: outdated published endpoints, ignoring them", "configMap", configMap.Name,
+			"publishedAt", published.PublishedAt, "currentPublishedAt", av.publishedAt)
+		return
+	}
+	av.publishedAt = published.PublishedAt
+	av.allowedTargets = set.New(published.Endpoints...)
+	av.generation++
+	av.ready.Store(true)
+
+	av.logger.Info("rebuilt allowlist from the published endpoints", "targetCount", len(av.allowedTargets), "targets", av.allowedTargets)
+}
+
+// onWatchError counts a failed list or watch of an informer, then logs it as the default handler
+func (av *AllowlistValidator) onWatchError(ctx context.Context, r *cache.Reflector, err error) {
+	av.watchErrors.Add(1)
+	cache.DefaultWatchErrorHandler(ctx, r, err)
+}
+
+// stats returns the number of targets of the allowlist, the number of its rebuilds and the number of
+// failed lists and watches of its informers
+func (av *AllowlistValidator) stats() (targets int, rebuilds uint64, watchErrors uint64) {
+	av.allowedTargetsMu.RLock()
+	defer av.allowedTargetsMu.RUnlock()
+	return len(av.allowedTargets), av.generation, av.watchErrors.Load()
+}
+
 // Stop stops all watchers and cleans up resources
 func (av *AllowlistValidator) Stop() {
 	if !av.enabled {
@@ -157,37 +606,185 @@ func (av *AllowlistValidator) Stop() {
 
 	av.logger.Info("stopping allowlist validator")
 
-	// Stop all pod informers first
-	av.podInformersMu.Lock()
-	for poolName, stopCh := range av.podStopChans {
-		av.logger.V(4).Info("stopping pod informer", "pool", poolName)
-		close(stopCh)
-	}
-	// Clear the maps
-	av.podStopChans = make(map[string]chan struct{})
-	av.podInformers = make(map[string]cache.SharedInformer)
-	av.podInformersMu.Unlock()
-
-	// Stop the main pool informer
 	close(av.stopCh)
+	if av.informerFactory != nil {
+		av.informerFactory.Shutdown()
+	}
+	if av.poolInformerFactory != nil {
+		av.poolInformerFactory.Shutdown()
+	}
 }
 
 // IsAllowed checks if a given host:port combination is in the allowlist
 func (av *AllowlistValidator) IsAllowed(hostPort string) bool {
+	allowed, _ := av.check(hostPort)
+	return allowed
+}
+
+// check returns whether a given host:port combination is in the allowlist, and the generation of the
+// allowlist consulted
+func (av *AllowlistValidator) check(hostPort string) (bool, uint64) {
 	if !av.enabled {
 		// If SSRF protection is disabled, allow all requests (backward compatibility)
-		return true
+		return true, 0
 	}
 
+	// the static allowlist may only allow some ports of a host
+	static := av.static.Allows(hostPort)
+
 	// Clean up the hostPort input
-	hostPort = av.normalizeHostPort(hostPort)
+	host := av.normalizeHostPort(hostPort)
 
 	av.allowedTargetsMu.RLock()
 	defer av.allowedTargetsMu.RUnlock()
 
-	allowed := av.allowedTargets.Has(hostPort)
-	av.logger.V(4).Info("allowlist check", "hostPort", hostPort, "allowed", allowed)
-	return allowed
+	allowed := av.allowedTargets.Has(host)
+	if allowed && av.enforcePorts {
+		// the target must then be on a target port of its InferencePool
+		allowed = av.allowsPort(hostPort)
+	}
+	allowed = allowed || static
+	av.logger.V(4).Info("allowlist check", "hostPort", hostPort, "allowed", allowed, "static", static, "generation", av.generation)
+	return allowed, av.generation
+}
+
+// allowsPort returns whether the host:port is on a target port of the InferencePool of its host, the
+// lock of the allowlist held
+func (av *AllowlistValidator) allowsPort(hostPort string) bool {
+	host, port, err := net.SplitHostPort(hostPort)
+	return err == nil && av.allowedHostPorts.Has(canonicalHostPort(host, port))
+}
+
+// staticallyAllowed returns whether all the given targets are allowed by the static allowlist, which
+// does not need the allowlist to be synced
+func (av *AllowlistValidator) staticallyAllowed(hostPorts []string) bool {
+	return av.static != nil && len(hostPorts) > 0 &&
+		!slices.ContainsFunc(hostPorts, func(hostPort string) bool { return !av.static.Allows(hostPort) })
+}
+
+// pending returns whether a target missing from the allowlist may still be added to it: until the
+// allowlist is synced, and during its grace period
+func (av *AllowlistValidator) pending() bool {
+	return av.enabled && (!av.IsReady() || time.Since(av.startedAt) < av.gracePeriod)
+}
+
+// readiness returns an error while the requests fail closed until the allowlist is synced, so that
+// the pod is only added to the endpoints of the Service once it can serve them
+func (av *AllowlistValidator) readiness() error {
+	if av.failOpen || av.IsReady() {
+		return nil
+	}
+	return errAllowlistNotReady
+}
+
+// currentGeneration returns the generation of the allowlist
+func (av *AllowlistValidator) currentGeneration() uint64 {
+	av.allowedTargetsMu.RLock()
+	defer av.allowedTargetsMu.RUnlock()
+	return av.generation
+}
+
+// allowlistState is the state of the allowlist reported by the admin API
+type allowlistState struct {
+	Enabled      bool     `json:"enabled"`
+	Synced       bool     `json:"synced"`
+	Namespace    string   `json:"namespace,omitempty"`
+	PoolName     string   `json:"pool_name,omitempty"`
+	PoolSelector string   `json:"pool_selector,omitempty"`
+	Service      string   `json:"service,omitempty"`
+	Static       []string `json:"static,omitempty"`
+	EnforcePorts bool     `json:"enforce_ports,omitempty"`
+	FailOpen     bool     `json:"fail_open,omitempty"`
+	GracePeriod  string   `json:"grace_period,omitempty"`
+	Generation   uint64   `json:"generation"`
+	Targets      []string `json:"targets"`
+}
+
+// state returns the state of the allowlist, its targets sorted
+func (av *AllowlistValidator) state() allowlistState {
+	state := allowlistState{Enabled: av.enabled, Synced: av.IsReady(), Targets: []string{}}
+	if !av.enabled {
+		return state
+	}
+	state.Namespace, state.PoolName, state.PoolSelector, state.Service = av.namespace, av.poolName, av.poolSelector, av.serviceName
+	state.Static, state.EnforcePorts = av.static.Entries(), av.enforcePorts
+	state.FailOpen = av.failOpen
+	if av.gracePeriod > 0 {
+		state.GracePeriod = av.gracePeriod.String()
+	}
+
+	av.allowedTargetsMu.RLock()
+	defer av.allowedTargetsMu.RUnlock()
+	state.Targets = av.allowedTargets.SortedList()
+	state.Generation = av.generation
+	return state
+}
+
+// explain returns whether a prefill target is allowed, and why
+func (av *AllowlistValidator) explain(hostPort string) (bool, string) {
+	switch {
+	case !av.enabled:
+		return true, "the SSRF protection is disabled"
+	case av.static.Allows(hostPort):
+		return true, fmt.Sprintf("the target %s is in the static allowlist", hostPort)
+	case !av.IsReady():
+		return av.failOpen, "the allowlist is not synced yet"
+	case av.IsAllowed(hostPort):
+		return true, fmt.Sprintf("the host %s is in the allowlist", av.normalizeHostPort(hostPort))
+	case av.pending():
+		return av.failOpen, fmt.Sprintf("the host %s is not in the allowlist yet, within its grace period", av.normalizeHostPort(hostPort))
+	case av.enforcePorts && av.hostAllowed(hostPort):
+		return false, fmt.Sprintf("the host %s is in the allowlist, but %s is not a target port of its InferencePool",
+			av.normalizeHostPort(hostPort), hostPort)
+	default:
+		return false, fmt.Sprintf("the host %s is not in the allowlist", av.normalizeHostPort(hostPort))
+	}
+}
+
+// hostAllowed returns whether the host of the host:port is in the allowlist, whatever its port
+func (av *AllowlistValidator) hostAllowed(hostPort string) bool {
+	av.allowedTargetsMu.RLock()
+	defer av.allowedTargetsMu.RUnlock()
+	return av.allowedTargets.Has(av.normalizeHostPort(hostPort))
+}
+
+// IsReady returns whether the allowlist was synced with the InferencePool and its pods.
+// Until then, the allowlist may be missing valid prefill targets, e.g. after a restart.
+func (av *AllowlistValidator) IsReady() bool {
+	if !av.enabled || av.ready.Load() {
+		return true
+	}
+	if av.poolInformer == nil || !av.poolInformer.HasSynced() || !av.podInformer.HasSynced() {
+		return false
+	}
+
+	// the pod event handlers may lag behind the synced stores
+	av.rebuildAllowlist()
+	av.ready.Store(true)
+	av.logger.Info("allowlist synced")
+	return true
+}
+
+// WaitReady waits up to the given time for the allowlist to be synced, and returns whether it is
+func (av *AllowlistValidator) WaitReady(ctx context.Context, timeout time.Duration) bool {
+	if av.IsReady() {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(allowlistReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return av.IsReady()
+		case <-ticker.C:
+			if av.IsReady() {
+				return true
+			}
+		}
+	}
 }
 
 // normalizeHostPort extracts the host part from a host:port string
@@ -206,163 +803,118 @@ func (av *AllowlistValidator) normalizeHostPort(hostPort string) string {
 
 // onInferencePoolAdd handles new InferencePool resources
 func (av *AllowlistValidator) onInferencePoolAdd(obj interface{}) {
-	pool := obj.(*unstructured.Unstructured)
-	av.logger.Info("InferencePool added", "name", pool.GetName())
-	av.updatePodsForPool(pool)
+	poolName, selector, ports := inferencePoolSpec(obj)
+	av.logger.Info("InferencePool added", "name", poolName, "targetPorts", ports)
+	av.updatePodsForPool(poolName, selector, ports)
 }
 
 // onInferencePoolUpdate handles updated InferencePool resources
 func (av *AllowlistValidator) onInferencePoolUpdate(_, newObj interface{}) {
-	pool := newObj.(*unstructured.Unstructured)
-	av.logger.Info("InferencePool updated", "name", pool.GetName())
-	av.updatePodsForPool(pool)
+	poolName, selector, ports := inferencePoolSpec(newObj)
+	av.logger.Info("InferencePool updated", "name", poolName, "targetPorts", ports)
+	av.updatePodsForPool(poolName, selector, ports)
 }
 
 // onInferencePoolDelete handles deleted InferencePool resources
 func (av *AllowlistValidator) onInferencePoolDelete(obj interface{}) {
-	pool := obj.(*unstructured.Unstructured)
-	poolName := pool.GetName()
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	poolName := obj.(metav1.Object).GetName()
 	av.logger.Info("InferencePool deleted", "name", poolName)
 
-	// Stop watching pods for this pool
-	av.podInformersMu.Lock()
-	if stopCh, exists := av.podStopChans[poolName]; exists {
-		close(stopCh) // properly stop the informer
-		delete(av.podStopChans, poolName)
-	}
-	delete(av.podInformers, poolName)
-	av.podInformersMu.Unlock()
+	av.selectorsMu.Lock()
+	delete(av.selectors, poolName)
+	delete(av.targetPorts, poolName)
+	av.selectorsMu.Unlock()
 
-	// Remove targets associated with this pool (simplified - removes all and rebuilds)
 	av.rebuildAllowlist()
 }
 
-// updatePodsForPool starts or updates pod watching for a specific InferencePool
-func (av *AllowlistValidator) updatePodsForPool(poolObj *unstructured.Unstructured) {
-	poolName := poolObj.GetName()
-
-	// Parse the pool spec to get selector
-	spec, found, err := unstructured.NestedMap(poolObj.Object, "spec")
-	if err != nil || !found {
-		av.logger.Error(err, "InferencePool missing or invalid spec field", "name", poolName, "found", found)
-		return
-	}
-
-	selectorData, found, err := unstructured.NestedMap(spec, "selector")
-	if err != nil || !found {
-		av.logger.Error(err, "InferencePool missing or invalid selector field", "name", poolName, "found", found)
-		return
-	}
-
-	// Convert to labels.Selector
-	labelSelector := labels.Set{}
-	for k, v := range selectorData {
-		labelSelector[k] = fmt.Sprintf("%v", v)
-	}
+// updatePodsForPool updates the pod selector and the target ports of a specific InferencePool
+func (av *AllowlistValidator) updatePodsForPool(poolName string, selector labels.Set, ports []int32) {
+	av.selectorsMu.Lock()
+	av.selectors[poolName] = selector
+	av.targetPorts[poolName] = ports
+	av.selectorsMu.Unlock()
 
-	// Create or update pod informer for this selector
-	av.createPodInformer(poolName, labelSelector.AsSelector())
+	av.rebuildAllowlist()
 }
 
-// createPodInformer creates a new pod informer for the given selector
-func (av *AllowlistValidator) createPodInformer(poolName string, selector labels.Selector) {
-	av.podInformersMu.Lock()
-	defer av.podInformersMu.Unlock()
-
-	// Stop existing informer if it exists
-	if _, exists := av.podInformers[poolName]; exists {
-		if stopCh, stopExists := av.podStopChans[poolName]; stopExists {
-			close(stopCh) // stop the existing informer
-			delete(av.podStopChans, poolName)
+// inferencePoolSpec returns the name of a v1 or v1alpha2 InferencePool, the labels selecting its pods,
+// and its target ports
+func inferencePoolSpec(obj interface{}) (string, labels.Set, []int32) {
+	selector := labels.Set{}
+	switch pool := obj.(type) {
+	case *infextv1.InferencePool:
+		// the v1 InferencePools select their pods with the labels of a label selector
+		for key, value := range pool.Spec.Selector.MatchLabels {
+			selector[string(key)] = string(value)
 		}
-		delete(av.podInformers, poolName)
-	}
-
-	// Create new pod informer
-	podLW := &cache.ListWatch{
-		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
-			options.LabelSelector = selector.String()
-			return av.dynamicClient.Resource(schema.GroupVersionResource{
-				Group:    "",
-				Version:  "v1",
-				Resource: "pods",
-			}).Namespace(av.namespace).List(context.TODO(), options)
-		},
-		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			options.LabelSelector = selector.String()
-			return av.dynamicClient.Resource(schema.GroupVersionResource{
-				Group:    "",
-				Version:  "v1",
-				Resource: "pods",
-			}).Namespace(av.namespace).Watch(context.TODO(), options)
-		},
+		ports := make([]int32, 0, len(pool.Spec.TargetPorts))
+		for _, port := range pool.Spec.TargetPorts {
+			ports = append(ports, int32(port.Number))
+		}
+		return pool.Name, selector, ports
+	case *infextv1a2.InferencePool:
+		for key, value := range pool.Spec.Selector {
+			selector[string(key)] = string(value)
+		}
+		return pool.Name, selector, []int32{pool.Spec.TargetPortNumber}
 	}
-
-	podInformer := cache.NewSharedInformer(podLW, &unstructured.Unstructured{}, resyncPeriod)
-
-	// Add event handlers
-	_, _ = podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    av.onPodAdd,
-		UpdateFunc: av.onPodUpdate,
-		DeleteFunc: av.onPodDelete,
-	})
-
-	// Create individual stop channel for this informer
-	podStopCh := make(chan struct{})
-
-	av.podInformers[poolName] = podInformer
-	av.podStopChans[poolName] = podStopCh
-
-	// Start the informer with its own stop channel
-	go podInformer.Run(podStopCh)
+	return "", selector, nil
 }
 
-// onPodAdd handles new pods matching our selectors
+// onPodAdd handles new pods
 func (av *AllowlistValidator) onPodAdd(obj interface{}) {
-	pod := obj.(*unstructured.Unstructured)
-	podIP, _, _ := unstructured.NestedString(pod.Object, "status", "podIP")
-	av.logger.V(4).Info("Pod added", "name", pod.GetName(), "ip", podIP)
+	pod := obj.(*corev1.Pod)
+	av.logger.V(4).Info("Pod added", "name", pod.Name, "ip", pod.Status.PodIP)
 	av.rebuildAllowlist()
 }
 
 // onPodUpdate handles updated pods
-func (av *AllowlistValidator) onPodUpdate(_, newObj interface{}) {
-	pod := newObj.(*unstructured.Unstructured)
-	podIP, _, _ := unstructured.NestedString(pod.Object, "status", "podIP")
-	av.logger.V(4).Info("Pod updated", "name", pod.GetName(), "ip", podIP)
+func (av *AllowlistValidator) onPodUpdate(oldObj, newObj interface{}) {
+	oldPod, pod := oldObj.(*corev1.Pod), newObj.(*corev1.Pod)
+	if oldPod.Status.PodIP == pod.Status.PodIP && labels.Equals(oldPod.Labels, pod.Labels) {
+		return // resync, or a change irrelevant to the allowlist
+	}
+	av.logger.V(4).Info("Pod updated", "name", pod.Name, "ip", pod.Status.PodIP)
 	av.rebuildAllowlist()
 }
 
 // onPodDelete handles deleted pods
 func (av *AllowlistValidator) onPodDelete(obj interface{}) {
-	pod := obj.(*unstructured.Unstructured)
-	av.logger.V(4).Info("Pod deleted", "name", pod.GetName())
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*corev1.Pod); ok {
+		av.logger.V(4).Info("Pod deleted", "name", pod.Name)
+	}
 	av.rebuildAllowlist()
 }
 
 // rebuildAllowlist rebuilds the entire allowlist from current pod state
 func (av *AllowlistValidator) rebuildAllowlist() {
+	if av.podInformer == nil {
+		return
+	}
+
 	av.allowedTargetsMu.Lock()
 	defer av.allowedTargetsMu.Unlock()
 
 	// Clear existing allowlist
 	av.allowedTargets = set.New[string]()
+	av.allowedHostPorts = set.New[string]()
+	av.generation++
 
-	av.podInformersMu.RLock()
-	defer av.podInformersMu.RUnlock()
-	// Rebuild from all pod informers
-	for poolName, informer := range av.podInformers {
-		store := informer.GetStore()
-		for _, obj := range store.List() {
-			pod := obj.(*unstructured.Unstructured)
-
-			// Get pod phase and IP
-			podIP, _, _ := unstructured.NestedString(pod.Object, "status", "podIP")
-
+	av.selectorsMu.RLock()
+	defer av.selectorsMu.RUnlock()
+	for poolName, selector := range av.selectors {
+		for _, pod := range av.podsForSelector(selector) {
 			// Only include pods with valid IPs
-			if podIP != "" {
+			if pod.Status.PodIP != "" {
 				// Add both IP and hostname variants
-				av.addPodToAllowlist(pod, poolName)
+				av.addPodToAllowlist(pod, poolName, av.targetPorts[poolName])
 			}
 		}
 	}
@@ -370,17 +922,83 @@ func (av *AllowlistValidator) rebuildAllowlist() {
 	av.logger.Info("rebuilt allowlist", "targetCount", len(av.allowedTargets), "targets", av.allowedTargets)
 }
 
+// podsForSelector returns the pods matching a selector, looked up by one of its labels in the index
+func (av *AllowlistValidator) podsForSelector(selector labels.Set) []*corev1.Pod {
+	if len(selector) == 0 {
+		return nil // an empty selector must not allow all the pods of the namespace
+	}
+
+	var indexKey string
+	for key, value := range selector {
+		indexKey = key + "=" + value
+		break
+	}
+	objs, err := av.podInformer.GetIndexer().ByIndex(podLabelIndex, indexKey)
+	if err != nil {
+		av.logger.Error(err, "failed to look up the pods by label", "label", indexKey)
+		return nil
+	}
+
+	pods := make([]*corev1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pod := obj.(*corev1.Pod)
+		if selector.AsSelector().Matches(labels.Set(pod.Labels)) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
 // addPodToAllowlist adds a pod's endpoints to the allowlist
-func (av *AllowlistValidator) addPodToAllowlist(pod *unstructured.Unstructured, poolName string) {
-	podIP, _, _ := unstructured.NestedString(pod.Object, "status", "podIP")
-	if podIP != "" {
-		av.allowedTargets.Insert(podIP)
+func (av *AllowlistValidator) addPodToAllowlist(pod *corev1.Pod, poolName string, ports []int32) {
+	if pod.Status.PodIP != "" {
+		av.allowedTargets.Insert(pod.Status.PodIP)
+	}
+
+	if pod.Name != "" {
+		av.allowedTargets.Insert(pod.Name)
+	}
+
+	for _, port := range ports {
+		portString := strconv.Itoa(int(port))
+		if pod.Status.PodIP != "" {
+			av.allowedHostPorts.Insert(canonicalHostPort(pod.Status.PodIP, portString))
+		}
+		if pod.Name != "" {
+			av.allowedHostPorts.Insert(canonicalHostPort(pod.Name, portString))
+		}
 	}
 
-	podName := pod.GetName()
-	if podName != "" {
-		av.allowedTargets.Insert(podName)
+	av.logger.V(5).Info("added pod to allowlist", "pod", pod.Name, "ip", pod.Status.PodIP, "pool", poolName)
+}
+
+// indexPodLabels indexes the pods by each of their "key=value" labels
+func indexPodLabels(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(pod.Labels))
+	for key, value := range pod.Labels {
+		keys = append(keys, key+"="+value)
 	}
+	return keys, nil
+}
 
-	av.logger.V(5).Info("added pod to allowlist", "pod", podName, "ip", podIP, "pool", poolName)
+// trimPod keeps the fields of the pods used by the allowlist, before they are stored by the informer
+func trimPod(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil // e.g. a tombstone
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			UID:             pod.UID,
+			ResourceVersion: pod.ResourceVersion,
+			Labels:          pod.Labels,
+		},
+		Status: corev1.PodStatus{PodIP: pod.Status.PodIP},
+	}, nil
 }
@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Allowlist failure policy", func() {
+	var server *Server
+
+	BeforeEach(func() {
+		server, _, _ = newInProcessProxy(ConnectorNIXLV2, []byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`))
+		server.config.AllowlistReadyWait = 10 * time.Millisecond
+		server.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New[string]()}
+	})
+
+	sendRequest := func() int {
+		req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		req.Header.Set(common.PrefillPodHeader, inProcessPrefillHostPort)
+		rec := httptest.NewRecorder()
+		server.chatCompletionsHandler(rec, req)
+		return rec.Code
+	}
+
+	It("should fail the requests closed until the allowlist is synced", func() {
+		Expect(sendRequest()).To(Equal(http.StatusServiceUnavailable))
+		Expect(testutil.ToFloat64(server.metrics.allowlistNotReady)).To(Equal(1.0))
+	})
+
+	It("should allow the prefill targets until the allowlist is synced when failing open", func() {
+		server.allowlistValidator.WithFailurePolicy(AllowlistFailOpen)
+
+		Expect(sendRequest()).To(Equal(http.StatusOK))
+		Expect(testutil.ToFloat64(server.metrics.allowlistFailOpen)).To(Equal(1.0))
+	})
+
+	It("should fail the requests to the prefill targets missing from the allowlist during its grace period", func() {
+		server.allowlistValidator.WithGracePeriod(time.Minute).startedAt = time.Now()
+		server.allowlistValidator.ready.Store(true)
+
+		Expect(sendRequest()).To(Equal(http.StatusServiceUnavailable))
+
+		server.allowlistValidator.WithFailurePolicy(AllowlistFailOpen)
+		Expect(sendRequest()).To(Equal(http.StatusOK))
+
+		server.allowlistValidator.startedAt = time.Now().Add(-time.Minute)
+		Expect(sendRequest()).To(Equal(http.StatusForbidden))
+		Expect(testutil.ToFloat64(server.metrics.ssrfRejections.WithLabelValues("prefill.local"))).To(Equal(1.0))
+	})
+
+	It("should explain the prefill targets handled by the failure policy", func() {
+		server.allowlistValidator.WithFailurePolicy(AllowlistFailOpen)
+		allowed, reason := server.allowlistValidator.explain("10.0.0.1:8000")
+		Expect(allowed).To(BeTrue())
+		Expect(reason).To(Equal("the allowlist is not synced yet"))
+
+		server.allowlistValidator.WithGracePeriod(time.Minute).startedAt = time.Now()
+		server.allowlistValidator.ready.Store(true)
+		allowed, reason = server.allowlistValidator.explain("10.0.0.1:8000")
+		Expect(allowed).To(BeTrue())
+		Expect(reason).To(Equal("the host 10.0.0.1 is not in the allowlist yet, within its grace period"))
+	})
+})
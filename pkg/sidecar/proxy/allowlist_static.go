@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/set"
+)
+
+// StaticAllowlist are the prefill targets allowed besides the ones of the InferencePool, e.g. the
+// prefillers running outside of the cluster. Its entries are CIDRs, IP addresses, hostnames, or
+// host:port pairs only allowing that port.
+type StaticAllowlist struct {
+	entries   []string
+	prefixes  []netip.Prefix
+	hosts     set.Set[string]
+	hostPorts set.Set[string]
+}
+
+// NewStaticAllowlist parses the entries of a static allowlist
+func NewStaticAllowlist(entries []string) (*StaticAllowlist, error) {
+	static := &StaticAllowlist{hosts: set.New[string](), hostPorts: set.New[string]()}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if err := static.add(entry); err != nil {
+			return nil, err
+		}
+		static.entries = append(static.entries, entry)
+	}
+	return static, nil
+}
+
+// add parses an entry of the static allowlist
+func (sa *StaticAllowlist) add(entry string) error {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR '%s' in the static allowlist: %w", entry, err)
+		}
+		sa.prefixes = append(sa.prefixes, prefix.Masked())
+		return nil
+	}
+	if host, port, err := net.SplitHostPort(entry); err == nil {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil || host == "" {
+			return fmt.Errorf("invalid host:port '%s' in the static allowlist", entry)
+		}
+		sa.hostPorts.Insert(canonicalHostPort(host, port))
+		return nil
+	}
+	if addr, err := netip.ParseAddr(entry); err == nil {
+		sa.prefixes = append(sa.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		return nil
+	}
+	if strings.ContainsAny(entry, ":[] ") {
+		return fmt.Errorf("invalid hostname '%s' in the static allowlist", entry)
+	}
+	sa.hosts.Insert(strings.ToLower(entry))
+	return nil
+}
+
+// Allows returns whether the static allowlist allows the given host:port, or host
+func (sa *StaticAllowlist) Allows(hostPort string) bool {
+	if sa == nil {
+		return false
+	}
+
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	} else if sa.hostPorts.Has(canonicalHostPort(host, port)) {
+		return true
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addr = addr.Unmap().WithZone("")
+		return slices.ContainsFunc(sa.prefixes, func(prefix netip.Prefix) bool { return prefix.Contains(addr) })
+	}
+	return sa.hosts.Has(strings.ToLower(host))
+}
+
+// Entries returns the entries of the static allowlist, as given
+func (sa *StaticAllowlist) Entries() []string {
+	if sa == nil {
+		return nil
+	}
+	return slices.Clone(sa.entries)
+}
+
+// canonicalHostPort returns the host:port with its IP address normalized and its hostname lowercased,
+// so that the different notations of a target match
+func canonicalHostPort(host string, port string) string {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		host = addr.Unmap().WithZone("").String()
+	}
+	return net.JoinHostPort(strings.ToLower(host), port)
+}
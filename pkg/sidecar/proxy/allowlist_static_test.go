@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"k8s.io/utils/set"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Static allowlist", func() {
+	DescribeTable("should allow the targets matching its entries",
+		func(target string, allowed bool) {
+			static, err := NewStaticAllowlist([]string{"192.168.10.0/24", " 2001:db8::/64", "10.1.2.3", "GPU-0.lab.example.com",
+				"gpu-1.lab.example.com:8000", "[2001:db8:1::1]:8000", ""})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(static.Allows(target)).To(Equal(allowed))
+		},
+		Entry("an address in a CIDR", "192.168.10.7:8000", true),
+		Entry("an address outside of the CIDRs", "192.168.11.7:8000", false),
+		Entry("an IPv6 address in a CIDR", "[2001:db8::5]:8000", true),
+		Entry("an IP address", "10.1.2.3:9000", true),
+		Entry("an IP address without port", "10.1.2.3", true),
+		Entry("a hostname, whatever its case", "gpu-0.LAB.example.com:8000", true),
+		Entry("another hostname", "gpu-2.lab.example.com:8000", false),
+		Entry("a host:port", "gpu-1.lab.example.com:8000", true),
+		Entry("another port of a host:port", "gpu-1.lab.example.com:8001", false),
+		Entry("an IPv6 host:port", "[2001:db8:1::1]:8000", true),
+		Entry("another port of an IPv6 host:port", "[2001:db8:1::1]:8001", false),
+	)
+
+	DescribeTable("should reject the invalid entries",
+		func(entry string) {
+			_, err := NewStaticAllowlist([]string{entry})
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("an invalid CIDR", "10.0.0.0/33"),
+		Entry("an invalid port", "gpu-0:http"),
+		Entry("a URL", "http://gpu-0:8000"),
+		Entry("an invalid hostname", "gpu 0"),
+	)
+
+	It("should allow the static targets before the allowlist is synced", func() {
+		server, _, prefillRequests := newInProcessProxy(ConnectorNIXLV2, []byte(`{"kv_transfer_params":{}}`))
+		static, err := NewStaticAllowlist([]string{"prefill.local:8000"})
+		Expect(err).ToNot(HaveOccurred())
+		server.allowlistValidator = (&AllowlistValidator{enabled: true, allowedTargets: set.New[string]()}).WithStaticAllowlist(static)
+
+		req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		req.Header.Set(common.PrefillPodHeader, inProcessPrefillHostPort)
+		rec := httptest.NewRecorder()
+		server.chatCompletionsHandler(rec, req)
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(HaveLen(1))
+		Expect(server.allowlistValidator.state().Static).To(Equal([]string{"prefill.local:8000"}))
+		allowed, reason := server.allowlistValidator.explain("prefill.local:8000")
+		Expect(allowed).To(BeTrue())
+		Expect(reason).To(Equal("the target prefill.local:8000 is in the static allowlist"))
+	})
+})
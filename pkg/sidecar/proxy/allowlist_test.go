@@ -17,11 +17,64 @@ limitations under the License.
 package proxy
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
 	. "github.com/onsi/ginkgo/v2" // nolint:revive
 	. "github.com/onsi/gomega"    // nolint:revive
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
 	"k8s.io/utils/set"
+	infextv1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
+	infextv1a2 "sigs.k8s.io/gateway-api-inference-extension/apix/v1alpha2"
+	poolfake "sigs.k8s.io/gateway-api-inference-extension/client-go/clientset/versioned/fake"
 )
 
+func testInferencePool(name string, selector map[string]string) *infextv1a2.InferencePool {
+	pool := &infextv1a2.InferencePool{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"}}
+	pool.Spec.Selector = map[infextv1a2.LabelKey]infextv1a2.LabelValue{}
+	for key, value := range selector {
+		pool.Spec.Selector[infextv1a2.LabelKey(key)] = infextv1a2.LabelValue(value)
+	}
+	return pool
+}
+
+func testV1InferencePool(name string, selector map[string]string) *infextv1.InferencePool {
+	pool := &infextv1.InferencePool{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"}}
+	pool.Spec.Selector.MatchLabels = map[infextv1.LabelKey]infextv1.LabelValue{}
+	for key, value := range selector {
+		pool.Spec.Selector.MatchLabels[infextv1.LabelKey(key)] = infextv1.LabelValue(value)
+	}
+	return pool
+}
+
+// newPoolClient returns a fake InferencePool client, without field management since the fake
+// clientset has no schema of the InferencePools
+func newPoolClient(pools ...runtime.Object) *poolfake.Clientset {
+	return poolfake.NewSimpleClientset(pools...)
+}
+
+func testPod(name string, app string, podIP string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace", Labels: map[string]string{"app": app, "tier": "inference"}},
+		Status:     corev1.PodStatus{PodIP: podIP},
+	}
+}
+
 var _ = Describe("AllowlistValidator", func() {
 	Context("when SSRF protection is disabled", func() {
 		var validator *AllowlistValidator
@@ -89,4 +142,433 @@ var _ = Describe("AllowlistValidator", func() {
 			Expect(normalized).To(Equal("::1"))
 		})
 	})
+
+	Context("until the allowlist is synced", func() {
+		var validator *AllowlistValidator
+
+		BeforeEach(func() {
+			validator = &AllowlistValidator{
+				enabled:        true,
+				namespace:      "test-namespace",
+				allowedTargets: set.New[string](),
+			}
+		})
+
+		It("should not be ready before its informers synced", func() {
+			Expect(validator.IsReady()).To(BeFalse())
+			Expect(validator.WaitReady(context.Background(), 100*time.Millisecond)).To(BeFalse())
+
+			validator.ready.Store(true)
+			Expect(validator.IsReady()).To(BeTrue())
+			Expect(validator.WaitReady(context.Background(), time.Hour)).To(BeTrue())
+		})
+
+		It("should always be ready when disabled", func() {
+			disabled, err := NewAllowlistValidator(false, "test-namespace", "test-pool")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disabled.IsReady()).To(BeTrue())
+		})
+
+		It("should fail the disaggregated requests closed", func() {
+			decodeHandler := &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+			decodeBackend := httptest.NewServer(decodeHandler)
+			DeferCleanup(decodeBackend.Close)
+			decodeURL, err := url.Parse(decodeBackend.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, AllowlistReadyWait: 100 * time.Millisecond})
+			proxy.allowlistValidator = validator
+			server := httptest.NewServer(proxy.createRoutes())
+			DeferCleanup(server.Close)
+
+			sendCompletion := func(prefillHostPort string) (int, errorResponse) {
+				req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+				Expect(err).ToNot(HaveOccurred())
+				if prefillHostPort != "" {
+					req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close() //nolint:all
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				var response errorResponse
+				_ = json.Unmarshal(body, &response) //nolint:all
+				return resp.StatusCode, response
+			}
+
+			code, response := sendCompletion("10.244.1.100:8000")
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(response.Type).To(Equal("AllowlistNotReadyError"))
+
+			// the requests without disaggregation do not depend on the allowlist
+			code, _ = sendCompletion("")
+			Expect(code).To(Equal(http.StatusOK))
+
+			resp, err := http.Get(server.URL + MetricsPath)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close() //nolint:all
+			metrics, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(metrics)).To(ContainSubstring("llm_d_sidecar_allowlist_not_ready_rejections_total 1"))
+		})
+	})
+
+	Context("with a fake Kubernetes client", func() {
+		var (
+			poolClient *poolfake.Clientset
+			client     *fake.Clientset
+			validator  *AllowlistValidator
+		)
+
+		BeforeEach(func() {
+			poolClient = newPoolClient(testInferencePool("test-pool", map[string]string{"app": "vllm", "tier": "inference"}))
+			client = fake.NewClientset(
+				testPod("vllm-0", "vllm", "10.244.1.1"),
+				testPod("vllm-1", "vllm", "10.244.1.2"),
+				testPod("other-0", "other", "10.244.2.1"),
+			)
+			validator = NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "test-pool")
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+		})
+
+		It("should allow the pods of the InferencePool", func() {
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.2:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("vllm-0:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("10.244.2.1:8000")).To(BeFalse())
+			Expect(validator.IsAllowed("other-0:8000")).To(BeFalse())
+		})
+
+		It("should follow the pods added and deleted", func() {
+			ctx := context.Background()
+			pods := client.CoreV1().Pods("test-namespace")
+
+			_, err := pods.Create(ctx, testPod("vllm-2", "vllm", "10.244.1.3"), metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.3:8000") }).Should(BeTrue())
+
+			Expect(pods.Delete(ctx, "vllm-0", metav1.DeleteOptions{})).To(Succeed())
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeFalse())
+			Expect(validator.IsAllowed("10.244.1.2:8000")).To(BeTrue())
+		})
+
+		It("should only allow the pods matching all the labels of the selector", func() {
+			pod := testPod("vllm-training-0", "vllm", "10.244.3.1")
+			pod.Labels["tier"] = "training"
+			_, err := client.CoreV1().Pods("test-namespace").Create(context.Background(), pod, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() []string { return validator.podInformer.GetStore().ListKeys() }).Should(ContainElement("test-namespace/vllm-training-0"))
+			Expect(validator.IsAllowed("10.244.3.1:8000")).To(BeFalse())
+		})
+
+		It("should only list and watch the running pods", func() {
+			Expect(client.Actions()).To(ContainElement(WithTransform(func(action clienttesting.Action) string {
+				if list, ok := action.(clienttesting.ListAction); ok && list.GetResource().Resource == "pods" {
+					return list.GetListRestrictions().Fields.String()
+				}
+				return ""
+			}, Equal("status.phase=Running"))))
+		})
+
+		It("should only store the fields of the pods used by the allowlist", func() {
+			pod := testPod("vllm-0", "vllm", "10.244.1.1")
+			pod.Spec.Containers = []corev1.Container{{Name: "vllm", Image: "vllm"}}
+			pod.Annotations = map[string]string{"large": "annotation"}
+
+			trimmed, err := trimPod(pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(trimmed).To(Equal(testPod("vllm-0", "vllm", "10.244.1.1")))
+		})
+
+		It("should follow the selector of the InferencePool", func() {
+			_, err := poolClient.XInferenceV1alpha2().InferencePools("test-namespace").Update(context.Background(),
+				testInferencePool("test-pool", map[string]string{"app": "other", "tier": "inference"}), metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.2.1:8000") }).Should(BeTrue())
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeFalse())
+		})
+
+		It("should remove the pods of a deleted InferencePool", func() {
+			Expect(poolClient.XInferenceV1alpha2().InferencePools("test-namespace").Delete(context.Background(),
+				"test-pool", metav1.DeleteOptions{})).To(Succeed())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeFalse())
+			Expect(validator.IsAllowed("10.244.1.2:8000")).To(BeFalse())
+		})
+	})
+
+	Context("with a v1 InferencePool", func() {
+		It("should allow the pods matching the labels of its label selector", func() {
+			poolClient := newPoolClient(testV1InferencePool("test-pool", map[string]string{"app": "vllm"}))
+			client := fake.NewClientset(testPod("vllm-0", "vllm", "10.244.1.1"), testPod("other-0", "other", "10.244.2.1"))
+
+			validator := NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "test-pool").
+				WithPoolGroup(InferencePoolGroup)
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeTrue())
+			Expect(validator.IsAllowed("10.244.2.1:8000")).To(BeFalse())
+		})
+	})
+
+	Context("with the target ports enforced", func() {
+		It("should only allow the target ports of the InferencePool", func() {
+			pool := testV1InferencePool("test-pool", map[string]string{"app": "vllm"})
+			pool.Spec.TargetPorts = []infextv1.Port{{Number: 8000}, {Number: 8001}}
+			poolClient := newPoolClient(pool)
+			client := fake.NewClientset(testPod("vllm-0", "vllm", "10.244.1.1"))
+
+			validator := NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "test-pool").
+				WithPoolGroup(InferencePoolGroup).WithPortEnforcement(true)
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.1:8001")).To(BeTrue())
+			Expect(validator.IsAllowed("vllm-0:8001")).To(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.1:9090")).To(BeFalse())
+			Expect(validator.IsAllowed("10.244.1.1")).To(BeFalse())
+			_, reason := validator.explain("10.244.1.1:9090")
+			Expect(reason).To(Equal("the host 10.244.1.1 is in the allowlist, but 10.244.1.1:9090 is not a target port of its InferencePool"))
+
+			pool.Spec.TargetPorts = []infextv1.Port{{Number: 9090}}
+			_, err := poolClient.InferenceV1().InferencePools("test-namespace").Update(context.Background(), pool, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:9090") }).Should(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeFalse())
+		})
+
+		It("should only allow the target port of a v1alpha2 InferencePool", func() {
+			pool := testInferencePool("test-pool", map[string]string{"app": "vllm"})
+			pool.Spec.TargetPortNumber = 8000
+			client := fake.NewClientset(testPod("vllm-0", "vllm", "10.244.1.1"))
+
+			validator := NewAllowlistValidatorWithClients(newPoolClient(pool), client, "test-namespace", "test-pool").
+				WithPortEnforcement(true)
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.1:8001")).To(BeFalse())
+		})
+	})
+
+	Context("with the API group of the InferencePools detected", func() {
+		detectGroup := func(servedGroups []string, pools ...runtime.Object) (string, error) {
+			client := fake.NewClientset()
+			discovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+			for _, group := range servedGroups {
+				discovery.Resources = append(discovery.Resources,
+					&metav1.APIResourceList{GroupVersion: inferencePoolGroupVersion(group),
+						APIResources: []metav1.APIResource{{Name: inferencePoolResource, Namespaced: true, Kind: "InferencePool"}}})
+			}
+			validator := NewAllowlistValidatorWithClients(newPoolClient(pools...), client, "test-namespace", "test-pool").
+				WithPoolGroup(InferencePoolAutoGroup)
+			if err := validator.Start(context.Background()); err != nil {
+				return "", err
+			}
+			DeferCleanup(validator.Stop)
+			return validator.poolGroup, nil
+		}
+
+		It("should watch the only InferencePool API group served", func() {
+			Expect(detectGroup([]string{InferencePoolAlphaGroup}, testInferencePool("test-pool", map[string]string{"app": "vllm"}))).
+				To(Equal(InferencePoolAlphaGroup))
+			Expect(detectGroup([]string{InferencePoolGroup}, testV1InferencePool("test-pool", map[string]string{"app": "vllm"}))).To(Equal(InferencePoolGroup))
+		})
+
+		It("should watch the v1 InferencePools when both groups are served and the pool is a v1 one", func() {
+			Expect(detectGroup([]string{InferencePoolGroup, InferencePoolAlphaGroup}, testV1InferencePool("test-pool", map[string]string{"app": "vllm"}))).
+				To(Equal(InferencePoolGroup))
+			Expect(detectGroup([]string{InferencePoolGroup, InferencePoolAlphaGroup}, testV1InferencePool("other-pool", map[string]string{"app": "vllm"}),
+				testInferencePool("test-pool", map[string]string{"app": "vllm"}))).To(Equal(InferencePoolAlphaGroup))
+		})
+
+		It("should fail when no InferencePool API group is served", func() {
+			_, err := detectGroup(nil)
+			Expect(err).To(MatchError(ContainSubstring("check that the InferencePool CRDs are installed")))
+		})
+	})
+
+	Context("with several InferencePools", func() {
+		var (
+			poolClient *poolfake.Clientset
+			client     *fake.Clientset
+		)
+
+		BeforeEach(func() {
+			shared := testInferencePool("prefill-pool", map[string]string{"app": "prefill"})
+			shared.SetLabels(map[string]string{"sidecar": "shared"})
+			decode := testInferencePool("decode-pool", map[string]string{"app": "decode"})
+			decode.SetLabels(map[string]string{"sidecar": "shared"})
+			poolClient = newPoolClient(shared, decode, testInferencePool("other-pool", map[string]string{"app": "other"}))
+			client = fake.NewClientset(
+				testPod("prefill-0", "prefill", "10.244.1.1"),
+				testPod("decode-0", "decode", "10.244.2.1"),
+				testPod("other-0", "other", "10.244.3.1"),
+			)
+		})
+
+		expectAllowed := func(validator *AllowlistValidator) {
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeTrue())
+			Eventually(func() bool { return validator.IsAllowed("10.244.2.1:8000") }).Should(BeTrue())
+			Expect(validator.IsAllowed("10.244.3.1:8000")).To(BeFalse())
+		}
+
+		It("should allow the pods of all the listed InferencePools", func() {
+			expectAllowed(NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "prefill-pool, decode-pool"))
+		})
+
+		It("should allow the pods of all the InferencePools matching the label selector", func() {
+			validator := NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "").WithPoolSelector("sidecar=shared")
+			expectAllowed(validator)
+			Expect(validator.state().PoolSelector).To(Equal("sidecar=shared"))
+		})
+
+		It("should remove the pods of one of the InferencePools when it is deleted", func() {
+			validator := NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "prefill-pool,decode-pool")
+			expectAllowed(validator)
+
+			Expect(poolClient.XInferenceV1alpha2().InferencePools("test-namespace").Delete(context.Background(),
+				"decode-pool", metav1.DeleteOptions{})).To(Succeed())
+			Eventually(func() bool { return validator.IsAllowed("10.244.2.1:8000") }).Should(BeFalse())
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeTrue())
+		})
+
+		It("should reject an invalid label selector", func() {
+			validator := NewAllowlistValidatorWithClients(poolClient, client, "test-namespace", "").WithPoolSelector("sidecar in (")
+			Expect(validator.Start(context.Background())).ToNot(Succeed())
+		})
+	})
+
+	Context("with the endpoints published by the EPP", func() {
+		var (
+			client    *fake.Clientset
+			validator *AllowlistValidator
+		)
+		signingKey := []byte("secret")
+
+		publishedConfigMap := func(publishedAt time.Time, key []byte, endpoints ...string) *corev1.ConfigMap {
+			payload, err := json.Marshal(common.PublishedEndpoints{Endpoints: endpoints, PublishedAt: publishedAt})
+			Expect(err).ToNot(HaveOccurred())
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "endpoints", Namespace: "test-namespace"},
+				Data: map[string]string{
+					common.PublishedEndpointsKey: string(payload),
+					common.PublishedSignatureKey: common.SignEndpoints(payload, key),
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			client = fake.NewClientset(publishedConfigMap(time.Now(), signingKey, "10.244.1.1", "vllm-0"))
+			validator = NewPublishedAllowlistValidatorWithClient(client, "test-namespace", "endpoints", signingKey)
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+		})
+
+		It("should allow the published endpoints", func() {
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("vllm-0:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("10.244.2.1:8000")).To(BeFalse())
+		})
+
+		It("should follow the updates of the published endpoints", func() {
+			_, err := client.CoreV1().ConfigMaps("test-namespace").Update(context.Background(),
+				publishedConfigMap(time.Now().Add(time.Second), signingKey, "10.244.1.2"), metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.2:8000") }).Should(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeFalse())
+			_, generation := validator.check("10.244.1.2:8000")
+			Expect(generation).To(BeEquivalentTo(2))
+		})
+
+		It("should ignore the endpoints with an invalid signature or published before the current ones", func() {
+			configMaps := client.CoreV1().ConfigMaps("test-namespace")
+			_, err := configMaps.Update(context.Background(),
+				publishedConfigMap(time.Now().Add(time.Second), []byte("forged"), "10.244.9.9"), metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = configMaps.Update(context.Background(),
+				publishedConfigMap(time.Now().Add(-time.Hour), signingKey, "10.244.9.8"), metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Consistently(func() bool {
+				return validator.IsAllowed("10.244.9.9:8000") || validator.IsAllowed("10.244.9.8:8000")
+			}, 200*time.Millisecond).Should(BeFalse())
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeTrue())
+		})
+	})
+
+	Context("with the EndpointSlices of a Service", func() {
+		var (
+			client    *fake.Clientset
+			validator *AllowlistValidator
+		)
+
+		endpointSlice := func(name string, service string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+			return &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace",
+					Labels: map[string]string{discoveryv1.LabelServiceName: service}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   endpoints,
+			}
+		}
+
+		BeforeEach(func() {
+			client = fake.NewClientset(
+				endpointSlice("prefill-abc", "prefill", discoveryv1.Endpoint{Addresses: []string{"10.244.1.1"},
+					Hostname: ptr.To("prefill-0"), TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "vllm-prefill-0"}}),
+				endpointSlice("prefill-ghi", "prefill",
+					discoveryv1.Endpoint{Addresses: []string{"10.244.1.3"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)},
+						TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "vllm-prefill-3"}},
+					discoveryv1.Endpoint{Addresses: []string{"10.244.1.4"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}}),
+				endpointSlice("other-abc", "other", discoveryv1.Endpoint{Addresses: []string{"10.244.2.1"}}),
+			)
+			validator = NewEndpointSliceAllowlistValidatorWithClient(client, "test-namespace", "prefill")
+			Expect(validator.Start(context.Background())).To(Succeed())
+			DeferCleanup(validator.Stop)
+			Eventually(validator.IsReady).Should(BeTrue())
+		})
+
+		It("should allow the addresses, hostnames and pods of the endpoints of the Service", func() {
+			Expect(validator.IsAllowed("10.244.1.1:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("prefill-0:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("vllm-prefill-0:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("10.244.2.1:8000")).To(BeFalse())
+			Expect(validator.state().Service).To(Equal("prefill"))
+		})
+
+		It("should skip the endpoints not ready", func() {
+			Expect(validator.IsAllowed("10.244.1.4:8000")).To(BeTrue())
+			Expect(validator.IsAllowed("10.244.1.3:8000")).To(BeFalse())
+			Expect(validator.IsAllowed("vllm-prefill-3:8000")).To(BeFalse())
+		})
+
+		It("should follow the changes of the EndpointSlices", func() {
+			slices := client.DiscoveryV1().EndpointSlices("test-namespace")
+			_, err := slices.Create(context.Background(),
+				endpointSlice("prefill-def", "prefill", discoveryv1.Endpoint{Addresses: []string{"10.244.1.2"}}), metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.2:8000") }).Should(BeTrue())
+
+			Expect(slices.Delete(context.Background(), "prefill-abc", metav1.DeleteOptions{})).To(Succeed())
+			Eventually(func() bool { return validator.IsAllowed("10.244.1.1:8000") }).Should(BeFalse())
+			Expect(validator.IsAllowed("10.244.1.2:8000")).To(BeTrue())
+		})
+	})
 })
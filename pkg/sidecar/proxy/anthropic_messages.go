@@ -0,0 +1,450 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MessagesPath is the Anthropic Messages API path
+const MessagesPath = "/v1/messages"
+
+// anthropicMessagesRequest is the subset of the Anthropic Messages API requests translated to chat completions
+type anthropicMessagesRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        json.RawMessage    `json:"system"`
+	Messages      []anthropicMessage `json:"messages"`
+	StopSequences []string           `json:"stop_sequences"`
+	Stream        bool               `json:"stream"`
+	Temperature   *float64           `json:"temperature"`
+	TopP          *float64           `json:"top_p"`
+	TopK          *int               `json:"top_k"`
+}
+
+// anthropicMessage is a message of an Anthropic Messages API request, whose content is either a
+// string or a list of content blocks
+type anthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// anthropicContentBlock is a content block of an Anthropic message, only the text blocks are supported
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// chatCompletionChoice is the subset of a chat completion choice, or of a streamed chunk choice,
+// translated to an Anthropic message
+type chatCompletionChoice struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Delta struct {
+		Content string `json:"content"`
+	} `json:"delta"`
+	FinishReason string `json:"finish_reason"`
+	StopReason   any    `json:"stop_reason"` // the matched stop string or token id of vLLM
+}
+
+// chatCompletionResponse is the subset of a chat completion response, or of a streamed chunk,
+// translated to an Anthropic message
+type chatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+	Usage   *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicUsage is the token usage of an Anthropic message
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicMessageResponse is an Anthropic Messages API response
+type anthropicMessageResponse struct {
+	ID           string                  `json:"id"`
+	Type         string                  `json:"type"`
+	Role         string                  `json:"role"`
+	Model        string                  `json:"model"`
+	Content      []anthropicContentBlock `json:"content"`
+	StopReason   *string                 `json:"stop_reason"`
+	StopSequence *string                 `json:"stop_sequence"`
+	Usage        anthropicUsage          `json:"usage"`
+}
+
+// messagesHandler serves the Anthropic Messages API requests: they are translated to chat completions,
+// served through the P/D pipeline, and their responses translated back
+func (s *Server) messagesHandler(w http.ResponseWriter, r *http.Request) {
+	mw := newMessagesResponseWriter(w)
+	defer mw.finish()
+
+	if !s.limitRequestBody(mw, r) {
+		return
+	}
+	body, ok := s.readRequestBody(mw, r)
+	if !ok {
+		return
+	}
+	chatRequest, err := translateMessagesRequest(body)
+	if err != nil {
+		s.replyError(mw, badRequestError(err))
+		return
+	}
+
+	r = r.Clone(r.Context())
+	r.URL.Path = ChatCompletionsPath
+	r.Body = io.NopCloser(bytes.NewReader(chatRequest))
+	r.ContentLength = int64(len(chatRequest))
+	r.TransferEncoding = nil
+	s.chatCompletionsHandler(mw, r)
+}
+
+// translateMessagesRequest translates an Anthropic Messages API request to a chat completion request
+func translateMessagesRequest(body []byte) ([]byte, error) {
+	var request anthropicMessagesRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	if request.Model == "" {
+		return nil, errors.New("model: field required")
+	}
+	if request.MaxTokens <= 0 {
+		return nil, errors.New("max_tokens: must be greater than 0")
+	}
+	if len(request.Messages) == 0 {
+		return nil, errors.New("messages: at least one message is required")
+	}
+
+	messages := make([]map[string]string, 0, len(request.Messages)+1)
+	if len(request.System) > 0 && string(request.System) != "null" {
+		system, err := anthropicText(request.System)
+		if err != nil {
+			return nil, fmt.Errorf("system: %w", err)
+		}
+		messages = append(messages, map[string]string{"role": "system", "content": system})
+	}
+	for i, message := range request.Messages {
+		if message.Role != "user" && message.Role != "assistant" {
+			return nil, fmt.Errorf("messages.%d.role: must be 'user' or 'assistant', got '%s'", i, message.Role)
+		}
+		content, err := anthropicText(message.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages.%d.content: %w", i, err)
+		}
+		messages = append(messages, map[string]string{"role": message.Role, "content": content})
+	}
+
+	chatRequest := map[string]any{
+		"model":               request.Model,
+		"messages":            messages,
+		requestFieldMaxTokens: request.MaxTokens,
+		requestFieldStream:    request.Stream,
+	}
+	if request.Stream {
+		chatRequest[requestFieldStreamOptions] = map[string]any{"include_usage": true}
+	}
+	if request.Temperature != nil {
+		chatRequest["temperature"] = *request.Temperature
+	}
+	if request.TopP != nil {
+		chatRequest["top_p"] = *request.TopP
+	}
+	if request.TopK != nil {
+		chatRequest["top_k"] = *request.TopK
+	}
+	if len(request.StopSequences) > 0 {
+		chatRequest["stop"] = request.StopSequences
+	}
+	return encodeJSON(chatRequest)
+}
+
+// anthropicText returns the text of an Anthropic content, either a string or a list of text blocks
+func anthropicText(content json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text, nil
+	}
+
+	var blocks []anthropicContentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return "", errors.New("must be a string or a list of content blocks")
+	}
+	var builder strings.Builder
+	for _, block := range blocks {
+		if block.Type != "text" {
+			return "", fmt.Errorf("unsupported content block type '%s', only text blocks are supported", block.Type)
+		}
+		builder.WriteString(block.Text)
+	}
+	return builder.String(), nil
+}
+
+// anthropicStopReason returns the Anthropic stop reason and stop sequence of a chat completion choice
+func anthropicStopReason(choice chatCompletionChoice) (string, *string) {
+	switch {
+	case choice.FinishReason == "length":
+		return "max_tokens", nil
+	case choice.FinishReason == "tool_calls":
+		return "tool_use", nil
+	default:
+		if sequence, ok := choice.StopReason.(string); ok {
+			return "stop_sequence", &sequence
+		}
+		return "end_turn", nil
+	}
+}
+
+// anthropicMessageID returns the ID of the Anthropic message of a chat completion
+func anthropicMessageID(id string) string {
+	return "msg_" + strings.TrimPrefix(id, "chatcmpl-")
+}
+
+// anthropicErrorType returns the Anthropic error type of a status code
+func anthropicErrorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable:
+		return "overloaded_error"
+	default:
+		if statusCode < http.StatusInternalServerError {
+			return "invalid_request_error"
+		}
+		return "api_error"
+	}
+}
+
+// translateChatCompletionResponse translates a chat completion response, or an error response, to the
+// Anthropic Messages API. The responses which cannot be parsed are returned as is.
+func translateChatCompletionResponse(statusCode int, body []byte) []byte {
+	var translated any
+	if statusCode >= http.StatusBadRequest {
+		var response errorResponse
+		if err := json.Unmarshal(body, &response); err != nil || response.Message == "" {
+			response.Message = strings.TrimSpace(string(body))
+		}
+		translated = map[string]any{
+			"type":  "error",
+			"error": map[string]string{"type": anthropicErrorType(statusCode), "message": response.Message},
+		}
+	} else {
+		var response chatCompletionResponse
+		if err := json.Unmarshal(body, &response); err != nil || len(response.Choices) == 0 {
+			return body
+		}
+		stopReason, stopSequence := anthropicStopReason(response.Choices[0])
+		message := anthropicMessageResponse{
+			ID:           anthropicMessageID(response.ID),
+			Type:         "message",
+			Role:         "assistant",
+			Model:        response.Model,
+			Content:      []anthropicContentBlock{{Type: "text", Text: response.Choices[0].Message.Content}},
+			StopReason:   &stopReason,
+			StopSequence: stopSequence,
+		}
+		if response.Usage != nil {
+			message.Usage = anthropicUsage{InputTokens: response.Usage.PromptTokens, OutputTokens: response.Usage.CompletionTokens}
+		}
+		translated = message
+	}
+
+	encoded, err := encodeJSON(translated)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
+
+// messagesResponseWriter translates the chat completion responses written to it to the Anthropic
+// Messages API. The JSON responses are buffered and translated once complete, the server-sent events
+// of the streamed responses are translated line by line.
+type messagesResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+	streaming  bool
+	buffer     bytes.Buffer // the JSON response, or the incomplete line of the event stream
+
+	started      bool // whether the message_start event was sent
+	stopped      bool // whether the message_stop event was sent
+	stopReason   string
+	stopSequence *string
+	outputTokens int
+}
+
+func newMessagesResponseWriter(w http.ResponseWriter) *messagesResponseWriter {
+	return &messagesResponseWriter{ResponseWriter: w}
+}
+
+func (w *messagesResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode != 0 {
+		return
+	}
+	w.statusCode = statusCode
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")) //nolint:all
+	if statusCode < http.StatusBadRequest && mediaType == "text/event-stream" {
+		w.streaming = true
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (w *messagesResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.buffer.Write(b)
+	if !w.streaming {
+		return len(b), nil
+	}
+
+	for {
+		line, err := w.buffer.ReadBytes('\n')
+		if err != nil {
+			w.buffer.Write(line) // the incomplete line waits for the next write
+			return len(b), nil
+		}
+		if err := w.translateEventLine(line); err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// Flush flushes the translated events of the streamed responses
+func (w *messagesResponseWriter) Flush() {
+	if w.streaming {
+		http.NewResponseController(w.ResponseWriter).Flush() //nolint:all
+	}
+}
+
+// Unwrap lets http.ResponseController set the deadlines of the response
+func (w *messagesResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the translated JSON response
+func (w *messagesResponseWriter) finish() {
+	if w.streaming {
+		return
+	}
+	statusCode := w.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	body := translateChatCompletionResponse(statusCode, w.buffer.Bytes())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(statusCode)
+	w.ResponseWriter.Write(body) //nolint:all
+}
+
+// translateEventLine translates a line of the chat completion event stream to the Anthropic events
+func (w *messagesResponseWriter) translateEventLine(line []byte) error {
+	line = bytes.TrimRight(line, "\r\n")
+	if !bytes.HasPrefix(line, sseDataPrefix) {
+		return nil
+	}
+	data := bytes.TrimPrefix(line[len(sseDataPrefix):], []byte(" "))
+	if bytes.Equal(data, sseDone) {
+		return w.stop()
+	}
+
+	var chunk chatCompletionResponse
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return nil // not a chunk, e.g. a keep-alive
+	}
+	if !w.started {
+		w.started = true
+		if err := w.writeEvent("message_start", map[string]any{"type": "message_start", "message": anthropicMessageResponse{
+			ID: anthropicMessageID(chunk.ID), Type: "message", Role: "assistant", Model: chunk.Model,
+			Content: []anthropicContentBlock{}}}); err != nil {
+			return err
+		}
+		if err := w.writeEvent("content_block_start", map[string]any{"type": "content_block_start", "index": 0,
+			"content_block": anthropicContentBlock{Type: "text"}}); err != nil {
+			return err
+		}
+	}
+
+	if chunk.Usage != nil {
+		w.outputTokens = chunk.Usage.CompletionTokens
+	}
+	for _, choice := range chunk.Choices {
+		if choice.Delta.Content != "" {
+			if err := w.writeEvent("content_block_delta", map[string]any{"type": "content_block_delta", "index": 0,
+				"delta": map[string]string{"type": "text_delta", "text": choice.Delta.Content}}); err != nil {
+				return err
+			}
+		}
+		if choice.FinishReason != "" {
+			w.stopReason, w.stopSequence = anthropicStopReason(choice)
+		}
+	}
+	return nil
+}
+
+// stop sends the events closing the message, once the chat completion event stream is done
+func (w *messagesResponseWriter) stop() error {
+	if !w.started || w.stopped {
+		return nil
+	}
+	w.stopped = true
+	if err := w.writeEvent("content_block_stop", map[string]any{"type": "content_block_stop", "index": 0}); err != nil {
+		return err
+	}
+	if err := w.writeEvent("message_delta", map[string]any{"type": "message_delta",
+		"delta": map[string]any{"stop_reason": w.stopReason, "stop_sequence": w.stopSequence},
+		"usage": map[string]int{"output_tokens": w.outputTokens}}); err != nil {
+		return err
+	}
+	return w.writeEvent("message_stop", map[string]any{"type": "message_stop"})
+}
+
+// writeEvent writes an Anthropic server-sent event
+func (w *messagesResponseWriter) writeEvent(event string, data any) error {
+	encoded, err := encodeJSON(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w.ResponseWriter, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Anthropic Messages API", func() {
+	var (
+		server        *httptest.Server
+		chatRequests  []map[string]any
+		enableRoute   bool
+		decodeBackend *httptest.Server
+	)
+
+	BeforeEach(func() {
+		chatRequests = nil
+		enableRoute = true
+		decodeBackend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != ChatCompletionsPath { // e.g. a vLLM without the Anthropic Messages API
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var request map[string]any
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			chatRequests = append(chatRequests, request)
+
+			if request["stream"] == true {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, chunk := range []string{
+					`{"id":"chatcmpl-1","model":"m","choices":[{"delta":{"role":"assistant","content":"Hel"}}]}`,
+					`{"id":"chatcmpl-1","model":"m","choices":[{"delta":{"content":"lo"},"finish_reason":"length"}]}`,
+					`{"id":"chatcmpl-1","model":"m","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2}}`,
+					`[DONE]`,
+				} {
+					_, _ = w.Write([]byte("data: " + chunk + "\n\n")) //nolint:all
+				}
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-1","model":"m","choices":[{"message":{"role":"assistant","content":"Hello"},` + //nolint:all
+				`"finish_reason":"stop","stop_reason":"END"}],"usage":{"prompt_tokens":5,"completion_tokens":2}}`))
+		}))
+		DeferCleanup(decodeBackend.Close)
+	})
+
+	JustBeforeEach(func() {
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, AnthropicMessages: enableRoute})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendMessages := func(body string) (*http.Response, string) {
+		resp, err := http.Post(server.URL+MessagesPath, "application/json", strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		respBody, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp, string(respBody)
+	}
+
+	It("should translate the requests and the responses", func() {
+		resp, body := sendMessages(`{"model":"m","max_tokens":64,"system":"Be brief.","stop_sequences":["END"],` +
+			`"messages":[{"role":"user","content":[{"type":"text","text":"Hi"}]}],"temperature":0.5}`)
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(chatRequests).To(HaveLen(1))
+		Expect(chatRequests[0]).To(And(
+			HaveKeyWithValue("model", "m"),
+			HaveKeyWithValue("max_tokens", BeNumerically("==", 64)),
+			HaveKeyWithValue("temperature", BeNumerically("==", 0.5)),
+			HaveKeyWithValue("stop", []any{"END"}),
+			HaveKeyWithValue("messages", []any{
+				map[string]any{"role": "system", "content": "Be brief."},
+				map[string]any{"role": "user", "content": "Hi"},
+			}),
+		))
+
+		var message anthropicMessageResponse
+		Expect(json.Unmarshal([]byte(body), &message)).To(Succeed())
+		stopReason, stopSequence := "stop_sequence", "END"
+		Expect(message).To(Equal(anthropicMessageResponse{
+			ID: "msg_1", Type: "message", Role: "assistant", Model: "m",
+			Content:    []anthropicContentBlock{{Type: "text", Text: "Hello"}},
+			StopReason: &stopReason, StopSequence: &stopSequence,
+			Usage: anthropicUsage{InputTokens: 5, OutputTokens: 2},
+		}))
+	})
+
+	It("should translate the streamed responses to the Anthropic events", func() {
+		resp, body := sendMessages(`{"model":"m","max_tokens":2,"stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+		Expect(chatRequests[0]).To(HaveKeyWithValue("stream_options", map[string]any{"include_usage": true}))
+
+		var events []string
+		for _, line := range strings.Split(body, "\n") {
+			if event, ok := strings.CutPrefix(line, "event: "); ok {
+				events = append(events, event)
+			}
+		}
+		Expect(events).To(Equal([]string{"message_start", "content_block_start", "content_block_delta",
+			"content_block_delta", "content_block_stop", "message_delta", "message_stop"}))
+		Expect(body).To(ContainSubstring(`"delta":{"text":"Hel","type":"text_delta"}`))
+		Expect(body).To(ContainSubstring(`"delta":{"stop_reason":"max_tokens","stop_sequence":null},"type":"message_delta","usage":{"output_tokens":2}`))
+	})
+
+	It("should reply the errors in the Anthropic format", func() {
+		resp, body := sendMessages(`{"model":"m","max_tokens":8,"messages":[{"role":"user","content":[{"type":"image"}]}]}`)
+
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(chatRequests).To(BeEmpty())
+		Expect(body).To(MatchJSON(`{"type":"error","error":{"type":"invalid_request_error",` +
+			`"message":"messages.0.content: unsupported content block type 'image', only text blocks are supported"}}`))
+	})
+
+	When("the Anthropic Messages API is disabled", func() {
+		BeforeEach(func() {
+			enableRoute = false
+		})
+
+		It("should forward its requests to the decoder as is", func() {
+			resp, _ := sendMessages(`{"model":"m","max_tokens":8,"messages":[{"role":"user","content":"Hi"}]}`)
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(chatRequests).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultCertReloadInterval is the default interval the files of the listener certificate are checked
+// for changes at
+const DefaultCertReloadInterval = time.Minute
+
+// certificateStore serves the certificate of the listeners of all the data parallel ranks, reloaded
+// from its files when they change, e.g. when the Secret mounted in the pod is renewed, so that the
+// listeners do not need a restart
+type certificateStore struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+
+	mutex     sync.Mutex
+	loadedPEM []byte // the certificate and the key loaded, to detect their changes
+}
+
+// newCertificateStore returns a store serving the given certificate, loaded from the tls.crt and tls.key
+// files of the given directory when set
+func newCertificateStore(cert *tls.Certificate, certPath string) *certificateStore {
+	store := &certificateStore{}
+	store.cert.Store(cert)
+	if certPath != "" {
+		store.certFile, store.keyFile = filepath.Join(certPath, "tls.crt"), filepath.Join(certPath, "tls.key")
+		store.loadedPEM, _ = store.readPEM() // the certificate was just loaded from these files
+	}
+	return store
+}
+
+// getCertificate returns the current certificate, for tls.Config.GetCertificate
+func (c *certificateStore) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// run reloads the certificate on each interval until the context is done
+func (c *certificateStore) run(ctx context.Context, interval time.Duration, logger logr.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := c.reload()
+			if err != nil {
+				logger.Error(err, "failed to reload the listener certificate, keeping the current one")
+			} else if reloaded {
+				logger.Info("reloaded the listener certificate", "certFile", c.certFile)
+			}
+		}
+	}
+}
+
+// reload loads the certificate from its files when they changed, and returns whether it did. The
+// current certificate is kept when the files are invalid, e.g. while they are being written.
+func (c *certificateStore) reload() (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	loadedPEM, err := c.readPEM()
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(loadedPEM, c.loadedPEM) {
+		return false, nil
+	}
+
+	certPEM, keyPEM, _ := bytes.Cut(loadedPEM, []byte{0})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("invalid certificate in %s: %w", c.certFile, err)
+	}
+	c.cert.Store(&cert)
+	c.loadedPEM = loadedPEM
+	return true, nil
+}
+
+// readPEM returns the content of the certificate and key files, separated by a zero byte
+func (c *certificateStore) readPEM() ([]byte, error) {
+	certPEM, err := os.ReadFile(c.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(certPEM, 0), keyPEM...), nil
+}
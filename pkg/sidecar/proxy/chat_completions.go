@@ -18,6 +18,8 @@ package proxy
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
 )
@@ -28,31 +30,160 @@ var (
 
 	// CompletionsPath is the legacy completions path
 	CompletionsPath = "/v1/completions"
+
+	// ResponsesPath is the OpenAI Responses API path
+	ResponsesPath = "/v1/responses"
+
+	// DefaultInterceptPaths are the paths whose requests are served through the P/D protocol by default
+	DefaultInterceptPaths = []string{ChatCompletionsPath, CompletionsPath, ResponsesPath}
 )
 
+// legacyPrefillURLPrefix prefixes the prefill targets of the legacy form of the prefill pod header, URLs
+// instead of <host:port>
+const legacyPrefillURLPrefix = "http://"
+
+// isLegacyPrefillTarget returns true when the prefill target has the legacy URL form
+func isLegacyPrefillTarget(target string) bool {
+	return strings.HasPrefix(target, legacyPrefillURLPrefix)
+}
+
 func (s *Server) chatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
+	r = extractTraceContext(r)
+
+	if !s.limitRequestBody(w, r) {
+		return
+	}
+
+	if s.tokenRates != nil && !s.limitTokenRate(w, r) {
+		return
+	}
+
+	if s.concurrency != nil {
+		release, ok := s.acquireConcurrencyLimit(w, r)
+		if !ok {
+			return
+		}
+		defer release()
+	}
+
+	if s.tenantQuotas != nil {
+		release, ok := s.acquireTenantQuota(w, r)
+		if !ok {
+			return
+		}
+		defer release()
+	}
+
+	if s.modelValidator != nil && !s.validateModel(w, r) {
+		return
+	}
+
+	if s.shadow != nil && !s.mirrorRequest(w, r) {
+		return
+	}
+
 	prefillPodHostPort := r.Header.Get(common.PrefillPodHeader)
+	if entry := accessLogFromContext(r.Context()); entry != nil {
+		entry.PrefillTarget = prefillPodHostPort
+	}
+
+	prefillTargets := parsePrefillTargets(prefillPodHostPort)
+	if len(prefillTargets) == 0 {
+		s.requestLogger(r).V(4).Info("skip disaggregated prefill")
+
+		s.decode(w, r, connectorNone)
+		return
+	}
+
+	// The disaggregated requests of a data parallel rank are decoded by the rank, check it exists first
+	if !s.checkDataParallelRank(w, r) {
+		return
+	}
+
+	if s.config.PrefillBypassTokens > 0 {
+		bypass, ok := s.bypassPrefill(w, r)
+		if !ok {
+			return
+		}
+		if bypass {
+			s.decode(w, r, connectorNone)
+			return
+		}
+	}
 
-	if prefillPodHostPort == "" {
-		s.logger.V(4).Info("skip disaggregated prefill")
+	if slices.ContainsFunc(prefillTargets, isLegacyPrefillTarget) {
+		s.metrics.observeLegacyPrefillURL()
+		if s.config.DisableLegacyPrefillURLs {
+			s.requestLogger(r).V(4).Info("legacy prefill URL rejected", "targets", prefillTargets)
+			s.replyError(w, badRequestError(errLegacyPrefillURL))
+			return
+		}
+	}
 
-		if s.forwardDataParallel && !s.dataParallelHandler(w, r) {
-			s.decoderProxy.ServeHTTP(w, r)
+	// SSRF Protection: fail closed until the allowlist is synced, it may be missing valid targets,
+	// unless the targets are all in the static allowlist or the policy is fail-open
+	readyWait := s.config.AllowlistReadyWait
+	if readyWait <= 0 {
+		readyWait = DefaultAllowlistReadyWait
+	}
+	if !s.allowlistValidator.staticallyAllowed(prefillTargets) && !s.allowlistValidator.WaitReady(r.Context(), readyWait) &&
+		!s.allowlistValidator.failOpen {
+		s.requestLogger(r).Info("SSRF protection: allowlist not synced yet, failing request", "target", prefillPodHostPort)
+		s.metrics.observeAllowlistNotReady()
+		generation := s.allowlistValidator.currentGeneration()
+		for _, target := range prefillTargets {
+			s.auditSSRF(r, target, false, ssrfReasonNotSynced, generation)
 		}
+		s.replyError(w, allowlistNotReadyError())
 		return
 	}
 
-	// SSRF Protection: Check if the prefill target is allowed
-	if !s.allowlistValidator.IsAllowed(prefillPodHostPort) {
-		s.logger.Error(nil, "SSRF protection: prefill target not in allowlist",
-			"target", prefillPodHostPort,
-			"clientIP", r.RemoteAddr,
-			"userAgent", r.Header.Get("User-Agent"),
-			"requestPath", r.URL.Path)
-		http.Error(w, "Forbidden: prefill target not allowed by SSRF protection", http.StatusForbidden)
+	// SSRF Protection: Check if the prefill targets are allowed, only the allowed ones are tried. The
+	// targets missing from the allowlist while it is pending are handled by the failure policy.
+	allowedTargets := make([]string, 0, len(prefillTargets))
+	pendingTargets := false
+	for _, target := range prefillTargets {
+		allowed, generation := s.allowlistValidator.check(target)
+		if !allowed && s.allowlistValidator.pending() {
+			if s.allowlistValidator.failOpen {
+				s.requestLogger(r).Info("SSRF protection: prefill target not in the pending allowlist, failing open", "target", target)
+				s.metrics.observeAllowlistFailOpen()
+				s.auditSSRF(r, target, true, ssrfReasonFailOpen, generation)
+				allowedTargets = append(allowedTargets, target)
+			} else {
+				s.requestLogger(r).Info("SSRF protection: prefill target not in the allowlist yet, within its grace period", "target", target)
+				s.auditSSRF(r, target, false, ssrfReasonGracePeriod, generation)
+				pendingTargets = true
+			}
+			continue
+		}
+		if !allowed {
+			s.auditSSRF(r, target, false, ssrfReasonNotAllowed, generation)
+			s.metrics.observeSSRFRejection(target)
+			s.requestLogger(r).Error(nil, "SSRF protection: prefill target not in allowlist",
+				"target", target,
+				"clientIP", r.RemoteAddr,
+				"userAgent", r.Header.Get("User-Agent"),
+				"requestPath", r.URL.Path)
+			continue
+		}
+		s.auditSSRF(r, target, true, ssrfReasonAllowed, generation)
+		allowedTargets = append(allowedTargets, target)
+	}
+	if len(allowedTargets) == 0 && pendingTargets {
+		// the targets may be added to the allowlist soon, the request may be retried
+		s.metrics.observeAllowlistNotReady()
+		s.replyError(w, allowlistNotReadyError())
+		return
+	}
+	if len(allowedTargets) == 0 {
+		s.replyError(w, ssrfBlockedError())
 		return
 	}
 
-	s.logger.V(4).Info("SSRF protection: prefill target allowed", "target", prefillPodHostPort)
-	s.runConnectorProtocol(w, r, prefillPodHostPort)
+	s.requestLogger(r).V(4).Info("SSRF protection: prefill targets allowed", "targets", allowedTargets)
+	if s.jobPins != nil {
+		allowedTargets = s.jobPins.order(r.Header.Get(s.jobPins.header), allowedTargets)
+	}
+	s.runConnectorProtocol(w, r, allowedTargets)
 }
@@ -0,0 +1,239 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+const (
+	// DefaultCircuitOpenDuration is the default time a circuit stays open before a probe request is let through
+	DefaultCircuitOpenDuration = 30 * time.Second
+
+	// DefaultCircuitBreakerWindow is the default number of recent requests the error rate of a target is computed over
+	DefaultCircuitBreakerWindow = 20
+)
+
+// circuitPolicy defines when the circuits open, and for how long
+type circuitPolicy struct {
+	threshold    int     // consecutive failures opening the circuit, disabled when not positive
+	errorRate    float64 // failure rate over the window opening the circuit, disabled when not positive
+	window       int
+	openDuration time.Duration
+}
+
+// circuitBreaker opens after consecutive failures of a target, or once the failure rate of its recent
+// requests is too high, failing the requests to the target fast. Once open long enough, it lets
+// a single probe request through, and closes when the probe succeeds.
+type circuitBreaker struct {
+	policy circuitPolicy
+
+	mutex    sync.Mutex
+	state    common.CircuitState
+	failures int // consecutive failures
+	openedAt time.Time
+	probing  bool
+
+	outcomes       []bool // the failures of the recent requests, when the error rate is enabled
+	next           int
+	recorded       int
+	recentFailures int
+}
+
+func newCircuitBreaker(policy circuitPolicy) *circuitBreaker {
+	breaker := &circuitBreaker{
+		policy: policy,
+		state:  common.CircuitClosed,
+	}
+	if policy.errorRate > 0 {
+		breaker.outcomes = make([]bool, policy.window)
+	}
+	return breaker
+}
+
+// allow returns whether a request may be sent to the target
+func (c *circuitBreaker) allow() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch c.state {
+	case common.CircuitOpen:
+		if time.Since(c.openedAt) < c.policy.openDuration {
+			return false
+		}
+		c.state = common.CircuitHalfOpen
+		c.probing = true
+		return true
+	case common.CircuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record records the outcome of a request sent to the target
+func (c *circuitBreaker) record(success bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.probing = false
+	if success {
+		if c.state == common.CircuitHalfOpen {
+			c.resetOutcomes()
+		}
+		c.state = common.CircuitClosed
+		c.failures = 0
+		c.recordOutcome(false)
+		return
+	}
+
+	c.failures++
+	c.recordOutcome(true)
+	if c.state == common.CircuitHalfOpen || c.tripped() {
+		c.state = common.CircuitOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// tripped returns whether the recorded failures open the circuit. The error rate only applies
+// once the window is full, so that a few early failures do not open the circuit.
+func (c *circuitBreaker) tripped() bool {
+	if c.policy.threshold > 0 && c.failures >= c.policy.threshold {
+		return true
+	}
+	return c.outcomes != nil && c.recorded == len(c.outcomes) &&
+		float64(c.recentFailures) >= c.policy.errorRate*float64(len(c.outcomes))
+}
+
+func (c *circuitBreaker) recordOutcome(failure bool) {
+	if c.outcomes == nil {
+		return
+	}
+	if c.recorded == len(c.outcomes) && c.outcomes[c.next] {
+		c.recentFailures--
+	} else if c.recorded < len(c.outcomes) {
+		c.recorded++
+	}
+	c.outcomes[c.next] = failure
+	if failure {
+		c.recentFailures++
+	}
+	c.next = (c.next + 1) % len(c.outcomes)
+}
+
+func (c *circuitBreaker) resetOutcomes() {
+	if c.outcomes == nil {
+		return
+	}
+	clear(c.outcomes)
+	c.next, c.recorded, c.recentFailures = 0, 0, 0
+}
+
+func (c *circuitBreaker) currentState() common.CircuitState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state
+}
+
+// circuitBreakers holds the circuit breakers of the local decoder and of the prefill targets
+type circuitBreakers struct {
+	policy circuitPolicy
+
+	decoder *circuitBreaker
+
+	mutex      sync.Mutex
+	prefillers map[string]*circuitBreaker // shared by the servers of all the data parallel ranks
+}
+
+// newCircuitBreakers returns the circuit breakers, or nil when neither the threshold nor the error rate is positive
+func newCircuitBreakers(policy circuitPolicy) *circuitBreakers {
+	if policy.threshold <= 0 && policy.errorRate <= 0 {
+		return nil
+	}
+	if policy.window <= 0 {
+		policy.window = DefaultCircuitBreakerWindow
+	}
+	if policy.openDuration <= 0 {
+		policy.openDuration = DefaultCircuitOpenDuration
+	}
+	return &circuitBreakers{
+		policy:     policy,
+		decoder:    newCircuitBreaker(policy),
+		prefillers: map[string]*circuitBreaker{},
+	}
+}
+
+// forRank returns the circuit breakers of a data parallel rank, with its own decoder circuit
+func (c *circuitBreakers) forRank() *circuitBreakers {
+	if c == nil {
+		return nil
+	}
+	return &circuitBreakers{
+		policy:     c.policy,
+		decoder:    newCircuitBreaker(c.policy),
+		prefillers: c.prefillers,
+	}
+}
+
+// prefiller returns the circuit breaker of a prefill target
+func (c *circuitBreakers) prefiller(hostPort string) *circuitBreaker {
+	hostPort = strings.TrimPrefix(hostPort, legacyPrefillURLPrefix)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	breaker, ok := c.prefillers[hostPort]
+	if !ok {
+		breaker = newCircuitBreaker(c.policy)
+		c.prefillers[hostPort] = breaker
+	}
+	return breaker
+}
+
+// handler reports the state of the circuits
+func (c *circuitBreakers) handler(w http.ResponseWriter, _ *http.Request) {
+	response := common.CircuitsResponse{
+		Decoder:    common.CircuitClosed,
+		Prefillers: map[string]common.CircuitState{},
+	}
+	if c != nil {
+		response.Decoder = c.decoder.currentState()
+		c.mutex.Lock()
+		for hostPort, breaker := range c.prefillers {
+			response.Prefillers[hostPort] = breaker.currentState()
+		}
+		c.mutex.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response) //nolint:all
+}
+
+// isFailure returns whether the response status code of a target counts as a failure.
+// The client errors are failures of the requests, not of the target.
+func isFailure(statusCode int) bool {
+	return statusCode >= http.StatusInternalServerError
+}
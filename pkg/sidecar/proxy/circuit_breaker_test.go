@@ -0,0 +1,163 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Circuit breakers", func() {
+	var (
+		server          *httptest.Server
+		prefillStatus   atomic.Int32
+		prefillRequests atomic.Int32
+		prefillHostPort string
+	)
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillStatus.Store(http.StatusServiceUnavailable)
+		prefillRequests.Store(0)
+		prefillHandler := &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			prefillRequests.Add(1)
+			if status := int(prefillStatus.Load()); status != http.StatusOK {
+				_, _ = io.ReadAll(r.Body) //nolint:all
+				w.WriteHeader(status)
+				return
+			}
+			prefillHandler.ServeHTTP(w, r)
+		}))
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		proxy := NewProxy("0", decodeURL, Config{
+			Connector:               ConnectorNIXLV2,
+			CircuitBreakerThreshold: 2,
+			CircuitOpenDuration:     200 * time.Millisecond,
+		})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendCompletion := func() int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		_, _ = io.ReadAll(resp.Body) //nolint:all
+		resp.Body.Close()            //nolint:all
+		return resp.StatusCode
+	}
+
+	getCircuits := func() common.CircuitsResponse {
+		resp, err := http.Get(server.URL + common.CircuitsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		var circuits common.CircuitsResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&circuits)).To(Succeed())
+		return circuits
+	}
+
+	It("should open the circuit of a failing prefiller and close it after a successful probe", func() {
+		Expect(sendCompletion()).To(Equal(http.StatusServiceUnavailable))
+		Expect(getCircuits().Prefillers).To(HaveKeyWithValue(prefillHostPort, common.CircuitClosed))
+		Expect(sendCompletion()).To(Equal(http.StatusServiceUnavailable))
+		Expect(getCircuits().Prefillers).To(HaveKeyWithValue(prefillHostPort, common.CircuitOpen))
+
+		// the open circuit fails the requests fast
+		Expect(sendCompletion()).To(Equal(http.StatusServiceUnavailable))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 2))
+
+		prefillStatus.Store(http.StatusOK)
+		time.Sleep(250 * time.Millisecond)
+
+		Expect(sendCompletion()).To(Equal(http.StatusOK))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 3))
+		Expect(getCircuits()).To(Equal(common.CircuitsResponse{
+			Decoder:    common.CircuitClosed,
+			Prefillers: map[string]common.CircuitState{prefillHostPort: common.CircuitClosed},
+		}))
+	})
+
+	It("should reopen the circuit when the probe fails", func() {
+		breaker := newCircuitBreaker(circuitPolicy{threshold: 1, openDuration: time.Hour})
+		Expect(breaker.allow()).To(BeTrue())
+		breaker.record(false)
+		Expect(breaker.currentState()).To(Equal(common.CircuitOpen))
+		Expect(breaker.allow()).To(BeFalse())
+
+		breaker.openedAt = time.Now().Add(-2 * time.Hour)
+		Expect(breaker.allow()).To(BeTrue())
+		Expect(breaker.currentState()).To(Equal(common.CircuitHalfOpen))
+		Expect(breaker.allow()).To(BeFalse()) // a single probe at a time
+		breaker.record(false)
+		Expect(breaker.currentState()).To(Equal(common.CircuitOpen))
+	})
+
+	It("should reply with a distinct error type when the circuit is open", func() {
+		Expect(sendCompletion()).To(Equal(http.StatusServiceUnavailable))
+		Expect(sendCompletion()).To(Equal(http.StatusServiceUnavailable))
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		var response errorResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&response)).To(Succeed())
+		Expect(response.Type).To(Equal("CircuitOpenError"))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 2))
+	})
+
+	It("should open the circuit once the error rate of the recent requests is reached", func() {
+		breaker := newCircuitBreaker(circuitPolicy{errorRate: 0.5, window: 4, openDuration: time.Hour})
+		breaker.record(false)
+		breaker.record(false)
+		breaker.record(false)
+		Expect(breaker.currentState()).To(Equal(common.CircuitClosed)) // the window is not full yet
+
+		breaker.record(true)
+		breaker.record(true)
+		breaker.record(true)
+		breaker.record(false)
+		Expect(breaker.currentState()).To(Equal(common.CircuitClosed)) // 1 failure out of the last 4
+		breaker.record(false)
+		Expect(breaker.currentState()).To(Equal(common.CircuitOpen)) // 2 failures out of the last 4
+	})
+})
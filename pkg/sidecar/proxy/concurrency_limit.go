@@ -0,0 +1,70 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// concurrencyLimitRetryAfter is the number of seconds the clients rejected by the concurrency limit are
+// asked to wait before retrying, in the Retry-After header
+const concurrencyLimitRetryAfter = 1
+
+// concurrencyLimit bounds the completion requests served concurrently by the pod, so that the requests
+// sent to a saturated decoder are rejected instead of piling up. It is shared by the servers of all the
+// data parallel ranks.
+type concurrencyLimit struct {
+	slots chan struct{}
+}
+
+// newConcurrencyLimit returns the concurrency limit, or nil when the limit is not positive
+func newConcurrencyLimit(maxConcurrent int) *concurrencyLimit {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &concurrencyLimit{slots: make(chan struct{}, maxConcurrent)}
+}
+
+// acquire returns whether a request may be served, and counts it in flight
+func (l *concurrencyLimit) acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release counts a request out of flight
+func (l *concurrencyLimit) release() {
+	<-l.slots
+}
+
+// acquireConcurrencyLimit replies with a rate limit error and a Retry-After header, and returns false,
+// when the pod serves as many requests as its limit. Otherwise, the returned function must be called
+// once the request is served.
+func (s *Server) acquireConcurrencyLimit(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	if !s.concurrency.acquire() {
+		s.requestLogger(r).V(4).Info("concurrency limit exceeded", "limit", cap(s.concurrency.slots))
+		s.metrics.observeConcurrencyRejection()
+		w.Header().Set("Retry-After", strconv.Itoa(concurrencyLimitRetryAfter))
+		s.replyError(w, concurrencyLimitExceededError())
+		return nil, false
+	}
+	return s.concurrency.release, true
+}
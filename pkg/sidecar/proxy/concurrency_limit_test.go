@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Concurrency limit", func() {
+	var (
+		proxy    *Server
+		server   *httptest.Server
+		started  chan struct{}
+		released chan struct{}
+	)
+
+	BeforeEach(func() {
+		started = make(chan struct{}, 1)
+		released = make(chan struct{})
+
+		// the decoder holds the requests with the "hold" prompt until released
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body) //nolint:all
+			if strings.Contains(string(body), "hold") {
+				started <- struct{}{}
+				<-released
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[]}`)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy = NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, MaxConcurrentRequests: 1})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendCompletion := func(prompt string) *http.Response {
+		resp, err := http.Post(server.URL+ChatCompletionsPath, "application/json",
+			strings.NewReader(`{"model":"m","messages":[{"role":"user","content":"`+prompt+`"}]}`))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	It("should reject the requests beyond the limit with a 429 and a Retry-After header", func() {
+		held := make(chan int)
+		go func() {
+			defer GinkgoRecover()
+			held <- sendCompletion("hold").StatusCode
+		}()
+		Eventually(started).Should(Receive())
+
+		resp := sendCompletion("hi")
+		Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(resp.Header.Get("Retry-After")).To(Equal("1"))
+		var response errorResponse
+		Expect(json.NewDecoder(resp.Body).Decode(&response)).To(Succeed())
+		Expect(response.Type).To(Equal("RateLimitError"))
+		Expect(response.Kind).To(Equal(errorKindDecodeOverloaded))
+
+		close(released)
+		Eventually(held).Should(Receive(Equal(http.StatusOK)))
+
+		// the slot of the completed request is released
+		Expect(sendCompletion("hi").StatusCode).To(Equal(http.StatusOK))
+		Expect(testutil.ToFloat64(proxy.metrics.limitRejections)).To(Equal(1.0))
+	})
+
+	It("should not limit the requests when disabled", func() {
+		Expect(newConcurrencyLimit(0)).To(BeNil())
+	})
+})
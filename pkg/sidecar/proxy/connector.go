@@ -0,0 +1,246 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+// Connector adapts the disaggregated requests to a KV-transfer protocol. The sidecar sends the
+// request prepared for the prefill to the prefiller, extracts the KV-transfer parameters from the
+// prefiller response, then sends the request prepared for the decode to the local decoder.
+// The numbers of the requests are decoded as json.Number, preserving their literal form.
+type Connector interface {
+	// PrepareForPrefill rewrites the request sent to the prefiller. The pooling requests generate
+	// no tokens, so they have no generation fields.
+	PrepareForPrefill(request map[string]any, pooling bool)
+
+	// ExtractTransferParams returns the KV-transfer parameters of the prefiller response, handed
+	// over to PrepareForDecode. It returns an error wrapping ErrMissingTransferParams when the
+	// response has none, and an error wrapping ErrInvalidPrefillResponse when it is malformed. The
+	// errors are handled according to the prefill response policy.
+	ExtractTransferParams(ctx context.Context, response []byte) (any, error)
+
+	// PrepareForDecode rewrites the request sent to the decoder with the KV-transfer parameters.
+	// It returns false to send the original request to the decoder untouched.
+	PrepareForDecode(request map[string]any, transferParams any) bool
+}
+
+// ErrInvalidPrefillResponse is wrapped by the errors of the connectors whose prefill response is malformed
+var ErrInvalidPrefillResponse = errors.New("invalid prefill response")
+
+// ErrMissingTransferParams is wrapped by the errors of the connectors whose prefill response has no
+// KV-transfer parameters. It wraps ErrInvalidPrefillResponse.
+var ErrMissingTransferParams = fmt.Errorf("%w: missing KV-transfer parameters", ErrInvalidPrefillResponse)
+
+// ConnectorFactory creates a connector
+type ConnectorFactory func() Connector
+
+var (
+	connectorFactories = map[string]ConnectorFactory{
+		ConnectorNIXLV2:  func() Connector { return &nixlV2Connector{} },
+		ConnectorNIXLV1:  func() Connector { return &nixlV1Connector{} },
+		ConnectorLMCache: func() Connector { return &lmCacheConnector{} },
+	}
+	connectorFactoriesMu sync.RWMutex
+)
+
+// RegisterConnector registers a KV-transfer protocol, selected by its name with the connector
+// option of the sidecar. It replaces a connector registered with the same name.
+func RegisterConnector(name string, factory ConnectorFactory) {
+	connectorFactoriesMu.Lock()
+	defer connectorFactoriesMu.Unlock()
+	connectorFactories[name] = factory
+}
+
+// RegisteredConnectors returns the sorted names of the registered connectors
+func RegisteredConnectors() []string {
+	connectorFactoriesMu.RLock()
+	defer connectorFactoriesMu.RUnlock()
+	return slices.Sorted(maps.Keys(connectorFactories))
+}
+
+// newConnector creates the connector registered with the given name, or the nixlv2 connector
+// when none is
+func newConnector(name string) (string, Connector) {
+	connectorFactoriesMu.RLock()
+	defer connectorFactoriesMu.RUnlock()
+	if factory, ok := connectorFactories[name]; ok {
+		return name, factory()
+	}
+	return ConnectorNIXLV2, connectorFactories[ConnectorNIXLV2]()
+}
+
+// runConnector sends a request to the prefiller, then to the local decoder, through the KV-transfer
+// protocol of the connector. The prefill targets are tried in order until one of them succeeds.
+func (s *Server) runConnector(w http.ResponseWriter, r *http.Request, prefillTargets []string) {
+	prefillPodHostPort := prefillTargets[0]
+	s.requestLogger(r).V(4).Info("running connector", "connector", s.connector, "url", prefillPodHostPort)
+
+	ctx, span := startSpan(r.Context(), s.connector, trace.WithAttributes(
+		connectorAttribute.String(s.connector), prefillTargetAttribute.String(prefillPodHostPort)))
+	defer span.End()
+
+	// Read request body
+	original, ok := s.readRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse completion request
+	completionRequest, err := decodeJSONObject(original)
+	if err != nil {
+		s.replyError(w, badRequestError(err))
+		return
+	}
+	if completionRequest == nil { // JSON null
+		s.replyError(w, badRequestError(errRequestNotObject))
+		return
+	}
+
+	// The request ID is sent to both the prefiller and the decoder
+	requestID := ensureRequestID(r)
+	span.SetAttributes(requestIDAttribute.String(requestID))
+
+	// Prefill Stage
+
+	// 1. Prepare prefill request
+	preq := r.Clone(ctx)
+
+	prefillRequest := maps.Clone(completionRequest)
+	s.kvConnector.PrepareForPrefill(prefillRequest, common.IsPoolingPath(r.URL.Path))
+	if r.URL.Path == ResponsesPath {
+		adaptPrefillToResponses(prefillRequest)
+	}
+	s.augmentTransferParams(r, TransferStagePrefill, prefillRequest)
+	pbody, err := marshalRequest(ctx, "marshal_prefill_request", prefillRequest)
+	if err != nil {
+		s.replyError(w, badRequestError(err))
+		return
+	}
+
+	// 2. Forward request to prefiller
+	s.requestLogger(r).V(4).Info("sending prefill request", "to", prefillTargets)
+	s.requestLogger(r).V(5).Info("Prefill request", "body", string(pbody))
+	var prefillResponse []byte
+	if s.config.PrefillPipelining {
+		var wait func()
+		prefillResponse, wait = s.pipelinedPrefill(w, r.WithContext(ctx), preq, pbody, original, prefillTargets)
+		defer wait()
+	} else {
+		pw, prefillPodHostPort := s.prefillTargets(preq, pbody, prefillTargets)
+		prefillResponse = s.prefillResponse(w, r.WithContext(ctx), original, pw, prefillPodHostPort)
+	}
+	if prefillResponse == nil {
+		return
+	}
+
+	// 3. Extract the KV-transfer parameters
+	transferParams, err := s.kvConnector.ExtractTransferParams(klog.NewContext(ctx, s.requestLogger(r)), prefillResponse)
+	if err != nil && !s.handleInvalidPrefillResponse(w, r.WithContext(ctx), original, err) {
+		return
+	}
+	s.requestLogger(r).V(5).Info("received prefiller response", "transferParams", transferParams)
+
+	// Decode Stage
+
+	// 1. Prepare decode request
+	dreq := r.Clone(ctx)
+
+	dbody := original
+	decodeRequest := maps.Clone(completionRequest)
+	if s.kvConnector.PrepareForDecode(decodeRequest, transferParams) {
+		s.augmentTransferParams(r, TransferStageDecode, decodeRequest)
+		dbody, err = marshalRequest(ctx, "marshal_decode_request", decodeRequest)
+		if err != nil {
+			s.replyError(w, badRequestError(err))
+			return
+		}
+	}
+	dreq.Body = io.NopCloser(strings.NewReader(string(dbody)))
+	dreq.ContentLength = int64(len(dbody))
+	dreq.TransferEncoding = nil
+
+	// 2. Forward to local decoder.
+	s.requestLogger(r).V(5).Info("sending request to decoder", "body", string(dbody))
+	s.decode(w, dreq, s.connector)
+}
+
+// prefillResponse returns the body of the prefill response of the given target. It returns nil when
+// the prefill failed, after falling back to the local decoder or returning the failure to the client.
+func (s *Server) prefillResponse(w http.ResponseWriter, r *http.Request, original []byte, pw *bufferedResponseWriter,
+	prefillPodHostPort string) []byte {
+	if entry := accessLogFromContext(r.Context()); entry != nil {
+		entry.PrefillTarget = prefillPodHostPort
+	}
+	s.annotateRouting(w, RoutingPrefillTargetHeader, prefillPodHostPort)
+
+	if pw.statusCode < 200 || pw.statusCode >= 300 {
+		kind := pw.errorKind
+		if kind == "" {
+			kind = errorKindPrefillRejected // the error response of the prefiller
+		}
+		s.requestLogger(r).Error(nil, "request failed", "code", pw.statusCode, "errorKind", kind)
+		if s.fallbackToDecode(w, r, original, pw.statusCode) {
+			return nil
+		}
+		s.metrics.observeError(kind)
+		if err := pw.writeTo(w); err != nil {
+			s.requestLogger(r).Error(err, "failed to send error response to client")
+		}
+		return nil
+	}
+	return []byte(pw.buffer.String())
+}
+
+// handleInvalidPrefillResponse applies the prefill response policy to a prefill response the KV-transfer
+// parameters could not be extracted from. It returns true when the decode must proceed without them,
+// otherwise the response to the client is written.
+func (s *Server) handleInvalidPrefillResponse(w http.ResponseWriter, r *http.Request, original []byte, err error) bool {
+	s.metrics.observeInvalidPrefill(s.connector)
+
+	switch s.config.PrefillResponsePolicy {
+	case PrefillResponsePolicyFail:
+		s.requestLogger(r).Error(err, "invalid prefill response, failing the request")
+		s.replyError(w, prefillInvalidError(err))
+		return false
+	case PrefillResponsePolicyDecode:
+		s.requestLogger(r).Error(err, "invalid prefill response, falling back to local decode")
+		s.decodeOriginal(w, r, original)
+		return false
+	default:
+		if errors.Is(err, ErrMissingTransferParams) {
+			s.requestLogger(r).Info("warning: the prefill response has no KV-transfer parameters", "error", err.Error())
+			return true
+		}
+		s.replyError(w, badRequestError(err))
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+const kvTransferParamsTestData = "testdata/kv_transfer_params"
+
+func readJSONFile(path string) map[string]any {
+	b, err := os.ReadFile(path)
+	Expect(err).ToNot(HaveOccurred())
+
+	var m map[string]any
+	Expect(json.Unmarshal(b, &m)).To(Succeed())
+	return m
+}
+
+var _ = Describe("kv_transfer_params compatibility across vLLM versions", func() {
+	DescribeTable("should forward the prefiller kv_transfer_params to the decoder",
+		func(version string) {
+			dir := filepath.Join(kvTransferParamsTestData, version)
+			request, err := os.ReadFile(filepath.Join(dir, "request.json"))
+			Expect(err).ToNot(HaveOccurred())
+			prefillResponse, err := os.ReadFile(filepath.Join(dir, "prefill_response.json"))
+			Expect(err).ToNot(HaveOccurred())
+
+			server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV2, prefillResponse)
+			rec := sendConnectorRequest(server, request)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			By("verifying the prefill request")
+			Expect(*prefillRequests).To(HaveLen(1))
+			var prefill map[string]any
+			Expect(json.Unmarshal((*prefillRequests)[0], &prefill)).To(Succeed())
+			Expect(prefill).To(HaveKeyWithValue(requestFieldMaxTokens, BeNumerically("==", 1)))
+			Expect(prefill).To(HaveKeyWithValue(requestFieldStream, false))
+			Expect(prefill).ToNot(HaveKey(requestFieldStreamOptions))
+
+			By("verifying the decode request matches the golden file")
+			Expect(decoder.bodies).To(HaveLen(1))
+			var decode map[string]any
+			Expect(json.Unmarshal(decoder.bodies[0], &decode)).To(Succeed())
+			Expect(decode).To(Equal(readJSONFile(filepath.Join(dir, "decode_request.golden.json"))))
+
+			By("verifying kv_transfer_params are forwarded verbatim")
+			response := readJSONFile(filepath.Join(dir, "prefill_response.json"))
+			Expect(decode[requestFieldKVTransferParams]).To(Equal(response[requestFieldKVTransferParams]))
+			kvTransferParams, ok := decode[requestFieldKVTransferParams].(map[string]any)
+			Expect(ok).To(BeTrue())
+			Expect(kvTransferParams).To(HaveKeyWithValue(requestFieldDoRemotePrefill, true))
+			Expect(kvTransferParams).To(HaveKeyWithValue(requestFieldDoRemoteDecode, false))
+			Expect(kvTransferParams).To(HaveKey(requestFieldRemoteBlockIDs))
+			Expect(kvTransferParams).To(HaveKey(requestFieldRemoteEngineID))
+			Expect(kvTransferParams).To(HaveKey(requestFieldRemoteHost))
+			Expect(kvTransferParams).To(HaveKey(requestFieldRemotePort))
+		},
+		Entry("vLLM v0.9.2", "vllm-v0.9.2"),
+		Entry("vLLM v0.10.1", "vllm-v0.10.1"),
+		Entry("vLLM v0.11.0", "vllm-v0.11.0"),
+	)
+})
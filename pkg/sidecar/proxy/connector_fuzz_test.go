@@ -0,0 +1,243 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const inProcessPrefillHostPort = "prefill.local:8000"
+
+// fuzzRequestSeeds are the request bodies used as seed corpus
+var fuzzRequestSeeds = []string{
+	`{"model":"Qwen/Qwen2-0.5B","messages":[{"role":"user","content":"Hello"}],"max_tokens":50}`,
+	`{"model":"m","prompt":"Hello","stream":true,"stream_options":{"include_usage":true},"max_completion_tokens":100}`,
+	`{"model":"m","prompt":"héllo wörld 你好 🚀 \u0000 \ud83d","kv_transfer_params":{"do_remote_prefill":true}}`,
+	`{"a":{"b":{"c":{"d":{"e":{"f":{"g":[[[[[[[[{"h":null}]]]]]]]]}}}}}}}`,
+	`{"max_tokens":"not-a-number","stream":null,"stream_options":[1,2,3]}`,
+	`{"n":12345678901234567890,"f":1.5e300,"neg":-0,"arr":[]}`,
+	`{"":1e700}`,
+	`[]`,
+	`null`,
+	`"string"`,
+	`{`,
+	``,
+}
+
+// fuzzResponseSeeds are the prefiller response bodies used as seed corpus
+var fuzzResponseSeeds = []string{
+	`{"kv_transfer_params":{"remote_block_ids":[1,2,3],"remote_engine_id":"5b5fb28f","remote_host":"ahost","remote_port":4032}}`,
+	`{"kv_transfer_params":null}`,
+	`{"kv_transfer_params":{"remote_port":1e700}}`,
+	`{}`,
+	`{"kv_transfer_params":"unexpected"}`,
+	`not json`,
+}
+
+// recordingTransport answers every request with an empty JSON object and records the request bodies
+type recordingTransport struct {
+	bodies [][]byte
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body) //nolint:all
+	}
+	t.bodies = append(t.bodies, body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+		Request:    req,
+	}, nil
+}
+
+// newInProcessProxy creates a Server whose prefiller and decoder are served in-process.
+// The prefiller answers every request with the given response.
+func newInProcessProxy(connector string, prefillResponse []byte) (*Server, *recordingTransport, *[][]byte) {
+	decoderURL, _ := url.Parse("http://decoder.local") //nolint:all
+	server := NewProxy("0", decoderURL, Config{Connector: connector})
+
+	decoder := &recordingTransport{}
+	server.decoderProxy = httputil.NewSingleHostReverseProxy(decoderURL)
+	server.decoderProxy.Transport = decoder
+
+	var prefillRequests [][]byte
+	server.prefillerProxies.Add(inProcessPrefillHostPort, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body) //nolint:all
+		prefillRequests = append(prefillRequests, body)
+		w.Write(prefillResponse) //nolint:all
+	}))
+
+	return server, decoder, &prefillRequests
+}
+
+func sendConnectorRequest(server *Server, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath, bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.runConnectorProtocol(rec, req, []string{inProcessPrefillHostPort})
+	return rec
+}
+
+// rewrittenFields are the fields the connectors are allowed to modify
+var rewrittenFields = []string{
+	requestFieldKVTransferParams,
+	requestFieldMaxTokens,
+	requestFieldMaxCompletionTokens,
+	requestFieldStream,
+	requestFieldStreamOptions,
+}
+
+func withoutRewrittenFields(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	for _, field := range rewrittenFields {
+		delete(out, field)
+	}
+	return out
+}
+
+func FuzzNIXLProtocolV2(f *testing.F) {
+	for _, request := range fuzzRequestSeeds {
+		for _, response := range fuzzResponseSeeds {
+			f.Add([]byte(request), []byte(response))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, requestBody []byte, prefillResponse []byte) {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV2, prefillResponse)
+		rec := sendConnectorRequest(server, requestBody)
+
+		// the request is decoded as the connectors decode it, keeping the numbers beyond the float64 range
+		original, err := decodeJSONObject(requestBody)
+		if err != nil || original == nil {
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d for invalid request, got %d", http.StatusBadRequest, rec.Code)
+			}
+			if len(*prefillRequests) != 0 || len(decoder.bodies) != 0 {
+				t.Fatalf("invalid request must not be forwarded")
+			}
+			return
+		}
+
+		if len(*prefillRequests) != 1 {
+			t.Fatalf("expected 1 prefill request, got %d", len(*prefillRequests))
+		}
+		prefill, err := decodeJSONObject((*prefillRequests)[0])
+		if err != nil {
+			t.Fatalf("prefill request is not valid JSON: %v", err)
+		}
+		if prefill[requestFieldStream] != false || prefill[requestFieldMaxTokens] != json.Number("1") ||
+			prefill[requestFieldMaxCompletionTokens] != json.Number("1") {
+			t.Fatalf("prefill request not rewritten: %v", prefill)
+		}
+		if _, ok := prefill[requestFieldStreamOptions]; ok {
+			t.Fatalf("prefill request must not contain %s", requestFieldStreamOptions)
+		}
+		if !reflect.DeepEqual(withoutRewrittenFields(original), withoutRewrittenFields(prefill)) {
+			t.Fatalf("prefill request fields corrupted:\noriginal: %v\nprefill:  %v", original, prefill)
+		}
+
+		response, err := decodeJSONObject(prefillResponse)
+		if err != nil {
+			if rec.Code != http.StatusBadRequest || len(decoder.bodies) != 0 {
+				t.Fatalf("invalid prefill response must fail the request, got status %d", rec.Code)
+			}
+			return
+		}
+
+		if len(decoder.bodies) != 1 {
+			t.Fatalf("expected 1 decode request, got %d", len(decoder.bodies))
+		}
+		decode, err := decodeJSONObject(decoder.bodies[0])
+		if err != nil {
+			t.Fatalf("decode request is not valid JSON: %v", err)
+		}
+		for _, field := range []string{requestFieldStream, requestFieldStreamOptions, requestFieldMaxTokens, requestFieldMaxCompletionTokens} {
+			originalValue, originalOk := original[field]
+			decodeValue, decodeOk := decode[field]
+			if originalOk != decodeOk || !reflect.DeepEqual(originalValue, decodeValue) {
+				t.Fatalf("field %s not restored in decode request: original %v, decode %v", field, originalValue, decodeValue)
+			}
+		}
+		if !reflect.DeepEqual(decode[requestFieldKVTransferParams], response[requestFieldKVTransferParams]) {
+			t.Fatalf("kv_transfer_params not forwarded: prefill response %v, decode %v",
+				response[requestFieldKVTransferParams], decode[requestFieldKVTransferParams])
+		}
+		if !reflect.DeepEqual(withoutRewrittenFields(original), withoutRewrittenFields(decode)) {
+			t.Fatalf("decode request fields corrupted:\noriginal: %v\ndecode:   %v", original, decode)
+		}
+	})
+}
+
+func FuzzLMCacheProtocol(f *testing.F) {
+	for _, request := range fuzzRequestSeeds {
+		f.Add([]byte(request))
+	}
+
+	f.Fuzz(func(t *testing.T, requestBody []byte) {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorLMCache, []byte(`{}`))
+		rec := sendConnectorRequest(server, requestBody)
+
+		// the request is decoded as the connectors decode it, keeping the numbers beyond the float64 range
+		original, err := decodeJSONObject(requestBody)
+		if err != nil || original == nil {
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d for invalid request, got %d", http.StatusBadRequest, rec.Code)
+			}
+			if len(*prefillRequests) != 0 || len(decoder.bodies) != 0 {
+				t.Fatalf("invalid request must not be forwarded")
+			}
+			return
+		}
+
+		if len(*prefillRequests) != 1 {
+			t.Fatalf("expected 1 prefill request, got %d", len(*prefillRequests))
+		}
+		prefill, err := decodeJSONObject((*prefillRequests)[0])
+		if err != nil {
+			t.Fatalf("prefill request is not valid JSON: %v", err)
+		}
+		if prefill[requestFieldMaxTokens] != json.Number("1") || prefill[requestFieldMaxCompletionTokens] != json.Number("1") {
+			t.Fatalf("prefill request not rewritten: %v", prefill)
+		}
+		delete(original, requestFieldMaxTokens)
+		delete(original, requestFieldMaxCompletionTokens)
+		delete(prefill, requestFieldMaxTokens)
+		delete(prefill, requestFieldMaxCompletionTokens)
+		if !reflect.DeepEqual(original, prefill) {
+			t.Fatalf("prefill request fields corrupted:\noriginal: %v\nprefill:  %v", original, prefill)
+		}
+
+		// the decoder receives the original request untouched
+		if len(decoder.bodies) != 1 || !bytes.Equal(decoder.bodies[0], requestBody) {
+			t.Fatalf("decode request differs from the original request")
+		}
+	})
+}
@@ -16,75 +16,26 @@ limitations under the License.
 
 package proxy
 
-import (
-	"encoding/json"
-	"io"
-	"net/http"
-	"strings"
-)
+import "context"
 
-func (s *Server) runLMCacheProtocol(w http.ResponseWriter, r *http.Request, prefillPodHostPort string) {
-	s.logger.Info("running LMCache protocol")
+// lmCacheConnector implements the (now deprecated) LMCache protocol: the prefiller stores the
+// KV-cache in LMCache, where the decoder finds it for the original request
+type lmCacheConnector struct{}
 
-	// Read and parse request body
-	defer r.Body.Close() //nolint:all
-	original, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest) // TODO: check FastAPI error code when failing to read body
-		w.Write([]byte(err.Error()))         //nolint:all
-		return
-	}
-
-	// Parse completion request
-	var completionRequest map[string]any
-	if err := json.Unmarshal(original, &completionRequest); err != nil {
-		if err := errorJSONInvalid(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-
-	// Create prefiller request. Set max_tokens to 1.
-
-	ctx := r.Context()
-	preq := r.Clone(ctx)
-
-	completionRequest[requestFieldMaxTokens] = 1
-	completionRequest[requestFieldMaxCompletionTokens] = 1
-
-	pbody, err := json.Marshal(completionRequest)
-	if err != nil {
-		if err := errorJSONInvalid(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-	preq.Body = io.NopCloser(strings.NewReader(string(pbody)))
-	preq.ContentLength = int64(len(pbody))
-
-	// Forward request to prefiller
-
-	prefillHandler, err := s.prefillerProxyHandler(prefillPodHostPort)
-	if err != nil {
-		if err := errorBadGateway(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-	s.logger.V(4).Info("sending prefill request", "to", prefillPodHostPort)
-	pw := &bufferedResponseWriter{}
-	prefillHandler.ServeHTTP(pw, preq)
-
-	if pw.statusCode < 200 || pw.statusCode >= 300 {
-		s.logger.Error(err, "request failed", "code", pw.statusCode)
-		w.WriteHeader(pw.statusCode)
-		return
+// PrepareForPrefill implements Connector
+func (c *lmCacheConnector) PrepareForPrefill(request map[string]any, pooling bool) {
+	if !pooling { // the pooling requests generate no tokens
+		request[requestFieldMaxTokens] = 1
+		request[requestFieldMaxCompletionTokens] = 1
 	}
+}
 
-	// Forward original request to local decoder
+// ExtractTransferParams implements Connector, the prefiller response is ignored
+func (c *lmCacheConnector) ExtractTransferParams(_ context.Context, _ []byte) (any, error) {
+	return nil, nil
+}
 
-	r.Body = io.NopCloser(strings.NewReader(string(original)))
-	if s.forwardDataParallel && !s.dataParallelHandler(w, r) {
-		s.decoderProxy.ServeHTTP(w, r)
-	}
+// PrepareForDecode implements Connector, the decoder receives the original request
+func (c *lmCacheConnector) PrepareForDecode(_ map[string]any, _ any) bool {
+	return false
 }
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+)
+
+// nixlV1TransferFields are the top-level fields of a NIXL v1 prefiller response the decoder pulls the
+// KV-cache with, the block IDs and the engine ID being required
+var nixlV1TransferFields = []string{
+	requestFieldRemoteBlockIDs,
+	requestFieldRemoteEngineID,
+	requestFieldRemoteHost,
+	requestFieldRemotePort,
+}
+
+// nixlV1Connector implements the legacy NIXL v1 protocol: the KV-transfer fields are top-level fields of
+// the requests and of the prefiller response, instead of being nested in kv_transfer_params
+type nixlV1Connector struct{}
+
+// PrepareForPrefill implements Connector
+func (c *nixlV1Connector) PrepareForPrefill(request map[string]any, pooling bool) {
+	request[requestFieldDoRemoteDecode] = true
+
+	// the pooling requests generate no tokens, they have no generation fields to limit the prefill with
+	if !pooling {
+		request[requestFieldStream] = false
+		delete(request, requestFieldStreamOptions)
+		request[requestFieldMaxTokens] = 1
+		request[requestFieldMaxCompletionTokens] = 1
+	}
+}
+
+// ExtractTransferParams implements Connector, the parameters are the KV-transfer fields of the response
+func (c *nixlV1Connector) ExtractTransferParams(_ context.Context, response []byte) (any, error) {
+	prefillerResponse, err := decodeJSONObject(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrefillResponse, err)
+	}
+
+	transferParams := map[string]any{}
+	for _, field := range nixlV1TransferFields {
+		if value, ok := prefillerResponse[field]; ok {
+			transferParams[field] = value
+		}
+	}
+	for _, field := range []string{requestFieldRemoteBlockIDs, requestFieldRemoteEngineID} {
+		if transferParams[field] == nil {
+			return nil, fmt.Errorf("%w: no '%s' field", ErrMissingTransferParams, field)
+		}
+	}
+	return transferParams, nil
+}
+
+// PrepareForDecode implements Connector, the original request is sent untouched without transfer parameters
+func (c *nixlV1Connector) PrepareForDecode(request map[string]any, transferParams any) bool {
+	params, ok := transferParams.(map[string]any)
+	if !ok {
+		return false
+	}
+	request[requestFieldDoRemotePrefill] = true
+	for field, value := range params {
+		request[field] = value
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("NIXL Connector (v1)", func() {
+	decodeBody := func(body []byte) map[string]any {
+		var object map[string]any
+		Expect(json.Unmarshal(body, &object)).To(Succeed())
+		return object
+	}
+
+	It("should send the KV-transfer fields as top-level fields", func() {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV1,
+			[]byte(`{"id":"cmpl-1","remote_block_ids":[1,2,3],"remote_engine_id":"e","remote_host":"ahost","remote_port":4032}`))
+		Expect(server.connector).To(Equal(ConnectorNIXLV1))
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi","max_tokens":50,"stream":true}`))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(HaveLen(1))
+		Expect(decodeBody((*prefillRequests)[0])).To(Equal(map[string]any{
+			"model": "m", "prompt": "hi", "max_tokens": 1.0, "max_completion_tokens": 1.0, "stream": false,
+			requestFieldDoRemoteDecode: true,
+		}))
+		Expect(decoder.bodies).To(HaveLen(1))
+		Expect(decodeBody(decoder.bodies[0])).To(Equal(map[string]any{
+			"model": "m", "prompt": "hi", "max_tokens": 50.0, "stream": true,
+			requestFieldDoRemotePrefill: true,
+			requestFieldRemoteBlockIDs:  []any{1.0, 2.0, 3.0},
+			requestFieldRemoteEngineID:  "e",
+			requestFieldRemoteHost:      "ahost",
+			requestFieldRemotePort:      4032.0,
+		}))
+	})
+
+	It("should decode the original request when the prefill response has no KV-transfer fields", func() {
+		server, decoder, _ := newInProcessProxy(ConnectorNIXLV1, []byte(`{"remote_engine_id":"e"}`))
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi"}`))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(decoder.bodies).To(HaveExactElements(MatchJSON(`{"model":"m","prompt":"hi"}`)))
+		Expect(testutil.ToFloat64(server.metrics.invalidPrefills.WithLabelValues(ConnectorNIXLV1))).To(Equal(1.0))
+	})
+})
@@ -17,59 +17,17 @@ limitations under the License.
 package proxy
 
 import (
-	"encoding/json"
-	"io"
-	"net/http"
-	"strings"
-
-	"github.com/google/uuid"
+	"context"
+	"fmt"
 )
 
-func (s *Server) runNIXLProtocolV2(w http.ResponseWriter, r *http.Request, prefillPodHostPort string) {
-	s.logger.V(4).Info("running NIXL protocol V2", "url", prefillPodHostPort)
-
-	// Read request body
-	defer r.Body.Close() //nolint:all
-	original, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest) // TODO: check FastAPI error code when failing to read body
-		w.Write([]byte(err.Error()))         //nolint:all
-		return
-	}
-
-	// Parse completion request
-	var completionRequest map[string]any
-	if err := json.Unmarshal(original, &completionRequest); err != nil {
-		if err := errorJSONInvalid(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-
-	// Generate unique request UUID
-	uuid, err := uuid.NewUUID()
-	if err != nil {
-		if err := errorBadGateway(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-	uuidStr := uuid.String()
-
-	// Prefill Stage
+// nixlV2Connector implements the NIXL v2 protocol: the prefiller is asked to keep the KV-cache for
+// a remote decode, and returns the KV-transfer parameters the decoder pulls it with
+type nixlV2Connector struct{}
 
-	// 1. Prepare prefill request
-	ctx := r.Context()
-	preq := r.Clone(ctx)
-
-	preq.Header.Add(requestHeaderRequestID, uuidStr)
-
-	streamValue, streamOk := completionRequest[requestFieldStream]
-	streamOptionsValue, streamOptionsOk := completionRequest[requestFieldStreamOptions]
-	maxTokensValue, maxTokensOk := completionRequest[requestFieldMaxTokens]
-	maxCompletionTokensValue, maxCompletionTokensOk := completionRequest[requestFieldMaxCompletionTokens]
-
-	completionRequest[requestFieldKVTransferParams] = map[string]any{
+// PrepareForPrefill implements Connector
+func (c *nixlV2Connector) PrepareForPrefill(request map[string]any, pooling bool) {
+	request[requestFieldKVTransferParams] = map[string]any{
 		requestFieldDoRemoteDecode:  true,
 		requestFieldDoRemotePrefill: false,
 		requestFieldRemoteEngineID:  nil,
@@ -78,97 +36,31 @@ func (s *Server) runNIXLProtocolV2(w http.ResponseWriter, r *http.Request, prefi
 		requestFieldRemotePort:      nil,
 	}
 
-	completionRequest[requestFieldStream] = false
-	delete(completionRequest, requestFieldStreamOptions)
-	completionRequest[requestFieldMaxTokens] = 1
-	completionRequest[requestFieldMaxCompletionTokens] = 1
-
-	pbody, err := json.Marshal(completionRequest)
-	if err != nil {
-		if err := errorJSONInvalid(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
+	// the pooling requests generate no tokens, they have no generation fields to limit the prefill with
+	if !pooling {
+		request[requestFieldStream] = false
+		delete(request, requestFieldStreamOptions)
+		request[requestFieldMaxTokens] = 1
+		request[requestFieldMaxCompletionTokens] = 1
 	}
-	preq.Body = io.NopCloser(strings.NewReader(string(pbody)))
-	preq.ContentLength = int64(len(pbody))
+}
 
-	prefillHandler, err := s.prefillerProxyHandler(prefillPodHostPort)
+// ExtractTransferParams implements Connector
+func (c *nixlV2Connector) ExtractTransferParams(_ context.Context, response []byte) (any, error) {
+	prefillerResponse, err := decodeJSONObject(response)
 	if err != nil {
-		if err := errorBadGateway(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-
-	// 2. Forward request to prefiller
-	s.logger.V(4).Info("sending prefill request", "to", prefillPodHostPort)
-	s.logger.V(5).Info("Prefill request", "body", string(pbody))
-	pw := &bufferedResponseWriter{}
-	prefillHandler.ServeHTTP(pw, preq)
-
-	if pw.statusCode < 200 || pw.statusCode >= 300 {
-		s.logger.Error(err, "request failed", "code", pw.statusCode)
-		w.WriteHeader(pw.statusCode)
-		return
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrefillResponse, err)
 	}
 
-	// Process response - extract p/d fields
-	var prefillerResponse map[string]any
-	if err := json.Unmarshal([]byte(pw.buffer.String()), &prefillerResponse); err != nil {
-		if err := errorJSONInvalid(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-
-	// 3. Verify response
-
-	pKVTransferParams, ok := prefillerResponse[requestFieldKVTransferParams]
+	transferParams, ok := prefillerResponse[requestFieldKVTransferParams]
 	if !ok {
-		s.logger.Info("warning: missing 'kv_transfer_params' field in prefiller response")
-	}
-
-	s.logger.V(5).Info("received prefiller response", requestFieldKVTransferParams, pKVTransferParams)
-
-	// Decode Stage
-
-	// 1. Prepare decode request
-	dreq := r.Clone(ctx)
-
-	dreq.Header.Add(requestHeaderRequestID, uuidStr)
-
-	delete(completionRequest, requestFieldStream)
-	if streamOk {
-		completionRequest[requestFieldStream] = streamValue
+		return nil, fmt.Errorf("%w: no '%s' field", ErrMissingTransferParams, requestFieldKVTransferParams)
 	}
-	if streamOptionsOk {
-		completionRequest[requestFieldStreamOptions] = streamOptionsValue
-	}
-	delete(completionRequest, requestFieldMaxTokens)
-	if maxTokensOk {
-		completionRequest[requestFieldMaxTokens] = maxTokensValue
-	}
-	delete(completionRequest, requestFieldMaxCompletionTokens)
-	if maxCompletionTokensOk {
-		completionRequest[requestFieldMaxCompletionTokens] = maxCompletionTokensValue
-	}
-	completionRequest[requestFieldKVTransferParams] = pKVTransferParams
-
-	dbody, err := json.Marshal(completionRequest)
-	if err != nil {
-		if err := errorJSONInvalid(err, w); err != nil {
-			s.logger.Error(err, "failed to send error response to client")
-		}
-		return
-	}
-	dreq.Body = io.NopCloser(strings.NewReader(string(dbody)))
-	dreq.ContentLength = int64(len(dbody))
-
-	// 2. Forward to local decoder.
+	return transferParams, nil
+}
 
-	s.logger.V(5).Info("sending request to decoder", "body", string(dbody))
-	if s.forwardDataParallel && !s.dataParallelHandler(w, dreq) {
-		s.decoderProxy.ServeHTTP(w, dreq)
-	}
+// PrepareForDecode implements Connector
+func (c *nixlV2Connector) PrepareForDecode(request map[string]any, transferParams any) bool {
+	request[requestFieldKVTransferParams] = transferParams
+	return true
 }
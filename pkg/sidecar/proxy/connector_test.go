@@ -45,7 +45,7 @@ type sidecarTestInfo struct {
 	proxy          *Server
 }
 
-var connectors = []string{ConnectorLMCache, ConnectorNIXLV2}
+var connectors = []string{ConnectorLMCache, ConnectorNIXLV1, ConnectorNIXLV2}
 
 var _ = Describe("Common Connector tests", func() {
 
@@ -209,3 +209,62 @@ func sidecarConnectionTestSetup(connector string) *sidecarTestInfo {
 
 	return &testInfo
 }
+
+// testConnector is a connector registered by the tests, passing a transfer id to the decoder
+type testConnector struct{}
+
+func (c *testConnector) PrepareForPrefill(request map[string]any, _ bool) {
+	request["prefill_only"] = true
+}
+
+func (c *testConnector) ExtractTransferParams(_ context.Context, response []byte) (any, error) {
+	if !strings.HasPrefix(string(response), "transfer-") {
+		return nil, fmt.Errorf("unexpected prefiller response %q", response)
+	}
+	return string(response), nil
+}
+
+func (c *testConnector) PrepareForDecode(request map[string]any, transferParams any) bool {
+	request["transfer_id"] = transferParams
+	return true
+}
+
+var _ = Describe("Connector registry", func() {
+	const testConnectorName = "test-connector"
+
+	BeforeEach(func() {
+		RegisterConnector(testConnectorName, func() Connector { return &testConnector{} })
+		DeferCleanup(func() {
+			connectorFactoriesMu.Lock()
+			defer connectorFactoriesMu.Unlock()
+			delete(connectorFactories, testConnectorName)
+		})
+	})
+
+	It("should list the registered connectors", func() {
+		Expect(RegisteredConnectors()).To(Equal([]string{ConnectorLMCache, ConnectorNIXLV1, ConnectorNIXLV2, testConnectorName}))
+	})
+
+	It("should run the protocol of a registered connector", func() {
+		server, decoder, prefillRequests := newInProcessProxy(testConnectorName, []byte("transfer-1"))
+		Expect(server.connector).To(Equal(testConnectorName))
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi"}`))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(HaveExactElements(MatchJSON(`{"model":"m","prompt":"hi","prefill_only":true}`)))
+		Expect(decoder.bodies).To(HaveExactElements(MatchJSON(`{"model":"m","prompt":"hi","transfer_id":"transfer-1"}`)))
+	})
+
+	It("should fail the request when the transfer parameters cannot be extracted", func() {
+		server, decoder, _ := newInProcessProxy(testConnectorName, []byte("unexpected"))
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi"}`))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(decoder.bodies).To(BeEmpty())
+	})
+
+	It("should default to the nixlv2 connector", func() {
+		server, _, _ := newInProcessProxy("unknown", []byte(`{}`))
+		Expect(server.connector).To(Equal(ConnectorNIXLV2))
+	})
+})
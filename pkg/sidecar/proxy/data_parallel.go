@@ -3,10 +3,12 @@ package proxy
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
@@ -15,71 +17,180 @@ import (
 )
 
 // dataParallelHandler checks if Data Parallel handling is needed.
-// Returns true if Data Parallel processing was needed
+// Returns true if Data Parallel processing was needed. The requests of the ranks after the first one
+// are decoded by the server of the rank, so that its decoder circuit and stream limits apply to the
+// decode of the disaggregated requests too.
 func (s *Server) dataParallelHandler(w http.ResponseWriter, r *http.Request) bool {
 	dataParallelPodHostPort := r.Header.Get(common.DataParallelPodHeader)
 	if dataParallelPodHostPort != "" {
+		s.dataParallelMu.RLock()
 		handler := s.dataParallelProxies[dataParallelPodHostPort]
-		if handler != nil {
-			s.logger.V(4).Info("Data parallel routing", "to", dataParallelPodHostPort)
+		rankServer := s.dataParallelServers[dataParallelPodHostPort]
+		s.dataParallelMu.RUnlock()
+		if rankServer != nil {
+			s.requestLogger(r).V(4).Info("Data parallel routing", "to", dataParallelPodHostPort)
+			rankServer.serveDecoder(w, r)
+		} else if handler != nil {
+			s.requestLogger(r).V(4).Info("Data parallel routing", "to", dataParallelPodHostPort)
+			s.annotateRouting(w, RoutingDecodeRankHeader, strconv.Itoa(s.dataParallelRank(dataParallelPodHostPort)))
 			handler.ServeHTTP(w, r)
+		} else if s.config.DataParallelFallback {
+			// the rank may have been removed by a resize the EPP is not aware of yet
+			fallback := s.leastLoadedRank()
+			s.requestLogger(r).V(4).Info("Didn't find the Data Parallel Proxy, falling back to the least loaded rank",
+				"for", dataParallelPodHostPort, "rank", fallback.rank)
+			s.metrics.observeDataParallelMiss(true)
+			if fallback == s {
+				return false
+			}
+			fallback.serveDecoder(w, r)
 		} else {
 			// Shouldn't happen, send to default server
-			s.logger.V(4).Info("Didn't find the Data Parallel Proxy", "for", dataParallelPodHostPort)
-			w.WriteHeader(http.StatusBadRequest)
+			s.requestLogger(r).V(4).Info("Didn't find the Data Parallel Proxy", "for", dataParallelPodHostPort)
+			s.metrics.observeDataParallelMiss(false)
+			s.replyError(w, badRequestError(fmt.Errorf("unknown data parallel rank %s", dataParallelPodHostPort)))
 		}
 		return true
 	}
 
-	s.logger.V(4).Info("skip data parallel")
+	s.requestLogger(r).V(4).Info("skip data parallel")
 	return false
 }
 
+// checkDataParallelRank replies with an error when the request targets an unknown data parallel rank
+// without the fallback, before its prefill is sent to the prefiller for nothing. It returns false when
+// the request failed.
+func (s *Server) checkDataParallelRank(w http.ResponseWriter, r *http.Request) bool {
+	dataParallelPodHostPort := r.Header.Get(common.DataParallelPodHeader)
+	if !s.forwardDataParallel || dataParallelPodHostPort == "" || s.config.DataParallelFallback {
+		return true
+	}
+
+	s.dataParallelMu.RLock()
+	_, ok := s.dataParallelProxies[dataParallelPodHostPort]
+	s.dataParallelMu.RUnlock()
+	if !ok {
+		s.requestLogger(r).V(4).Info("Didn't find the Data Parallel Proxy", "for", dataParallelPodHostPort)
+		s.metrics.observeDataParallelMiss(false)
+		s.replyError(w, badRequestError(fmt.Errorf("unknown data parallel rank %s", dataParallelPodHostPort)))
+	}
+	return ok
+}
+
+// leastLoadedRank returns the server of the local data parallel rank with the fewest decodes in flight,
+// the ties being broken round-robin
+func (s *Server) leastLoadedRank() *Server {
+	ranks := []*Server{s}
+	s.dataParallelMu.RLock()
+	for _, rankServer := range s.dataParallelServers {
+		ranks = append(ranks, rankServer)
+	}
+	s.dataParallelMu.RUnlock()
+	slices.SortFunc(ranks, func(a, b *Server) int { return a.rank - b.rank })
+
+	start := s.fallbackRanks.Add(1)
+	least := ranks[start%uint64(len(ranks))]
+	for i := range uint64(len(ranks)) {
+		rank := ranks[(start+i)%uint64(len(ranks))]
+		if rank.decodesInFlight.Load() < least.decodesInFlight.Load() {
+			least = rank
+		}
+	}
+	return least
+}
+
 func (s *Server) startDataParallel(ctx context.Context, cert *tls.Certificate, grp *errgroup.Group) error {
-	podIP := os.Getenv("POD_IP")
-	basePort, err := strconv.Atoi(s.port)
-	if err != nil {
+	s.dataParallelMu.Lock()
+	s.dataParallelProxies[net.JoinHostPort(os.Getenv("POD_IP"), s.port)] = s.decoderProxy
+	s.dataParallelURLs[net.JoinHostPort(os.Getenv("POD_IP"), s.port)] = s.decoderURL
+	s.dataParallelMu.Unlock()
+
+	if err := s.resizeDataParallel(ctx, cert, grp, s.config.DataParallelSize); err != nil {
 		return err
 	}
-	baseDecoderPort, err := strconv.Atoi(s.decoderURL.Port())
-	if err != nil {
-		return err
+	if s.config.DataParallelDiscoveryInterval > 0 {
+		grp.Go(func() error {
+			s.discoverDataParallelSize(ctx, cert, grp)
+			return nil
+		})
 	}
+	return nil
+}
 
-	s.dataParallelProxies[net.JoinHostPort(podIP, s.port)] = s.decoderProxy
+// resizeDataParallel starts the proxies of the data parallel ranks up to the given size, and stops the
+// ones beyond it, their requests in flight being drained
+func (s *Server) resizeDataParallel(ctx context.Context, cert *tls.Certificate, grp *errgroup.Group, size int) error {
+	podIP := os.Getenv("POD_IP")
+
+	s.dataParallelMu.Lock()
+	defer s.dataParallelMu.Unlock()
 
-	// Fill in map of proxies, thus avoiding locks
-	for idx := range s.config.DataParallelSize - 1 {
-		decoderPort := strconv.Itoa(baseDecoderPort + idx + 1)
-		rankPort := strconv.Itoa(basePort + idx + 1)
+	for rank := len(s.dataParallelRanks) + 1; rank < size; rank++ {
+		rankPort, err := s.dataParallelPort(rank)
+		if err != nil {
+			return err
+		}
 		hostPort := net.JoinHostPort(podIP, rankPort)
-		rankURL, err := url.Parse(s.decoderURL.Scheme + "://localhost:" + decoderPort)
+		rankURL, err := s.dataParallelRankURL(rank)
 		if err != nil {
 			return err
 		}
-		handler := s.createDecoderProxyHandler(rankURL, s.config.DecoderInsecureSkipVerify)
-		s.dataParallelProxies[hostPort] = handler
-	}
+		s.dataParallelProxies[hostPort] = s.createDecoderProxyHandler(rankURL)
+		s.dataParallelURLs[hostPort] = rankURL
 
-	for idx := range s.config.DataParallelSize - 1 {
+		clone := s.Clone()
+		clone.logger = klog.FromContext(ctx).WithName("proxy server on port " + rankPort)
+		clone.port = rankPort
+		clone.decoderURL = rankURL
+		clone.forwardDataParallel = false
+		clone.rank = rank
+		clone.circuits = s.circuits.forRank()
+		// Configure handlers
+		clone.handler = clone.createRoutes()
+		s.dataParallelServers[hostPort] = clone
+
+		rankCtx, cancel := context.WithCancel(ctx)
+		s.dataParallelRanks = append(s.dataParallelRanks, cancel)
 		grp.Go(func() error {
-			rankPort := strconv.Itoa(basePort + idx + 1)
-			decoderPort := strconv.Itoa(baseDecoderPort + idx + 1)
-			decoderURL, err := url.Parse(s.decoderURL.Scheme + "://localhost:" + decoderPort)
-			if err != nil {
-				return err
-			}
+			return clone.startHTTP(rankCtx, cert)
+		})
+	}
 
-			clone := s.Clone()
-			clone.logger = klog.FromContext(ctx).WithName("proxy server on port " + rankPort)
-			clone.port = rankPort
-			clone.decoderURL = decoderURL
-			clone.forwardDataParallel = false
-			// Configure handlers
-			clone.handler = clone.createRoutes()
+	for rank := len(s.dataParallelRanks); rank >= max(size, 1); rank-- {
+		rankPort, err := s.dataParallelPort(rank)
+		if err != nil {
+			return err
+		}
+		hostPort := net.JoinHostPort(podIP, rankPort)
+		delete(s.dataParallelProxies, hostPort)
+		delete(s.dataParallelURLs, hostPort)
+		delete(s.dataParallelServers, hostPort)
+		s.dataParallelRanks[rank-1]()
+		s.dataParallelRanks = s.dataParallelRanks[:rank-1]
+	}
 
-			return clone.startHTTP(ctx, cert)
-		})
+	if s.config.ReadinessCheckAllRanks && s.readiness != nil {
+		s.readiness.setURLs(s.readinessURLs(len(s.dataParallelRanks) + 1))
 	}
 	return nil
 }
+
+// dataParallelSize returns the number of the data parallel ranks served, the first one included
+func (s *Server) dataParallelSize() int {
+	s.dataParallelMu.RLock()
+	defer s.dataParallelMu.RUnlock()
+	return len(s.dataParallelRanks) + 1
+}
+
+// dataParallelRankURL returns the URL of the local decoder of a data parallel rank, listening on the
+// port given by DataParallelPorts, or on the port of the decoder of the first rank plus the rank
+func (s *Server) dataParallelRankURL(rank int) (*url.URL, error) {
+	if ports, ok := s.config.DataParallelPorts[rank]; ok {
+		return url.Parse(s.decoderURL.Scheme + "://localhost:" + strconv.Itoa(ports.DecoderPort))
+	}
+	baseDecoderPort, err := strconv.Atoi(s.decoderURL.Port())
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(s.decoderURL.Scheme + "://localhost:" + strconv.Itoa(baseDecoderPort+rank))
+}
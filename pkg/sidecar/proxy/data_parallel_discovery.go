@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// maxDataParallelSize bounds the data parallel ranks probed by the discovery
+const maxDataParallelSize = 64
+
+// discoverDataParallelSize discovers the data parallel size of the local vLLM servers at startup, then
+// on each discovery interval, and resizes the proxies of the ranks when it changed
+func (s *Server) discoverDataParallelSize(ctx context.Context, cert *tls.Certificate, grp *errgroup.Group) {
+	client := &http.Client{Timeout: readinessRequestTimeout, Transport: s.decoderClientTransport()}
+	ticker := time.NewTicker(s.config.DataParallelDiscoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		if size, ok := s.probeDataParallelSize(ctx, client); ok && size != s.dataParallelSize() {
+			s.logger.Info("discovered a new data parallel size", "size", size)
+			if err := s.resizeDataParallel(ctx, cert, grp, size); err != nil {
+				s.logger.Error(err, "failed to resize the data parallel ranks", "size", size)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeDataParallelSize returns the number of the local vLLM servers listening on consecutive ports from
+// the decoder, the ranks listening on the port of the decoder of the first rank plus the rank. It returns
+// false until the decoder of the first rank is healthy, e.g. while the vLLM servers start, so that the
+// ranks are not stopped then. The other ranks are counted whatever their health, only an unreachable
+// one ends the ranks.
+func (s *Server) probeDataParallelSize(ctx context.Context, client *http.Client) (int, bool) {
+	if status, err := probeHealth(ctx, client, s.decoderURL); err != nil || status != http.StatusOK {
+		return 0, false
+	}
+
+	size := 1
+	for ; size < maxDataParallelSize; size++ {
+		rankURL, err := s.dataParallelRankURL(size)
+		if err != nil {
+			break
+		}
+		if _, err := probeHealth(ctx, client, rankURL); err != nil {
+			break
+		}
+	}
+	return size, true
+}
+
+// probeHealth returns the status code of the health endpoint of a local vLLM server, or the error
+// when it is not reachable
+func probeHealth(ctx context.Context, client *http.Client, decoderURL *url.URL) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, decoderURL.JoinPath(HealthPath).String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close() //nolint:all
+	return resp.StatusCode, nil
+}
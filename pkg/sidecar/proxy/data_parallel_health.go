@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DataParallelHealthPath is the path of the endpoint reporting the reachability and the readiness of
+// the local vLLM server of each data parallel rank, so that a wedged rank can be told apart from the
+// others of the pod
+const DataParallelHealthPath = "/dp/health"
+
+// dataParallelHealth is the health of the data parallel ranks reported by DataParallelHealthPath
+type dataParallelHealth struct {
+	Ready bool         `json:"ready"` // whether all the ranks are ready
+	Ranks []rankHealth `json:"ranks"`
+}
+
+// rankHealth is the health of the local vLLM server of a data parallel rank
+type rankHealth struct {
+	Rank       int     `json:"rank"`
+	Port       string  `json:"port"` // the port of the sidecar serving the rank
+	Decoder    string  `json:"decoder"`
+	Reachable  bool    `json:"reachable"`
+	Ready      bool    `json:"ready"`
+	StatusCode int     `json:"status_code,omitempty"`
+	LatencyMs  float64 `json:"latency_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// dataParallelHealthHandler probes the health endpoint of the local vLLM servers of all the data
+// parallel ranks concurrently, and reports each of them
+func (s *Server) dataParallelHealthHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessRequestTimeout)
+	defer cancel()
+
+	client := &http.Client{Transport: s.decoderClientTransport()}
+	decoders := s.rankDecoders()
+	health := dataParallelHealth{Ready: true, Ranks: make([]rankHealth, len(decoders))}
+	var wg sync.WaitGroup
+	for i, decoder := range decoders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			status, err := probeHealth(ctx, client, decoder.url)
+			port, _ := s.dataParallelPort(decoder.rank)
+			health.Ranks[i] = rankHealth{
+				Rank:       decoder.rank,
+				Port:       port,
+				Decoder:    decoder.url.String(),
+				Reachable:  err == nil,
+				Ready:      err == nil && status == http.StatusOK,
+				StatusCode: status,
+				LatencyMs:  float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				health.Ranks[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, rank := range health.Ranks {
+		health.Ready = health.Ready && rank.Ready
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health) //nolint:all
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Data parallel health", func() {
+	newVLLM := func(status int) *url.URL {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal(HealthPath))
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(server.Close)
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		return serverURL
+	}
+
+	getHealth := func(proxy *Server) dataParallelHealth {
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		resp, err := http.Get(server.URL + DataParallelHealthPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var health dataParallelHealth
+		Expect(json.NewDecoder(resp.Body).Decode(&health)).To(Succeed())
+		return health
+	}
+
+	It("should report the reachability and the readiness of each rank", func() {
+		rank0, rank1 := newVLLM(http.StatusOK), newVLLM(http.StatusServiceUnavailable)
+		proxy := NewProxy("8000", rank0, Config{})
+		proxy.dataParallelURLs["10.0.0.1:8000"] = rank0
+		proxy.dataParallelURLs["10.0.0.1:8001"] = rank1
+		proxy.dataParallelURLs["10.0.0.1:8002"] = &url.URL{Scheme: "http", Host: "127.0.0.1:1"}
+
+		health := getHealth(proxy)
+
+		Expect(health.Ready).To(BeFalse())
+		Expect(health.Ranks).To(HaveLen(3))
+		Expect(health.Ranks[2].Error).ToNot(BeEmpty())
+		for i := range health.Ranks {
+			health.Ranks[i].LatencyMs, health.Ranks[i].Error = 0, ""
+		}
+		Expect(health.Ranks).To(Equal([]rankHealth{
+			{Rank: 0, Port: "8000", Decoder: rank0.String(), Reachable: true, Ready: true, StatusCode: http.StatusOK},
+			{Rank: 1, Port: "8001", Decoder: rank1.String(), Reachable: true, StatusCode: http.StatusServiceUnavailable},
+			{Rank: 2, Port: "8002", Decoder: "http://127.0.0.1:1"},
+		}))
+	})
+
+	It("should only report its own rank on the servers of the other ranks", func() {
+		proxy := NewProxy("8001", newVLLM(http.StatusOK), Config{})
+		proxy.forwardDataParallel = false
+		proxy.rank = 1
+
+		health := getHealth(proxy)
+
+		Expect(health.Ready).To(BeTrue())
+		Expect(health.Ranks).To(HaveLen(1))
+		Expect(health.Ranks[0].Rank).To(Equal(1))
+		Expect(health.Ranks[0].Port).To(Equal("8001"))
+	})
+})
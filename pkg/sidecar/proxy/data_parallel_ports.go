@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DataParallelPort are the ports of a data parallel rank: the port the sidecar listens on for the rank,
+// and the port of the local vLLM server of the rank
+type DataParallelPort struct {
+	Port        int
+	DecoderPort int
+}
+
+// ParseDataParallelPorts parses the ports of the data parallel ranks after the first one, comma separated
+// <rank>=<sidecar port>:<vLLM port> entries, e.g. for the vLLM servers listening on non-contiguous ports
+func ParseDataParallelPorts(value string) (map[int]DataParallelPort, error) {
+	ports := map[int]DataParallelPort{}
+	used := map[int]int{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rankValue, portsValue, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid data parallel ports '%s', expected <rank>=<sidecar port>:<vLLM port>", entry)
+		}
+		rank, err := strconv.Atoi(rankValue)
+		if err != nil || rank < 1 {
+			return nil, fmt.Errorf("invalid data parallel rank '%s', the ranks after the first one are mapped", rankValue)
+		}
+		if _, ok := ports[rank]; ok {
+			return nil, fmt.Errorf("the data parallel rank %d is mapped twice", rank)
+		}
+		portValue, decoderPortValue, ok := strings.Cut(portsValue, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid data parallel ports '%s', expected <rank>=<sidecar port>:<vLLM port>", entry)
+		}
+		port, err := parsePort(portValue)
+		if err != nil {
+			return nil, err
+		}
+		decoderPort, err := parsePort(decoderPortValue)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range []int{port, decoderPort} {
+			if other, ok := used[p]; ok {
+				return nil, fmt.Errorf("the port %d of the data parallel rank %d is already used by the rank %d", p, rank, other)
+			}
+			used[p] = rank
+		}
+		ports[rank] = DataParallelPort{Port: port, DecoderPort: decoderPort}
+	}
+	return ports, nil
+}
+
+// parsePort parses a TCP port
+func parsePort(value string) (int, error) {
+	port, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port '%s'", value)
+	}
+	return int(port), nil
+}
+
+// dataParallelPort returns the port the sidecar listens on for a data parallel rank: the one given by
+// DataParallelPorts, or the port of the first rank plus the rank
+func (s *Server) dataParallelPort(rank int) (string, error) {
+	if rank == s.rank {
+		return s.port, nil
+	}
+	if ports, ok := s.config.DataParallelPorts[rank]; ok {
+		return strconv.Itoa(ports.Port), nil
+	}
+	basePort, err := strconv.Atoi(s.port)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(basePort + rank - s.rank), nil
+}
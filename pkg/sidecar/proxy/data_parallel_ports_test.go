@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Data parallel ports", func() {
+	It("should parse the ports of the ranks", func() {
+		ports, err := ParseDataParallelPorts(" 1=9000:9100, 3=9300:9400,")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(Equal(map[int]DataParallelPort{
+			1: {Port: 9000, DecoderPort: 9100},
+			3: {Port: 9300, DecoderPort: 9400},
+		}))
+
+		ports, err = ParseDataParallelPorts("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(BeEmpty())
+	})
+
+	DescribeTable("should reject the invalid ports",
+		func(value string) {
+			_, err := ParseDataParallelPorts(value)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing rank", "9000:9100"),
+		Entry("missing vLLM port", "1=9000"),
+		Entry("first rank", "0=9000:9100"),
+		Entry("invalid rank", "one=9000:9100"),
+		Entry("invalid port", "1=90000:9100"),
+		Entry("zero port", "1=9000:0"),
+		Entry("rank mapped twice", "1=9000:9100,1=9200:9300"),
+		Entry("port used twice", "1=9000:9100,2=9200:9000"),
+	)
+
+	It("should map the ranks to their ports, the other ranks keeping the contiguous ports", func() {
+		decodeURL, err := url.Parse("http://localhost:8200")
+		Expect(err).ToNot(HaveOccurred())
+		proxy := NewProxy("8000", decodeURL, Config{
+			Connector:         ConnectorNIXLV2,
+			DataParallelPorts: map[int]DataParallelPort{2: {Port: 9000, DecoderPort: 9100}},
+		})
+
+		port, err := proxy.dataParallelPort(2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal("9000"))
+		port, err = proxy.dataParallelPort(1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal("8001"))
+
+		rankURL, err := proxy.dataParallelRankURL(2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rankURL.String()).To(Equal("http://localhost:9100"))
+		rankURL, err = proxy.dataParallelRankURL(1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rankURL.String()).To(Equal("http://localhost:8201"))
+
+		Expect(proxy.dataParallelRank("10.0.0.1:9000")).To(Equal(2))
+		Expect(proxy.dataParallelRank("10.0.0.1:8001")).To(Equal(1))
+	})
+})
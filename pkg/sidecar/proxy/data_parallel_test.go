@@ -2,13 +2,25 @@ package proxy
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2" // nolint:revive
 	. "github.com/onsi/gomega"    // nolint:revive
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/klog/v2/ktesting"
 
@@ -21,6 +33,30 @@ const (
 	testDataParallelSize = 2
 )
 
+// listenConsecutive listens on the given number of consecutive ports of the loopback interface
+func listenConsecutive(count int) []net.Listener {
+	for range 10 {
+		first, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		listeners := []net.Listener{first}
+		for i := 1; i < count; i++ {
+			ln, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(first.Addr().(*net.TCPAddr).Port+i))
+			if err != nil {
+				break
+			}
+			listeners = append(listeners, ln)
+		}
+		if len(listeners) == count {
+			return listeners
+		}
+		for _, ln := range listeners {
+			ln.Close() //nolint:all
+		}
+	}
+	Fail("no consecutive free ports")
+	return nil
+}
+
 var _ = Describe("Data Parallel support", func() {
 	When("configured with --data-parallel-size > 1", func() {
 		It("should create an extra proxy", func() {
@@ -96,5 +132,359 @@ var _ = Describe("Data Parallel support", func() {
 			err = grp.Wait()
 			Expect(err).ToNot(HaveOccurred())
 		})
+
+		It("should proxy the ranks on the ports they are mapped to", func() {
+			_, ctx := ktesting.NewTestContext(GinkgoT())
+			ctx, cancel := context.WithCancel(ctx)
+			grp, ctx := errgroup.WithContext(ctx)
+
+			DeferCleanup(os.Setenv, "POD_IP", os.Getenv("POD_IP"))
+			Expect(os.Setenv("POD_IP", "127.0.0.1")).To(Succeed())
+
+			rank0Server := httptest.NewServer(&sidecarmock.GenericHandler{})
+			defer rank0Server.Close()
+			rank1Handler := sidecarmock.GenericHandler{}
+			rank1Server := httptest.NewServer(&rank1Handler)
+			defer rank1Server.Close()
+			decodeURL, err := url.Parse(rank0Server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			freePort, err := testutils.GetFreePort()
+			Expect(err).ToNot(HaveOccurred())
+			rankPort, err := strconv.Atoi(freePort)
+			Expect(err).ToNot(HaveOccurred())
+
+			theProxy := NewProxy("0", decodeURL, Config{
+				Connector:        ConnectorNIXLV2,
+				DataParallelSize: testDataParallelSize,
+				DataParallelPorts: map[int]DataParallelPort{
+					1: {Port: rankPort, DecoderPort: rank1Server.Listener.Addr().(*net.TCPAddr).Port},
+				},
+			})
+			theProxy.allowlistValidator = &AllowlistValidator{enabled: false}
+			proxyHandler := theProxy.createRoutes()
+			Expect(theProxy.startDataParallel(ctx, nil, grp)).To(Succeed())
+
+			rankHostPort := "127.0.0.1:" + freePort
+			Expect(theProxy.dataParallelURLs).To(HaveKeyWithValue(rankHostPort, HaveField("Port()", strconv.Itoa(rank1Server.Listener.Addr().(*net.TCPAddr).Port))))
+
+			req := httptest.NewRequest(http.MethodPost, CompletionsPath, nil)
+			req.Header.Add(common.DataParallelPodHeader, rankHostPort)
+			proxyHandler.ServeHTTP(httptest.NewRecorder(), req)
+			Expect(int(rank1Handler.RequestCount.Load())).To(Equal(1))
+
+			Eventually(func() (int, error) {
+				resp, err := http.Post("http://"+rankHostPort+CompletionsPath, "application/json", strings.NewReader(`{}`))
+				if err != nil {
+					return 0, err
+				}
+				resp.Body.Close() //nolint:all
+				return resp.StatusCode, nil
+			}).Should(Equal(http.StatusOK))
+			Expect(int(rank1Handler.RequestCount.Load())).To(Equal(2))
+
+			cancel()
+			Expect(grp.Wait()).To(Succeed())
+		})
+	})
+
+	When("discovering the data parallel size", func() {
+		// startDecoders starts fake vLLM servers on consecutive ports replying with the given health statuses
+		startDecoders := func(statuses ...*atomic.Int32) ([]*httptest.Server, *url.URL) {
+			var servers []*httptest.Server
+			for i, ln := range listenConsecutive(len(statuses)) {
+				server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(int(statuses[i].Load()))
+				}))
+				server.Listener.Close() //nolint:all
+				server.Listener = ln
+				server.Start()
+				DeferCleanup(server.Close)
+				servers = append(servers, server)
+			}
+			decodeURL, err := url.Parse("http://localhost:" + strconv.Itoa(servers[0].Listener.Addr().(*net.TCPAddr).Port))
+			Expect(err).ToNot(HaveOccurred())
+			return servers, decodeURL
+		}
+
+		status := func(code int32) *atomic.Int32 {
+			status := &atomic.Int32{}
+			status.Store(code)
+			return status
+		}
+
+		It("should count the local vLLM servers on consecutive ports once the first one is healthy", func() {
+			rank0, rank1 := status(http.StatusServiceUnavailable), status(http.StatusServiceUnavailable)
+			servers, decodeURL := startDecoders(rank0, rank1, status(http.StatusOK))
+			proxy := NewProxy("0", decodeURL, Config{})
+			client := &http.Client{Timeout: time.Second}
+
+			_, ok := proxy.probeDataParallelSize(context.Background(), client)
+			Expect(ok).To(BeFalse())
+
+			rank0.Store(http.StatusOK)
+			size, ok := proxy.probeDataParallelSize(context.Background(), client)
+			Expect(ok).To(BeTrue())
+			Expect(size).To(Equal(3))
+
+			servers[1].Close()
+			size, _ = proxy.probeDataParallelSize(context.Background(), client)
+			Expect(size).To(Equal(1))
+		})
+
+		It("should start and stop the proxies of the ranks", func() {
+			_, ctx := ktesting.NewTestContext(GinkgoT())
+			ctx, cancel := context.WithCancel(ctx)
+			grp, ctx := errgroup.WithContext(ctx)
+
+			DeferCleanup(os.Setenv, "POD_IP", os.Getenv("POD_IP"))
+			Expect(os.Setenv("POD_IP", "127.0.0.1")).To(Succeed())
+
+			listeners := listenConsecutive(3)
+			basePort := listeners[0].Addr().(*net.TCPAddr).Port
+			for _, ln := range listeners {
+				ln.Close() //nolint:all
+			}
+			decoders, decodeURL := startDecoders(status(http.StatusOK), status(http.StatusOK))
+
+			proxy := NewProxy(strconv.Itoa(basePort), decodeURL, Config{DataParallelDiscoveryInterval: 10 * time.Millisecond})
+			proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+			proxy.handler = proxy.createRoutes()
+			Expect(proxy.startDataParallel(ctx, nil, grp)).To(Succeed())
+
+			Eventually(proxy.dataParallelSize).Should(Equal(2))
+			rankHostPort := "127.0.0.1:" + strconv.Itoa(basePort+1)
+			Eventually(func() error {
+				conn, err := net.Dial("tcp", rankHostPort)
+				if err == nil {
+					conn.Close() //nolint:all
+				}
+				return err
+			}).Should(Succeed())
+
+			decoders[1].Close()
+			Eventually(proxy.dataParallelSize).Should(Equal(1))
+			proxy.dataParallelMu.RLock()
+			Expect(proxy.dataParallelProxies).ToNot(HaveKey(rankHostPort))
+			proxy.dataParallelMu.RUnlock()
+			Eventually(func() error {
+				conn, err := net.Dial("tcp", rankHostPort)
+				if err == nil {
+					conn.Close() //nolint:all
+				}
+				return err
+			}).Should(HaveOccurred())
+
+			cancel()
+			Expect(grp.Wait()).To(Succeed())
+		})
+	})
+
+	When("serving TLS", func() {
+		// writeCertificate writes the certificate and its key to the tls.crt and tls.key files of the directory
+		writeCertificate := func(dir string, cert tls.Certificate) {
+			keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, "tls.crt"),
+				pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "tls.key"),
+				pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)).To(Succeed())
+		}
+
+		It("should serve the same certificate and client certificate authentication on the ports of all the ranks", func() {
+			_, ctx := ktesting.NewTestContext(GinkgoT())
+			DeferCleanup(os.Setenv, "POD_IP", os.Getenv("POD_IP"))
+			Expect(os.Setenv("POD_IP", "127.0.0.1")).To(Succeed())
+
+			// the vLLM servers of the ranks, on consecutive ports
+			var requests [2]atomic.Int32
+			var decodeURL *url.URL
+			for rank, ln := range listenConsecutive(2) {
+				decoder := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					requests[rank].Add(1)
+					w.WriteHeader(http.StatusOK)
+				}))
+				decoder.Listener.Close() //nolint:all
+				decoder.Listener = ln
+				decoder.Start()
+				DeferCleanup(decoder.Close)
+				if rank == 0 {
+					var err error
+					decodeURL, err = url.Parse(decoder.URL)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}
+			listeners := listenConsecutive(2)
+			basePort := listeners[0].Addr().(*net.TCPAddr).Port
+			for _, ln := range listeners {
+				ln.Close() //nolint:all
+			}
+
+			serverCA, clientCA := newTestCertificateAuthority(), newTestCertificateAuthority()
+			clientCAs, err := LoadCertPool(clientCA.writePEM())
+			Expect(err).ToNot(HaveOccurred())
+			certPath := GinkgoT().TempDir()
+			serverCert := serverCA.issue(x509.ExtKeyUsageServerAuth, "localhost")
+			writeCertificate(certPath, serverCert)
+
+			proxy := NewProxy(strconv.Itoa(basePort), decodeURL, Config{DataParallelSize: 2, ClientCAs: clientCAs,
+				CertPath: certPath, CertReloadInterval: 10 * time.Millisecond})
+			ctx, cancelFn := context.WithCancel(ctx)
+			stoppedCh := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				Expect(proxy.Start(ctx, &serverCert, &AllowlistValidator{enabled: false})).To(Succeed())
+				close(stoppedCh)
+			}()
+			DeferCleanup(func() {
+				cancelFn()
+				<-stoppedCh
+			})
+
+			rank1HostPort := "127.0.0.1:" + strconv.Itoa(basePort+1)
+			send := func(hostPort string, header http.Header, certs ...tls.Certificate) (*http.Response, error) {
+				client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs}, //nolint:gosec // verified by the test
+				}}
+				req, err := http.NewRequest(http.MethodGet, "https://"+hostPort+"/v1/models", nil)
+				Expect(err).ToNot(HaveOccurred())
+				if header != nil {
+					// a completion request, routed to the rank of the header
+					req, err = http.NewRequest(http.MethodPost, "https://"+hostPort+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+					Expect(err).ToNot(HaveOccurred())
+					req.Header = header
+				}
+				resp, err := client.Do(req)
+				if err == nil {
+					resp.Body.Close() //nolint:all
+				}
+				return resp, err
+			}
+			clientCert := clientCA.issue(x509.ExtKeyUsageClientAuth)
+
+			Eventually(func() error {
+				_, err := send(rank1HostPort, nil, clientCert)
+				return err
+			}).Should(Succeed())
+			Expect(requests[1].Load()).To(BeNumerically("==", 1))
+
+			resp, err := send(rank1HostPort, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(resp.TLS.PeerCertificates[0].Raw).To(Equal(serverCert.Certificate[0]))
+
+			// the requests of the rank sent to the port of the first rank are forwarded to the vLLM server of the rank
+			resp, err = send("127.0.0.1:"+strconv.Itoa(basePort), http.Header{common.DataParallelPodHeader: {rank1HostPort}}, clientCert)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(requests[1].Load()).To(BeNumerically("==", 2))
+			Expect(requests[0].Load()).To(BeNumerically("==", 0))
+
+			// the renewed certificate is served on the ports of all the ranks
+			renewedCert := serverCA.issue(x509.ExtKeyUsageServerAuth, "localhost")
+			writeCertificate(certPath, renewedCert)
+			for _, hostPort := range []string{"127.0.0.1:" + strconv.Itoa(basePort), rank1HostPort} {
+				Eventually(func() []byte {
+					resp, err := send(hostPort, nil)
+					Expect(err).ToNot(HaveOccurred())
+					return resp.TLS.PeerCertificates[0].Raw
+				}).Should(Equal(renewedCert.Certificate[0]))
+			}
+		})
+	})
+
+	When("disaggregating the requests of a rank", func() {
+		const rankHostPort = "10.0.0.1:8001"
+
+		var (
+			server          *Server
+			decoder         *recordingTransport
+			rankDecoder     *recordingTransport
+			prefillRequests *[][]byte
+		)
+
+		BeforeEach(func() {
+			server, decoder, prefillRequests = newInProcessProxy(ConnectorNIXLV2, []byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`))
+			server.config.RoutingResponseHeaders = true
+			var err error
+			server.allowlistValidator, err = NewAllowlistValidator(false, "", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			rankURL, err := url.Parse("http://decoder.local:8201")
+			Expect(err).ToNot(HaveOccurred())
+			rankServer := server.Clone()
+			rankServer.decoderURL = rankURL
+			rankServer.forwardDataParallel = false
+			rankServer.rank = 1
+			rankDecoder = &recordingTransport{}
+			rankServer.decoderProxy = httputil.NewSingleHostReverseProxy(rankURL)
+			rankServer.decoderProxy.Transport = rankDecoder
+			server.dataParallelProxies[rankHostPort] = rankServer.decoderProxy
+			server.dataParallelServers[rankHostPort] = rankServer
+		})
+
+		send := func(dataParallelPodHostPort string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+			req.Header.Set(common.PrefillPodHeader, inProcessPrefillHostPort)
+			req.Header.Set(common.DataParallelPodHeader, dataParallelPodHostPort)
+			rec := httptest.NewRecorder()
+			server.chatCompletionsHandler(rec, req)
+			return rec
+		}
+
+		It("should run the connector protocol against the decoder of the rank", func() {
+			rec := send(rankHostPort)
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(*prefillRequests).To(HaveLen(1))
+			Expect(decoder.bodies).To(BeEmpty())
+			Expect(rankDecoder.bodies).To(HaveLen(1))
+			Expect(string(rankDecoder.bodies[0])).To(ContainSubstring(`"kv_transfer_params":{"remote_engine_id":"e"}`))
+			Expect(rec.Header().Get(RoutingDecodeRankHeader)).To(Equal("1"))
+			Expect(rec.Header().Get(RoutingConnectorHeader)).To(Equal(ConnectorNIXLV2))
+		})
+
+		It("should reject an unknown rank before sending the prefill", func() {
+			rec := send("10.0.0.1:8009")
+
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(*prefillRequests).To(BeEmpty())
+			Expect(decoder.bodies).To(BeEmpty())
+			Expect(rankDecoder.bodies).To(BeEmpty())
+			Expect(testutil.ToFloat64(server.metrics.dataParallelMiss.WithLabelValues("rejected"))).To(Equal(1.0))
+		})
+
+		It("should fall back to the least loaded rank for an unknown rank", func() {
+			server.config.DataParallelFallback = true
+			server.decodesInFlight.Store(2)
+			server.dataParallelServers[rankHostPort].decodesInFlight.Store(1)
+
+			rec := send("10.0.0.1:8009")
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(*prefillRequests).To(HaveLen(1))
+			Expect(decoder.bodies).To(BeEmpty())
+			Expect(rankDecoder.bodies).To(HaveLen(1))
+			Expect(rec.Header().Get(RoutingDecodeRankHeader)).To(Equal("1"))
+			Expect(testutil.ToFloat64(server.metrics.dataParallelMiss.WithLabelValues("fallback"))).To(Equal(1.0))
+
+			server.decodesInFlight.Store(0)
+			rec = send("10.0.0.1:8009")
+
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(decoder.bodies).To(HaveLen(1))
+			Expect(rec.Header().Get(RoutingDecodeRankHeader)).To(Equal("0"))
+		})
+
+		It("should spread the fallback round-robin over the ranks equally loaded", func() {
+			server.config.DataParallelFallback = true
+
+			ranks := map[string]int{}
+			for range 4 {
+				ranks[send("10.0.0.1:8009").Header().Get(RoutingDecodeRankHeader)]++
+			}
+			Expect(ranks).To(Equal(map[string]int{"0": 2, "1": 2}))
+			Expect(decoder.bodies).To(HaveLen(2))
+			Expect(rankDecoder.bodies).To(HaveLen(2))
+		})
 	})
 })
@@ -0,0 +1,163 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DrainPath is the path of the admin endpoint draining the sidecar. It is only served to the
+	// local clients, e.g. the preStop hook of the pod.
+	DrainPath = "/drain"
+
+	// DefaultDrainTimeout is the default time the sidecar waits for the requests in flight on shutdown
+	DefaultDrainTimeout = 60 * time.Second
+)
+
+// drainStatus is the response of the drain endpoint
+type drainStatus struct {
+	Draining bool `json:"draining"`
+	InFlight int  `json:"in_flight"`
+}
+
+// drainer counts the inference requests in flight and rejects the new ones once draining, so that the
+// pod only terminates once the outstanding prefill/decode exchanges completed. It is shared by the
+// servers of all the data parallel ranks.
+type drainer struct {
+	mutex    sync.Mutex
+	draining bool
+	inFlight int
+	drained  chan struct{} // closed once draining with no request in flight
+}
+
+func newDrainer() *drainer {
+	return &drainer{drained: make(chan struct{})}
+}
+
+// acquire counts a request in flight, and returns false when draining
+func (d *drainer) acquire() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.inFlight++
+	return true
+}
+
+// release counts a request out of flight
+func (d *drainer) release() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.inFlight--
+	d.closeIfDrained()
+}
+
+// drain stops accepting new requests, and returns a channel closed once no request is in flight
+func (d *drainer) drain() <-chan struct{} {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.draining = true
+	d.closeIfDrained()
+	return d.drained
+}
+
+// closeIfDrained closes the drained channel once draining with no request in flight, the mutex being held
+func (d *drainer) closeIfDrained() {
+	if !d.draining || d.inFlight > 0 {
+		return
+	}
+	select {
+	case <-d.drained:
+	default:
+		close(d.drained)
+	}
+}
+
+func (d *drainer) status() drainStatus {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return drainStatus{Draining: d.draining, InFlight: d.inFlight}
+}
+
+// trackInFlight serves an inference request counted in flight, and rejects it with a 503 once draining
+func (s *Server) trackInFlight(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.drainer.acquire() {
+			s.requestLogger(r).V(4).Info("sidecar draining, rejecting request", "path", r.URL.Path)
+			s.replyError(w, drainingError())
+			return
+		}
+		defer s.drainer.release()
+		defer s.metrics.observeInFlight()()
+
+		next(w, r)
+	}
+}
+
+// drain stops accepting new inference requests, and waits for the requests in flight to complete
+// until the context is done. It returns whether all the requests completed.
+func (s *Server) drain(ctx context.Context) bool {
+	drained := s.drainer.drain()
+	s.logger.Info("draining", "inFlight", s.drainer.status().InFlight)
+
+	select {
+	case <-drained:
+		s.logger.Info("drained")
+		return true
+	case <-ctx.Done():
+		s.logger.Info("drain interrupted, requests still in flight", "inFlight", s.drainer.status().InFlight)
+		return false
+	}
+}
+
+// drainHandler reports the drain status on GET. On POST, it drains the sidecar and replies once the
+// requests in flight completed, or the client gave up waiting.
+func (s *Server) drainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "Forbidden: the sidecar can only be drained from the pod", http.StatusForbidden)
+			return
+		}
+		if !s.drain(r.Context()) {
+			return // the client is gone
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.drainer.status()) //nolint:all
+}
+
+// isLoopback returns whether the remote address of a request is a loopback address
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Drain", func() {
+	It("should be drained once the requests in flight completed", func() {
+		d := newDrainer()
+		Expect(d.acquire()).To(BeTrue())
+
+		drained := d.drain()
+		Expect(d.acquire()).To(BeFalse())
+		Consistently(drained, "50ms").ShouldNot(BeClosed())
+		Expect(d.status()).To(Equal(drainStatus{Draining: true, InFlight: 1}))
+
+		d.release()
+		Expect(drained).To(BeClosed())
+		Expect(d.drain()).To(BeClosed())
+	})
+
+	Context("with a decoder answering once released", func() {
+		var (
+			server   *httptest.Server
+			proxy    *Server
+			released chan struct{}
+		)
+
+		BeforeEach(func() {
+			released = make(chan struct{})
+			decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body) //nolint:all
+				<-released
+				w.WriteHeader(http.StatusOK)
+			}))
+			DeferCleanup(decodeBackend.Close)
+			DeferCleanup(func() {
+				select {
+				case <-released:
+				default:
+					close(released)
+				}
+			})
+			decodeURL, err := url.Parse(decodeBackend.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			proxy = NewProxy("0", decodeURL, Config{})
+			server = httptest.NewServer(proxy.createRoutes())
+			DeferCleanup(server.Close)
+		})
+
+		sendCompletion := func() int {
+			resp, err := http.Post(server.URL+CompletionsPath, "application/json", strings.NewReader(`{"model":"m","prompt":"hi"}`))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close() //nolint:all
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			return resp.StatusCode
+		}
+
+		getStatus := func() drainStatus {
+			resp, err := http.Get(server.URL + DrainPath)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close() //nolint:all
+			var status drainStatus
+			Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+			return status
+		}
+
+		It("should wait for the requests in flight and reject the new ones", func() {
+			codes := make(chan int, 1)
+			go func() {
+				defer GinkgoRecover()
+				codes <- sendCompletion()
+			}()
+			Eventually(getStatus).Should(Equal(drainStatus{InFlight: 1}))
+			Expect(testutil.ToFloat64(proxy.metrics.inFlight)).To(Equal(1.0))
+
+			drained := make(chan drainStatus, 1)
+			go func() {
+				defer GinkgoRecover()
+				resp, err := http.Post(server.URL+DrainPath, "", nil)
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close() //nolint:all
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				var status drainStatus
+				Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+				drained <- status
+			}()
+			Eventually(getStatus).Should(Equal(drainStatus{Draining: true, InFlight: 1}))
+			Expect(sendCompletion()).To(Equal(http.StatusServiceUnavailable))
+			Consistently(drained, "50ms").ShouldNot(Receive())
+
+			close(released)
+			Eventually(codes).Should(Receive(Equal(http.StatusOK)))
+			Eventually(drained).Should(Receive(Equal(drainStatus{Draining: true})))
+			Expect(testutil.ToFloat64(proxy.metrics.inFlight)).To(Equal(0.0))
+		})
+
+		It("should only be drained from the pod", func() {
+			req := httptest.NewRequest(http.MethodPost, DrainPath, nil)
+			req.RemoteAddr = "192.0.2.1:4321"
+			recorder := httptest.NewRecorder()
+
+			proxy.drainHandler(recorder, req)
+
+			Expect(recorder.Code).To(Equal(http.StatusForbidden))
+			Expect(getStatus()).To(Equal(drainStatus{}))
+		})
+	})
+})
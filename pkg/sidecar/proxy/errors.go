@@ -18,39 +18,64 @@ package proxy
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 )
 
-// vLLM error response
-type errorResponse struct {
-	Object  string `json:"object"`
-	Message string `json:"message"`
-	Type    string `json:"type"`
-	Param   string `json:"param"`
-	Code    int    `json:"code"`
-}
+// errorKind classifies the errors replied by the sidecar. The kind is reported in the error responses,
+// the logs and the labels of the errors metric, so that a failure can be followed across them.
+type errorKind string
 
-var decoderServiceUnavailableResponseJSON []byte
+const (
+	// errorKindBadRequest classifies the invalid requests
+	errorKindBadRequest errorKind = "bad_request"
+	// errorKindUnauthenticated classifies the requests without a verified client certificate
+	errorKindUnauthenticated errorKind = "unauthenticated"
+	// errorKindSSRFBlocked classifies the requests whose prefill targets are denied by the SSRF protection
+	errorKindSSRFBlocked errorKind = "ssrf_blocked"
+	// errorKindPrefillUnreachable classifies the prefill requests not answered by the prefiller:
+	// connection errors, timeouts and open circuits
+	errorKindPrefillUnreachable errorKind = "prefill_unreachable"
+	// errorKindPrefillRejected classifies the prefill requests answered with an error by the prefiller
+	errorKindPrefillRejected errorKind = "prefill_rejected"
+	// errorKindPrefillInvalid classifies the successful prefill responses the KV-transfer parameters could
+	// not be extracted from
+	errorKindPrefillInvalid errorKind = "prefill_invalid"
+	// errorKindDecodeUnreachable classifies the requests the local decoder could not be reached for
+	errorKindDecodeUnreachable errorKind = "decode_unreachable"
+	// errorKindDecodeOverloaded classifies the requests rejected to protect the local decoder: open
+	// circuit, concurrency limit, tenant quotas and token rate limits
+	errorKindDecodeOverloaded errorKind = "decode_overloaded"
+	// errorKindDraining classifies the requests rejected by a draining sidecar
+	errorKindDraining errorKind = "draining"
+	// errorKindInternal classifies the failures of the sidecar itself
+	errorKindInternal errorKind = "internal"
+)
 
-func init() {
-	response := errorResponse{
-		Object:  "error",
-		Message: "The decode node is not ready. Please check that the vLLM service is running and the port configuration is correct.",
-		Type:    "ServiceUnavailable",
-		Code:    http.StatusServiceUnavailable,
-	}
-	decoderServiceUnavailableResponseJSON, _ = json.Marshal(response)
+// vLLM error response
+type errorResponse struct {
+	Object  string    `json:"object"`
+	Message string    `json:"message"`
+	Type    string    `json:"type"`
+	Param   string    `json:"param"`
+	Code    int       `json:"code"`
+	Kind    errorKind `json:"kind,omitempty"`
 }
 
-func errorJSONInvalid(err error, w http.ResponseWriter) error {
-	return sendError(err, "BadRequestError", http.StatusBadRequest, w)
+// sidecarError is an error replied by the sidecar, in the format of the vLLM errors
+type sidecarError struct {
+	kind      errorKind
+	errorType string // the vLLM error type, e.g. BadRequestError
+	code      int
+	message   string
 }
 
-func errorBadGateway(err error, w http.ResponseWriter) error {
-	return sendError(err, "BadGateway", http.StatusBadGateway, w)
+func (e *sidecarError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind, e.message)
 }
 
-// sendError simulates vLLM errors
+// write simulates vLLM errors
 //
 // Example:
 //
@@ -59,22 +84,156 @@ func errorBadGateway(err error, w http.ResponseWriter) error {
 //		  "message": "[{'type': 'json_invalid', 'loc': ('body', 167), 'msg': 'JSON decode error', 'input': {}, 'ctx': {'error': 'Invalid control character at'}}]",
 //		  "type": "BadRequestError",
 //		  "param": null,
-//		  "code": 400
+//		  "code": 400,
+//		  "kind": "bad_request"
 //	 }
-func sendError(err error, errorType string, code int, w http.ResponseWriter) error {
-	er := errorResponse{
+func (e *sidecarError) write(w http.ResponseWriter) error {
+	b, err := json.Marshal(errorResponse{
 		Object:  "error",
-		Message: err.Error(),
-		Type:    errorType,
-		Code:    code,
-	}
-
-	b, err := json.Marshal(er)
+		Message: e.message,
+		Type:    e.errorType,
+		Code:    e.code,
+		Kind:    e.kind,
+	})
 	if err != nil {
 		return err
 	}
 
-	w.WriteHeader(code)
+	if bw, ok := w.(interface{ setErrorKind(errorKind) }); ok {
+		bw.setErrorKind(e.kind) // the buffered prefill responses
+	}
+	w.WriteHeader(e.code)
 	_, err = w.Write(b)
 	return err
 }
+
+// replyError replies with the error to the client, logging it and counting it by kind
+func (s *Server) replyError(w http.ResponseWriter, e *sidecarError) {
+	s.logger.V(4).Info("replying with an error", "errorKind", e.kind, "code", e.code, "message", e.message)
+	s.metrics.observeError(e.kind)
+	if err := e.write(w); err != nil {
+		s.logger.Error(err, "failed to send error response to client")
+	}
+}
+
+// bufferError writes the error of a prefill request to its buffered response. It is only replied to
+// the client, and counted, when all the prefill targets failed.
+func (s *Server) bufferError(pw *bufferedResponseWriter, e *sidecarError) {
+	s.logger.V(4).Info("prefill request failed", "errorKind", e.kind, "code", e.code, "message", e.message)
+	if err := e.write(pw); err != nil {
+		s.logger.Error(err, "failed to buffer error response")
+	}
+}
+
+// errRequestNotObject is returned when the request body is valid JSON but not a JSON object
+var errRequestNotObject = errors.New("request body must be a JSON object")
+
+// errCircuitOpen is returned when the circuit of a target is open after repeated failures
+var errCircuitOpen = errors.New("the target failed repeatedly, its circuit is open")
+
+// errPrefillTimeout is returned when a prefill request did not complete within the prefill timeout
+var errPrefillTimeout = errors.New("the prefill request timed out")
+
+// errLegacyPrefillURL is returned when the prefill pod header has the legacy URL form and it is disabled
+var errLegacyPrefillURL = errors.New("the prefill pod header must list <host:port> prefill targets, the legacy http:// URLs are disabled")
+
+// errDraining is returned when a request is sent to a draining sidecar
+var errDraining = errors.New("the server is shutting down and no longer accepts new requests")
+
+// errAllowlistNotReady is returned when the allowlist of the prefill targets is not synced yet
+var errAllowlistNotReady = errors.New("the SSRF protection allowlist of the prefill targets is not synced yet")
+
+// errPrefillTargetsNotAllowed is returned when none of the prefill targets is allowed by the SSRF protection
+var errPrefillTargetsNotAllowed = errors.New("prefill target not allowed by SSRF protection")
+
+// errDecoderUnavailable is returned when the local decoder refuses the connections
+var errDecoderUnavailable = errors.New("The decode node is not ready. Please check that the vLLM service is running and the port configuration is correct.") //nolint:staticcheck // vLLM message
+
+func badRequestError(err error) *sidecarError {
+	return &sidecarError{kind: errorKindBadRequest, errorType: "BadRequestError", code: http.StatusBadRequest, message: err.Error()}
+}
+
+func requestTooLargeError(limit int64) *sidecarError {
+	return &sidecarError{kind: errorKindBadRequest, errorType: "RequestTooLargeError", code: http.StatusRequestEntityTooLarge,
+		message: fmt.Sprintf("The request body exceeds the limit of %d bytes.", limit)}
+}
+
+func modelNotFoundError(model string) *sidecarError {
+	return &sidecarError{kind: errorKindBadRequest, errorType: "NotFoundError", code: http.StatusNotFound,
+		message: fmt.Sprintf("The model `%s` does not exist.", model)}
+}
+
+func unauthenticatedError() *sidecarError {
+	return &sidecarError{kind: errorKindUnauthenticated, errorType: "AuthenticationError", code: http.StatusUnauthorized,
+		message: errClientCertificateRequired.Error()}
+}
+
+func ssrfBlockedError() *sidecarError {
+	return &sidecarError{kind: errorKindSSRFBlocked, errorType: "ForbiddenError", code: http.StatusForbidden,
+		message: errPrefillTargetsNotAllowed.Error()}
+}
+
+// allowlistNotReadyError has a distinct error type, so that the requests failed closed until the
+// allowlist is synced can be told apart from the requests to targets not allowed
+func allowlistNotReadyError() *sidecarError {
+	return &sidecarError{kind: errorKindSSRFBlocked, errorType: "AllowlistNotReadyError", code: http.StatusServiceUnavailable,
+		message: errAllowlistNotReady.Error()}
+}
+
+func prefillUnreachableError(err error) *sidecarError {
+	return &sidecarError{kind: errorKindPrefillUnreachable, errorType: "BadGateway", code: http.StatusBadGateway, message: err.Error()}
+}
+
+func prefillInvalidError(err error) *sidecarError {
+	return &sidecarError{kind: errorKindPrefillInvalid, errorType: "BadGateway", code: http.StatusBadGateway, message: err.Error()}
+}
+
+func prefillTimeoutError() *sidecarError {
+	return &sidecarError{kind: errorKindPrefillUnreachable, errorType: "GatewayTimeout", code: http.StatusGatewayTimeout,
+		message: errPrefillTimeout.Error()}
+}
+
+// circuitOpenError has a distinct error type, so that the requests failed fast because of an open
+// circuit can be told apart from the failures of the targets
+func circuitOpenError(kind errorKind) *sidecarError {
+	return &sidecarError{kind: kind, errorType: "CircuitOpenError", code: http.StatusServiceUnavailable, message: errCircuitOpen.Error()}
+}
+
+func decodeUnavailableError() *sidecarError {
+	return &sidecarError{kind: errorKindDecodeUnreachable, errorType: "ServiceUnavailable", code: http.StatusServiceUnavailable,
+		message: errDecoderUnavailable.Error()}
+}
+
+func decodeUnreachableError(err error) *sidecarError {
+	return &sidecarError{kind: errorKindDecodeUnreachable, errorType: "BadGateway", code: http.StatusBadGateway, message: err.Error()}
+}
+
+// notReadyError replies to the readiness probes when a local vLLM server is not healthy
+func notReadyError(err error) *sidecarError {
+	return &sidecarError{kind: errorKindDecodeUnreachable, errorType: "ServiceUnavailable", code: http.StatusServiceUnavailable, message: err.Error()}
+}
+
+func tenantQuotaExceededError(tenant string) *sidecarError {
+	return &sidecarError{kind: errorKindDecodeOverloaded, errorType: "RateLimitError", code: http.StatusTooManyRequests,
+		message: fmt.Sprintf("Too many concurrent requests for tenant `%s`.", tenant)}
+}
+
+func concurrencyLimitExceededError() *sidecarError {
+	return &sidecarError{kind: errorKindDecodeOverloaded, errorType: "RateLimitError", code: http.StatusTooManyRequests,
+		message: "Too many concurrent requests, please retry later."}
+}
+
+func tokenRateLimitExceededError() *sidecarError {
+	return &sidecarError{kind: errorKindDecodeOverloaded, errorType: "RateLimitError", code: http.StatusTooManyRequests,
+		message: "Too many prompt tokens, please retry later."}
+}
+
+func internalError(err error) *sidecarError {
+	return &sidecarError{kind: errorKindInternal, errorType: "InternalServerError", code: http.StatusInternalServerError, message: err.Error()}
+}
+
+// drainingError has a distinct error type, so that the clients can retry the requests rejected by a
+// terminating pod on another one
+func drainingError() *sidecarError {
+	return &sidecarError{kind: errorKindDraining, errorType: "DrainingError", code: http.StatusServiceUnavailable, message: errDraining.Error()}
+}
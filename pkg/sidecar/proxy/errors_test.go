@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Error kinds", func() {
+	const unreachableHostPort = "127.0.0.1:1" // nothing listens on port 1
+
+	var (
+		decodeURL        *url.URL
+		prefillHostPort  string
+		rejectingPrefill string
+	)
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill})
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		rejectingBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"object":"error","message":"max_tokens is too large","type":"BadRequestError","code":400}`)) //nolint:all
+		}))
+		DeferCleanup(rejectingBackend.Close)
+		rejectingPrefill = strings.TrimPrefix(rejectingBackend.URL, "http://")
+	})
+
+	DescribeTable("should report the kind of the errors in the responses and the metrics",
+		func(decoderDown bool, prefillTarget func() string, body string, wantCode int, wantKind errorKind, wantKindInBody bool) {
+			if decoderDown {
+				decodeURL = &url.URL{Scheme: "http", Host: unreachableHostPort}
+			}
+			proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2})
+			proxy.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New("127.0.0.1")}
+			proxy.allowlistValidator.ready.Store(true)
+			server := httptest.NewServer(proxy.createRoutes())
+			DeferCleanup(server.Close)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, prefillTarget())
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close() //nolint:all
+			respBody, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(wantCode))
+			var response errorResponse
+			Expect(json.Unmarshal(respBody, &response)).To(Succeed())
+			if wantKindInBody {
+				Expect(response.Kind).To(Equal(wantKind))
+			} else {
+				Expect(response.Kind).To(BeEmpty()) // the response of the prefiller is returned as is
+			}
+			Expect(testutil.ToFloat64(proxy.metrics.errors.WithLabelValues(string(wantKind)))).To(Equal(1.0))
+			Expect(testutil.CollectAndCount(proxy.metrics.errors)).To(Equal(1))
+		},
+		Entry("invalid request", false, func() string { return prefillHostPort }, `{"model":`,
+			http.StatusBadRequest, errorKindBadRequest, true),
+		Entry("prefill target denied", false, func() string { return strings.Replace(prefillHostPort, "127.0.0.1", "localhost", 1) },
+			`{"model":"m","prompt":"hi"}`, http.StatusForbidden, errorKindSSRFBlocked, true),
+		Entry("prefiller unreachable", false, func() string { return unreachableHostPort }, `{"model":"m","prompt":"hi"}`,
+			http.StatusBadGateway, errorKindPrefillUnreachable, true),
+		Entry("prefill rejected", false, func() string { return rejectingPrefill }, `{"model":"m","prompt":"hi"}`,
+			http.StatusBadRequest, errorKindPrefillRejected, false),
+		Entry("decoder unreachable", true, func() string { return prefillHostPort }, `{"model":"m","prompt":"hi"}`,
+			http.StatusServiceUnavailable, errorKindDecodeUnreachable, true),
+	)
+})
@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"slices"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/sidecar/version"
+)
+
+const (
+	protocolHTTP1 = "http/1.1"
+	protocolHTTP2 = "h2"
+	protocolH2C   = "h2c"
+)
+
+// featuresHandler reports the enabled features of the sidecar
+func (s *Server) featuresHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.features()) //nolint:all
+}
+
+// features returns the build, the enabled features and the protocols of the sidecar
+func (s *Server) features() common.Features {
+	decoderTLS := s.decoderURL.Scheme == "https"
+	features := common.Features{
+		Component:  "sidecar",
+		Version:    version.BuildRef,
+		Commit:     version.CommitSHA,
+		GoVersion:  runtime.Version(),
+		Connector:  s.connector,
+		Connectors: RegisteredConnectors(),
+		Security: map[string]bool{
+			"ssrfProtection":              s.allowlistValidator != nil && s.allowlistValidator.enabled,
+			"ssrfAuditLog":                s.allowlistValidator != nil && s.allowlistValidator.enabled && s.ssrfAudit != nil,
+			"ssrfPortEnforcement":         s.allowlistValidator != nil && s.allowlistValidator.enabled && s.allowlistValidator.enforcePorts,
+			"ssrfFailOpen":                s.allowlistValidator != nil && s.allowlistValidator.enabled && s.allowlistValidator.failOpen,
+			"listenerTLS":                 s.listenerTLS,
+			"clientCertificates":          s.listenerTLS && s.config.ClientCAs != nil,
+			"prefillerTLS":                s.config.PrefillerUseTLS,
+			"prefillerInsecureSkipVerify": s.config.PrefillerUseTLS && s.config.PrefillerInsecureSkipVerify,
+			"decoderTLS":                  decoderTLS,
+			"decoderInsecureSkipVerify":   decoderTLS && s.config.DecoderInsecureSkipVerify,
+		},
+		Protocols: map[string]string{
+			"listener":  httpProtocol(s.config.ListenerHTTP2, s.listenerTLS),
+			"prefiller": httpProtocol(s.config.PrefillerHTTP2, s.config.PrefillerUseTLS),
+			"decoder":   httpProtocol(s.config.DecoderHTTP2, decoderTLS),
+		},
+		Enabled: []string{},
+	}
+	if s.listenerTLS {
+		// the TLS listener always negotiates HTTP/2
+		features.Protocols["listener"] = protocolHTTP2
+	}
+	if s.config.DecoderSocket != "" {
+		features.Protocols["decoderTransport"] = "unix"
+	} else {
+		features.Protocols["decoderTransport"] = "tcp"
+	}
+
+	transferParamsAugmentation := len(s.config.PrefillTransferParams) > 0 || len(s.config.DecodeTransferParams) > 0 ||
+		len(registeredTransferParamsHooks()) > 0
+	for name, enabled := range map[string]bool{
+		"modelValidation":            s.modelValidator != nil,
+		"circuitBreakers":            s.circuits != nil,
+		"concurrencyLimit":           s.concurrency != nil,
+		"tenantQuotas":               s.tenantQuotas != nil,
+		"tokenRateLimits":            s.tokenRates != nil,
+		"jobPinning":                 s.jobPins != nil,
+		"shadow":                     s.shadow != nil,
+		"prefillerDNSCache":          s.prefillerResolver != nil,
+		"accessLog":                  s.accessLogger != nil,
+		"adminAPI":                   s.config.AdminPort != "",
+		"dataParallel":               s.config.DataParallelSize > 1 || s.config.DataParallelDiscoveryInterval > 0,
+		"dataParallelFallback":       s.config.DataParallelFallback,
+		"requestBodyLimit":           s.config.MaxRequestBodyBytes > 0,
+		"prefillRetries":             s.config.PrefillRetries > 0,
+		"prefillHedging":             s.config.PrefillHedgeDelay > 0,
+		"prefillPipelining":          s.config.PrefillPipelining,
+		"prefillFallback":            s.config.PrefillFallback,
+		"prefillBypass":              s.config.PrefillBypassTokens > 0,
+		"disaggregatedPooling":       len(s.config.DisaggregatedPoolingPaths) > 0,
+		"unbufferedPaths":            len(s.config.UnbufferedPaths) > 0,
+		"anthropicMessages":          s.config.AnthropicMessages,
+		"transferParamsAugmentation": transferParamsAugmentation,
+		"scrubInternalFields":        s.config.ScrubInternalResponseFields,
+		"routingResponseHeaders":     s.config.RoutingResponseHeaders,
+		"legacyPrefillURLs":          !s.config.DisableLegacyPrefillURLs,
+	} {
+		if enabled {
+			features.Enabled = append(features.Enabled, name)
+		}
+	}
+	slices.Sort(features.Enabled)
+	return features
+}
+
+// httpProtocol returns the HTTP protocol of the connections: HTTP/2 is negotiated with TLS, or
+// used with prior knowledge (h2c) without TLS
+func httpProtocol(http2 bool, useTLS bool) string {
+	switch {
+	case !http2:
+		return protocolHTTP1
+	case useTLS:
+		return protocolHTTP2
+	default:
+		return protocolH2C
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Features", func() {
+	getFeatures := func(proxy *Server) common.Features {
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		resp, err := http.Get(server.URL + common.FeaturesPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var features common.Features
+		Expect(json.NewDecoder(resp.Body).Decode(&features)).To(Succeed())
+		return features
+	}
+
+	It("should report the default features", func() {
+		proxy := NewProxy("0", &url.URL{Scheme: "http", Host: "localhost:8200"}, Config{Connector: ConnectorNIXLV2})
+
+		features := getFeatures(proxy)
+
+		Expect(features.Component).To(Equal("sidecar"))
+		Expect(features.GoVersion).ToNot(BeEmpty())
+		Expect(features.Connector).To(Equal(ConnectorNIXLV2))
+		Expect(features.Connectors).To(ContainElements(ConnectorLMCache, ConnectorNIXLV2))
+		Expect(features.Security).To(HaveKeyWithValue("ssrfProtection", false))
+		Expect(features.Security).To(HaveKeyWithValue("listenerTLS", false))
+		Expect(features.Security).To(HaveKeyWithValue("decoderTLS", false))
+		Expect(features.Protocols).To(Equal(map[string]string{
+			"listener": "http/1.1", "prefiller": "http/1.1", "decoder": "http/1.1", "decoderTransport": "tcp"}))
+		Expect(features.Enabled).To(Equal([]string{"legacyPrefillURLs"}))
+	})
+
+	It("should report the configured features", func() {
+		proxy := NewProxy("0", &url.URL{Scheme: "https", Host: "localhost:8200"}, Config{
+			Connector:                ConnectorLMCache,
+			PrefillerUseTLS:          true,
+			PrefillerHTTP2:           true,
+			DecoderHTTP2:             true,
+			DecoderSocket:            "/tmp/vllm.sock",
+			ListenerHTTP2:            true,
+			CircuitBreakerThreshold:  3,
+			JobHeader:                "x-job-id",
+			PrefillRetries:           2,
+			DisableLegacyPrefillURLs: true,
+		})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: true}
+
+		features := getFeatures(proxy)
+
+		Expect(features.Connector).To(Equal(ConnectorLMCache))
+		Expect(features.Security).To(HaveKeyWithValue("ssrfProtection", true))
+		Expect(features.Security).To(HaveKeyWithValue("prefillerTLS", true))
+		Expect(features.Security).To(HaveKeyWithValue("prefillerInsecureSkipVerify", false))
+		Expect(features.Security).To(HaveKeyWithValue("decoderTLS", true))
+		Expect(features.Protocols).To(Equal(map[string]string{
+			"listener": "h2c", "prefiller": "h2", "decoder": "h2", "decoderTransport": "unix"}))
+		Expect(features.Enabled).To(Equal([]string{"circuitBreakers", "jobPinning", "prefillRetries"}))
+
+		proxy.listenerTLS = true
+		Expect(getFeatures(proxy).Protocols).To(HaveKeyWithValue("listener", "h2"))
+	})
+})
@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Intercept paths", func() {
+	var (
+		routes          http.Handler
+		prefillHostPort string
+		prefillPaths    []string
+		decodeRequests  []string
+	)
+
+	BeforeEach(func() {
+		prefillPaths, decodeRequests = nil, nil
+
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			prefillPaths = append(prefillPaths, r.URL.Path)
+			_, _ = w.Write([]byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`)) //nolint:all
+		}))
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body) //nolint:all
+			decodeRequests = append(decodeRequests, r.URL.Path+" "+string(body))
+			_, _ = w.Write([]byte(`{}`)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		server := NewProxy("0", decodeURL, Config{
+			Connector:      ConnectorNIXLV2,
+			InterceptPaths: []string{"/v1/custom/generate", ChatCompletionsPath, ChatCompletionsPath},
+		})
+		server.allowlistValidator = &AllowlistValidator{enabled: false}
+		routes = server.createRoutes()
+	})
+
+	send := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	It("should serve the requests of the configured paths through the P/D protocol", func() {
+		Expect(send("/v1/custom/generate")).To(Equal(http.StatusOK))
+		Expect(send(ChatCompletionsPath)).To(Equal(http.StatusOK))
+
+		Expect(prefillPaths).To(Equal([]string{"/v1/custom/generate", ChatCompletionsPath}))
+		Expect(decodeRequests).To(HaveExactElements(
+			And(HavePrefix("/v1/custom/generate "), ContainSubstring(`"kv_transfer_params"`)),
+			And(HavePrefix(ChatCompletionsPath+" "), ContainSubstring(`"kv_transfer_params"`)),
+		))
+	})
+
+	It("should pass the requests of the other paths through to the decoder", func() {
+		Expect(send(CompletionsPath)).To(Equal(http.StatusOK))
+
+		Expect(prefillPaths).To(BeEmpty())
+		Expect(decodeRequests).To(Equal([]string{CompletionsPath + ` {"model":"m","prompt":"hi"}`}))
+	})
+})
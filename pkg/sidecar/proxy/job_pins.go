@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"slices"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const (
+	// DefaultJobPinTTL is the default time a job is pinned to its prefill target after its last request
+	DefaultJobPinTTL = 10 * time.Minute
+
+	// the number of jobs whose prefill target is remembered, the least recently used are forgotten
+	maxPinnedJobs = 10000
+)
+
+// jobPins keeps the requests of a batch job, identified by a request header, on the prefill target
+// which served its first request, as long as the EPP selects it among the prefill targets of the
+// job requests. The EPP assigns the subset of pods of a job upfront, e.g. with the job affinity
+// scorer, and the sidecar keeps the job on one pod of the subset, reusing the prefix of the job.
+type jobPins struct {
+	header  string
+	targets *expirable.LRU[string, string] // the prefill target of each job
+}
+
+// newJobPins returns the job pins, or nil when the header is not set
+func newJobPins(header string, ttl time.Duration) *jobPins {
+	if header == "" {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultJobPinTTL
+	}
+	return &jobPins{
+		header:  header,
+		targets: expirable.NewLRU[string, string](maxPinnedJobs, nil, ttl),
+	}
+}
+
+// order returns the ranked prefill targets with the prefill target of the job first, when the job
+// is pinned to one of them
+func (p *jobPins) order(job string, targets []string) []string {
+	if job == "" {
+		return targets
+	}
+	target, ok := p.targets.Get(job)
+	if !ok {
+		return targets
+	}
+	i := slices.Index(targets, target)
+	if i <= 0 {
+		return targets
+	}
+	return slices.Concat([]string{target}, targets[:i], targets[i+1:])
+}
+
+// pin pins the job to the prefill target which served its request
+func (p *jobPins) pin(job string, target string) {
+	if job == "" {
+		return
+	}
+	p.targets.Add(job, target)
+}
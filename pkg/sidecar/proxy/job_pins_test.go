@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Job pins", func() {
+	const jobHeader = "x-job-id"
+
+	It("should order the prefill target of the job first", func() {
+		pins := newJobPins(jobHeader, 0)
+		targets := []string{"10.0.0.1:8000", "10.0.0.2:8000", "10.0.0.3:8000"}
+
+		Expect(pins.order("job-1", targets)).To(Equal(targets))
+		pins.pin("job-1", "10.0.0.3:8000")
+		Expect(pins.order("job-1", targets)).To(Equal([]string{"10.0.0.3:8000", "10.0.0.1:8000", "10.0.0.2:8000"}))
+		Expect(pins.order("job-1", targets[:2])).To(Equal(targets[:2]))
+		Expect(pins.order("job-2", targets)).To(Equal(targets))
+		Expect(pins.order("", targets)).To(Equal(targets))
+		Expect(targets).To(Equal([]string{"10.0.0.1:8000", "10.0.0.2:8000", "10.0.0.3:8000"}))
+
+		Expect(newJobPins("", 0)).To(BeNil())
+	})
+
+	It("should keep the requests of a job on the prefill target which served its first request", func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		newPrefiller := func(requests *atomic.Int32) string {
+			handler := &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				handler.ServeHTTP(w, r)
+			}))
+			DeferCleanup(backend.Close)
+			return strings.TrimPrefix(backend.URL, "http://")
+		}
+		var requestsA, requestsB atomic.Int32
+		prefillerA, prefillerB := newPrefiller(&requestsA), newPrefiller(&requestsB)
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, JobHeader: jobHeader})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New("127.0.0.1")}
+		proxy.allowlistValidator.ready.Store(true)
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		sendCompletion := func(job string, targets ...string) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, strings.Join(targets, ","))
+			req.Header.Set(jobHeader, job)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()      //nolint:all
+			_, _ = io.ReadAll(resp.Body) //nolint:all
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		}
+
+		sendCompletion("job-1", prefillerA, prefillerB)
+		sendCompletion("job-1", prefillerB, prefillerA)
+		sendCompletion("job-1", prefillerB, prefillerA)
+		Expect(requestsA.Load()).To(BeNumerically("==", 3))
+		Expect(requestsB.Load()).To(BeNumerically("==", 0))
+
+		sendCompletion("job-2", prefillerB, prefillerA)
+		sendCompletion("", prefillerB, prefillerA)
+		Expect(requestsB.Load()).To(BeNumerically("==", 2))
+	})
+})
@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// errTrailingData is returned when a JSON body has data after its top-level value
+var errTrailingData = errors.New("invalid data after top-level value")
+
+// The request bodies rewritten by the connectors are decoded and encoded so that the payloads the
+// engine validates strictly keep their values: the numbers are decoded as json.Number, keeping their
+// literal form, e.g. the integers beyond the float64 precision, and the strings are encoded without
+// escaping the HTML characters, the other non-ASCII characters being encoded as UTF-8 as well.
+
+// decodeJSONObject decodes a JSON object, its numbers decoded as json.Number. A JSON null decodes to a
+// nil map.
+func decodeJSONObject(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errTrailingData
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+// encodeJSON encodes a value without escaping the HTML characters
+func encodeJSON(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
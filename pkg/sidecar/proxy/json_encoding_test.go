@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("JSON encoding", func() {
+	DescribeTable("should round-trip the JSON objects byte for byte",
+		func(body string) {
+			object, err := decodeJSONObject([]byte(body))
+			Expect(err).ToNot(HaveOccurred())
+			encoded, err := encodeJSON(object)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(encoded)).To(Equal(body))
+		},
+		Entry("integers", `{"max_tokens":100,"seed":12345678901234567890}`),
+		Entry("number formats", `{"a":1.0,"b":1e3,"c":-0,"d":0.1000000000000000055511151231257827}`),
+		Entry("non-ASCII content", `{"prompt":"héllo wörld 你好 🚀"}`),
+		Entry("HTML characters", `{"prompt":"<b>a & b</b> -> c"}`),
+		Entry("nested values", `{"messages":[{"content":"<hi>","role":"user"}],"n":[1,2.50,null,true]}`),
+	)
+
+	It("should reject the invalid JSON objects like json.Unmarshal", func() {
+		for _, body := range []string{`{} {}`, `{}x`, `{`, ``, `[]`, `"string"`, `{"a":1}]`} {
+			var object map[string]any
+			Expect(json.Unmarshal([]byte(body), &object)).ToNot(Succeed(), body)
+			_, err := decodeJSONObject([]byte(body))
+			Expect(err).To(HaveOccurred(), body)
+		}
+
+		object, err := decodeJSONObject([]byte(" null \n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(object).To(BeNil())
+	})
+
+	It("should preserve the payloads of the requests rewritten by the connector", func() {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV2,
+			[]byte(`{"kv_transfer_params":{"remote_block_ids":[1,2,3],"remote_engine_id":"<engine>","remote_port":4032}}`))
+
+		rec := sendConnectorRequest(server,
+			[]byte(`{"model":"m","prompt":"<b>héllo</b> & 你好","max_tokens":50,"seed":12345678901234567890,"temperature":0.70}`))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(HaveLen(1))
+		Expect(string((*prefillRequests)[0])).To(And(
+			ContainSubstring(`"prompt":"<b>héllo</b> & 你好"`),
+			ContainSubstring(`"seed":12345678901234567890`),
+			ContainSubstring(`"temperature":0.70`),
+		))
+		Expect(decoder.bodies).To(HaveLen(1))
+		Expect(string(decoder.bodies[0])).To(And(
+			ContainSubstring(`"prompt":"<b>héllo</b> & 你好"`),
+			ContainSubstring(`"max_tokens":50`),
+			ContainSubstring(`"seed":12345678901234567890`),
+			ContainSubstring(`"temperature":0.70`),
+			ContainSubstring(`"kv_transfer_params":{"remote_block_ids":[1,2,3],"remote_engine_id":"<engine>","remote_port":4032}`),
+		))
+	})
+})
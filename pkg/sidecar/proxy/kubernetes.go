@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// DefaultKubernetesQPS is the default rate of the requests to the Kubernetes API server
+	DefaultKubernetesQPS = 5
+
+	// DefaultKubernetesBurst is the default burst of the requests to the Kubernetes API server
+	DefaultKubernetesBurst = 10
+)
+
+// KubernetesClientOptions configures the client of the Kubernetes API server
+type KubernetesClientOptions struct {
+	// QPS is the rate of the requests to the API server. Defaults to DefaultKubernetesQPS.
+	QPS float32
+
+	// Burst is the burst of the requests to the API server. Defaults to DefaultKubernetesBurst.
+	Burst int
+
+	// UserAgent identifies the sidecar in the API server logs and metrics.
+	// Defaults to the client-go user agent.
+	UserAgent string
+}
+
+// NewKubernetesConfig returns the config of the clients of the Kubernetes API server. The kubeconfig file is
+// used when the KUBECONFIG environment variable is set, otherwise the in-cluster config, authenticating with
+// the token of the pod service account, which is reloaded when rotated. The default kubeconfig loading rules
+// are used outside of a cluster.
+func NewKubernetesConfig(options KubernetesClientOptions) (*rest.Config, error) {
+	config, err := kubernetesConfig()
+	if err != nil {
+		return nil, err
+	}
+	applyKubernetesClientOptions(config, options)
+	return config, nil
+}
+
+func kubernetesConfig() (*rest.Config, error) {
+	if os.Getenv(clientcmd.RecommendedConfigPathEnvVar) == "" {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
+		if !errors.Is(err, rest.ErrNotInCluster) {
+			return nil, fmt.Errorf("failed to get the in-cluster Kubernetes config (check the service account token of the pod): %w", err)
+		}
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kubernetes config (ensure running in a pod with proper RBAC): %w", err)
+	}
+	return config, nil
+}
+
+func applyKubernetesClientOptions(config *rest.Config, options KubernetesClientOptions) {
+	config.QPS = options.QPS
+	if config.QPS <= 0 {
+		config.QPS = DefaultKubernetesQPS
+	}
+	config.Burst = options.Burst
+	if config.Burst <= 0 {
+		config.Burst = DefaultKubernetesBurst
+	}
+	if options.UserAgent != "" {
+		config.UserAgent = options.UserAgent
+	}
+}
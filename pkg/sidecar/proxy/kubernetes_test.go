@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Kubernetes client", func() {
+	It("should apply the client options", func() {
+		config := &rest.Config{UserAgent: "default"}
+		applyKubernetesClientOptions(config, KubernetesClientOptions{QPS: 50, Burst: 100, UserAgent: "llm-d-pd-sidecar/test"})
+		Expect(config.QPS).To(BeEquivalentTo(50))
+		Expect(config.Burst).To(Equal(100))
+		Expect(config.UserAgent).To(Equal("llm-d-pd-sidecar/test"))
+	})
+
+	It("should default the client options", func() {
+		config := &rest.Config{UserAgent: "default"}
+		applyKubernetesClientOptions(config, KubernetesClientOptions{})
+		Expect(config.QPS).To(BeEquivalentTo(DefaultKubernetesQPS))
+		Expect(config.Burst).To(Equal(DefaultKubernetesBurst))
+		Expect(config.UserAgent).To(Equal("default"))
+	})
+
+	It("should prefer the kubeconfig file given by KUBECONFIG to the in-cluster config", func() {
+		kubeconfig := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+		Expect(os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://kubeconfig.example:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: secret
+`), 0o600)).To(Succeed())
+		GinkgoT().Setenv(clientcmd.RecommendedConfigPathEnvVar, kubeconfig)
+		GinkgoT().Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+		GinkgoT().Setenv("KUBERNETES_SERVICE_PORT", "443")
+
+		config, err := kubernetesConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Host).To(Equal("https://kubeconfig.example:6443"))
+	})
+
+	It("should fail when the in-cluster service account token is missing", func() {
+		GinkgoT().Setenv(clientcmd.RecommendedConfigPathEnvVar, "")
+		GinkgoT().Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+		GinkgoT().Setenv("KUBERNETES_SERVICE_PORT", "443")
+		if _, err := os.Stat("/var/run/secrets/kubernetes.io/serviceaccount/token"); err == nil {
+			Skip("running in a pod with a service account token")
+		}
+
+		_, err := kubernetesConfig()
+		Expect(err).To(MatchError(ContainSubstring("service account token")))
+	})
+})
@@ -0,0 +1,483 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// MetricsPath is the path of the Prometheus metrics endpoint
+	MetricsPath = "/metrics"
+
+	metricsNamespace = "llm_d"
+	metricsSubsystem = "sidecar"
+
+	// maxSSRFRejectionTargets is the number of distinct targets labelling the SSRF rejections, the
+	// further ones are labelled ssrfRejectionOtherTarget since the targets are chosen by the clients
+	maxSSRFRejectionTargets = 100
+
+	// ssrfRejectionOtherTarget labels the SSRF rejections beyond the distinct targets limit
+	ssrfRejectionOtherTarget = "other"
+
+	// connectorNone labels the decode metrics of the requests without disaggregated prefill
+	connectorNone = "none"
+)
+
+// proxyMetrics are the Prometheus metrics of the proxy server. They are registered on a
+// dedicated registry, shared by the servers of all the data parallel ranks.
+type proxyMetrics struct {
+	registry *prometheus.Registry
+
+	requests          *prometheus.CounterVec
+	connectorRequests *prometheus.CounterVec
+	prefillFailures   *prometheus.CounterVec
+	prefillDuration   *prometheus.HistogramVec
+	decodeDuration    *prometheus.HistogramVec
+	poolingDuration   *prometheus.HistogramVec
+	prefillRetries    *prometheus.CounterVec
+	prefillFallbacks  *prometheus.CounterVec
+	prefillHedges     *prometheus.CounterVec
+	prefillFailovers  *prometheus.CounterVec
+	prefillPipelined  *prometheus.CounterVec
+	invalidPrefills   *prometheus.CounterVec
+	legacyPrefillURLs prometheus.Counter
+	prefillBypasses   prometheus.Counter
+	limitRejections   prometheus.Counter
+	tenantRejections  prometheus.Counter
+	tokenRejections   prometheus.Counter
+	allowlistNotReady prometheus.Counter
+	allowlistFailOpen prometheus.Counter
+	dataParallelMiss  *prometheus.CounterVec
+	ssrfRejections    *prometheus.CounterVec
+	shadowRequests    *prometheus.CounterVec
+	inFlight          prometheus.Gauge
+	errors            *prometheus.CounterVec
+
+	// allowlist is the SSRF protection allowlist reported by the allowlist metrics, nil until the server starts
+	allowlist atomic.Pointer[AllowlistValidator]
+
+	// ssrfRejectionTargets are the distinct targets labelling the SSRF rejections
+	ssrfRejectionTargetsMu sync.Mutex
+	ssrfRejectionTargets   map[string]struct{}
+}
+
+func newProxyMetrics() *proxyMetrics {
+	latencyBuckets := []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+	m := &proxyMetrics{
+		registry:             prometheus.NewRegistry(),
+		ssrfRejectionTargets: map[string]struct{}{},
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests handled by the sidecar, by route and response status code.",
+		}, []string{"route", "code"}),
+		connectorRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "connector_requests_total",
+			Help:      "Number of disaggregated requests handled by each P/D connector.",
+		}, []string{"connector"}),
+		prefillFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_failures_total",
+			Help:      "Number of failed remote prefill requests, by connector and prefiller response status code.",
+		}, []string{"connector", "code"}),
+		prefillDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_duration_seconds",
+			Help:      "Duration of the remote prefill requests.",
+			Buckets:   latencyBuckets,
+		}, []string{"connector"}),
+		decodeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "decode_duration_seconds",
+			Help:      "Duration of the decode requests, until their response is complete. The connector is 'none' without disaggregated prefill.",
+			Buckets:   latencyBuckets,
+		}, []string{"connector"}),
+		poolingDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "pooling_duration_seconds",
+			Help:      "Duration of the pooling requests, such as scoring, reranking and embeddings, by route.",
+			Buckets:   latencyBuckets,
+		}, []string{"route"}),
+		prefillRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_retries_total",
+			Help:      "Number of retried remote prefill requests.",
+		}, []string{"connector"}),
+		prefillFallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_fallbacks_total",
+			Help:      "Number of requests sent to the local decoder without disaggregation after their remote prefill failed.",
+		}, []string{"connector"}),
+		invalidPrefills: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_invalid_responses_total",
+			Help:      "Number of successful prefill responses the KV-transfer parameters could not be extracted from.",
+		}, []string{"connector"}),
+		prefillHedges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_hedges_total",
+			Help:      "Number of prefill requests hedged to a second prefiller after the latency budget, by the prefiller answering first ('primary', 'hedge', or 'none' when both failed).",
+		}, []string{"connector", "winner"}),
+		prefillFailovers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_failovers_total",
+			Help:      "Number of prefill requests sent to the next prefill target ranked by the EPP after the previous one failed.",
+		}, []string{"connector"}),
+		prefillPipelined: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_pipelined_total",
+			Help:      "Number of decode requests sent as soon as the KV-transfer parameters were received, before the end of the prefill response.",
+		}, []string{"connector"}),
+		legacyPrefillURLs: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "legacy_prefill_urls_total",
+			Help:      "Number of requests whose prefill pod header has the deprecated http:// URL form instead of <host:port>.",
+		}),
+		prefillBypasses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "prefill_bypasses_total",
+			Help:      "Number of requests decoded locally, although a prefill pod was selected, because their estimated prompt tokens are below the bypass threshold.",
+		}),
+		limitRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "concurrency_rejections_total",
+			Help:      "Number of completion requests rejected because the pod served as many requests as its concurrency limit.",
+		}),
+		tenantRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "tenant_rejections_total",
+			Help:      "Number of requests rejected because their tenant reached its concurrency quota.",
+		}),
+		tokenRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "token_rate_rejections_total",
+			Help:      "Number of completion requests rejected because their client exceeded its rate of estimated prompt tokens.",
+		}),
+		allowlistNotReady: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "allowlist_not_ready_rejections_total",
+			Help:      "Number of disaggregated requests rejected because the SSRF protection allowlist was not synced yet, or their prefill targets were not in it during its grace period.",
+		}),
+		allowlistFailOpen: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "allowlist_fail_open_total",
+			Help:      "Number of prefill targets missing from the pending SSRF protection allowlist allowed by the fail-open policy.",
+		}),
+		dataParallelMiss: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "data_parallel_misses_total",
+			Help:      "Number of requests naming an unknown data parallel rank, by result ('fallback' when sent to the least loaded local rank, or 'rejected').",
+		}, []string{"result"}),
+		ssrfRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "ssrf_rejections_total",
+			Help:      "Number of prefill targets rejected because they are not in the SSRF protection allowlist, by target host.",
+		}, []string{"target"}),
+		shadowRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "shadow_requests_total",
+			Help:      "Number of completion requests mirrored to the shadow engine, by result ('success', 'failure', or 'dropped' when too many were in flight).",
+		}, []string{"result"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "in_flight_requests",
+			Help:      "Number of inference requests being served, that a drain waits for.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "errors_total",
+			Help:      "Number of errors replied to the clients, by kind.",
+		}, []string{"kind"}),
+	}
+
+	m.registry.MustRegister(m.requests, m.connectorRequests, m.prefillFailures, m.prefillDuration, m.decodeDuration,
+		m.poolingDuration, m.prefillRetries, m.prefillFallbacks, m.prefillHedges, m.prefillFailovers, m.prefillPipelined, m.invalidPrefills, m.legacyPrefillURLs, m.prefillBypasses, m.limitRejections, m.tenantRejections, m.tokenRejections, m.allowlistNotReady, m.allowlistFailOpen, m.dataParallelMiss, m.ssrfRejections, m.shadowRequests, m.inFlight, m.errors)
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "allowlist_targets",
+			Help:      "Number of hosts in the SSRF protection allowlist, an empty allowlist rejects every disaggregated request.",
+		}, func() float64 {
+			targets, _, _ := m.allowlistStats()
+			return float64(targets)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "allowlist_rebuilds_total",
+			Help:      "Number of times the SSRF protection allowlist was rebuilt.",
+		}, func() float64 {
+			_, rebuilds, _ := m.allowlistStats()
+			return float64(rebuilds)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "allowlist_watch_errors_total",
+			Help:      "Number of failed lists and watches of the resources the SSRF protection allowlist is built from.",
+		}, func() float64 {
+			_, _, watchErrors := m.allowlistStats()
+			return float64(watchErrors)
+		}),
+	)
+	return m
+}
+
+// setAllowlist sets the SSRF protection allowlist reported by the allowlist metrics
+func (m *proxyMetrics) setAllowlist(allowlist *AllowlistValidator) {
+	m.allowlist.Store(allowlist)
+}
+
+// allowlistStats returns the statistics of the SSRF protection allowlist, zero when it is disabled
+func (m *proxyMetrics) allowlistStats() (targets int, rebuilds uint64, watchErrors uint64) {
+	allowlist := m.allowlist.Load()
+	if allowlist == nil || !allowlist.enabled {
+		return 0, 0, 0
+	}
+	return allowlist.stats()
+}
+
+// handler returns the handler of the metrics endpoint
+func (m *proxyMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// instrument counts the requests served by the given mux, by route pattern and status code
+func (m *proxyMetrics) instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusRecorder{ResponseWriter: w}
+		mux.ServeHTTP(sw, r)
+
+		// the mux sets the pattern of the matched route, dropping the method
+		route := r.Pattern
+		if _, path, found := strings.Cut(route, " "); found {
+			route = path
+		}
+		m.requests.WithLabelValues(route, strconv.Itoa(sw.status())).Inc()
+	})
+}
+
+// observePrefill records a remote prefill request
+func (m *proxyMetrics) observePrefill(connector string, start time.Time, statusCode int) {
+	m.connectorRequests.WithLabelValues(connector).Inc()
+	m.prefillDuration.WithLabelValues(connector).Observe(time.Since(start).Seconds())
+	if statusCode < 200 || statusCode >= 300 {
+		m.prefillFailures.WithLabelValues(connector, strconv.Itoa(statusCode)).Inc()
+	}
+}
+
+// observePrefillRetry records a retry of a remote prefill request
+func (m *proxyMetrics) observePrefillRetry(connector string) {
+	m.prefillRetries.WithLabelValues(connector).Inc()
+}
+
+// observePrefillFallback records a request sent to the local decoder after its remote prefill failed
+func (m *proxyMetrics) observePrefillFallback(connector string) {
+	m.prefillFallbacks.WithLabelValues(connector).Inc()
+}
+
+// observeInvalidPrefill records a successful prefill response the KV-transfer parameters could not be extracted from
+func (m *proxyMetrics) observeInvalidPrefill(connector string) {
+	m.invalidPrefills.WithLabelValues(connector).Inc()
+}
+
+// observePrefillHedge records a hedged prefill request, with the prefiller answering first
+func (m *proxyMetrics) observePrefillHedge(connector string, winner string) {
+	m.prefillHedges.WithLabelValues(connector, winner).Inc()
+}
+
+// observePrefillFailover records a prefill request sent to the next prefill target after a failure
+func (m *proxyMetrics) observePrefillFailover(connector string) {
+	m.prefillFailovers.WithLabelValues(connector).Inc()
+}
+
+// observePrefillPipelined records a decode request sent before the end of the prefill response
+func (m *proxyMetrics) observePrefillPipelined(connector string) {
+	m.prefillPipelined.WithLabelValues(connector).Inc()
+}
+
+// observeLegacyPrefillURL records a request whose prefill pod header has the deprecated URL form
+func (m *proxyMetrics) observeLegacyPrefillURL() {
+	m.legacyPrefillURLs.Inc()
+}
+
+// observePrefillBypass records a request decoded locally because of its short prompt
+func (m *proxyMetrics) observePrefillBypass() {
+	m.prefillBypasses.Inc()
+}
+
+// observeConcurrencyRejection records a request rejected by the concurrency limit
+func (m *proxyMetrics) observeConcurrencyRejection() {
+	m.limitRejections.Inc()
+}
+
+// observeTenantRejection records a request rejected by the tenant quotas
+func (m *proxyMetrics) observeTenantRejection() {
+	m.tenantRejections.Inc()
+}
+
+// observeTokenRateRejection records a request rejected by the token rate limits
+func (m *proxyMetrics) observeTokenRateRejection() {
+	m.tokenRejections.Inc()
+}
+
+// observeAllowlistNotReady records a disaggregated request rejected until the allowlist is synced, or
+// during its grace period
+func (m *proxyMetrics) observeAllowlistNotReady() {
+	m.allowlistNotReady.Inc()
+}
+
+// observeAllowlistFailOpen records a prefill target missing from the pending allowlist, allowed by the
+// fail-open policy
+func (m *proxyMetrics) observeAllowlistFailOpen() {
+	m.allowlistFailOpen.Inc()
+}
+
+// observeDataParallelMiss records a request naming an unknown data parallel rank, sent to another rank
+// by the fallback or rejected
+func (m *proxyMetrics) observeDataParallelMiss(fallback bool) {
+	result := "rejected"
+	if fallback {
+		result = "fallback"
+	}
+	m.dataParallelMiss.WithLabelValues(result).Inc()
+}
+
+// observeSSRFRejection records a prefill target rejected by the SSRF protection, labelled by its host
+// until the distinct targets limit is reached
+func (m *proxyMetrics) observeSSRFRejection(target string) {
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+
+	m.ssrfRejectionTargetsMu.Lock()
+	if _, ok := m.ssrfRejectionTargets[target]; !ok {
+		if len(m.ssrfRejectionTargets) >= maxSSRFRejectionTargets {
+			target = ssrfRejectionOtherTarget
+		} else {
+			m.ssrfRejectionTargets[target] = struct{}{}
+		}
+	}
+	m.ssrfRejectionTargetsMu.Unlock()
+
+	m.ssrfRejections.WithLabelValues(target).Inc()
+}
+
+// observeShadow records a request mirrored to the shadow engine, with its result
+func (m *proxyMetrics) observeShadow(result string) {
+	m.shadowRequests.WithLabelValues(result).Inc()
+}
+
+// observeError records an error replied to a client
+func (m *proxyMetrics) observeError(kind errorKind) {
+	m.errors.WithLabelValues(string(kind)).Inc()
+}
+
+// observeInFlight records an inference request in flight, until the returned function is called
+func (m *proxyMetrics) observeInFlight() func() {
+	m.inFlight.Inc()
+	return m.inFlight.Dec
+}
+
+// observeDecode records a decode request
+func (m *proxyMetrics) observeDecode(connector string, start time.Time) {
+	m.decodeDuration.WithLabelValues(connector).Observe(time.Since(start).Seconds())
+}
+
+// observePooling records a pooling request
+func (m *proxyMetrics) observePooling(route string, start time.Time) {
+	m.poolingDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+}
+
+// statusRecorder records the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack records the switch of protocols of the upgraded connections, e.g. the websockets
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.statusCode == 0 {
+		w.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// Unwrap lets http.ResponseController flush streamed responses
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusRecorder) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/utils/set"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Metrics endpoint", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendCompletion := func(prefillHostPort string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		if prefillHostPort != "" {
+			req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		_, _ = io.ReadAll(resp.Body) //nolint:all
+		resp.Body.Close()            //nolint:all
+	}
+
+	scrapeMetrics := func() string {
+		resp, err := http.Get(server.URL + MetricsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return string(body)
+	}
+
+	It("should count the requests and record the decode latency without prefill", func() {
+		sendCompletion("")
+
+		metrics := scrapeMetrics()
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_requests_total{code="200",route="/v1/completions"} 1`))
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_decode_duration_seconds_count{connector="none"} 1`))
+		Expect(metrics).ToNot(ContainSubstring(`llm_d_sidecar_prefill_duration_seconds_count`))
+	})
+
+	It("should record the prefill and decode latencies of disaggregated requests", func() {
+		prefillBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill})
+		DeferCleanup(prefillBackend.Close)
+
+		sendCompletion(strings.TrimPrefix(prefillBackend.URL, "http://"))
+
+		metrics := scrapeMetrics()
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_connector_requests_total{connector="nixlv2"} 1`))
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_prefill_duration_seconds_count{connector="nixlv2"} 1`))
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_decode_duration_seconds_count{connector="nixlv2"} 1`))
+		Expect(metrics).ToNot(ContainSubstring(`llm_d_sidecar_prefill_failures_total`))
+	})
+
+	It("should count the prefill failures", func() {
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		DeferCleanup(prefillBackend.Close)
+
+		sendCompletion(strings.TrimPrefix(prefillBackend.URL, "http://"))
+
+		metrics := scrapeMetrics()
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_prefill_failures_total{code="503",connector="nixlv2"} 1`))
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_requests_total{code="503",route="/v1/completions"} 1`))
+		Expect(metrics).ToNot(ContainSubstring(`llm_d_sidecar_decode_duration_seconds_count`))
+	})
+})
+
+var _ = Describe("SSRF protection metrics", func() {
+	It("should report the state of the allowlist", func() {
+		metrics := newProxyMetrics()
+		Expect(testutil.GatherAndCompare(metrics.registry, strings.NewReader(`
+# HELP llm_d_sidecar_allowlist_targets Number of hosts in the SSRF protection allowlist, an empty allowlist rejects every disaggregated request.
+# TYPE llm_d_sidecar_allowlist_targets gauge
+llm_d_sidecar_allowlist_targets 0
+`), "llm_d_sidecar_allowlist_targets")).To(Succeed())
+
+		validator := &AllowlistValidator{enabled: true, allowedTargets: set.New("10.0.0.1", "10.0.0.2"), generation: 3}
+		validator.watchErrors.Add(2)
+		metrics.setAllowlist(validator)
+
+		Expect(testutil.GatherAndCompare(metrics.registry, strings.NewReader(`
+# HELP llm_d_sidecar_allowlist_rebuilds_total Number of times the SSRF protection allowlist was rebuilt.
+# TYPE llm_d_sidecar_allowlist_rebuilds_total counter
+llm_d_sidecar_allowlist_rebuilds_total 3
+# HELP llm_d_sidecar_allowlist_targets Number of hosts in the SSRF protection allowlist, an empty allowlist rejects every disaggregated request.
+# TYPE llm_d_sidecar_allowlist_targets gauge
+llm_d_sidecar_allowlist_targets 2
+# HELP llm_d_sidecar_allowlist_watch_errors_total Number of failed lists and watches of the resources the SSRF protection allowlist is built from.
+# TYPE llm_d_sidecar_allowlist_watch_errors_total counter
+llm_d_sidecar_allowlist_watch_errors_total 2
+`), "llm_d_sidecar_allowlist_targets", "llm_d_sidecar_allowlist_rebuilds_total",
+			"llm_d_sidecar_allowlist_watch_errors_total")).To(Succeed())
+	})
+
+	It("should count the rejected targets by host with a bounded number of labels", func() {
+		metrics := newProxyMetrics()
+		for i := range maxSSRFRejectionTargets {
+			metrics.observeSSRFRejection(fmt.Sprintf("10.0.%d.%d:8000", i/256, i%256))
+		}
+		metrics.observeSSRFRejection("10.0.0.0:8001")
+		metrics.observeSSRFRejection("192.168.0.1:8000")
+
+		Expect(testutil.ToFloat64(metrics.ssrfRejections.WithLabelValues("10.0.0.0"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(metrics.ssrfRejections.WithLabelValues(ssrfRejectionOtherTarget))).To(Equal(1.0))
+		Expect(testutil.CollectAndCount(metrics.ssrfRejections)).To(Equal(maxSSRFRejectionTargets + 1))
+	})
+
+	It("should count the prefill targets rejected by the SSRF protection", func() {
+		server, _, _ := newInProcessProxy(ConnectorNIXLV2, nil)
+		server.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New[string]()}
+		server.allowlistValidator.ready.Store(true)
+
+		req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		req.Header.Set(common.PrefillPodHeader, inProcessPrefillHostPort)
+		rec := httptest.NewRecorder()
+		server.chatCompletionsHandler(rec, req)
+
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(testutil.ToFloat64(server.metrics.ssrfRejections.WithLabelValues("prefill.local"))).To(Equal(1.0))
+	})
+})
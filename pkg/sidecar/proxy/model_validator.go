@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// ModelsPath is the OpenAI models path
+	ModelsPath = "/v1/models"
+
+	// DefaultModelsCacheTTL is the default time the models served by the local engine are cached
+	DefaultModelsCacheTTL = 30 * time.Second
+
+	// minModelsRefreshInterval limits the refreshes triggered by unknown models,
+	// e.g. to pick up newly loaded LoRA adapters
+	minModelsRefreshInterval = time.Second
+
+	modelsRequestTimeout = 5 * time.Second
+)
+
+// modelsResponse is the OpenAI models list response
+type modelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// modelValidator checks that the requested models are served by the local engine,
+// using a cached list of its models
+type modelValidator struct {
+	modelsURL string
+	client    *http.Client
+	ttl       time.Duration
+
+	mutex     sync.Mutex
+	models    map[string]struct{}
+	fetchedAt time.Time
+}
+
+func newModelValidator(decoderURL *url.URL, transport http.RoundTripper, ttl time.Duration) *modelValidator {
+	if ttl <= 0 {
+		ttl = DefaultModelsCacheTTL
+	}
+
+	client := &http.Client{Timeout: modelsRequestTimeout, Transport: transport}
+
+	return &modelValidator{
+		modelsURL: decoderURL.JoinPath(ModelsPath).String(),
+		client:    client,
+		ttl:       ttl,
+	}
+}
+
+// isServed returns whether the model is served by the local engine. The cached models are
+// refreshed when they expired, or when the model is unknown and they were not refreshed recently.
+func (v *modelValidator) isServed(ctx context.Context, model string) (bool, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	age := time.Since(v.fetchedAt)
+	if _, ok := v.models[model]; ok && age < v.ttl {
+		return true, nil
+	}
+	if v.models != nil && age < minModelsRefreshInterval {
+		return false, nil
+	}
+
+	models, err := v.fetchModels(ctx)
+	if err != nil {
+		return false, err
+	}
+	v.models = models
+	v.fetchedAt = time.Now()
+
+	_, ok := v.models[model]
+	return ok, nil
+}
+
+func (v *modelValidator) fetchModels(ctx context.Context) (map[string]struct{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.modelsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:all
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d listing the models", resp.StatusCode)
+	}
+
+	var response modelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode the models list: %w", err)
+	}
+
+	models := make(map[string]struct{}, len(response.Data))
+	for _, model := range response.Data {
+		models[model.ID] = struct{}{}
+	}
+	return models, nil
+}
+
+// validateModel replies with a model not found error and returns false when the requested model
+// is not served by the local engine. The request body is restored to be forwarded.
+// Requests are let through when the models of the local engine cannot be listed.
+func (s *Server) validateModel(w http.ResponseWriter, r *http.Request) bool {
+	body, ok := s.readRequestBody(w, r)
+	if !ok {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.TransferEncoding = nil
+
+	var request struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil || request.Model == "" {
+		return true // let the engine report the invalid request
+	}
+
+	served, err := s.modelValidator.isServed(r.Context(), request.Model)
+	if err != nil {
+		s.requestLogger(r).Error(err, "failed to list the models of the local engine, skipping model validation")
+		return true
+	}
+	if !served {
+		s.requestLogger(r).V(4).Info("model not served by the local engine", "model", request.Model)
+		s.replyError(w, modelNotFoundError(request.Model))
+		return false
+	}
+	return true
+}
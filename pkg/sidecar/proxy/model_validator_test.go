@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Model validation", func() {
+	var (
+		server          *httptest.Server
+		prefillHandler  *mock.ChatCompletionHandler
+		prefillHostPort string
+		modelsRequests  atomic.Int32
+		modelsStatus    int
+	)
+
+	BeforeEach(func() {
+		modelsRequests.Store(0)
+		modelsStatus = http.StatusOK
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET "+ModelsPath, func(w http.ResponseWriter, _ *http.Request) {
+			modelsRequests.Add(1)
+			if modelsStatus != http.StatusOK {
+				w.WriteHeader(modelsStatus)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"object":"list","data":[{"id":"base-model","object":"model"},{"id":"lora-adapter","object":"model"}]}`)) //nolint:all
+		})
+		mux.Handle("/", &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		decodeBackend := httptest.NewServer(mux)
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+		prefillBackend := httptest.NewServer(prefillHandler)
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, ValidateModel: true})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendCompletion := func(body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+ChatCompletionsPath, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	It("should forward the requests for served models", func() {
+		resp := sendCompletion(`{"model":"lora-adapter","messages":[{"role":"user","content":"hi"}]}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+
+		resp = sendCompletion(`{"model":"base-model","messages":[{"role":"user","content":"hi"}]}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(modelsRequests.Load()).To(BeNumerically("==", 1))
+	})
+
+	It("should reply with a model not found error without running the prefill", func() {
+		resp := sendCompletion(`{"model":"unknown","messages":[{"role":"user","content":"hi"}]}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var er errorResponse
+		Expect(json.Unmarshal(body, &er)).To(Succeed())
+		Expect(er).To(Equal(errorResponse{
+			Object:  "error",
+			Message: "The model `unknown` does not exist.",
+			Type:    "NotFoundError",
+			Code:    http.StatusNotFound,
+			Kind:    errorKindBadRequest,
+		}))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should let the requests through when the models cannot be listed", func() {
+		modelsStatus = http.StatusInternalServerError
+
+		resp := sendCompletion(`{"model":"unknown","messages":[{"role":"user","content":"hi"}]}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+// passthroughHandler forwards the requests of the routes not served by the sidecar to the local decoder,
+// e.g. the websockets of the interactive sessions, flushing the responses of the unbuffered paths as
+// soon as they are written
+func (s *Server) passthroughHandler(w http.ResponseWriter, r *http.Request) {
+	if common.MatchesPath(s.config.UnbufferedPaths, r.URL.Path) {
+		w = &flushWriter{ResponseWriter: w}
+	}
+	s.decoderProxy.ServeHTTP(w, r)
+}
+
+// isUpgradeRequest returns true when the request asks to switch protocols, e.g. to a websocket
+func isUpgradeRequest(r *http.Request) bool {
+	for _, value := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeTransport sends the protocol upgrade requests over HTTP/1.1, the h2c connections not being
+// able to switch protocols, and the other requests over HTTP/2
+type upgradeTransport struct {
+	http2 http.RoundTripper
+	http1 http.RoundTripper
+}
+
+// newUpgradeTransport returns a transport sending the upgrade requests over an HTTP/1.1 copy of the
+// given h2c transport
+func newUpgradeTransport(transport *http.Transport) *upgradeTransport {
+	http1 := transport.Clone()
+	http1.Protocols = nil
+	return &upgradeTransport{http2: transport, http1: http1}
+}
+
+func (t *upgradeTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if isUpgradeRequest(r) {
+		return t.http1.RoundTrip(r)
+	}
+	return t.http2.RoundTrip(r)
+}
+
+// flushWriter flushes each write of a response, so that the clients of the unbuffered paths, e.g. long
+// polls, receive the data as soon as the decoder sent it
+type flushWriter struct {
+	http.ResponseWriter
+}
+
+func (w *flushWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if err == nil {
+		http.NewResponseController(w.ResponseWriter).Flush() //nolint:all
+	}
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying response writer
+func (w *flushWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
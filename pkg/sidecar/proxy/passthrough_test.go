@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Passthrough routes", func() {
+	startProxy := func(handler http.Handler, config Config) (*Server, string) {
+		decodeBackend := httptest.NewServer(handler)
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, config)
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+		return proxy, strings.TrimPrefix(server.URL, "http://")
+	}
+
+	// echoUpgrade switches to an echo protocol, answering each line sent by the client
+	echoUpgrade := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()                                                                                              //nolint:all
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n") //nolint:all
+		_ = brw.Flush()                                                                                                 //nolint:all
+		for {
+			line, err := brw.ReadString('\n')
+			if err != nil {
+				return
+			}
+			_, _ = brw.WriteString("echo " + line) //nolint:all
+			_ = brw.Flush()                        //nolint:all
+		}
+	})
+
+	DescribeTable("should switch protocols and stream the upgraded connections",
+		func(config Config) {
+			proxy, addr := startProxy(echoUpgrade, config)
+
+			conn, err := net.Dial("tcp", addr)
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close() //nolint:all
+			Expect(conn.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			_, err = conn.Write([]byte("GET /v1/realtime HTTP/1.1\r\nHost: sidecar\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+			Expect(resp.Header.Get(requestHeaderRequestID)).ToNot(BeEmpty())
+
+			for _, message := range []string{"hello\n", "world\n"} {
+				_, err = conn.Write([]byte(message))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(reader.ReadString('\n')).To(Equal("echo " + message))
+			}
+
+			Expect(conn.Close()).To(Succeed())
+			Eventually(func() float64 {
+				return testutil.ToFloat64(proxy.metrics.requests.WithLabelValues("/", "101"))
+			}).Should(Equal(1.0))
+		},
+		Entry("over HTTP/1.1", Config{}),
+		Entry("over h2c", Config{DecoderHTTP2: true}),
+		Entry("with the stream limits", Config{StreamWriteTimeout: time.Second, StreamBufferSize: 1024}),
+	)
+
+	It("should flush the responses of the unbuffered paths as soon as they are written", func() {
+		release := make(chan struct{})
+		poll := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Length", "10")
+			_, _ = w.Write([]byte("first"))       //nolint:all
+			http.NewResponseController(w).Flush() //nolint:all
+			<-release
+			_, _ = w.Write([]byte("-last")) //nolint:all
+		})
+		_, addr := startProxy(poll, Config{UnbufferedPaths: []string{"/v1/poll"}})
+		// released before the servers are closed, which wait for the handler
+		releaseOnce := sync.OnceFunc(func() { close(release) })
+		DeferCleanup(releaseOnce)
+
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get("http://" + addr + "/v1/poll")
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+
+		// the first part is received while the decoder still holds the rest of the response
+		first := make([]byte, 5)
+		_, err = io.ReadFull(resp.Body, first)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(first)).To(Equal("first"))
+
+		releaseOnce()
+		Expect(io.ReadAll(resp.Body)).To(Equal([]byte("-last")))
+	})
+})
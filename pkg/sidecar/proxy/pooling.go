@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+// poolingHandler serves the pooling requests, such as scoring, reranking and embeddings, from
+// the local engine. They generate no tokens, so they are never disaggregated, even when the EPP
+// selected a prefill pod.
+func (s *Server) poolingHandler(w http.ResponseWriter, r *http.Request) {
+	route := r.URL.Path
+
+	r = extractTraceContext(r)
+	ctx, span := startSpan(r.Context(), "pooling", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(routeAttribute.String(route)))
+	r = r.WithContext(ctx)
+	injectTraceContext(r)
+
+	if prefillPodHostPort := r.Header.Get(common.PrefillPodHeader); prefillPodHostPort != "" {
+		s.requestLogger(r).V(4).Info("skip disaggregated prefill of pooling request", "route", route, "prefiller", prefillPodHostPort)
+		r.Header.Del(common.PrefillPodHeader)
+	}
+
+	sw := &statusRecorder{ResponseWriter: w}
+	start := time.Now()
+	s.serveDecoder(sw, r)
+	s.metrics.observePooling(route, start)
+	endSpan(span, sw.status())
+}
+
+// disaggregatedPoolingHandler sends the pooling requests of the DisaggregatedPoolingPaths through the
+// P/D protocol when the EPP selected a prefill pod, and serves the others from the local engine
+func (s *Server) disaggregatedPoolingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(common.PrefillPodHeader) == "" {
+		s.poolingHandler(w, r)
+		return
+	}
+	s.chatCompletionsHandler(w, r)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Pooling requests", func() {
+	var (
+		server          *httptest.Server
+		decodeHandler   *mock.GenericHandler
+		prefillHandler  *mock.GenericHandler
+		prefillHostPort string
+		prefillHeaders  []string
+	)
+
+	BeforeEach(func() {
+		decodeHandler = &mock.GenericHandler{}
+		prefillHeaders = nil
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			prefillHeaders = append(prefillHeaders, r.Header.Get(common.PrefillPodHeader))
+			decodeHandler.ServeHTTP(w, r)
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillHandler = &mock.GenericHandler{}
+		prefillBackend := httptest.NewServer(prefillHandler)
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	scrapeMetrics := func() string {
+		resp, err := http.Get(server.URL + MetricsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return string(body)
+	}
+
+	DescribeTable("should serve the pooling requests locally and never disaggregate them",
+		func(path string, body string) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			_, _ = io.ReadAll(resp.Body) //nolint:all
+			resp.Body.Close()            //nolint:all
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+			Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+			Expect(prefillHeaders).To(Equal([]string{""}))
+
+			metrics := scrapeMetrics()
+			Expect(metrics).To(ContainSubstring(`llm_d_sidecar_requests_total{code="200",route="` + path + `"} 1`))
+			Expect(metrics).To(ContainSubstring(`llm_d_sidecar_pooling_duration_seconds_count{route="` + path + `"} 1`))
+			Expect(metrics).ToNot(ContainSubstring(`llm_d_sidecar_decode_duration_seconds_count`))
+		},
+		Entry("score", "/score", `{"model":"m","text_1":"a","text_2":"b"}`),
+		Entry("v1 score", "/v1/score", `{"model":"m","text_1":"a","text_2":"b"}`),
+		Entry("rerank", "/rerank", `{"model":"m","query":"q","documents":["a","b"]}`),
+		Entry("v2 rerank", "/v2/rerank", `{"model":"m","query":"q","documents":["a","b"]}`),
+		Entry("pooling", "/pooling", `{"model":"m","input":"a"}`),
+		Entry("embeddings", "/v1/embeddings", `{"model":"m","input":"a"}`),
+	)
+})
+
+var _ = Describe("Disaggregated pooling requests", func() {
+	var (
+		decodeHandler   *mock.ChatCompletionHandler
+		prefillHandler  *mock.ChatCompletionHandler
+		decodeURL       *url.URL
+		prefillHostPort string
+	)
+
+	BeforeEach(func() {
+		decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+		decodeBackend := httptest.NewServer(decodeHandler)
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+		prefillBackend := httptest.NewServer(prefillHandler)
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+	})
+
+	send := func(paths []string, path string, prefill bool) int {
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, DisaggregatedPoolingPaths: paths})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(`{"model":"m","input":"a"}`))
+		Expect(err).ToNot(HaveOccurred())
+		if prefill {
+			req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		_, _ = io.ReadAll(resp.Body) //nolint:all
+		resp.Body.Close()            //nolint:all
+		return resp.StatusCode
+	}
+
+	It("should send the requests of the configured paths through the P/D protocol", func() {
+		Expect(send([]string{"/v1/embeddings"}, "/v1/embeddings", true)).To(Equal(http.StatusOK))
+
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		// the pooling requests have no generation fields to limit the prefill with
+		Expect(prefillHandler.CompletionRequests[0]).ToNot(HaveKey(requestFieldMaxTokens))
+		Expect(prefillHandler.CompletionRequests[0]).ToNot(HaveKey(requestFieldStream))
+		Expect(decodeHandler.CompletionRequests[0]).To(HaveKey(requestFieldKVTransferParams))
+		Expect(decodeHandler.CompletionRequests[0]).ToNot(HaveKey(requestFieldMaxTokens))
+	})
+
+	It("should support the pooling paths alias", func() {
+		Expect(send([]string{common.PoolingPathsAlias}, "/score", true)).To(Equal(http.StatusOK))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+	})
+
+	It("should serve the requests without a prefill pod locally", func() {
+		Expect(send([]string{"/v1/embeddings"}, "/v1/embeddings", false)).To(Equal(http.StatusOK))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+	})
+
+	It("should not disaggregate the other pooling paths", func() {
+		Expect(send([]string{"/v1/embeddings"}, "/score", true)).To(Equal(http.StatusOK))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+	})
+})
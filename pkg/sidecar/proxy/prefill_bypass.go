@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// bypassPrefill returns true when the estimated prompt tokens of the request are below
+// Config.PrefillBypassTokens, the request being then decoded locally even though a prefill pod was
+// selected, e.g. by an EPP with a misconfigured threshold, saving the prefill round trip of the tiny
+// prompts. Returns false and replies with an error when the body cannot be read.
+func (s *Server) bypassPrefill(w http.ResponseWriter, r *http.Request) (bypass bool, ok bool) {
+	body, ok := s.readRequestBody(w, r)
+	if !ok {
+		return false, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.TransferEncoding = nil
+
+	tokens := estimatePromptTokens(body)
+	if tokens >= s.config.PrefillBypassTokens {
+		return false, true
+	}
+	s.requestLogger(r).V(4).Info("prompt below the prefill bypass threshold, skip disaggregated prefill", "tokens", tokens)
+	s.metrics.observePrefillBypass()
+	return true, true
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill bypass", func() {
+	sendCompletion := func(server *Server, prompt string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath,
+			strings.NewReader(`{"model":"m","prompt":"`+prompt+`"}`))
+		req.Header.Set(common.PrefillPodHeader, inProcessPrefillHostPort)
+		rec := httptest.NewRecorder()
+		server.chatCompletionsHandler(rec, req)
+		return rec
+	}
+
+	It("should decode the short prompts locally, even when a prefill pod is selected", func() {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV2,
+			[]byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`))
+		server.allowlistValidator = &AllowlistValidator{enabled: false}
+		server.config.PrefillBypassTokens = 50 // about 200 bytes of request body
+
+		rec := sendCompletion(server, "hi")
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(BeEmpty())
+		Expect(decoder.bodies).To(HaveExactElements(MatchJSON(`{"model":"m","prompt":"hi"}`)))
+		Expect(testutil.ToFloat64(server.metrics.prefillBypasses)).To(Equal(1.0))
+
+		rec = sendCompletion(server, strings.Repeat("x", 200))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(HaveLen(1))
+		Expect(decoder.bodies).To(HaveLen(2))
+		Expect(testutil.ToFloat64(server.metrics.prefillBypasses)).To(Equal(1.0))
+	})
+})
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// hedgeWinnerPrimary labels the hedged prefills answered first by the prefill target
+	hedgeWinnerPrimary = "primary"
+
+	// hedgeWinnerHedge labels the hedged prefills answered first by the hedge target
+	hedgeWinnerHedge = "hedge"
+
+	// hedgeWinnerNone labels the hedged prefills failed on both targets
+	hedgeWinnerNone = "none"
+)
+
+// prefillResult is the buffered response of the prefill request sent to a target
+type prefillResult struct {
+	pw     *bufferedResponseWriter
+	target string
+}
+
+// hedgedPrefill sends the prefill request to the prefill target, and to the hedge target as well when
+// the prefill target did not answer within the hedge delay. It returns the first successful response
+// with the target it came from, and cancels the other request. The prefill target failing before the
+// hedge delay fails over to the hedge target, and the last failure is returned when both requests failed.
+func (s *Server) hedgedPrefill(preq *http.Request, body []byte, prefillPodHostPort string, hedgePodHostPort string) (*bufferedResponseWriter, string) {
+	ctx, cancel := context.WithCancel(preq.Context())
+	defer cancel() // cancels the request still running
+
+	results := make(chan prefillResult, 2)
+	send := func(target string) {
+		results <- prefillResult{pw: s.prefillTarget(preq.Clone(ctx), body, target), target: target}
+	}
+	go send(prefillPodHostPort)
+
+	timer := time.NewTimer(s.config.PrefillHedgeDelay)
+	defer timer.Stop()
+
+	pending := 1
+	hedged := false    // the hedge target was sent the request after the hedge delay
+	hedgeSent := false // the hedge target was sent the request, after the hedge delay or a failure
+	for {
+		select {
+		case <-timer.C:
+			if hedgeSent {
+				continue
+			}
+			s.requestLogger(preq).V(4).Info("prefill latency budget exceeded, hedging the prefill request",
+				"from", prefillPodHostPort, "to", hedgePodHostPort, "delay", s.config.PrefillHedgeDelay)
+			hedged, hedgeSent = true, true
+			pending++
+			go send(hedgePodHostPort)
+
+		case result := <-results:
+			pending--
+			succeeded := result.pw.statusCode >= 200 && result.pw.statusCode < 300
+			if !succeeded && !hedgeSent && isFailure(result.pw.statusCode) && ctx.Err() == nil {
+				s.requestLogger(preq).V(4).Info("prefill failed, trying the next prefill target",
+					"failed", prefillPodHostPort, "code", result.pw.statusCode, "next", hedgePodHostPort)
+				s.metrics.observePrefillFailover(s.connector)
+				hedgeSent = true
+				pending++
+				go send(hedgePodHostPort)
+				continue
+			}
+			if !succeeded && pending > 0 {
+				continue // the other request may still succeed
+			}
+			if hedged {
+				winner := hedgeWinnerNone
+				switch {
+				case succeeded && result.target == hedgePodHostPort:
+					winner = hedgeWinnerHedge
+				case succeeded:
+					winner = hedgeWinnerPrimary
+				}
+				s.metrics.observePrefillHedge(s.connector, winner)
+			}
+			return result.pw, result.target
+		}
+	}
+}
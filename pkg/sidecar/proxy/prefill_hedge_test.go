@@ -0,0 +1,151 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill hedging", func() {
+	var (
+		decodeHandler    *mock.ChatCompletionHandler
+		hedgeHandler     *mock.ChatCompletionHandler
+		decodeURL        *url.URL
+		slowHostPort     string
+		hedgeHostPort    string
+		slowCancelled    chan struct{}
+		slowRequestCount *atomic.Int32
+	)
+
+	BeforeEach(func() {
+		decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+		decodeBackend := httptest.NewServer(decodeHandler)
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		// the slow prefiller hangs until the request is cancelled, or the test ends
+		released := make(chan struct{})
+		slowCancelled = make(chan struct{}, 1)
+		slowRequestCount = &atomic.Int32{}
+		slowBackend := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			slowRequestCount.Add(1)
+			select {
+			case <-r.Context().Done():
+				slowCancelled <- struct{}{}
+			case <-released:
+			}
+		}))
+		DeferCleanup(slowBackend.Close)
+		DeferCleanup(func() { close(released) })
+		slowHostPort = strings.TrimPrefix(slowBackend.URL, "http://")
+
+		hedgeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+		hedgeBackend := httptest.NewServer(hedgeHandler)
+		DeferCleanup(hedgeBackend.Close)
+		hedgeHostPort = strings.TrimPrefix(hedgeBackend.URL, "http://")
+	})
+
+	sendCompletion := func(proxy *Server, prefillTargets string) int {
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillTargets)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		_, err = io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp.StatusCode
+	}
+
+	newHedgingProxy := func(delay time.Duration) *Server {
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, PrefillHedgeDelay: delay})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		return proxy
+	}
+
+	It("should use the hedge prefiller when the prefiller exceeds the latency budget", func() {
+		proxy := newHedgingProxy(50 * time.Millisecond)
+
+		start := time.Now()
+		code := sendCompletion(proxy, slowHostPort+","+hedgeHostPort)
+
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		Expect(hedgeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		Eventually(slowCancelled).Should(Receive())
+		Expect(testutil.ToFloat64(proxy.metrics.prefillHedges.WithLabelValues(ConnectorNIXLV2, hedgeWinnerHedge))).To(Equal(1.0))
+	})
+
+	It("should not hedge the prefills answered within the latency budget", func() {
+		proxy := newHedgingProxy(time.Minute)
+
+		code := sendCompletion(proxy, hedgeHostPort+","+slowHostPort)
+
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(slowRequestCount.Load()).To(BeNumerically("==", 0))
+		Expect(testutil.CollectAndCount(proxy.metrics.prefillHedges)).To(Equal(0))
+	})
+
+	It("should only fail over to the next prefill target when disabled", func() {
+		proxy := newHedgingProxy(0)
+		proxy.config.PrefillTimeout = 100 * time.Millisecond
+
+		start := time.Now()
+		code := sendCompletion(proxy, slowHostPort+","+hedgeHostPort)
+
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+		Expect(hedgeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		Expect(testutil.CollectAndCount(proxy.metrics.prefillHedges)).To(Equal(0))
+		Expect(testutil.ToFloat64(proxy.metrics.prefillFailovers.WithLabelValues(ConnectorNIXLV2))).To(Equal(1.0))
+	})
+
+	It("should not hedge to the candidates not in the allowlist", func() {
+		proxy := newHedgingProxy(50 * time.Millisecond)
+		proxy.config.PrefillTimeout = 200 * time.Millisecond
+		proxy.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New("127.0.0.1")}
+		proxy.allowlistValidator.ready.Store(true)
+
+		// the allowlist is by host, only the IP of the test servers is allowed
+		deniedHostPort := strings.Replace(hedgeHostPort, "127.0.0.1", "localhost", 1)
+		code := sendCompletion(proxy, slowHostPort+","+deniedHostPort)
+
+		Expect(code).To(Equal(http.StatusGatewayTimeout))
+		Expect(hedgeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+})
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// pipelinedTransferParams are the KV-transfer parameters parsed from a prefill response still being received
+type pipelinedTransferParams struct {
+	response []byte // a prefiller response holding only the kv_transfer_params field
+	target   string
+}
+
+// prefillPipeline hands over the KV-transfer parameters of the first successful prefill response
+// to the decode stage, as soon as they are parsed
+type prefillPipeline struct {
+	once  sync.Once
+	ready chan pipelinedTransferParams
+}
+
+type prefillPipelineKey struct{}
+
+// prefillPipelineFromContext returns the prefill pipeline of a prefill request, nil when not pipelined
+func prefillPipelineFromContext(ctx context.Context) *prefillPipeline {
+	pipeline, _ := ctx.Value(prefillPipelineKey{}).(*prefillPipeline)
+	return pipeline
+}
+
+// pipelinedResponseWriter buffers a prefill response, and hands its KV-transfer parameters over to the
+// pipeline once they are received, before the end of the response
+type pipelinedResponseWriter struct {
+	*bufferedResponseWriter
+	pipeline *prefillPipeline
+	target   string
+	done     bool
+}
+
+func (w *pipelinedResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.bufferedResponseWriter.Write(b)
+	if w.done || w.statusCode < 200 || w.statusCode >= 300 {
+		return n, err
+	}
+
+	transferParams, ok := completeField([]byte(w.buffer.String()), requestFieldKVTransferParams)
+	if !ok {
+		return n, err
+	}
+	w.done = true
+	response, marshalErr := encodeJSON(map[string]json.RawMessage{requestFieldKVTransferParams: transferParams})
+	if marshalErr != nil {
+		return n, err
+	}
+	w.pipeline.once.Do(func() {
+		w.pipeline.ready <- pipelinedTransferParams{response: response, target: w.target}
+	})
+	return n, err
+}
+
+// completeField returns the value of the given field of the JSON object starting the data, and true
+// once the value is complete, even though the object is not
+func completeField(data []byte, field string) (json.RawMessage, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, false
+		}
+		if key == field {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// pipelinedPrefill sends the prefill request to the prefill targets, and returns the KV-transfer
+// parameters of the first successful response as soon as they are received, the rest of the response
+// being received in the background. The returned function waits for the prefill to complete. The
+// response is nil when the prefill failed, the response to the client being written.
+func (s *Server) pipelinedPrefill(w http.ResponseWriter, r *http.Request, preq *http.Request, body []byte,
+	original []byte, targets []string) ([]byte, func()) {
+	pipeline := &prefillPipeline{ready: make(chan pipelinedTransferParams, 1)}
+	preq = preq.WithContext(context.WithValue(preq.Context(), prefillPipelineKey{}, pipeline))
+
+	var pw *bufferedResponseWriter
+	var target string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw, target = s.prefillTargets(preq, body, targets)
+	}()
+	wait := func() { <-done }
+
+	select {
+	case params := <-pipeline.ready:
+		s.requestLogger(r).V(4).Info("KV-transfer parameters received, pipelining the decode request", "from", params.target)
+		s.metrics.observePrefillPipelined(s.connector)
+		if entry := accessLogFromContext(r.Context()); entry != nil {
+			entry.PrefillTarget = params.target
+		}
+		s.annotateRouting(w, RoutingPrefillTargetHeader, params.target)
+		return params.response, wait
+	case <-done:
+		return s.prefillResponse(w, r, original, pw, target), wait
+	}
+}
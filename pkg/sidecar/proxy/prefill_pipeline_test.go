@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill pipelining", func() {
+	It("should find the complete fields of a partial JSON object", func() {
+		value, ok := completeField([]byte(`{"id":"cmpl-1","kv_transfer_params":{"remote_port":4032},"choi`), "kv_transfer_params")
+		Expect(ok).To(BeTrue())
+		Expect(string(value)).To(Equal(`{"remote_port":4032}`))
+
+		_, ok = completeField([]byte(`{"id":"cmpl-1","kv_transfer_params":{"remote_po`), "kv_transfer_params")
+		Expect(ok).To(BeFalse())
+		_, ok = completeField([]byte(`{"id":"cmpl-1","choices":[]}`), "kv_transfer_params")
+		Expect(ok).To(BeFalse())
+		_, ok = completeField([]byte(`["kv_transfer_params"]`), "kv_transfer_params")
+		Expect(ok).To(BeFalse())
+	})
+
+	Context("with a prefiller sending its response in chunks", func() {
+		var (
+			decodeHandler   *mock.ChatCompletionHandler
+			decodeURL       *url.URL
+			prefillHostPort string
+			prefillStatus   int
+			released        chan struct{}
+		)
+
+		BeforeEach(func() {
+			decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+			decodeBackend := httptest.NewServer(decodeHandler)
+			DeferCleanup(decodeBackend.Close)
+			var err error
+			decodeURL, err = url.Parse(decodeBackend.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the prefiller sends the KV-transfer parameters, then hangs until released
+			prefillStatus = http.StatusOK
+			released = make(chan struct{})
+			prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body) //nolint:all
+				w.WriteHeader(prefillStatus)
+				w.Write([]byte(`{"id":"cmpl-1","kv_transfer_params":{"remote_block_ids":[1,2,3],"remote_engine_id":"engine","remote_host":"ahost","remote_port":4032},`)) //nolint:all
+				w.(http.Flusher).Flush()
+				select {
+				case <-released:
+				case <-r.Context().Done():
+				}
+				w.Write([]byte(`"choices":[{"text":""}]}`)) //nolint:all
+			}))
+			DeferCleanup(prefillBackend.Close)
+			prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+		})
+
+		sendCompletion := func(proxy *Server) *http.Response {
+			server := httptest.NewServer(proxy.createRoutes())
+			DeferCleanup(server.Close)
+			DeferCleanup(func() { // the server waits for the prefill to complete
+				select {
+				case <-released:
+				default:
+					close(released)
+				}
+			})
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			return resp
+		}
+
+		newProxy := func(pipelining bool) *Server {
+			proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, PrefillPipelining: pipelining})
+			proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+			return proxy
+		}
+
+		It("should send the decode request before the end of the prefill response", func() {
+			proxy := newProxy(true)
+
+			responses := make(chan *http.Response, 1)
+			go func() {
+				defer GinkgoRecover()
+				responses <- sendCompletion(proxy)
+			}()
+			Eventually(decodeHandler.RequestCount.Load).Should(BeNumerically("==", 1))
+			Expect(testutil.ToFloat64(proxy.metrics.prefillPipelined.WithLabelValues(ConnectorNIXLV2))).To(Equal(1.0))
+
+			close(released)
+			Eventually(responses).Should(Receive(HaveField("StatusCode", http.StatusOK)))
+
+			transferParams, err := json.Marshal(decodeHandler.CompletionRequests[0][requestFieldKVTransferParams])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transferParams).To(MatchJSON(`{"remote_block_ids":[1,2,3],"remote_engine_id":"engine","remote_host":"ahost","remote_port":4032}`))
+		})
+
+		It("should wait for the end of the prefill response when disabled", func() {
+			proxy := newProxy(false)
+
+			responses := make(chan *http.Response, 1)
+			go func() {
+				defer GinkgoRecover()
+				responses <- sendCompletion(proxy)
+			}()
+			Consistently(decodeHandler.RequestCount.Load, "100ms").Should(BeNumerically("==", 0))
+
+			close(released)
+			Eventually(responses).Should(Receive(HaveField("StatusCode", http.StatusOK)))
+			Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		})
+
+		It("should not pipeline the failed prefill responses", func() {
+			prefillStatus = http.StatusInternalServerError
+			proxy := newProxy(true)
+			close(released)
+
+			resp := sendCompletion(proxy)
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+			Expect(testutil.CollectAndCount(proxy.metrics.prefillPipelined)).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill response policy", func() {
+	const request = `{"model":"m","prompt":"hi","max_tokens":10}`
+
+	DescribeTable("should handle the prefill responses without valid KV-transfer parameters",
+		func(policy string, prefillResponse string, wantCode int, wantKind errorKind, wantDecode string) {
+			server, decoder, _ := newInProcessProxy(ConnectorNIXLV2, []byte(prefillResponse))
+			server.config.PrefillResponsePolicy = policy
+
+			rec := sendConnectorRequest(server, []byte(request))
+
+			Expect(rec.Code).To(Equal(wantCode))
+			if wantKind != "" {
+				var response errorResponse
+				Expect(json.Unmarshal(rec.Body.Bytes(), &response)).To(Succeed())
+				Expect(response.Kind).To(Equal(wantKind))
+			}
+			if wantDecode == "" {
+				Expect(decoder.bodies).To(BeEmpty())
+			} else {
+				Expect(decoder.bodies).To(HaveLen(1))
+				Expect(string(decoder.bodies[0])).To(Equal(wantDecode))
+			}
+			Expect(testutil.ToFloat64(server.metrics.invalidPrefills.WithLabelValues(ConnectorNIXLV2))).To(Equal(1.0))
+		},
+		Entry("warn on missing parameters", PrefillResponsePolicyWarn, `{}`, http.StatusOK, errorKind(""),
+			`{"kv_transfer_params":null,"max_tokens":10,"model":"m","prompt":"hi"}`),
+		Entry("warn on malformed response", PrefillResponsePolicyWarn, `not json`, http.StatusBadRequest, errorKindBadRequest, ""),
+		Entry("fail on missing parameters", PrefillResponsePolicyFail, `{}`, http.StatusBadGateway, errorKindPrefillInvalid, ""),
+		Entry("fail on malformed response", PrefillResponsePolicyFail, `[]`, http.StatusBadGateway, errorKindPrefillInvalid, ""),
+		Entry("decode on missing parameters", PrefillResponsePolicyDecode, `{}`, http.StatusOK, errorKind(""), request),
+	)
+
+	It("should not count the valid prefill responses", func() {
+		server, decoder, _ := newInProcessProxy(ConnectorNIXLV2, []byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`))
+		server.config.PrefillResponsePolicy = PrefillResponsePolicyFail
+
+		Expect(sendConnectorRequest(server, []byte(request)).Code).To(Equal(http.StatusOK))
+		Expect(decoder.bodies).To(HaveLen(1))
+		Expect(testutil.CollectAndCount(server.metrics.invalidPrefills)).To(Equal(0))
+	})
+
+	It("should classify the errors of the nixlv2 connector", func() {
+		connector := &nixlV2Connector{}
+
+		_, err := connector.ExtractTransferParams(context.Background(), []byte(`{"id":"cmpl-1"}`))
+		Expect(errors.Is(err, ErrMissingTransferParams)).To(BeTrue())
+		_, err = connector.ExtractTransferParams(context.Background(), []byte(`{`))
+		Expect(errors.Is(err, ErrInvalidPrefillResponse)).To(BeTrue())
+		Expect(errors.Is(err, ErrMissingTransferParams)).To(BeFalse())
+	})
+})
@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill retries and fallback", func() {
+	var (
+		decodeHandler   *mock.ChatCompletionHandler
+		prefillFailures atomic.Int32 // the number of prefill requests failing before the prefiller recovers
+		prefillStatus   int
+		prefillRequests atomic.Int32
+		prefillHostPort string
+		decodeURL       *url.URL
+	)
+
+	BeforeEach(func() {
+		decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+		decodeBackend := httptest.NewServer(decodeHandler)
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillStatus = http.StatusServiceUnavailable
+		prefillRequests.Store(0)
+		prefillHandler := &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if prefillRequests.Add(1) <= prefillFailures.Load() {
+				_, _ = io.ReadAll(r.Body) //nolint:all
+				w.WriteHeader(prefillStatus)
+				return
+			}
+			prefillHandler.ServeHTTP(w, r)
+		}))
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+	})
+
+	startProxy := func(config Config) *httptest.Server {
+		config.Connector = ConnectorNIXLV2
+		config.PrefillRetryBackoff = time.Millisecond
+		proxy := NewProxy("0", decodeURL, config)
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	sendCompletion := func(server *httptest.Server) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		_, _ = io.ReadAll(resp.Body) //nolint:all
+		resp.Body.Close()            //nolint:all
+		return resp.StatusCode
+	}
+
+	scrapeMetrics := func(server *httptest.Server) string {
+		resp, err := http.Get(server.URL + MetricsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return string(body)
+	}
+
+	It("should retry the failed prefill requests", func() {
+		prefillFailures.Store(2)
+		server := startProxy(Config{PrefillRetries: 2})
+
+		Expect(sendCompletion(server)).To(Equal(http.StatusOK))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 3))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		Expect(decodeHandler.CompletionRequests[0]).To(HaveKey(requestFieldKVTransferParams))
+		Expect(scrapeMetrics(server)).To(ContainSubstring(`llm_d_sidecar_prefill_retries_total{connector="nixlv2"} 2`))
+	})
+
+	It("should return the prefill failure once the retries are exhausted", func() {
+		prefillFailures.Store(3)
+		server := startProxy(Config{PrefillRetries: 2})
+
+		Expect(sendCompletion(server)).To(Equal(http.StatusServiceUnavailable))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 3))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should fall back to the local decoder when the prefill failed", func() {
+		prefillFailures.Store(3)
+		server := startProxy(Config{PrefillRetries: 1, PrefillFallback: true})
+
+		Expect(sendCompletion(server)).To(Equal(http.StatusOK))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 2))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+		Expect(decodeHandler.CompletionRequests[0]).ToNot(HaveKey(requestFieldKVTransferParams))
+
+		metrics := scrapeMetrics(server)
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_prefill_fallbacks_total{connector="nixlv2"} 1`))
+		Expect(metrics).To(ContainSubstring(`llm_d_sidecar_decode_duration_seconds_count{connector="none"} 1`))
+	})
+
+	It("should neither retry nor fall back on client errors", func() {
+		prefillFailures.Store(1)
+		prefillStatus = http.StatusBadRequest
+		server := startProxy(Config{PrefillRetries: 2, PrefillFallback: true})
+
+		Expect(sendCompletion(server)).To(Equal(http.StatusBadRequest))
+		Expect(prefillRequests.Load()).To(BeNumerically("==", 1))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+})
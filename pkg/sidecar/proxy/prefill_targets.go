@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// parsePrefillTargets returns the prefill targets of the prefill pod header: a single <host:port>, or a
+// comma separated list of them ranked by the EPP
+func parsePrefillTargets(header string) []string {
+	targets := []string{}
+	for _, target := range strings.Split(header, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// prefillTargets sends the prefill request to the prefill targets in order, until one of them does not
+// fail because of the prefiller, so that the requests survive the prefiller churn without a scheduling
+// round trip. With hedging, each target is hedged to the next one. The job of the request is pinned to
+// the target which served it. Returns the response of the last prefill request sent, and its target.
+func (s *Server) prefillTargets(preq *http.Request, body []byte, targets []string) (*bufferedResponseWriter, string) {
+	var pw *bufferedResponseWriter
+	var target string
+	for len(targets) > 0 {
+		if pw != nil {
+			s.requestLogger(preq).V(4).Info("prefill failed, trying the next prefill target", "failed", target, "code", pw.statusCode, "next", targets[0])
+			s.metrics.observePrefillFailover(s.connector)
+		}
+
+		target, targets = targets[0], targets[1:]
+		if s.config.PrefillHedgeDelay > 0 && len(targets) > 0 {
+			var hedge string
+			hedge, targets = targets[0], targets[1:]
+			pw, target = s.hedgedPrefill(preq, body, target, hedge)
+		} else {
+			pw = s.prefillTarget(preq, body, target)
+		}
+
+		if !isFailure(pw.statusCode) || preq.Context().Err() != nil {
+			break
+		}
+	}
+	if s.jobPins != nil && pw.statusCode < http.StatusBadRequest {
+		s.jobPins.pin(preq.Header.Get(s.jobPins.header), target)
+	}
+	return pw, target
+}
+
+// prefillTarget sends the prefill request to a single prefill target
+func (s *Server) prefillTarget(preq *http.Request, body []byte, target string) *bufferedResponseWriter {
+	prefillHandler, err := s.prefillerProxyHandler(target)
+	if err != nil {
+		pw := &bufferedResponseWriter{}
+		s.bufferError(pw, prefillUnreachableError(err))
+		return pw
+	}
+	return s.prefill(prefillHandler, preq, body, target)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill targets", func() {
+	It("should parse the ranked prefill targets", func() {
+		Expect(parsePrefillTargets("10.0.0.1:8000")).To(Equal([]string{"10.0.0.1:8000"}))
+		Expect(parsePrefillTargets("10.0.0.1:8000, 10.0.0.2:8000,,")).To(Equal([]string{"10.0.0.1:8000", "10.0.0.2:8000"}))
+		Expect(parsePrefillTargets(" , ")).To(BeEmpty())
+		Expect(parsePrefillTargets("")).To(BeEmpty())
+	})
+
+	Context("with several prefill targets", func() {
+		var (
+			decodeHandler    *mock.ChatCompletionHandler
+			prefillHandler   *mock.ChatCompletionHandler
+			decodeURL        *url.URL
+			failingRequests  *atomic.Int32
+			failingHostPort  string
+			prefillHostPort  string
+			accessLog        *lockedBuffer
+			sendCompletionTo func(proxy *Server, prefillTargets string) int
+		)
+
+		BeforeEach(func() {
+			decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+			decodeBackend := httptest.NewServer(decodeHandler)
+			DeferCleanup(decodeBackend.Close)
+			var err error
+			decodeURL, err = url.Parse(decodeBackend.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			failingRequests = &atomic.Int32{}
+			failingBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body) //nolint:all
+				failingRequests.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			DeferCleanup(failingBackend.Close)
+			failingHostPort = strings.TrimPrefix(failingBackend.URL, "http://")
+
+			prefillHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+			prefillBackend := httptest.NewServer(prefillHandler)
+			DeferCleanup(prefillBackend.Close)
+			prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+			accessLog = &lockedBuffer{}
+			sendCompletionTo = func(proxy *Server, prefillTargets string) int {
+				server := httptest.NewServer(proxy.createRoutes())
+				DeferCleanup(server.Close)
+
+				req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set(common.PrefillPodHeader, prefillTargets)
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close() //nolint:all
+				_, err = io.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				return resp.StatusCode
+			}
+		})
+
+		newProxy := func() *Server {
+			proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, AccessLog: accessLog})
+			proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+			return proxy
+		}
+
+		It("should fail over to the next prefill target when the prefill failed", func() {
+			proxy := newProxy()
+
+			code := sendCompletionTo(proxy, failingHostPort+","+prefillHostPort)
+
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(failingRequests.Load()).To(BeNumerically("==", 1))
+			Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+			Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+			Expect(testutil.ToFloat64(proxy.metrics.prefillFailovers.WithLabelValues(ConnectorNIXLV2))).To(Equal(1.0))
+			Eventually(accessLog.String).Should(ContainSubstring(`"prefill_target":"` + prefillHostPort + `"`))
+		})
+
+		It("should only send the prefill request to the first prefill target when it succeeded", func() {
+			proxy := newProxy()
+
+			code := sendCompletionTo(proxy, prefillHostPort+","+failingHostPort)
+
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(failingRequests.Load()).To(BeNumerically("==", 0))
+			Expect(testutil.CollectAndCount(proxy.metrics.prefillFailovers)).To(Equal(0))
+		})
+
+		It("should return the last failure when all the prefill targets failed", func() {
+			proxy := newProxy()
+
+			code := sendCompletionTo(proxy, failingHostPort+","+failingHostPort)
+
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(failingRequests.Load()).To(BeNumerically("==", 2))
+			Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+		})
+
+		It("should skip the prefill targets not in the allowlist", func() {
+			proxy := newProxy()
+			proxy.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New("127.0.0.1")}
+			proxy.allowlistValidator.ready.Store(true)
+
+			// the allowlist is by host, only the IP of the test servers is allowed
+			deniedHostPort := strings.Replace(failingHostPort, "127.0.0.1", "localhost", 1)
+			code := sendCompletionTo(proxy, deniedHostPort+","+prefillHostPort)
+
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(failingRequests.Load()).To(BeNumerically("==", 0))
+
+			code = sendCompletionTo(proxy, deniedHostPort)
+			Expect(code).To(Equal(http.StatusForbidden))
+		})
+
+		It("should accept and count the legacy prefill URLs", func() {
+			proxy := newProxy()
+
+			code := sendCompletionTo(proxy, "http://"+prefillHostPort)
+
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+			Expect(testutil.ToFloat64(proxy.metrics.legacyPrefillURLs)).To(Equal(1.0))
+
+			Expect(sendCompletionTo(proxy, prefillHostPort)).To(Equal(http.StatusOK))
+			Expect(testutil.ToFloat64(proxy.metrics.legacyPrefillURLs)).To(Equal(1.0))
+		})
+
+		It("should reject the legacy prefill URLs when disabled", func() {
+			proxy := newProxy()
+			proxy.config.DisableLegacyPrefillURLs = true
+
+			code := sendCompletionTo(proxy, prefillHostPort+",http://"+failingHostPort)
+
+			Expect(code).To(Equal(http.StatusBadRequest))
+			Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+			Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+			Expect(testutil.ToFloat64(proxy.metrics.legacyPrefillURLs)).To(Equal(1.0))
+
+			Expect(sendCompletionTo(proxy, prefillHostPort)).To(Equal(http.StatusOK))
+		})
+	})
+})
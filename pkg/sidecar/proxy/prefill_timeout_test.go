@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefill timeout", func() {
+	var (
+		decodeHandler   *mock.ChatCompletionHandler
+		decodeURL       *url.URL
+		prefillHostPort string
+	)
+
+	BeforeEach(func() {
+		decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+		decodeBackend := httptest.NewServer(decodeHandler)
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		// the prefiller hangs until the request is cancelled, or the test ends
+		released := make(chan struct{})
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			select {
+			case <-r.Context().Done():
+			case <-released:
+			}
+		}))
+		DeferCleanup(prefillBackend.Close)
+		DeferCleanup(func() { close(released) })
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+	})
+
+	sendCompletion := func(config Config) (int, errorResponse) {
+		config.Connector = ConnectorNIXLV2
+		proxy := NewProxy("0", decodeURL, config)
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+
+		var response errorResponse
+		_ = json.Unmarshal(body, &response) //nolint:all
+		return resp.StatusCode, response
+	}
+
+	It("should fail a hung prefill with a gateway timeout", func() {
+		start := time.Now()
+		code, response := sendCompletion(Config{PrefillTimeout: 100 * time.Millisecond})
+
+		Expect(code).To(Equal(http.StatusGatewayTimeout))
+		Expect(response.Type).To(Equal("GatewayTimeout"))
+		Expect(response.Code).To(Equal(http.StatusGatewayTimeout))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should fall back to the local decoder when the prefill timed out", func() {
+		code, _ := sendCompletion(Config{PrefillTimeout: 100 * time.Millisecond, PrefillFallback: true})
+
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+const prefillerDialTimeout = 30 * time.Second
+
+// resolvedHost are the cached addresses of a prefiller DNS name
+type resolvedHost struct {
+	addrs      []string
+	resolvedAt time.Time
+}
+
+// prefillerResolver resolves the DNS names of the prefill targets, e.g. the records of a headless
+// service, and caches their addresses for a TTL instead of resolving them on each connection.
+// The addresses are re-resolved when connecting to all of them failed, since the prefill pods
+// may have moved, and the outdated addresses are kept when the name cannot be resolved anymore.
+type prefillerResolver struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+	dialer *net.Dialer
+
+	mutex sync.Mutex
+	hosts map[string]*resolvedHost
+}
+
+// newPrefillerResolver returns the prefiller resolver, or nil when the TTL is not positive
+func newPrefillerResolver(ttl time.Duration) *prefillerResolver {
+	if ttl <= 0 {
+		return nil
+	}
+	return &prefillerResolver{
+		ttl:    ttl,
+		lookup: net.DefaultResolver.LookupHost,
+		dialer: &net.Dialer{Timeout: prefillerDialTimeout, KeepAlive: 30 * time.Second},
+		hosts:  map[string]*resolvedHost{},
+	}
+}
+
+// dialContext connects to the address of a prefiller, resolving its host with the cache.
+// It is used as the DialContext of the prefiller transports.
+func (r *prefillerResolver) dialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return r.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := r.resolve(ctx, host, false)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := r.dialAny(ctx, network, addrs, port)
+	if err == nil {
+		return conn, nil
+	}
+
+	refreshed, resolveErr := r.resolve(ctx, host, true)
+	if resolveErr != nil || slices.Equal(refreshed, addrs) {
+		return nil, err
+	}
+	return r.dialAny(ctx, network, refreshed, port)
+}
+
+// resolve returns the addresses of a host, from the cache unless they expired or a refresh is forced
+func (r *prefillerResolver) resolve(ctx context.Context, host string, refresh bool) ([]string, error) {
+	r.mutex.Lock()
+	cached, ok := r.hosts[host]
+	r.mutex.Unlock()
+	if ok && !refresh && time.Since(cached.resolvedAt) < r.ttl {
+		return cached.addrs, nil
+	}
+
+	addrs, err := r.lookup(ctx, host)
+	if err == nil && len(addrs) == 0 {
+		err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+	}
+	if err != nil {
+		if ok {
+			return cached.addrs, nil
+		}
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	r.hosts[host] = &resolvedHost{addrs: addrs, resolvedAt: time.Now()}
+	r.mutex.Unlock()
+	return addrs, nil
+}
+
+// dialAny connects to the first reachable address
+func (r *prefillerResolver) dialAny(ctx context.Context, network string, addrs []string, port string) (net.Conn, error) {
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := r.dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Prefiller resolver", func() {
+	const prefillerName = "prefill.pd.svc.cluster.local"
+
+	var (
+		prefillPort string
+		mutex       sync.Mutex
+		records     []string // the addresses of the prefiller name
+		lookups     atomic.Int32
+	)
+
+	BeforeEach(func() {
+		prefillBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill})
+		DeferCleanup(prefillBackend.Close)
+		_, port, err := net.SplitHostPort(strings.TrimPrefix(prefillBackend.URL, "http://"))
+		Expect(err).ToNot(HaveOccurred())
+		prefillPort = port
+
+		records = []string{"127.0.0.1"}
+		lookups.Store(0)
+	})
+
+	newResolver := func(ttl time.Duration) *prefillerResolver {
+		resolver := newPrefillerResolver(ttl)
+		resolver.lookup = func(_ context.Context, host string) ([]string, error) {
+			lookups.Add(1)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if host != prefillerName || records == nil {
+				return nil, errors.New("no such host")
+			}
+			return records, nil
+		}
+		return resolver
+	}
+
+	dial := func(resolver *prefillerResolver) error {
+		conn, err := resolver.dialContext(context.Background(), "tcp", net.JoinHostPort(prefillerName, prefillPort))
+		if err == nil {
+			conn.Close() //nolint:all
+		}
+		return err
+	}
+
+	It("should cache the addresses of a prefiller until they expire", func() {
+		resolver := newResolver(100 * time.Millisecond)
+
+		Expect(dial(resolver)).To(Succeed())
+		Expect(dial(resolver)).To(Succeed())
+		Expect(lookups.Load()).To(BeNumerically("==", 1))
+
+		time.Sleep(150 * time.Millisecond)
+		Expect(dial(resolver)).To(Succeed())
+		Expect(lookups.Load()).To(BeNumerically("==", 2))
+	})
+
+	It("should re-resolve the addresses of a prefiller when they are not reachable", func() {
+		resolver := newResolver(time.Hour)
+
+		// the prefiller moved from an unreachable address
+		mutex.Lock()
+		records = []string{"127.0.0.2"}
+		mutex.Unlock()
+		Expect(resolver.resolve(context.Background(), prefillerName, false)).To(Equal([]string{"127.0.0.2"}))
+		mutex.Lock()
+		records = []string{"127.0.0.1"}
+		mutex.Unlock()
+
+		Expect(dial(resolver)).To(Succeed())
+		Expect(lookups.Load()).To(BeNumerically("==", 2))
+	})
+
+	It("should keep the outdated addresses when the prefiller cannot be resolved", func() {
+		resolver := newResolver(time.Millisecond)
+		Expect(dial(resolver)).To(Succeed())
+
+		mutex.Lock()
+		records = nil
+		mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+
+		Expect(dial(resolver)).To(Succeed())
+		Expect(lookups.Load()).To(BeNumerically("==", 2))
+	})
+
+	It("should send the prefill requests to a prefiller given by DNS name", func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, PrefillerDNSCacheTTL: time.Hour})
+		proxy.prefillerResolver = newResolver(time.Hour)
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		for range 2 {
+			req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, net.JoinHostPort(prefillerName, prefillPort))
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			_, _ = io.ReadAll(resp.Body) //nolint:all
+			resp.Body.Close()            //nolint:all
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		}
+		Expect(lookups.Load()).To(BeNumerically("==", 1))
+	})
+})
@@ -17,18 +17,30 @@ limitations under the License.
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
 	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/klog/v2"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
 )
 
 const (
@@ -45,12 +57,33 @@ const (
 	requestFieldRemotePort          = "remote_port"
 	requestFieldStream              = "stream"
 	requestFieldStreamOptions       = "stream_options"
+	requestFieldMaxOutputTokens     = "max_output_tokens"
+	requestFieldStore               = "store"
+	requestFieldBackground          = "background"
+
+	// PrefillResponsePolicyWarn logs the prefill responses without KV-transfer parameters and decodes
+	// without them, and fails the requests of the malformed prefill responses with a 400
+	PrefillResponsePolicyWarn = "warn"
+
+	// PrefillResponsePolicyFail fails the requests of the invalid prefill responses with a 502 of kind
+	// prefill_invalid
+	PrefillResponsePolicyFail = "fail"
+
+	// PrefillResponsePolicyDecode sends the original requests of the invalid prefill responses to the
+	// local decoder, without disaggregation
+	PrefillResponsePolicyDecode = "decode"
 
 	// ConnectorNIXLV2 enables the P/D NIXL v2 protocol
 	ConnectorNIXLV2 = "nixlv2"
 
+	// ConnectorNIXLV1 enables the legacy P/D NIXL v1 protocol, for the vLLM versions predating kv_transfer_params
+	ConnectorNIXLV1 = "nixl"
+
 	// ConnectorLMCache enables (now deprecated) P/D LMCache protocol
 	ConnectorLMCache = "lmcache"
+
+	// DefaultPrefillRetryBackoff is the default delay before the first prefill retry
+	DefaultPrefillRetryBackoff = 100 * time.Millisecond
 )
 
 // Config represents the proxy server configuration
@@ -67,11 +100,270 @@ type Config struct {
 	// DecoderInsecureSkipVerify configure the proxy to skip TLS verification for requests to decoder.
 	DecoderInsecureSkipVerify bool
 
+	// PrefillerRootCAs are the certificate authorities verifying the certificates of the prefillers.
+	// The system certificate authorities are used when nil.
+	PrefillerRootCAs *x509.CertPool
+
+	// PrefillerServerName is the name the certificates of the prefillers must be valid for,
+	// instead of their IP, e.g. the name of their service.
+	PrefillerServerName string
+
+	// DecoderRootCAs are the certificate authorities verifying the certificate of the decoder.
+	// The system certificate authorities are used when nil.
+	DecoderRootCAs *x509.CertPool
+
+	// DecoderServerName is the name the certificate of the decoder must be valid for, instead of localhost.
+	DecoderServerName string
+
+	// PrefillerHTTP2 sends the requests to the prefillers over HTTP/2: negotiated with TLS, or with
+	// prior knowledge (h2c) without TLS. The HTTP/2 connections are multiplexed, so that the requests
+	// share one connection per prefiller instead of paying a handshake for each new connection.
+	PrefillerHTTP2 bool
+
+	// DecoderHTTP2 sends the requests to the decoder over HTTP/2, as PrefillerHTTP2, e.g. h2c to the local vLLM.
+	DecoderHTTP2 bool
+
+	// DecoderSocket is the path of the Unix domain socket of the decoder. When set, the connections to the
+	// decoder are made over the socket instead of TCP, the host of the decoder URL being localhost.
+	DecoderSocket string
+
+	// ListenerHTTP2 accepts HTTP/2 with prior knowledge (h2c) on the listener without TLS. The TLS
+	// listener always negotiates HTTP/2.
+	ListenerHTTP2 bool
+
 	// DataParallelSize is the value passed to the vLLM server's --DATA_PARALLEL-SIZE command line argument
 	DataParallelSize int
+
+	// DataParallelPorts are the ports of the data parallel ranks after the first one, by rank, for the
+	// vLLM servers listening on non-contiguous ports. The ranks missing from it listen on the ports of
+	// the first rank plus the rank.
+	DataParallelPorts map[int]DataParallelPort
+
+	// DataParallelDiscoveryInterval is the interval the data parallel size of the local vLLM servers is
+	// discovered at, from their health endpoints on the ports following the decoder, the proxies of the
+	// ranks being started and stopped accordingly. DataParallelSize is then the size until it is
+	// discovered. Disabled when 0.
+	DataParallelDiscoveryInterval time.Duration
+
+	// DataParallelFallback sends the requests naming an unknown data parallel rank, e.g. after a resize,
+	// to the local rank with the fewest decodes in flight instead of rejecting them with a 400
+	DataParallelFallback bool
+
+	// ScrubInternalResponseFields removes kv_transfer_params and other P/D internal fields
+	// from the decoder responses returned to clients.
+	ScrubInternalResponseFields bool
+
+	// RoutingResponseHeaders adds response headers reporting the prefill target, the decode rank and
+	// the connector of each request, so that the clients can verify where a request was served.
+	RoutingResponseHeaders bool
+
+	// ValidateModel rejects the requests for models not served by the local engine
+	// before running the prefill and decode stages.
+	ValidateModel bool
+
+	// ModelsCacheTTL is the time the models served by the local engine are cached.
+	// Defaults to DefaultModelsCacheTTL.
+	ModelsCacheTTL time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failures of a prefill target or of the
+	// decoder opening its circuit. Disabled when not positive.
+	CircuitBreakerThreshold int
+
+	// CircuitBreakerErrorRate is the failure rate, between 0 and 1, of the recent requests to a prefill
+	// target or to the decoder opening its circuit. Disabled when not positive.
+	// The circuit breakers are disabled when neither the threshold nor the error rate is positive.
+	CircuitBreakerErrorRate float64
+
+	// CircuitBreakerWindow is the number of recent requests the error rate is computed over.
+	// Defaults to DefaultCircuitBreakerWindow.
+	CircuitBreakerWindow int
+
+	// CircuitOpenDuration is the time a circuit stays open before a probe request is let through.
+	// Defaults to DefaultCircuitOpenDuration.
+	CircuitOpenDuration time.Duration
+
+	// ClientCAs are the certificate authorities of the client certificates required by the
+	// TLS listener, e.g. the certificates of the gateway and the EPP. Not required when nil.
+	ClientCAs *x509.CertPool
+
+	// CertPath is the directory of the tls.crt and tls.key files the listener certificate was loaded
+	// from, reloaded every CertReloadInterval when they change. The certificate is not reloaded when empty.
+	CertPath string
+
+	// CertReloadInterval is the interval the files of the listener certificate are checked for changes
+	// at. Defaults to DefaultCertReloadInterval.
+	CertReloadInterval time.Duration
+
+	// AllowlistReadyWait is the time a disaggregated request waits for the SSRF protection allowlist
+	// to be synced, before failing with a 503. Defaults to DefaultAllowlistReadyWait.
+	AllowlistReadyWait time.Duration
+
+	// ReadinessCacheTTL is the time the result of a readiness probe of the local vLLM servers is cached.
+	// Defaults to DefaultReadinessCacheTTL.
+	ReadinessCacheTTL time.Duration
+
+	// ReadinessCheckAllRanks makes the readiness of the sidecar depend on the vLLM servers
+	// of all the data parallel ranks, instead of its decoder only.
+	ReadinessCheckAllRanks bool
+
+	// DrainTimeout is the time the sidecar waits on shutdown for the inference requests in flight to
+	// complete, new requests being rejected. Defaults to DefaultDrainTimeout.
+	DrainTimeout time.Duration
+
+	// PrefillerDNSCacheTTL is the time the addresses of the prefill targets given by DNS name,
+	// e.g. the records of a headless service, are cached. The names are resolved on each
+	// new connection when not positive.
+	PrefillerDNSCacheTTL time.Duration
+
+	// PrefillRetries is the number of times a prefill request failing with a 5xx status code,
+	// or a connection error, is retried.
+	PrefillRetries int
+
+	// PrefillRetryBackoff is the delay before the first prefill retry, doubled on each retry.
+	// Defaults to DefaultPrefillRetryBackoff.
+	PrefillRetryBackoff time.Duration
+
+	// PrefillTimeout is the time each prefill request has to complete before it fails with a 504.
+	// Disabled when not positive.
+	PrefillTimeout time.Duration
+
+	// PrefillHedgeDelay is the latency budget of the prefill requests: the requests not answered within
+	// it are sent to the next prefill target selected by the EPP as well, and the first successful
+	// response is used. Disabled when not positive.
+	PrefillHedgeDelay time.Duration
+
+	// MaxRequestBodyBytes is the size limit of the bodies of the requests to the completions paths and to
+	// the disaggregated pooling paths, the larger ones are rejected with a 413. Unlimited when not positive.
+	MaxRequestBodyBytes int64
+
+	// DisableLegacyPrefillURLs rejects with a 400 the requests whose prefill pod header has the legacy
+	// form, http:// URLs instead of <host:port>, so that the new header contract can be enforced before
+	// the legacy form is removed. The legacy headers are counted either way.
+	DisableLegacyPrefillURLs bool
+
+	// PrefillPipelining sends the decode request as soon as the KV-transfer parameters are received from
+	// the prefiller, instead of once its whole response is buffered. The connector is then handed a
+	// prefiller response holding only the kv_transfer_params field.
+	PrefillPipelining bool
+
+	// PrefillBypassTokens is the number of estimated prompt tokens below which the requests are decoded
+	// locally even when a prefill pod is selected. Disabled when 0.
+	PrefillBypassTokens int
+
+	// PrefillFallback sends the original request to the local decoder when the prefill failed
+	// because of the prefiller, instead of failing the request.
+	PrefillFallback bool
+
+	// PrefillTransferParams are the fields injected in the kv_transfer_params of the prefill requests,
+	// e.g. transport hints, the fields set by the connector being kept
+	PrefillTransferParams map[string]any
+
+	// DecodeTransferParams are the fields injected in the kv_transfer_params of the decode requests,
+	// the fields set by the connector, e.g. those returned by the prefiller, being kept
+	DecodeTransferParams map[string]any
+
+	// PrefillResponsePolicy handles the successful prefill responses the KV-transfer parameters could
+	// not be extracted from: PrefillResponsePolicyWarn, the default, PrefillResponsePolicyFail or
+	// PrefillResponsePolicyDecode.
+	PrefillResponsePolicy string
+
+	// InterceptPaths are the paths whose requests are served through the P/D protocol, e.g. the routes
+	// of OpenAI-compatible extensions of vLLM. DefaultInterceptPaths when empty.
+	InterceptPaths []string
+
+	// UnbufferedPaths are the paths whose decoder responses are flushed as soon as they are written, e.g.
+	// the long polls, regardless of the stream flush interval and buffer size. "pooling" stands for all
+	// the pooling paths.
+	UnbufferedPaths []string
+
+	// DisaggregatedPoolingPaths are the pooling paths, e.g. /v1/embeddings and /score, whose requests are
+	// sent through the P/D protocol when the EPP selected a prefill pod. "pooling" stands for all the
+	// pooling paths. The requests of the other pooling paths are never disaggregated.
+	DisaggregatedPoolingPaths []string
+
+	// AnthropicMessages serves the Anthropic Messages API on MessagesPath: its requests are translated to
+	// chat completions, served through the P/D pipeline, and their responses translated back, so that the
+	// clients of the Anthropic SDKs need no external adapter. Only the text content blocks are supported.
+	AnthropicMessages bool
+
+	// MaxConcurrentRequests is the number of concurrent completion requests served by the pod, the other
+	// requests are rejected with a 429 and a Retry-After header. Unlimited when not positive.
+	MaxConcurrentRequests int
+
+	// TenantHeader is the request header identifying the tenant of a request, for the tenant quotas.
+	TenantHeader string
+
+	// TenantMaxConcurrentRequests is the number of concurrent completion requests of a tenant served
+	// by the pod, the other requests of the tenant are rejected with a 429. The tenant quotas are
+	// disabled when not positive, or when TenantHeader is not set.
+	TenantMaxConcurrentRequests int
+
+	// TokenRateLimit is the number of estimated prompt tokens per second of a client served by the pod,
+	// the other requests of the client are rejected with a 429 and a Retry-After header. The prompt
+	// tokens are estimated from the size of the request bodies. Disabled when not positive.
+	TokenRateLimit float64
+
+	// TokenRateBurst is the number of estimated prompt tokens a client may send at once.
+	// Defaults to ten seconds of TokenRateLimit when not positive.
+	TokenRateBurst int
+
+	// TokenRateClientHeader is the request header identifying the client of a request for the token
+	// rate limits, e.g. the header of its API key. The clients are identified by their source IP when
+	// empty, or when the header is missing.
+	TokenRateClientHeader string
+
+	// JobHeader is the request header identifying the batch job of a request. The requests of a job
+	// are sent to the prefill target which served its first request, when the EPP selects it among
+	// the prefill targets of the request. Disabled when empty.
+	JobHeader string
+
+	// JobPinTTL is the time a job is pinned to its prefill target after its last request.
+	// Defaults to DefaultJobPinTTL.
+	JobPinTTL time.Duration
+
+	// ShadowURL is the URL of a secondary engine, e.g. a canary vLLM build, a sample of the completion
+	// requests is mirrored to in the background, its responses being discarded. Disabled when nil.
+	ShadowURL *url.URL
+
+	// ShadowPercentage is the percentage of the completion requests mirrored to the ShadowURL.
+	ShadowPercentage float64
+
+	// ShadowTimeout is the time a mirrored request has to complete. Defaults to DefaultShadowTimeout.
+	ShadowTimeout time.Duration
+
+	// StreamWriteTimeout is the time a client has to accept each write of a decoder response.
+	// The responses of slower clients are aborted, cancelling their decode request.
+	// Disabled when not positive.
+	StreamWriteTimeout time.Duration
+
+	// StreamFlushInterval is the interval the flushes of streamed decoder responses are coalesced over.
+	// Each event is flushed immediately when not positive.
+	StreamFlushInterval time.Duration
+
+	// StreamBufferSize is the number of bytes written to a client before a flush is forced.
+	// Defaults to DefaultStreamBufferSize.
+	StreamBufferSize int
+
+	// Transport tunes the transports of the prefiller and decoder reverse proxies.
+	Transport TransportConfig
+
+	// AdminPort is the port of the admin API, reporting the runtime state of the sidecar. It is only
+	// bound to the loopback interface. Disabled when empty.
+	AdminPort string
+
+	// AccessLog is the writer of the access log, one JSON line per request with its request id,
+	// its prefill and decode targets, its status code, duration and response size.
+	// Disabled when nil.
+	AccessLog io.Writer
+
+	// SSRFAuditLog is the writer of the SSRF audit log, one JSON line per allow or deny decision about a
+	// prefill target, with the client IP, the request id and the generation of the allowlist consulted.
+	// Disabled when nil or when the SSRF protection is disabled.
+	SSRFAuditLog io.Writer
 }
 
-type protocolRunner func(http.ResponseWriter, *http.Request, string)
+// protocolRunner runs the P/D protocol of a request with the ranked prefill targets
+type protocolRunner func(http.ResponseWriter, *http.Request, []string)
 
 // Server is the reverse proxy server
 type Server struct {
@@ -81,13 +373,39 @@ type Server struct {
 	decoderURL           *url.URL     // the local decoder URL
 	handler              http.Handler // the handler function. either a Mux or a proxy
 	allowlistValidator   *AllowlistValidator
-	runConnectorProtocol protocolRunner // the handler for running the protocol
+	modelValidator       *modelValidator // nil when the model validation is disabled
+	runConnectorProtocol protocolRunner  // the handler for running the protocol
+	connector            string          // the name of the P/D protocol
+	kvConnector          Connector       // the P/D protocol
 	prefillerURLPrefix   string
 
 	decoderProxy        *httputil.ReverseProxy            // decoder proxy handler
 	prefillerProxies    *lru.Cache[string, http.Handler]  // cached prefiller proxy handlers
 	dataParallelProxies map[string]*httputil.ReverseProxy // Proxies to other vLLM servers
+	dataParallelURLs    map[string]*url.URL               // URLs of the vLLM servers of the proxies
+	dataParallelServers map[string]*Server                // Servers of the ranks after the first one, decoding their requests
+	dataParallelRanks   []context.CancelFunc              // stop the servers of the ranks after the first one
+	dataParallelMu      sync.RWMutex                      // guards the proxies of the ranks, resized by the discovery
 	forwardDataParallel bool                              // Use special Data Parallel work around
+	rank                int                               // the data parallel rank of the server
+	decodesInFlight     atomic.Int64                      // the decodes in flight on the local decoder of the server
+	fallbackRanks       atomic.Uint64                     // breaks the ties of the data parallel fallback round-robin
+	listenerTLS         bool                              // whether the listener serves TLS
+	certificates        *certificateStore                 // the listener certificate, shared by the servers of all the data parallel ranks
+
+	metrics      *proxyMetrics     // shared by the servers of all the data parallel ranks
+	circuits     *circuitBreakers  // nil when the circuit breakers are disabled
+	concurrency  *concurrencyLimit // nil when the concurrency limit is disabled
+	tenantQuotas *tenantQuotas     // nil when the tenant quotas are disabled
+	tokenRates   *tokenRateLimits  // nil when the token rate limits are disabled
+	jobPins      *jobPins          // nil when the job pinning is disabled
+	shadow       *shadowMirror     // nil when the shadow mode is disabled
+	drainer      *drainer          // shared by the servers of all the data parallel ranks
+	readiness    *readinessChecker // probes the local vLLM servers of the server
+
+	prefillerResolver *prefillerResolver // nil when the prefiller DNS names are resolved on each connection
+	accessLogger      *accessLogger      // nil when the access log is disabled
+	ssrfAudit         *ssrfAuditLogger   // nil when the SSRF audit log is disabled
 
 	config Config
 }
@@ -103,21 +421,41 @@ func NewProxy(port string, decodeURL *url.URL, config Config) *Server {
 		prefillerURLPrefix:  "http://",
 		config:              config,
 		dataParallelProxies: map[string]*httputil.ReverseProxy{},
+		dataParallelURLs:    map[string]*url.URL{},
+		dataParallelServers: map[string]*Server{},
 		forwardDataParallel: true,
+		metrics:             newProxyMetrics(),
+		drainer:             newDrainer(),
+		concurrency:         newConcurrencyLimit(config.MaxConcurrentRequests),
+		tenantQuotas:        newTenantQuotas(config.TenantHeader, config.TenantMaxConcurrentRequests),
+		tokenRates:          newTokenRateLimits(config.TokenRateClientHeader, config.TokenRateLimit, config.TokenRateBurst),
+		jobPins:             newJobPins(config.JobHeader, config.JobPinTTL),
+		prefillerResolver:   newPrefillerResolver(config.PrefillerDNSCacheTTL),
+		accessLogger:        newAccessLogger(config.AccessLog),
+		ssrfAudit:           newSSRFAuditLogger(config.SSRFAuditLog),
+		circuits: newCircuitBreakers(circuitPolicy{
+			threshold:    config.CircuitBreakerThreshold,
+			errorRate:    config.CircuitBreakerErrorRate,
+			window:       config.CircuitBreakerWindow,
+			openDuration: config.CircuitOpenDuration,
+		}),
 	}
-	switch config.Connector {
-	case ConnectorLMCache:
-		server.runConnectorProtocol = server.runLMCacheProtocol
-	case ConnectorNIXLV2:
-		fallthrough
-	default:
-		server.runConnectorProtocol = server.runNIXLProtocolV2
+	if config.DecoderSocket != "" {
+		// the requests are sent over the socket, the URL only sets their scheme and host
+		server.decoderURL = &url.URL{Scheme: decodeURL.Scheme, Host: "localhost"}
 	}
+	server.shadow = server.newShadowMirror()
+	server.connector, server.kvConnector = newConnector(config.Connector)
+	server.runConnectorProtocol = server.runConnector
 
 	if config.PrefillerUseTLS {
 		server.prefillerURLPrefix = "https://"
 	}
 
+	if config.ValidateModel {
+		server.modelValidator = newModelValidator(server.decoderURL, server.decoderClientTransport(), config.ModelsCacheTTL)
+	}
+
 	return server
 }
 
@@ -126,11 +464,33 @@ func (s *Server) Start(ctx context.Context, cert *tls.Certificate, allowlistVali
 	s.logger = klog.FromContext(ctx).WithName("proxy server on port " + s.port)
 
 	s.allowlistValidator = allowlistValidator
+	s.metrics.setAllowlist(allowlistValidator)
+	s.listenerTLS = cert != nil
 
 	// Configure handlers
 	s.handler = s.createRoutes()
 
+	// Start SSRF protection validator, shared by the servers of all the data parallel ranks
+	if err := s.allowlistValidator.Start(ctx); err != nil {
+		s.logger.Error(err, "Failed to start allowlist validator")
+		return err
+	}
+	defer s.allowlistValidator.Stop()
+
 	grp, ctx := errgroup.WithContext(ctx)
+	if cert != nil {
+		s.certificates = newCertificateStore(cert, s.config.CertPath)
+		if s.config.CertPath != "" {
+			reloadInterval := s.config.CertReloadInterval
+			if reloadInterval <= 0 {
+				reloadInterval = DefaultCertReloadInterval
+			}
+			grp.Go(func() error {
+				s.certificates.run(ctx, reloadInterval, s.logger)
+				return nil
+			})
+		}
+	}
 	if err := s.startDataParallel(ctx, cert, grp); err != nil {
 		return err
 	}
@@ -138,6 +498,11 @@ func (s *Server) Start(ctx context.Context, cert *tls.Certificate, allowlistVali
 	grp.Go(func() error {
 		return s.startHTTP(ctx, cert)
 	})
+	if s.config.AdminPort != "" {
+		grp.Go(func() error {
+			return s.startAdmin(ctx)
+		})
+	}
 
 	return grp.Wait()
 }
@@ -150,30 +515,239 @@ func (s *Server) Clone() *Server {
 		decoderURL:           s.decoderURL,
 		handler:              s.handler,
 		allowlistValidator:   s.allowlistValidator,
+		modelValidator:       s.modelValidator,
 		runConnectorProtocol: s.runConnectorProtocol,
+		connector:            s.connector,
+		kvConnector:          s.kvConnector,
 		decoderProxy:         s.decoderProxy,
 		prefillerProxies:     s.prefillerProxies,
 		dataParallelProxies:  s.dataParallelProxies,
+		dataParallelURLs:     s.dataParallelURLs,
+		dataParallelServers:  s.dataParallelServers,
 		forwardDataParallel:  s.forwardDataParallel,
+		rank:                 s.rank,
+		listenerTLS:          s.listenerTLS,
+		certificates:         s.certificates,
+		metrics:              s.metrics,
+		circuits:             s.circuits,
+		concurrency:          s.concurrency,
+		tenantQuotas:         s.tenantQuotas,
+		tokenRates:           s.tokenRates,
+		jobPins:              s.jobPins,
+		shadow:               s.shadow,
+		drainer:              s.drainer,
+		prefillerResolver:    s.prefillerResolver,
+		accessLogger:         s.accessLogger,
+		ssrfAudit:            s.ssrfAudit,
+		config:               s.config,
 	}
 }
 
-func (s *Server) createRoutes() *http.ServeMux {
+func (s *Server) createRoutes() http.Handler {
 	// Configure handlers
 	mux := http.NewServeMux()
 
 	// Intercept chat requests
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+	mux.HandleFunc("GET "+HealthPath, func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
-	mux.HandleFunc("POST "+ChatCompletionsPath, s.chatCompletionsHandler) // /v1/chat/completions (openai)
-	mux.HandleFunc("POST "+CompletionsPath, s.chatCompletionsHandler)     // /v1/completions (legacy)
+	s.readiness = newReadinessChecker(s.readinessURLs(s.config.DataParallelSize), s.decoderClientTransport(), s.config.ReadinessCacheTTL)
+	mux.HandleFunc("GET "+ReadyPath, s.readyHandler)
+	mux.Handle("GET "+MetricsPath, s.metrics.handler())
+	mux.HandleFunc("GET "+VLLMMetricsPath, s.vllmMetricsHandler)
+	mux.HandleFunc("GET "+DataParallelHealthPath, s.dataParallelHealthHandler)
+	mux.HandleFunc("GET "+common.CircuitsPath, s.circuits.handler)
+	mux.HandleFunc("GET "+common.FeaturesPath, s.featuresHandler)
+	mux.HandleFunc("GET "+DrainPath, s.drainHandler)
+	mux.HandleFunc("POST "+DrainPath, s.drainHandler)
+	interceptPaths := s.config.InterceptPaths
+	if len(interceptPaths) == 0 {
+		interceptPaths = DefaultInterceptPaths
+	}
+	for _, path := range slices.Compact(slices.Sorted(slices.Values(interceptPaths))) {
+		mux.HandleFunc("POST "+path, s.trackInFlight(s.chatCompletionsHandler)) // /v1/chat/completions (openai), /v1/completions (legacy)...
+	}
+	if s.config.AnthropicMessages {
+		mux.HandleFunc("POST "+MessagesPath, s.trackInFlight(s.messagesHandler)) // /v1/messages (anthropic)
+	}
+	for _, path := range common.PoolingPaths {
+		handler := s.poolingHandler
+		if common.MatchesPath(s.config.DisaggregatedPoolingPaths, path) {
+			handler = s.disaggregatedPoolingHandler
+		}
+		mux.HandleFunc("POST "+path, s.trackInFlight(handler)) // /score, /rerank, /v1/embeddings...
+	}
 
-	s.decoderProxy = s.createDecoderProxyHandler(s.decoderURL, s.config.DecoderInsecureSkipVerify)
+	s.decoderProxy = s.createDecoderProxyHandler(s.decoderURL)
 
-	mux.Handle("/", s.decoderProxy)
+	mux.HandleFunc("/", s.passthroughHandler)
 
-	return mux
+	return s.requestIDHandler(s.accessLogger.handler(s.metrics.instrument(mux)))
+}
+
+// decode forwards the request to the local decoder, or to the data parallel rank it targets.
+// The connector labels the decode metrics.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, connector string) {
+	ctx, span := startSpan(r.Context(), "decode", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(connectorAttribute.String(connector)))
+	r = r.WithContext(ctx)
+	injectTraceContext(r)
+	s.annotateRouting(w, RoutingConnectorHeader, connector)
+
+	sw := &statusRecorder{ResponseWriter: w}
+	start := time.Now()
+	s.serveDecoder(sw, r)
+	s.metrics.observeDecode(connector, start)
+	endSpan(span, sw.status())
+}
+
+// serveDecoder forwards the request to the local decoder, or to the data parallel rank it targets
+func (s *Server) serveDecoder(w http.ResponseWriter, r *http.Request) {
+	if s.forwardDataParallel && s.dataParallelHandler(w, r) {
+		return
+	}
+	s.annotateRouting(w, RoutingDecodeRankHeader, strconv.Itoa(s.rank))
+	s.decodesInFlight.Add(1)
+	defer s.decodesInFlight.Add(-1)
+
+	if s.circuits == nil {
+		s.serveDecoderProxy(w, r)
+		return
+	}
+
+	if !s.circuits.decoder.allow() {
+		s.requestLogger(r).V(4).Info("decoder circuit is open, failing request")
+		s.replyError(w, circuitOpenError(errorKindDecodeOverloaded))
+		return
+	}
+	sw := &statusRecorder{ResponseWriter: w}
+	s.serveDecoderProxy(sw, r)
+	s.circuits.decoder.record(!isFailure(sw.status()))
+}
+
+// serveDecoderProxy forwards the request to the local decoder, applying the stream limits to its response
+func (s *Server) serveDecoderProxy(w http.ResponseWriter, r *http.Request) {
+	if common.MatchesPath(s.config.UnbufferedPaths, r.URL.Path) {
+		s.decoderProxy.ServeHTTP(&flushWriter{ResponseWriter: w}, r)
+		return
+	}
+	if (s.config.StreamWriteTimeout <= 0 && s.config.StreamFlushInterval <= 0 && s.config.StreamBufferSize <= 0) ||
+		isUpgradeRequest(r) {
+		s.decoderProxy.ServeHTTP(w, r)
+		return
+	}
+
+	sw := newStreamWriter(w, s.config.StreamWriteTimeout, s.config.StreamFlushInterval, s.config.StreamBufferSize)
+	defer sw.close()
+	s.decoderProxy.ServeHTTP(sw, r)
+}
+
+// prefill sends a prefill request with the given body to the prefiller handler and buffers its
+// response. The request is retried with an exponential backoff while it fails because of the
+// prefiller, up to the configured number of retries.
+func (s *Server) prefill(prefillHandler http.Handler, preq *http.Request, body []byte, prefillPodHostPort string) *bufferedResponseWriter {
+	ctx, span := startSpan(preq.Context(), "prefill", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(connectorAttribute.String(s.connector), prefillTargetAttribute.String(prefillPodHostPort)))
+	preq = preq.WithContext(ctx)
+	injectTraceContext(preq)
+
+	pw, sent := s.sendPrefill(prefillHandler, preq, body, prefillPodHostPort)
+
+	backoff := s.config.PrefillRetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultPrefillRetryBackoff
+	}
+	retries := 0
+	for sent && isFailure(pw.statusCode) && retries < s.config.PrefillRetries {
+		select {
+		case <-ctx.Done():
+			endSpan(span, pw.statusCode)
+			return pw
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		retries++
+
+		s.requestLogger(preq).V(4).Info("retrying prefill request", "to", prefillPodHostPort, "code", pw.statusCode, "retry", retries)
+		s.metrics.observePrefillRetry(s.connector)
+		pw, sent = s.sendPrefill(prefillHandler, preq, body, prefillPodHostPort)
+	}
+
+	span.SetAttributes(retriesAttribute.Int(retries))
+	endSpan(span, pw.statusCode)
+	return pw
+}
+
+// sendPrefill sends a single prefill request, unless the circuit of the prefiller is open.
+// Returns false when the request was not sent.
+func (s *Server) sendPrefill(prefillHandler http.Handler, preq *http.Request, body []byte, prefillPodHostPort string) (*bufferedResponseWriter, bool) {
+	pw := &bufferedResponseWriter{}
+
+	var circuit *circuitBreaker
+	if s.circuits != nil {
+		circuit = s.circuits.prefiller(prefillPodHostPort)
+		if !circuit.allow() {
+			s.requestLogger(preq).V(4).Info("prefill circuit is open, failing request", "to", prefillPodHostPort)
+			s.bufferError(pw, circuitOpenError(errorKindPrefillUnreachable))
+			return pw, false
+		}
+	}
+
+	preq.Body = io.NopCloser(bytes.NewReader(body))
+	preq.ContentLength = int64(len(body))
+	preq.TransferEncoding = nil // the rewritten body has a known length
+
+	ctx := preq.Context()
+	if s.config.PrefillTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.PrefillTimeout)
+		defer cancel()
+	}
+
+	var rw http.ResponseWriter = pw
+	if pipeline := prefillPipelineFromContext(ctx); pipeline != nil {
+		rw = &pipelinedResponseWriter{bufferedResponseWriter: pw, pipeline: pipeline, target: prefillPodHostPort}
+	}
+
+	start := time.Now()
+	prefillHandler.ServeHTTP(rw, preq.WithContext(ctx))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && preq.Context().Err() == nil && (pw.statusCode == 0 || isFailure(pw.statusCode)) {
+		s.requestLogger(preq).V(4).Info("prefill request timed out", "to", prefillPodHostPort, "timeout", s.config.PrefillTimeout)
+		pw = &bufferedResponseWriter{}
+		s.bufferError(pw, prefillTimeoutError())
+	}
+	if errors.Is(preq.Context().Err(), context.Canceled) {
+		// cancelled by the client, or by the hedging: it says nothing about the prefiller
+		return pw, true
+	}
+	s.metrics.observePrefill(s.connector, start, pw.statusCode)
+	if circuit != nil {
+		circuit.record(!isFailure(pw.statusCode))
+	}
+	return pw, true
+}
+
+// fallbackToDecode sends the original request to the local decoder when the prefill failed because
+// of the prefiller and the fallback is enabled. Returns false when the prefill failure must be
+// returned to the client instead.
+func (s *Server) fallbackToDecode(w http.ResponseWriter, r *http.Request, original []byte, prefillStatusCode int) bool {
+	if !s.config.PrefillFallback || !isFailure(prefillStatusCode) {
+		return false
+	}
+
+	s.requestLogger(r).V(4).Info("prefill failed, falling back to local decode", "code", prefillStatusCode)
+	s.metrics.observePrefillFallback(s.connector)
+	s.decodeOriginal(w, r, original)
+	return true
+}
+
+// decodeOriginal sends the original request to the local decoder, without disaggregation
+func (s *Server) decodeOriginal(w http.ResponseWriter, r *http.Request, original []byte) {
+	dreq := r.Clone(r.Context())
+	dreq.Body = io.NopCloser(bytes.NewReader(original))
+	dreq.ContentLength = int64(len(original))
+	dreq.TransferEncoding = nil
+	s.decode(w, dreq, connectorNone)
 }
 
 func (s *Server) prefillerProxyHandler(hostPort string) (http.Handler, error) {
@@ -183,7 +757,7 @@ func (s *Server) prefillerProxyHandler(hostPort string) (http.Handler, error) {
 	}
 
 	// Backward compatible behavior: trim `http:` prefix
-	hostPort, _ = strings.CutPrefix(hostPort, "http://")
+	hostPort, _ = strings.CutPrefix(hostPort, legacyPrefillURLPrefix)
 
 	u, err := url.Parse(s.prefillerURLPrefix + hostPort)
 	if err != nil {
@@ -192,22 +766,23 @@ func (s *Server) prefillerProxyHandler(hostPort string) (http.Handler, error) {
 	}
 
 	newProxy := httputil.NewSingleHostReverseProxy(u)
-	if u.Scheme == "https" {
-		newProxy.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: s.config.PrefillerInsecureSkipVerify,
-				MinVersion:         tls.VersionTLS12,
-				CipherSuites: []uint16{
-					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-					tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-				},
-			},
+	newProxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if r.Context().Err() == nil { // not cancelled by the client, the hedging or the prefill timeout
+			s.requestLogger(r).Error(err, "failed to send prefill request", "to", hostPort, "errorKind", errorKindPrefillUnreachable)
 		}
+		if err := prefillUnreachableError(err).write(w); err != nil {
+			s.requestLogger(r).Error(err, "failed to buffer error response")
+		}
+	}
+	var tlsConfig *tls.Config
+	if u.Scheme == "https" {
+		tlsConfig = s.prefillerTLSConfig()
+	}
+	transport := s.newTransport(tlsConfig, s.config.PrefillerHTTP2)
+	if s.prefillerResolver != nil {
+		transport.DialContext = s.prefillerResolver.dialContext
 	}
+	newProxy.Transport = transport
 	s.prefillerProxies.Add(hostPort, newProxy)
 
 	return newProxy, nil
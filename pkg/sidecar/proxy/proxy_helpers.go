@@ -14,12 +14,6 @@ import (
 
 // startHTTP starts the HTTP reverse proxy.
 func (s *Server) startHTTP(ctx context.Context, cert *tls.Certificate) error {
-	// Start SSRF protection validator
-	if err := s.allowlistValidator.Start(ctx); err != nil {
-		s.logger.Error(err, "Failed to start allowlist validator")
-		return err
-	}
-
 	ln, err := net.Listen("tcp", ":"+s.port)
 	if err != nil {
 		s.logger.Error(err, "Failed to start")
@@ -27,19 +21,34 @@ func (s *Server) startHTTP(ctx context.Context, cert *tls.Certificate) error {
 	}
 	s.addr = ln.Addr()
 
+	handler := s.handler
+	if cert != nil && s.config.ClientCAs != nil {
+		handler = requireClientCertificate(handler)
+	}
+
 	server := &http.Server{
-		Handler: s.handler,
+		Handler: handler,
 		// No ReadTimeout/WriteTimeout for LLM inference - can take hours for large contexts
 		IdleTimeout:       300 * time.Second, // 5 minutes for keep-alive connections
 		ReadHeaderTimeout: 30 * time.Second,  // Reasonable for headers only
 		MaxHeaderBytes:    1 << 20,           // 1 MB for headers is sufficient
 	}
+	if s.config.ListenerHTTP2 {
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetHTTP2(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
 
 	// Create TLS certificates
 	if cert != nil {
+		certificates := s.certificates
+		if certificates == nil {
+			certificates = newCertificateStore(cert, "")
+		}
 		server.TLSConfig = &tls.Config{
-			Certificates: []tls.Certificate{*cert},
-			MinVersion:   tls.VersionTLS12,
+			GetCertificate: certificates.getCertificate,
+			MinVersion:     tls.VersionTLS12,
 			CipherSuites: []uint16{
 				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
 				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
@@ -49,19 +58,27 @@ func (s *Server) startHTTP(ctx context.Context, cert *tls.Certificate) error {
 				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
 			},
 		}
+		if s.config.ClientCAs != nil {
+			// the certificates are required by the handler, so that the health checks do not need one
+			server.TLSConfig.ClientCAs = s.config.ClientCAs
+			server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			s.logger.Info("client certificate authentication configured")
+		}
 		s.logger.Info("server TLS configured")
 	}
 
-	// Setup graceful termination (not strictly needed for sidecars)
+	// Setup graceful termination: wait for the requests in flight, then close the connections
 	go func() {
 		<-ctx.Done()
 		s.logger.Info("shutting down")
 
-		// Stop allowlist validator
-		s.allowlistValidator.Stop()
-
-		ctx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
+		drainTimeout := s.config.DrainTimeout
+		if drainTimeout <= 0 {
+			drainTimeout = DefaultDrainTimeout
+		}
+		ctx, cancelFn := context.WithTimeout(context.Background(), drainTimeout)
 		defer cancelFn()
+		s.drain(ctx)
 		if err := server.Shutdown(ctx); err != nil {
 			s.logger.Error(err, "failed to gracefully shutdown")
 		}
@@ -84,42 +101,50 @@ func (s *Server) startHTTP(ctx context.Context, cert *tls.Certificate) error {
 }
 
 // Passthrough decoder handler
-func (s *Server) createDecoderProxyHandler(decoderURL *url.URL, decoderInsecureSkipVerify bool) *httputil.ReverseProxy {
+func (s *Server) createDecoderProxyHandler(decoderURL *url.URL) *httputil.ReverseProxy {
 	decoderProxy := httputil.NewSingleHostReverseProxy(decoderURL)
+	var tlsConfig *tls.Config
 	if decoderURL.Scheme == "https" {
-		decoderProxy.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: decoderInsecureSkipVerify,
-				MinVersion:         tls.VersionTLS12,
-				CipherSuites: []uint16{
-					tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-					tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-					tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-					tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-				},
-			},
+		tlsConfig = s.decoderTLSConfig()
+	}
+	transport := s.newTransport(tlsConfig, s.config.DecoderHTTP2)
+	decodeTarget := decoderURL.Host
+	if s.config.DecoderSocket != "" {
+		transport.DialContext = dialUnixSocket(s.config.DecoderSocket)
+		decodeTarget = "unix:" + s.config.DecoderSocket
+	}
+	decoderProxy.Transport = transport
+	if s.config.DecoderHTTP2 && tlsConfig == nil {
+		// h2c cannot switch protocols, e.g. to the websockets
+		decoderProxy.Transport = newUpgradeTransport(transport)
+	}
+	director := decoderProxy.Director
+	decoderProxy.Director = func(r *http.Request) {
+		director(r)
+		if entry := accessLogFromContext(r.Context()); entry != nil {
+			entry.DecodeTarget = decodeTarget
 		}
 	}
-	decoderProxy.ErrorHandler = func(res http.ResponseWriter, _ *http.Request, err error) {
+	if s.config.ScrubInternalResponseFields {
+		decoderProxy.ModifyResponse = scrubResponse
+	}
+	decoderProxy.ErrorHandler = func(res http.ResponseWriter, r *http.Request, err error) {
 
 		// Log errors from the decoder proxy
-		var writeError error
+		var maxBytesErr *http.MaxBytesError
 		switch {
 		case errors.Is(err, syscall.ECONNREFUSED):
-			s.logger.Error(err, "failed to connect to vLLM decoder",
-				"decoderURL", s.decoderURL.String())
-			res.WriteHeader(http.StatusServiceUnavailable)
-			_, writeError = res.Write(decoderServiceUnavailableResponseJSON)
+			s.requestLogger(r).Error(err, "failed to connect to vLLM decoder",
+				"decoderURL", s.decoderURL.String(), "errorKind", errorKindDecodeUnreachable)
+			s.replyError(res, decodeUnavailableError())
+
+		case errors.As(err, &maxBytesErr):
+			s.replyError(res, requestTooLargeError(maxBytesErr.Limit))
 
 		default:
-			s.logger.Error(err, "http: proxy error",
-				"decoderURL", s.decoderURL.String())
-			writeError = errorBadGateway(err, res)
-		}
-		if writeError != nil {
-			s.logger.Error(err, "failed to send error response to client")
+			s.requestLogger(r).Error(err, "http: proxy error",
+				"decoderURL", s.decoderURL.String(), "errorKind", errorKindDecodeUnreachable)
+			s.replyError(res, decodeUnreachableError(err))
 		}
 	}
 	return decoderProxy
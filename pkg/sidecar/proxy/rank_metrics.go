@@ -0,0 +1,167 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// VLLMMetricsPath is the path of the endpoint exposing the metrics of the local vLLM servers of all
+	// the data parallel ranks, labelled with their rank, so that a single scrape target covers the pod
+	VLLMMetricsPath = "/metrics/vllm"
+
+	// dataParallelRankLabel is the label of the rank of the vLLM server of the aggregated metrics
+	dataParallelRankLabel = "dp_rank"
+
+	// rankMetricsUpName is the metric of the aggregated metrics reporting whether the metrics of a rank
+	// were scraped
+	rankMetricsUpName = metricsNamespace + "_" + metricsSubsystem + "_vllm_metrics_up"
+
+	rankMetricsTimeout = 5 * time.Second
+)
+
+// rankDecoder is the local vLLM server of a data parallel rank
+type rankDecoder struct {
+	rank int
+	url  *url.URL
+}
+
+// rankDecoders returns the local vLLM servers of the ranks served by the server, ordered by rank: its
+// decoder, and the decoders of the other data parallel ranks
+func (s *Server) rankDecoders() []rankDecoder {
+	decoders := []rankDecoder{{rank: s.rank, url: s.decoderURL}}
+	if !s.forwardDataParallel {
+		return decoders
+	}
+
+	s.dataParallelMu.RLock()
+	for hostPort, rankURL := range s.dataParallelURLs {
+		if rank := s.dataParallelRank(hostPort); rank != 0 {
+			decoders = append(decoders, rankDecoder{rank: rank, url: rankURL})
+		}
+	}
+	s.dataParallelMu.RUnlock()
+	slices.SortFunc(decoders, func(a, b rankDecoder) int { return a.rank - b.rank })
+	return decoders
+}
+
+// vllmMetricsHandler scrapes the metrics of the local vLLM servers of all the data parallel ranks,
+// and replies with their union, each metric labelled with the rank of its server. The ranks which
+// could not be scraped are reported by rankMetricsUpName.
+func (s *Server) vllmMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), rankMetricsTimeout)
+	defer cancel()
+
+	client := &http.Client{Transport: s.decoderClientTransport()}
+	decoders := s.rankDecoders()
+	scraped := make([]map[string]*dto.MetricFamily, len(decoders))
+	var wg sync.WaitGroup
+	for i, decoder := range decoders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			families, err := scrapeMetrics(ctx, client, decoder.url)
+			if err != nil {
+				s.requestLogger(r).V(4).Info("failed to scrape the metrics of a data parallel rank", "rank", decoder.rank, "error", err.Error())
+				return
+			}
+			scraped[i] = families
+		}()
+	}
+	wg.Wait()
+
+	up := &dto.MetricFamily{
+		Name: proto.String(rankMetricsUpName),
+		Help: proto.String("Whether the metrics of the vLLM server of the data parallel rank were scraped."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	merged := map[string]*dto.MetricFamily{rankMetricsUpName: up}
+	for i, decoder := range decoders {
+		rank := strconv.Itoa(decoder.rank)
+		value := 0.0
+		if scraped[i] != nil {
+			value = 1
+		}
+		up.Metric = append(up.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{{Name: proto.String(dataParallelRankLabel), Value: proto.String(rank)}},
+			Gauge: &dto.Gauge{Value: proto.Float64(value)},
+		})
+		mergeRankMetrics(merged, scraped[i], rank)
+	}
+
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	w.Header().Set("Content-Type", string(format))
+	encoder := expfmt.NewEncoder(w, format)
+	for _, name := range slices.Sorted(maps.Keys(merged)) {
+		if err := encoder.Encode(merged[name]); err != nil {
+			s.requestLogger(r).Error(err, "failed to write the metrics of the data parallel ranks")
+			return
+		}
+	}
+}
+
+// mergeRankMetrics adds the metrics of a rank to the merged ones, labelled with the rank. The metrics
+// whose type differs from the one of the same name of another rank are dropped.
+func mergeRankMetrics(merged map[string]*dto.MetricFamily, families map[string]*dto.MetricFamily, rank string) {
+	for name, family := range families {
+		for _, metric := range family.Metric {
+			metric.Label = append(metric.Label, &dto.LabelPair{Name: proto.String(dataParallelRankLabel), Value: proto.String(rank)})
+		}
+		existing, ok := merged[name]
+		if !ok {
+			merged[name] = family
+			continue
+		}
+		if existing.GetType() == family.GetType() {
+			existing.Metric = append(existing.Metric, family.Metric...)
+		}
+	}
+}
+
+// scrapeMetrics returns the metrics of a local vLLM server, in the Prometheus text format
+func scrapeMetrics(ctx context.Context, client *http.Client, decoderURL *url.URL) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, decoderURL.JoinPath(MetricsPath).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:all
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the metrics endpoint %s replied with the status code %d", req.URL, resp.StatusCode)
+	}
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	return parser.TextToMetricFamilies(resp.Body)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("vLLM metrics of the data parallel ranks", func() {
+	newVLLM := func(metrics string) *url.URL {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal(MetricsPath))
+			_, _ = io.WriteString(w, metrics)
+		}))
+		DeferCleanup(server.Close)
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		return serverURL
+	}
+
+	getMetrics := func(proxy *Server) string {
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		resp, err := http.Get(server.URL + VLLMMetricsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return string(body)
+	}
+
+	It("should merge the metrics of the ranks labelled with their rank", func() {
+		rank0 := newVLLM("# HELP vllm:num_requests_running Number of requests running.\n" +
+			"# TYPE vllm:num_requests_running gauge\n" +
+			"vllm:num_requests_running{model_name=\"m\"} 3\n")
+		rank1 := newVLLM("# TYPE vllm:num_requests_running gauge\n" +
+			"vllm:num_requests_running{model_name=\"m\"} 5\n" +
+			"# TYPE vllm:prompt_tokens_total counter\n" +
+			"vllm:prompt_tokens_total{model_name=\"m\"} 42\n")
+
+		proxy := NewProxy("8000", rank0, Config{})
+		proxy.dataParallelURLs["10.0.0.1:8000"] = rank0
+		proxy.dataParallelURLs["10.0.0.1:8001"] = rank1
+		proxy.dataParallelURLs["10.0.0.1:8002"] = &url.URL{Scheme: "http", Host: "127.0.0.1:1"}
+
+		Expect(getMetrics(proxy)).To(Equal(`# HELP llm_d_sidecar_vllm_metrics_up Whether the metrics of the vLLM server of the data parallel rank were scraped.
+# TYPE llm_d_sidecar_vllm_metrics_up gauge
+llm_d_sidecar_vllm_metrics_up{dp_rank="0"} 1
+llm_d_sidecar_vllm_metrics_up{dp_rank="1"} 1
+llm_d_sidecar_vllm_metrics_up{dp_rank="2"} 0
+# HELP vllm:num_requests_running Number of requests running.
+# TYPE vllm:num_requests_running gauge
+vllm:num_requests_running{model_name="m",dp_rank="0"} 3
+vllm:num_requests_running{model_name="m",dp_rank="1"} 5
+# TYPE vllm:prompt_tokens_total counter
+vllm:prompt_tokens_total{model_name="m",dp_rank="1"} 42
+`))
+	})
+
+	It("should only report the metrics of its own rank on the servers of the other ranks", func() {
+		proxy := NewProxy("8001", newVLLM("# TYPE vllm:num_requests_waiting gauge\nvllm:num_requests_waiting 2\n"), Config{})
+		proxy.forwardDataParallel = false
+		proxy.rank = 1
+
+		Expect(getMetrics(proxy)).To(ContainSubstring(`vllm:num_requests_waiting{dp_rank="1"} 2`))
+	})
+})
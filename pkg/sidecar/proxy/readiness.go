@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// ReadyPath is the path of the readiness endpoint, probing the local vLLM servers. It is served
+	// without client certificate for the probes.
+	ReadyPath = "/ready"
+
+	// DefaultReadinessCacheTTL is the default time the result of a readiness probe of the local vLLM
+	// servers is cached
+	DefaultReadinessCacheTTL = 2 * time.Second
+
+	readinessRequestTimeout = 2 * time.Second
+)
+
+// readinessChecker probes the health endpoint of the local vLLM servers, caching the result so that
+// frequent readiness probes do not load them
+type readinessChecker struct {
+	healthURLs []string
+	client     *http.Client
+	ttl        time.Duration
+
+	mutex     sync.Mutex
+	err       error // the result of the last probe
+	checkedAt time.Time
+}
+
+func newReadinessChecker(decoderURLs []*url.URL, transport http.RoundTripper, ttl time.Duration) *readinessChecker {
+	if ttl <= 0 {
+		ttl = DefaultReadinessCacheTTL
+	}
+
+	client := &http.Client{Timeout: readinessRequestTimeout, Transport: transport}
+
+	healthURLs := make([]string, len(decoderURLs))
+	for i, decoderURL := range decoderURLs {
+		healthURLs[i] = decoderURL.JoinPath(HealthPath).String() // vLLM serves its health on the same path
+	}
+
+	return &readinessChecker{
+		healthURLs: healthURLs,
+		client:     client,
+		ttl:        ttl,
+	}
+}
+
+// check returns an error when one of the local vLLM servers is not healthy. The servers are probed
+// again once the result of the last probe expired.
+func (c *readinessChecker) check(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.ttl {
+		return c.err
+	}
+
+	c.err = nil
+	for _, healthURL := range c.healthURLs {
+		if err := c.probe(ctx, healthURL); err != nil {
+			c.err = err
+			break
+		}
+	}
+	c.checkedAt = time.Now()
+	return c.err
+}
+
+// setURLs sets the local vLLM servers to probe, e.g. when the data parallel size changed, and expires
+// the result of the last probe
+func (c *readinessChecker) setURLs(decoderURLs []*url.URL) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.healthURLs = make([]string, len(decoderURLs))
+	for i, decoderURL := range decoderURLs {
+		c.healthURLs[i] = decoderURL.JoinPath(HealthPath).String()
+	}
+	c.checkedAt = time.Time{}
+}
+
+func (c *readinessChecker) probe(ctx context.Context, healthURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("the vLLM server %s is not reachable: %w", healthURL, err)
+	}
+	defer resp.Body.Close() //nolint:all
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the vLLM server %s is not healthy, status code %d", healthURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// readinessURLs returns the URLs of the local vLLM servers the readiness of the server depends on: its
+// decoder, and the decoders of the other data parallel ranks, up to the given size, when configured
+func (s *Server) readinessURLs(dataParallelSize int) []*url.URL {
+	urls := []*url.URL{s.decoderURL}
+	if !s.forwardDataParallel || !s.config.ReadinessCheckAllRanks {
+		return urls
+	}
+	for rank := 1; rank < dataParallelSize; rank++ {
+		rankURL, err := s.dataParallelRankURL(rank)
+		if err != nil {
+			s.logger.Error(err, "failed to get the URL of a data parallel rank, not probing it", "rank", rank)
+			continue
+		}
+		urls = append(urls, rankURL)
+	}
+	return urls
+}
+
+// readyHandler replies with a 200 when the local vLLM servers are healthy, the sidecar is not
+// draining and the SSRF protection allowlist does not fail the requests closed, so that the readiness
+// probes reflect the actual serving ability of the pod
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if s.drainer.status().Draining {
+		s.replyError(w, drainingError())
+		return
+	}
+
+	if s.allowlistValidator != nil {
+		if err := s.allowlistValidator.readiness(); err != nil {
+			s.replyError(w, notReadyError(err))
+			return
+		}
+	}
+
+	if err := s.readiness.check(r.Context()); err != nil {
+		s.replyError(w, notReadyError(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
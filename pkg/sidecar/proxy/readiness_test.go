@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Readiness", func() {
+	var (
+		decoderStatus *atomic.Int32
+		probes        *atomic.Int32
+		decodeURL     *url.URL
+	)
+
+	BeforeEach(func() {
+		decoderStatus = &atomic.Int32{}
+		decoderStatus.Store(http.StatusOK)
+		probes = &atomic.Int32{}
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal(HealthPath))
+			probes.Add(1)
+			w.WriteHeader(int(decoderStatus.Load()))
+		}))
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	getReady := func(proxy *Server) int {
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		resp, err := http.Get(server.URL + ReadyPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		return resp.StatusCode
+	}
+
+	It("should be ready when the decoder is healthy", func() {
+		proxy := NewProxy("0", decodeURL, Config{})
+
+		Expect(getReady(proxy)).To(Equal(http.StatusOK))
+		Expect(probes.Load()).To(BeNumerically("==", 1))
+	})
+
+	It("should not be ready when the decoder is unhealthy", func() {
+		decoderStatus.Store(http.StatusInternalServerError)
+		proxy := NewProxy("0", decodeURL, Config{})
+
+		Expect(getReady(proxy)).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should not be ready when the decoder is down", func() {
+		proxy := NewProxy("0", &url.URL{Scheme: "http", Host: "127.0.0.1:1"}, Config{})
+
+		Expect(getReady(proxy)).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should not be ready when draining", func() {
+		proxy := NewProxy("0", decodeURL, Config{})
+		proxy.drainer.drain()
+
+		Expect(getReady(proxy)).To(Equal(http.StatusServiceUnavailable))
+		Expect(probes.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should not be ready until the allowlist is synced when failing closed", func() {
+		proxy := NewProxy("0", decodeURL, Config{})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: true}
+		Expect(getReady(proxy)).To(Equal(http.StatusServiceUnavailable))
+
+		proxy.allowlistValidator.WithFailurePolicy(AllowlistFailOpen)
+		Expect(getReady(proxy)).To(Equal(http.StatusOK))
+
+		proxy.allowlistValidator.WithFailurePolicy(AllowlistFailClosed).ready.Store(true)
+		Expect(getReady(proxy)).To(Equal(http.StatusOK))
+	})
+
+	It("should cache the result of the probes", func() {
+		ctx := context.Background()
+		checker := newReadinessChecker([]*url.URL{decodeURL}, nil, 50*time.Millisecond)
+
+		Expect(checker.check(ctx)).To(Succeed())
+		decoderStatus.Store(http.StatusServiceUnavailable)
+		Expect(checker.check(ctx)).To(Succeed())
+		Expect(probes.Load()).To(BeNumerically("==", 1))
+
+		Eventually(func() error { return checker.check(ctx) }).Should(HaveOccurred())
+		Expect(probes.Load()).To(BeNumerically("==", 2))
+	})
+
+	It("should probe the decoders of all the data parallel ranks when configured", func() {
+		proxy := NewProxy("0", &url.URL{Scheme: "http", Host: "localhost:8200"}, Config{DataParallelSize: 3})
+		Expect(proxy.readinessURLs(proxy.config.DataParallelSize)).To(HaveLen(1))
+
+		proxy.config.ReadinessCheckAllRanks = true
+		Expect(proxy.readinessURLs(proxy.config.DataParallelSize)).To(Equal([]*url.URL{
+			{Scheme: "http", Host: "localhost:8200"},
+			{Scheme: "http", Host: "localhost:8201"},
+			{Scheme: "http", Host: "localhost:8202"},
+		}))
+	})
+})
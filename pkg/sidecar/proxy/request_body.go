@@ -0,0 +1,56 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// limitRequestBody replies with a request too large error and returns false when the request body is
+// declared larger than MaxRequestBodyBytes. The bodies of unknown length are limited while being read.
+func (s *Server) limitRequestBody(w http.ResponseWriter, r *http.Request) bool {
+	limit := s.config.MaxRequestBodyBytes
+	if limit <= 0 {
+		return true
+	}
+	if r.ContentLength > limit {
+		s.replyError(w, requestTooLargeError(limit))
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return true
+}
+
+// readRequestBody reads the whole request body, replying with an error and returning false
+// when it cannot be read or exceeds MaxRequestBodyBytes
+func (s *Server) readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close() //nolint:all
+	if err == nil {
+		return body, true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		s.replyError(w, requestTooLargeError(maxBytesErr.Limit))
+	} else {
+		s.replyError(w, badRequestError(err)) // TODO: check FastAPI error code when failing to read body
+	}
+	return nil, false
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Request body limit", func() {
+	var (
+		server          *httptest.Server
+		decodeHandler   *mock.ChatCompletionHandler
+		prefillHandler  *mock.ChatCompletionHandler
+		prefillHostPort string
+	)
+
+	BeforeEach(func() {
+		decodeHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}
+		decodeBackend := httptest.NewServer(decodeHandler)
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillHandler = &mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}
+		prefillBackend := httptest.NewServer(prefillHandler)
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, MaxRequestBodyBytes: 64})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	// sendCompletion sends a completion request, of unknown length when chunked
+	sendCompletion := func(prompt string, prefillTarget string, chunked bool) *http.Response {
+		var body io.Reader = strings.NewReader(`{"model":"m","prompt":"` + prompt + `"}`)
+		if chunked {
+			body = io.MultiReader(body) // hides the length of the body
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, body)
+		Expect(err).ToNot(HaveOccurred())
+		if prefillTarget != "" {
+			req.Header.Set(common.PrefillPodHeader, prefillTarget)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	expectRequestTooLarge := func(resp *http.Response) {
+		Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var er errorResponse
+		Expect(json.Unmarshal(body, &er)).To(Succeed())
+		Expect(er).To(Equal(errorResponse{
+			Object:  "error",
+			Message: "The request body exceeds the limit of 64 bytes.",
+			Type:    "RequestTooLargeError",
+			Code:    http.StatusRequestEntityTooLarge,
+			Kind:    errorKindBadRequest,
+		}))
+	}
+
+	It("should serve the requests within the limit", func() {
+		resp := sendCompletion("hi", prefillHostPort, true)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 1))
+	})
+
+	It("should reject the requests declared larger than the limit", func() {
+		expectRequestTooLarge(sendCompletion(strings.Repeat("a", 100), prefillHostPort, false))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should reject the requests of unknown length exceeding the limit", func() {
+		expectRequestTooLarge(sendCompletion(strings.Repeat("a", 100), prefillHostPort, true))
+		Expect(prefillHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+		Expect(decodeHandler.RequestCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should reject the decode only requests exceeding the limit", func() {
+		expectRequestTooLarge(sendCompletion(strings.Repeat("a", 100), "", true))
+	})
+})
@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength is the length of the longest request ID honored, the longer ones being replaced
+// by a generated ID
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// requestIDHandler honors the request ID of the requests, or generates one when it is missing or invalid,
+// so that the prefill and the decode requests, the log lines, the spans and the access log of a request
+// share the ID the client can correlate with. The ID is returned in the response.
+func (s *Server) requestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := ensureRequestID(r)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		ctx = logr.NewContext(ctx, s.logger.WithValues("requestID", requestID))
+		next.ServeHTTP(&requestIDWriter{ResponseWriter: w, requestID: requestID}, r.WithContext(ctx))
+	})
+}
+
+// ensureRequestID returns the request ID of the request, after setting a generated one in its header
+// when it is missing or invalid
+func ensureRequestID(r *http.Request) string {
+	requestID := r.Header.Get(requestHeaderRequestID)
+	if !validRequestID(requestID) {
+		requestID = uuid.NewString()
+		r.Header.Set(requestHeaderRequestID, requestID)
+	}
+	return requestID
+}
+
+// validRequestID returns true when the request ID is not empty, short enough and made of printable
+// ASCII characters, so that it can be safely logged and sent in the headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(requestID) {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDFromContext returns the request ID set by requestIDHandler, empty when there is none
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string) //nolint:all
+	return requestID
+}
+
+// requestLogger returns the logger of the request, with its request ID
+func (s *Server) requestLogger(r *http.Request) logr.Logger {
+	if logger, err := logr.FromContext(r.Context()); err == nil {
+		return logger
+	}
+	return s.logger
+}
+
+// requestIDWriter returns the request ID in the response, replacing the one set by the backends
+type requestIDWriter struct {
+	http.ResponseWriter
+	requestID   string
+	wroteHeader bool
+}
+
+func (w *requestIDWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		// the informational responses are followed by the final one, whose headers are set again
+		w.wroteHeader = statusCode >= http.StatusOK
+		w.Header().Set(requestHeaderRequestID, w.requestID)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *requestIDWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack returns the request ID in the switching protocols response of the upgraded connections
+func (w *requestIDWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set(requestHeaderRequestID, w.requestID)
+	}
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController flush streamed responses
+func (w *requestIDWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Request IDs", func() {
+	var (
+		server          *httptest.Server
+		prefillHostPort string
+		prefillIDs      []string
+		decodeIDs       []string
+	)
+
+	BeforeEach(func() {
+		prefillIDs, decodeIDs = nil, nil
+
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			prefillIDs = append(prefillIDs, r.Header.Values(requestHeaderRequestID)...)
+			_, _ = w.Write([]byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`)) //nolint:all
+		}))
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			decodeIDs = append(decodeIDs, r.Header.Values(requestHeaderRequestID)...)
+			w.Header().Set(requestHeaderRequestID, "engine-request")
+			_, _ = w.Write([]byte(`{"choices":[]}`)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	send := func(requestID string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, server.URL+ChatCompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+		if requestID != "" {
+			req.Header.Set(requestHeaderRequestID, requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		_, _ = io.ReadAll(resp.Body) //nolint:all
+		resp.Body.Close()            //nolint:all
+		return resp
+	}
+
+	It("should propagate the request ID of the client to the prefill and decode requests, and return it", func() {
+		resp := send("client-request")
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Values(requestHeaderRequestID)).To(Equal([]string{"client-request"}))
+		Expect(prefillIDs).To(Equal([]string{"client-request"}))
+		Expect(decodeIDs).To(Equal([]string{"client-request"}))
+	})
+
+	DescribeTable("should generate a request ID when the client sent none or an invalid one",
+		func(requestID string) {
+			resp := send(requestID)
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			generated := resp.Header.Get(requestHeaderRequestID)
+			Expect(uuid.Parse(generated)).Error().ToNot(HaveOccurred())
+			Expect(prefillIDs).To(Equal([]string{generated}))
+			Expect(decodeIDs).To(Equal([]string{generated}))
+		},
+		Entry("missing", ""),
+		Entry("too long", strings.Repeat("x", maxRequestIDLength+1)),
+		Entry("not printable", "client\trequest"),
+	)
+})
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// internalResponseFields are the top-level response fields exposing P/D internals
+// (engine IDs, block IDs, hosts and ports) which must not reach external callers.
+var internalResponseFields = []string{
+	requestFieldKVTransferParams,
+}
+
+var (
+	sseDataPrefix = []byte("data:")
+	sseDone       = []byte("[DONE]")
+)
+
+// scrubResponse removes the internal fields from JSON and server-sent events decoder responses
+func scrubResponse(resp *http.Response) error {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")) //nolint:all
+	switch mediaType {
+	case "application/json":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close() //nolint:all
+		if err != nil {
+			return err
+		}
+		if scrubbed, ok := scrubJSON(body); ok {
+			body = scrubbed
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		if resp.Header.Get("Content-Length") != "" {
+			resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+	case "text/event-stream":
+		resp.Body = &eventStreamScrubber{
+			body:   resp.Body,
+			reader: bufio.NewReader(resp.Body),
+		}
+		resp.ContentLength = -1
+		resp.Header.Del("Content-Length")
+	}
+	return nil
+}
+
+// scrubJSON returns the JSON object without the internal fields.
+// It returns false when the body is not a JSON object or has no internal field.
+func scrubJSON(body []byte) ([]byte, bool) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil || object == nil {
+		return nil, false
+	}
+
+	found := false
+	for _, field := range internalResponseFields {
+		if _, ok := object[field]; ok {
+			delete(object, field)
+			found = true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	scrubbed, err := encodeJSON(object)
+	if err != nil {
+		return nil, false
+	}
+	return scrubbed, true
+}
+
+// eventStreamScrubber scrubs the JSON payload of each server-sent event data line
+type eventStreamScrubber struct {
+	body    io.ReadCloser
+	reader  *bufio.Reader
+	pending []byte
+}
+
+func (e *eventStreamScrubber) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 {
+		line, err := e.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			e.pending = scrubEventLine(line)
+		}
+		if err != nil {
+			if len(e.pending) == 0 {
+				return 0, err
+			}
+			break
+		}
+	}
+
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+func (e *eventStreamScrubber) Close() error {
+	return e.body.Close()
+}
+
+// scrubEventLine scrubs a single server-sent event line, keeping its line ending
+func scrubEventLine(line []byte) []byte {
+	if !bytes.HasPrefix(line, sseDataPrefix) {
+		return line
+	}
+
+	content := bytes.TrimRight(line, "\r\n")
+	ending := line[len(content):]
+	data := bytes.TrimPrefix(content[len(sseDataPrefix):], []byte(" "))
+	if len(data) == 0 || bytes.Equal(data, sseDone) {
+		return line
+	}
+
+	scrubbed, ok := scrubJSON(data)
+	if !ok {
+		return line
+	}
+
+	result := make([]byte, 0, len(sseDataPrefix)+1+len(scrubbed)+len(ending))
+	result = append(result, sseDataPrefix...)
+	result = append(result, ' ')
+	result = append(result, scrubbed...)
+	return append(result, ending...)
+}
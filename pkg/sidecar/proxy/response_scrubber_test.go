@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+const (
+	decodeJSONResponse = `{"id":"cmpl-1","choices":[{"text":"hi"}],"kv_transfer_params":{"remote_engine_id":"5b5fb28f","remote_block_ids":[1,2,3]}}`
+	decodeSSEResponse  = "data: {\"id\":\"cmpl-1\",\"kv_transfer_params\":{\"remote_engine_id\":\"5b5fb28f\"}}\n\n" +
+		"data: {\"id\":\"cmpl-1\",\"choices\":[{\"text\":\"a\"}]}\r\n\r\n" +
+		": keep-alive\n\n" +
+		"data: [DONE]\n\n"
+)
+
+var _ = Describe("Response scrubbing", func() {
+	newScrubbingProxy := func(scrub bool, contentType string, body string) *httptest.Server {
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Write([]byte(body)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{ScrubInternalResponseFields: scrub})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	post := func(server *httptest.Server) (*http.Response, string) {
+		resp, err := http.Post(server.URL+CompletionsPath, "application/json", strings.NewReader(`{"prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp, string(body)
+	}
+
+	It("should remove internal fields from JSON responses", func() {
+		resp, body := post(newScrubbingProxy(true, "application/json", decodeJSONResponse))
+
+		Expect(body).To(MatchJSON(`{"id":"cmpl-1","choices":[{"text":"hi"}]}`))
+		Expect(resp.ContentLength).To(BeNumerically("==", len(body)))
+	})
+
+	It("should remove internal fields from streamed events", func() {
+		_, body := post(newScrubbingProxy(true, "text/event-stream; charset=utf-8", decodeSSEResponse))
+
+		Expect(body).To(Equal("data: {\"id\":\"cmpl-1\"}\n\n" +
+			"data: {\"id\":\"cmpl-1\",\"choices\":[{\"text\":\"a\"}]}\r\n\r\n" +
+			": keep-alive\n\n" +
+			"data: [DONE]\n\n"))
+	})
+
+	It("should leave responses without internal fields untouched", func() {
+		_, body := post(newScrubbingProxy(true, "application/json", `{"id":"cmpl-1",  "choices":[]}`))
+
+		Expect(body).To(Equal(`{"id":"cmpl-1",  "choices":[]}`))
+	})
+
+	It("should leave non JSON responses untouched", func() {
+		_, body := post(newScrubbingProxy(true, "text/plain", decodeJSONResponse))
+
+		Expect(body).To(Equal(decodeJSONResponse))
+	})
+
+	It("should not scrub responses when disabled", func() {
+		_, body := post(newScrubbingProxy(false, "application/json", decodeJSONResponse))
+
+		Expect(body).To(Equal(decodeJSONResponse))
+	})
+})
@@ -0,0 +1,33 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+// adaptPrefillToResponses adapts a prefill request prepared by the connector for the completions APIs
+// to the Responses API: its generation limit is max_output_tokens and it has no stream options. The
+// prefill response is neither stored nor run in the background, so that it cannot be chained with
+// previous_response_id and the KV-transfer parameters are returned synchronously. The decode request
+// keeps the fields of the original request, e.g. stream and store.
+func adaptPrefillToResponses(request map[string]any) {
+	if maxTokens, ok := request[requestFieldMaxTokens]; ok {
+		request[requestFieldMaxOutputTokens] = maxTokens
+	}
+	delete(request, requestFieldMaxTokens)
+	delete(request, requestFieldMaxCompletionTokens)
+	delete(request, requestFieldStreamOptions)
+	request[requestFieldStore] = false
+	request[requestFieldBackground] = false
+}
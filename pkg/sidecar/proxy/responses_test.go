@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Responses API", func() {
+	const request = `{"model":"m","input":"Hello","stream":true,"max_output_tokens":100,"store":true}`
+
+	sendResponsesRequest := func(server *Server) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, ResponsesPath, bytes.NewReader([]byte(request)))
+		rec := httptest.NewRecorder()
+		server.runConnectorProtocol(rec, req, []string{inProcessPrefillHostPort})
+		return rec
+	}
+
+	decodeBody := func(body []byte) map[string]any {
+		var object map[string]any
+		Expect(json.Unmarshal(body, &object)).To(Succeed())
+		return object
+	}
+
+	for _, connector := range connectors {
+		It("should clamp the output tokens of the prefill and restore the streaming fields for decode with "+connector, func() {
+			server, decoder, prefillRequests := newInProcessProxy(connector,
+				[]byte(`{"kv_transfer_params":{"remote_block_ids":[1],"remote_engine_id":"e"}}`))
+
+			Expect(sendResponsesRequest(server).Code).To(Equal(http.StatusOK))
+
+			Expect(*prefillRequests).To(HaveLen(1))
+			prefillRequest := decodeBody((*prefillRequests)[0])
+			Expect(prefillRequest).To(HaveKeyWithValue(requestFieldMaxOutputTokens, BeNumerically("==", 1)))
+			Expect(prefillRequest).To(HaveKeyWithValue(requestFieldStore, false))
+			Expect(prefillRequest).To(HaveKeyWithValue(requestFieldBackground, false))
+			Expect(prefillRequest).ToNot(HaveKey(requestFieldMaxTokens))
+			Expect(prefillRequest).ToNot(HaveKey(requestFieldMaxCompletionTokens))
+
+			Expect(decoder.bodies).To(HaveLen(1))
+			decodeRequest := decodeBody(decoder.bodies[0])
+			Expect(decodeRequest).To(HaveKeyWithValue(requestFieldStream, true))
+			Expect(decodeRequest).To(HaveKeyWithValue(requestFieldMaxOutputTokens, BeNumerically("==", 100)))
+			Expect(decodeRequest).To(HaveKeyWithValue(requestFieldStore, true))
+			Expect(decodeRequest).ToNot(HaveKey(requestFieldBackground))
+			if connector == ConnectorNIXLV2 {
+				Expect(prefillRequest).To(HaveKeyWithValue(requestFieldStream, false))
+				Expect(decodeRequest).To(HaveKeyWithValue(requestFieldKVTransferParams,
+					HaveKeyWithValue("remote_engine_id", "e")))
+			}
+		})
+	}
+
+	It("should route the Responses API requests", func() {
+		var decodeRequests []string
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body) //nolint:all
+			decodeRequests = append(decodeRequests, r.URL.Path+" "+string(body))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		server := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2})
+		server.allowlistValidator = &AllowlistValidator{enabled: false}
+		rec := httptest.NewRecorder()
+		server.createRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ResponsesPath, strings.NewReader(request)))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(decodeRequests).To(Equal([]string{ResponsesPath + " " + request}))
+	})
+})
@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// RoutingPrefillTargetHeader is the response header reporting the prefill target of a request
+	RoutingPrefillTargetHeader = "x-llm-d-prefill-target"
+
+	// RoutingDecodeRankHeader is the response header reporting the data parallel rank which decoded a request
+	RoutingDecodeRankHeader = "x-llm-d-decode-rank"
+
+	// RoutingConnectorHeader is the response header reporting the P/D connector of a request, none
+	// when the request was not disaggregated
+	RoutingConnectorHeader = "x-llm-d-connector"
+)
+
+// annotateRouting sets a response header reporting a routing decision, when enabled
+func (s *Server) annotateRouting(w http.ResponseWriter, header string, value string) {
+	if s.config.RoutingResponseHeaders {
+		w.Header().Set(header, value)
+	}
+}
+
+// dataParallelRank returns the data parallel rank of the sidecar listening on the given <host:port>,
+// the ranks listening on the port given by DataParallelPorts, or on the port of the first rank plus the rank
+func (s *Server) dataParallelRank(hostPort string) int {
+	_, rankPort, _ := net.SplitHostPort(hostPort)
+	port, err := strconv.Atoi(rankPort)
+	if err != nil {
+		return 0
+	}
+	for rank, ports := range s.config.DataParallelPorts {
+		if ports.Port == port {
+			return rank
+		}
+	}
+	basePort, err := strconv.Atoi(s.port)
+	if err != nil {
+		return 0
+	}
+	return port - basePort
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Routing response headers", func() {
+	var (
+		decodeURL       *url.URL
+		prefillHostPort string
+	)
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		prefillBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill})
+		DeferCleanup(prefillBackend.Close)
+		prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "http://")
+	})
+
+	send := func(proxy *Server, headers map[string]string) http.Header {
+		proxy.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New("127.0.0.1")}
+		proxy.allowlistValidator.ready.Store(true)
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		_, err = io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		return resp.Header
+	}
+
+	It("should report the prefill target, the decode rank and the connector when enabled", func() {
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, RoutingResponseHeaders: true})
+
+		headers := send(proxy, map[string]string{common.PrefillPodHeader: prefillHostPort})
+		Expect(headers.Get(RoutingPrefillTargetHeader)).To(Equal(prefillHostPort))
+		Expect(headers.Get(RoutingDecodeRankHeader)).To(Equal("0"))
+		Expect(headers.Get(RoutingConnectorHeader)).To(Equal(ConnectorNIXLV2))
+	})
+
+	It("should report the requests which were not disaggregated", func() {
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, RoutingResponseHeaders: true})
+
+		headers := send(proxy, nil)
+		Expect(headers.Values(RoutingPrefillTargetHeader)).To(BeEmpty())
+		Expect(headers.Get(RoutingDecodeRankHeader)).To(Equal("0"))
+		Expect(headers.Get(RoutingConnectorHeader)).To(Equal(connectorNone))
+	})
+
+	It("should report the data parallel rank the request was forwarded to", func() {
+		proxy := NewProxy("8000", decodeURL, Config{Connector: ConnectorNIXLV2, RoutingResponseHeaders: true})
+		proxy.dataParallelProxies["10.0.0.1:8002"] = proxy.createDecoderProxyHandler(decodeURL)
+
+		headers := send(proxy, map[string]string{common.DataParallelPodHeader: "10.0.0.1:8002"})
+		Expect(headers.Get(RoutingDecodeRankHeader)).To(Equal("2"))
+	})
+
+	It("should not add the headers when disabled", func() {
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2})
+
+		headers := send(proxy, map[string]string{common.PrefillPodHeader: prefillHostPort})
+		Expect(headers.Values(RoutingPrefillTargetHeader)).To(BeEmpty())
+		Expect(headers.Values(RoutingDecodeRankHeader)).To(BeEmpty())
+		Expect(headers.Values(RoutingConnectorHeader)).To(BeEmpty())
+	})
+})
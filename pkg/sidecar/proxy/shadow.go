@@ -0,0 +1,132 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+const (
+	// DefaultShadowTimeout is the default time a mirrored request has to complete
+	DefaultShadowTimeout = 5 * time.Minute
+
+	// the number of mirrored requests in flight, the requests sampled beyond are not mirrored so that
+	// a slow shadow engine does not pile up requests in the sidecar
+	maxShadowRequests = 64
+
+	shadowResultSuccess = "success"
+	shadowResultFailure = "failure"
+	shadowResultDropped = "dropped"
+)
+
+// shadowMirror mirrors a sample of the completion requests to a secondary engine, e.g. a canary vLLM
+// build, in the background. Its responses are discarded, so that the new engine is validated against
+// the production traffic without impact on the clients.
+type shadowMirror struct {
+	url        *url.URL
+	percentage float64
+	client     *http.Client
+	slots      chan struct{}
+}
+
+// newShadowMirror returns the shadow mirror of the server, or nil when the shadow URL is not set
+func (s *Server) newShadowMirror() *shadowMirror {
+	if s.config.ShadowURL == nil {
+		return nil
+	}
+	timeout := s.config.ShadowTimeout
+	if timeout <= 0 {
+		timeout = DefaultShadowTimeout
+	}
+	var tlsConfig *tls.Config
+	if s.config.ShadowURL.Scheme == "https" {
+		tlsConfig = upstreamTLSConfig(false, nil, "")
+	}
+	return &shadowMirror{
+		url:        s.config.ShadowURL,
+		percentage: s.config.ShadowPercentage,
+		client:     &http.Client{Timeout: timeout, Transport: s.newTransport(tlsConfig, false)},
+		slots:      make(chan struct{}, maxShadowRequests),
+	}
+}
+
+// mirrorRequest mirrors a sample of the requests to the shadow engine, in the background. It replies
+// with an error and returns false when the request body cannot be read.
+func (s *Server) mirrorRequest(w http.ResponseWriter, r *http.Request) bool {
+	if rand.Float64()*100 >= s.shadow.percentage {
+		return true
+	}
+	body, ok := s.readRequestBody(w, r)
+	if !ok {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.TransferEncoding = nil
+
+	select {
+	case s.shadow.slots <- struct{}{}:
+	default:
+		s.requestLogger(r).V(4).Info("too many mirrored requests in flight, skipping the shadow request")
+		s.metrics.observeShadow(shadowResultDropped)
+		return true
+	}
+
+	// the shadow request outlives the request of the client
+	ctx := context.WithoutCancel(r.Context())
+	header := r.Header.Clone()
+	header.Del(common.PrefillPodHeader) // the shadow engine prefills locally
+	header.Del(common.DataParallelPodHeader)
+	target := s.shadow.url.JoinPath(r.URL.Path)
+	target.RawQuery = r.URL.RawQuery
+	go func() {
+		defer func() { <-s.shadow.slots }()
+		s.metrics.observeShadow(s.sendShadowRequest(ctx, r.Method, target.String(), header, body))
+	}()
+	return true
+}
+
+// sendShadowRequest sends a mirrored request, discards its response and returns its result
+func (s *Server) sendShadowRequest(ctx context.Context, method string, target string, header http.Header, body []byte) string {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		s.logger.Error(err, "failed to create the shadow request", "url", target)
+		return shadowResultFailure
+	}
+	req.Header = header
+
+	resp, err := s.shadow.client.Do(req)
+	if err != nil {
+		s.requestLogger(req).V(4).Info("shadow request failed", "url", target, "error", err.Error())
+		return shadowResultFailure
+	}
+	defer resp.Body.Close() //nolint:all
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		s.requestLogger(req).V(4).Info("shadow request failed", "url", target, "code", resp.StatusCode)
+		return shadowResultFailure
+	}
+	return shadowResultSuccess
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Shadow mode", func() {
+	type shadowRequest struct {
+		path    string
+		body    string
+		prefill string
+	}
+
+	var (
+		decodeURL      *url.URL
+		shadowURL      *url.URL
+		shadowRequests chan shadowRequest
+	)
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode})
+		DeferCleanup(decodeBackend.Close)
+		var err error
+		decodeURL, err = url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		shadowRequests = make(chan shadowRequest, 10)
+		shadowBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body) //nolint:all
+			shadowRequests <- shadowRequest{path: r.URL.RequestURI(), body: string(body), prefill: r.Header.Get(common.PrefillPodHeader)}
+			w.Write([]byte(`{"choices":[{"text":"shadow"}]}`)) //nolint:all
+		}))
+		DeferCleanup(shadowBackend.Close)
+		shadowURL, err = url.Parse(shadowBackend.URL + "/canary")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	send := func(proxy *Server) string {
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		resp, err := http.Post(server.URL+CompletionsPath+"?debug=1", "application/json", strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		return string(body)
+	}
+
+	It("should mirror the requests to the shadow engine and discard its responses", func() {
+		proxy := NewProxy("0", decodeURL, Config{ShadowURL: shadowURL, ShadowPercentage: 100})
+
+		Expect(send(proxy)).ToNot(ContainSubstring("shadow"))
+
+		var mirrored shadowRequest
+		Eventually(shadowRequests).Should(Receive(&mirrored))
+		Expect(mirrored).To(Equal(shadowRequest{path: "/canary" + CompletionsPath + "?debug=1", body: `{"model":"m","prompt":"hi"}`}))
+		Eventually(func() float64 {
+			return testutil.ToFloat64(proxy.metrics.shadowRequests.WithLabelValues(shadowResultSuccess))
+		}).Should(Equal(1.0))
+	})
+
+	It("should not mirror the requests out of the sample", func() {
+		proxy := NewProxy("0", decodeURL, Config{ShadowURL: shadowURL, ShadowPercentage: 0})
+
+		send(proxy)
+		Consistently(shadowRequests, "100ms").ShouldNot(Receive())
+	})
+
+	It("should drop the shadow requests when too many are in flight", func() {
+		proxy := NewProxy("0", decodeURL, Config{ShadowURL: shadowURL, ShadowPercentage: 100})
+		proxy.shadow.slots = make(chan struct{}) // no slot available
+
+		send(proxy)
+		Consistently(shadowRequests, "100ms").ShouldNot(Receive())
+		Expect(testutil.ToFloat64(proxy.metrics.shadowRequests.WithLabelValues(shadowResultDropped))).To(Equal(1.0))
+	})
+
+	It("should be disabled without shadow URL", func() {
+		Expect(NewProxy("0", decodeURL, Config{}).shadow).To(BeNil())
+	})
+})
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	ssrfDecisionAllow = "allow"
+	ssrfDecisionDeny  = "deny"
+
+	// ssrfReasonAllowed is the reason of the prefill targets found in the allowlist
+	ssrfReasonAllowed = "in_allowlist"
+
+	// ssrfReasonNotAllowed is the reason of the prefill targets missing from the allowlist
+	ssrfReasonNotAllowed = "not_in_allowlist"
+
+	// ssrfReasonNotSynced is the reason of the prefill targets denied until the allowlist is synced
+	ssrfReasonNotSynced = "allowlist_not_synced"
+
+	// ssrfReasonGracePeriod is the reason of the prefill targets missing from the allowlist denied during
+	// its grace period, they may still be added to it
+	ssrfReasonGracePeriod = "allowlist_grace_period"
+
+	// ssrfReasonFailOpen is the reason of the prefill targets missing from the allowlist allowed while it is
+	// pending, by the fail-open policy
+	ssrfReasonFailOpen = "fail_open"
+)
+
+// ssrfAuditEntry is the audit log line of an SSRF protection decision about a prefill target
+type ssrfAuditEntry struct {
+	Time       string `json:"time"`
+	Decision   string `json:"decision"`
+	Reason     string `json:"reason"`
+	Target     string `json:"target"`
+	ClientIP   string `json:"client_ip"`
+	RequestID  string `json:"request_id,omitempty"`
+	Path       string `json:"path"`
+	Generation uint64 `json:"allowlist_generation"`
+}
+
+// ssrfAuditLogger writes the SSRF audit log lines, shared by the servers of all the data parallel ranks
+type ssrfAuditLogger struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+func newSSRFAuditLogger(out io.Writer) *ssrfAuditLogger {
+	if out == nil {
+		return nil
+	}
+	return &ssrfAuditLogger{out: out}
+}
+
+// auditSSRF records an SSRF protection decision about a prefill target of the request, with the
+// generation of the allowlist consulted. Nothing is recorded when the SSRF protection is disabled.
+func (s *Server) auditSSRF(r *http.Request, target string, allowed bool, reason string, generation uint64) {
+	if s.ssrfAudit == nil || !s.allowlistValidator.enabled {
+		return
+	}
+
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	entry := ssrfAuditEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Decision:   ssrfDecisionDeny,
+		Reason:     reason,
+		Target:     target,
+		ClientIP:   clientIP,
+		RequestID:  r.Header.Get(requestHeaderRequestID),
+		Path:       r.URL.Path,
+		Generation: generation,
+	}
+	if allowed {
+		entry.Decision = ssrfDecisionAllow
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	s.ssrfAudit.mutex.Lock()
+	defer s.ssrfAudit.mutex.Unlock()
+	_, _ = s.ssrfAudit.out.Write(line) //nolint:all
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"k8s.io/utils/set"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("SSRF audit log", func() {
+	var (
+		server   *Server
+		auditLog *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		server, _, _ = newInProcessProxy(ConnectorNIXLV2, []byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`))
+		server.config.AllowlistReadyWait = time.Millisecond
+		server.allowlistValidator = &AllowlistValidator{enabled: true, allowedTargets: set.New("prefill.local"), generation: 3}
+		server.allowlistValidator.ready.Store(true)
+		auditLog = &bytes.Buffer{}
+		server.ssrfAudit = newSSRFAuditLogger(auditLog)
+	})
+
+	send := func(prefillTargets string) int {
+		req := httptest.NewRequest(http.MethodPost, ChatCompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		req.RemoteAddr = "192.0.2.7:41000"
+		req.Header.Set(common.PrefillPodHeader, prefillTargets)
+		req.Header.Set(requestHeaderRequestID, "client-request")
+		rec := httptest.NewRecorder()
+		server.chatCompletionsHandler(rec, req)
+		return rec.Code
+	}
+
+	entries := func() []ssrfAuditEntry {
+		var entries []ssrfAuditEntry
+		for _, line := range strings.Split(strings.TrimSpace(auditLog.String()), "\n") {
+			var entry ssrfAuditEntry
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			Expect(entry.Time).ToNot(BeEmpty())
+			entry.Time = ""
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	It("should record the allow and deny decisions of the prefill targets", func() {
+		Expect(send("10.0.0.9:8000," + inProcessPrefillHostPort)).To(Equal(http.StatusOK))
+
+		Expect(entries()).To(Equal([]ssrfAuditEntry{
+			{Decision: ssrfDecisionDeny, Reason: ssrfReasonNotAllowed, Target: "10.0.0.9:8000", ClientIP: "192.0.2.7",
+				RequestID: "client-request", Path: ChatCompletionsPath, Generation: 3},
+			{Decision: ssrfDecisionAllow, Reason: ssrfReasonAllowed, Target: inProcessPrefillHostPort, ClientIP: "192.0.2.7",
+				RequestID: "client-request", Path: ChatCompletionsPath, Generation: 3},
+		}))
+	})
+
+	It("should record the targets denied until the allowlist is synced", func() {
+		server.allowlistValidator.ready.Store(false)
+
+		Expect(send(inProcessPrefillHostPort)).To(Equal(http.StatusServiceUnavailable))
+
+		Expect(entries()).To(Equal([]ssrfAuditEntry{
+			{Decision: ssrfDecisionDeny, Reason: ssrfReasonNotSynced, Target: inProcessPrefillHostPort, ClientIP: "192.0.2.7",
+				RequestID: "client-request", Path: ChatCompletionsPath, Generation: 3},
+		}))
+	})
+
+	It("should not record anything when the SSRF protection is disabled", func() {
+		server.allowlistValidator = &AllowlistValidator{enabled: false}
+
+		Expect(send(inProcessPrefillHostPort)).To(Equal(http.StatusOK))
+
+		Expect(auditLog.String()).To(BeEmpty())
+	})
+})
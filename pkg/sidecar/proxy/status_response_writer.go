@@ -17,15 +17,18 @@ limitations under the License.
 package proxy
 
 import (
+	"io"
 	"net/http"
 	"strings"
 )
 
 // bufferedResponseWriter receives responses from prefillers
 type bufferedResponseWriter struct {
-	headers    http.Header
-	buffer     strings.Builder
-	statusCode int
+	headers     http.Header
+	wroteHeader http.Header // snapshot of the headers when the status code was written
+	buffer      strings.Builder
+	statusCode  int
+	errorKind   errorKind // the kind of the error written by the sidecar, empty for the responses of the targets
 }
 
 func (w *bufferedResponseWriter) Header() http.Header {
@@ -37,11 +40,59 @@ func (w *bufferedResponseWriter) Header() http.Header {
 
 func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
 	if w.statusCode == 0 {
-		w.statusCode = http.StatusOK
+		w.WriteHeader(http.StatusOK)
 	}
 	return w.buffer.Write(b)
 }
 
 func (w *bufferedResponseWriter) WriteHeader(statusCode int) {
 	w.statusCode = statusCode
+	w.wroteHeader = w.Header().Clone()
+}
+
+// setErrorKind records the kind of the error written by the sidecar
+func (w *bufferedResponseWriter) setErrorKind(kind errorKind) {
+	w.errorKind = kind
+}
+
+// trailers returns the trailers set after the body was written, both the ones
+// announced in the Trailer header and the ones using the http.TrailerPrefix convention
+func (w *bufferedResponseWriter) trailers() http.Header {
+	trailers := http.Header{}
+	for _, declared := range w.wroteHeader.Values("Trailer") {
+		for _, key := range strings.Split(declared, ",") {
+			key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+			if values, ok := w.headers[key]; ok && key != "" {
+				trailers[key] = values
+			}
+		}
+	}
+	for key, values := range w.headers {
+		if strings.HasPrefix(key, http.TrailerPrefix) {
+			trailers[key] = values
+		}
+	}
+	return trailers
+}
+
+// writeTo replays the buffered response, including headers and trailers, to the given writer
+func (w *bufferedResponseWriter) writeTo(dst http.ResponseWriter) error {
+	statusCode := w.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	header := dst.Header()
+	for key, values := range w.wroteHeader {
+		header[key] = values
+	}
+	dst.WriteHeader(statusCode)
+
+	_, err := io.WriteString(dst, w.buffer.String())
+
+	// headers set after the body is written are sent as trailers
+	for key, values := range w.trailers() {
+		header[key] = values
+	}
+	return err
 }
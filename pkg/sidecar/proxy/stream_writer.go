@@ -0,0 +1,150 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultStreamBufferSize is the default number of bytes written to a client before a flush is forced
+const DefaultStreamBufferSize = 64 * 1024
+
+// streamWriter applies backpressure limits to the decoder responses written to a client:
+//   - each write must complete within the write timeout, otherwise the client is too slow and the
+//     response is aborted, which cancels the decoder request instead of letting the engine keep
+//     producing tokens for it
+//   - the flushes of streamed responses are coalesced over the flush interval, and forced once the
+//     buffer size is reached
+//
+// The writes and the flushes may happen concurrently with the delayed flushes, so they are serialized.
+type streamWriter struct {
+	http.ResponseWriter
+	controller *http.ResponseController
+
+	writeTimeout  time.Duration
+	flushInterval time.Duration
+	bufferSize    int
+
+	mutex    sync.Mutex
+	buffered int // bytes written since the last flush
+	timer    *time.Timer
+	err      error
+	closed   bool
+}
+
+func newStreamWriter(w http.ResponseWriter, writeTimeout time.Duration, flushInterval time.Duration, bufferSize int) *streamWriter {
+	if bufferSize <= 0 {
+		bufferSize = DefaultStreamBufferSize
+	}
+	return &streamWriter{
+		ResponseWriter: w,
+		controller:     http.NewResponseController(w),
+		writeTimeout:   writeTimeout,
+		flushInterval:  flushInterval,
+		bufferSize:     bufferSize,
+	}
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.setWriteDeadline()
+	n, err := w.ResponseWriter.Write(b)
+	w.buffered += n
+	if err != nil {
+		w.err = err
+		return n, err
+	}
+
+	if w.buffered >= w.bufferSize {
+		w.flushLocked()
+	}
+	return n, w.err
+}
+
+// FlushError flushes the response immediately, or within the flush interval when configured
+func (w *streamWriter) FlushError() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.flushInterval <= 0 {
+		return w.flushLocked()
+	}
+	if w.timer == nil && w.err == nil {
+		w.timer = time.AfterFunc(w.flushInterval, func() {
+			w.mutex.Lock()
+			defer w.mutex.Unlock()
+			w.timer = nil
+			if !w.closed {
+				w.flushLocked()
+			}
+		})
+	}
+	return w.err
+}
+
+// Unwrap lets http.ResponseController reach the underlying response writer
+func (w *streamWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flushes the pending data and stops the delayed flushes, once the response is complete
+func (w *streamWriter) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.buffered > 0 && w.err == nil {
+		w.flushLocked()
+	}
+	w.closed = true
+}
+
+func (w *streamWriter) flushLocked() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+
+	w.setWriteDeadline()
+	if err := w.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		w.err = err
+	}
+	w.buffered = 0
+	return w.err
+}
+
+func (w *streamWriter) setWriteDeadline() {
+	if w.writeTimeout > 0 {
+		// not supported by all the response writers, the write timeout is best effort
+		_ = w.controller.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+// flushCountingWriter counts the flushes of a response
+type flushCountingWriter struct {
+	*httptest.ResponseRecorder
+	flushes atomic.Int32
+}
+
+func (w *flushCountingWriter) Flush() {
+	w.flushes.Add(1)
+}
+
+var _ = Describe("Stream writer", func() {
+	It("should flush each event when no flush interval is configured", func() {
+		w := &flushCountingWriter{ResponseRecorder: httptest.NewRecorder()}
+		sw := newStreamWriter(w, 0, 0, 0)
+		controller := http.NewResponseController(sw)
+
+		for range 3 {
+			_, err := sw.Write([]byte("data: {}\n\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(controller.Flush()).To(Succeed())
+		}
+		sw.close()
+
+		Expect(w.flushes.Load()).To(BeNumerically("==", 3))
+		Expect(w.Body.String()).To(Equal(strings.Repeat("data: {}\n\n", 3)))
+	})
+
+	It("should coalesce the flushes over the flush interval", func() {
+		w := &flushCountingWriter{ResponseRecorder: httptest.NewRecorder()}
+		sw := newStreamWriter(w, 0, 50*time.Millisecond, 0)
+		controller := http.NewResponseController(sw)
+
+		for range 3 {
+			_, err := sw.Write([]byte("data: {}\n\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(controller.Flush()).To(Succeed())
+		}
+		Expect(w.flushes.Load()).To(BeNumerically("==", 0))
+		Eventually(w.flushes.Load).Should(BeNumerically("==", 1))
+
+		sw.close()
+		Consistently(w.flushes.Load, 100*time.Millisecond).Should(BeNumerically("==", 1))
+	})
+
+	It("should force a flush once the buffer size is reached", func() {
+		w := &flushCountingWriter{ResponseRecorder: httptest.NewRecorder()}
+		sw := newStreamWriter(w, 0, time.Hour, 16)
+
+		_, err := sw.Write([]byte("data: {}\n\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.flushes.Load()).To(BeNumerically("==", 0))
+		_, err = sw.Write([]byte("data: {}\n\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.flushes.Load()).To(BeNumerically("==", 1))
+
+		sw.close()
+		Expect(w.flushes.Load()).To(BeNumerically("==", 1))
+	})
+
+	It("should abort the decode request of a client not reading its stream", func() {
+		cancelled := make(chan struct{})
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			event := []byte(fmt.Sprintf("data: %s\n\n", bytes.Repeat([]byte("x"), 32*1024)))
+			for {
+				select {
+				case <-r.Context().Done():
+					close(cancelled)
+					return
+				default:
+				}
+				if _, err := w.Write(event); err != nil {
+					close(cancelled)
+					return
+				}
+				w.(http.Flusher).Flush()
+			}
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: ConnectorNIXLV2, StreamWriteTimeout: 200 * time.Millisecond})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		// the client sends a streamed request and never reads the response
+		conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		Expect(conn.(*net.TCPConn).SetReadBuffer(4096)).To(Succeed())
+		body := `{"model":"m","prompt":"hi","stream":true}`
+		_, err = fmt.Fprintf(conn, "POST %s HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s",
+			CompletionsPath, len(body), body)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(cancelled, 10*time.Second).Should(BeClosed())
+	})
+})
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// tenantQuotas limits the concurrent requests of each tenant, identified by a request header,
+// so that a single tenant cannot take all the decode slots of the pod. The quotas are shared by
+// the servers of all the data parallel ranks.
+type tenantQuotas struct {
+	header        string
+	maxConcurrent int
+
+	mutex    sync.Mutex
+	inFlight map[string]int // by tenant, only the tenants with requests in flight
+}
+
+// newTenantQuotas returns the tenant quotas, or nil when the header is not set or the limit is not positive
+func newTenantQuotas(header string, maxConcurrent int) *tenantQuotas {
+	if header == "" || maxConcurrent <= 0 {
+		return nil
+	}
+	return &tenantQuotas{
+		header:        header,
+		maxConcurrent: maxConcurrent,
+		inFlight:      map[string]int{},
+	}
+}
+
+// acquire returns whether a request of the tenant may be served, and counts it in flight
+func (q *tenantQuotas) acquire(tenant string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.inFlight[tenant] >= q.maxConcurrent {
+		return false
+	}
+	q.inFlight[tenant]++
+	return true
+}
+
+// release counts a request of the tenant out of flight
+func (q *tenantQuotas) release(tenant string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.inFlight[tenant]--
+	if q.inFlight[tenant] <= 0 {
+		delete(q.inFlight, tenant)
+	}
+}
+
+// acquireTenantQuota replies with a rate limit error and returns false when the tenant of the request
+// reached its concurrency quota. Otherwise, the returned function must be called once the request is served.
+// The requests without a tenant are not limited.
+func (s *Server) acquireTenantQuota(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	tenant := r.Header.Get(s.tenantQuotas.header)
+	if tenant == "" {
+		return func() {}, true
+	}
+
+	if !s.tenantQuotas.acquire(tenant) {
+		s.requestLogger(r).V(4).Info("tenant concurrency quota exceeded", "tenant", tenant)
+		s.metrics.observeTenantRejection()
+		s.replyError(w, tenantQuotaExceededError(tenant))
+		return nil, false
+	}
+	return func() { s.tenantQuotas.release(tenant) }, true
+}
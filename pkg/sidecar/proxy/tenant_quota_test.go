@@ -0,0 +1,117 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Tenant quotas", func() {
+	const tenantHeader = "x-tenant-id"
+
+	var (
+		server   *httptest.Server
+		started  chan struct{}
+		released chan struct{}
+	)
+
+	BeforeEach(func() {
+		started = make(chan struct{}, 1)
+		released = make(chan struct{})
+
+		// the decoder holds the requests with the "hold" prompt until released
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body) //nolint:all
+			if strings.Contains(string(body), "hold") {
+				started <- struct{}{}
+				<-released
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[]}`)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{
+			Connector:                   ConnectorNIXLV2,
+			TenantHeader:                tenantHeader,
+			TenantMaxConcurrentRequests: 1,
+		})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendCompletion := func(tenant string, prompt string) (int, errorResponse) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"`+prompt+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		if tenant != "" {
+			req.Header.Set(tenantHeader, tenant)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+
+		var response errorResponse
+		_ = json.Unmarshal(body, &response) //nolint:all
+		return resp.StatusCode, response
+	}
+
+	It("should reject the requests of a tenant exceeding its quota", func() {
+		held := make(chan int)
+		go func() {
+			defer GinkgoRecover()
+			code, _ := sendCompletion("a", "hold")
+			held <- code
+		}()
+		Eventually(started).Should(Receive())
+
+		code, response := sendCompletion("a", "hi")
+		Expect(code).To(Equal(http.StatusTooManyRequests))
+		Expect(response.Type).To(Equal("RateLimitError"))
+
+		// the other tenants and the requests without tenant are not limited
+		code, _ = sendCompletion("b", "hi")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = sendCompletion("", "hi")
+		Expect(code).To(Equal(http.StatusOK))
+
+		close(released)
+		Eventually(held).Should(Receive(Equal(http.StatusOK)))
+
+		code, _ = sendCompletion("a", "hi")
+		Expect(code).To(Equal(http.StatusOK))
+
+		resp, err := http.Get(server.URL + MetricsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		metrics, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(metrics)).To(ContainSubstring("llm_d_sidecar_tenant_rejections_total 1"))
+	})
+})
@@ -23,11 +23,20 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
+	"os"
 	"time"
 )
 
+// HealthPath is the path of the health endpoint, served without client certificate for the probes
+const HealthPath = "/health"
+
+// errClientCertificateRequired is returned when a request is sent without a verified client certificate
+var errClientCertificateRequired = errors.New("a client certificate signed by a trusted certificate authority is required")
+
 // CreateSelfSignedTLSCertificate creates a self-signed cert the server can use to serve TLS.
 // Original code: https://github.com/kubernetes-sigs/gateway-api-inference-extension/blob/8d01161ec48d6b49cd371f179551b35da46e6fd6/internal/tls/tls.go
 func CreateSelfSignedTLSCertificate() (tls.Certificate, error) {
@@ -70,3 +79,59 @@ func CreateSelfSignedTLSCertificate() (tls.Certificate, error) {
 
 	return tls.X509KeyPair(certBytes, keyBytes)
 }
+
+// LoadCertPool loads the PEM encoded certificates of the file at the given path in a certificate pool
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	pemCerts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificates: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("no PEM encoded certificate found in %s", path)
+	}
+	return pool, nil
+}
+
+// requireClientCertificate rejects the requests without a verified client certificate, except the
+// health checks. The certificates are verified by the TLS handshake when presented.
+func requireClientCertificate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the drain endpoint is only served to the local clients, which have no client certificate
+		if r.URL.Path != HealthPath && r.URL.Path != ReadyPath && r.URL.Path != DrainPath && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			unauthenticatedError().write(w) //nolint:all
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// upstreamTLSConfig returns the TLS configuration of the connections to a vLLM server. Its certificate
+// is verified with the given certificate authorities, or the system ones when nil, and must be valid
+// for the given server name when set, instead of the host name or IP of the server.
+func upstreamTLSConfig(insecureSkipVerify bool, rootCAs *x509.CertPool, serverName string) *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // configured by the operator
+		RootCAs:            rootCAs,
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+		},
+	}
+}
+
+// decoderTLSConfig returns the TLS configuration of the connections to the decoder
+func (s *Server) decoderTLSConfig() *tls.Config {
+	return upstreamTLSConfig(s.config.DecoderInsecureSkipVerify, s.config.DecoderRootCAs, s.config.DecoderServerName)
+}
+
+// prefillerTLSConfig returns the TLS configuration of the connections to the prefillers
+func (s *Server) prefillerTLSConfig() *tls.Config {
+	return upstreamTLSConfig(s.config.PrefillerInsecureSkipVerify, s.config.PrefillerRootCAs, s.config.PrefillerServerName)
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+	"k8s.io/klog/v2/ktesting"
+)
+
+// testCertificateAuthority is a certificate authority issuing certificates in tests
+type testCertificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCertificateAuthority() *testCertificateAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	return &testCertificateAuthority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate issued by the certificate authority
+func (ca *testCertificateAuthority) issue(usage x509.ExtKeyUsage, dnsNames ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).ToNot(HaveOccurred())
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writePEM writes the PEM encoded certificates of the certificate authority to a file
+func (ca *testCertificateAuthority) writePEM() string {
+	path := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+	Expect(os.WriteFile(path, ca.pem, 0o600)).To(Succeed())
+	return path
+}
+
+var _ = Describe("TLS", func() {
+	It("should load a certificate pool", func() {
+		ca := newTestCertificateAuthority()
+		pool, err := LoadCertPool(ca.writePEM())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = ca.cert.Verify(x509.VerifyOptions{Roots: pool})
+		Expect(err).ToNot(HaveOccurred())
+
+		empty := filepath.Join(GinkgoT().TempDir(), "empty.crt")
+		Expect(os.WriteFile(empty, []byte("not a certificate"), 0o600)).To(Succeed())
+		_, err = LoadCertPool(empty)
+		Expect(err).To(MatchError(ContainSubstring("no PEM encoded certificate")))
+
+		_, err = LoadCertPool(filepath.Join(GinkgoT().TempDir(), "missing.crt"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reload the listener certificate when its files change", func() {
+		ca := newTestCertificateAuthority()
+		certPath := GinkgoT().TempDir()
+		write := func(certPEM, keyPEM []byte) {
+			Expect(os.WriteFile(filepath.Join(certPath, "tls.crt"), certPEM, 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(certPath, "tls.key"), keyPEM, 0o600)).To(Succeed())
+		}
+		encode := func(cert tls.Certificate) ([]byte, []byte) {
+			keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+			Expect(err).ToNot(HaveOccurred())
+			return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}),
+				pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+		}
+
+		initial := ca.issue(x509.ExtKeyUsageServerAuth)
+		write(encode(initial))
+		store := newCertificateStore(&initial, certPath)
+		Expect(store.reload()).To(BeFalse())
+
+		renewed := ca.issue(x509.ExtKeyUsageServerAuth)
+		write(encode(renewed))
+		Expect(store.reload()).To(BeTrue())
+		served, err := store.getCertificate(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(served.Certificate[0]).To(Equal(renewed.Certificate[0]))
+
+		// a certificate being written is invalid, the current one is kept
+		certPEM, _ := encode(ca.issue(x509.ExtKeyUsageServerAuth))
+		write(certPEM, []byte("partial key"))
+		_, err = store.reload()
+		Expect(err).To(HaveOccurred())
+		served, _ = store.getCertificate(nil)
+		Expect(served.Certificate[0]).To(Equal(renewed.Certificate[0]))
+	})
+
+	It("should require a client certificate signed by the client certificate authorities", func() {
+		_, ctx := ktesting.NewTestContext(GinkgoT())
+
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		clientCA := newTestCertificateAuthority()
+		clientCAs, err := LoadCertPool(clientCA.writePEM())
+		Expect(err).ToNot(HaveOccurred())
+		serverCert, err := CreateSelfSignedTLSCertificate()
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{ClientCAs: clientCAs})
+		ctx, cancelFn := context.WithCancel(ctx)
+		stoppedCh := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			Expect(proxy.Start(ctx, &serverCert, &AllowlistValidator{enabled: false})).To(Succeed())
+			close(stoppedCh)
+		}()
+		DeferCleanup(func() {
+			cancelFn()
+			<-stoppedCh
+		})
+
+		time.Sleep(1 * time.Second)
+		Expect(proxy.addr).ToNot(BeNil())
+
+		get := func(path string, certs ...tls.Certificate) (int, error) {
+			client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs}, //nolint:gosec // self-signed server certificate
+			}}
+			resp, err := client.Get("https://" + proxy.addr.String() + path)
+			if err != nil {
+				return 0, err
+			}
+			resp.Body.Close() //nolint:all
+			return resp.StatusCode, nil
+		}
+
+		Expect(get("/v1/models", clientCA.issue(x509.ExtKeyUsageClientAuth))).To(Equal(http.StatusOK))
+		Expect(get("/v1/models")).To(Equal(http.StatusUnauthorized))
+		Expect(get(HealthPath)).To(Equal(http.StatusOK))
+
+		// the certificates of other authorities fail the handshake
+		_, err = get("/v1/models", newTestCertificateAuthority().issue(x509.ExtKeyUsageClientAuth))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("with the certificate authorities of the upstreams", func() {
+		var (
+			ca              *testCertificateAuthority
+			decodeURL       *url.URL
+			prefillHostPort string
+		)
+
+		// startTLSServer starts a server with a certificate valid for the given name only, not for its IP
+		startTLSServer := func(handler http.Handler, name string) *httptest.Server {
+			server := httptest.NewUnstartedServer(handler)
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{ca.issue(x509.ExtKeyUsageServerAuth, name)}}
+			server.StartTLS()
+			DeferCleanup(server.Close)
+			return server
+		}
+
+		BeforeEach(func() {
+			ca = newTestCertificateAuthority()
+
+			decodeBackend := startTLSServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RoleDecode}, "decode.test")
+			var err error
+			decodeURL, err = url.Parse(decodeBackend.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			prefillBackend := startTLSServer(&mock.ChatCompletionHandler{Connector: ConnectorNIXLV2, Role: mock.RolePrefill}, "prefill.test")
+			prefillHostPort = strings.TrimPrefix(prefillBackend.URL, "https://")
+		})
+
+		sendCompletion := func(config Config, prefill bool) int {
+			config.Connector = ConnectorNIXLV2
+			config.PrefillerUseTLS = true
+			proxy := NewProxy("0", decodeURL, config)
+			proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+			server := httptest.NewServer(proxy.createRoutes())
+			DeferCleanup(server.Close)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+			Expect(err).ToNot(HaveOccurred())
+			if prefill {
+				req.Header.Set(common.PrefillPodHeader, prefillHostPort)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close() //nolint:all
+			return resp.StatusCode
+		}
+
+		It("should verify the certificate of the decoder", func() {
+			pool, err := LoadCertPool(ca.writePEM())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sendCompletion(Config{DecoderRootCAs: pool, DecoderServerName: "decode.test"}, false)).To(Equal(http.StatusOK))
+			Expect(sendCompletion(Config{DecoderRootCAs: pool}, false)).To(Equal(http.StatusBadGateway))
+			Expect(sendCompletion(Config{DecoderServerName: "decode.test"}, false)).To(Equal(http.StatusBadGateway))
+		})
+
+		It("should verify the certificates of the prefillers", func() {
+			pool, err := LoadCertPool(ca.writePEM())
+			Expect(err).ToNot(HaveOccurred())
+			decoder := Config{DecoderRootCAs: pool, DecoderServerName: "decode.test"}
+
+			config := decoder
+			config.PrefillerRootCAs, config.PrefillerServerName = pool, "prefill.test"
+			Expect(sendCompletion(config, true)).To(Equal(http.StatusOK))
+
+			config = decoder
+			config.PrefillerRootCAs = pool
+			Expect(sendCompletion(config, true)).To(Equal(http.StatusBadGateway))
+		})
+	})
+})
@@ -0,0 +1,136 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// bytesPerToken is the number of request body bytes the prompt token estimates assume per token
+	bytesPerToken = 4
+
+	// defaultTokenRateBurstSeconds is the number of seconds of the token rate the clients may burst
+	// when the burst is not set
+	defaultTokenRateBurstSeconds = 10
+
+	// maxTokenRateClients is the number of clients whose token buckets are kept, the least recently
+	// seen clients are forgotten beyond it
+	maxTokenRateClients = 10000
+)
+
+// tokenRateLimits limits the estimated prompt tokens per second of each client, identified by a request
+// header, e.g. its API key, or by its source IP, so that the giant prompts of a client cannot starve the
+// other clients of the decoder. The limits are shared by the servers of all the data parallel ranks.
+type tokenRateLimits struct {
+	header string
+	rate   rate.Limit
+	burst  int
+
+	mutex    sync.Mutex
+	limiters *lru.Cache[string, *rate.Limiter] // by client
+}
+
+// newTokenRateLimits returns the token rate limits, or nil when the rate is not positive. The burst
+// defaults to defaultTokenRateBurstSeconds of the rate when not positive.
+func newTokenRateLimits(header string, tokensPerSecond float64, burst int) *tokenRateLimits {
+	if tokensPerSecond <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(tokensPerSecond * defaultTokenRateBurstSeconds))
+	}
+	limiters, _ := lru.New[string, *rate.Limiter](maxTokenRateClients) // nolint:all
+	return &tokenRateLimits{
+		header:   header,
+		rate:     rate.Limit(tokensPerSecond),
+		burst:    burst,
+		limiters: limiters,
+	}
+}
+
+// client returns the client of the request: the value of the header when set, its source IP otherwise
+func (l *tokenRateLimits) client(r *http.Request) string {
+	if l.header != "" {
+		if client := r.Header.Get(l.header); client != "" {
+			return "header:" + client
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// reserve takes the tokens from the bucket of the client, and returns 0 when the request may be served,
+// or the time to wait before the bucket holds the tokens otherwise. The requests estimated larger than
+// the burst take the whole bucket, instead of being rejected forever.
+func (l *tokenRateLimits) reserve(client string, tokens int) time.Duration {
+	l.mutex.Lock()
+	limiter, ok := l.limiters.Get(client)
+	if !ok {
+		limiter = rate.NewLimiter(l.rate, l.burst)
+		l.limiters.Add(client, limiter)
+	}
+	l.mutex.Unlock()
+
+	now := time.Now()
+	reservation := limiter.ReserveN(now, min(tokens, l.burst))
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay
+	}
+	return 0
+}
+
+// estimatePromptTokens estimates the prompt tokens of a request from the size of its body
+func estimatePromptTokens(body []byte) int {
+	return max(len(body)/bytesPerToken, 1)
+}
+
+// limitTokenRate replies with a rate limit error and a Retry-After header, and returns false, when the
+// client of the request exceeded its rate of estimated prompt tokens
+func (s *Server) limitTokenRate(w http.ResponseWriter, r *http.Request) bool {
+	body, ok := s.readRequestBody(w, r)
+	if !ok {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.TransferEncoding = nil
+
+	tokens := estimatePromptTokens(body)
+	if delay := s.tokenRates.reserve(s.tokenRates.client(r), tokens); delay > 0 {
+		s.requestLogger(r).V(4).Info("token rate limit exceeded", "tokens", tokens, "retryAfter", delay)
+		s.metrics.observeTokenRateRejection()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		s.replyError(w, tokenRateLimitExceededError())
+		return false
+	}
+	return true
+}
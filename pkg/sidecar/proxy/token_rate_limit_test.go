@@ -0,0 +1,117 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Token rate limits", func() {
+	const clientHeader = "x-api-key"
+
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		decodeBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[]}`)) //nolint:all
+		}))
+		DeferCleanup(decodeBackend.Close)
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		// a client may send 100 tokens at once, refilled at 1 token per second
+		proxy := NewProxy("0", decodeURL, Config{
+			Connector:             ConnectorNIXLV2,
+			TokenRateLimit:        1,
+			TokenRateBurst:        100,
+			TokenRateClientHeader: clientHeader,
+		})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server = httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+	})
+
+	sendCompletion := func(client string, prompt string) (*http.Response, errorResponse) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"`+prompt+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		if client != "" {
+			req.Header.Set(clientHeader, client)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+
+		var response errorResponse
+		_ = json.Unmarshal(body, &response) //nolint:all
+		return resp, response
+	}
+
+	It("should reject the requests of a client exceeding its token rate", func() {
+		largePrompt := strings.Repeat("x", 300) // about 80 tokens
+
+		resp, _ := sendCompletion("a", largePrompt)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		resp, response := sendCompletion("a", largePrompt)
+		Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(response.Type).To(Equal("RateLimitError"))
+		Expect(response.Kind).To(Equal(errorKindDecodeOverloaded))
+		Expect(resp.Header.Get("Retry-After")).ToNot(BeEmpty())
+
+		// the small requests of the client fit in the rest of its bucket
+		resp, _ = sendCompletion("a", "hi")
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		// the other clients, including the ones identified by their source IP, have their own bucket
+		resp, _ = sendCompletion("b", largePrompt)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		resp, _ = sendCompletion("", largePrompt)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		resp, err := http.Get(server.URL + MetricsPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		metrics, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(metrics)).To(ContainSubstring("llm_d_sidecar_token_rate_rejections_total 1"))
+	})
+
+	It("should let a request larger than the burst take the whole bucket", func() {
+		limits := newTokenRateLimits("", 1, 100)
+
+		Expect(limits.reserve("a", 1000)).To(BeZero())
+		Expect(limits.reserve("a", 10)).To(BeNumerically(">", 9*time.Second))
+	})
+
+	It("should be disabled without a positive rate", func() {
+		Expect(newTokenRateLimits(clientHeader, 0, 100)).To(BeNil())
+		Expect(newTokenRateLimits("", 2, 0).burst).To(Equal(20))
+	})
+})
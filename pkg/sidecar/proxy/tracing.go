@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/llm-d/llm-d-inference-scheduler/pkg/sidecar/proxy"
+
+	spanNamePrefix = "llm_d.pd_proxy."
+
+	connectorAttribute     = attribute.Key("llm_d.pd_proxy.connector")
+	prefillTargetAttribute = attribute.Key("llm_d.pd_proxy.prefill_target")
+	requestIDAttribute     = attribute.Key("llm_d.pd_proxy.request_id")
+	routeAttribute         = attribute.Key("http.route")
+	retriesAttribute       = attribute.Key("llm_d.pd_proxy.retries")
+	statusCodeAttribute    = attribute.Key("http.response.status_code")
+)
+
+// startSpan starts a span of the proxy as a child of the span in the context, with the request ID
+// of the context. The spans are not recorded unless a tracer provider is installed.
+func startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		opts = append(opts, trace.WithAttributes(requestIDAttribute.String(requestID)))
+	}
+	return otel.Tracer(tracerName).Start(ctx, spanNamePrefix+name, opts...)
+}
+
+// extractTraceContext returns the request with the trace context propagated in its headers,
+// so that the spans of the sidecar join the trace started by the gateway and the EPP
+func extractTraceContext(r *http.Request) *http.Request {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return r.WithContext(ctx)
+}
+
+// injectTraceContext propagates the trace context of the request to vLLM in its headers
+func injectTraceContext(r *http.Request) {
+	otel.GetTextMapPropagator().Inject(r.Context(), propagation.HeaderCarrier(r.Header))
+}
+
+// marshalRequest marshals a request body rewritten by a connector, in a span
+func marshalRequest(ctx context.Context, spanName string, request map[string]any) ([]byte, error) {
+	_, span := startSpan(ctx, spanName)
+	body, err := encodeJSON(request)
+	endSpanWithError(span, err)
+	return body, err
+}
+
+// endSpan records the response status code of a span and ends it
+func endSpan(span trace.Span, statusCode int) {
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	span.SetAttributes(statusCodeAttribute.Int(statusCode))
+	if statusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+	span.End()
+}
+
+// endSpanWithError records an error on a span and ends it
+func endSpanWithError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+const (
+	incomingTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingTraceparent = "00-" + incomingTraceID + "-00f067aa0ba902b7-01"
+)
+
+// traceparentRecorder records the traceparent headers received by a backend
+type traceparentRecorder struct {
+	handler http.Handler
+	mu      sync.Mutex
+	values  []string
+}
+
+func (t *traceparentRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	t.values = append(t.values, r.Header.Get("traceparent"))
+	t.mu.Unlock()
+	t.handler.ServeHTTP(w, r)
+}
+
+var _ = Describe("Tracing", func() {
+	var recorder *tracetest.SpanRecorder
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		DeferCleanup(func() {
+			otel.SetTracerProvider(previousProvider)
+			otel.SetTextMapPropagator(previousPropagator)
+		})
+	})
+
+	spanNames := func() []string {
+		var names []string
+		for _, span := range recorder.Ended() {
+			Expect(span.SpanContext().TraceID().String()).To(Equal(incomingTraceID))
+			names = append(names, span.Name())
+		}
+		return names
+	}
+
+	DescribeTable("should trace the connector protocols end to end",
+		func(connector string, expectedSpans []string) {
+			decoder := &traceparentRecorder{handler: &mock.ChatCompletionHandler{Connector: connector, Role: mock.RoleDecode}}
+			decodeBackend := httptest.NewServer(decoder)
+			DeferCleanup(decodeBackend.Close)
+			prefiller := &traceparentRecorder{handler: &mock.ChatCompletionHandler{Connector: connector, Role: mock.RolePrefill}}
+			prefillBackend := httptest.NewServer(prefiller)
+			DeferCleanup(prefillBackend.Close)
+
+			decodeURL, err := url.Parse(decodeBackend.URL)
+			Expect(err).ToNot(HaveOccurred())
+			proxy := NewProxy("0", decodeURL, Config{Connector: connector})
+			proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+			server := httptest.NewServer(proxy.createRoutes())
+			DeferCleanup(server.Close)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+ChatCompletionsPath,
+				strings.NewReader(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, strings.TrimPrefix(prefillBackend.URL, "http://"))
+			req.Header.Set("traceparent", incomingTraceparent)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			_, _ = io.ReadAll(resp.Body) //nolint:all
+			resp.Body.Close()            //nolint:all
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			Expect(spanNames()).To(ConsistOf(expectedSpans))
+
+			// vLLM receives the context of the prefill and decode spans
+			spanIDs := map[string]string{}
+			for _, span := range recorder.Ended() {
+				spanIDs[span.Name()] = span.SpanContext().SpanID().String()
+			}
+			Expect(prefiller.values).To(ConsistOf("00-" + incomingTraceID + "-" + spanIDs["llm_d.pd_proxy.prefill"] + "-01"))
+			Expect(decoder.values).To(ConsistOf("00-" + incomingTraceID + "-" + spanIDs["llm_d.pd_proxy.decode"] + "-01"))
+		},
+		Entry("nixlv2", ConnectorNIXLV2, []string{
+			"llm_d.pd_proxy.nixlv2", "llm_d.pd_proxy.marshal_prefill_request", "llm_d.pd_proxy.prefill",
+			"llm_d.pd_proxy.marshal_decode_request", "llm_d.pd_proxy.decode",
+		}),
+		Entry("lmcache", ConnectorLMCache, []string{
+			"llm_d.pd_proxy.lmcache", "llm_d.pd_proxy.marshal_prefill_request", "llm_d.pd_proxy.prefill",
+			"llm_d.pd_proxy.decode",
+		}),
+	)
+})
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/test/sidecar/mock"
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+const (
+	usageTrailer   = "X-Usage"
+	usageValue     = "prompt_tokens=5,completion_tokens=3"
+	streamedChunks = "data: {\"choices\":[{\"text\":\"a\"}]}\n\ndata: {\"choices\":[{\"text\":\"b\"}]}\n\ndata: [DONE]\n\n"
+)
+
+// streamingTrailerHandler streams chunked server-sent events followed by a trailer
+func streamingTrailerHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.ReadAll(r.Body) //nolint:all
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Trailer", usageTrailer)
+	w.WriteHeader(http.StatusOK)
+	for _, chunk := range strings.SplitAfter(streamedChunks, "\n\n") {
+		_, _ = w.Write([]byte(chunk)) //nolint:all
+		w.(http.Flusher).Flush()
+	}
+	w.Header().Set(usageTrailer, usageValue)
+}
+
+var _ = Describe("Trailers and chunked transfer encoding", func() {
+	var decodeBackend *httptest.Server
+	var decodeRequests chan string
+
+	BeforeEach(func() {
+		decodeRequests = make(chan string, 1)
+		decodeBackend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body) //nolint:all
+			decodeRequests <- string(body)
+			r.Body = io.NopCloser(strings.NewReader(string(body)))
+			streamingTrailerHandler(w, r)
+		}))
+		DeferCleanup(decodeBackend.Close)
+	})
+
+	newProxyServer := func(connector string) *httptest.Server {
+		decodeURL, err := url.Parse(decodeBackend.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		proxy := NewProxy("0", decodeURL, Config{Connector: connector})
+		proxy.allowlistValidator = &AllowlistValidator{enabled: false}
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	expectStreamWithTrailer := func(resp *http.Response) {
+		defer resp.Body.Close() //nolint:all
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.TransferEncoding).To(ContainElement("chunked"))
+
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal(streamedChunks))
+
+		// trailers are only available once the body is fully read
+		Expect(resp.Trailer.Get(usageTrailer)).To(Equal(usageValue))
+	}
+
+	It("should forward decoder trailers when there is no prefill", func() {
+		server := newProxyServer(ConnectorNIXLV2)
+
+		resp, err := http.Post(server.URL+CompletionsPath, "application/json",
+			strings.NewReader(`{"model":"m","prompt":"hi","stream":true}`))
+		Expect(err).ToNot(HaveOccurred())
+		expectStreamWithTrailer(resp)
+	})
+
+	for _, connector := range connectors {
+		It("should forward decoder trailers after a disaggregated prefill using "+connector, func() {
+			prefillBackend := httptest.NewServer(&mock.ChatCompletionHandler{Connector: connector, Role: mock.RolePrefill})
+			DeferCleanup(prefillBackend.Close)
+			server := newProxyServer(connector)
+
+			// an io.Reader of unknown length makes the client use chunked transfer encoding
+			body := io.MultiReader(strings.NewReader(`{"model":"m",`), strings.NewReader(`"prompt":"hi","stream":true}`))
+			req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, body)
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set(common.PrefillPodHeader, strings.TrimPrefix(prefillBackend.URL, "http://"))
+			Expect(req.ContentLength).To(BeNumerically("==", 0))
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			expectStreamWithTrailer(resp)
+			Expect(<-decodeRequests).To(ContainSubstring(`"prompt":"hi"`))
+		})
+	}
+
+	It("should return the prefiller error response with its headers, body and trailers", func() {
+		prefillBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body) //nolint:all
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Trailer", usageTrailer)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"object":"error","message":"overloaded"}`)) //nolint:all
+			w.Header().Set(usageTrailer, usageValue)
+		}))
+		DeferCleanup(prefillBackend.Close)
+		server := newProxyServer(ConnectorNIXLV2)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+CompletionsPath, strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(common.PrefillPodHeader, strings.TrimPrefix(prefillBackend.URL, "http://"))
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal(`{"object":"error","message":"overloaded"}`))
+		Expect(resp.Trailer.Get(usageTrailer)).To(Equal(usageValue))
+		Expect(decodeRequests).To(BeEmpty())
+	})
+})
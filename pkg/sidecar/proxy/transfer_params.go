@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// TransferStage is the stage of the P/D protocol a request is sent for
+type TransferStage string
+
+const (
+	// TransferStagePrefill is the stage of the requests sent to the prefiller
+	TransferStagePrefill TransferStage = "prefill"
+
+	// TransferStageDecode is the stage of the requests sent to the local decoder
+	TransferStageDecode TransferStage = "decode"
+)
+
+// TransferParamsHook augments the KV-transfer parameters of the requests prepared by the connector,
+// e.g. with transport hints or tenant tags, without forking the connector
+type TransferParamsHook interface {
+	// AugmentTransferParams modifies the kv_transfer_params of a request sent for the given stage.
+	// The request is the one received by the sidecar, its body must not be read.
+	AugmentTransferParams(r *http.Request, stage TransferStage, params map[string]any)
+}
+
+// TransferParamsHookFunc adapts a function to the TransferParamsHook interface
+type TransferParamsHookFunc func(r *http.Request, stage TransferStage, params map[string]any)
+
+// AugmentTransferParams implements TransferParamsHook
+func (f TransferParamsHookFunc) AugmentTransferParams(r *http.Request, stage TransferStage, params map[string]any) {
+	f(r, stage, params)
+}
+
+var (
+	transferParamsHooks   []TransferParamsHook
+	transferParamsHooksMu sync.RWMutex
+)
+
+// RegisterTransferParamsHook registers a hook augmenting the KV-transfer parameters of the requests of
+// all the connectors, called in the registration order after the configured fields are injected
+func RegisterTransferParamsHook(hook TransferParamsHook) {
+	transferParamsHooksMu.Lock()
+	defer transferParamsHooksMu.Unlock()
+	transferParamsHooks = append(transferParamsHooks, hook)
+}
+
+// registeredTransferParamsHooks returns the registered hooks
+func registeredTransferParamsHooks() []TransferParamsHook {
+	transferParamsHooksMu.RLock()
+	defer transferParamsHooksMu.RUnlock()
+	return transferParamsHooks
+}
+
+// augmentTransferParams injects the configured fields of the stage in the kv_transfer_params of a request
+// prepared by the connector, keeping the fields set by the connector, then calls the registered hooks.
+// The requests without a kv_transfer_params object, e.g. of the lmcache connector, are not modified.
+func (s *Server) augmentTransferParams(r *http.Request, stage TransferStage, request map[string]any) {
+	params, ok := request[requestFieldKVTransferParams].(map[string]any)
+	if !ok {
+		return
+	}
+
+	fields := s.config.PrefillTransferParams
+	if stage == TransferStageDecode {
+		fields = s.config.DecodeTransferParams
+	}
+	for field, value := range fields {
+		if _, ok := params[field]; !ok {
+			params[field] = value
+		}
+	}
+
+	for _, hook := range registeredTransferParamsHooks() {
+		hook.AugmentTransferParams(r, stage, params)
+	}
+}
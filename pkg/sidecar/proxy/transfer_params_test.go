@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("KV-transfer parameters augmentation", func() {
+	transferParams := func(body []byte) any {
+		var object map[string]any
+		Expect(json.Unmarshal(body, &object)).To(Succeed())
+		return object[requestFieldKVTransferParams]
+	}
+
+	It("should inject the configured fields, keeping the fields set by the connector", func() {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV2,
+			[]byte(`{"kv_transfer_params":{"remote_engine_id":"e","remote_block_ids":[1]}}`))
+		server.config.PrefillTransferParams = map[string]any{"transport": "ucx", "do_remote_decode": false}
+		server.config.DecodeTransferParams = map[string]any{"tenant": "a", "remote_engine_id": "other"}
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi"}`))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(*prefillRequests).To(HaveLen(1))
+		Expect(transferParams((*prefillRequests)[0])).To(And(
+			HaveKeyWithValue("transport", "ucx"),
+			HaveKeyWithValue("do_remote_decode", true),
+		))
+		Expect(decoder.bodies).To(HaveLen(1))
+		Expect(transferParams(decoder.bodies[0])).To(Equal(map[string]any{
+			"remote_engine_id": "e", "remote_block_ids": []any{1.0}, "tenant": "a",
+		}))
+	})
+
+	It("should call the registered hooks with the stage of the requests", func() {
+		DeferCleanup(func(hooks []TransferParamsHook) { transferParamsHooks = hooks }, transferParamsHooks)
+		var stages []TransferStage
+		RegisterTransferParamsHook(TransferParamsHookFunc(func(r *http.Request, stage TransferStage, params map[string]any) {
+			stages = append(stages, stage)
+			params["hook"] = string(stage) + " " + r.URL.Path
+		}))
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV2,
+			[]byte(`{"kv_transfer_params":{"remote_engine_id":"e"}}`))
+		Expect(server.features().Enabled).To(ContainElement("transferParamsAugmentation"))
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi"}`))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(stages).To(Equal([]TransferStage{TransferStagePrefill, TransferStageDecode}))
+		Expect(transferParams((*prefillRequests)[0])).To(HaveKeyWithValue("hook", "prefill "+ChatCompletionsPath))
+		Expect(transferParams(decoder.bodies[0])).To(HaveKeyWithValue("hook", "decode "+ChatCompletionsPath))
+	})
+
+	It("should not modify the requests without KV-transfer parameters", func() {
+		server, decoder, prefillRequests := newInProcessProxy(ConnectorNIXLV1,
+			[]byte(`{"remote_block_ids":[1],"remote_engine_id":"e"}`))
+		server.config.PrefillTransferParams = map[string]any{"transport": "ucx"}
+		server.config.DecodeTransferParams = map[string]any{"tenant": "a"}
+
+		rec := sendConnectorRequest(server, []byte(`{"model":"m","prompt":"hi"}`))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(transferParams((*prefillRequests)[0])).To(BeNil())
+		Expect(transferParams(decoder.bodies[0])).To(BeNil())
+	})
+})
@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the transports of the prefiller and decoder reverse proxies. The zero
+// values keep the defaults of the Go default transport, which limits the idle connections kept
+// per host to 2 and then bottlenecks high-concurrency P/D traffic on new connections.
+type TransportConfig struct {
+	// MaxIdleConns is the number of idle connections kept per transport, each prefill target and
+	// the decoder having its own transport. Unlimited when negative.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the number of idle connections kept per host.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost is the number of connections per host, including the active ones. The new
+	// requests wait for a connection once it is reached. Unlimited when not positive.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is the time an idle connection is kept before being closed.
+	IdleConnTimeout time.Duration
+
+	// TLSHandshakeTimeout is the time a TLS handshake has to complete.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the time the response headers have to be received once a request is
+	// written, including the time of a whole prefill. Unlimited when not positive.
+	ResponseHeaderTimeout time.Duration
+}
+
+// newTransport returns a transport of the reverse proxies with the given TLS configuration, the
+// connections without TLS configuration being plaintext, tuned by the transport configuration. The
+// transport speaks HTTP/1.1 unless http2 is set: it then speaks HTTP/2 over TLS, falling back to
+// HTTP/1.1 when not negotiated, and HTTP/2 with prior knowledge (h2c) over plaintext connections.
+func (s *Server) newTransport(tlsConfig *tls.Config, http2 bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.ForceAttemptHTTP2 = false
+	if http2 {
+		transport.Protocols = new(http.Protocols)
+		if tlsConfig != nil {
+			transport.Protocols.SetHTTP1(true)
+			transport.Protocols.SetHTTP2(true)
+		} else {
+			transport.Protocols.SetUnencryptedHTTP2(true)
+		}
+	}
+
+	tuning := s.config.Transport
+	switch {
+	case tuning.MaxIdleConns < 0:
+		transport.MaxIdleConns = 0 // unlimited
+	case tuning.MaxIdleConns > 0:
+		transport.MaxIdleConns = tuning.MaxIdleConns
+	}
+	if tuning.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+	}
+	if tuning.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = tuning.MaxConnsPerHost
+	}
+	if tuning.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = tuning.IdleConnTimeout
+	}
+	if tuning.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = tuning.TLSHandshakeTimeout
+	}
+	if tuning.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = tuning.ResponseHeaderTimeout
+	}
+	return transport
+}
+
+// decoderClientTransport returns the transport of the clients probing the decoder, nil for the
+// default transport when the decoder is reached over plaintext TCP
+func (s *Server) decoderClientTransport() http.RoundTripper {
+	if s.decoderURL.Scheme != "https" && s.config.DecoderSocket == "" {
+		return nil
+	}
+	transport := &http.Transport{}
+	if s.decoderURL.Scheme == "https" {
+		transport.TLSClientConfig = s.decoderTLSConfig()
+	}
+	if s.config.DecoderSocket != "" {
+		transport.DialContext = dialUnixSocket(s.config.DecoderSocket)
+	}
+	return transport
+}
+
+// dialUnixSocket returns a dial function connecting to the Unix domain socket at the given path,
+// whatever the address of the request
+func dialUnixSocket(path string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" // nolint:revive
+	. "github.com/onsi/gomega"    // nolint:revive
+)
+
+var _ = Describe("Transport tuning", func() {
+	var decodeURL *url.URL
+
+	BeforeEach(func() {
+		var err error
+		decodeURL, err = url.Parse("https://localhost:8200")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should keep the defaults of the Go transport when not tuned", func() {
+		proxy := NewProxy("0", decodeURL, Config{})
+		defaultTransport := http.DefaultTransport.(*http.Transport)
+
+		transport := proxy.newTransport(nil, false)
+		Expect(transport.MaxIdleConns).To(Equal(defaultTransport.MaxIdleConns))
+		Expect(transport.MaxIdleConnsPerHost).To(Equal(defaultTransport.MaxIdleConnsPerHost))
+		Expect(transport.MaxConnsPerHost).To(Equal(defaultTransport.MaxConnsPerHost))
+		Expect(transport.IdleConnTimeout).To(Equal(defaultTransport.IdleConnTimeout))
+		Expect(transport.TLSHandshakeTimeout).To(Equal(defaultTransport.TLSHandshakeTimeout))
+		Expect(transport.ResponseHeaderTimeout).To(BeZero())
+	})
+
+	It("should tune the transports of the prefiller and decoder proxies", func() {
+		proxy := NewProxy("0", decodeURL, Config{PrefillerUseTLS: true, Transport: TransportConfig{
+			MaxIdleConns:          -1,
+			MaxIdleConnsPerHost:   64,
+			MaxConnsPerHost:       256,
+			IdleConnTimeout:       time.Minute,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+		}})
+
+		prefillerProxy, err := proxy.prefillerProxyHandler("10.0.0.1:8000")
+		Expect(err).ToNot(HaveOccurred())
+		decoderProxy := proxy.createDecoderProxyHandler(decodeURL)
+
+		for _, handler := range []http.Handler{prefillerProxy, decoderProxy} {
+			transport := handler.(*httputil.ReverseProxy).Transport.(*http.Transport)
+			Expect(transport.MaxIdleConns).To(BeZero())
+			Expect(transport.MaxIdleConnsPerHost).To(Equal(64))
+			Expect(transport.MaxConnsPerHost).To(Equal(256))
+			Expect(transport.IdleConnTimeout).To(Equal(time.Minute))
+			Expect(transport.TLSHandshakeTimeout).To(Equal(5 * time.Second))
+			Expect(transport.ResponseHeaderTimeout).To(Equal(30 * time.Second))
+			Expect(transport.TLSClientConfig).ToNot(BeNil())
+		}
+	})
+
+	Describe("HTTP/2", func() {
+		// newBackend returns a backend replying with the protocol of the requests
+		newBackend := func(useTLS bool) *httptest.Server {
+			backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body) //nolint:all
+				w.Write([]byte(r.Proto))  //nolint:all
+			}))
+			backend.Config.Protocols = new(http.Protocols)
+			backend.Config.Protocols.SetHTTP1(true)
+			backend.Config.Protocols.SetHTTP2(true)
+			backend.Config.Protocols.SetUnencryptedHTTP2(true)
+			if useTLS {
+				backend.EnableHTTP2 = true
+				backend.StartTLS()
+			} else {
+				backend.Start()
+			}
+			DeferCleanup(backend.Close)
+			return backend
+		}
+
+		getProto := func(handler http.Handler) string {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, CompletionsPath, strings.NewReader(`{}`)))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			return recorder.Body.String()
+		}
+
+		DescribeTable("should speak HTTP/2 to the decoder only when enabled",
+			func(useTLS bool, http2 bool, wantProto string) {
+				backendURL, err := url.Parse(newBackend(useTLS).URL)
+				Expect(err).ToNot(HaveOccurred())
+				proxy := NewProxy("0", backendURL, Config{DecoderHTTP2: http2, DecoderInsecureSkipVerify: true})
+
+				Expect(getProto(proxy.createDecoderProxyHandler(backendURL))).To(Equal(wantProto))
+			},
+			Entry("h2c", false, true, "HTTP/2.0"),
+			Entry("h2 over TLS", true, true, "HTTP/2.0"),
+			Entry("HTTP/1.1", false, false, "HTTP/1.1"),
+			Entry("HTTP/1.1 over TLS", true, false, "HTTP/1.1"),
+		)
+
+		It("should speak HTTP/2 to the prefillers when enabled", func() {
+			backend := newBackend(false)
+			proxy := NewProxy("0", decodeURL, Config{PrefillerHTTP2: true})
+
+			handler, err := proxy.prefillerProxyHandler(strings.TrimPrefix(backend.URL, "http://"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getProto(handler)).To(Equal("HTTP/2.0"))
+		})
+
+		It("should accept h2c on the listener when enabled", func() {
+			backendURL, err := url.Parse(newBackend(false).URL)
+			Expect(err).ToNot(HaveOccurred())
+			proxy := NewProxy("0", backendURL, Config{ListenerHTTP2: true})
+			ctx, cancelFn := context.WithCancel(context.Background())
+			stoppedCh := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				Expect(proxy.Start(ctx, nil, &AllowlistValidator{enabled: false})).To(Succeed())
+				close(stoppedCh)
+			}()
+			DeferCleanup(func() {
+				cancelFn()
+				<-stoppedCh
+			})
+
+			time.Sleep(1 * time.Second)
+			Expect(proxy.addr).ToNot(BeNil())
+
+			client := &http.Client{Transport: &http.Transport{Protocols: new(http.Protocols)}}
+			client.Transport.(*http.Transport).Protocols.SetUnencryptedHTTP2(true)
+			resp, err := client.Get("http://" + proxy.addr.String() + HealthPath)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close() //nolint:all
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Proto).To(Equal("HTTP/2.0"))
+		})
+	})
+
+	It("should connect to the decoder over its Unix domain socket when configured", func() {
+		dir, err := os.MkdirTemp("", "uds") // short path, the socket paths being limited to about 100 bytes
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+		socket := filepath.Join(dir, "vllm.sock")
+		ln, err := net.Listen("unix", socket)
+		Expect(err).ToNot(HaveOccurred())
+		backend := &httptest.Server{Listener: ln, Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)   //nolint:all
+			w.Write([]byte(r.URL.Path)) //nolint:all
+		})}}
+		backend.Start()
+		DeferCleanup(backend.Close)
+
+		proxy := NewProxy("0", &url.URL{Scheme: "http", Host: "localhost:8200"}, Config{DecoderSocket: socket})
+		Expect(proxy.decoderURL.String()).To(Equal("http://localhost"))
+		server := httptest.NewServer(proxy.createRoutes())
+		DeferCleanup(server.Close)
+
+		resp, err := http.Post(server.URL+CompletionsPath, "application/json", strings.NewReader(`{"model":"m","prompt":"hi"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close() //nolint:all
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(string(body)).To(Equal(CompletionsPath))
+
+		Expect(proxy.readiness.check(context.Background())).To(Succeed())
+	})
+})
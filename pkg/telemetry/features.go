@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+)
+
+// FeaturesOptions holds the configuration of the features endpoint
+type FeaturesOptions struct {
+	// Addr is the address the features endpoint listens on, e.g. :9091.
+	// The endpoint is disabled when empty.
+	Addr string
+}
+
+// NewFeaturesOptions returns the default features options, with the endpoint disabled
+func NewFeaturesOptions() *FeaturesOptions {
+	return &FeaturesOptions{}
+}
+
+// AddFlags registers the features command line flags on the given FlagSet
+func (o *FeaturesOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Addr, "features-addr", o.Addr,
+		"the address the "+common.FeaturesPath+" endpoint reporting the enabled features listens on, e.g. :9091. Disabled when empty")
+}
+
+// FeaturesHandler returns the handler of the features endpoint, served on common.FeaturesPath
+func FeaturesHandler(features common.Features) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+common.FeaturesPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(features) //nolint:all
+	})
+	return mux
+}
+
+// StartFeaturesServer serves the features endpoint on the configured address until the context
+// is done. It is a no-op when the endpoint is disabled.
+func StartFeaturesServer(ctx context.Context, logger logr.Logger, opts *FeaturesOptions, features common.Features) error {
+	if opts.Addr == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the features address: %w", err)
+	}
+
+	logger = logger.WithName("features")
+	logger.Info("features endpoint enabled", "addr", ln.Addr().String())
+
+	server := &http.Server{Handler: FeaturesHandler(features), ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close() //nolint:all
+	}()
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err, "failed to serve the features endpoint")
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/common"
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/telemetry"
+)
+
+func TestFeaturesHandler(t *testing.T) {
+	features := common.Features{
+		Component: "epp",
+		Plugins:   []string{"load-aware-scorer", "pd-profile-handler"},
+		Security:  map[string]bool{"secureServing": true},
+		Protocols: map[string]string{"extProc": "grpc"},
+		Enabled:   []string{"tracing"},
+	}
+	server := httptest.NewServer(telemetry.FeaturesHandler(features))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + common.FeaturesPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close() //nolint:all
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var got common.Features
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, features) {
+		t.Errorf("expected features %+v, got %+v", features, got)
+	}
+}
+
+func TestStartFeaturesServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := telemetry.StartFeaturesServer(ctx, logr.Discard(), telemetry.NewFeaturesOptions(), common.Features{}); err != nil {
+		t.Errorf("expected a disabled features endpoint to be a no-op, got %v", err)
+	}
+
+	if err := telemetry.StartFeaturesServer(ctx, logr.Discard(), &telemetry.FeaturesOptions{Addr: "invalid-address"}, common.Features{}); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// PprofOptions holds the configuration of the pprof endpoints
+type PprofOptions struct {
+	// Addr is the address the pprof endpoints listen on, e.g. localhost:6060.
+	// The endpoints are disabled when empty.
+	Addr string
+
+	// MutexProfileFraction is the rate of the mutex contention events reported in the
+	// mutex profile, 1/n on average. A zero value disables the mutex profile.
+	MutexProfileFraction int
+
+	// BlockProfileRate is the rate of the blocking events reported in the block profile,
+	// one per n nanoseconds spent blocked on average. A zero value disables the block profile.
+	BlockProfileRate int
+}
+
+// NewPprofOptions returns the default pprof options, with the endpoints disabled
+func NewPprofOptions() *PprofOptions {
+	return &PprofOptions{}
+}
+
+// AddFlags registers the pprof command line flags on the given FlagSet
+func (o *PprofOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Addr, "pprof-addr", o.Addr,
+		"the address the pprof endpoints listen on, e.g. localhost:6060. Disabled when empty")
+	fs.IntVar(&o.MutexProfileFraction, "pprof-mutex-profile-fraction", o.MutexProfileFraction,
+		"reports 1/n of the mutex contention events in the mutex profile, 0 disables it")
+	fs.IntVar(&o.BlockProfileRate, "pprof-block-profile-rate", o.BlockProfileRate,
+		"reports one blocking event per n nanoseconds spent blocked in the block profile, 0 disables it")
+}
+
+// Validate checks the pprof options are consistent
+func (o *PprofOptions) Validate() error {
+	if o.MutexProfileFraction < 0 {
+		return fmt.Errorf("invalid pprof mutex profile fraction: must be >= 0, got %d", o.MutexProfileFraction)
+	}
+	if o.BlockProfileRate < 0 {
+		return fmt.Errorf("invalid pprof block profile rate: must be >= 0, got %d", o.BlockProfileRate)
+	}
+	return nil
+}
+
+// PprofHandler returns the handler of the net/http/pprof endpoints, served under /debug/pprof/
+func PprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// StartPprofServer serves the pprof endpoints on the configured address until the context
+// is done. It is a no-op when the endpoints are disabled.
+func StartPprofServer(ctx context.Context, logger logr.Logger, opts *PprofOptions) error {
+	if opts.Addr == "" {
+		return nil
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on the pprof address: %w", err)
+	}
+
+	// the rates are left unchanged when disabled, as set by the continuous profiling
+	if opts.MutexProfileFraction > 0 {
+		runtime.SetMutexProfileFraction(opts.MutexProfileFraction)
+	}
+	if opts.BlockProfileRate > 0 {
+		runtime.SetBlockProfileRate(opts.BlockProfileRate)
+	}
+
+	logger = logger.WithName("pprof")
+	logger.Info("pprof endpoints enabled", "addr", ln.Addr().String())
+
+	// the CPU profiles and the execution traces are recorded for up to the requested duration
+	server := &http.Server{Handler: PprofHandler(), ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close() //nolint:all
+	}()
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err, "failed to serve the pprof endpoints")
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/telemetry"
+)
+
+func TestPprofHandler(t *testing.T) {
+	server := httptest.NewServer(telemetry.PprofHandler())
+	defer server.Close()
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close() //nolint:all
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestStartPprofServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := telemetry.StartPprofServer(ctx, logr.Discard(), telemetry.NewPprofOptions()); err != nil {
+		t.Errorf("expected disabled pprof endpoints to be a no-op, got %v", err)
+	}
+
+	err := telemetry.StartPprofServer(ctx, logr.Discard(), &telemetry.PprofOptions{Addr: "localhost:6060", BlockProfileRate: -1})
+	if err == nil || !strings.Contains(err.Error(), "block profile rate") {
+		t.Errorf("expected an invalid block profile rate error, got %v", err)
+	}
+
+	if err := telemetry.StartPprofServer(ctx, logr.Discard(), &telemetry.PprofOptions{Addr: "invalid-address"}); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
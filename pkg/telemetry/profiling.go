@@ -0,0 +1,261 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	defaultProfilingInterval    = 5 * time.Minute
+	defaultProfilingCPUDuration = 30 * time.Second
+	defaultProfilingMaxFiles    = 24
+	profileFileSuffix           = ".pprof"
+
+	// profileTimestampFormat is sortable, and fine enough for the captures of sub-second intervals
+	// to be written to distinct files
+	profileTimestampFormat = "20060102T150405.000000000Z"
+)
+
+// ProfilingOptions holds the continuous profiling configuration
+type ProfilingOptions struct {
+	// Enabled turns on the periodic profile capture
+	Enabled bool
+
+	// Dir is the directory the profiles are written to
+	Dir string
+
+	// Interval is the time between two consecutive captures
+	Interval time.Duration
+
+	// CPUDuration is how long the CPU profile is recorded on each capture.
+	// A zero value disables CPU profiling.
+	CPUDuration time.Duration
+
+	// MaxFiles is the number of profile files kept per profile type. Older files are removed.
+	MaxFiles int
+
+	// MutexProfileFraction is the rate of the mutex contention events reported in the mutex
+	// profile, 1/n on average. A zero value disables the mutex profile.
+	MutexProfileFraction int
+
+	// BlockProfileRate is the rate of the blocking events reported in the block profile, one per
+	// n nanoseconds spent blocked on average. A zero value disables the block profile.
+	BlockProfileRate int
+}
+
+// NewProfilingOptions returns the default continuous profiling options
+func NewProfilingOptions() *ProfilingOptions {
+	return &ProfilingOptions{
+		Dir:         filepath.Join(os.TempDir(), "llm-d-profiles"),
+		Interval:    defaultProfilingInterval,
+		CPUDuration: defaultProfilingCPUDuration,
+		MaxFiles:    defaultProfilingMaxFiles,
+	}
+}
+
+// AddFlags registers the profiling command line flags on the given FlagSet
+func (o *ProfilingOptions) AddFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "continuous-profiling", o.Enabled,
+		"enables the periodic capture of pprof profiles")
+	fs.StringVar(&o.Dir, "continuous-profiling-dir", o.Dir,
+		"the directory continuous profiles are written to")
+	fs.DurationVar(&o.Interval, "continuous-profiling-interval", o.Interval,
+		"the interval between two profile captures")
+	fs.DurationVar(&o.CPUDuration, "continuous-profiling-cpu-duration", o.CPUDuration,
+		"how long the CPU profile is recorded on each capture, 0 disables CPU profiling")
+	fs.IntVar(&o.MaxFiles, "continuous-profiling-max-files", o.MaxFiles,
+		"the number of files kept per profile type")
+	fs.IntVar(&o.MutexProfileFraction, "continuous-profiling-mutex-profile-fraction", o.MutexProfileFraction,
+		"reports 1/n of the mutex contention events in the captured mutex profiles, 0 disables them")
+	fs.IntVar(&o.BlockProfileRate, "continuous-profiling-block-profile-rate", o.BlockProfileRate,
+		"reports one blocking event per n nanoseconds spent blocked in the captured block profiles, 0 disables them")
+}
+
+// Validate checks the profiling options are consistent
+func (o *ProfilingOptions) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.Dir == "" {
+		return errors.New("invalid continuous profiling directory: must not be empty")
+	}
+	if o.Interval <= 0 {
+		return fmt.Errorf("invalid continuous profiling interval: must be > 0, got %s", o.Interval)
+	}
+	if o.CPUDuration < 0 || o.CPUDuration >= o.Interval {
+		return fmt.Errorf("invalid continuous profiling CPU duration: must be >= 0 and shorter than the interval, got %s", o.CPUDuration)
+	}
+	if o.MaxFiles <= 0 {
+		return fmt.Errorf("invalid continuous profiling max files: must be > 0, got %d", o.MaxFiles)
+	}
+	if o.MutexProfileFraction < 0 {
+		return fmt.Errorf("invalid continuous profiling mutex profile fraction: must be >= 0, got %d", o.MutexProfileFraction)
+	}
+	if o.BlockProfileRate < 0 {
+		return fmt.Errorf("invalid continuous profiling block profile rate: must be >= 0, got %d", o.BlockProfileRate)
+	}
+	return nil
+}
+
+// profileTypes returns the runtime profiles captured on each interval, in addition to the CPU
+// profile. The mutex and block profiles are captured only when their events are reported.
+func (o *ProfilingOptions) profileTypes() []string {
+	profileTypes := []string{"heap", "goroutine"}
+	if o.MutexProfileFraction > 0 {
+		profileTypes = append(profileTypes, "mutex")
+	}
+	if o.BlockProfileRate > 0 {
+		profileTypes = append(profileTypes, "block")
+	}
+	return profileTypes
+}
+
+// StartContinuousProfiling periodically writes pprof profiles to the configured
+// directory until the context is done. It is a no-op when profiling is disabled.
+func StartContinuousProfiling(ctx context.Context, logger logr.Logger, opts *ProfilingOptions) error {
+	if !opts.Enabled {
+		return nil
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create continuous profiling directory: %w", err)
+	}
+	if opts.MutexProfileFraction > 0 {
+		runtime.SetMutexProfileFraction(opts.MutexProfileFraction)
+	}
+	if opts.BlockProfileRate > 0 {
+		runtime.SetBlockProfileRate(opts.BlockProfileRate)
+	}
+
+	logger = logger.WithName("profiling")
+	logger.Info("continuous profiling enabled", "dir", opts.Dir, "interval", opts.Interval,
+		"cpuDuration", opts.CPUDuration)
+
+	go func() {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				captureProfiles(ctx, logger, opts)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// captureProfiles writes one file per profile type and prunes old files
+func captureProfiles(ctx context.Context, logger logr.Logger, opts *ProfilingOptions) {
+	timestamp := time.Now().UTC().Format(profileTimestampFormat)
+
+	if opts.CPUDuration > 0 {
+		if err := captureCPUProfile(ctx, opts, timestamp); err != nil {
+			logger.Error(err, "failed to capture CPU profile")
+		}
+	}
+
+	profileTypes := opts.profileTypes()
+	for _, profileType := range profileTypes {
+		if err := captureProfile(opts, profileType, timestamp); err != nil {
+			logger.Error(err, "failed to capture profile", "type", profileType)
+		}
+	}
+
+	for _, profileType := range append([]string{"cpu"}, profileTypes...) {
+		if err := pruneProfiles(opts, profileType); err != nil {
+			logger.Error(err, "failed to remove old profiles", "type", profileType)
+		}
+	}
+	logger.V(4).Info("profiles captured", "timestamp", timestamp)
+}
+
+func captureCPUProfile(ctx context.Context, opts *ProfilingOptions, timestamp string) error {
+	f, err := os.Create(profilePath(opts, "cpu", timestamp))
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:all
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(opts.CPUDuration):
+	}
+	pprof.StopCPUProfile()
+	return nil
+}
+
+func captureProfile(opts *ProfilingOptions, profileType string, timestamp string) error {
+	profile := pprof.Lookup(profileType)
+	if profile == nil {
+		return fmt.Errorf("unknown profile type %s", profileType)
+	}
+
+	f, err := os.Create(profilePath(opts, profileType, timestamp))
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:all
+
+	return profile.WriteTo(f, 0)
+}
+
+// pruneProfiles keeps only the most recent MaxFiles files of the given profile type
+func pruneProfiles(opts *ProfilingOptions, profileType string) error {
+	files, err := filepath.Glob(filepath.Join(opts.Dir, profileType+"-*"+profileFileSuffix))
+	if err != nil {
+		return err
+	}
+	if len(files) <= opts.MaxFiles {
+		return nil
+	}
+
+	// file names embed a sortable timestamp, oldest first
+	sort.Strings(files)
+	var errs []error
+	for _, file := range files[:len(files)-opts.MaxFiles] {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func profilePath(opts *ProfilingOptions, profileType string, timestamp string) string {
+	return filepath.Join(opts.Dir, strings.Join([]string{profileType, timestamp}, "-")+profileFileSuffix)
+}
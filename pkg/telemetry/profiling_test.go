@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry_test
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/telemetry"
+)
+
+func TestProfilingOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*telemetry.ProfilingOptions)
+		wantErr bool
+	}{
+		{
+			name:   "disabled options are not validated",
+			modify: func(o *telemetry.ProfilingOptions) { o.Enabled = false; o.Interval = 0 },
+		},
+		{
+			name:   "defaults",
+			modify: func(*telemetry.ProfilingOptions) {},
+		},
+		{
+			name:    "zero interval",
+			modify:  func(o *telemetry.ProfilingOptions) { o.Interval = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "cpu duration longer than interval",
+			modify:  func(o *telemetry.ProfilingOptions) { o.CPUDuration = 2 * o.Interval },
+			wantErr: true,
+		},
+		{
+			name:    "no files kept",
+			modify:  func(o *telemetry.ProfilingOptions) { o.MaxFiles = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "negative mutex profile fraction",
+			modify:  func(o *telemetry.ProfilingOptions) { o.MutexProfileFraction = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative block profile rate",
+			modify:  func(o *telemetry.ProfilingOptions) { o.BlockProfileRate = -1 },
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := telemetry.NewProfilingOptions()
+			opts.Enabled = true
+			test.modify(opts)
+
+			err := opts.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartContinuousProfiling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := &telemetry.ProfilingOptions{
+		Enabled:              true,
+		Dir:                  t.TempDir(),
+		Interval:             50 * time.Millisecond,
+		CPUDuration:          10 * time.Millisecond,
+		MaxFiles:             2,
+		MutexProfileFraction: 1,
+		BlockProfileRate:     1,
+	}
+	t.Cleanup(func() {
+		runtime.SetMutexProfileFraction(0)
+		runtime.SetBlockProfileRate(0)
+	})
+	if err := telemetry.StartContinuousProfiling(ctx, logr.Discard(), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+
+	// the captures of the sub-second interval are written to distinct files
+	for _, profileType := range []string{"cpu", "heap", "goroutine", "mutex", "block"} {
+		files, err := filepath.Glob(filepath.Join(opts.Dir, profileType+"-*.pprof"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != opts.MaxFiles {
+			t.Errorf("expected %d %s profiles, got %d", opts.MaxFiles, profileType, len(files))
+		}
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	// TracingExporterConsole writes spans to stdout, for development use
+	TracingExporterConsole = "console"
+
+	// TracingExporterOTLP sends spans to an OpenTelemetry collector
+	TracingExporterOTLP = "otlp"
+
+	// TracingProtocolGRPC selects the OTLP/gRPC exporter
+	TracingProtocolGRPC = "grpc"
+
+	// TracingProtocolHTTP selects the OTLP/HTTP (protobuf) exporter
+	TracingProtocolHTTP = "http/protobuf"
+
+	// InferencePoolNameAttribute is the resource attribute holding the InferencePool name
+	InferencePoolNameAttribute = attribute.Key("llm-d.inference_pool.name")
+
+	defaultTracingServiceName   = "llm-d-inference-scheduler"
+	defaultTracingExporter      = TracingExporterConsole
+	defaultTracingProtocol      = TracingProtocolGRPC
+	defaultTracingSamplingRatio = 0.1
+)
+
+// TracingOptions holds the OpenTelemetry tracing configuration
+type TracingOptions struct {
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+
+	// ServiceVersion is reported as the service.version resource attribute
+	ServiceVersion string
+
+	// Exporter is either console or otlp
+	Exporter string
+
+	// Endpoint is the OTLP collector endpoint URL. When empty the exporter
+	// falls back to the standard OTEL_EXPORTER_OTLP_* environment variables.
+	Endpoint string
+
+	// Protocol is the OTLP protocol, either grpc or http/protobuf
+	Protocol string
+
+	// Insecure disables TLS when talking to the OTLP collector
+	Insecure bool
+
+	// SamplingRatio is the ratio of root spans to sample. Child spans follow
+	// the sampling decision of their parent.
+	SamplingRatio float64
+
+	// ClusterName is reported as the k8s.cluster.name resource attribute
+	ClusterName string
+
+	// PoolName is reported as the llm-d.inference_pool.name resource attribute
+	PoolName string
+
+	// PoolNamespace is reported as the k8s.namespace.name resource attribute
+	PoolNamespace string
+}
+
+// NewTracingOptions returns tracing options initialized from the standard
+// OpenTelemetry environment variables, falling back to the defaults.
+func NewTracingOptions() *TracingOptions {
+	opts := &TracingOptions{
+		ServiceName:   getEnvString("OTEL_SERVICE_NAME", defaultTracingServiceName),
+		Exporter:      getEnvString("OTEL_TRACES_EXPORTER", defaultTracingExporter),
+		Endpoint:      os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Protocol:      getEnvString("OTEL_EXPORTER_OTLP_PROTOCOL", defaultTracingProtocol),
+		SamplingRatio: defaultTracingSamplingRatio,
+		ClusterName:   os.Getenv("CLUSTER_NAME"),
+	}
+	if insecure, err := strconv.ParseBool(os.Getenv("OTEL_EXPORTER_OTLP_INSECURE")); err == nil {
+		opts.Insecure = insecure
+	}
+	if ratio, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64); err == nil {
+		opts.SamplingRatio = ratio
+	}
+	return opts
+}
+
+// AddFlags registers the tracing command line flags on the given FlagSet
+func (o *TracingOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Exporter, "tracing-exporter", o.Exporter,
+		"the trace exporter, either console or otlp (defaults to OTEL_TRACES_EXPORTER env var)")
+	fs.StringVar(&o.Endpoint, "tracing-endpoint", o.Endpoint,
+		"the OTLP collector endpoint URL (defaults to OTEL_EXPORTER_OTLP_ENDPOINT env var)")
+	fs.StringVar(&o.Protocol, "tracing-protocol", o.Protocol,
+		"the OTLP protocol, either grpc or http/protobuf (defaults to OTEL_EXPORTER_OTLP_PROTOCOL env var)")
+	fs.BoolVar(&o.Insecure, "tracing-insecure", o.Insecure,
+		"disables TLS when exporting traces to the OTLP collector (defaults to OTEL_EXPORTER_OTLP_INSECURE env var)")
+	fs.Float64Var(&o.SamplingRatio, "tracing-sampling-ratio", o.SamplingRatio,
+		"the parent based ratio of traces to sample, between 0 and 1 (defaults to OTEL_TRACES_SAMPLER_ARG env var)")
+	fs.StringVar(&o.ClusterName, "tracing-cluster-name", o.ClusterName,
+		"the cluster name reported in the trace resource attributes (defaults to CLUSTER_NAME env var)")
+}
+
+// Validate checks the tracing options are consistent
+func (o *TracingOptions) Validate() error {
+	if o.Exporter != TracingExporterConsole && o.Exporter != TracingExporterOTLP {
+		return fmt.Errorf("invalid tracing exporter '%s': must be either '%s' or '%s'",
+			o.Exporter, TracingExporterConsole, TracingExporterOTLP)
+	}
+	if o.Protocol != TracingProtocolGRPC && o.Protocol != TracingProtocolHTTP {
+		return fmt.Errorf("invalid tracing protocol '%s': must be either '%s' or '%s'",
+			o.Protocol, TracingProtocolGRPC, TracingProtocolHTTP)
+	}
+	if o.SamplingRatio < 0 || o.SamplingRatio > 1 {
+		return fmt.Errorf("invalid tracing sampling ratio: must be between 0 and 1, got %v", o.SamplingRatio)
+	}
+	return nil
+}
+
+// InitTracing installs a global tracer provider configured from the given options.
+// The provider is shut down, flushing pending spans, when the context is done.
+func InitTracing(ctx context.Context, logger logr.Logger, opts *TracingOptions) error {
+	logger = logger.WithName("trace")
+
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	exporter, err := newTraceExporter(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(resourceAttributes(opts)...),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SamplingRatio))),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Error(err, "trace error occurred")
+	}))
+
+	logger.Info("tracing initialized", "exporter", opts.Exporter, "protocol", opts.Protocol,
+		"endpoint", opts.Endpoint, "samplingRatio", opts.SamplingRatio)
+
+	go func() {
+		<-ctx.Done()
+		if err := tracerProvider.Shutdown(context.Background()); err != nil {
+			logger.Error(err, "failed to shutdown the tracer provider")
+		}
+	}()
+
+	return nil
+}
+
+// newTraceExporter creates the span exporter selected by the options
+func newTraceExporter(ctx context.Context, opts *TracingOptions) (sdktrace.SpanExporter, error) {
+	if opts.Exporter == TracingExporterConsole {
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdouttrace exporter: %w", err)
+		}
+		return exporter, nil
+	}
+
+	if opts.Protocol == TracingProtocolHTTP {
+		var httpOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		if opts.Insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, httpOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp-http exporter: %w", err)
+		}
+		return exporter, nil
+	}
+
+	var grpcOpts []otlptracegrpc.Option
+	if opts.Endpoint != "" {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+	}
+	if opts.Insecure {
+		grpcOpts = append(grpcOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, grpcOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp-grpc exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// resourceAttributes returns the resource attributes describing this process
+func resourceAttributes(opts *TracingOptions) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(opts.ServiceName),
+	}
+	if opts.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(opts.ServiceVersion))
+	}
+	if opts.ClusterName != "" {
+		attrs = append(attrs, semconv.K8SClusterName(opts.ClusterName))
+	}
+	if opts.PoolNamespace != "" {
+		attrs = append(attrs, semconv.K8SNamespaceName(opts.PoolNamespace))
+	}
+	if opts.PoolName != "" {
+		attrs = append(attrs, InferencePoolNameAttribute.String(opts.PoolName))
+	}
+	return attrs
+}
+
+func getEnvString(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
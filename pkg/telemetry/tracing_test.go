@@ -0,0 +1,150 @@
+/*
+Copyright 2025 The llm-d Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/llm-d/llm-d-inference-scheduler/pkg/telemetry"
+)
+
+func TestNewTracingOptions(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.5")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	t.Setenv("CLUSTER_NAME", "cluster-a")
+
+	got := telemetry.NewTracingOptions()
+	want := &telemetry.TracingOptions{
+		ServiceName:   "llm-d-inference-scheduler",
+		Exporter:      telemetry.TracingExporterOTLP,
+		Endpoint:      "http://collector:4317",
+		Protocol:      telemetry.TracingProtocolGRPC,
+		Insecure:      true,
+		SamplingRatio: 0.5,
+		ClusterName:   "cluster-a",
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected output (-want +got): %v", diff)
+	}
+}
+
+func TestTracingOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*telemetry.TracingOptions)
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			modify: func(*telemetry.TracingOptions) {},
+		},
+		{
+			name: "otlp over http",
+			modify: func(o *telemetry.TracingOptions) {
+				o.Exporter = telemetry.TracingExporterOTLP
+				o.Protocol = telemetry.TracingProtocolHTTP
+			},
+		},
+		{
+			name:    "unknown exporter",
+			modify:  func(o *telemetry.TracingOptions) { o.Exporter = "jaeger" },
+			wantErr: true,
+		},
+		{
+			name:    "unknown protocol",
+			modify:  func(o *telemetry.TracingOptions) { o.Protocol = "http/json" },
+			wantErr: true,
+		},
+		{
+			name:    "sampling ratio above 1",
+			modify:  func(o *telemetry.TracingOptions) { o.SamplingRatio = 1.5 },
+			wantErr: true,
+		},
+		{
+			name:    "negative sampling ratio",
+			modify:  func(o *telemetry.TracingOptions) { o.SamplingRatio = -0.1 },
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := &telemetry.TracingOptions{
+				Exporter:      telemetry.TracingExporterConsole,
+				Protocol:      telemetry.TracingProtocolGRPC,
+				SamplingRatio: 0.1,
+			}
+			test.modify(opts)
+
+			err := opts.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseKnownFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	opts := &telemetry.TracingOptions{SamplingRatio: 0.1}
+	opts.AddFlags(fs)
+	poolName := fs.String("pool-name", "", "")
+	enabled := fs.Bool("tracing", false, "")
+	other := fs.String("other", "unchanged", "")
+
+	args := []string{
+		"--other", "value",
+		"--tracing",
+		"-tracing-exporter=otlp",
+		"--tracing-sampling-ratio", "0.25",
+		"--unknown-flag",
+		"--pool-name", "my-pool",
+	}
+	err := telemetry.ParseKnownFlags(fs, args, "tracing", "tracing-exporter", "tracing-sampling-ratio", "pool-name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !*enabled {
+		t.Errorf("expected tracing to be enabled")
+	}
+	if opts.Exporter != telemetry.TracingExporterOTLP {
+		t.Errorf("expected exporter %q, got %q", telemetry.TracingExporterOTLP, opts.Exporter)
+	}
+	if opts.SamplingRatio != 0.25 {
+		t.Errorf("expected sampling ratio 0.25, got %v", opts.SamplingRatio)
+	}
+	if *poolName != "my-pool" {
+		t.Errorf("expected pool name %q, got %q", "my-pool", *poolName)
+	}
+	if *other != "unchanged" {
+		t.Errorf("expected flag not in the known list to be ignored, got %q", *other)
+	}
+
+	err = telemetry.ParseKnownFlags(fs, []string{"--tracing-sampling-ratio", "abc"}, "tracing-sampling-ratio")
+	if err == nil {
+		t.Errorf("expected an error for an invalid flag value")
+	}
+}
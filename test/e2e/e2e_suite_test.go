@@ -30,8 +30,6 @@ const (
 	defaultInterval = time.Millisecond * 250
 	// xInferPoolManifest is the manifest for the inference pool CRD with 'inference.networking.x-k8s.io' group.
 	gieCrdsKustomize = "../../deploy/components/crds-gie"
-	// inferExtManifest is the manifest for the inference extension test resources.
-	inferExtManifest = "./yaml/inference-pools.yaml"
 	// modelName is the test model name.
 	modelName = "food-review"
 	// kvModelName is the model name used in KV tests.
@@ -55,6 +53,12 @@ const (
 var (
 	port string
 
+	// poolGroup is the API group of the current InferencePool
+	poolGroup string
+
+	// poolGroups are the API groups of the InferencePools the EPP and the sidecar flows are run against
+	poolGroups = []string{infextv1.GroupName, infextv1a2.GroupName}
+
 	testConfig *testutils.TestConfig
 
 	containerRuntime  = env.GetEnvString("CONTAINER_RUNTIME", "docker", ginkgo.GinkgoLogr)
@@ -86,7 +90,7 @@ var _ = ginkgo.BeforeSuite(func() {
 	testutils.ApplyYAMLFile(testConfig, servicesManifest)
 
 	// Prevent failure in tests due to InferencePool not existing before the test
-	createInferencePool(1, false)
+	createInferencePool(infextv1.GroupName, 1, false)
 })
 
 var _ = ginkgo.AfterSuite(func() {
@@ -179,26 +183,18 @@ func createEnvoy() {
 	testutils.CreateObjsFromYaml(testConfig, manifests)
 }
 
-func createInferencePool(numTargetPorts int, toDelete bool) {
-	poolName := modelName + "-inference-pool"
-
+// createInferencePool creates the InferencePool of the given API group, v1 or v1alpha2, replacing the
+// current one when toDelete is set. The EPP and the sidecars created next watch the pool in this group.
+func createInferencePool(group string, numTargetPorts int, toDelete bool) {
 	if toDelete {
-		objName := []string{"inferencepool/" + poolName}
-		testutils.DeleteObjects(testConfig, objName)
+		deleteInferencePool(poolGroup)
 	}
 
-	infPoolYaml := testutils.ReadYaml(inferExtManifest)
-	targetPorts := ""
-	for idx := range numTargetPorts {
-		targetPorts += fmt.Sprintf("\n  - number: %d", 8000+idx)
-	}
-	infPoolYaml = substituteMany(infPoolYaml,
-		map[string]string{
-			"${POOL_NAME}":    poolName,
-			"${TARGET_PORTS}": targetPorts,
-		})
-
-	testutils.CreateObjsFromYaml(testConfig, infPoolYaml)
+	poolGroup = group
+	pool := newInferencePool(poolGroup, numTargetPorts)
+	ginkgo.By(fmt.Sprintf("Creating the InferencePool %s in the API group %s", pool.GetName(), poolGroup))
+	err := testConfig.K8sClient.Create(testConfig.Context, pool)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
 }
 
 const kindClusterConfig = `
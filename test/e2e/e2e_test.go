@@ -15,6 +15,7 @@ import (
 	healthPb "google.golang.org/grpc/health/grpc_health_v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	infextv1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
 	testutils "sigs.k8s.io/gateway-api-inference-extension/test/utils"
 )
 
@@ -41,83 +42,86 @@ var (
 )
 
 var _ = ginkgo.Describe("Run end to end tests", ginkgo.Ordered, func() {
-	ginkgo.When("Running simple non-PD configuration", func() {
-		ginkgo.It("should run successfully", func() {
-			createInferencePool(1, true)
+	// The EPP and the sidecar SSRF protection run against the InferencePools of both API groups
+	for _, group := range poolGroups {
+		ginkgo.When("Running simple non-PD configuration with a "+group+" InferencePool", func() {
+			ginkgo.It("should run successfully", func() {
+				createInferencePool(group, 1, true)
 
-			modelServers := createModelServers(false, false, false, 1, 0, 0)
+				modelServers := createModelServers(false, false, false, 1, 0, 0)
 
-			epp := createEndPointPicker(simpleConfig)
+				epp := createEndPointPicker(simpleConfig)
 
-			prefillPods, decodePods := getModelServerPods(podSelector, prefillSelector, decodeSelector)
-			gomega.Expect(prefillPods).Should(gomega.BeEmpty())
-			gomega.Expect(decodePods).Should(gomega.HaveLen(1))
+				prefillPods, decodePods := getModelServerPods(podSelector, prefillSelector, decodeSelector)
+				gomega.Expect(prefillPods).Should(gomega.BeEmpty())
+				gomega.Expect(decodePods).Should(gomega.HaveLen(1))
 
-			nsHdr, podHdr, _ := runCompletion(simplePrompt, modelName)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdr).Should(gomega.Equal(decodePods[0]))
+				nsHdr, podHdr, _ := runCompletion(simplePrompt, modelName)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdr).Should(gomega.Equal(decodePods[0]))
 
-			nsHdr, podHdr, _ = runChatCompletion(simplePrompt)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdr).Should(gomega.Equal(decodePods[0]))
+				nsHdr, podHdr, _ = runChatCompletion(simplePrompt)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdr).Should(gomega.Equal(decodePods[0]))
 
-			testutils.DeleteObjects(testConfig, epp)
-			testutils.DeleteObjects(testConfig, modelServers)
+				testutils.DeleteObjects(testConfig, epp)
+				testutils.DeleteObjects(testConfig, modelServers)
+			})
 		})
-	})
 
-	ginkgo.When("Running a PD configuration", func() {
-		ginkgo.It("should run successfully", func() {
-			createInferencePool(1, true)
+		ginkgo.When("Running a PD configuration with a "+group+" InferencePool", func() {
+			ginkgo.It("should run successfully", func() {
+				createInferencePool(group, 1, true)
 
-			prefillReplicas := 1
-			decodeReplicas := 4
-			modelServers := createModelServers(true, false, false, 0, prefillReplicas, decodeReplicas)
+				prefillReplicas := 1
+				decodeReplicas := 4
+				modelServers := createModelServers(true, false, false, 0, prefillReplicas, decodeReplicas)
 
-			epp := createEndPointPicker(pdConfig)
+				epp := createEndPointPicker(pdConfig)
 
-			prefillPods, decodePods := getModelServerPods(podSelector, prefillSelector, decodeSelector)
-			gomega.Expect(prefillPods).Should(gomega.HaveLen(prefillReplicas))
-			gomega.Expect(decodePods).Should(gomega.HaveLen(decodeReplicas))
+				prefillPods, decodePods := getModelServerPods(podSelector, prefillSelector, decodeSelector)
+				gomega.Expect(prefillPods).Should(gomega.HaveLen(prefillReplicas))
+				gomega.Expect(decodePods).Should(gomega.HaveLen(decodeReplicas))
 
-			nsHdr, podHdrCompletion, _ := runCompletion(simplePrompt, modelName)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdrCompletion).Should(gomega.BeElementOf(decodePods))
+				nsHdr, podHdrCompletion, _ := runCompletion(simplePrompt, modelName)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdrCompletion).Should(gomega.BeElementOf(decodePods))
 
-			nsHdr, podHdrChat, _ := runChatCompletion(simplePrompt)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdrChat).Should(gomega.BeElementOf(decodePods))
+				nsHdr, podHdrChat, _ := runChatCompletion(simplePrompt)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdrChat).Should(gomega.BeElementOf(decodePods))
 
-			// Do an extra completion call with a different prompt
-			nsHdr, podHdr, _ := runCompletion(extraPrompt, modelName)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
+				// Do an extra completion call with a different prompt
+				nsHdr, podHdr, _ := runCompletion(extraPrompt, modelName)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
 
-			// Run completion with the original prompt
-			nsHdr, podHdr, _ = runCompletion(simplePrompt, modelName)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
-			gomega.Expect(podHdr).Should(gomega.Equal(podHdrCompletion))
+				// Run completion with the original prompt
+				nsHdr, podHdr, _ = runCompletion(simplePrompt, modelName)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
+				gomega.Expect(podHdr).Should(gomega.Equal(podHdrCompletion))
 
-			// Do an extra chat completion call with a different prompt
-			nsHdr, podHdr, _ = runChatCompletion(extraPrompt)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
+				// Do an extra chat completion call with a different prompt
+				nsHdr, podHdr, _ = runChatCompletion(extraPrompt)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
 
-			// Run chat completion with the original prompt
-			nsHdr, podHdr, _ = runChatCompletion(simplePrompt)
-			gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
-			gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
-			gomega.Expect(podHdr).Should(gomega.Equal(podHdrChat))
+				// Run chat completion with the original prompt
+				nsHdr, podHdr, _ = runChatCompletion(simplePrompt)
+				gomega.Expect(nsHdr).Should(gomega.Equal(nsName))
+				gomega.Expect(podHdr).Should(gomega.BeElementOf(decodePods))
+				gomega.Expect(podHdr).Should(gomega.Equal(podHdrChat))
 
-			testutils.DeleteObjects(testConfig, epp)
-			testutils.DeleteObjects(testConfig, modelServers)
+				testutils.DeleteObjects(testConfig, epp)
+				testutils.DeleteObjects(testConfig, modelServers)
+			})
 		})
-	})
+	}
 
 	ginkgo.When("Running simple non-PD KV enabled configuration", func() {
 		ginkgo.It("should run successfully", func() {
-			createInferencePool(1, true)
+			createInferencePool(infextv1.GroupName, 1, true)
 
 			epp := createEndPointPicker(kvConfig)
 
@@ -141,7 +145,7 @@ var _ = ginkgo.Describe("Run end to end tests", ginkgo.Ordered, func() {
 
 	ginkgo.When("Scaling up and down the model servers", func() {
 		ginkgo.It("should distribute inference requests across all model servers", func() {
-			createInferencePool(1, true)
+			createInferencePool(infextv1.GroupName, 1, true)
 
 			modelServers := createModelServers(false, false, false, 1, 0, 0)
 
@@ -197,7 +201,7 @@ var _ = ginkgo.Describe("Run end to end tests", ginkgo.Ordered, func() {
 
 	ginkgo.When("Running a vLLM Data Parallel configuration", func() {
 		ginkgo.It("should schedule inference on all ranks", func() {
-			createInferencePool(2, true)
+			createInferencePool(infextv1.GroupName, 2, true)
 
 			modelServers := createModelServers(false, false, true, 1, 0, 0)
 
@@ -266,6 +270,7 @@ func createModelServers(withPD, withKV, withDP bool, vllmReplicas, prefillReplic
 			"${MODEL_NAME}":           theModelName,
 			"${MODEL_NAME_SAFE}":      theSafeModelName,
 			"${POOL_NAME}":            poolName,
+			"${POOL_GROUP}":           poolGroup,
 			"${KV_CACHE_ENABLED}":     strconv.FormatBool(withKV),
 			"${SIDECAR_TAG}":          routingSideCarTag,
 			"${VLLM_REPLICA_COUNT}":   strconv.Itoa(vllmReplicas),
@@ -300,8 +305,9 @@ func createEndPointPicker(eppConfig string) []string {
 	eppYamls := testutils.ReadYaml(eppManifest)
 	eppYamls = substituteMany(eppYamls,
 		map[string]string{
-			"${EPP_TAG}":   eppTag,
-			"${POOL_NAME}": modelName + "-inference-pool",
+			"${EPP_TAG}":    eppTag,
+			"${POOL_NAME}":  poolName,
+			"${POOL_GROUP}": poolGroup,
 		})
 
 	objects = append(objects, testutils.CreateObjsFromYaml(testConfig, eppYamls)...)
@@ -11,12 +11,17 @@ import (
 	"github.com/onsi/gomega/gexec"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apilabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	infextv1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
+	infextv1a2 "sigs.k8s.io/gateway-api-inference-extension/apix/v1alpha2"
 )
 
 const (
@@ -137,3 +142,62 @@ func substituteMany(inputs []string, substitutions map[string]string) []string {
 	}
 	return outputs
 }
+
+// newInferencePool returns the InferencePool selecting the model server pods in the given API group, v1 or
+// v1alpha2, with the given number of target ports from 8000. The v1alpha2 InferencePools have a single one.
+func newInferencePool(group string, numTargetPorts int) client.Object {
+	meta := v1.ObjectMeta{Name: poolName, Namespace: nsName}
+	if group == infextv1a2.GroupName {
+		gomega.Expect(numTargetPorts).To(gomega.Equal(1), "the v1alpha2 InferencePools have a single target port")
+		return &infextv1a2.InferencePool{
+			ObjectMeta: meta,
+			Spec: infextv1a2.InferencePoolSpec{
+				Selector:         map[infextv1a2.LabelKey]infextv1a2.LabelValue{"app": infextv1a2.LabelValue(poolName)},
+				TargetPortNumber: 8000,
+				ExtensionRef: infextv1a2.Extension{
+					Name:        "e2e-epp",
+					PortNumber:  ptr.To(infextv1a2.PortNumber(9002)),
+					FailureMode: ptr.To(infextv1a2.FailClose),
+				},
+			},
+		}
+	}
+
+	targetPorts := []infextv1.Port{}
+	for idx := range numTargetPorts {
+		targetPorts = append(targetPorts, infextv1.Port{Number: infextv1.PortNumber(8000 + idx)})
+	}
+	return &infextv1.InferencePool{
+		ObjectMeta: meta,
+		Spec: infextv1.InferencePoolSpec{
+			Selector: infextv1.LabelSelector{
+				MatchLabels: map[infextv1.LabelKey]infextv1.LabelValue{"app": infextv1.LabelValue(poolName)},
+			},
+			TargetPorts: targetPorts,
+			EndpointPickerRef: infextv1.EndpointPickerRef{
+				Name: "e2e-epp",
+				Kind: "Service",
+				Port: &infextv1.Port{Number: 9002},
+			},
+		},
+	}
+}
+
+// deleteInferencePool deletes the InferencePool of the given API group and waits for it to be gone, the
+// test utilities of the inference extension only handling the v1 InferencePools
+func deleteInferencePool(group string) {
+	var pool client.Object = &infextv1.InferencePool{}
+	if group == infextv1a2.GroupName {
+		pool = &infextv1a2.InferencePool{}
+	}
+	pool.SetName(poolName)
+	pool.SetNamespace(nsName)
+
+	ginkgo.By("Deleting the InferencePool " + poolName + " in the API group " + group)
+	err := testConfig.K8sClient.Delete(testConfig.Context, pool)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Eventually(func() bool {
+		err := testConfig.K8sClient.Get(testConfig.Context, client.ObjectKeyFromObject(pool), pool)
+		return apierrors.IsNotFound(err)
+	}, readyTimeout, interval).Should(gomega.BeTrue())
+}